@@ -27,6 +27,7 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type Key struct {
 	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Speculative          bool     `protobuf:"varint,2,opt,name=speculative,proto3" json:"speculative,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -36,7 +37,7 @@ func (m *Key) Reset()         { *m = Key{} }
 func (m *Key) String() string { return proto.CompactTextString(m) }
 func (*Key) ProtoMessage()    {}
 func (*Key) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_71fcc8955c74af3a, []int{0}
+	return fileDescriptor_api_1b40cafcd4234784, []int{0}
 }
 func (m *Key) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Key.Unmarshal(m, b)
@@ -63,6 +64,13 @@ func (m *Key) GetKey() string {
 	return ""
 }
 
+func (m *Key) GetSpeculative() bool {
+	if m != nil {
+		return m.Speculative
+	}
+	return false
+}
+
 type Value struct {
 	Version              *consensus.Version `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
 	Data                 []byte             `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
@@ -75,7 +83,7 @@ func (m *Value) Reset()         { *m = Value{} }
 func (m *Value) String() string { return proto.CompactTextString(m) }
 func (*Value) ProtoMessage()    {}
 func (*Value) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_71fcc8955c74af3a, []int{1}
+	return fileDescriptor_api_1b40cafcd4234784, []int{1}
 }
 func (m *Value) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Value.Unmarshal(m, b)
@@ -109,9 +117,80 @@ func (m *Value) GetData() []byte {
 	return nil
 }
 
+type Provenance struct {
+	Version              *consensus.Version   `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Data                 []byte               `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Uuid                 string               `protobuf:"bytes,3,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Emitter              string               `protobuf:"bytes,4,opt,name=emitter,proto3" json:"emitter,omitempty"`
+	Committed            *timestamp.Timestamp `protobuf:"bytes,5,opt,name=committed,proto3" json:"committed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Provenance) Reset()         { *m = Provenance{} }
+func (m *Provenance) String() string { return proto.CompactTextString(m) }
+func (*Provenance) ProtoMessage()    {}
+func (*Provenance) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{2}
+}
+func (m *Provenance) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Provenance.Unmarshal(m, b)
+}
+func (m *Provenance) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Provenance.Marshal(b, m, deterministic)
+}
+func (dst *Provenance) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Provenance.Merge(dst, src)
+}
+func (m *Provenance) XXX_Size() int {
+	return xxx_messageInfo_Provenance.Size(m)
+}
+func (m *Provenance) XXX_DiscardUnknown() {
+	xxx_messageInfo_Provenance.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Provenance proto.InternalMessageInfo
+
+func (m *Provenance) GetVersion() *consensus.Version {
+	if m != nil {
+		return m.Version
+	}
+	return nil
+}
+
+func (m *Provenance) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *Provenance) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *Provenance) GetEmitter() string {
+	if m != nil {
+		return m.Emitter
+	}
+	return ""
+}
+
+func (m *Provenance) GetCommitted() *timestamp.Timestamp {
+	if m != nil {
+		return m.Committed
+	}
+	return nil
+}
+
 type KeyValue struct {
 	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
 	Value                []byte   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Speculative          bool     `protobuf:"varint,3,opt,name=speculative,proto3" json:"speculative,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -121,7 +200,7 @@ func (m *KeyValue) Reset()         { *m = KeyValue{} }
 func (m *KeyValue) String() string { return proto.CompactTextString(m) }
 func (*KeyValue) ProtoMessage()    {}
 func (*KeyValue) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_71fcc8955c74af3a, []int{2}
+	return fileDescriptor_api_1b40cafcd4234784, []int{3}
 }
 func (m *KeyValue) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_KeyValue.Unmarshal(m, b)
@@ -155,6 +234,59 @@ func (m *KeyValue) GetValue() []byte {
 	return nil
 }
 
+func (m *KeyValue) GetSpeculative() bool {
+	if m != nil {
+		return m.Speculative
+	}
+	return false
+}
+
+type Integer struct {
+	Value                int64              `protobuf:"zigzag64,1,opt,name=value,proto3" json:"value,omitempty"`
+	Version              *consensus.Version `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *Integer) Reset()         { *m = Integer{} }
+func (m *Integer) String() string { return proto.CompactTextString(m) }
+func (*Integer) ProtoMessage()    {}
+func (*Integer) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{4}
+}
+func (m *Integer) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Integer.Unmarshal(m, b)
+}
+func (m *Integer) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Integer.Marshal(b, m, deterministic)
+}
+func (dst *Integer) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Integer.Merge(dst, src)
+}
+func (m *Integer) XXX_Size() int {
+	return xxx_messageInfo_Integer.Size(m)
+}
+func (m *Integer) XXX_DiscardUnknown() {
+	xxx_messageInfo_Integer.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Integer proto.InternalMessageInfo
+
+func (m *Integer) GetValue() int64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+func (m *Integer) GetVersion() *consensus.Version {
+	if m != nil {
+		return m.Version
+	}
+	return nil
+}
+
 type Values struct {
 	Version              *consensus.Version `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
 	Data                 [][]byte           `protobuf:"bytes,2,rep,name=data,proto3" json:"data,omitempty"`
@@ -167,7 +299,7 @@ func (m *Values) Reset()         { *m = Values{} }
 func (m *Values) String() string { return proto.CompactTextString(m) }
 func (*Values) ProtoMessage()    {}
 func (*Values) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_71fcc8955c74af3a, []int{3}
+	return fileDescriptor_api_1b40cafcd4234784, []int{5}
 }
 func (m *Values) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Values.Unmarshal(m, b)
@@ -201,6 +333,214 @@ func (m *Values) GetData() [][]byte {
 	return nil
 }
 
+type RangeRequest struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Start                int64    `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	Stop                 int64    `protobuf:"varint,3,opt,name=stop,proto3" json:"stop,omitempty"`
+	Speculative          bool     `protobuf:"varint,4,opt,name=speculative,proto3" json:"speculative,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RangeRequest) Reset()         { *m = RangeRequest{} }
+func (m *RangeRequest) String() string { return proto.CompactTextString(m) }
+func (*RangeRequest) ProtoMessage()    {}
+func (*RangeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{6}
+}
+func (m *RangeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RangeRequest.Unmarshal(m, b)
+}
+func (m *RangeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RangeRequest.Marshal(b, m, deterministic)
+}
+func (dst *RangeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RangeRequest.Merge(dst, src)
+}
+func (m *RangeRequest) XXX_Size() int {
+	return xxx_messageInfo_RangeRequest.Size(m)
+}
+func (m *RangeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RangeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RangeRequest proto.InternalMessageInfo
+
+func (m *RangeRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *RangeRequest) GetStart() int64 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *RangeRequest) GetStop() int64 {
+	if m != nil {
+		return m.Stop
+	}
+	return 0
+}
+
+func (m *RangeRequest) GetSpeculative() bool {
+	if m != nil {
+		return m.Speculative
+	}
+	return false
+}
+
+type Length struct {
+	Length               uint64             `protobuf:"varint,1,opt,name=length,proto3" json:"length,omitempty"`
+	Version              *consensus.Version `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *Length) Reset()         { *m = Length{} }
+func (m *Length) String() string { return proto.CompactTextString(m) }
+func (*Length) ProtoMessage()    {}
+func (*Length) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{7}
+}
+func (m *Length) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Length.Unmarshal(m, b)
+}
+func (m *Length) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Length.Marshal(b, m, deterministic)
+}
+func (dst *Length) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Length.Merge(dst, src)
+}
+func (m *Length) XXX_Size() int {
+	return xxx_messageInfo_Length.Size(m)
+}
+func (m *Length) XXX_DiscardUnknown() {
+	xxx_messageInfo_Length.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Length proto.InternalMessageInfo
+
+func (m *Length) GetLength() uint64 {
+	if m != nil {
+		return m.Length
+	}
+	return 0
+}
+
+func (m *Length) GetVersion() *consensus.Version {
+	if m != nil {
+		return m.Version
+	}
+	return nil
+}
+
+type FieldRequest struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Field                string   `protobuf:"bytes,2,opt,name=field,proto3" json:"field,omitempty"`
+	Speculative          bool     `protobuf:"varint,3,opt,name=speculative,proto3" json:"speculative,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FieldRequest) Reset()         { *m = FieldRequest{} }
+func (m *FieldRequest) String() string { return proto.CompactTextString(m) }
+func (*FieldRequest) ProtoMessage()    {}
+func (*FieldRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{8}
+}
+func (m *FieldRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FieldRequest.Unmarshal(m, b)
+}
+func (m *FieldRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FieldRequest.Marshal(b, m, deterministic)
+}
+func (dst *FieldRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FieldRequest.Merge(dst, src)
+}
+func (m *FieldRequest) XXX_Size() int {
+	return xxx_messageInfo_FieldRequest.Size(m)
+}
+func (m *FieldRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FieldRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FieldRequest proto.InternalMessageInfo
+
+func (m *FieldRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *FieldRequest) GetField() string {
+	if m != nil {
+		return m.Field
+	}
+	return ""
+}
+
+func (m *FieldRequest) GetSpeculative() bool {
+	if m != nil {
+		return m.Speculative
+	}
+	return false
+}
+
+type Fields struct {
+	Version              *consensus.Version `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Fields               []*KeyValue        `protobuf:"bytes,2,rep,name=fields,proto3" json:"fields,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *Fields) Reset()         { *m = Fields{} }
+func (m *Fields) String() string { return proto.CompactTextString(m) }
+func (*Fields) ProtoMessage()    {}
+func (*Fields) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{9}
+}
+func (m *Fields) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Fields.Unmarshal(m, b)
+}
+func (m *Fields) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Fields.Marshal(b, m, deterministic)
+}
+func (dst *Fields) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Fields.Merge(dst, src)
+}
+func (m *Fields) XXX_Size() int {
+	return xxx_messageInfo_Fields.Size(m)
+}
+func (m *Fields) XXX_DiscardUnknown() {
+	xxx_messageInfo_Fields.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Fields proto.InternalMessageInfo
+
+func (m *Fields) GetVersion() *consensus.Version {
+	if m != nil {
+		return m.Version
+	}
+	return nil
+}
+
+func (m *Fields) GetFields() []*KeyValue {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
 type Boolean struct {
 	Boolean              bool     `protobuf:"varint,1,opt,name=boolean,proto3" json:"boolean,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -212,7 +552,7 @@ func (m *Boolean) Reset()         { *m = Boolean{} }
 func (m *Boolean) String() string { return proto.CompactTextString(m) }
 func (*Boolean) ProtoMessage()    {}
 func (*Boolean) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_71fcc8955c74af3a, []int{4}
+	return fileDescriptor_api_1b40cafcd4234784, []int{10}
 }
 func (m *Boolean) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Boolean.Unmarshal(m, b)
@@ -244,6 +584,7 @@ type Transaction struct {
 	Deadline             *timestamp.Timestamp          `protobuf:"bytes,2,opt,name=deadline,proto3" json:"deadline,omitempty"`
 	Requirements         map[string]*consensus.Version `protobuf:"bytes,3,rep,name=requirements,proto3" json:"requirements,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	Operations           []*consensus.Operation        `protobuf:"bytes,4,rep,name=operations,proto3" json:"operations,omitempty"`
+	Sequence             uint64                        `protobuf:"varint,5,opt,name=sequence,proto3" json:"sequence,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
 	XXX_unrecognized     []byte                        `json:"-"`
 	XXX_sizecache        int32                         `json:"-"`
@@ -253,7 +594,7 @@ func (m *Transaction) Reset()         { *m = Transaction{} }
 func (m *Transaction) String() string { return proto.CompactTextString(m) }
 func (*Transaction) ProtoMessage()    {}
 func (*Transaction) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_71fcc8955c74af3a, []int{5}
+	return fileDescriptor_api_1b40cafcd4234784, []int{11}
 }
 func (m *Transaction) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Transaction.Unmarshal(m, b)
@@ -301,8 +642,17 @@ func (m *Transaction) GetOperations() []*consensus.Operation {
 	return nil
 }
 
+func (m *Transaction) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
 type Receipt struct {
 	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Sequence             uint64   `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Error                string   `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -312,7 +662,7 @@ func (m *Receipt) Reset()         { *m = Receipt{} }
 func (m *Receipt) String() string { return proto.CompactTextString(m) }
 func (*Receipt) ProtoMessage()    {}
 func (*Receipt) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_71fcc8955c74af3a, []int{6}
+	return fileDescriptor_api_1b40cafcd4234784, []int{12}
 }
 func (m *Receipt) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Receipt.Unmarshal(m, b)
@@ -339,159 +689,2499 @@ func (m *Receipt) GetUuid() string {
 	return ""
 }
 
-func init() {
-	proto.RegisterType((*Key)(nil), "api.Key")
-	proto.RegisterType((*Value)(nil), "api.Value")
-	proto.RegisterType((*KeyValue)(nil), "api.KeyValue")
-	proto.RegisterType((*Values)(nil), "api.Values")
-	proto.RegisterType((*Boolean)(nil), "api.Boolean")
-	proto.RegisterType((*Transaction)(nil), "api.Transaction")
-	proto.RegisterMapType((map[string]*consensus.Version)(nil), "api.Transaction.RequirementsEntry")
-	proto.RegisterType((*Receipt)(nil), "api.Receipt")
+func (m *Receipt) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
-
-// EndorserClient is the client API for Endorser service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type EndorserClient interface {
-	Get(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Value, error)
-	Members(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Values, error)
-	Contains(ctx context.Context, in *KeyValue, opts ...grpc.CallOption) (*Boolean, error)
-	Submit(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*Receipt, error)
+func (m *Receipt) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
 }
 
-type endorserClient struct {
-	cc *grpc.ClientConn
+type ReplayRequest struct {
+	Since                uint64   `protobuf:"varint,1,opt,name=since,proto3" json:"since,omitempty"`
+	Follow               bool     `protobuf:"varint,2,opt,name=follow,proto3" json:"follow,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func NewEndorserClient(cc *grpc.ClientConn) EndorserClient {
-	return &endorserClient{cc}
+func (m *ReplayRequest) Reset()         { *m = ReplayRequest{} }
+func (m *ReplayRequest) String() string { return proto.CompactTextString(m) }
+func (*ReplayRequest) ProtoMessage()    {}
+func (*ReplayRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{13}
+}
+func (m *ReplayRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplayRequest.Unmarshal(m, b)
+}
+func (m *ReplayRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplayRequest.Marshal(b, m, deterministic)
+}
+func (dst *ReplayRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplayRequest.Merge(dst, src)
+}
+func (m *ReplayRequest) XXX_Size() int {
+	return xxx_messageInfo_ReplayRequest.Size(m)
+}
+func (m *ReplayRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplayRequest.DiscardUnknown(m)
 }
 
-func (c *endorserClient) Get(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Value, error) {
-	out := new(Value)
-	err := c.cc.Invoke(ctx, "/api.Endorser/Get", in, out, opts...)
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_ReplayRequest proto.InternalMessageInfo
+
+func (m *ReplayRequest) GetSince() uint64 {
+	if m != nil {
+		return m.Since
 	}
-	return out, nil
+	return 0
 }
 
-func (c *endorserClient) Members(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Values, error) {
-	out := new(Values)
-	err := c.cc.Invoke(ctx, "/api.Endorser/Members", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *ReplayRequest) GetFollow() bool {
+	if m != nil {
+		return m.Follow
 	}
-	return out, nil
+	return false
 }
 
-func (c *endorserClient) Contains(ctx context.Context, in *KeyValue, opts ...grpc.CallOption) (*Boolean, error) {
-	out := new(Boolean)
-	err := c.cc.Invoke(ctx, "/api.Endorser/Contains", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+type WatchRequest struct {
+	Prefix               string   `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (c *endorserClient) Submit(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*Receipt, error) {
+func (m *WatchRequest) Reset()         { *m = WatchRequest{} }
+func (m *WatchRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchRequest) ProtoMessage()    {}
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{14}
+}
+func (m *WatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchRequest.Unmarshal(m, b)
+}
+func (m *WatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchRequest.Marshal(b, m, deterministic)
+}
+func (dst *WatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchRequest.Merge(dst, src)
+}
+func (m *WatchRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchRequest.Size(m)
+}
+func (m *WatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchRequest proto.InternalMessageInfo
+
+func (m *WatchRequest) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+// KeyUpdate is sent every time a watched key is written by a committed transaction.
+type KeyUpdate struct {
+	Key                  string             `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Version              *consensus.Version `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Uuid                 string             `protobuf:"bytes,3,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Dropped              uint64             `protobuf:"varint,4,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *KeyUpdate) Reset()         { *m = KeyUpdate{} }
+func (m *KeyUpdate) String() string { return proto.CompactTextString(m) }
+func (*KeyUpdate) ProtoMessage()    {}
+func (*KeyUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{15}
+}
+func (m *KeyUpdate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeyUpdate.Unmarshal(m, b)
+}
+func (m *KeyUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeyUpdate.Marshal(b, m, deterministic)
+}
+func (dst *KeyUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeyUpdate.Merge(dst, src)
+}
+func (m *KeyUpdate) XXX_Size() int {
+	return xxx_messageInfo_KeyUpdate.Size(m)
+}
+func (m *KeyUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeyUpdate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeyUpdate proto.InternalMessageInfo
+
+func (m *KeyUpdate) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *KeyUpdate) GetVersion() *consensus.Version {
+	if m != nil {
+		return m.Version
+	}
+	return nil
+}
+
+func (m *KeyUpdate) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *KeyUpdate) GetDropped() uint64 {
+	if m != nil {
+		return m.Dropped
+	}
+	return 0
+}
+
+type Empty struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Empty) Reset()         { *m = Empty{} }
+func (m *Empty) String() string { return proto.CompactTextString(m) }
+func (*Empty) ProtoMessage()    {}
+func (*Empty) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{16}
+}
+func (m *Empty) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Empty.Unmarshal(m, b)
+}
+func (m *Empty) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Empty.Marshal(b, m, deterministic)
+}
+func (dst *Empty) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Empty.Merge(dst, src)
+}
+func (m *Empty) XXX_Size() int {
+	return xxx_messageInfo_Empty.Size(m)
+}
+func (m *Empty) XXX_DiscardUnknown() {
+	xxx_messageInfo_Empty.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Empty proto.InternalMessageInfo
+
+type Quota struct {
+	Prefix               string   `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Used                 int64    `protobuf:"varint,2,opt,name=used,proto3" json:"used,omitempty"`
+	Limit                int64    `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Quota) Reset()         { *m = Quota{} }
+func (m *Quota) String() string { return proto.CompactTextString(m) }
+func (*Quota) ProtoMessage()    {}
+func (*Quota) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{17}
+}
+func (m *Quota) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Quota.Unmarshal(m, b)
+}
+func (m *Quota) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Quota.Marshal(b, m, deterministic)
+}
+func (dst *Quota) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Quota.Merge(dst, src)
+}
+func (m *Quota) XXX_Size() int {
+	return xxx_messageInfo_Quota.Size(m)
+}
+func (m *Quota) XXX_DiscardUnknown() {
+	xxx_messageInfo_Quota.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Quota proto.InternalMessageInfo
+
+func (m *Quota) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *Quota) GetUsed() int64 {
+	if m != nil {
+		return m.Used
+	}
+	return 0
+}
+
+func (m *Quota) GetLimit() int64 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type Quotas struct {
+	Quotas               []*Quota `protobuf:"bytes,1,rep,name=quotas,proto3" json:"quotas,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Quotas) Reset()         { *m = Quotas{} }
+func (m *Quotas) String() string { return proto.CompactTextString(m) }
+func (*Quotas) ProtoMessage()    {}
+func (*Quotas) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{18}
+}
+func (m *Quotas) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Quotas.Unmarshal(m, b)
+}
+func (m *Quotas) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Quotas.Marshal(b, m, deterministic)
+}
+func (dst *Quotas) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Quotas.Merge(dst, src)
+}
+func (m *Quotas) XXX_Size() int {
+	return xxx_messageInfo_Quotas.Size(m)
+}
+func (m *Quotas) XXX_DiscardUnknown() {
+	xxx_messageInfo_Quotas.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Quotas proto.InternalMessageInfo
+
+func (m *Quotas) GetQuotas() []*Quota {
+	if m != nil {
+		return m.Quotas
+	}
+	return nil
+}
+
+type KeysRequest struct {
+	Prefix               string   `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Details              bool     `protobuf:"varint,2,opt,name=details,proto3" json:"details,omitempty"`
+	PreviewLimit         uint32   `protobuf:"varint,3,opt,name=preview_limit,json=previewLimit,proto3" json:"preview_limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeysRequest) Reset()         { *m = KeysRequest{} }
+func (m *KeysRequest) String() string { return proto.CompactTextString(m) }
+func (*KeysRequest) ProtoMessage()    {}
+func (*KeysRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{19}
+}
+func (m *KeysRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeysRequest.Unmarshal(m, b)
+}
+func (m *KeysRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeysRequest.Marshal(b, m, deterministic)
+}
+func (dst *KeysRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeysRequest.Merge(dst, src)
+}
+func (m *KeysRequest) XXX_Size() int {
+	return xxx_messageInfo_KeysRequest.Size(m)
+}
+func (m *KeysRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeysRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeysRequest proto.InternalMessageInfo
+
+func (m *KeysRequest) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *KeysRequest) GetDetails() bool {
+	if m != nil {
+		return m.Details
+	}
+	return false
+}
+
+func (m *KeysRequest) GetPreviewLimit() uint32 {
+	if m != nil {
+		return m.PreviewLimit
+	}
+	return 0
+}
+
+type KeyInfo struct {
+	Key                  string             `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Version              *consensus.Version `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Type                 string             `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Size                 uint64             `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	Preview              string             `protobuf:"bytes,5,opt,name=preview,proto3" json:"preview,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *KeyInfo) Reset()         { *m = KeyInfo{} }
+func (m *KeyInfo) String() string { return proto.CompactTextString(m) }
+func (*KeyInfo) ProtoMessage()    {}
+func (*KeyInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{20}
+}
+func (m *KeyInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeyInfo.Unmarshal(m, b)
+}
+func (m *KeyInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeyInfo.Marshal(b, m, deterministic)
+}
+func (dst *KeyInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeyInfo.Merge(dst, src)
+}
+func (m *KeyInfo) XXX_Size() int {
+	return xxx_messageInfo_KeyInfo.Size(m)
+}
+func (m *KeyInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeyInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeyInfo proto.InternalMessageInfo
+
+func (m *KeyInfo) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *KeyInfo) GetVersion() *consensus.Version {
+	if m != nil {
+		return m.Version
+	}
+	return nil
+}
+
+func (m *KeyInfo) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *KeyInfo) GetSize() uint64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *KeyInfo) GetPreview() string {
+	if m != nil {
+		return m.Preview
+	}
+	return ""
+}
+
+type KeyInfos struct {
+	Keys                 []*KeyInfo `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *KeyInfos) Reset()         { *m = KeyInfos{} }
+func (m *KeyInfos) String() string { return proto.CompactTextString(m) }
+func (*KeyInfos) ProtoMessage()    {}
+func (*KeyInfos) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{21}
+}
+func (m *KeyInfos) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeyInfos.Unmarshal(m, b)
+}
+func (m *KeyInfos) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeyInfos.Marshal(b, m, deterministic)
+}
+func (dst *KeyInfos) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeyInfos.Merge(dst, src)
+}
+func (m *KeyInfos) XXX_Size() int {
+	return xxx_messageInfo_KeyInfos.Size(m)
+}
+func (m *KeyInfos) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeyInfos.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeyInfos proto.InternalMessageInfo
+
+func (m *KeyInfos) GetKeys() []*KeyInfo {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type KeyList struct {
+	Keys                 []string `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeyList) Reset()         { *m = KeyList{} }
+func (m *KeyList) String() string { return proto.CompactTextString(m) }
+func (*KeyList) ProtoMessage()    {}
+func (*KeyList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{22}
+}
+func (m *KeyList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeyList.Unmarshal(m, b)
+}
+func (m *KeyList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeyList.Marshal(b, m, deterministic)
+}
+func (dst *KeyList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeyList.Merge(dst, src)
+}
+func (m *KeyList) XXX_Size() int {
+	return xxx_messageInfo_KeyList.Size(m)
+}
+func (m *KeyList) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeyList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeyList proto.InternalMessageInfo
+
+func (m *KeyList) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type Requirements struct {
+	Requirements         map[string]*consensus.Version `protobuf:"bytes,1,rep,name=requirements,proto3" json:"requirements,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
+	XXX_unrecognized     []byte                        `json:"-"`
+	XXX_sizecache        int32                         `json:"-"`
+}
+
+func (m *Requirements) Reset()         { *m = Requirements{} }
+func (m *Requirements) String() string { return proto.CompactTextString(m) }
+func (*Requirements) ProtoMessage()    {}
+func (*Requirements) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{23}
+}
+func (m *Requirements) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Requirements.Unmarshal(m, b)
+}
+func (m *Requirements) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Requirements.Marshal(b, m, deterministic)
+}
+func (dst *Requirements) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Requirements.Merge(dst, src)
+}
+func (m *Requirements) XXX_Size() int {
+	return xxx_messageInfo_Requirements.Size(m)
+}
+func (m *Requirements) XXX_DiscardUnknown() {
+	xxx_messageInfo_Requirements.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Requirements proto.InternalMessageInfo
+
+func (m *Requirements) GetRequirements() map[string]*consensus.Version {
+	if m != nil {
+		return m.Requirements
+	}
+	return nil
+}
+
+type CertificateRequest struct {
+	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CertificateRequest) Reset()         { *m = CertificateRequest{} }
+func (m *CertificateRequest) String() string { return proto.CompactTextString(m) }
+func (*CertificateRequest) ProtoMessage()    {}
+func (*CertificateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{24}
+}
+func (m *CertificateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CertificateRequest.Unmarshal(m, b)
+}
+func (m *CertificateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CertificateRequest.Marshal(b, m, deterministic)
+}
+func (dst *CertificateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CertificateRequest.Merge(dst, src)
+}
+func (m *CertificateRequest) XXX_Size() int {
+	return xxx_messageInfo_CertificateRequest.Size(m)
+}
+func (m *CertificateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CertificateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CertificateRequest proto.InternalMessageInfo
+
+func (m *CertificateRequest) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+type EndorsersRequest struct {
+	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EndorsersRequest) Reset()         { *m = EndorsersRequest{} }
+func (m *EndorsersRequest) String() string { return proto.CompactTextString(m) }
+func (*EndorsersRequest) ProtoMessage()    {}
+func (*EndorsersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{25}
+}
+func (m *EndorsersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EndorsersRequest.Unmarshal(m, b)
+}
+func (m *EndorsersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EndorsersRequest.Marshal(b, m, deterministic)
+}
+func (dst *EndorsersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EndorsersRequest.Merge(dst, src)
+}
+func (m *EndorsersRequest) XXX_Size() int {
+	return xxx_messageInfo_EndorsersRequest.Size(m)
+}
+func (m *EndorsersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_EndorsersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EndorsersRequest proto.InternalMessageInfo
+
+func (m *EndorsersRequest) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+type EndorserInfo struct {
+	Emitter              string   `protobuf:"bytes,1,opt,name=emitter,proto3" json:"emitter,omitempty"`
+	Conditions           []string `protobuf:"bytes,2,rep,name=conditions,proto3" json:"conditions,omitempty"`
+	Fingerprint          string   `protobuf:"bytes,3,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	Aggregated           bool     `protobuf:"varint,4,opt,name=aggregated,proto3" json:"aggregated,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EndorserInfo) Reset()         { *m = EndorserInfo{} }
+func (m *EndorserInfo) String() string { return proto.CompactTextString(m) }
+func (*EndorserInfo) ProtoMessage()    {}
+func (*EndorserInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{26}
+}
+func (m *EndorserInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EndorserInfo.Unmarshal(m, b)
+}
+func (m *EndorserInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EndorserInfo.Marshal(b, m, deterministic)
+}
+func (dst *EndorserInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EndorserInfo.Merge(dst, src)
+}
+func (m *EndorserInfo) XXX_Size() int {
+	return xxx_messageInfo_EndorserInfo.Size(m)
+}
+func (m *EndorserInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_EndorserInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EndorserInfo proto.InternalMessageInfo
+
+func (m *EndorserInfo) GetEmitter() string {
+	if m != nil {
+		return m.Emitter
+	}
+	return ""
+}
+
+func (m *EndorserInfo) GetConditions() []string {
+	if m != nil {
+		return m.Conditions
+	}
+	return nil
+}
+
+func (m *EndorserInfo) GetFingerprint() string {
+	if m != nil {
+		return m.Fingerprint
+	}
+	return ""
+}
+
+func (m *EndorserInfo) GetAggregated() bool {
+	if m != nil {
+		return m.Aggregated
+	}
+	return false
+}
+
+type EndorserInfos struct {
+	Endorsers            []*EndorserInfo `protobuf:"bytes,1,rep,name=endorsers,proto3" json:"endorsers,omitempty"`
+	Certified            bool            `protobuf:"varint,2,opt,name=certified,proto3" json:"certified,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *EndorserInfos) Reset()         { *m = EndorserInfos{} }
+func (m *EndorserInfos) String() string { return proto.CompactTextString(m) }
+func (*EndorserInfos) ProtoMessage()    {}
+func (*EndorserInfos) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{27}
+}
+func (m *EndorserInfos) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EndorserInfos.Unmarshal(m, b)
+}
+func (m *EndorserInfos) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EndorserInfos.Marshal(b, m, deterministic)
+}
+func (dst *EndorserInfos) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EndorserInfos.Merge(dst, src)
+}
+func (m *EndorserInfos) XXX_Size() int {
+	return xxx_messageInfo_EndorserInfos.Size(m)
+}
+func (m *EndorserInfos) XXX_DiscardUnknown() {
+	xxx_messageInfo_EndorserInfos.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EndorserInfos proto.InternalMessageInfo
+
+func (m *EndorserInfos) GetEndorsers() []*EndorserInfo {
+	if m != nil {
+		return m.Endorsers
+	}
+	return nil
+}
+
+func (m *EndorserInfos) GetCertified() bool {
+	if m != nil {
+		return m.Certified
+	}
+	return false
+}
+
+type RetentionPolicy struct {
+	Prefix               string   `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Age                  int64    `protobuf:"varint,2,opt,name=age,proto3" json:"age,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RetentionPolicy) Reset()         { *m = RetentionPolicy{} }
+func (m *RetentionPolicy) String() string { return proto.CompactTextString(m) }
+func (*RetentionPolicy) ProtoMessage()    {}
+func (*RetentionPolicy) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{28}
+}
+func (m *RetentionPolicy) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RetentionPolicy.Unmarshal(m, b)
+}
+func (m *RetentionPolicy) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RetentionPolicy.Marshal(b, m, deterministic)
+}
+func (dst *RetentionPolicy) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RetentionPolicy.Merge(dst, src)
+}
+func (m *RetentionPolicy) XXX_Size() int {
+	return xxx_messageInfo_RetentionPolicy.Size(m)
+}
+func (m *RetentionPolicy) XXX_DiscardUnknown() {
+	xxx_messageInfo_RetentionPolicy.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RetentionPolicy proto.InternalMessageInfo
+
+func (m *RetentionPolicy) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *RetentionPolicy) GetAge() int64 {
+	if m != nil {
+		return m.Age
+	}
+	return 0
+}
+
+type ExpiredKey struct {
+	Key                  string               `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Prefix               string               `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Modified             *timestamp.Timestamp `protobuf:"bytes,3,opt,name=modified,proto3" json:"modified,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *ExpiredKey) Reset()         { *m = ExpiredKey{} }
+func (m *ExpiredKey) String() string { return proto.CompactTextString(m) }
+func (*ExpiredKey) ProtoMessage()    {}
+func (*ExpiredKey) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{29}
+}
+func (m *ExpiredKey) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExpiredKey.Unmarshal(m, b)
+}
+func (m *ExpiredKey) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExpiredKey.Marshal(b, m, deterministic)
+}
+func (dst *ExpiredKey) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExpiredKey.Merge(dst, src)
+}
+func (m *ExpiredKey) XXX_Size() int {
+	return xxx_messageInfo_ExpiredKey.Size(m)
+}
+func (m *ExpiredKey) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExpiredKey.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExpiredKey proto.InternalMessageInfo
+
+func (m *ExpiredKey) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *ExpiredKey) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *ExpiredKey) GetModified() *timestamp.Timestamp {
+	if m != nil {
+		return m.Modified
+	}
+	return nil
+}
+
+type RetentionReport struct {
+	Policies             []*RetentionPolicy `protobuf:"bytes,1,rep,name=policies,proto3" json:"policies,omitempty"`
+	Expired              []*ExpiredKey      `protobuf:"bytes,2,rep,name=expired,proto3" json:"expired,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *RetentionReport) Reset()         { *m = RetentionReport{} }
+func (m *RetentionReport) String() string { return proto.CompactTextString(m) }
+func (*RetentionReport) ProtoMessage()    {}
+func (*RetentionReport) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{30}
+}
+func (m *RetentionReport) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RetentionReport.Unmarshal(m, b)
+}
+func (m *RetentionReport) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RetentionReport.Marshal(b, m, deterministic)
+}
+func (dst *RetentionReport) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RetentionReport.Merge(dst, src)
+}
+func (m *RetentionReport) XXX_Size() int {
+	return xxx_messageInfo_RetentionReport.Size(m)
+}
+func (m *RetentionReport) XXX_DiscardUnknown() {
+	xxx_messageInfo_RetentionReport.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RetentionReport proto.InternalMessageInfo
+
+func (m *RetentionReport) GetPolicies() []*RetentionPolicy {
+	if m != nil {
+		return m.Policies
+	}
+	return nil
+}
+
+func (m *RetentionReport) GetExpired() []*ExpiredKey {
+	if m != nil {
+		return m.Expired
+	}
+	return nil
+}
+
+// NodeInfo describes the configuration of a node relevant to its clients.
+type NodeInfo struct {
+	Identity             string        `protobuf:"bytes,1,opt,name=identity,proto3" json:"identity,omitempty"`
+	Version              string        `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Threshold            uint32        `protobuf:"varint,3,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	Policies             []*PolicyInfo `protobuf:"bytes,4,rep,name=policies,proto3" json:"policies,omitempty"`
+	MaxTimeout           int64         `protobuf:"varint,5,opt,name=max_timeout,json=maxTimeout,proto3" json:"max_timeout,omitempty"`
+	MaxOperations        uint32        `protobuf:"varint,6,opt,name=max_operations,json=maxOperations,proto3" json:"max_operations,omitempty"`
+	MaxValueSize         uint64        `protobuf:"varint,7,opt,name=max_value_size,json=maxValueSize,proto3" json:"max_value_size,omitempty"`
+	Operations           []string      `protobuf:"bytes,8,rep,name=operations,proto3" json:"operations,omitempty"`
+	Features             []string      `protobuf:"bytes,9,rep,name=features,proto3" json:"features,omitempty"`
+	Failure              string        `protobuf:"bytes,10,opt,name=failure,proto3" json:"failure,omitempty"`
+	MemoryUsed           uint64        `protobuf:"varint,11,opt,name=memory_used,json=memoryUsed,proto3" json:"memory_used,omitempty"`
+	MemoryBudget         uint64        `protobuf:"varint,12,opt,name=memory_budget,json=memoryBudget,proto3" json:"memory_budget,omitempty"`
+	MemoryRejected       uint64        `protobuf:"varint,13,opt,name=memory_rejected,json=memoryRejected,proto3" json:"memory_rejected,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *NodeInfo) Reset()         { *m = NodeInfo{} }
+func (m *NodeInfo) String() string { return proto.CompactTextString(m) }
+func (*NodeInfo) ProtoMessage()    {}
+func (*NodeInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{31}
+}
+func (m *NodeInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeInfo.Unmarshal(m, b)
+}
+func (m *NodeInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeInfo.Marshal(b, m, deterministic)
+}
+func (dst *NodeInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeInfo.Merge(dst, src)
+}
+func (m *NodeInfo) XXX_Size() int {
+	return xxx_messageInfo_NodeInfo.Size(m)
+}
+func (m *NodeInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeInfo proto.InternalMessageInfo
+
+func (m *NodeInfo) GetIdentity() string {
+	if m != nil {
+		return m.Identity
+	}
+	return ""
+}
+
+func (m *NodeInfo) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *NodeInfo) GetThreshold() uint32 {
+	if m != nil {
+		return m.Threshold
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetPolicies() []*PolicyInfo {
+	if m != nil {
+		return m.Policies
+	}
+	return nil
+}
+
+func (m *NodeInfo) GetMaxTimeout() int64 {
+	if m != nil {
+		return m.MaxTimeout
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetMaxOperations() uint32 {
+	if m != nil {
+		return m.MaxOperations
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetMaxValueSize() uint64 {
+	if m != nil {
+		return m.MaxValueSize
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetOperations() []string {
+	if m != nil {
+		return m.Operations
+	}
+	return nil
+}
+
+func (m *NodeInfo) GetFeatures() []string {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
+func (m *NodeInfo) GetFailure() string {
+	if m != nil {
+		return m.Failure
+	}
+	return ""
+}
+
+func (m *NodeInfo) GetMemoryUsed() uint64 {
+	if m != nil {
+		return m.MemoryUsed
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetMemoryBudget() uint64 {
+	if m != nil {
+		return m.MemoryBudget
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetMemoryRejected() uint64 {
+	if m != nil {
+		return m.MemoryRejected
+	}
+	return 0
+}
+
+type PolicyInfo struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Prefixes             []string `protobuf:"bytes,2,rep,name=prefixes,proto3" json:"prefixes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PolicyInfo) Reset()         { *m = PolicyInfo{} }
+func (m *PolicyInfo) String() string { return proto.CompactTextString(m) }
+func (*PolicyInfo) ProtoMessage()    {}
+func (*PolicyInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{32}
+}
+func (m *PolicyInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PolicyInfo.Unmarshal(m, b)
+}
+func (m *PolicyInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PolicyInfo.Marshal(b, m, deterministic)
+}
+func (dst *PolicyInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PolicyInfo.Merge(dst, src)
+}
+func (m *PolicyInfo) XXX_Size() int {
+	return xxx_messageInfo_PolicyInfo.Size(m)
+}
+func (m *PolicyInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_PolicyInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PolicyInfo proto.InternalMessageInfo
+
+func (m *PolicyInfo) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *PolicyInfo) GetPrefixes() []string {
+	if m != nil {
+		return m.Prefixes
+	}
+	return nil
+}
+
+// RecoveryReport describes the recoveries of keys asked to the node.
+type RecoveryReport struct {
+	Breaker              string        `protobuf:"bytes,1,opt,name=breaker,proto3" json:"breaker,omitempty"`
+	Pending              uint32        `protobuf:"varint,2,opt,name=pending,proto3" json:"pending,omitempty"`
+	Retries              uint64        `protobuf:"varint,3,opt,name=retries,proto3" json:"retries,omitempty"`
+	Successes            uint64        `protobuf:"varint,4,opt,name=successes,proto3" json:"successes,omitempty"`
+	Failures             uint64        `protobuf:"varint,5,opt,name=failures,proto3" json:"failures,omitempty"`
+	BreakerOpenings      uint64        `protobuf:"varint,6,opt,name=breaker_openings,json=breakerOpenings,proto3" json:"breaker_openings,omitempty"`
+	DeadLetters          []*DeadLetter `protobuf:"bytes,7,rep,name=dead_letters,json=deadLetters,proto3" json:"dead_letters,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *RecoveryReport) Reset()         { *m = RecoveryReport{} }
+func (m *RecoveryReport) String() string { return proto.CompactTextString(m) }
+func (*RecoveryReport) ProtoMessage()    {}
+func (*RecoveryReport) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{33}
+}
+func (m *RecoveryReport) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecoveryReport.Unmarshal(m, b)
+}
+func (m *RecoveryReport) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecoveryReport.Marshal(b, m, deterministic)
+}
+func (dst *RecoveryReport) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecoveryReport.Merge(dst, src)
+}
+func (m *RecoveryReport) XXX_Size() int {
+	return xxx_messageInfo_RecoveryReport.Size(m)
+}
+func (m *RecoveryReport) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecoveryReport.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecoveryReport proto.InternalMessageInfo
+
+func (m *RecoveryReport) GetBreaker() string {
+	if m != nil {
+		return m.Breaker
+	}
+	return ""
+}
+
+func (m *RecoveryReport) GetPending() uint32 {
+	if m != nil {
+		return m.Pending
+	}
+	return 0
+}
+
+func (m *RecoveryReport) GetRetries() uint64 {
+	if m != nil {
+		return m.Retries
+	}
+	return 0
+}
+
+func (m *RecoveryReport) GetSuccesses() uint64 {
+	if m != nil {
+		return m.Successes
+	}
+	return 0
+}
+
+func (m *RecoveryReport) GetFailures() uint64 {
+	if m != nil {
+		return m.Failures
+	}
+	return 0
+}
+
+func (m *RecoveryReport) GetBreakerOpenings() uint64 {
+	if m != nil {
+		return m.BreakerOpenings
+	}
+	return 0
+}
+
+func (m *RecoveryReport) GetDeadLetters() []*DeadLetter {
+	if m != nil {
+		return m.DeadLetters
+	}
+	return nil
+}
+
+// DeadLetter is a key whose recovery failed too many times.
+type DeadLetter struct {
+	Key                  string               `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Attempts             uint32               `protobuf:"varint,2,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	Error                string               `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	Time                 *timestamp.Timestamp `protobuf:"bytes,4,opt,name=time,proto3" json:"time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *DeadLetter) Reset()         { *m = DeadLetter{} }
+func (m *DeadLetter) String() string { return proto.CompactTextString(m) }
+func (*DeadLetter) ProtoMessage()    {}
+func (*DeadLetter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{34}
+}
+func (m *DeadLetter) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeadLetter.Unmarshal(m, b)
+}
+func (m *DeadLetter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeadLetter.Marshal(b, m, deterministic)
+}
+func (dst *DeadLetter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeadLetter.Merge(dst, src)
+}
+func (m *DeadLetter) XXX_Size() int {
+	return xxx_messageInfo_DeadLetter.Size(m)
+}
+func (m *DeadLetter) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeadLetter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeadLetter proto.InternalMessageInfo
+
+func (m *DeadLetter) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *DeadLetter) GetAttempts() uint32 {
+	if m != nil {
+		return m.Attempts
+	}
+	return 0
+}
+
+func (m *DeadLetter) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *DeadLetter) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+// QueryStatus describes the progress of a submitted transaction.
+type QueryStatus struct {
+	State                string               `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	Endorsements         uint32               `protobuf:"varint,2,opt,name=endorsements,proto3" json:"endorsements,omitempty"`
+	Deadline             *timestamp.Timestamp `protobuf:"bytes,3,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	Resolved             *timestamp.Timestamp `protobuf:"bytes,4,opt,name=resolved,proto3" json:"resolved,omitempty"`
+	Applied              bool                 `protobuf:"varint,5,opt,name=applied,proto3" json:"applied,omitempty"`
+	ApplyError           string               `protobuf:"bytes,6,opt,name=apply_error,json=applyError,proto3" json:"apply_error,omitempty"`
+	Uuid                 string               `protobuf:"bytes,7,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *QueryStatus) Reset()         { *m = QueryStatus{} }
+func (m *QueryStatus) String() string { return proto.CompactTextString(m) }
+func (*QueryStatus) ProtoMessage()    {}
+func (*QueryStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{35}
+}
+func (m *QueryStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QueryStatus.Unmarshal(m, b)
+}
+func (m *QueryStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QueryStatus.Marshal(b, m, deterministic)
+}
+func (dst *QueryStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryStatus.Merge(dst, src)
+}
+func (m *QueryStatus) XXX_Size() int {
+	return xxx_messageInfo_QueryStatus.Size(m)
+}
+func (m *QueryStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryStatus proto.InternalMessageInfo
+
+func (m *QueryStatus) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *QueryStatus) GetEndorsements() uint32 {
+	if m != nil {
+		return m.Endorsements
+	}
+	return 0
+}
+
+func (m *QueryStatus) GetDeadline() *timestamp.Timestamp {
+	if m != nil {
+		return m.Deadline
+	}
+	return nil
+}
+
+func (m *QueryStatus) GetResolved() *timestamp.Timestamp {
+	if m != nil {
+		return m.Resolved
+	}
+	return nil
+}
+
+func (m *QueryStatus) GetApplied() bool {
+	if m != nil {
+		return m.Applied
+	}
+	return false
+}
+
+func (m *QueryStatus) GetApplyError() string {
+	if m != nil {
+		return m.ApplyError
+	}
+	return ""
+}
+
+func (m *QueryStatus) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+// NodeStatuses holds the status of the node, followed by the recent
+// statuses broadcast by the other nodes.
+type NodeStatuses struct {
+	Nodes                []*consensus.NodeStatus `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
+}
+
+func (m *NodeStatuses) Reset()         { *m = NodeStatuses{} }
+func (m *NodeStatuses) String() string { return proto.CompactTextString(m) }
+func (*NodeStatuses) ProtoMessage()    {}
+func (*NodeStatuses) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{36}
+}
+func (m *NodeStatuses) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeStatuses.Unmarshal(m, b)
+}
+func (m *NodeStatuses) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeStatuses.Marshal(b, m, deterministic)
+}
+func (dst *NodeStatuses) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeStatuses.Merge(dst, src)
+}
+func (m *NodeStatuses) XXX_Size() int {
+	return xxx_messageInfo_NodeStatuses.Size(m)
+}
+func (m *NodeStatuses) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeStatuses.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeStatuses proto.InternalMessageInfo
+
+func (m *NodeStatuses) GetNodes() []*consensus.NodeStatus {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+// UnknownEmitter is an identity missing from the keyring of the node, whose
+// queries or endorsements have been received recently.
+type UnknownEmitter struct {
+	Identity             string               `protobuf:"bytes,1,opt,name=identity,proto3" json:"identity,omitempty"`
+	Messages             uint64               `protobuf:"varint,2,opt,name=messages,proto3" json:"messages,omitempty"`
+	Quarantined          uint32               `protobuf:"varint,3,opt,name=quarantined,proto3" json:"quarantined,omitempty"`
+	FirstSeen            *timestamp.Timestamp `protobuf:"bytes,4,opt,name=first_seen,json=firstSeen,proto3" json:"first_seen,omitempty"`
+	LastSeen             *timestamp.Timestamp `protobuf:"bytes,5,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *UnknownEmitter) Reset()         { *m = UnknownEmitter{} }
+func (m *UnknownEmitter) String() string { return proto.CompactTextString(m) }
+func (*UnknownEmitter) ProtoMessage()    {}
+func (*UnknownEmitter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{37}
+}
+func (m *UnknownEmitter) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UnknownEmitter.Unmarshal(m, b)
+}
+func (m *UnknownEmitter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UnknownEmitter.Marshal(b, m, deterministic)
+}
+func (dst *UnknownEmitter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UnknownEmitter.Merge(dst, src)
+}
+func (m *UnknownEmitter) XXX_Size() int {
+	return xxx_messageInfo_UnknownEmitter.Size(m)
+}
+func (m *UnknownEmitter) XXX_DiscardUnknown() {
+	xxx_messageInfo_UnknownEmitter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UnknownEmitter proto.InternalMessageInfo
+
+func (m *UnknownEmitter) GetIdentity() string {
+	if m != nil {
+		return m.Identity
+	}
+	return ""
+}
+
+func (m *UnknownEmitter) GetMessages() uint64 {
+	if m != nil {
+		return m.Messages
+	}
+	return 0
+}
+
+func (m *UnknownEmitter) GetQuarantined() uint32 {
+	if m != nil {
+		return m.Quarantined
+	}
+	return 0
+}
+
+func (m *UnknownEmitter) GetFirstSeen() *timestamp.Timestamp {
+	if m != nil {
+		return m.FirstSeen
+	}
+	return nil
+}
+
+func (m *UnknownEmitter) GetLastSeen() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastSeen
+	}
+	return nil
+}
+
+type UnknownEmitterList struct {
+	Emitters             []*UnknownEmitter `protobuf:"bytes,1,rep,name=emitters,proto3" json:"emitters,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *UnknownEmitterList) Reset()         { *m = UnknownEmitterList{} }
+func (m *UnknownEmitterList) String() string { return proto.CompactTextString(m) }
+func (*UnknownEmitterList) ProtoMessage()    {}
+func (*UnknownEmitterList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{38}
+}
+func (m *UnknownEmitterList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UnknownEmitterList.Unmarshal(m, b)
+}
+func (m *UnknownEmitterList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UnknownEmitterList.Marshal(b, m, deterministic)
+}
+func (dst *UnknownEmitterList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UnknownEmitterList.Merge(dst, src)
+}
+func (m *UnknownEmitterList) XXX_Size() int {
+	return xxx_messageInfo_UnknownEmitterList.Size(m)
+}
+func (m *UnknownEmitterList) XXX_DiscardUnknown() {
+	xxx_messageInfo_UnknownEmitterList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UnknownEmitterList proto.InternalMessageInfo
+
+func (m *UnknownEmitterList) GetEmitters() []*UnknownEmitter {
+	if m != nil {
+		return m.Emitters
+	}
+	return nil
+}
+
+// FaultProfile describes the faults injected in the broadcasts of a staging
+// node.
+type FaultProfile struct {
+	MinLatency           int64    `protobuf:"varint,1,opt,name=min_latency,json=minLatency,proto3" json:"min_latency,omitempty"`
+	MedianLatency        int64    `protobuf:"varint,2,opt,name=median_latency,json=medianLatency,proto3" json:"median_latency,omitempty"`
+	MaxLatency           int64    `protobuf:"varint,3,opt,name=max_latency,json=maxLatency,proto3" json:"max_latency,omitempty"`
+	Loss                 float64  `protobuf:"fixed64,4,opt,name=loss,proto3" json:"loss,omitempty"`
+	Duplication          float64  `protobuf:"fixed64,5,opt,name=duplication,proto3" json:"duplication,omitempty"`
+	Seed                 int64    `protobuf:"varint,6,opt,name=seed,proto3" json:"seed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FaultProfile) Reset()         { *m = FaultProfile{} }
+func (m *FaultProfile) String() string { return proto.CompactTextString(m) }
+func (*FaultProfile) ProtoMessage()    {}
+func (*FaultProfile) Descriptor() ([]byte, []int) {
+	return fileDescriptor_api_1b40cafcd4234784, []int{39}
+}
+func (m *FaultProfile) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FaultProfile.Unmarshal(m, b)
+}
+func (m *FaultProfile) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FaultProfile.Marshal(b, m, deterministic)
+}
+func (dst *FaultProfile) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FaultProfile.Merge(dst, src)
+}
+func (m *FaultProfile) XXX_Size() int {
+	return xxx_messageInfo_FaultProfile.Size(m)
+}
+func (m *FaultProfile) XXX_DiscardUnknown() {
+	xxx_messageInfo_FaultProfile.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FaultProfile proto.InternalMessageInfo
+
+func (m *FaultProfile) GetMinLatency() int64 {
+	if m != nil {
+		return m.MinLatency
+	}
+	return 0
+}
+
+func (m *FaultProfile) GetMedianLatency() int64 {
+	if m != nil {
+		return m.MedianLatency
+	}
+	return 0
+}
+
+func (m *FaultProfile) GetMaxLatency() int64 {
+	if m != nil {
+		return m.MaxLatency
+	}
+	return 0
+}
+
+func (m *FaultProfile) GetLoss() float64 {
+	if m != nil {
+		return m.Loss
+	}
+	return 0
+}
+
+func (m *FaultProfile) GetDuplication() float64 {
+	if m != nil {
+		return m.Duplication
+	}
+	return 0
+}
+
+func (m *FaultProfile) GetSeed() int64 {
+	if m != nil {
+		return m.Seed
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*Key)(nil), "api.Key")
+	proto.RegisterType((*Value)(nil), "api.Value")
+	proto.RegisterType((*Provenance)(nil), "api.Provenance")
+	proto.RegisterType((*KeyValue)(nil), "api.KeyValue")
+	proto.RegisterType((*Integer)(nil), "api.Integer")
+	proto.RegisterType((*Values)(nil), "api.Values")
+	proto.RegisterType((*RangeRequest)(nil), "api.RangeRequest")
+	proto.RegisterType((*Length)(nil), "api.Length")
+	proto.RegisterType((*FieldRequest)(nil), "api.FieldRequest")
+	proto.RegisterType((*Fields)(nil), "api.Fields")
+	proto.RegisterType((*Boolean)(nil), "api.Boolean")
+	proto.RegisterType((*Transaction)(nil), "api.Transaction")
+	proto.RegisterMapType((map[string]*consensus.Version)(nil), "api.Transaction.RequirementsEntry")
+	proto.RegisterType((*Receipt)(nil), "api.Receipt")
+	proto.RegisterType((*ReplayRequest)(nil), "api.ReplayRequest")
+	proto.RegisterType((*WatchRequest)(nil), "api.WatchRequest")
+	proto.RegisterType((*KeyUpdate)(nil), "api.KeyUpdate")
+	proto.RegisterType((*Empty)(nil), "api.Empty")
+	proto.RegisterType((*Quota)(nil), "api.Quota")
+	proto.RegisterType((*Quotas)(nil), "api.Quotas")
+	proto.RegisterType((*KeysRequest)(nil), "api.KeysRequest")
+	proto.RegisterType((*KeyInfo)(nil), "api.KeyInfo")
+	proto.RegisterType((*KeyInfos)(nil), "api.KeyInfos")
+	proto.RegisterType((*KeyList)(nil), "api.KeyList")
+	proto.RegisterType((*Requirements)(nil), "api.Requirements")
+	proto.RegisterMapType((map[string]*consensus.Version)(nil), "api.Requirements.RequirementsEntry")
+	proto.RegisterType((*CertificateRequest)(nil), "api.CertificateRequest")
+	proto.RegisterType((*EndorsersRequest)(nil), "api.EndorsersRequest")
+	proto.RegisterType((*EndorserInfo)(nil), "api.EndorserInfo")
+	proto.RegisterType((*EndorserInfos)(nil), "api.EndorserInfos")
+	proto.RegisterType((*RetentionPolicy)(nil), "api.RetentionPolicy")
+	proto.RegisterType((*ExpiredKey)(nil), "api.ExpiredKey")
+	proto.RegisterType((*RetentionReport)(nil), "api.RetentionReport")
+	proto.RegisterType((*NodeInfo)(nil), "api.NodeInfo")
+	proto.RegisterType((*PolicyInfo)(nil), "api.PolicyInfo")
+	proto.RegisterType((*RecoveryReport)(nil), "api.RecoveryReport")
+	proto.RegisterType((*DeadLetter)(nil), "api.DeadLetter")
+	proto.RegisterType((*QueryStatus)(nil), "api.QueryStatus")
+	proto.RegisterType((*NodeStatuses)(nil), "api.NodeStatuses")
+	proto.RegisterType((*UnknownEmitter)(nil), "api.UnknownEmitter")
+	proto.RegisterType((*UnknownEmitterList)(nil), "api.UnknownEmitterList")
+	proto.RegisterType((*FaultProfile)(nil), "api.FaultProfile")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// EndorserClient is the client API for Endorser service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type EndorserClient interface {
+	Get(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Value, error)
+	GetInt(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Integer, error)
+	Members(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Values, error)
+	Contains(ctx context.Context, in *KeyValue, opts ...grpc.CallOption) (*Boolean, error)
+	Range(ctx context.Context, in *RangeRequest, opts ...grpc.CallOption) (*Values, error)
+	Len(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Length, error)
+	HGet(ctx context.Context, in *FieldRequest, opts ...grpc.CallOption) (*Value, error)
+	HGetAll(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Fields, error)
+	GetProvenance(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Provenance, error)
+	Submit(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*Receipt, error)
+	SubmitAndWait(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*QueryStatus, error)
+	SubmitStream(ctx context.Context, opts ...grpc.CallOption) (Endorser_SubmitStreamClient, error)
+	ReplayEvents(ctx context.Context, in *ReplayRequest, opts ...grpc.CallOption) (Endorser_ReplayEventsClient, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Endorser_WatchClient, error)
+	QuotaStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Quotas, error)
+	Keys(ctx context.Context, in *KeysRequest, opts ...grpc.CallOption) (*KeyInfos, error)
+	SnapshotRequirements(ctx context.Context, in *KeyList, opts ...grpc.CallOption) (*Requirements, error)
+	Certificate(ctx context.Context, in *CertificateRequest, opts ...grpc.CallOption) (*consensus.CommitCertificate, error)
+	Endorsers(ctx context.Context, in *EndorsersRequest, opts ...grpc.CallOption) (*EndorserInfos, error)
+	RetentionDryRun(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*RetentionReport, error)
+	AdminDrop(ctx context.Context, in *consensus.AdminDrop, opts ...grpc.CallOption) (*Empty, error)
+	Info(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NodeInfo, error)
+	RecoveryStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*RecoveryReport, error)
+	RetryRecovery(ctx context.Context, in *KeyList, opts ...grpc.CallOption) (*RecoveryReport, error)
+	GetStatus(ctx context.Context, in *Receipt, opts ...grpc.CallOption) (*QueryStatus, error)
+	ClusterStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NodeStatuses, error)
+	UnknownEmitters(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*UnknownEmitterList, error)
+	GetFaultProfile(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*FaultProfile, error)
+	SetFaultProfile(ctx context.Context, in *FaultProfile, opts ...grpc.CallOption) (*FaultProfile, error)
+}
+
+type endorserClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewEndorserClient(cc *grpc.ClientConn) EndorserClient {
+	return &endorserClient{cc}
+}
+
+func (c *endorserClient) Get(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Value, error) {
+	out := new(Value)
+	err := c.cc.Invoke(ctx, "/api.Endorser/Get", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) GetInt(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Integer, error) {
+	out := new(Integer)
+	err := c.cc.Invoke(ctx, "/api.Endorser/GetInt", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) Members(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Values, error) {
+	out := new(Values)
+	err := c.cc.Invoke(ctx, "/api.Endorser/Members", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) Contains(ctx context.Context, in *KeyValue, opts ...grpc.CallOption) (*Boolean, error) {
+	out := new(Boolean)
+	err := c.cc.Invoke(ctx, "/api.Endorser/Contains", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) Range(ctx context.Context, in *RangeRequest, opts ...grpc.CallOption) (*Values, error) {
+	out := new(Values)
+	err := c.cc.Invoke(ctx, "/api.Endorser/Range", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) Len(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Length, error) {
+	out := new(Length)
+	err := c.cc.Invoke(ctx, "/api.Endorser/Len", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) HGet(ctx context.Context, in *FieldRequest, opts ...grpc.CallOption) (*Value, error) {
+	out := new(Value)
+	err := c.cc.Invoke(ctx, "/api.Endorser/HGet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) HGetAll(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Fields, error) {
+	out := new(Fields)
+	err := c.cc.Invoke(ctx, "/api.Endorser/HGetAll", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) GetProvenance(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Provenance, error) {
+	out := new(Provenance)
+	err := c.cc.Invoke(ctx, "/api.Endorser/GetProvenance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) Submit(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*Receipt, error) {
 	out := new(Receipt)
 	err := c.cc.Invoke(ctx, "/api.Endorser/Submit", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	return out, nil
+}
+
+func (c *endorserClient) SubmitAndWait(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*QueryStatus, error) {
+	out := new(QueryStatus)
+	err := c.cc.Invoke(ctx, "/api.Endorser/SubmitAndWait", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) SubmitStream(ctx context.Context, opts ...grpc.CallOption) (Endorser_SubmitStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Endorser_serviceDesc.Streams[0], "/api.Endorser/SubmitStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &endorserSubmitStreamClient{stream}
+	return x, nil
+}
+
+type Endorser_SubmitStreamClient interface {
+	Send(*Transaction) error
+	Recv() (*Receipt, error)
+	grpc.ClientStream
+}
+
+type endorserSubmitStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *endorserSubmitStreamClient) Send(m *Transaction) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *endorserSubmitStreamClient) Recv() (*Receipt, error) {
+	m := new(Receipt)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *endorserClient) ReplayEvents(ctx context.Context, in *ReplayRequest, opts ...grpc.CallOption) (Endorser_ReplayEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Endorser_serviceDesc.Streams[1], "/api.Endorser/ReplayEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &endorserReplayEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Endorser_ReplayEventsClient interface {
+	Recv() (*consensus.CommitEvent, error)
+	grpc.ClientStream
+}
+
+type endorserReplayEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *endorserReplayEventsClient) Recv() (*consensus.CommitEvent, error) {
+	m := new(consensus.CommitEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *endorserClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Endorser_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Endorser_serviceDesc.Streams[2], "/api.Endorser/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &endorserWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Endorser_WatchClient interface {
+	Recv() (*KeyUpdate, error)
+	grpc.ClientStream
+}
+
+type endorserWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *endorserWatchClient) Recv() (*KeyUpdate, error) {
+	m := new(KeyUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *endorserClient) QuotaStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Quotas, error) {
+	out := new(Quotas)
+	err := c.cc.Invoke(ctx, "/api.Endorser/QuotaStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) Keys(ctx context.Context, in *KeysRequest, opts ...grpc.CallOption) (*KeyInfos, error) {
+	out := new(KeyInfos)
+	err := c.cc.Invoke(ctx, "/api.Endorser/Keys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) SnapshotRequirements(ctx context.Context, in *KeyList, opts ...grpc.CallOption) (*Requirements, error) {
+	out := new(Requirements)
+	err := c.cc.Invoke(ctx, "/api.Endorser/SnapshotRequirements", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) Certificate(ctx context.Context, in *CertificateRequest, opts ...grpc.CallOption) (*consensus.CommitCertificate, error) {
+	out := new(consensus.CommitCertificate)
+	err := c.cc.Invoke(ctx, "/api.Endorser/Certificate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) Endorsers(ctx context.Context, in *EndorsersRequest, opts ...grpc.CallOption) (*EndorserInfos, error) {
+	out := new(EndorserInfos)
+	err := c.cc.Invoke(ctx, "/api.Endorser/Endorsers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) RetentionDryRun(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*RetentionReport, error) {
+	out := new(RetentionReport)
+	err := c.cc.Invoke(ctx, "/api.Endorser/RetentionDryRun", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) AdminDrop(ctx context.Context, in *consensus.AdminDrop, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/api.Endorser/AdminDrop", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) Info(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NodeInfo, error) {
+	out := new(NodeInfo)
+	err := c.cc.Invoke(ctx, "/api.Endorser/Info", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) RecoveryStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*RecoveryReport, error) {
+	out := new(RecoveryReport)
+	err := c.cc.Invoke(ctx, "/api.Endorser/RecoveryStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) RetryRecovery(ctx context.Context, in *KeyList, opts ...grpc.CallOption) (*RecoveryReport, error) {
+	out := new(RecoveryReport)
+	err := c.cc.Invoke(ctx, "/api.Endorser/RetryRecovery", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) GetStatus(ctx context.Context, in *Receipt, opts ...grpc.CallOption) (*QueryStatus, error) {
+	out := new(QueryStatus)
+	err := c.cc.Invoke(ctx, "/api.Endorser/GetStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) ClusterStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NodeStatuses, error) {
+	out := new(NodeStatuses)
+	err := c.cc.Invoke(ctx, "/api.Endorser/ClusterStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) UnknownEmitters(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*UnknownEmitterList, error) {
+	out := new(UnknownEmitterList)
+	err := c.cc.Invoke(ctx, "/api.Endorser/UnknownEmitters", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) GetFaultProfile(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*FaultProfile, error) {
+	out := new(FaultProfile)
+	err := c.cc.Invoke(ctx, "/api.Endorser/GetFaultProfile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endorserClient) SetFaultProfile(ctx context.Context, in *FaultProfile, opts ...grpc.CallOption) (*FaultProfile, error) {
+	out := new(FaultProfile)
+	err := c.cc.Invoke(ctx, "/api.Endorser/SetFaultProfile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EndorserServer is the server API for Endorser service.
+type EndorserServer interface {
+	Get(context.Context, *Key) (*Value, error)
+	GetInt(context.Context, *Key) (*Integer, error)
+	Members(context.Context, *Key) (*Values, error)
+	Contains(context.Context, *KeyValue) (*Boolean, error)
+	Range(context.Context, *RangeRequest) (*Values, error)
+	Len(context.Context, *Key) (*Length, error)
+	HGet(context.Context, *FieldRequest) (*Value, error)
+	HGetAll(context.Context, *Key) (*Fields, error)
+	GetProvenance(context.Context, *Key) (*Provenance, error)
+	Submit(context.Context, *Transaction) (*Receipt, error)
+	SubmitAndWait(context.Context, *Transaction) (*QueryStatus, error)
+	SubmitStream(Endorser_SubmitStreamServer) error
+	ReplayEvents(*ReplayRequest, Endorser_ReplayEventsServer) error
+	Watch(*WatchRequest, Endorser_WatchServer) error
+	QuotaStatus(context.Context, *Empty) (*Quotas, error)
+	Keys(context.Context, *KeysRequest) (*KeyInfos, error)
+	SnapshotRequirements(context.Context, *KeyList) (*Requirements, error)
+	Certificate(context.Context, *CertificateRequest) (*consensus.CommitCertificate, error)
+	Endorsers(context.Context, *EndorsersRequest) (*EndorserInfos, error)
+	RetentionDryRun(context.Context, *Empty) (*RetentionReport, error)
+	AdminDrop(context.Context, *consensus.AdminDrop) (*Empty, error)
+	Info(context.Context, *Empty) (*NodeInfo, error)
+	RecoveryStatus(context.Context, *Empty) (*RecoveryReport, error)
+	RetryRecovery(context.Context, *KeyList) (*RecoveryReport, error)
+	GetStatus(context.Context, *Receipt) (*QueryStatus, error)
+	ClusterStatus(context.Context, *Empty) (*NodeStatuses, error)
+	UnknownEmitters(context.Context, *Empty) (*UnknownEmitterList, error)
+	GetFaultProfile(context.Context, *Empty) (*FaultProfile, error)
+	SetFaultProfile(context.Context, *FaultProfile) (*FaultProfile, error)
+}
+
+func RegisterEndorserServer(s *grpc.Server, srv EndorserServer) {
+	s.RegisterService(&_Endorser_serviceDesc, srv)
+}
+
+func _Endorser_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).Get(ctx, req.(*Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_GetInt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).GetInt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/GetInt",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).GetInt(ctx, req.(*Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_Members_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).Members(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/Members",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).Members(ctx, req.(*Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_Contains_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).Contains(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/Contains",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).Contains(ctx, req.(*KeyValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_Range_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).Range(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/Range",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).Range(ctx, req.(*RangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_Len_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).Len(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/Len",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).Len(ctx, req.(*Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_HGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FieldRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).HGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/HGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).HGet(ctx, req.(*FieldRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_HGetAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).HGetAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/HGetAll",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).HGetAll(ctx, req.(*Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_GetProvenance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).GetProvenance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/GetProvenance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).GetProvenance(ctx, req.(*Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_Submit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Transaction)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).Submit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/Submit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).Submit(ctx, req.(*Transaction))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_SubmitAndWait_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Transaction)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).SubmitAndWait(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/SubmitAndWait",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).SubmitAndWait(ctx, req.(*Transaction))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_SubmitStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EndorserServer).SubmitStream(&endorserSubmitStreamServer{stream})
+}
+
+type Endorser_SubmitStreamServer interface {
+	Send(*Receipt) error
+	Recv() (*Transaction, error)
+	grpc.ServerStream
+}
+
+type endorserSubmitStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *endorserSubmitStreamServer) Send(m *Receipt) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *endorserSubmitStreamServer) Recv() (*Transaction, error) {
+	m := new(Transaction)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
-// EndorserServer is the server API for Endorser service.
-type EndorserServer interface {
-	Get(context.Context, *Key) (*Value, error)
-	Members(context.Context, *Key) (*Values, error)
-	Contains(context.Context, *KeyValue) (*Boolean, error)
-	Submit(context.Context, *Transaction) (*Receipt, error)
+func _Endorser_ReplayEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReplayRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EndorserServer).ReplayEvents(m, &endorserReplayEventsServer{stream})
 }
 
-func RegisterEndorserServer(s *grpc.Server, srv EndorserServer) {
-	s.RegisterService(&_Endorser_serviceDesc, srv)
+type Endorser_ReplayEventsServer interface {
+	Send(*consensus.CommitEvent) error
+	grpc.ServerStream
 }
 
-func _Endorser_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Key)
+type endorserReplayEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *endorserReplayEventsServer) Send(m *consensus.CommitEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Endorser_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EndorserServer).Watch(m, &endorserWatchServer{stream})
+}
+
+type Endorser_WatchServer interface {
+	Send(*KeyUpdate) error
+	grpc.ServerStream
+}
+
+type endorserWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *endorserWatchServer) Send(m *KeyUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Endorser_QuotaStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(EndorserServer).Get(ctx, in)
+		return srv.(EndorserServer).QuotaStatus(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Endorser/Get",
+		FullMethod: "/api.Endorser/QuotaStatus",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EndorserServer).Get(ctx, req.(*Key))
+		return srv.(EndorserServer).QuotaStatus(ctx, req.(*Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Endorser_Members_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Key)
+func _Endorser_Keys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeysRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(EndorserServer).Members(ctx, in)
+		return srv.(EndorserServer).Keys(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Endorser/Members",
+		FullMethod: "/api.Endorser/Keys",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EndorserServer).Members(ctx, req.(*Key))
+		return srv.(EndorserServer).Keys(ctx, req.(*KeysRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Endorser_Contains_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(KeyValue)
+func _Endorser_SnapshotRequirements_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyList)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(EndorserServer).Contains(ctx, in)
+		return srv.(EndorserServer).SnapshotRequirements(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Endorser/Contains",
+		FullMethod: "/api.Endorser/SnapshotRequirements",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EndorserServer).Contains(ctx, req.(*KeyValue))
+		return srv.(EndorserServer).SnapshotRequirements(ctx, req.(*KeyList))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Endorser_Submit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Transaction)
+func _Endorser_Certificate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CertificateRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(EndorserServer).Submit(ctx, in)
+		return srv.(EndorserServer).Certificate(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Endorser/Submit",
+		FullMethod: "/api.Endorser/Certificate",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(EndorserServer).Submit(ctx, req.(*Transaction))
+		return srv.(EndorserServer).Certificate(ctx, req.(*CertificateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_Endorsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EndorsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).Endorsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/Endorsers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).Endorsers(ctx, req.(*EndorsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_RetentionDryRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).RetentionDryRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/RetentionDryRun",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).RetentionDryRun(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_AdminDrop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(consensus.AdminDrop)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).AdminDrop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/AdminDrop",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).AdminDrop(ctx, req.(*consensus.AdminDrop))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_Info_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).Info(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/Info",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).Info(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_RecoveryStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).RecoveryStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/RecoveryStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).RecoveryStatus(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_RetryRecovery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyList)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).RetryRecovery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/RetryRecovery",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).RetryRecovery(ctx, req.(*KeyList))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Receipt)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/GetStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).GetStatus(ctx, req.(*Receipt))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_ClusterStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).ClusterStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/ClusterStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).ClusterStatus(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_UnknownEmitters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).UnknownEmitters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/UnknownEmitters",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).UnknownEmitters(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_GetFaultProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).GetFaultProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/GetFaultProfile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).GetFaultProfile(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Endorser_SetFaultProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FaultProfile)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndorserServer).SetFaultProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Endorser/SetFaultProfile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndorserServer).SetFaultProfile(ctx, req.(*FaultProfile))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -504,6 +3194,10 @@ var _Endorser_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Get",
 			Handler:    _Endorser_Get_Handler,
 		},
+		{
+			MethodName: "GetInt",
+			Handler:    _Endorser_GetInt_Handler,
+		},
 		{
 			MethodName: "Members",
 			Handler:    _Endorser_Members_Handler,
@@ -512,46 +3206,255 @@ var _Endorser_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Contains",
 			Handler:    _Endorser_Contains_Handler,
 		},
+		{
+			MethodName: "Range",
+			Handler:    _Endorser_Range_Handler,
+		},
+		{
+			MethodName: "Len",
+			Handler:    _Endorser_Len_Handler,
+		},
+		{
+			MethodName: "HGet",
+			Handler:    _Endorser_HGet_Handler,
+		},
+		{
+			MethodName: "HGetAll",
+			Handler:    _Endorser_HGetAll_Handler,
+		},
+		{
+			MethodName: "GetProvenance",
+			Handler:    _Endorser_GetProvenance_Handler,
+		},
 		{
 			MethodName: "Submit",
 			Handler:    _Endorser_Submit_Handler,
 		},
+		{
+			MethodName: "SubmitAndWait",
+			Handler:    _Endorser_SubmitAndWait_Handler,
+		},
+		{
+			MethodName: "QuotaStatus",
+			Handler:    _Endorser_QuotaStatus_Handler,
+		},
+		{
+			MethodName: "Keys",
+			Handler:    _Endorser_Keys_Handler,
+		},
+		{
+			MethodName: "SnapshotRequirements",
+			Handler:    _Endorser_SnapshotRequirements_Handler,
+		},
+		{
+			MethodName: "Certificate",
+			Handler:    _Endorser_Certificate_Handler,
+		},
+		{
+			MethodName: "Endorsers",
+			Handler:    _Endorser_Endorsers_Handler,
+		},
+		{
+			MethodName: "RetentionDryRun",
+			Handler:    _Endorser_RetentionDryRun_Handler,
+		},
+		{
+			MethodName: "AdminDrop",
+			Handler:    _Endorser_AdminDrop_Handler,
+		},
+		{
+			MethodName: "Info",
+			Handler:    _Endorser_Info_Handler,
+		},
+		{
+			MethodName: "RecoveryStatus",
+			Handler:    _Endorser_RecoveryStatus_Handler,
+		},
+		{
+			MethodName: "RetryRecovery",
+			Handler:    _Endorser_RetryRecovery_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _Endorser_GetStatus_Handler,
+		},
+		{
+			MethodName: "ClusterStatus",
+			Handler:    _Endorser_ClusterStatus_Handler,
+		},
+		{
+			MethodName: "UnknownEmitters",
+			Handler:    _Endorser_UnknownEmitters_Handler,
+		},
+		{
+			MethodName: "GetFaultProfile",
+			Handler:    _Endorser_GetFaultProfile_Handler,
+		},
+		{
+			MethodName: "SetFaultProfile",
+			Handler:    _Endorser_SetFaultProfile_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubmitStream",
+			Handler:       _Endorser_SubmitStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ReplayEvents",
+			Handler:       _Endorser_ReplayEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _Endorser_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/api.proto",
 }
 
-func init() { proto.RegisterFile("api/api.proto", fileDescriptor_api_71fcc8955c74af3a) }
-
-var fileDescriptor_api_71fcc8955c74af3a = []byte{
-	// 450 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x91, 0x51, 0x6b, 0xd4, 0x40,
-	0x10, 0xc7, 0x2f, 0x97, 0xf6, 0x12, 0x27, 0x57, 0xa8, 0x4b, 0xd1, 0x10, 0x28, 0x1e, 0xeb, 0xcb,
-	0x29, 0x92, 0x83, 0x28, 0x22, 0x3e, 0x2a, 0x55, 0xf4, 0x10, 0x21, 0x2d, 0x7d, 0xdf, 0x24, 0x63,
-	0x59, 0x4c, 0x76, 0xe3, 0xee, 0xa6, 0x90, 0xef, 0xe3, 0x07, 0xf0, 0x23, 0x4a, 0x76, 0x93, 0x33,
-	0xda, 0x3e, 0xf9, 0x36, 0xc3, 0xfc, 0xf6, 0x3f, 0xb3, 0xff, 0x3f, 0x9c, 0xb0, 0x96, 0xef, 0x58,
-	0xcb, 0xd3, 0x56, 0x49, 0x23, 0x89, 0xcf, 0x5a, 0x9e, 0x24, 0xa5, 0x14, 0x1a, 0x85, 0xee, 0xf4,
-	0x4e, 0x1b, 0xd5, 0x95, 0xa6, 0x53, 0xa8, 0x1d, 0x90, 0x3c, 0xb9, 0x91, 0xf2, 0xa6, 0xc6, 0x9d,
-	0xed, 0x8a, 0xee, 0xdb, 0xce, 0xf0, 0x06, 0xb5, 0x61, 0x4d, 0xeb, 0x00, 0xfa, 0x18, 0xfc, 0x3d,
-	0xf6, 0xe4, 0x14, 0xfc, 0xef, 0xd8, 0xc7, 0xde, 0xc6, 0xdb, 0x3e, 0xc8, 0x87, 0x92, 0x7e, 0x82,
-	0xe3, 0x6b, 0x56, 0x77, 0x48, 0x5e, 0x40, 0x70, 0x8b, 0x4a, 0x73, 0x29, 0xec, 0x38, 0xca, 0x48,
-	0x7a, 0x58, 0x98, 0x5e, 0xbb, 0x49, 0x3e, 0x21, 0x84, 0xc0, 0x51, 0xc5, 0x0c, 0x8b, 0x97, 0x1b,
-	0x6f, 0xbb, 0xce, 0x6d, 0x4d, 0x33, 0x08, 0xf7, 0xd8, 0x3b, 0xb5, 0x3b, 0x8b, 0xc8, 0x19, 0x1c,
-	0xdf, 0x0e, 0xa3, 0xf1, 0x89, 0x6b, 0xe8, 0x67, 0x58, 0xd9, 0x07, 0xfa, 0xbf, 0xf7, 0xfb, 0x87,
-	0xfd, 0x4f, 0x21, 0x78, 0x27, 0x65, 0x8d, 0x4c, 0x90, 0x18, 0x82, 0xc2, 0x95, 0x56, 0x2c, 0xcc,
-	0xa7, 0x96, 0xfe, 0x5a, 0x42, 0x74, 0xa5, 0x98, 0xd0, 0xac, 0x34, 0x83, 0xd0, 0x23, 0x58, 0xb5,
-	0xb2, 0xe6, 0xe5, 0x74, 0xeb, 0xd8, 0x91, 0xd7, 0x10, 0x56, 0xc8, 0xaa, 0x9a, 0x0b, 0x77, 0x71,
-	0x94, 0x25, 0xa9, 0x33, 0x39, 0x9d, 0x4c, 0x4e, 0xaf, 0x26, 0x93, 0xf3, 0x03, 0x4b, 0x3e, 0xc0,
-	0x5a, 0xe1, 0x8f, 0x8e, 0x2b, 0x6c, 0x50, 0x18, 0x1d, 0xfb, 0x1b, 0x7f, 0x1b, 0x65, 0x34, 0x1d,
-	0xc2, 0x9c, 0xed, 0x4d, 0xf3, 0x19, 0x74, 0x21, 0x8c, 0xea, 0xf3, 0xbf, 0xde, 0x91, 0x57, 0x00,
-	0xb2, 0x45, 0xc5, 0x06, 0x58, 0xc7, 0x47, 0x56, 0xe5, 0x6c, 0xe6, 0xc8, 0xd7, 0x69, 0x98, 0xcf,
-	0xb8, 0xe4, 0x12, 0x1e, 0xde, 0x11, 0xbe, 0x27, 0x8b, 0xed, 0x3c, 0x8b, 0xfb, 0x9d, 0x76, 0xc0,
-	0xdb, 0xe5, 0x1b, 0x8f, 0x9e, 0x43, 0x90, 0x63, 0x89, 0xbc, 0x35, 0x83, 0xed, 0x5d, 0xc7, 0xab,
-	0x51, 0xcb, 0xd6, 0xd9, 0x4f, 0x0f, 0xc2, 0x0b, 0x51, 0x49, 0xa5, 0x51, 0x91, 0x73, 0xf0, 0x3f,
-	0xa2, 0x21, 0xa1, 0xfd, 0xef, 0x1e, 0xfb, 0x04, 0x6c, 0x65, 0x33, 0xa6, 0x0b, 0x42, 0x21, 0xf8,
-	0x82, 0x4d, 0x81, 0x4a, 0xcf, 0x90, 0xe8, 0x0f, 0xa2, 0xe9, 0x82, 0x3c, 0x83, 0xf0, 0xbd, 0x14,
-	0x86, 0x71, 0xa1, 0xc9, 0xc9, 0x04, 0xd9, 0x69, 0xb2, 0xb6, 0xed, 0x18, 0x32, 0x5d, 0x90, 0xe7,
-	0xb0, 0xba, 0xec, 0x8a, 0x86, 0x1b, 0x72, 0xfa, 0xaf, 0xc1, 0x23, 0x3b, 0x1e, 0x4e, 0x17, 0xc5,
-	0xca, 0xc6, 0xf6, 0xf2, 0x77, 0x00, 0x00, 0x00, 0xff, 0xff, 0x9c, 0x9e, 0xb5, 0x49, 0x5b, 0x03,
-	0x00, 0x00,
+func init() { proto.RegisterFile("api/api.proto", fileDescriptor_api_1b40cafcd4234784) }
+
+var fileDescriptor_api_1b40cafcd4234784 = []byte{
+	// 2186 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0x4f, 0x73, 0x1b, 0xb7,
+	0x15, 0x27, 0x45, 0x8a, 0x7f, 0x9e, 0x48, 0x4b, 0x46, 0x1c, 0x87, 0xc3, 0x71, 0x1c, 0x0f, 0xdc,
+	0x24, 0x72, 0xe3, 0x4a, 0x1e, 0x27, 0x75, 0xe3, 0xa6, 0xed, 0x8c, 0x63, 0xcb, 0xaa, 0x6b, 0x25,
+	0xb6, 0x57, 0x76, 0xd2, 0x4b, 0xab, 0x81, 0xb8, 0x4f, 0xf4, 0xd6, 0x4b, 0xec, 0x1a, 0xc0, 0xca,
+	0x62, 0x4f, 0x3d, 0xf5, 0xd0, 0x7e, 0x95, 0x9e, 0x7b, 0xec, 0x4c, 0xef, 0xfd, 0x0c, 0xfd, 0x1a,
+	0x3d, 0xb6, 0x83, 0x07, 0x60, 0xb9, 0x14, 0x69, 0x2b, 0xee, 0x24, 0x37, 0xbc, 0x87, 0xdf, 0x3e,
+	0x3c, 0x3c, 0xbc, 0xbf, 0x0b, 0x7d, 0x91, 0x27, 0xdb, 0x22, 0x4f, 0xb6, 0x72, 0x95, 0x99, 0x8c,
+	0x35, 0x44, 0x9e, 0x0c, 0x87, 0xa3, 0x4c, 0x6a, 0x94, 0xba, 0xd0, 0xdb, 0xda, 0xa8, 0x62, 0x64,
+	0x0a, 0x85, 0xda, 0x01, 0x86, 0x1f, 0x8c, 0xb3, 0x6c, 0x9c, 0xe2, 0x36, 0x51, 0x87, 0xc5, 0xd1,
+	0xb6, 0x49, 0x26, 0xa8, 0x8d, 0x98, 0xe4, 0x0e, 0xc0, 0x6f, 0x43, 0xe3, 0x21, 0x4e, 0xd9, 0x06,
+	0x34, 0x5e, 0xe0, 0x74, 0x50, 0xbf, 0x52, 0xdf, 0xec, 0x46, 0x76, 0xc9, 0xae, 0xc0, 0x9a, 0xce,
+	0x71, 0x54, 0xa4, 0xc2, 0x24, 0xc7, 0x38, 0x58, 0xb9, 0x52, 0xdf, 0xec, 0x44, 0x55, 0x16, 0x7f,
+	0x00, 0xab, 0xdf, 0x88, 0xb4, 0x40, 0x76, 0x1d, 0xda, 0xc7, 0xa8, 0x74, 0x92, 0x49, 0x12, 0xb0,
+	0x76, 0x93, 0x6d, 0x95, 0x2a, 0x6d, 0x7d, 0xe3, 0x76, 0xa2, 0x00, 0x61, 0x0c, 0x9a, 0xb1, 0x30,
+	0x82, 0x24, 0xf6, 0x22, 0x5a, 0xf3, 0xbf, 0xd7, 0x01, 0x1e, 0xab, 0xec, 0x18, 0xa5, 0x90, 0xa3,
+	0xef, 0x41, 0xa0, 0xe5, 0x15, 0x45, 0x12, 0x0f, 0x1a, 0x74, 0x21, 0x5a, 0xb3, 0x01, 0xb4, 0x71,
+	0x92, 0x18, 0x83, 0x6a, 0xd0, 0x24, 0x76, 0x20, 0xd9, 0xe7, 0xd0, 0x1d, 0x65, 0x13, 0x22, 0xe2,
+	0xc1, 0x2a, 0x9d, 0x38, 0xdc, 0x72, 0x96, 0xdb, 0x0a, 0x96, 0xdb, 0x7a, 0x1a, 0x2c, 0x17, 0xcd,
+	0xc0, 0xfc, 0x29, 0x74, 0x1e, 0xe2, 0xd4, 0x99, 0x61, 0xd1, 0x86, 0x17, 0x60, 0xf5, 0xd8, 0x6e,
+	0x79, 0xd5, 0x1c, 0x71, 0xda, 0xb2, 0x8d, 0x45, 0xcb, 0x7e, 0x05, 0xed, 0x07, 0xd2, 0xe0, 0x18,
+	0xd5, 0x4c, 0x84, 0x15, 0xcb, 0x82, 0x88, 0x8a, 0x81, 0x56, 0xce, 0x34, 0x10, 0xff, 0x0d, 0xb4,
+	0x48, 0x43, 0xfd, 0x7f, 0x1b, 0xb6, 0x51, 0xbe, 0x54, 0x0a, 0xbd, 0x48, 0xc8, 0x31, 0x46, 0xf8,
+	0xb2, 0x40, 0x6d, 0x96, 0x5f, 0x5a, 0x1b, 0xa1, 0x0c, 0x69, 0xd6, 0x88, 0x1c, 0x61, 0x65, 0x69,
+	0x93, 0xe5, 0x74, 0xdb, 0x46, 0x44, 0xeb, 0xd3, 0x86, 0x68, 0x2e, 0x1a, 0xe2, 0x6b, 0x68, 0xed,
+	0xa1, 0x1c, 0x9b, 0xe7, 0xec, 0x22, 0xb4, 0x52, 0x5a, 0xd1, 0x51, 0xcd, 0xc8, 0x53, 0x6f, 0x69,
+	0x89, 0xdf, 0x42, 0xef, 0x7e, 0x82, 0x69, 0xfc, 0x46, 0xed, 0x8f, 0x2c, 0x82, 0xa4, 0x75, 0x23,
+	0x47, 0x7c, 0x87, 0x27, 0xfb, 0x1d, 0xb4, 0x48, 0xf2, 0xdb, 0xda, 0xf8, 0x43, 0x68, 0xd1, 0x11,
+	0x9a, 0xac, 0xbc, 0x76, 0xb3, 0xbf, 0x65, 0xa3, 0x3b, 0xf8, 0x54, 0xe4, 0x37, 0xf9, 0x55, 0x68,
+	0x7f, 0x99, 0x65, 0x29, 0x0a, 0x69, 0xdd, 0xf8, 0xd0, 0x2d, 0x49, 0x7e, 0x27, 0x0a, 0x24, 0xff,
+	0xd7, 0x0a, 0xac, 0x3d, 0x55, 0x42, 0x6a, 0x31, 0x32, 0x56, 0xf6, 0x45, 0x68, 0xe5, 0x59, 0x9a,
+	0x8c, 0xc2, 0x05, 0x3d, 0xc5, 0x6e, 0x41, 0x27, 0x46, 0x11, 0xa7, 0x89, 0xc4, 0xc1, 0xca, 0x99,
+	0xde, 0x5e, 0x62, 0xd9, 0x7d, 0xe8, 0x29, 0x7c, 0x59, 0x24, 0x0a, 0x27, 0x28, 0x8d, 0x1e, 0x34,
+	0x48, 0x63, 0x4e, 0x1a, 0x57, 0xce, 0xdd, 0x8a, 0x2a, 0xa0, 0x1d, 0x69, 0xd4, 0x34, 0x9a, 0xfb,
+	0x8e, 0x7d, 0x06, 0x90, 0xe5, 0xa8, 0x84, 0x05, 0xeb, 0x41, 0x93, 0xa4, 0x5c, 0xa8, 0x18, 0xe9,
+	0x51, 0xd8, 0x8c, 0x2a, 0x38, 0x36, 0x84, 0x8e, 0xb6, 0xcf, 0x26, 0x47, 0x48, 0x31, 0xda, 0x8c,
+	0x4a, 0x7a, 0xb8, 0x0f, 0xe7, 0x17, 0x0e, 0x5d, 0xf2, 0xb8, 0x9b, 0xd5, 0x78, 0x5c, 0xfe, 0x30,
+	0x0e, 0xf0, 0xf3, 0x95, 0xcf, 0xeb, 0xfc, 0x11, 0xb4, 0x23, 0x1c, 0x61, 0x92, 0x9b, 0x32, 0x9d,
+	0xd4, 0x2b, 0xe9, 0xa4, 0xaa, 0xcf, 0xca, 0xbc, 0x3e, 0xd6, 0x8b, 0x50, 0xa9, 0x4c, 0xf9, 0xfc,
+	0xe3, 0x08, 0xfe, 0x4b, 0xe8, 0x47, 0x98, 0xa7, 0x62, 0x1a, 0xdc, 0xcf, 0x86, 0x4a, 0x62, 0xbf,
+	0x77, 0x3e, 0xed, 0x08, 0xfb, 0x6c, 0x47, 0x59, 0x9a, 0x66, 0xaf, 0x7c, 0xd2, 0xf5, 0x14, 0xff,
+	0x08, 0x7a, 0xdf, 0x0a, 0x33, 0x7a, 0x1e, 0xbe, 0xb6, 0xcf, 0xab, 0xf0, 0x28, 0x39, 0x29, 0x9f,
+	0x97, 0x28, 0x3e, 0x85, 0xee, 0x43, 0x9c, 0x3e, 0xcb, 0x63, 0x61, 0x96, 0x25, 0xa5, 0xb7, 0x8a,
+	0x98, 0xd7, 0x25, 0xd2, 0x58, 0x65, 0x79, 0x8e, 0x31, 0xc5, 0x6c, 0x33, 0x0a, 0x24, 0x6f, 0xc3,
+	0xea, 0xce, 0x24, 0x37, 0x53, 0x5b, 0x1b, 0x9e, 0x14, 0x99, 0x11, 0xaf, 0x53, 0x92, 0xe4, 0x6a,
+	0x8c, 0x7d, 0x92, 0xa0, 0xb5, 0x35, 0x47, 0x9a, 0x4c, 0x12, 0xe3, 0x93, 0x84, 0x23, 0xf8, 0x75,
+	0x68, 0x91, 0x28, 0xcd, 0x38, 0xb4, 0x5e, 0xd2, 0x6a, 0x50, 0x27, 0x9f, 0x01, 0xf2, 0x3c, 0xda,
+	0x8c, 0xfc, 0x0e, 0x8f, 0x61, 0xed, 0x21, 0x4e, 0xf5, 0x19, 0x36, 0xa2, 0x2b, 0xa0, 0x11, 0x49,
+	0xaa, 0xbd, 0x91, 0x03, 0xc9, 0xae, 0x42, 0x3f, 0x57, 0x78, 0x9c, 0xe0, 0xab, 0x83, 0x99, 0x32,
+	0xfd, 0xa8, 0xe7, 0x99, 0x7b, 0xa4, 0xd3, 0x5f, 0xeb, 0xd0, 0x7e, 0x88, 0xd3, 0x07, 0xf2, 0x28,
+	0xfb, 0x3e, 0x2c, 0x6c, 0xa6, 0x39, 0x06, 0x0b, 0xdb, 0xb5, 0xe5, 0xe9, 0xe4, 0x8f, 0xe8, 0xcd,
+	0x4b, 0x6b, 0xab, 0xb2, 0xd7, 0x81, 0xdc, 0xbf, 0x1b, 0x05, 0x92, 0x5f, 0x87, 0x8e, 0x57, 0x46,
+	0xb3, 0x2b, 0xd0, 0x7c, 0x81, 0xd3, 0x60, 0xa1, 0x5e, 0xc8, 0x26, 0x76, 0x33, 0xa2, 0x1d, 0xfe,
+	0x3e, 0xa9, 0xbe, 0x97, 0x68, 0x72, 0xeb, 0x12, 0xdc, 0xf5, 0xdb, 0x7f, 0xab, 0x43, 0xaf, 0x1a,
+	0x4b, 0x6c, 0xf7, 0x54, 0xd4, 0x3b, 0xc9, 0x57, 0x49, 0x72, 0x15, 0x78, 0x56, 0xd8, 0xff, 0x30,
+	0x41, 0xba, 0x09, 0xec, 0x2e, 0x2a, 0x93, 0x1c, 0x25, 0x23, 0x61, 0xca, 0xaa, 0xb4, 0x24, 0x5e,
+	0xf9, 0x47, 0xb0, 0xb1, 0x23, 0xe3, 0x4c, 0x69, 0x54, 0xfa, 0x4d, 0xb8, 0xbf, 0xd4, 0xa1, 0x17,
+	0x80, 0xf4, 0xc0, 0x95, 0xbe, 0xa1, 0x3e, 0xdf, 0x37, 0x5c, 0x06, 0x18, 0x65, 0x32, 0x4e, 0x5c,
+	0x22, 0x5b, 0x21, 0x2b, 0x56, 0x38, 0xb6, 0x6c, 0x1c, 0x25, 0x72, 0x8c, 0x2a, 0x57, 0x89, 0x34,
+	0xfe, 0x85, 0xab, 0x2c, 0x2b, 0x41, 0x8c, 0xc7, 0x0a, 0xc7, 0xc2, 0xf8, 0x68, 0xea, 0x44, 0x15,
+	0x0e, 0xff, 0x3d, 0xf4, 0xab, 0xba, 0x68, 0xb6, 0x0d, 0x5d, 0x0c, 0xb7, 0xf0, 0x4f, 0x71, 0x9e,
+	0x9e, 0xa2, 0x0a, 0x8b, 0x66, 0x18, 0x76, 0x09, 0xba, 0x23, 0x67, 0x20, 0x1f, 0x6d, 0x9d, 0x68,
+	0xc6, 0xe0, 0x5f, 0xc0, 0x7a, 0x84, 0x06, 0xa5, 0xd5, 0xf7, 0xb1, 0xab, 0x0e, 0xaf, 0x0b, 0x99,
+	0x0d, 0x68, 0x88, 0x31, 0xfa, 0x80, 0xb5, 0x4b, 0x2e, 0x01, 0x76, 0x4e, 0xf2, 0x44, 0x61, 0xbc,
+	0xbc, 0x85, 0x9c, 0x49, 0x5a, 0x99, 0x93, 0x74, 0x0b, 0x3a, 0x93, 0x2c, 0x76, 0x1a, 0x35, 0xce,
+	0xae, 0x3f, 0x01, 0xcb, 0x65, 0x45, 0xd9, 0x08, 0xf3, 0x4c, 0x19, 0x76, 0x03, 0x3a, 0x54, 0xd4,
+	0x12, 0x0c, 0xd6, 0xb8, 0xe0, 0x1d, 0x73, 0xee, 0x52, 0x51, 0x89, 0x62, 0xd7, 0xa0, 0x8d, 0x4e,
+	0x69, 0x5f, 0x71, 0xd7, 0x9d, 0xf9, 0xca, 0x8b, 0x44, 0x61, 0x9f, 0xff, 0xa3, 0x01, 0x9d, 0xaf,
+	0xb3, 0x18, 0xc9, 0x0b, 0x86, 0xd0, 0x49, 0x62, 0x2b, 0xd3, 0x84, 0x3b, 0x96, 0xb4, 0xf5, 0x90,
+	0x6a, 0xc0, 0x77, 0x67, 0xc1, 0x7d, 0x09, 0xba, 0xe6, 0xb9, 0x42, 0xfd, 0x3c, 0x4b, 0x63, 0x9f,
+	0x49, 0x66, 0x0c, 0xf6, 0x49, 0x45, 0xfb, 0x66, 0x45, 0x19, 0xa7, 0x34, 0xbd, 0xe4, 0x4c, 0xf1,
+	0x0f, 0x60, 0x6d, 0x22, 0x4e, 0x0e, 0x4c, 0x32, 0xc1, 0xac, 0x30, 0x94, 0x03, 0x1a, 0x11, 0x4c,
+	0xc4, 0xc9, 0x53, 0xc7, 0x61, 0x1f, 0xc2, 0x39, 0x0b, 0xa8, 0x94, 0xd6, 0x16, 0x1d, 0xd8, 0x9f,
+	0x88, 0x93, 0xb2, 0xa4, 0x6a, 0xf6, 0x23, 0x07, 0xa3, 0x10, 0x3a, 0xa0, 0x2c, 0xd3, 0xa6, 0x2c,
+	0xd3, 0x9b, 0x88, 0x13, 0x6a, 0x3a, 0xf6, 0x6d, 0xb6, 0xb9, 0x3c, 0x57, 0xa3, 0x3b, 0xce, 0xb5,
+	0xe7, 0xab, 0xf1, 0x11, 0x0a, 0x1a, 0x35, 0x06, 0x5d, 0xda, 0x2d, 0x69, 0x6b, 0x8e, 0x23, 0x91,
+	0xa4, 0x85, 0xc2, 0x01, 0x38, 0x73, 0x78, 0x92, 0xee, 0x80, 0x93, 0x4c, 0x4d, 0x0f, 0x28, 0xf9,
+	0xaf, 0xd1, 0xc1, 0xe0, 0x58, 0xcf, 0x6c, 0x09, 0xb8, 0x0a, 0x7d, 0x0f, 0x38, 0x2c, 0xe2, 0x31,
+	0x9a, 0x41, 0xcf, 0xeb, 0x46, 0xcc, 0x2f, 0x89, 0xc7, 0x3e, 0x86, 0x75, 0x0f, 0x52, 0xf8, 0x07,
+	0x1c, 0xd9, 0xc8, 0xe9, 0x13, 0xec, 0x9c, 0x63, 0x47, 0x9e, 0xcb, 0x7f, 0x01, 0x30, 0x33, 0xa5,
+	0x0d, 0x76, 0x29, 0x26, 0x18, 0x82, 0xdd, 0xae, 0xed, 0x35, 0x9c, 0x53, 0x62, 0x88, 0xdf, 0x92,
+	0xe6, 0xff, 0xa9, 0xc3, 0xb9, 0x08, 0x47, 0xd9, 0x31, 0xaa, 0xa9, 0x77, 0x37, 0xdb, 0x7b, 0x29,
+	0x14, 0x2f, 0x66, 0xa9, 0xc0, 0x93, 0x76, 0x27, 0x47, 0x19, 0x27, 0x72, 0x4c, 0x2e, 0xd0, 0x8f,
+	0x02, 0x69, 0x77, 0x14, 0x1a, 0x65, 0xdf, 0xb8, 0xe1, 0xaa, 0xa5, 0x27, 0xad, 0x73, 0xe8, 0x62,
+	0x34, 0x42, 0xad, 0xe9, 0xfd, 0xed, 0xde, 0x8c, 0x41, 0x16, 0x76, 0x66, 0xd3, 0xa1, 0xdf, 0x09,
+	0x34, 0xbb, 0x06, 0x1b, 0xfe, 0x60, 0xfb, 0xdc, 0x32, 0x91, 0x63, 0xf7, 0xd8, 0xcd, 0x68, 0xdd,
+	0xf3, 0x1f, 0x79, 0x36, 0xbb, 0x09, 0x3d, 0xdb, 0xc0, 0x1d, 0xa4, 0x68, 0x8c, 0xcd, 0x19, 0xed,
+	0x8a, 0x9f, 0xdd, 0x43, 0x11, 0xef, 0x11, 0x3f, 0x5a, 0x8b, 0xcb, 0xb5, 0xe6, 0x7f, 0xaa, 0x03,
+	0xcc, 0xf6, 0x96, 0x44, 0xf6, 0x10, 0x3a, 0xc2, 0x18, 0x9c, 0xe4, 0x46, 0xfb, 0xeb, 0x96, 0xf4,
+	0xf2, 0xde, 0x87, 0x6d, 0x41, 0xd3, 0x7a, 0xee, 0xa0, 0x79, 0x66, 0xbc, 0x13, 0x8e, 0xff, 0x79,
+	0x05, 0xd6, 0x9e, 0x14, 0xa8, 0xa6, 0xfb, 0x46, 0x98, 0x42, 0xfb, 0xa9, 0xc2, 0x84, 0xd7, 0x73,
+	0x04, 0xe3, 0xd0, 0xf3, 0x99, 0xce, 0xd5, 0x26, 0xa7, 0xcb, 0x1c, 0x6f, 0xae, 0xdb, 0x6d, 0xbc,
+	0x45, 0xb7, 0x7b, 0x0b, 0x3a, 0x0a, 0x75, 0x96, 0x1e, 0xfb, 0xc4, 0x7c, 0xc6, 0x77, 0x01, 0x6b,
+	0xdf, 0x5b, 0xe4, 0x79, 0x9a, 0xf8, 0x51, 0xb2, 0x13, 0x05, 0xd2, 0x7a, 0xbf, 0x5d, 0x4e, 0x0f,
+	0x9c, 0x7d, 0x5a, 0x74, 0x13, 0x20, 0xd6, 0x0e, 0x19, 0x29, 0x94, 0xa3, 0x76, 0xa5, 0x1c, 0x7d,
+	0x01, 0x3d, 0x9b, 0x83, 0x9c, 0x19, 0x50, 0xb3, 0x4f, 0x60, 0x55, 0x66, 0x71, 0x99, 0xee, 0xde,
+	0xad, 0x94, 0xc7, 0x19, 0x2e, 0x72, 0x18, 0xfe, 0xef, 0x3a, 0x9c, 0x7b, 0x26, 0x5f, 0xc8, 0xec,
+	0x95, 0xdc, 0xf1, 0x35, 0xeb, 0x4d, 0x79, 0x6c, 0x08, 0x9d, 0x09, 0x6a, 0x2d, 0xc6, 0xa8, 0x43,
+	0x4b, 0x1b, 0x68, 0x5b, 0xcb, 0x5e, 0x16, 0x42, 0x09, 0x69, 0x12, 0x89, 0x21, 0x97, 0x55, 0x59,
+	0xec, 0x36, 0xc0, 0x51, 0xa2, 0xb4, 0x39, 0xd0, 0x88, 0xf2, 0x3b, 0x98, 0xac, 0x4b, 0xe8, 0x7d,
+	0x44, 0xc9, 0x7e, 0x06, 0xdd, 0x54, 0x84, 0x2f, 0xcf, 0x1e, 0xc0, 0x3b, 0xa9, 0x70, 0x1f, 0xf2,
+	0x1d, 0x60, 0xf3, 0xf7, 0xa3, 0xbe, 0x66, 0x1b, 0x3a, 0xbe, 0x44, 0x07, 0x33, 0xbd, 0x43, 0xfe,
+	0x3e, 0x0f, 0x8d, 0x4a, 0x10, 0xff, 0x67, 0x1d, 0x7a, 0xf7, 0x45, 0x91, 0x9a, 0xc7, 0x2a, 0x3b,
+	0x4a, 0x52, 0x97, 0xa8, 0x12, 0x79, 0x90, 0x0a, 0x83, 0xd2, 0xcf, 0x4f, 0x36, 0xd9, 0x26, 0x72,
+	0xcf, 0x71, 0x28, 0xd9, 0x62, 0x9c, 0x88, 0x19, 0xc6, 0x15, 0xc6, 0xbe, 0xe3, 0x06, 0x98, 0x4f,
+	0xda, 0x01, 0xd3, 0x28, 0x93, 0x76, 0x00, 0x30, 0x68, 0xa6, 0x99, 0x76, 0xe1, 0x5f, 0x8f, 0x68,
+	0x6d, 0x4d, 0x1d, 0x17, 0x79, 0x6a, 0x3b, 0x9a, 0x24, 0x73, 0xf6, 0xa8, 0x47, 0x55, 0x96, 0xfd,
+	0x4a, 0x23, 0xc6, 0x83, 0x96, 0x9f, 0xa6, 0x11, 0xe3, 0x9b, 0xff, 0x05, 0xe8, 0x84, 0x26, 0x80,
+	0xbd, 0x0f, 0x8d, 0x5d, 0x34, 0xac, 0x13, 0xfa, 0xbf, 0xa1, 0xeb, 0x95, 0x29, 0xbf, 0xf3, 0x9a,
+	0xed, 0xa4, 0x77, 0xd1, 0x3c, 0x90, 0x55, 0x84, 0xeb, 0x15, 0xfd, 0x7f, 0x07, 0xc2, 0xb4, 0xbf,
+	0xc2, 0xc9, 0xa1, 0xed, 0x21, 0x66, 0xa0, 0xb5, 0x99, 0x18, 0xcd, 0x6b, 0xec, 0x1a, 0x74, 0xee,
+	0x66, 0xd2, 0x88, 0x44, 0x6a, 0x36, 0x3f, 0xb9, 0x7a, 0x71, 0x7e, 0x68, 0x25, 0xe8, 0x2a, 0xfd,
+	0x38, 0x60, 0xae, 0x5d, 0xa9, 0xfe, 0x44, 0x38, 0x2d, 0xf5, 0x32, 0x34, 0xf6, 0x50, 0x2e, 0x9c,
+	0xea, 0xfe, 0x04, 0xf0, 0x1a, 0xfb, 0x18, 0x9a, 0xbf, 0xb6, 0xb7, 0x73, 0x92, 0xaa, 0x03, 0xfd,
+	0xc2, 0x35, 0xdb, 0x16, 0x78, 0x27, 0x4d, 0x17, 0x84, 0xdd, 0x77, 0x73, 0x75, 0x8d, 0x5d, 0x87,
+	0xfe, 0x2e, 0x9a, 0xca, 0xcf, 0xa7, 0x19, 0xd2, 0x17, 0xe3, 0x72, 0x8b, 0xd7, 0xd8, 0x8f, 0xa1,
+	0xb5, 0x5f, 0x1c, 0x4e, 0x12, 0xc3, 0x36, 0x4e, 0x8f, 0xbd, 0xfe, 0xc6, 0x7e, 0x64, 0xe4, 0x35,
+	0xf6, 0x53, 0xe8, 0x3b, 0xec, 0x1d, 0x19, 0x7f, 0x2b, 0x96, 0x7e, 0xb2, 0xe1, 0x27, 0x98, 0x32,
+	0xcf, 0xf1, 0x1a, 0xfb, 0x0c, 0x7a, 0xee, 0xb3, 0x7d, 0xa3, 0x50, 0x4c, 0xce, 0x3e, 0x68, 0xb3,
+	0x7e, 0xa3, 0xce, 0x7e, 0x05, 0x3d, 0x37, 0x5b, 0xee, 0x1c, 0x53, 0xd6, 0x63, 0x1e, 0x53, 0x19,
+	0x37, 0x87, 0x17, 0x2b, 0xb9, 0xe2, 0x2e, 0xfd, 0xc2, 0x22, 0x30, 0xaf, 0xdd, 0xa8, 0xb3, 0x2d,
+	0x58, 0xa5, 0xe1, 0xd2, 0x1b, 0xb5, 0x3a, 0x68, 0x0e, 0xcf, 0x05, 0x8b, 0xb8, 0x99, 0x92, 0xf0,
+	0x9b, 0x36, 0x3d, 0x67, 0x46, 0xf8, 0xf4, 0xec, 0xec, 0x4e, 0xb3, 0x9f, 0x37, 0xf0, 0x13, 0x37,
+	0x8f, 0xd9, 0x87, 0x6f, 0xda, 0x89, 0xcc, 0xdf, 0xa3, 0x32, 0x9c, 0x0d, 0xfb, 0xd5, 0xe9, 0xc4,
+	0x42, 0x6f, 0xc3, 0x85, 0x7d, 0x29, 0x72, 0xfd, 0x3c, 0x33, 0x73, 0x23, 0x48, 0x39, 0xc6, 0xd8,
+	0xe8, 0x1e, 0x9e, 0x5f, 0x18, 0x3d, 0x78, 0x8d, 0xdd, 0x87, 0xb5, 0xca, 0x1c, 0xc0, 0xde, 0x23,
+	0xcc, 0xe2, 0x64, 0x30, 0xbc, 0xb4, 0x60, 0x83, 0x0a, 0x88, 0xd7, 0xec, 0xaf, 0xc0, 0x72, 0x4a,
+	0x60, 0xef, 0xce, 0x75, 0xd6, 0xa5, 0xde, 0x6c, 0xa1, 0xe1, 0xd6, 0xf4, 0xdc, 0xb3, 0xee, 0xf4,
+	0x9e, 0x9a, 0x46, 0x85, 0x9c, 0xb3, 0xca, 0xa9, 0xbe, 0xd4, 0x35, 0x14, 0xbc, 0x66, 0x1b, 0xfa,
+	0x3b, 0xf1, 0x24, 0x91, 0xf7, 0x54, 0x96, 0xb3, 0xea, 0x5f, 0x90, 0x92, 0x3b, 0xac, 0x88, 0xe1,
+	0x35, 0x76, 0x15, 0x9a, 0xf6, 0xc8, 0x39, 0xe1, 0xce, 0x92, 0xa1, 0x57, 0xe5, 0x35, 0xf6, 0xe9,
+	0xac, 0x75, 0x59, 0xf2, 0x42, 0xef, 0x04, 0x07, 0xaa, 0xf4, 0x36, 0xe4, 0x79, 0xfd, 0x08, 0xed,
+	0xdc, 0xe6, 0x37, 0x4e, 0xd9, 0xfd, 0x35, 0x5f, 0xfd, 0x04, 0xba, 0xbb, 0x68, 0xfc, 0x29, 0x73,
+	0xae, 0xb9, 0xd4, 0xbd, 0x6f, 0x40, 0xff, 0x6e, 0x5a, 0x68, 0x83, 0x6a, 0x89, 0x62, 0xe7, 0xcb,
+	0x7b, 0x84, 0x7a, 0x47, 0x4f, 0xb2, 0x3e, 0x9f, 0xb8, 0xe7, 0xbf, 0x79, 0x6f, 0x49, 0x6a, 0xb7,
+	0xfa, 0xf2, 0x1a, 0xbb, 0x09, 0xeb, 0xbb, 0x68, 0xe6, 0x12, 0xfb, 0xe2, 0x69, 0xd5, 0x6d, 0xf2,
+	0xc1, 0xf5, 0xfd, 0x53, 0xdf, 0x2c, 0xe2, 0x96, 0x7e, 0x7a, 0xd8, 0xa2, 0x52, 0xf5, 0xe9, 0xff,
+	0x06, 0x00, 0xb5, 0x04, 0x74, 0x78, 0xa5, 0x17, 0x00, 0x00,
 }
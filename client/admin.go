@@ -0,0 +1,226 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+// QuotaStatus returns the storage usage of every prefix having a quota on the endpoint.
+func (c *Client) QuotaStatus(ctx context.Context) ([]*api.Quota, error) {
+	res, err := c.client.QuotaStatus(ctx, &api.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Quotas, nil
+}
+
+func (c *Client) processQUOTAS(string) error {
+	ctx, done := c.ctx()
+	defer done()
+
+	quotas, err := c.QuotaStatus(ctx)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	for _, q := range quotas {
+		fmt.Printf("%s\t%d / %d bytes\n", q.Prefix, q.Used, q.Limit)
+	}
+	return nil
+}
+
+// RetentionDryRun returns the retention policies of the endpoint, along with
+// the expired keys that would be deleted by retention queries.
+func (c *Client) RetentionDryRun(ctx context.Context) (*api.RetentionReport, error) {
+	return c.client.RetentionDryRun(ctx, &api.Empty{})
+}
+
+func (c *Client) processRETENTION(string) error {
+	ctx, done := c.ctx()
+	defer done()
+
+	report, err := c.RetentionDryRun(ctx)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	for _, p := range report.Policies {
+		fmt.Printf("%s\t%s\n", p.Prefix, time.Duration(p.Age)*time.Second)
+	}
+	for _, e := range report.Expired {
+		modified, _ := ptypes.Timestamp(e.Modified)
+		fmt.Printf("expired\t%s\t%s\n", e.Key, modified.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// RecoveryStatus returns the state of the recoveries asked to the endpoint.
+func (c *Client) RecoveryStatus(ctx context.Context) (*api.RecoveryReport, error) {
+	return c.client.RecoveryStatus(ctx, &api.Empty{})
+}
+
+// RetryRecovery asks the endpoint to submit again the recovery of dead
+// letters, or of all of them if no key is provided.
+func (c *Client) RetryRecovery(ctx context.Context, keys ...string) (*api.RecoveryReport, error) {
+	return c.client.RetryRecovery(ctx, &api.KeyList{Keys: keys})
+}
+
+// processRECOVERY prints the recovery status of the endpoint.
+// With "RETRY [keys...]", dead letters are submitted again first.
+func (c *Client) processRECOVERY(input string) error {
+	ctx, done := c.ctx()
+	defer done()
+
+	var report *api.RecoveryReport
+	var err error
+	args := strings.Fields(input)
+	switch {
+	case len(args) == 0:
+		report, err = c.RecoveryStatus(ctx)
+	case strings.ToUpper(args[0]) == "RETRY":
+		report, err = c.RetryRecovery(ctx, args[1:]...)
+	default:
+		err = fmt.Errorf("usage: RECOVERY [RETRY [keys...]]")
+		fmt.Println(err)
+		return err
+	}
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	fmt.Printf("breaker\t%s (%d openings)\n", report.Breaker, report.BreakerOpenings)
+	fmt.Printf("pending\t%d\n", report.Pending)
+	fmt.Printf("attempts\t%d successes, %d failures, %d retries\n", report.Successes, report.Failures, report.Retries)
+	for _, d := range report.DeadLetters {
+		t, _ := ptypes.Timestamp(d.Time)
+		fmt.Printf("dead\t%s\t%d attempts\t%s\t%s\n", d.Key, d.Attempts, t.Format(time.RFC3339), d.Error)
+	}
+	return nil
+}
+
+// ClusterStatus returns the status of the endpoint, followed by the recent
+// statuses broadcast by the other nodes.
+func (c *Client) ClusterStatus(ctx context.Context) ([]*consensus.NodeStatus, error) {
+	res, err := c.client.ClusterStatus(ctx, &api.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Nodes, nil
+}
+
+// UnknownEmitters returns the identities missing from the keyring of the
+// endpoint, whose queries or endorsements it received recently.
+func (c *Client) UnknownEmitters(ctx context.Context) ([]*api.UnknownEmitter, error) {
+	res, err := c.client.UnknownEmitters(ctx, &api.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Emitters, nil
+}
+
+// AdminDrop submits a drop statement signed by a quorum of administrators
+// (see consensus.SignAdminDrop), which drops a pending query on every node.
+func (c *Client) AdminDrop(ctx context.Context, d *consensus.AdminDrop) error {
+	_, err := c.client.AdminDrop(ctx, d)
+	return err
+}
+
+// FaultProfile returns the faults injected in the broadcasts of a staging
+// endpoint.
+func (c *Client) FaultProfile(ctx context.Context) (*api.FaultProfile, error) {
+	return c.client.GetFaultProfile(ctx, &api.Empty{})
+}
+
+// SetFaultProfile updates the faults injected in the broadcasts of a staging
+// endpoint, and returns the profile in effect.
+func (c *Client) SetFaultProfile(ctx context.Context, p *api.FaultProfile) (*api.FaultProfile, error) {
+	return c.client.SetFaultProfile(ctx, p)
+}
+
+// processFAULTS prints the faults injected by the endpoint.
+// With "minLatency medianLatency maxLatency loss duplication", they are
+// updated first.
+func (c *Client) processFAULTS(input string) error {
+	ctx, done := c.ctx()
+	defer done()
+
+	var profile *api.FaultProfile
+	var err error
+	args := strings.Fields(input)
+	switch len(args) {
+	case 0:
+		profile, err = c.FaultProfile(ctx)
+	case 5:
+		profile, err = parseFaultProfile(args)
+		if err != nil {
+			fmt.Println("FAULTS function expects durations and probabilities:", err)
+			return errors.New("invalid arguments")
+		}
+		profile, err = c.SetFaultProfile(ctx, profile)
+	default:
+		fmt.Println("FAULTS function expects zero or five arguments: (minLatency, medianLatency, maxLatency, loss, duplication)")
+		return errors.New("invalid arguments")
+	}
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	fmt.Printf("latency\t%s min, %s median, %s max\n",
+		time.Duration(profile.MinLatency)*time.Millisecond,
+		time.Duration(profile.MedianLatency)*time.Millisecond,
+		time.Duration(profile.MaxLatency)*time.Millisecond,
+	)
+	fmt.Printf("loss\t%g\n", profile.Loss)
+	fmt.Printf("duplication\t%g\n", profile.Duplication)
+	return nil
+}
+
+func parseFaultProfile(args []string) (*api.FaultProfile, error) {
+	var latencies [3]int64
+	for i := range latencies {
+		d, err := time.ParseDuration(args[i])
+		if err != nil {
+			return nil, err
+		}
+		latencies[i] = int64(d / time.Millisecond)
+	}
+
+	loss, err := strconv.ParseFloat(args[3], 64)
+	if err != nil {
+		return nil, err
+	}
+	duplication, err := strconv.ParseFloat(args[4], 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.FaultProfile{
+		MinLatency:    latencies[0],
+		MedianLatency: latencies[1],
+		MaxLatency:    latencies[2],
+		Loss:          loss,
+		Duplication:   duplication,
+	}, nil
+}
@@ -17,19 +17,63 @@ type cliMap map[string]func(arg string) error
 
 func (c *Client) getCLIMap() cliMap {
 	return cliMap{
-		"HELP":      c.help,
-		"GET":       c.processGET,
-		"VERSION":   c.processVERSION,
-		"SET":       c.processGeneric2("SET"),
-		"CONCAT":    c.processGeneric2("CONCAT"),
-		"ADD":       c.processGeneric2("ADD"),
-		"MUL":       c.processGeneric2("MUL"),
-		"SADD":      c.processGeneric2("SADD"),
-		"SREM":      c.processGeneric2("SREM"),
-		"SMEMBERS":  c.processMEMBERS,
-		"SCONTAINS": c.processCONTAINS,
-		"POL":       c.SetPolicy,
-		"TIMEOUT":   c.SetTxTimeout,
+		"HELP":       c.help,
+		"GET":        c.processGET,
+		"SPECGET":    c.processSPECGET,
+		"VERSION":    c.processVERSION,
+		"PROVENANCE": c.processPROVENANCE,
+		"SET":        c.processGeneric2("SET"),
+		"SETW":       c.processSETW,
+		"CONCAT":     c.processGeneric2("CONCAT"),
+		"REPLACE":    c.processREPLACE,
+		"TRUNCATE":   c.processTRUNCATE,
+		"SETRANGE":   c.processSETRANGE,
+		"CAS":        c.processCAS,
+		"SETEX":      c.processSETEX,
+		"EXPIRE":     c.processEXPIRE,
+		"TTL":        c.processTTL,
+		"ADD":        c.processGeneric2("ADD"),
+		"MUL":        c.processGeneric2("MUL"),
+		"MIN":        c.processGeneric2("MIN"),
+		"MAX":        c.processGeneric2("MAX"),
+		"INCR":       c.processCounter("INCR"),
+		"DECR":       c.processCounter("DECR"),
+		"GETINT":     c.processGETINT,
+		"SADD":       c.processGeneric2("SADD"),
+		"SREM":       c.processGeneric2("SREM"),
+		"LPUSH":      c.processGeneric2("LPUSH"),
+		"RPUSH":      c.processGeneric2("RPUSH"),
+		"LPOP":       c.processLPOP,
+		"LRANGE":     c.processLRANGE,
+		"LLEN":       c.processLLEN,
+		"HSET":       c.processHSET,
+		"HDEL":       c.processGeneric2("HDEL"),
+		"HGET":       c.processHGET,
+		"HGETALL":    c.processHGETALL,
+		"DEL":        c.processDEL,
+		"RESTORE":    c.processRESTORE,
+		"SMEMBERS":   c.processMEMBERS,
+		"SCONTAINS":  c.processCONTAINS,
+		"POL":        c.SetPolicy,
+		"TIMEOUT":    c.SetTxTimeout,
+		"EVENTS":     c.processEVENTS,
+		"WATCH":      c.processWATCH,
+		"CERT":       c.processCERT,
+		"ENDORSERS":  c.processENDORSERS,
+		"QUOTAS":     c.processQUOTAS,
+		"RETENTION":  c.processRETENTION,
+		"RECOVERY":   c.processRECOVERY,
+		"KEYS":       c.processKEYS,
+		"MULTI":      c.processMULTI,
+		"EXEC":       c.processEXEC,
+		"DISCARD":    c.processDISCARD,
+		"LOAD":       c.processLOAD,
+		"DRYRUN":     c.processDRYRUN,
+		"INFO":       c.processINFO,
+		"SETENC":     c.processSETENC,
+		"GETENC":     c.processGETENC,
+		"STATUS":     c.processSTATUS,
+		"FAULTS":     c.processFAULTS,
 	}
 }
 
@@ -44,11 +88,14 @@ func (c *Client) SetTxTimeout(timeout string) error {
 	t, err := time.ParseDuration(timeout)
 	if err != nil {
 		fmt.Println(err)
-	} else {
-		c.txTimeout = t
+		return err
 	}
 
-	return err
+	c.txTimeout = t
+	if clamped := c.TxTimeout(); clamped < t {
+		fmt.Println("Timeout clamped to", clamped, "by the server")
+	}
+	return nil
 }
 
 func (c *Client) help(string) error {
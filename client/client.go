@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/keyring"
 
 	"github.com/chzyer/readline"
 	"google.golang.org/grpc"
@@ -30,15 +31,21 @@ import (
 type Client struct {
 	Addr    string
 	Timeout time.Duration
+	KeyRing *keyring.KeyRing // optional, encrypts and decrypts values (see SETENC)
 
 	conn      *grpc.ClientConn
 	client    api.EndorserClient
 	policy    string
 	txTimeout time.Duration
 	climap    cliMap
+	multi     *TransactionBuilder // transaction in progress, see MULTI
+	dryRun    bool                // operations are simulated, see DRYRUN
+	wait      bool                // transactions are waited for, see SetWait
+	info      *api.NodeInfo       // see Info
 }
 
-// Connect proceeds to the GRPC connection step to the server.
+// Connect proceeds to the GRPC connection step to the server, and fetches
+// its configuration (see Info).
 func (c *Client) Connect() (err error) {
 	ctx, cancel := context.WithTimeout(context.TODO(), c.Timeout)
 	defer cancel()
@@ -50,7 +57,7 @@ func (c *Client) Connect() (err error) {
 
 	c.client = api.NewEndorserClient(c.conn)
 	c.climap = c.getCLIMap()
-	return nil
+	return c.fetchInfo()
 }
 
 // Close closes the GRPC connection to the server.
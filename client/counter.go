@@ -0,0 +1,70 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+// GetInt returns an integer value, such as a counter written by INCR and DECR
+// operations. It fails if the value is not an integer, or does not fit in 64 bits.
+func (c *Client) GetInt(ctx context.Context, key string) (value int64, v *consensus.Version, err error) {
+	res, err := c.client.GetInt(ctx, &api.Key{Key: key})
+	if res != nil {
+		value = res.Value
+		v = res.Version
+	}
+
+	return
+}
+
+func (c *Client) processGETINT(arg string) error {
+	ctx, done := c.ctx()
+	defer done()
+
+	value, _, err := c.GetInt(ctx, arg)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	fmt.Println(value)
+	return nil
+}
+
+// processCounter returns the handler of INCR and DECR, whose delta is 1 if omitted.
+func (c *Client) processCounter(op string) func(arg string) error {
+	return func(arg string) error {
+		args := strings.Fields(arg)
+		if len(args) == 1 {
+			args = append(args, "1")
+		}
+
+		if len(args) != 2 {
+			fmt.Println(op, "function expects one or two arguments: (key, [delta])")
+			return errors.New("invalid arguments")
+		}
+
+		if _, err := strconv.ParseInt(args[1], 10, 64); err != nil {
+			fmt.Println(op, "function expects a 64-bit integer delta")
+			return errors.New("invalid arguments")
+		}
+
+		return c.submitOperation(consensus.Operation_Op(consensus.Operation_Op_value[op]), args[0], []byte(args[1]))
+	}
+}
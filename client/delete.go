@@ -0,0 +1,60 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+// DefaultGracePeriod is the duration during which a deleted key can be
+// restored, when no grace period is given to DEL.
+const DefaultGracePeriod = 24 * time.Hour
+
+// processDEL soft-deletes a key, unless the grace period is zero: the key is
+// then deleted permanently.
+func (c *Client) processDEL(arg string) error {
+	args := strings.Fields(arg)
+	grace := DefaultGracePeriod
+	switch len(args) {
+	case 1:
+	case 2:
+		var err error
+		grace, err = time.ParseDuration(args[1])
+		if err == nil && grace < 0 {
+			err = errors.New("negative grace period")
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			return err
+		}
+	default:
+		fmt.Println("DEL function expects one or two arguments: (key, [grace period])")
+		return errors.New("invalid arguments")
+	}
+
+	if grace == 0 {
+		return c.submitOperation(consensus.Operation_DELETE, args[0], nil)
+	}
+	return c.submitOperation(consensus.Operation_SOFTDELETE, args[0], []byte(grace.String()))
+}
+
+func (c *Client) processRESTORE(arg string) error {
+	args := strings.Fields(arg)
+	if len(args) != 1 {
+		fmt.Println("RESTORE function expects one argument: (key)")
+		return errors.New("invalid arguments")
+	}
+
+	return c.submitOperation(consensus.Operation_RESTORE, args[0], nil)
+}
@@ -0,0 +1,70 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+)
+
+// dryRunPreviewLimit bounds the size of the values printed by DRYRUN.
+const dryRunPreviewLimit = 1024
+
+// DryRun returns the value that an operation would produce if it was
+// applied now to the current value of its key, as read from the endpoint.
+// Nothing is submitted: concurrent transactions may lead to another value.
+func (c *Client) DryRun(ctx context.Context, op *consensus.Operation) ([]byte, error) {
+	deadline, _ := ptypes.TimestampProto(time.Now())
+	p, err := c.Preview(ctx, &api.Transaction{
+		Operations: []*consensus.Operation{op},
+		Deadline:   deadline,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.Values[op.Key], nil
+}
+
+func (c *Client) processDRYRUN(arg string) error {
+	cmd := strings.ToUpper(strings.SplitN(arg, " ", 2)[0])
+	if _, ok := consensus.Operation_Op_value[cmd]; !ok && cmd != "DEL" {
+		fmt.Println("DRYRUN function expects an operation to simulate, such as: DRYRUN SET key data")
+		return errors.New("invalid arguments")
+	}
+
+	c.dryRun = true
+	defer func() { c.dryRun = false }()
+	return c.Run(arg)
+}
+
+// dryRunOperation prints the value that an operation would produce.
+func (c *Client) dryRunOperation(op *consensus.Operation) error {
+	ctx, done := c.ctx()
+	defer done()
+
+	raw, err := c.DryRun(ctx, op)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	t := encoding.TypeOf(raw)
+	fmt.Printf("(%s, %d bytes) %s\n", t, len(raw), encoding.Preview(raw, t, dryRunPreviewLimit))
+	return nil
+}
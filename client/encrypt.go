@@ -0,0 +1,76 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+// ErrNoKeyRing is returned by encryption functions when the client has no keyring.
+var ErrNoKeyRing = errors.New("client has no keyring")
+
+// GetDecrypted gets a value encrypted with EncryptFor, and decrypts it with
+// the keyring of the client.
+func (c *Client) GetDecrypted(ctx context.Context, key string) ([]byte, *consensus.Version, error) {
+	if c.KeyRing == nil {
+		return nil, nil, ErrNoKeyRing
+	}
+
+	value, v, err := c.Get(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plaintext, err := c.KeyRing.Decrypt(value)
+	return plaintext, v, err
+}
+
+// processSETENC sets a value encrypted for a comma-separated list of
+// recipients: SETENC key alice,bob value.
+func (c *Client) processSETENC(arg string) error {
+	args := strings.SplitN(arg, " ", 3)
+	if len(args) != 3 {
+		fmt.Println("SETENC function expects three arguments: (key, recipients, data)")
+		return errors.New("invalid arguments")
+	}
+
+	if c.KeyRing == nil {
+		fmt.Println("Error:", ErrNoKeyRing)
+		return ErrNoKeyRing
+	}
+
+	ciphertext, err := c.KeyRing.EncryptFor([]byte(args[2]), strings.Split(args[1], ",")...)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return err
+	}
+
+	return c.submitOperation(consensus.Operation_SET, args[0], ciphertext)
+}
+
+func (c *Client) processGETENC(arg string) error {
+	ctx, done := c.ctx()
+	defer done()
+
+	value, _, err := c.GetDecrypted(ctx, arg)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	fmt.Printf("%s\n", value)
+	return nil
+}
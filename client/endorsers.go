@@ -0,0 +1,101 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+)
+
+// Endorsers returns the endorsements of a query known by the endpoint, and
+// whether they have been read from its commit certificate.
+func (c *Client) Endorsers(ctx context.Context, uuid string) ([]*api.EndorserInfo, bool, error) {
+	res, err := c.client.Endorsers(ctx, &api.EndorsersRequest{Uuid: uuid})
+	if err != nil {
+		return nil, false, err
+	}
+	return res.Endorsers, res.Certified, nil
+}
+
+// trust returns the trust level of an identity in the keyring of the client,
+// or "-" without keyring.
+func (c *Client) trust(identity string) string {
+	if c.KeyRing == nil {
+		return "-"
+	}
+
+	lvl, err := c.KeyRing.EffectiveTrust(identity)
+	if err != nil {
+		return "unknown"
+	}
+	return lvl.String()
+}
+
+// displayName returns an identity along with its display name in the
+// keyring of the client, if any.
+func (c *Client) displayName(identity string) string {
+	if c.KeyRing == nil {
+		return identity
+	}
+
+	if name := c.KeyRing.DisplayName(identity); name != "" {
+		return fmt.Sprintf("%s (%s)", identity, name)
+	}
+	return identity
+}
+
+func (c *Client) processENDORSERS(arg string) error {
+	args := strings.Fields(arg)
+	if len(args) != 1 {
+		fmt.Println("ENDORSERS function expects a query UUID")
+		return errors.New("invalid arguments")
+	}
+
+	ctx, done := c.ctx()
+	defer done()
+
+	endorsers, certified, err := c.Endorsers(ctx, args[0])
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Emitter", "Trust", "Signature", "Conditions"})
+	table.SetAutoFormatHeaders(false)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+	for _, e := range endorsers {
+		signature := e.Fingerprint
+		if e.Aggregated {
+			signature += " (aggregated)"
+		}
+		table.Append([]string{
+			c.displayName(e.Emitter),
+			c.trust(e.Emitter),
+			signature,
+			strings.Join(e.Conditions, " "),
+		})
+	}
+	table.Render()
+
+	if certified {
+		fmt.Println("Read from the commit certificate")
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+// ReplayEvents calls handler for each commit event recorded by the endpoint
+// journal with a sequence number strictly greater than since.
+// If follow is set, the call only returns when the context is done, or
+// when the handler returns an error.
+//
+// Events are delivered at least once: a consumer should persist the sequence
+// number of the last processed event, and resume from it.
+func (c *Client) ReplayEvents(ctx context.Context, since uint64, follow bool, handler func(*consensus.CommitEvent) error) error {
+	stream, err := c.client.ReplayEvents(ctx, &api.ReplayRequest{
+		Since:  since,
+		Follow: follow,
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = handler(event)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) processEVENTS(arg string) error {
+	ctx, done := c.ctx()
+	defer done()
+
+	var since uint64
+	if arg = strings.TrimSpace(arg); arg != "" {
+		var err error
+		since, err = strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			fmt.Println("EVENTS function expects an optional sequence number")
+			return err
+		}
+	}
+
+	err := c.ReplayEvents(ctx, since, false, func(e *consensus.CommitEvent) error {
+		fmt.Printf("#%d %s %s\n", e.Sequence, e.Uuid, strings.Join(e.Keys, ","))
+		return nil
+	})
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+	}
+
+	return err
+}
+
+// Certificate returns the commit certificate of a query, attested by the
+// endpoint. It can be verified offline with consensus.VerifyCertificate.
+func (c *Client) Certificate(ctx context.Context, uuid string) (*consensus.CommitCertificate, error) {
+	return c.client.Certificate(ctx, &api.CertificateRequest{Uuid: uuid})
+}
+
+func (c *Client) processCERT(arg string) error {
+	args := strings.Fields(arg)
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Println("CERT function expects a query UUID, and an optional output file")
+		return errors.New("invalid arguments")
+	}
+
+	ctx, done := c.ctx()
+	defer done()
+
+	cert, err := c.Certificate(ctx, args[0])
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	fmt.Printf("Query %s by %s, attested by %s\n", cert.Query.Uuid, cert.Query.Emitter, cert.Attester)
+	for _, e := range cert.Endorsements {
+		fmt.Println("Endorsed by", e.Emitter)
+	}
+
+	if len(args) == 1 {
+		return nil
+	}
+
+	data, err := proto.Marshal(cert)
+	if err == nil {
+		err = ioutil.WriteFile(args[1], data, 0644)
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+// TTL returns the time remaining before a key expires, or ok set to false if
+// it does not expire.
+func (c *Client) TTL(ctx context.Context, key string) (ttl time.Duration, ok bool, err error) {
+	_, v, err := c.Get(ctx, key)
+	if err != nil {
+		return 0, false, err
+	}
+
+	expiry := v.ExpiryTime()
+	if expiry.IsZero() {
+		return 0, false, nil
+	}
+	return time.Until(expiry), true, nil
+}
+
+// parseTTL parses a number of seconds, or a duration such as "1m30s".
+func parseTTL(arg string) (time.Duration, error) {
+	ttl, err := time.ParseDuration(arg)
+	if seconds, e := strconv.ParseUint(arg, 10, 32); e == nil {
+		ttl, err = time.Duration(seconds)*time.Second, nil
+	}
+	if err != nil || ttl <= 0 {
+		return 0, errors.New("invalid arguments")
+	}
+	return ttl, nil
+}
+
+func (c *Client) processSETEX(arg string) error {
+	args := strings.SplitN(arg, " ", 3)
+	if len(args) != 3 {
+		fmt.Println("SETEX function expects three arguments: (key, ttl, data)")
+		return errors.New("invalid arguments")
+	}
+
+	ttl, err := parseTTL(args[1])
+	if err != nil {
+		fmt.Println("SETEX function expects a positive number of seconds, or a duration such as 1m30s")
+		return err
+	}
+
+	return c.submitExpiring(consensus.Operation_SET, args[0], []byte(args[2]), ttl)
+}
+
+func (c *Client) processEXPIRE(arg string) error {
+	args := strings.Fields(arg)
+	if len(args) != 2 {
+		fmt.Println("EXPIRE function expects two arguments: (key, ttl)")
+		return errors.New("invalid arguments")
+	}
+
+	ttl, err := parseTTL(args[1])
+	if err != nil {
+		fmt.Println("EXPIRE function expects a positive number of seconds, or a duration such as 1m30s")
+		return err
+	}
+
+	return c.submitOperation(consensus.Operation_EXPIRE, args[0], []byte(ttl.String()))
+}
+
+func (c *Client) processTTL(arg string) error {
+	ctx, done := c.ctx()
+	defer done()
+
+	ttl, ok, err := c.TTL(ctx, strings.TrimSpace(arg))
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	if !ok {
+		fmt.Println("none")
+		return nil
+	}
+	if ttl < 0 {
+		ttl = 0 // expired in the meantime
+	}
+	fmt.Println(ttl.Round(time.Second))
+	return nil
+}
@@ -12,7 +12,9 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
+	"github.com/golang/protobuf/ptypes"
 	"google.golang.org/grpc/status"
 
 	"github.com/technicolor-research/pnyxdb/api"
@@ -30,6 +32,18 @@ func (c *Client) Get(ctx context.Context, key string) (value []byte, v *consensu
 	return
 }
 
+// GetSpeculative gets the key from the endpoint, including the effects of
+// the queries it considers applicable although they are not committed yet.
+func (c *Client) GetSpeculative(ctx context.Context, key string) (value []byte, v *consensus.Version, err error) {
+	res, err := c.client.Get(ctx, &api.Key{Key: key, Speculative: true})
+	if res != nil {
+		value = res.Data
+		v = res.Version
+	}
+
+	return
+}
+
 // Members returns the slice of every element of a container.
 func (c *Client) Members(ctx context.Context, key string) (values [][]byte, v *consensus.Version, err error) {
 	members, err := c.client.Members(ctx, &api.Key{Key: key})
@@ -62,6 +76,20 @@ func (c *Client) processGET(arg string) error {
 	return nil
 }
 
+func (c *Client) processSPECGET(arg string) error {
+	ctx, done := c.ctx()
+	defer done()
+
+	value, _, err := c.GetSpeculative(ctx, arg)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	fmt.Printf("%s\n", value)
+	return nil
+}
+
 func (c *Client) processVERSION(arg string) error {
 	ctx, done := c.ctx()
 	defer done()
@@ -75,6 +103,30 @@ func (c *Client) processVERSION(arg string) error {
 	return nil
 }
 
+// Provenance returns the value of a key, along with the query which wrote it.
+func (c *Client) Provenance(ctx context.Context, key string) (*api.Provenance, error) {
+	return c.client.GetProvenance(ctx, &api.Key{Key: key})
+}
+
+func (c *Client) processPROVENANCE(arg string) error {
+	ctx, done := c.ctx()
+	defer done()
+	p, err := c.Provenance(ctx, arg)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	if p.Uuid == "" {
+		fmt.Println("unknown")
+		return nil
+	}
+
+	committed, _ := ptypes.Timestamp(p.Committed)
+	fmt.Printf("%s\t%s\t%s\n", p.Uuid, p.Emitter, committed.Format(time.RFC3339))
+	return nil
+}
+
 func (c *Client) processMEMBERS(arg string) error {
 	ctx, done := c.ctx()
 	defer done()
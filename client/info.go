@@ -0,0 +1,110 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+)
+
+// defaultTxTimeout is the timeout of the transactions submitted by the CLI
+// commands, unless set with SetTxTimeout.
+const defaultTxTimeout = 5 * time.Second
+
+// Info fetches the configuration of the endpoint relevant to clients, and
+// adapts the defaults of the client to its limits.
+func (c *Client) Info(ctx context.Context) (*api.NodeInfo, error) {
+	info, err := c.client.Info(ctx, &api.Empty{})
+	if err != nil {
+		return nil, err
+	}
+
+	c.info = info
+	return info, nil
+}
+
+// TxTimeout returns the timeout of the transactions submitted by the CLI
+// commands, clamped to the maximum accepted by the endpoint.
+func (c *Client) TxTimeout() time.Duration {
+	timeout := c.txTimeout
+	if timeout == 0 {
+		timeout = defaultTxTimeout
+	}
+
+	if c.info != nil && c.info.MaxTimeout > 0 {
+		max := time.Duration(c.info.MaxTimeout) * time.Millisecond
+		if timeout > max {
+			timeout = max
+		}
+	}
+	return timeout
+}
+
+// fetchInfo is called by Connect. Endpoints older than the Info call are
+// assumed to have no limit.
+func (c *Client) fetchInfo() error {
+	ctx, done := c.ctx()
+	defer done()
+
+	_, err := c.Info(ctx)
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	return err
+}
+
+func (c *Client) processINFO(string) error {
+	ctx, done := c.ctx()
+	defer done()
+
+	info, err := c.Info(ctx)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	limit := func(v uint64, unit string) string {
+		if v == 0 {
+			return "unlimited"
+		}
+		return fmt.Sprint(v, unit)
+	}
+
+	if info.Failure != "" {
+		fmt.Println("FAILED:\t\t", info.Failure)
+	}
+	fmt.Println("Identity:\t", info.Identity)
+	if info.Version != "" {
+		fmt.Println("Version:\t", info.Version)
+	}
+	fmt.Println("Threshold:\t", info.Threshold)
+	if info.MaxTimeout > 0 {
+		fmt.Println("Max timeout:\t", time.Duration(info.MaxTimeout)*time.Millisecond)
+	} else {
+		fmt.Println("Max timeout:\t unlimited")
+	}
+	fmt.Println("Max operations:\t", limit(uint64(info.MaxOperations), ""))
+	fmt.Println("Max value size:\t", limit(info.MaxValueSize, " bytes"))
+	fmt.Printf("Memory:\t\t %d / %s (%d rejected)\n", info.MemoryUsed, limit(info.MemoryBudget, " bytes"), info.MemoryRejected)
+	fmt.Println("Operations:\t", strings.Join(info.Operations, " "))
+	fmt.Println("Features:\t", strings.Join(info.Features, " "))
+	if len(info.Policies) == 0 {
+		fmt.Println("Policies:\t any")
+	}
+	for _, p := range info.Policies {
+		fmt.Printf("Policy %s:\t %s\n", p.Name, strings.Join(p.Prefixes, " "))
+	}
+	return nil
+}
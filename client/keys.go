@@ -0,0 +1,93 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+)
+
+// Keys lists the keys of the endpoint starting with prefix.
+// When details is set, the type, size and a preview of at most previewLimit
+// bytes of each value are also returned (previewLimit may be 0 for the
+// server default).
+func (c *Client) Keys(ctx context.Context, prefix string, details bool, previewLimit uint32) ([]*api.KeyInfo, error) {
+	res, err := c.client.Keys(ctx, &api.KeysRequest{
+		Prefix:       prefix,
+		Details:      details,
+		PreviewLimit: previewLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Keys, nil
+}
+
+func (c *Client) processKEYS(arg string) error {
+	var prefix string
+	var preview bool
+	for _, a := range strings.Fields(arg) {
+		if a == "--preview" {
+			preview = true
+		} else {
+			prefix = a
+		}
+	}
+
+	ctx, done := c.ctx()
+	defer done()
+
+	keys, err := c.Keys(ctx, prefix, preview, 0)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	if !preview {
+		for _, k := range keys {
+			fmt.Println(k.Key)
+		}
+		fmt.Println(len(keys), "key(s)")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Key", "Type", "Size", "Version", "Preview"})
+	table.SetAutoFormatHeaders(false)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+	for _, k := range keys {
+		table.Append([]string{
+			k.Key,
+			k.Type,
+			strconv.FormatUint(k.Size, 10),
+			versionFingerprint(k),
+			k.Preview,
+		})
+	}
+
+	table.Render()
+	return nil
+}
+
+func versionFingerprint(k *api.KeyInfo) string {
+	if k.Version == nil || len(k.Version.Hash) < 4 {
+		return "-"
+	}
+	return fmt.Sprintf("%x", k.Version.Hash[:4])
+}
@@ -0,0 +1,109 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+// Range returns the elements of a list from start to stop, both included.
+// Negative indexes count from the end of the list, -1 being the last element.
+func (c *Client) Range(ctx context.Context, key string, start, stop int64) (values [][]byte, v *consensus.Version, err error) {
+	res, err := c.client.Range(ctx, &api.RangeRequest{Key: key, Start: start, Stop: stop})
+	if res != nil {
+		values = res.Data
+		v = res.Version
+	}
+
+	return
+}
+
+// Len returns the number of elements of a list.
+func (c *Client) Len(ctx context.Context, key string) (length uint64, v *consensus.Version, err error) {
+	res, err := c.client.Len(ctx, &api.Key{Key: key})
+	if res != nil {
+		length = res.Length
+		v = res.Version
+	}
+
+	return
+}
+
+// processLPOP handles LPOP, which removes one element if the count is omitted.
+func (c *Client) processLPOP(arg string) error {
+	args := strings.Fields(arg)
+	if len(args) == 1 {
+		args = append(args, "1")
+	}
+
+	if len(args) != 2 {
+		fmt.Println("LPOP function expects one or two arguments: (key, [count])")
+		return errors.New("invalid arguments")
+	}
+
+	if n, err := strconv.Atoi(args[1]); err != nil || n <= 0 {
+		fmt.Println("LPOP function expects a positive count")
+		return errors.New("invalid arguments")
+	}
+
+	return c.submitOperation(consensus.Operation_LPOP, args[0], []byte(args[1]))
+}
+
+func (c *Client) processLRANGE(arg string) error {
+	args := strings.Fields(arg)
+	if len(args) != 3 {
+		fmt.Println("LRANGE function expects three arguments: (key, start, stop)")
+		return errors.New("invalid arguments")
+	}
+
+	start, err1 := strconv.ParseInt(args[1], 10, 64)
+	stop, err2 := strconv.ParseInt(args[2], 10, 64)
+	if err1 != nil || err2 != nil {
+		fmt.Println("LRANGE function expects integer indexes")
+		return errors.New("invalid arguments")
+	}
+
+	ctx, done := c.ctx()
+	defer done()
+
+	values, _, err := c.Range(ctx, args[0], start, stop)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	fmt.Println(len(values), "element(s)")
+	for _, data := range values {
+		fmt.Printf("- %s\n", data)
+	}
+	return nil
+}
+
+func (c *Client) processLLEN(arg string) error {
+	ctx, done := c.ctx()
+	defer done()
+
+	length, _, err := c.Len(ctx, arg)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	fmt.Println(length)
+	return nil
+}
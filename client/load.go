@@ -0,0 +1,188 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+// SubmitSession submits transactions over a single stream, see SubmitStream.
+type SubmitSession struct {
+	stream   api.Endorser_SubmitStreamClient
+	sequence uint64
+}
+
+// SubmitStream opens a session submitting transactions over a single
+// stream, which avoids the overhead of a call per transaction for bulk
+// ingestion. The session is closed when the context is done.
+//
+// Endpoints that do not support streams make Recv fail with an Unimplemented
+// status code; Submit shall be used instead.
+func (c *Client) SubmitStream(ctx context.Context) (*SubmitSession, error) {
+	stream, err := c.client.SubmitStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SubmitSession{stream: stream}, nil
+}
+
+// Send sends a transaction, after having set its sequence, which is returned
+// and identifies the receipt of the transaction. It blocks if the endpoint
+// does not keep up with the client.
+// Send must not be called concurrently, but can be called concurrently with Recv.
+func (s *SubmitSession) Send(tx *api.Transaction) (sequence uint64, err error) {
+	s.sequence++
+	tx.Sequence = s.sequence
+	return s.sequence, s.stream.Send(tx)
+}
+
+// CloseSend tells the endpoint that no more transactions will be sent.
+func (s *SubmitSession) CloseSend() error {
+	return s.stream.CloseSend()
+}
+
+// Recv returns the receipt of the next transaction, in sending order. The
+// submission has failed if the Error of the receipt is set.
+// It returns io.EOF after the last receipt once CloseSend has been called.
+func (s *SubmitSession) Recv() (*api.Receipt, error) {
+	return s.stream.Recv()
+}
+
+// loadTimeout bounds the duration of a LOAD command.
+const loadTimeout = time.Hour
+
+func (c *Client) processLOAD(arg string) error {
+	path := strings.TrimSpace(arg)
+	if path == "" {
+		fmt.Println("LOAD function expects a file, holding an operation per line: OP key data")
+		return errors.New("invalid arguments")
+	}
+
+	builders, err := c.readLoadFile(path)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+
+	failed, err := c.loadStream(ctx, builders)
+	if status.Code(err) == codes.Unimplemented {
+		failed, err = c.loadUnary(ctx, builders)
+	}
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	fmt.Printf("Submitted %d transactions, %d failed\n", len(builders)-failed, failed)
+	return nil
+}
+
+// readLoadFile returns a single-operation transaction for each non-empty
+// line of a file, ignoring comments starting with #.
+func (c *Client) readLoadFile(path string) ([]*TransactionBuilder, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	timeout := c.TxTimeout()
+
+	var builders []*TransactionBuilder
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		args := strings.SplitN(line, " ", 3)
+		op, ok := consensus.Operation_Op_value[strings.ToUpper(args[0])]
+		if !ok || len(args) < 2 {
+			return nil, fmt.Errorf("line %d: expected OP key data", n)
+		}
+
+		var data []byte
+		if len(args) > 2 {
+			data = []byte(args[2])
+		}
+
+		builders = append(builders, NewTransactionBuilder(c.policy, timeout).Add(consensus.Operation_Op(op), args[1], data))
+	}
+	return builders, scanner.Err()
+}
+
+// loadStream submits transactions through SubmitStream, and returns the
+// number of failed submissions.
+func (c *Client) loadStream(ctx context.Context, builders []*TransactionBuilder) (failed int, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	session, err := c.SubmitStream(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	go func() {
+		for _, b := range builders {
+			if _, err := session.Send(b.Transaction()); err != nil {
+				return // the error is returned by Recv
+			}
+		}
+		_ = session.CloseSend()
+	}()
+
+	for {
+		receipt, err := session.Recv()
+		if err == io.EOF {
+			return failed, nil
+		}
+		if err != nil {
+			return failed, err
+		}
+
+		if receipt.Error != "" {
+			failed++
+			fmt.Printf("Error: transaction %d: %s\n", receipt.Sequence, receipt.Error)
+		}
+	}
+}
+
+// loadUnary submits transactions one by one, and returns the number of
+// failed submissions.
+func (c *Client) loadUnary(ctx context.Context, builders []*TransactionBuilder) (failed int, err error) {
+	for i, b := range builders {
+		_, err = c.Submit(ctx, b.Transaction())
+		if status.Code(err) == codes.Canceled || status.Code(err) == codes.DeadlineExceeded {
+			return failed, err
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("Error: transaction %d: %s\n", i+1, status.Convert(err).Message())
+		}
+	}
+	return failed, nil
+}
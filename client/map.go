@@ -0,0 +1,103 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+)
+
+// HGet returns the value of a field of a map.
+// It fails with a NotFound status if the field is not set.
+func (c *Client) HGet(ctx context.Context, key, field string) (value []byte, v *consensus.Version, err error) {
+	res, err := c.client.HGet(ctx, &api.FieldRequest{Key: key, Field: field})
+	if res != nil {
+		value = res.Data
+		v = res.Version
+	}
+
+	return
+}
+
+// HGetAll returns the fields of a map, along with their values.
+func (c *Client) HGetAll(ctx context.Context, key string) (fields map[string][]byte, v *consensus.Version, err error) {
+	res, err := c.client.HGetAll(ctx, &api.Key{Key: key})
+	if res != nil {
+		fields = make(map[string][]byte, len(res.Fields))
+		for _, f := range res.Fields {
+			fields[f.Key] = f.Value
+		}
+		v = res.Version
+	}
+
+	return
+}
+
+func (c *Client) processHSET(arg string) error {
+	args := strings.SplitN(arg, " ", 3)
+	if len(args) != 3 {
+		fmt.Println("HSET function expects three arguments: (key, field, value)")
+		return errors.New("invalid arguments")
+	}
+
+	data := encoding.EncodeArgs([]byte(args[1]), []byte(args[2]))
+	return c.submitOperation(consensus.Operation_HSET, args[0], data)
+}
+
+func (c *Client) processHGET(arg string) error {
+	key, field, err := split2args(arg)
+	if err != nil {
+		fmt.Println("HGET function expects two arguments: (key, field)")
+		return err
+	}
+
+	ctx, done := c.ctx()
+	defer done()
+
+	value, _, err := c.HGet(ctx, key, field)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	fmt.Printf("%s\n", value)
+	return nil
+}
+
+func (c *Client) processHGETALL(arg string) error {
+	ctx, done := c.ctx()
+	defer done()
+
+	fields, _, err := c.HGetAll(ctx, arg)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println(len(names), "field(s)")
+	for _, name := range names {
+		fmt.Printf("- %s: %s\n", name, fields[name])
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+)
+
+// Preview holds the values that a transaction is expected to write, computed
+// locally before it is committed. It allows to display them immediately
+// (optimistic UI), and to reconcile once the transaction is committed or
+// dropped: concurrent transactions may lead to other values.
+type Preview struct {
+	Values   map[string][]byte             // by key
+	Versions map[string]*consensus.Version // by key, as reported by commit events
+}
+
+// PreviewTransaction computes the values that a transaction would write if
+// it was applied to the current values given by key, missing keys being
+// empty. Operations are run by the same code as in the nodes, at the
+// deadline of the transaction. An error is returned if the transaction would
+// fail to apply. Deleted keys get a nil value and NoVersion.
+func PreviewTransaction(tx *api.Transaction, current map[string][]byte) (*Preview, error) {
+	q := &consensus.Query{Operations: tx.Operations, Deadline: tx.Deadline}
+	values, err := q.Execute(func(key string) ([]byte, error) {
+		return current[key], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Preview{
+		Values:   make(map[string][]byte, len(values)),
+		Versions: make(map[string]*consensus.Version, len(values)),
+	}
+	for key, v := range values {
+		if encoding.IsDeletion(v.Raw) {
+			p.Values[key] = nil
+			p.Versions[key] = consensus.NoVersion
+			continue
+		}
+
+		p.Values[key] = v.Raw
+		p.Versions[key] = consensus.NewVersion(v.Raw)
+	}
+	return p, nil
+}
+
+// Preview fetches the current values of the keys written by a transaction
+// from the endpoint, and computes the values it would write, see
+// PreviewTransaction. Deleted keys are seen as missing, hence RESTORE
+// operations cannot be previewed this way.
+func (c *Client) Preview(ctx context.Context, tx *api.Transaction) (*Preview, error) {
+	current := make(map[string][]byte)
+	for _, op := range tx.Operations {
+		if _, ok := current[op.Key]; ok {
+			continue
+		}
+
+		value, _, err := c.Get(ctx, op.Key)
+		if status.Code(err) == codes.NotFound {
+			value, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		current[op.Key] = value
+	}
+
+	return PreviewTransaction(tx, current)
+}
+
+// Diverging returns the sorted keys whose committed version, as reported by
+// the commit event of the transaction, differs from the preview. The
+// preview was right if there is none; otherwise, the values of these keys
+// shall be read again.
+func (p *Preview) Diverging(e *consensus.CommitEvent) []string {
+	committed := make(map[string]*consensus.Version, len(e.Keys))
+	for i, key := range e.Keys {
+		if i < len(e.Versions) {
+			committed[key] = e.Versions[i]
+		}
+	}
+
+	var keys []string
+	for key, v := range p.Versions {
+		if c, ok := committed[key]; !ok || !bytes.Equal(c.Hash, v.Hash) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,78 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+)
+
+func (c *Client) processREPLACE(arg string) error {
+	count := "-1"
+	if strings.HasPrefix(arg, "--first ") {
+		count = "1"
+		arg = strings.TrimPrefix(arg, "--first ")
+	}
+
+	args := strings.SplitN(arg, " ", 3)
+	if len(args) != 3 || args[1] == "" {
+		fmt.Println("REPLACE function expects three arguments: ([--first] key, pattern, replacement)")
+		return errors.New("invalid arguments")
+	}
+
+	data := encoding.EncodeArgs([]byte(args[1]), []byte(args[2]), []byte(count))
+	return c.submitOperation(consensus.Operation_REPLACE, args[0], data)
+}
+
+func (c *Client) processTRUNCATE(arg string) error {
+	args := strings.Fields(arg)
+	if len(args) != 2 {
+		fmt.Println("TRUNCATE function expects two arguments: (key, length)")
+		return errors.New("invalid arguments")
+	}
+
+	if _, err := strconv.ParseUint(args[1], 10, 63); err != nil {
+		fmt.Println("Error: invalid length", args[1])
+		return err
+	}
+
+	return c.submitOperation(consensus.Operation_TRUNCATE, args[0], []byte(args[1]))
+}
+
+func (c *Client) processSETRANGE(arg string) error {
+	args := strings.SplitN(arg, " ", 3)
+	if len(args) != 3 {
+		fmt.Println("SETRANGE function expects three arguments: (key, offset, data)")
+		return errors.New("invalid arguments")
+	}
+
+	if _, err := strconv.ParseUint(args[1], 10, 63); err != nil {
+		fmt.Println("Error: invalid offset", args[1])
+		return err
+	}
+
+	data := encoding.EncodeArgs([]byte(args[1]), []byte(args[2]))
+	return c.submitOperation(consensus.Operation_SETRANGE, args[0], data)
+}
+
+func (c *Client) processCAS(arg string) error {
+	args := strings.SplitN(arg, " ", 3)
+	if len(args) != 3 {
+		fmt.Println("CAS function expects three arguments: (key, expected, data)")
+		return errors.New("invalid arguments")
+	}
+
+	data := encoding.EncodeArgs([]byte(args[1]), []byte(args[2]))
+	return c.submitOperation(consensus.Operation_CAS, args[0], data)
+}
@@ -10,6 +10,7 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -32,31 +33,26 @@ func (c *Client) Submit(ctx context.Context, tx *api.Transaction) (uuid string,
 	return
 }
 
-func (c *Client) processGeneric2(op string) func(arg string) error {
-	return func(arg string) error {
-		arg1, arg2, err := split2args(arg)
-		if err != nil {
-			fmt.Println(op, "function expects two arguments: (key, data)")
-			return err
-		}
-
-		timeout := c.txTimeout
-		if timeout == 0 {
-			timeout = 5 * time.Second
-		}
+// ErrNotCommitted is returned in wait mode (see SetWait) when a transaction
+// has not been committed and written by the endpoint.
+var ErrNotCommitted = errors.New("transaction not committed")
 
-		deadline, _ := ptypes.TimestampProto(time.Now().Add(timeout))
+// SubmitAndWait submits the transaction to the endpoint, and waits until it
+// is dropped, committed and written, or still pending at its deadline.
+func (c *Client) SubmitAndWait(ctx context.Context, tx *api.Transaction) (*api.QueryStatus, error) {
+	return c.client.SubmitAndWait(ctx, tx)
+}
 
-		tx := &api.Transaction{
-			Operations: []*consensus.Operation{{
-				Key:  arg1,
-				Op:   consensus.Operation_Op(consensus.Operation_Op_value[op]),
-				Data: []byte(arg2),
-			}},
-			Policy:   c.policy,
-			Deadline: deadline,
-		}
+// SetWait sets whether the next transactions are waited for before returning,
+// so that their values can be read as soon as they are committed.
+func (c *Client) SetWait(wait bool) {
+	c.wait = wait
+}
 
+// submit submits a transaction, and prints its uuid. In wait mode, it also
+// waits for the transaction and prints its final state.
+func (c *Client) submit(tx *api.Transaction) error {
+	if !c.wait {
 		ctx, done := c.ctx()
 		defer done()
 
@@ -69,6 +65,124 @@ func (c *Client) processGeneric2(op string) func(arg string) error {
 		fmt.Println(uuid)
 		return nil
 	}
+
+	timeout := c.Timeout
+	if tx.Deadline != nil {
+		deadline, _ := ptypes.Timestamp(tx.Deadline)
+		timeout += time.Until(deadline)
+	}
+	ctx, done := context.WithTimeout(context.Background(), timeout)
+	defer done()
+
+	st, err := c.SubmitAndWait(ctx, tx)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	fmt.Println(st.Uuid, st.State)
+	if st.ApplyError != "" {
+		fmt.Println("Error:", st.ApplyError)
+	}
+	if !st.Applied {
+		return ErrNotCommitted
+	}
+	return nil
+}
+
+func (c *Client) processSETW(arg string) error {
+	wait := c.wait
+	c.wait = true
+	defer func() { c.wait = wait }()
+	return c.processGeneric2("SET")(arg)
+}
+
+// Status returns the status of a submitted transaction.
+func (c *Client) Status(ctx context.Context, uuid string) (*api.QueryStatus, error) {
+	return c.client.GetStatus(ctx, &api.Receipt{Uuid: uuid})
+}
+
+func (c *Client) processSTATUS(uuid string) error {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		err := fmt.Errorf("usage: STATUS uuid")
+		fmt.Println(err)
+		return err
+	}
+
+	ctx, done := c.ctx()
+	defer done()
+
+	st, err := c.Status(ctx, uuid)
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+		return err
+	}
+
+	fmt.Printf("state\t%s\n", st.State)
+	if st.State == string(consensus.StateUnknown) {
+		return nil
+	}
+	fmt.Printf("endorsements\t%d\n", st.Endorsements)
+	if st.Deadline != nil {
+		t, _ := ptypes.Timestamp(st.Deadline)
+		fmt.Printf("deadline\t%s\n", t.Format(time.RFC3339))
+	}
+	if st.Resolved != nil {
+		t, _ := ptypes.Timestamp(st.Resolved)
+		fmt.Printf("resolved\t%s\n", t.Format(time.RFC3339))
+	}
+	if st.ApplyError != "" {
+		fmt.Printf("apply error\t%s\n", st.ApplyError)
+	} else if st.Applied {
+		fmt.Println("applied")
+	}
+	return nil
+}
+
+func (c *Client) processGeneric2(op string) func(arg string) error {
+	return func(arg string) error {
+		arg1, arg2, err := split2args(arg)
+		if err != nil {
+			fmt.Println(op, "function expects two arguments: (key, data)")
+			return err
+		}
+
+		return c.submitOperation(consensus.Operation_Op(consensus.Operation_Op_value[op]), arg1, []byte(arg2))
+	}
+}
+
+// submitOperation submits a transaction made of a single operation, or
+// queues the operation if a transaction is in progress.
+func (c *Client) submitOperation(op consensus.Operation_Op, key string, data []byte) error {
+	return c.submitExpiring(op, key, data, 0)
+}
+
+// submitExpiring is submitOperation, the key expiring ttl after the deadline
+// of the transaction if ttl is not zero.
+func (c *Client) submitExpiring(op consensus.Operation_Op, key string, data []byte, ttl time.Duration) error {
+	o := newExpiringOperation(op, key, data, ttl)
+	if c.dryRun {
+		return c.dryRunOperation(o)
+	}
+
+	if c.multi != nil {
+		c.multi.AddExpiring(op, key, data, ttl)
+		fmt.Println("QUEUED")
+		return nil
+	}
+
+	timeout := c.TxTimeout()
+
+	deadline, _ := ptypes.TimestampProto(time.Now().Add(timeout))
+
+	tx := &api.Transaction{
+		Operations: []*consensus.Operation{o},
+		Policy:     c.policy,
+		Deadline:   deadline,
+	}
+
+	return c.submit(tx)
 }
 
 func split2args(arg string) (arg1, arg2 string, err error) {
@@ -0,0 +1,166 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+// SnapshotRequirements returns the versions of several keys, read
+// atomically by the endpoint. Missing keys are mapped to the empty version.
+func (c *Client) SnapshotRequirements(ctx context.Context, keys []string) (map[string]*consensus.Version, error) {
+	res, err := c.client.SnapshotRequirements(ctx, &api.KeyList{Keys: keys})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Requirements, nil
+}
+
+// TransactionBuilder builds a transaction made of several operations.
+type TransactionBuilder struct {
+	tx      *api.Transaction
+	timeout time.Duration
+}
+
+// NewTransactionBuilder returns a builder for a transaction expiring
+// timeout after it is built.
+func NewTransactionBuilder(policy string, timeout time.Duration) *TransactionBuilder {
+	return &TransactionBuilder{
+		tx: &api.Transaction{
+			Policy:       policy,
+			Requirements: make(map[string]*consensus.Version),
+		},
+		timeout: timeout,
+	}
+}
+
+// Require requires the version of a key to match v when the transaction
+// is applied.
+func (b *TransactionBuilder) Require(key string, v *consensus.Version) *TransactionBuilder {
+	b.tx.Requirements[key] = v
+	return b
+}
+
+// FromSnapshot requires the current versions of keys, read atomically by
+// the endpoint. The transaction will only be applied if none of these keys
+// has been modified in the meantime.
+func (b *TransactionBuilder) FromSnapshot(ctx context.Context, c *Client, keys ...string) error {
+	versions, err := c.SnapshotRequirements(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	for key, v := range versions {
+		b.Require(key, v)
+	}
+	return nil
+}
+
+// Add appends an operation to the transaction. Operations which are not
+// idempotent are given a nonce, so that they are applied once even if the
+// transaction is submitted again.
+func (b *TransactionBuilder) Add(op consensus.Operation_Op, key string, data []byte) *TransactionBuilder {
+	b.tx.Operations = append(b.tx.Operations, newOperation(op, key, data))
+	return b
+}
+
+// AddExpiring appends an operation whose key expires ttl after the deadline
+// of the transaction, see Add. Only SET, CONCAT, REPLACE, TRUNCATE, SETRANGE
+// and CAS accept a TTL.
+func (b *TransactionBuilder) AddExpiring(op consensus.Operation_Op, key string, data []byte, ttl time.Duration) *TransactionBuilder {
+	b.tx.Operations = append(b.tx.Operations, newExpiringOperation(op, key, data, ttl))
+	return b
+}
+
+// newOperation returns an operation, with a nonce if it is not idempotent.
+func newOperation(op consensus.Operation_Op, key string, data []byte) *consensus.Operation {
+	o := &consensus.Operation{
+		Key:  key,
+		Op:   op,
+		Data: data,
+	}
+	if !o.Idempotent() {
+		o.Nonce = consensus.NewNonce()
+	}
+	return o
+}
+
+// newExpiringOperation returns an operation with a TTL, unless ttl is zero.
+func newExpiringOperation(op consensus.Operation_Op, key string, data []byte, ttl time.Duration) *consensus.Operation {
+	o := newOperation(op, key, data)
+	if ttl != 0 {
+		o.Ttl = ptypes.DurationProto(ttl)
+	}
+	return o
+}
+
+// Transaction returns the built transaction, setting its deadline.
+func (b *TransactionBuilder) Transaction() *api.Transaction {
+	b.tx.Deadline, _ = ptypes.TimestampProto(time.Now().Add(b.timeout))
+	return b.tx
+}
+
+func (c *Client) processMULTI(arg string) error {
+	if c.multi != nil {
+		fmt.Println("Error: a transaction is already in progress, use EXEC or DISCARD")
+		return errors.New("nested transaction")
+	}
+
+	timeout := c.TxTimeout()
+	b := NewTransactionBuilder(c.policy, timeout)
+
+	args := strings.Fields(arg)
+	switch {
+	case len(args) == 0:
+	case len(args) == 2 && args[0] == "--require-snapshot":
+		ctx, done := c.ctx()
+		defer done()
+
+		err := b.FromSnapshot(ctx, c, strings.Split(args[1], ",")...)
+		if err != nil {
+			fmt.Println("Error:", status.Convert(err).Message())
+			return err
+		}
+	default:
+		fmt.Println("MULTI function expects no argument, or: --require-snapshot key1,key2,...")
+		return errors.New("invalid arguments")
+	}
+
+	c.multi = b
+	fmt.Println("OK")
+	return nil
+}
+
+func (c *Client) processEXEC(string) error {
+	if c.multi == nil {
+		fmt.Println("Error: no transaction in progress, use MULTI first")
+		return errors.New("no transaction")
+	}
+
+	tx := c.multi.Transaction()
+	c.multi = nil
+	return c.submit(tx)
+}
+
+func (c *Client) processDISCARD(string) error {
+	c.multi = nil
+	fmt.Println("OK")
+	return nil
+}
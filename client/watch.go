@@ -0,0 +1,81 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+)
+
+// Watch calls handler every time a key starting with prefix is written by a
+// committed transaction, until the context is done or the handler returns an
+// error. Updates are dropped by the endpoint when the handler is too slow,
+// as reported by their Dropped field.
+func (c *Client) Watch(ctx context.Context, prefix string, handler func(*api.KeyUpdate) error) error {
+	stream, err := c.client.Watch(ctx, &api.WatchRequest{Prefix: prefix})
+	if err != nil {
+		return err
+	}
+
+	for {
+		u, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = handler(u)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) processWATCH(prefix string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Watch until interrupted
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := c.Watch(ctx, strings.TrimSpace(prefix), func(u *api.KeyUpdate) error {
+		if u.Dropped > 0 {
+			fmt.Printf("(%d updates dropped)\n", u.Dropped)
+		}
+		fmt.Printf("%s 0x%x %s\n", u.Key, u.Version.GetHash(), u.Uuid)
+		return nil
+	})
+	if status.Code(err) == codes.Canceled {
+		return nil
+	}
+	if err != nil {
+		fmt.Println("Error:", status.Convert(err).Message())
+	}
+	return err
+}
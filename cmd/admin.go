@@ -0,0 +1,263 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/client"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/keyring"
+)
+
+var errDropMismatch = errors.New("the statement drops another query, or for another reason")
+
+var adminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Administer the cluster, including operations requiring a quorum of administrators",
+}
+
+var dropStatement, dropReason *string
+
+var adminProposeDropCmd = &cobra.Command{
+	Use:   "propose-drop [uuid]",
+	Short: "Sign a statement dropping a stuck query",
+	Long: `Sign a statement dropping a stuck query.
+
+The statement is written to --statement, or to "<uuid>.drop" by default. If
+the file already exists, the signature of the local identity is added to it:
+pass it around until enough administrators have signed, as configured by
+"policy.admins" on the nodes, then send it with "pnyxdb admin submit-drop".`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := *dropStatement
+		if path == "" {
+			path = args[0] + ".drop"
+		}
+
+		keyRing := getKeyRing()
+		unlockKeyRing(keyRing)
+
+		d, err := signDropStatement(path, args[0], *dropReason, keyRing)
+		check(err)
+
+		admins := make([]string, len(d.Signatures))
+		for i, s := range d.Signatures {
+			admins[i] = s.Admin
+		}
+		fmt.Printf("Statement %s signed by %d administrators: %s\n", path, len(admins), strings.Join(admins, ", "))
+	},
+}
+
+// signDropStatement adds the signature of the keyring to the drop statement
+// stored in path, creating it if needed.
+func signDropStatement(path, uuid, reason string, keyRing *keyring.KeyRing) (*consensus.AdminDrop, error) {
+	d := &consensus.AdminDrop{Uuid: uuid, Reason: reason}
+
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = proto.Unmarshal(data, d)
+		if err != nil {
+			return nil, err
+		}
+		if d.Uuid != uuid || reason != "" && d.Reason != reason {
+			return nil, errDropMismatch
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	err = consensus.SignAdminDrop(d, keyRing)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = proto.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return d, ioutil.WriteFile(path, data, 0644)
+}
+
+var adminServer *string
+var adminTimeout *time.Duration
+
+var adminSubmitDropCmd = &cobra.Command{
+	Use:   "submit-drop [statement]",
+	Short: "Send a statement signed by enough administrators to drop a query",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := ioutil.ReadFile(args[0])
+		check(err)
+
+		d := &consensus.AdminDrop{}
+		check(proto.Unmarshal(data, d))
+
+		cli := &client.Client{
+			Addr:    *adminServer,
+			Timeout: *adminTimeout,
+		}
+		check(cli.Connect())
+		defer cli.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), *adminTimeout)
+		defer cancel()
+		check(cli.AdminDrop(ctx, d))
+
+		fmt.Println("Drop of query", d.Uuid, "submitted")
+	},
+}
+
+var clusterServer *string
+var clusterTimeout *time.Duration
+
+var adminClusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Show the status of every node, as broadcast to a server",
+	Long: `Show the status of every node, as broadcast to a server.
+
+Nodes broadcast their status when "status.period" is set; the statuses that
+have not been refreshed for a few periods are omitted. The first line is the
+status of the server itself.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli := &client.Client{
+			Addr:    *clusterServer,
+			Timeout: *clusterTimeout,
+		}
+		check(cli.Connect())
+		defer cli.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), *clusterTimeout)
+		defer cancel()
+		nodes, err := cli.ClusterStatus(ctx)
+		check(err)
+
+		printClusterStatus(os.Stdout, nodes, localKeyRing(), time.Now())
+	},
+}
+
+// printClusterStatus writes a table of node statuses. Identities are shown
+// with their display name in keyRing, which may be nil.
+func printClusterStatus(out io.Writer, nodes []*consensus.NodeStatus, keyRing *keyring.KeyRing, now time.Time) {
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Identity", "Version", "Pending", "Checkpoints", "Keys", "Last commit", "Uptime", "Age"})
+	table.SetAutoFormatHeaders(false)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+	for _, n := range nodes {
+		lastCommit := "-"
+		if n.LastCommit != nil {
+			t, _ := ptypes.Timestamp(n.LastCommit)
+			lastCommit = t.Format(time.RFC3339)
+		}
+		identity := n.Emitter
+		if keyRing != nil {
+			if name := keyRing.DisplayName(n.Emitter); name != "" {
+				identity = fmt.Sprintf("%s (%s)", n.Emitter, name)
+			}
+		}
+
+		t, _ := ptypes.Timestamp(n.Time)
+		table.Append([]string{
+			identity,
+			n.Version,
+			strconv.FormatUint(uint64(n.Pending), 10),
+			strconv.FormatUint(uint64(n.PendingCheckpoints), 10),
+			strconv.FormatUint(n.Keys, 10),
+			lastCommit,
+			(time.Duration(n.Uptime) * time.Second).String(),
+			now.Sub(t).Round(time.Second).String(),
+		})
+	}
+
+	table.Render()
+}
+
+var unknownServer *string
+var unknownTimeout *time.Duration
+
+var adminUnknownCmd = &cobra.Command{
+	Use:   "unknown",
+	Short: "List the identities missing from the keyring of a server",
+	Long: `List the identities missing from the keyring of a server, whose queries
+or endorsements it received recently.
+
+The server keeps the latest queries of these identities in quarantine, and
+handles them as soon as their key is imported in its keyring.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli := &client.Client{
+			Addr:    *unknownServer,
+			Timeout: *unknownTimeout,
+		}
+		check(cli.Connect())
+		defer cli.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), *unknownTimeout)
+		defer cancel()
+		emitters, err := cli.UnknownEmitters(ctx)
+		check(err)
+
+		printUnknownEmitters(os.Stdout, emitters, time.Now())
+	},
+}
+
+// printUnknownEmitters writes a table of unknown emitters.
+func printUnknownEmitters(out io.Writer, emitters []*api.UnknownEmitter, now time.Time) {
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Identity", "Messages", "Quarantined", "First seen", "Last seen"})
+	table.SetAutoFormatHeaders(false)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+	for _, u := range emitters {
+		firstSeen, _ := ptypes.Timestamp(u.FirstSeen)
+		lastSeen, _ := ptypes.Timestamp(u.LastSeen)
+		table.Append([]string{
+			u.Identity,
+			strconv.FormatUint(u.Messages, 10),
+			strconv.FormatUint(uint64(u.Quarantined), 10),
+			firstSeen.Format(time.RFC3339),
+			now.Sub(lastSeen).Round(time.Second).String() + " ago",
+		})
+	}
+
+	table.Render()
+}
+
+func init() {
+	dropStatement = adminProposeDropCmd.Flags().String("statement", "", "file holding the statement (default is <uuid>.drop)")
+	dropReason = adminProposeDropCmd.Flags().String("reason", "", "reason of the drop, set by the first administrator")
+	adminServer = adminSubmitDropCmd.Flags().StringP("server", "s", "localhost:4200", "server address")
+	adminTimeout = adminSubmitDropCmd.Flags().DurationP("timeout", "t", 10*time.Second, "connection timeout")
+
+	clusterServer = adminClusterCmd.Flags().StringP("server", "s", "localhost:4200", "server address")
+	clusterTimeout = adminClusterCmd.Flags().DurationP("timeout", "t", 10*time.Second, "connection timeout")
+
+	unknownServer = adminUnknownCmd.Flags().StringP("server", "s", "localhost:4200", "server address")
+	unknownTimeout = adminUnknownCmd.Flags().DurationP("timeout", "t", 10*time.Second, "connection timeout")
+
+	adminCmd.AddCommand(adminProposeDropCmd, adminSubmitDropCmd, adminClusterCmd, adminUnknownCmd)
+	RootCmd.AddCommand(adminCmd)
+}
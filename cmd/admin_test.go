@@ -0,0 +1,66 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/keyring"
+)
+
+func TestSignDropStatement(t *testing.T) {
+	defer memguard.DestroyAll()
+
+	password, err := memguard.NewImmutableFromBytes([]byte("password"))
+	require.Nil(t, err)
+
+	dir, err := ioutil.TempDir("", "admin")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, "stuck.drop")
+
+	// Every administrator trusts the others
+	rings := make(map[string]*keyring.KeyRing)
+	for _, identity := range []string{"alice", "bob", "carol"} {
+		k, err := keyring.NewKeyRing(identity, "ed25519")
+		require.Nil(t, err)
+		require.Nil(t, k.CreatePrivate(password))
+		rings[identity] = k
+	}
+	for identity, k := range rings {
+		public, _, _ := k.GetPublic(identity)
+		for other, k2 := range rings {
+			if other != identity {
+				require.Nil(t, k2.AddPublic(identity, keyring.TrustHIGH, public))
+			}
+		}
+	}
+	admins := []string{"alice", "bob", "carol"}
+
+	d, err := signDropStatement(path, "stuck", "wedged", rings["alice"])
+	require.Nil(t, err)
+	require.Len(t, d.Signatures, 1)
+	require.Exactly(t, consensus.ErrAdminQuorum, consensus.VerifyAdminDrop(d, rings["carol"], admins, 2))
+
+	_, err = signDropStatement(path, "other", "", rings["bob"])
+	require.Exactly(t, errDropMismatch, err)
+	_, err = signDropStatement(path, "stuck", "another reason", rings["bob"])
+	require.Exactly(t, errDropMismatch, err)
+
+	d, err = signDropStatement(path, "stuck", "", rings["bob"])
+	require.Nil(t, err)
+	require.Exactly(t, "wedged", d.Reason)
+	require.Len(t, d.Signatures, 2)
+	require.Nil(t, consensus.VerifyAdminDrop(d, rings["carol"], admins, 2))
+}
@@ -0,0 +1,89 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/awnumar/memguard"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/technicolor-research/pnyxdb/keyring"
+)
+
+// unlockKeyRing makes the private key of the keyring usable, either through
+// the agent configured by `keyring.agent`, or by decrypting it with the
+// password.
+func unlockKeyRing(keyRing *keyring.KeyRing) {
+	path := viper.GetString("keyring.agent")
+	if path == "" {
+		check(keyRing.UnlockPrivate(getPassword()))
+		return
+	}
+
+	signer, err := keyring.DialAgent(path)
+	check(err)
+	check(keyRing.SetSigner(signer))
+}
+
+var agentKeyRing *string
+
+var keysAgentCmd = &cobra.Command{
+	Use:   "agent [socket]",
+	Short: "Serve the private key to other processes through a Unix socket",
+	Long: `Serve the private key to other processes through a Unix socket.
+
+Nodes configured with "keyring.agent: /path/to/socket" delegate their
+signatures to the agent, and never read the private key. This command is a
+reference agent, holding the private key of a keyring in memory: agents
+backed by hardware tokens shall implement the same protocol.
+
+The socket is only accessible by the current user.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var keyRing *keyring.KeyRing
+		if *agentKeyRing != "" {
+			rawKeyRing, err := ioutil.ReadFile(*agentKeyRing)
+			check(err)
+			keyRing, err = keyring.NewKeyRing(getSelfIdentity(), keyring.CryptoOf(rawKeyRing))
+			check(err)
+			unmarshalKeyRing(keyRing, rawKeyRing)
+		} else {
+			keyRing = getKeyRing()
+		}
+		check(keyRing.UnlockPrivate(getPassword()))
+
+		oldMask := syscall.Umask(0077)
+		l, err := net.Listen("unix", args[0])
+		syscall.Umask(oldMask)
+		check(err)
+
+		go func() {
+			c := make(chan os.Signal, 2)
+			signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+			<-c
+			_ = l.Close() // removes the socket
+			memguard.SafeExit(0)
+		}()
+
+		fmt.Fprintf(os.Stderr, "Serving the private key of %s on %s\n", keyRing.Identity(), args[0])
+		check(keyring.ServeAgent(l, keyRing))
+	},
+}
+
+func init() {
+	agentKeyRing = keysAgentCmd.Flags().String("keyring", "", "keyring holding the private key (default is the configured keyring)")
+	keysCmd.AddCommand(keysAgentCmd)
+}
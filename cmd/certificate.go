@@ -0,0 +1,80 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/keyring"
+)
+
+var verifyThreshold *int
+var verifyKeyRing *string
+
+var verifyCertificateCmd = &cobra.Command{
+	Use:   "verify-certificate [file]",
+	Short: "Verify a commit certificate offline against a keyring",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := ioutil.ReadFile(args[0])
+		check(err)
+
+		cert := &consensus.CommitCertificate{}
+		check(proto.Unmarshal(data, cert))
+
+		// The identity only names the private key of the keyring, if any
+		identity := viper.GetString("identity")
+		if identity == "" {
+			identity = "<self>"
+		}
+
+		var rawKeyRing []byte
+		if *verifyKeyRing != "" {
+			rawKeyRing, err = ioutil.ReadFile(*verifyKeyRing)
+		} else {
+			rawKeyRing, err = loadKeyRing()
+		}
+		check(err)
+		keyRing, err := keyring.NewKeyRing(identity, keyring.CryptoOf(rawKeyRing))
+		check(err)
+		unmarshalKeyRing(keyRing, rawKeyRing)
+		keyRing.SetMaxTrustDepth(maxTrustDepth())
+		keyRing.SetMaxSignatureAge(viper.GetDuration("trust.maxsignatureage"))
+
+		// The threshold claimed by the certificate is not trusted
+		threshold := *verifyThreshold
+		if threshold == 0 {
+			threshold = viper.GetInt("w")
+		}
+		if threshold < 1 {
+			check(errors.New("unknown threshold: use --threshold, or set w in the configuration file"))
+		}
+		if int(cert.Threshold) < threshold {
+			check(fmt.Errorf("certificate claims a threshold of %d, below the required %d", cert.Threshold, threshold))
+		}
+
+		check(consensus.VerifyCertificate(cert, keyRing, threshold))
+
+		fmt.Printf("Valid certificate: query %s by %s, endorsed by %d/%d nodes, attested by %s\n",
+			cert.Query.Uuid, cert.Query.Emitter, len(cert.Endorsements), threshold, cert.Attester)
+	},
+}
+
+func init() {
+	verifyThreshold = verifyCertificateCmd.Flags().Int("threshold", 0, "minimum number of endorsements (default is w, from the configuration file)")
+	verifyKeyRing = verifyCertificateCmd.Flags().String("keyring", "", "keyring used to check the signatures (default is the configured keyring)")
+	RootCmd.AddCommand(verifyCertificateCmd)
+}
@@ -22,6 +22,7 @@ var addrSrv *string
 var timeoutSrv *time.Duration
 var policy *string
 var txTimeout *time.Duration
+var clientKeyRing, clientWait *bool
 
 // clientCmd represents the client command
 var clientCmd = &cobra.Command{
@@ -32,12 +33,17 @@ var clientCmd = &cobra.Command{
 			Addr:    *addrSrv,
 			Timeout: *timeoutSrv,
 		}
+		if *clientKeyRing {
+			cli.KeyRing = getKeyRing()
+			check(cli.KeyRing.UnlockPrivate(getPassword()))
+		}
 
 		err := cli.Connect()
 		check(err)
 
 		_ = cli.SetPolicy(*policy)
 		_ = cli.SetTxTimeout(txTimeout.String())
+		cli.SetWait(*clientWait)
 
 		var status int
 		if len(args) == 0 {
@@ -59,4 +65,6 @@ func init() {
 	timeoutSrv = clientCmd.Flags().DurationP("timeout", "t", 10*time.Second, "connection timeout")
 	policy = clientCmd.Flags().StringP("policy", "p", "none", "default policy to use when submitting")
 	txTimeout = clientCmd.Flags().DurationP("txtimeout", "x", 5*time.Second, "transaction timeout")
+	clientWait = clientCmd.Flags().BoolP("wait", "w", false, "wait until transactions are committed and written (or dropped) before returning")
+	clientKeyRing = clientCmd.Flags().BoolP("keyring", "k", false, "unlock the keyring to encrypt and decrypt values (SETENC, GETENC)")
 }
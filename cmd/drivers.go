@@ -0,0 +1,158 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/keyring"
+)
+
+type driverConstructor func(string) (consensus.Store, error)
+type networkConstructor func(context.Context, *keyring.KeyRing) (consensus.Network, error)
+
+// driverInfo describes a driver compiled in the binary.
+type driverInfo struct {
+	name   string
+	module string // module providing the driver implementation, used to report its version
+}
+
+var storeDrivers map[string]driverConstructor
+var networkDrivers map[string]networkConstructor
+var compiledDrivers = map[string][]driverInfo{}
+
+// optionalDrivers lists the drivers that are only compiled when the
+// corresponding build tag is provided, by kind and name.
+var optionalDrivers = map[string]map[string]string{
+	"database": {},
+	"network": {
+		"redis": "redis",
+	},
+}
+
+func addDriver(name, module string, c driverConstructor) {
+	if storeDrivers == nil {
+		storeDrivers = make(map[string]driverConstructor)
+	}
+
+	storeDrivers[name] = c
+	compiledDrivers["database"] = append(compiledDrivers["database"], driverInfo{name, module})
+}
+
+func addNetwork(name, module string, c networkConstructor) {
+	if networkDrivers == nil {
+		networkDrivers = make(map[string]networkConstructor)
+	}
+
+	networkDrivers[name] = c
+	compiledDrivers["network"] = append(compiledDrivers["network"], driverInfo{name, module})
+}
+
+// missingDriverError returns a helpful error for a driver that is not
+// available in this binary.
+func missingDriverError(kind, name string) error {
+	if tag, ok := optionalDrivers[kind][name]; ok {
+		return fmt.Errorf("%s driver %q is not compiled in this binary: rebuild with `-tags %s` to enable it", kind, name, tag)
+	}
+	return fmt.Errorf("unknown %s driver: %s (see `pnyxdb drivers`)", kind, name)
+}
+
+func getDriver(name string, path string) (consensus.Store, error) {
+	c, ok := storeDrivers[name]
+	if !ok {
+		return nil, missingDriverError("database", name)
+	}
+
+	return c(path)
+}
+
+func getNetwork(ctx context.Context, name string, keyRing *keyring.KeyRing) (consensus.Network, error) {
+	c, ok := networkDrivers[name]
+	if !ok {
+		return nil, missingDriverError("network", name)
+	}
+
+	return c(ctx, keyRing)
+}
+
+// moduleVersion returns the version of a dependency of the running binary.
+func moduleVersion(module string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	if info.Main.Path == module {
+		return info.Main.Version
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == module {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
+
+func writeDrivers(w io.Writer) {
+	for _, kind := range []string{"database", "network"} {
+		fmt.Fprintf(w, "Compiled %s drivers:\n", kind)
+
+		drivers := compiledDrivers[kind]
+		sort.Slice(drivers, func(i, j int) bool { return drivers[i].name < drivers[j].name })
+		for _, d := range drivers {
+			fmt.Fprintf(w, "  * %-12s %s %s\n", d.name, d.module, moduleVersion(d.module))
+		}
+
+		var missing []string
+		for name := range optionalDrivers[kind] {
+			if isCompiled(kind, name) {
+				continue
+			}
+			missing = append(missing, name)
+		}
+
+		sort.Strings(missing)
+		for _, name := range missing {
+			fmt.Fprintf(w, "  - %-12s (requires `-tags %s`)\n", name, optionalDrivers[kind][name])
+		}
+	}
+}
+
+func isCompiled(kind, name string) bool {
+	for _, d := range compiledDrivers[kind] {
+		if d.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+var driversCmd = &cobra.Command{
+	Use:   "drivers",
+	Short: "List the database and network drivers compiled in this binary",
+	Run: func(cmd *cobra.Command, args []string) {
+		writeDrivers(os.Stdout)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(driversCmd)
+}
@@ -0,0 +1,30 @@
+//go:build !redis
+// +build !redis
+
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrivers_RedisNotCompiled(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeDrivers(buf)
+	require.Contains(t, buf.String(), "- redis        (requires `-tags redis`)")
+
+	_, err := getNetwork(context.Background(), "redis", nil)
+	require.NotNil(t, err)
+	require.Equal(t, "network driver \"redis\" is not compiled in this binary: rebuild with `-tags redis` to enable it", err.Error())
+}
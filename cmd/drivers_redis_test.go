@@ -0,0 +1,27 @@
+//go:build redis
+// +build redis
+
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrivers_RedisCompiled(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeDrivers(buf)
+	require.Contains(t, buf.String(), "* redis")
+	require.NotContains(t, buf.String(), "-tags redis")
+	require.Contains(t, networkDrivers, "redis")
+}
@@ -0,0 +1,33 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDrivers(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeDrivers(buf)
+
+	require.Contains(t, buf.String(), "Compiled database drivers:")
+	require.Contains(t, buf.String(), "* boltdb")
+	require.Contains(t, buf.String(), "Compiled network drivers:")
+	require.Contains(t, buf.String(), "* gossipsub")
+}
+
+func TestGetDriver_Unknown(t *testing.T) {
+	_, err := getDriver("unknown", "")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "unknown database driver: unknown")
+	require.Contains(t, err.Error(), "pnyxdb drivers")
+}
@@ -0,0 +1,191 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+var errNothingToStrip = errors.New("nothing to strip: provide --uuid or --resolved")
+
+var dumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Inspect and edit dump files offline",
+	Long: `Inspect and edit dump files offline.
+
+The server must be stopped while its dump file is edited, since it rewrites
+the file periodically.`,
+}
+
+var inspectUUIDs *[]string
+
+var dumpInspectCmd = &cobra.Command{
+	Use:   "inspect [file]",
+	Short: "Print a summary of a dump file, or the details of some queries",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		d, err := readDumpFile(args[0])
+		check(err)
+
+		if len(*inspectUUIDs) == 0 {
+			printDumpSummary(os.Stdout, d.Summary())
+			return
+		}
+
+		for i, uuid := range *inspectUUIDs {
+			if i > 0 {
+				fmt.Println()
+			}
+
+			q, ok := d.Query(uuid)
+			if !ok {
+				fmt.Println("Unknown query", uuid)
+				continue
+			}
+			printDumpedQuery(os.Stdout, uuid, q)
+		}
+	},
+}
+
+var stripUUIDs *[]string
+var stripResolved *bool
+var stripOutput *string
+
+var dumpStripCmd = &cobra.Command{
+	Use:   "strip [file]",
+	Short: "Remove queries from a dump file",
+	Long: `Remove queries from a dump file, along with their endorsements.
+
+Queries are selected by UUID, or with --resolved, every dropped query and every
+query whose deadline has been reached, except committed queries that are not
+applied yet. The file is rewritten in place, unless --output is provided.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(*stripUUIDs) == 0 && !*stripResolved {
+			check(errNothingToStrip)
+		}
+
+		d, err := readDumpFile(args[0])
+		check(err)
+
+		n := d.Strip(*stripUUIDs...)
+		if n < len(*stripUUIDs) {
+			fmt.Fprintln(os.Stderr, "!!", len(*stripUUIDs)-n, "unknown query(ies) ignored")
+		}
+		if *stripResolved {
+			n += len(d.StripResolved(time.Now()))
+		}
+
+		output := *stripOutput
+		if output == "" {
+			output = args[0]
+		}
+		check(writeDumpFile(output, d))
+
+		fmt.Printf("Removed %d query(ies), dump written to %s\n", n, output)
+	},
+}
+
+func readDumpFile(path string) (*consensus.DumpFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	return consensus.ReadDump(file)
+}
+
+// writeDumpFile writes a dump through a temporary file, so that a server
+// never loads a partially written dump.
+func writeDumpFile(path string, d *consensus.DumpFile) error {
+	buf := &bytes.Buffer{}
+	err := d.Write(buf)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(path+".tmp", buf.Bytes(), 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func printDumpSummary(out io.Writer, s consensus.DumpSummary) {
+	var total int
+	for _, n := range s.States {
+		total += n
+	}
+
+	fmt.Fprintf(out, "Queries: %d (%s %d, %s %d, %s %d)\n", total,
+		consensus.StatePending, s.States[consensus.StatePending],
+		consensus.StateCommitted, s.States[consensus.StateCommitted],
+		consensus.StateDropped, s.States[consensus.StateDropped],
+	)
+	fmt.Fprintln(out, "Pending endorsements:", s.PendingEndorsements)
+	if !s.OldestDeadline.IsZero() {
+		fmt.Fprintln(out, "Oldest deadline:", s.OldestDeadline.Format(time.RFC3339))
+		fmt.Fprintln(out, "Newest deadline:", s.NewestDeadline.Format(time.RFC3339))
+	}
+}
+
+func printDumpedQuery(out io.Writer, uuid string, q consensus.DumpedQuery) {
+	state := string(q.Status.State)
+	switch {
+	case q.Status.ApplyError != nil:
+		state += ", apply failed: " + q.Status.ApplyError.Error()
+	case q.Status.Applied:
+		state += ", applied"
+	}
+
+	fmt.Fprintln(out, "Query:", uuid)
+	fmt.Fprintln(out, "State:", state)
+	if !q.Status.Resolved.IsZero() {
+		fmt.Fprintln(out, "Resolved:", q.Status.Resolved.Format(time.RFC3339))
+	}
+	fmt.Fprintf(out, "Endorsers (%d): %s\n", len(q.Endorsers), strings.Join(q.Endorsers, ", "))
+	if len(q.Dependents) > 0 {
+		fmt.Fprintln(out, "Dependents:", strings.Join(q.Dependents, ", "))
+	}
+
+	if q.Query == nil {
+		fmt.Fprintln(out, "Content: unknown (committed through a checkpoint)")
+		return
+	}
+
+	fmt.Fprintln(out, "Emitter:", q.Emitter)
+	fmt.Fprintln(out, "Deadline:", q.Status.Deadline.Format(time.RFC3339))
+	fmt.Fprintln(out, "Operations:")
+	for _, op := range q.Operations {
+		fmt.Fprintf(out, "- %s %s (%d bytes)\n", op.Op, op.Key, len(op.Data))
+	}
+}
+
+func init() {
+	inspectUUIDs = dumpInspectCmd.Flags().StringSliceP("uuid", "u", nil, "print the details of these queries")
+
+	stripUUIDs = dumpStripCmd.Flags().StringSliceP("uuid", "u", nil, "queries to remove")
+	stripResolved = dumpStripCmd.Flags().Bool("resolved", false, "remove dropped and expired queries")
+	stripOutput = dumpStripCmd.Flags().StringP("output", "o", "", "file to write (default is the input file)")
+
+	dumpCmd.AddCommand(dumpInspectCmd, dumpStripCmd)
+	RootCmd.AddCommand(dumpCmd)
+}
@@ -0,0 +1,104 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+func TestDumpFile_RoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dump")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, ".dump.p")
+
+	buf := &bytes.Buffer{}
+	require.Nil(t, consensus.NewEngine(nil, nil, nil, nil, 1).Dump(buf))
+	require.Nil(t, ioutil.WriteFile(path, buf.Bytes(), 0600))
+
+	d, err := readDumpFile(path)
+	require.Nil(t, err)
+	require.Equal(t, 0, d.Strip("unknown"))
+	require.Nil(t, writeDumpFile(path, d))
+
+	_, err = os.Stat(path + ".tmp")
+	require.True(t, os.IsNotExist(err), "temporary file should be renamed")
+
+	file, err := os.Open(path)
+	require.Nil(t, err)
+	defer func() { _ = file.Close() }()
+	require.Nil(t, consensus.NewEngine(nil, nil, nil, nil, 1).Load(file), "engine should load the rewritten dump")
+
+	_, err = readDumpFile(filepath.Join(dir, "missing"))
+	require.NotNil(t, err)
+}
+
+func TestPrintDumpSummary(t *testing.T) {
+	deadline := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	buf := &bytes.Buffer{}
+	printDumpSummary(buf, consensus.DumpSummary{
+		States:              map[consensus.QueryState]int{consensus.StatePending: 2, consensus.StateDropped: 1},
+		PendingEndorsements: 3,
+		OldestDeadline:      deadline,
+		NewestDeadline:      deadline.Add(time.Hour),
+	})
+
+	require.Equal(t, `Queries: 3 (pending 2, committed 0, dropped 1)
+Pending endorsements: 3
+Oldest deadline: 2019-06-01T12:00:00Z
+Newest deadline: 2019-06-01T13:00:00Z
+`, buf.String())
+
+	buf.Reset()
+	printDumpSummary(buf, consensus.DumpSummary{})
+	require.NotContains(t, buf.String(), "deadline", "empty dumps have no deadlines")
+}
+
+func TestPrintDumpedQuery(t *testing.T) {
+	q := consensus.NewQuery()
+	q.Emitter = "alice"
+	q.Operations = []*consensus.Operation{{Key: "k", Op: consensus.Operation_SET, Data: []byte("v")}}
+	deadline := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	buf := &bytes.Buffer{}
+	printDumpedQuery(buf, q.Uuid, consensus.DumpedQuery{
+		Query: q,
+		Status: consensus.QueryStatus{
+			State:      consensus.StateCommitted,
+			Deadline:   deadline,
+			ApplyError: errors.New("boom"),
+		},
+		Endorsers:  []string{"alice", "bob"},
+		Dependents: []string{"other"},
+	})
+
+	require.Equal(t, "Query: "+q.Uuid+`
+State: committed, apply failed: boom
+Endorsers (2): alice, bob
+Dependents: other
+Emitter: alice
+Deadline: 2019-06-01T12:00:00Z
+Operations:
+- SET k (1 bytes)
+`, buf.String())
+
+	buf.Reset()
+	printDumpedQuery(buf, "checkpointed", consensus.DumpedQuery{Status: consensus.QueryStatus{State: consensus.StateCommitted}})
+	require.Contains(t, buf.String(), "Content: unknown")
+}
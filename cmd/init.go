@@ -21,24 +21,113 @@ var configTmpl = template.Must(template.New("config").Parse(
 # Update it to your needs!
 
 identity: {{.ID}}
-keyring: {{.Prefix}}{{.ID}}.pem
+keyring: {{.Prefix}}{{.ID}}.pem # or "store", to keep it in the database
+#keyring: # to sign through an agent (see "pnyxdb keys agent"), instead of reading the private key, or to limit trust
+  #path: {{.Prefix}}{{.ID}}.pem
+  #agent: /run/pnyxdb/agent.sock
+  #maxdepth: 2 # length of the signature chains certifying a key (formerly trust.maxdepth, still read but deprecated)
+#autolock: 1h # lock the private key when unused, it is then read again from --password-file or prompted for
 n: {{.N}}
 w: {{.W}}
 
 db:
   path: {{.Prefix}}{{.ID}}.db
   driver: boltdb
+  #cache: 67108864 # bytes of recently read values kept in memory
+  #verifyreads: true # check values against their version when read, recovering corrupt ones from peers; or a probability, such as 0.1
+  #expirysweep: 1m # interval between two removals of expired keys from the file, negative to never remove them
 
 p2p:
+  driver: gossipsub # or redis (demonstration only), which requires a binary built with -tags redis
   listen: "/ip4/0.0.0.0/tcp/4100"
+  #key: {{.Prefix}}{{.ID}}.p2p # identity of the host, required with keyring.agent
+  #broadcasttimeout: 10s # messages not handed to the transport in time are given up
   peers: # uncomment and edit to connect to other peers
     #- "/ip4/172.17.0.1/tcp/4100/p2p/12D3KooWKVwkSqnBQajcAYZNmUrhvDqj59BzBtRzmGd4qYaTv2Y4"
     #- "/ip4/172.17.0.2/tcp/4100/p2p/12D3KooWNaQFB9f1j9MutyoXPuFy3gMA6sxCR2EUUxVg6ShFFaak"
+  faultinjection: # uncomment to simulate a degraded network, on staging nodes only
+    #enabled: true
+    #minlatency: 10ms
+    #medianlatency: 50ms
+    #maxlatency: 1s
+    #loss: 0.01 # probability of dropping a broadcast message
+    #duplication: 0.01 # probability of broadcasting a message twice
+    #seed: 42
+
+#staging: true # allow fault injection, and its adjustment through the API (see FAULTS in the client)
 
 recoveryQuorum: 3
+recovery: # uncomment to tune the retries of the keys recovered with --recover
+  #maxattempts: 10 # keys failing more often are kept as dead letters (see RECOVERY in the client)
+  #backoff: 1s # delay before the first retry, doubled after each failure
+  #maxbackoff: 5m
+  #breakerthreshold: 5 # consecutive network failures pausing every recovery
+  #breakercooldown: 30s # pause before probing the network again
+
+trust: # uncomment to limit the age of signatures
+  #maxsignatureage: 8760h
+  #reloadperiod: 10s # interval between two checks of the keyring by a running node, 0 to disable
+
+policy:
+  quotas: # uncomment to bound the total size of values stored under a prefix
+    #- prefix: "{{.ID}}/"
+    #  bytes: 104857600
+  writers: # uncomment to restrict the keys each identity may write, by query policy
+    #- policy: none
+    #  identity: "{{.ID}}" # or "*" for every identity
+    #  prefixes: ["{{.ID}}/"]
+  retention: # uncomment to delete, through consensus, the values not modified for a given age
+    #- prefix: "metrics/"
+    #  age: 720h
+  #retentionperiod: 1h # interval between two retention rounds
+  #retentionmaxkeys: 100 # maximum number of keys deleted per retention query
+  distrust: strict # or grandfather, to keep pending queries of distrusted emitters
+  admins: # uncomment to let administrators drop stuck queries (see "pnyxdb admin")
+    #identities: [alice, bob, carol]
+    #quorum: 2 # number of signatures required, all administrators by default
+
+consensus:
+  bbc: veto # or threshold, to decide checkpoints with signed votes in small trusted consortia
+  #threshold: 3 # votes deciding a checkpoint with the threshold engine, a majority of the n nodes by default
+  #maxquerytimeout: 10m # queries whose deadline is further away, in the future or in the past, are rejected, -1 to disable
+  #stoptimeout: 30s # time given to applicable queries to be applied when the node is stopped
+  #memorybudget: 256MB # size of the pending queries and endorsements above which new queries are rejected, unlimited by default
+
+checkpoint: # uncomment to bound the number of queries proposed by each checkpoint
+  #minbatch: 1
+  #maxbatch: 100
+  #proofsummarysize: 16384 # vetoes carrying larger proofs let peers fetch them instead, -1 to always embed them
+  #roundtimeout: 2s # the choice of the node is broadcast again after each round
+  #deadline: 1m # checkpoints without decision are given up, and their queries checkpointed again
 
 api:
   listen: "127.0.0.1:4200"
+  #maxtimeout: 10m # later deadlines of transactions are clamped
+  #maxoperations: 1000 # per transaction
+  #maxvaluesize: 1048576 # in bytes, for the data of an operation
+  #statusretention: 1h # resolved transactions are forgotten after this delay (and as long after their deadline)
+  httpdebug: # uncomment to serve a read-only HTTP endpoint, without authentication
+    #listen: "127.0.0.1:4280"
+  requestlog: # uncomment to log the requests
+    #enabled: true
+    #sample: 100 # one read request out of 100 is logged, requests modifying the database always are
+    #slow: 500ms # slower requests are always logged, as warnings
+
+events: # uncomment to keep a durable journal of local commits
+  #journal: {{.Prefix}}{{.ID}}.events
+  #maxsize: 67108864
+  #maxage: 168h
+
+status: # uncomment to broadcast the status of the node, see "pnyxdb admin cluster"
+  #period: 30s
+
+beacon: # uncomment to check query deadlines against a cluster time
+  #period: 10s
+  #emitters: 3
+  #maxskew: 500ms
+
+metrics: # uncomment to serve /metrics in the Prometheus text format, without authentication
+  #listen: "127.0.0.1:4290"
 `))
 
 // initCmd represents the client command
@@ -0,0 +1,120 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/keyring"
+)
+
+// keyRingInStore is the value of the `keyring` configuration key selecting
+// the database of the node as keyring storage, instead of a file path.
+const keyRingInStore = "store"
+
+// keyRingPath returns the configured keyring storage. The `keyring`
+// configuration key is either the storage itself, or a map with `path` and
+// `agent` keys, see unlockKeyRing.
+func keyRingPath() string {
+	if viper.IsSet("keyring.path") {
+		return viper.GetString("keyring.path")
+	}
+	return viper.GetString("keyring")
+}
+
+// keyRingStore is the database holding the keyring when it is stored in it.
+// It is opened on first use, or set by the server to share its own database.
+var keyRingStore consensus.Store
+
+func getKeyRingStore() (consensus.Store, error) {
+	if keyRingStore == nil {
+		s, err := getDriver(viper.GetString("db.driver"), viper.GetString("db.path"))
+		if err != nil {
+			return nil, err
+		}
+		keyRingStore = s
+	}
+	return keyRingStore, nil
+}
+
+// loadKeyRing returns the marshaled keyring from the configured storage.
+func loadKeyRing() ([]byte, error) {
+	path := keyRingPath()
+	if path != keyRingInStore {
+		return ioutil.ReadFile(path)
+	}
+
+	s, err := getKeyRingStore()
+	if err != nil {
+		return nil, err
+	}
+	return consensus.LoadKeyRing(s)
+}
+
+// storeKeyRing writes a marshaled keyring to the configured storage.
+// Files are written through a temporary file, so that the stored keyring is
+// never left partially written.
+func storeKeyRing(data []byte) error {
+	path := keyRingPath()
+	if path != keyRingInStore {
+		err := ioutil.WriteFile(path+".tmp", data, 0600)
+		if err != nil {
+			return err
+		}
+		return os.Rename(path+".tmp", path)
+	}
+
+	s, err := getKeyRingStore()
+	if err != nil {
+		return err
+	}
+	return consensus.SaveKeyRing(s, data)
+}
+
+// watchKeyRing reloads the keyring of a running node when its storage is
+// modified by another process (typically `pnyxdb keys` commands).
+func watchKeyRing(ctx context.Context, keyRing *keyring.KeyRing, period time.Duration) {
+	last, err := loadKeyRing()
+	if err != nil {
+		zap.L().Warn("KeyRingReload", zap.Error(err))
+	}
+
+	for {
+		select {
+		case <-time.After(period):
+		case <-ctx.Done():
+			return
+		}
+
+		data, err := loadKeyRing()
+		if err != nil {
+			zap.L().Warn("KeyRingReload", zap.Error(err))
+			continue
+		}
+		if bytes.Equal(data, last) {
+			continue
+		}
+
+		err = keyRing.Reload(data)
+		if err != nil {
+			zap.L().Warn("KeyRingReload", zap.Error(err))
+			continue
+		}
+		last = data
+		zap.L().Info("KeyRingReload", zap.Int("keys", len(keyRing.ListPublic())))
+	}
+}
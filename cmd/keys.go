@@ -13,12 +13,15 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/awnumar/memguard"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/technicolor-research/pnyxdb/keyring"
+	"go.uber.org/zap"
 )
 
 const strTrustLevel = "none,low,high,ultimate"
@@ -34,33 +37,76 @@ func getSelfIdentity() string {
 	return identity
 }
 
-func getPassword() *memguard.LockedBuffer {
-	password := viper.GetString("password")
-	if len(password) == 0 {
-		check(errors.New("please provide a password through `PASSWORD` environment variable"))
-	}
+func getKeyRing() *keyring.KeyRing {
+	rawKeyRing, err := loadKeyRing()
+	check(err)
 
-	buffer, err := memguard.NewImmutableFromBytes([]byte(password))
+	keyRing, err := keyring.NewKeyRing(getSelfIdentity(), keyring.CryptoOf(rawKeyRing))
 	check(err)
+	unmarshalKeyRing(keyRing, rawKeyRing)
+	keyRing.SetMaxTrustDepth(maxTrustDepth())
+	keyRing.SetMaxSignatureAge(viper.GetDuration("trust.maxsignatureage"))
+	return keyRing
+}
+
+// maxTrustDepth returns the keyring.maxdepth setting, or the deprecated
+// trust.maxdepth one if only the latter is set.
+func maxTrustDepth() int {
+	if viper.IsSet("keyring.maxdepth") || !viper.IsSet("trust.maxdepth") {
+		return viper.GetInt("keyring.maxdepth")
+	}
 
-	viper.Set("password", nil)
-	return buffer
+	zap.L().Warn("DeprecatedSetting",
+		zap.String("key", "trust.maxdepth"),
+		zap.String("hint", "use keyring.maxdepth instead"),
+	)
+	return viper.GetInt("trust.maxdepth")
 }
 
-func getKeyRing() *keyring.KeyRing {
-	rawKeyRing, err := ioutil.ReadFile(viper.GetString("keyring"))
-	check(err)
+// localKeyRing returns the local keyring if it can be loaded from a file,
+// or nil. It is only used to display names, so that commands talking to a
+// server do not fail without keyring, nor open the database of the node.
+func localKeyRing() *keyring.KeyRing {
+	identity := viper.GetString("identity")
+	if identity == "" || keyRingPath() == keyRingInStore {
+		return nil
+	}
 
-	keyRing, err := keyring.NewKeyRing(getSelfIdentity(), "ed25519")
-	check(err)
-	check(keyRing.UnmarshalBinary(rawKeyRing))
+	raw, err := ioutil.ReadFile(keyRingPath())
+	if err != nil {
+		return nil
+	}
+
+	keyRing, err := keyring.NewKeyRing(identity, keyring.CryptoOf(raw))
+	if err != nil {
+		return nil
+	}
+	if err = keyRing.UnmarshalBinary(raw); err != nil {
+		if _, ok := err.(keyring.ErrSkippedBlocks); !ok {
+			return nil
+		}
+	}
 	return keyRing
 }
 
+// unmarshalKeyRing loads a marshaled keyring. The blocks that cannot be
+// loaded are reported as warnings, since the other keys remain usable.
+func unmarshalKeyRing(keyRing *keyring.KeyRing, raw []byte) {
+	err := keyRing.UnmarshalBinary(raw)
+	if skipped, ok := err.(keyring.ErrSkippedBlocks); ok {
+		for _, b := range skipped.Blocks {
+			fmt.Fprintln(os.Stderr, "!! Skipped keyring block", b)
+		}
+		fmt.Fprintln(os.Stderr, "!! Skipped blocks are not kept if the keyring is modified")
+		return
+	}
+	check(err)
+}
+
 func saveKeyRing(keyRing *keyring.KeyRing) {
 	data, err := keyRing.MarshalBinary()
 	check(err)
-	check(ioutil.WriteFile(viper.GetString("keyring"), data, 0600))
+	check(storeKeyRing(data))
 }
 
 var keysCmd = &cobra.Command{
@@ -68,35 +114,73 @@ var keysCmd = &cobra.Command{
 	Short: "Manage signature keys",
 }
 
+var initCrypto *string
+var initMnemonic *bool
+
 var keysInitCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Create local keyring",
+	Long: `Create local keyring.
+
+With --mnemonic, the key pair is derived from a 24-word recovery phrase,
+read from the standard input (without echo if it is a terminal). A new
+phrase is generated and printed if none is given. Running the command again
+with the same phrase regenerates the same key pair, on any node. Only
+ed25519 keys can be derived from a phrase.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		check(cfgErr)
 		// Generate new KeyRing
-		keyRing, err := keyring.NewKeyRing(getSelfIdentity(), "ed25519")
+		keyRing, err := keyring.NewKeyRing(getSelfIdentity(), *initCrypto)
 		check(err)
-		check(keyRing.CreatePrivate(getPassword()))
 
-		// Save to disk
+		if *initMnemonic {
+			mnemonic := readMnemonic()
+			if mnemonic == "" {
+				mnemonic, err = keyring.NewMnemonic()
+				check(err)
+				fmt.Fprintln(os.Stderr, "Write down the following recovery phrase, it allows to regenerate the key:")
+				fmt.Println(mnemonic)
+			}
+			check(keyRing.CreatePrivateFromMnemonic(mnemonic, getNewPassword("password", *passwordFile, "Password: ")))
+		} else {
+			check(keyRing.CreatePrivate(getNewPassword("password", *passwordFile, "Password: ")))
+		}
+
+		// Save to the configured storage
 		saveKeyRing(keyRing)
 
 		// Print confirmation
 		pub, _, _ := keyRing.GetPublic(keyRing.Identity())
-		fmt.Printf("Generated new keyring (%s)\n", keyring.Fingerprint(pub))
+		fmt.Printf("Generated new %s keyring (%s)\n", keyRing.Crypto(), keyring.Fingerprint(pub))
 	},
 }
 
+var keysJSON bool // set by the --json flag of ls, show and export
+
+var exportAll, importAll *bool
+
 var keysExportCmd = &cobra.Command{
 	Use:   "export [identity]",
 	Short: "Export a public key from the keyring",
 	Run: func(cmd *cobra.Command, args []string) {
 		keyRing := getKeyRing()
 
+		if *exportAll {
+			data, err := keyRing.ExportAll()
+			check(err)
+			fmt.Printf("%s", data)
+			return
+		}
+
 		if len(args) == 0 {
 			args = []string{keyRing.Identity()}
 		}
 
+		if keysJSON {
+			check(writeKeyJSON(os.Stdout, keyRing, args[0], true))
+			return
+		}
+
 		data, err := keyRing.Export(args[0])
 		check(err)
 		fmt.Printf("%s", data)
@@ -110,6 +194,17 @@ var keysImportCmd = &cobra.Command{
 	Short: "Import a public key to the keyring",
 	Run: func(cmd *cobra.Command, args []string) {
 		keyRing := getKeyRing()
+		if *importAll {
+			data, err := ioutil.ReadAll(os.Stdin)
+			check(err)
+			n, err := keyRing.ImportAll(data)
+			check(err)
+
+			saveKeyRing(keyRing)
+			fmt.Printf("Imported %d keys\n", n)
+			return
+		}
+
 		identity := getIdentity(cmd, args)
 
 		lvl, err := keyring.ParseTrust(*importTrust)
@@ -118,11 +213,45 @@ var keysImportCmd = &cobra.Command{
 		data, err := ioutil.ReadAll(os.Stdin)
 		check(err)
 		check(keyRing.Import(data, identity, lvl))
+		applyMeta(cmd, keyRing, identity)
 
 		saveKeyRing(keyRing)
 
 		pub, _, _ := keyRing.GetPublic(identity)
 		fmt.Printf("Imported new key for identity %s (%s) with %s trust level\n", args[0], keyring.Fingerprint(pub), lvl)
+		fmt.Printf("Full fingerprint: %s\n", keyring.FingerprintFull(pub))
+	},
+}
+
+var fingerprintVerify *string
+
+var keysFingerprintCmd = &cobra.Command{
+	Use:   "fingerprint [id]",
+	Short: "Print the fingerprints of a public key",
+	Long: `Print the fingerprints of a public key (default is the local one).
+
+The short fingerprint is only meant to tell keys apart. The full fingerprint
+(SHA-256 of the public key) shall be compared out-of-band, for instance by
+phone, before trusting a key. With --verify, the command exits with an error
+if the full fingerprint is not the expected one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keyRing := getKeyRing()
+		if len(args) == 0 {
+			args = []string{keyRing.Identity()}
+		}
+
+		data, _, err := keyRing.GetPublic(args[0])
+		check(err)
+
+		fmt.Printf("Fingerprint:      %s\n", keyring.Fingerprint(data))
+		fmt.Printf("Full fingerprint: %s\n", keyring.FingerprintFull(data))
+
+		if *fingerprintVerify != "" {
+			if !keyring.MatchFingerprint(data, *fingerprintVerify) {
+				check(fmt.Errorf("fingerprint mismatch for identity %s", args[0]))
+			}
+			fmt.Println("Fingerprint verified")
+		}
 	},
 }
 
@@ -133,6 +262,8 @@ var keysRemoveCmd = &cobra.Command{
 		keyRing := getKeyRing()
 		identity := getIdentity(cmd, args)
 
+		_, _, err := keyRing.GetPublic(identity)
+		check(err)
 		keyRing.RemovePublic(identity)
 		saveKeyRing(keyRing)
 	},
@@ -143,9 +274,13 @@ var keysListCmd = &cobra.Command{
 	Short: "List public keys from the keyring",
 	Run: func(cmd *cobra.Command, args []string) {
 		keyRing := getKeyRing()
+		if keysJSON {
+			check(writeKeysJSON(os.Stdout, keyRing))
+			return
+		}
 
 		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Identity", "Trust", "Certified", "Fingerprint"})
+		table.SetHeader([]string{"Identity", "Trust", "Effective", "Certified", "Expires", "Fingerprint"})
 		table.SetRowLine(true)
 		table.SetAutoFormatHeaders(false)
 		table.SetAlignment(tablewriter.ALIGN_LEFT)
@@ -158,11 +293,8 @@ var keysListCmd = &cobra.Command{
 				cert = "❌ no"
 			}
 
-			if identity == keyRing.Identity() {
-				identity = "<self>"
-			}
-
-			table.Append([]string{identity, trust.String(), cert, keyring.Fingerprint(data)})
+			expiry, _ := keyRing.Expiry(identity)
+			table.Append([]string{displayIdentity(keyRing, identity), trust.String(), k.EffectiveTrust().String(), cert, formatExpiry(expiry), keyring.Fingerprint(data)})
 		}
 
 		table.Render()
@@ -175,16 +307,36 @@ var keysShowCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		keyRing := getKeyRing()
 		identity := getIdentity(cmd, args)
+		if keysJSON {
+			check(writeKeyJSON(os.Stdout, keyRing, identity, false))
+			return
+		}
 
 		data, trust, err := keyRing.GetPublic(identity)
 		check(err)
 
 		signatures := keyRing.GetSignatures(identity)
 
+		effectiveTrust, err := keyRing.EffectiveTrust(identity)
+		check(err)
+
+		expiry, err := keyRing.Expiry(identity)
+		check(err)
+
 		status := "Certified"
-		err = keyRing.Trusted(identity)
-		if err != nil {
-			status = fmt.Sprintf("Insufficient trust (%d/%d)", err.(*keyring.ErrInsufficientTrust).L, keyring.TrustThreshold)
+		switch err := keyRing.Trusted(identity).(type) {
+		case nil:
+		case *keyring.ErrInsufficientTrust:
+			status = fmt.Sprintf("Insufficient trust (%d/%d)", err.L, keyring.TrustThreshold)
+		case *keyring.ErrKeyExpired:
+			status = "Expired"
+		case *keyring.ErrKeyRevoked:
+			status = "Revoked"
+			if r := keyRing.Revocation(identity); r != nil {
+				status = fmt.Sprintf("Revoked on %s (%s)", r.Date.Local().Format("2006-01-02 15:04"), r.Reason)
+			}
+		default:
+			status = err.Error()
 		}
 
 		table := tablewriter.NewWriter(os.Stdout)
@@ -195,16 +347,35 @@ var keysShowCmd = &cobra.Command{
 		table.SetColumnSeparator(":")
 
 		table.Append([]string{"Identity", identity})
-		table.Append([]string{"Trust", trust.String()})
+		meta, err := keyRing.GetMeta(identity)
+		check(err)
+		for _, field := range sortedFields(meta) {
+			table.Append([]string{strings.Title(field), meta[field] + " (unauthenticated)"})
+		}
+		table.Append([]string{"Trust", fmt.Sprintf("%s (effective: %s)", trust, effectiveTrust)})
 		table.Append([]string{"Fingerprint", keyring.Fingerprint(data)})
+		table.Append([]string{"Full fingerprint", keyring.FingerprintFull(data)})
 		table.Append([]string{"Public key", fmt.Sprintf("%X", data)})
+		table.Append([]string{"Expires", formatExpiry(expiry)})
+		if encryption, err := keyRing.EncryptionKey(identity); err == nil {
+			table.Append([]string{"Encryption key", fmt.Sprintf("%X", encryption)})
+		} else {
+			table.Append([]string{"Encryption key", "(none)"})
+		}
 		table.Append([]string{"Status", status})
 
+		path, err := keyRing.TrustPath(identity)
+		check(err)
+		for _, e := range path {
+			table.Append([]string{"Trust path", fmt.Sprintf("%s → %s (%s)", displayIdentity(keyRing, e.Signer), displayIdentity(keyRing, e.Signee), e.Trust)})
+		}
+
+		if len(path) == 0 {
+			table.Append([]string{"Trust path", "(local trust only)"})
+		}
+
 		for i, s := range signatures {
-			if i == keyRing.Identity() {
-				i = "<self>"
-			}
-			table.Append([]string{"Approved by", fmt.Sprintf("%s (%s)", i, s.Trust)})
+			table.Append([]string{"Approved by", fmt.Sprintf("%s (%s)", displayIdentity(keyRing, i), s.Trust)})
 		}
 
 		if len(signatures) == 0 {
@@ -215,11 +386,33 @@ var keysShowCmd = &cobra.Command{
 	},
 }
 
+var trustAllKeys, trustYes *bool
+
 var keysTrustCmd = &cobra.Command{
 	Use:   "trust [id] [" + strTrustLevel + "]",
 	Short: "Update local trust level in specific key",
+	Long: `Update local trust level in specific key.
+
+With --all, the trust level (only argument) is set on every public key of
+the keyring, after a confirmation unless --yes is given.
+
+The --name, --organization and --email flags set the metadata of the key
+(an empty value removes the field). They are exported along with the key but
+are not signed: they only help humans to recognize identities.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		keyRing := getKeyRing()
+		if *trustAllKeys {
+			lvl, err := keyring.ParseTrust(getArg(cmd, args, 0))
+			check(err)
+
+			question := fmt.Sprintf("Set %s trust level on the %d public keys of the keyring?", lvl, len(keyRing.ListPublic())-1)
+			if !*trustYes && !confirm(question) {
+				return
+			}
+			runBatch(keyRing, trustAll(keyRing, lvl))
+			return
+		}
+
 		identity := getIdentity(cmd, args)
 		lvl, err := keyring.ParseTrust(getArg(cmd, args, 1))
 		check(err)
@@ -227,23 +420,263 @@ var keysTrustCmd = &cobra.Command{
 		data, _, err := keyRing.GetPublic(identity)
 		check(err)
 		check(keyRing.AddPublic(identity, lvl, data))
+		applyMeta(cmd, keyRing, identity)
 		saveKeyRing(keyRing)
 	},
 }
 
+var signAllKeys *bool
+var signMinTrust *string
+
 var keysSignCmd = &cobra.Command{
 	Use:   "sign [id]",
 	Short: "Sign an identity with private key according to stored trust level",
+	Long: `Sign an identity with private key according to stored trust level.
+
+With --all, every public key whose local trust level is at least --min-trust
+is signed, unless it is already signed at this trust level.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		keyRing := getKeyRing()
-		password := getPassword()
+		if *signAllKeys {
+			min, err := keyring.ParseTrust(*signMinTrust)
+			check(err)
+			unlockKeyRing(keyRing)
+			runBatch(keyRing, signAll(keyRing, min))
+			return
+		}
+
 		identity := getIdentity(cmd, args)
-		check(keyRing.UnlockPrivate(password))
+		unlockKeyRing(keyRing)
 		check(keyRing.AddSignature(identity, keyRing.Identity(), nil))
 		saveKeyRing(keyRing)
 	},
 }
 
+var unsignFrom *string
+var unsignForce *bool
+
+var keysUnsignCmd = &cobra.Command{
+	Use:   "unsign [id]",
+	Short: "Remove the signature of an identity made with private key",
+	Long: `Remove the signature of an identity made with private key.
+
+With --from, the signature made by another key is removed instead, which
+requires --force: such a signature is imported again with the key of its
+signer.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keyRing := getKeyRing()
+		identity := getIdentity(cmd, args)
+		from := *unsignFrom
+		if from == "" {
+			from = keyRing.Identity()
+		}
+
+		check(keyRing.RemoveSignature(identity, from, *unsignForce))
+		saveKeyRing(keyRing)
+	},
+}
+
+var mergeStrategy, mergeSelf *string
+
+var keysMergeCmd = &cobra.Command{
+	Use:   "merge [file]",
+	Short: "Merge the public keys of another keyring",
+	Long: `Merge the public keys, signatures and revocations of another keyring,
+typically maintained on another machine.
+
+The highest trust level is kept for the keys known by both keyrings. When an
+identity is bound to different public keys, --strategy decides whether the
+merge fails (error), or keeps the local key (keep-local) or the other one
+(keep-remote). The local key is never replaced.
+
+The own key of the other keyring is only merged if its identity, which is not
+stored in the keyring file, is given with --self.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keyRing := getKeyRing()
+		path := getArg(cmd, args, 0)
+
+		strategy, err := keyring.ParseMergeStrategy(*mergeStrategy)
+		check(err)
+
+		rawOther, err := ioutil.ReadFile(path)
+		check(err)
+		other, err := keyring.NewKeyRing(*mergeSelf, keyring.CryptoOf(rawOther))
+		check(err)
+		unmarshalKeyRing(other, rawOther)
+
+		check(keyRing.Merge(other, strategy))
+		saveKeyRing(keyRing)
+	},
+}
+
+var keysExpireCmd = &cobra.Command{
+	Use:   "expire [id] [date|duration|never]",
+	Short: "Set the expiration date of a key (resets its signatures)",
+	Long: `Set the expiration date of a key.
+
+The expiration can be provided as a RFC 3339 date ("2020-01-02T15:04:05Z"),
+a simple date ("2020-01-02"), a duration from now ("720h"), or "never".
+
+Since the expiration date is part of signed messages, every signature of
+the key is removed: it must be signed again afterwards.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keyRing := getKeyRing()
+		identity := getIdentity(cmd, args)
+		expiry, err := parseExpiry(getArg(cmd, args, 1))
+		check(err)
+
+		check(keyRing.SetExpiry(identity, expiry))
+		saveKeyRing(keyRing)
+		fmt.Printf("Key of identity %s expires: %s\n", identity, formatExpiry(expiry))
+	},
+}
+
+var keysRevokeCmd = &cobra.Command{
+	Use:   "revoke [reason]",
+	Short: "Revoke the local key, and print the revocation certificate to send to peers",
+	Long: `Revoke the local key, and print the revocation certificate to send to peers.
+
+Peers can import the certificate with the "keys import" command. Once
+revoked, a key is never trusted again, even if it is imported again.
+This operation cannot be undone.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keyRing := getKeyRing()
+		unlockKeyRing(keyRing)
+
+		reason := "unspecified"
+		if len(args) > 0 {
+			reason = strings.Join(args, " ")
+		}
+
+		data, err := keyRing.Revoke(reason)
+		check(err)
+		saveKeyRing(keyRing)
+		fmt.Printf("%s", data)
+	},
+}
+
+var keysUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Re-encrypt the private key with the current encryption format",
+	Long: `Re-encrypt the private key with the current encryption format.
+
+Private keys generated by older versions are encrypted with AES-256-CBC
+without key derivation function. This command encrypts them again with
+AES-256-GCM and an Argon2id-derived key, using the same password.
+
+It also adds to older public keys the encryption key allowing other members
+to encrypt values for this identity (see SETENC in the client).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keyRing := getKeyRing()
+		legacy := keyRing.LegacyPrivate()
+		_, err := keyRing.EncryptionKey("")
+		if !legacy && err == nil {
+			fmt.Println("Private key already uses the current encryption format")
+			return
+		}
+
+		password := getPassword()
+		check(keyRing.UnlockPrivate(password)) // publishes the encryption key
+		if legacy {
+			check(keyRing.ReEncryptPrivate(password))
+		}
+		saveKeyRing(keyRing)
+		if legacy {
+			fmt.Println("Private key has been re-encrypted")
+		}
+		if err != nil {
+			fmt.Println("Encryption key has been added to the public key")
+		}
+	},
+}
+
+var keysPasswdCmd = &cobra.Command{
+	Use:   "passwd",
+	Short: "Change the password protecting the private key",
+	Long: `Change the password protecting the private key.
+
+The current password is read from the file given by --password-file or the
+PASSWORD environment variable, and the new one from the NEW_PASSWORD
+environment variable. When unset, they are prompted for if the standard
+input is a terminal.
+
+The keyring file is left untouched if the current password is wrong.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keyRing := getKeyRing()
+		oldPassword := readPassword("password", *passwordFile, "Current password: ")
+		newPassword := getNewPassword("new_password", "", "New password: ")
+
+		check(keyRing.ChangePassword(oldPassword, newPassword))
+		saveKeyRing(keyRing)
+		fmt.Println("Password has been changed")
+	},
+}
+
+func parseExpiry(str string) (time.Time, error) {
+	if strings.ToLower(str) == "never" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(str); err == nil {
+		return time.Now().Add(d).Truncate(time.Second), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t, nil
+	}
+
+	return time.Parse("2006-01-02", str)
+}
+
+func formatExpiry(expiry time.Time) string {
+	if expiry.IsZero() {
+		return "never"
+	}
+
+	str := expiry.Local().Format("2006-01-02 15:04")
+	if !expiry.After(time.Now()) {
+		str += " (expired)"
+	}
+	return str
+}
+
+// displayIdentity replaces the self identity by "<self>", and appends the
+// display name of other identities if they have one.
+func displayIdentity(keyRing *keyring.KeyRing, identity string) string {
+	if identity == keyRing.Identity() {
+		return "<self>"
+	}
+	if name := keyRing.DisplayName(identity); name != "" {
+		return fmt.Sprintf("%s (%s)", identity, name)
+	}
+	return identity
+}
+
+var metaFields = []string{keyring.MetaName, keyring.MetaOrganization, keyring.MetaEmail}
+
+// applyMeta sets the metadata given by the flags of cmd on the key of an
+// identity. Flags that are not given leave the fields unchanged.
+func applyMeta(cmd *cobra.Command, keyRing *keyring.KeyRing, identity string) {
+	for _, field := range metaFields {
+		if !cmd.Flags().Changed(field) {
+			continue
+		}
+
+		value, err := cmd.Flags().GetString(field)
+		check(err)
+		check(keyRing.SetMeta(identity, field, value))
+	}
+}
+
+func sortedFields(meta map[string]string) []string {
+	fields := make([]string, 0, len(meta))
+	for field := range meta {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
 func getIdentity(cmd *cobra.Command, args []string) string {
 	return getArg(cmd, args, 0)
 }
@@ -256,10 +689,38 @@ func init() {
 		keysRemoveCmd,
 		keysListCmd,
 		keysShowCmd,
+		keysFingerprintCmd,
 		keysTrustCmd,
 		keysSignCmd,
+		keysUnsignCmd,
+		keysMergeCmd,
+		keysExpireCmd,
+		keysRevokeCmd,
+		keysUpgradeCmd,
+		keysPasswdCmd,
 	)
 	RootCmd.AddCommand(keysCmd)
 
+	initCrypto = keysInitCmd.Flags().String("crypto", "ed25519", "signature algorithm (ed25519, secp256k1, bls12381)")
+	initMnemonic = keysInitCmd.Flags().Bool("mnemonic", false, "derive the key from a recovery phrase read from stdin, or generate one")
+	fingerprintVerify = keysFingerprintCmd.Flags().String("verify", "", "expected full fingerprint")
+	trustAllKeys = keysTrustCmd.Flags().Bool("all", false, "set the trust level of every public key")
+	trustYes = keysTrustCmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation with --all")
+	signAllKeys = keysSignCmd.Flags().Bool("all", false, "sign every public key trusted at least at --min-trust")
+	signMinTrust = keysSignCmd.Flags().String("min-trust", "high", "minimum local trust level of the keys signed with --all ("+strTrustLevel+")")
 	importTrust = keysImportCmd.Flags().StringP("trust", "t", "low", "public key local trust ("+strTrustLevel+")")
+	exportAll = keysExportCmd.Flags().Bool("all", false, "export every public key of the keyring, with identities and trust levels")
+	importAll = keysImportCmd.Flags().Bool("all", false, "import every public key of a bundle made by export --all")
+	for _, c := range []*cobra.Command{keysListCmd, keysShowCmd, keysExportCmd} {
+		c.Flags().BoolVar(&keysJSON, "json", false, "print structured JSON output")
+	}
+	for _, c := range []*cobra.Command{keysImportCmd, keysTrustCmd} {
+		c.Flags().String(keyring.MetaName, "", "display name of the identity (unauthenticated, ignored with --all)")
+		c.Flags().String(keyring.MetaOrganization, "", "organization of the identity (unauthenticated, ignored with --all)")
+		c.Flags().String(keyring.MetaEmail, "", "contact email of the identity (unauthenticated, ignored with --all)")
+	}
+	mergeStrategy = keysMergeCmd.Flags().String("strategy", "error", "handling of identities bound to different public keys (error, keep-local, keep-remote)")
+	mergeSelf = keysMergeCmd.Flags().String("self", "", "identity of the own key of the other keyring")
+	unsignFrom = keysUnsignCmd.Flags().String("from", "", "identity of the signer (default is the local identity)")
+	unsignForce = keysUnsignCmd.Flags().Bool("force", false, "allow the removal of signatures made by other keys")
 }
@@ -0,0 +1,262 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/technicolor-research/pnyxdb/keyring"
+)
+
+// Outcomes of a batch operation on a key.
+const (
+	batchDone    = "done"
+	batchSkipped = "skipped"
+	batchInvalid = "invalid"
+)
+
+// batchResult is the outcome of a batch operation on a key.
+type batchResult struct {
+	Identity string
+	Outcome  string // batchDone, batchSkipped or batchInvalid
+	Detail   string
+}
+
+type batchResults []batchResult
+
+func (r *batchResults) add(identity, outcome, detail string) {
+	*r = append(*r, batchResult{Identity: identity, Outcome: outcome, Detail: detail})
+}
+
+// count returns the number of results with the given outcome.
+func (r batchResults) count(outcome string) (n int) {
+	for _, res := range r {
+		if res.Outcome == outcome {
+			n++
+		}
+	}
+	return
+}
+
+// err returns an error if the operation failed for some keys.
+func (r batchResults) err() error {
+	if n := r.count(batchInvalid); n > 0 {
+		return fmt.Errorf("%d invalid keys", n)
+	}
+	return nil
+}
+
+// print writes a table of the results, followed by a summary.
+func (r batchResults) print(w io.Writer) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Identity", "Outcome", "Detail"})
+	table.SetAutoFormatHeaders(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	for _, res := range r {
+		table.Append([]string{res.Identity, res.Outcome, res.Detail})
+	}
+	table.Render()
+
+	fmt.Fprintf(w, "%d done, %d skipped, %d invalid\n", r.count(batchDone), r.count(batchSkipped), r.count(batchInvalid))
+}
+
+// importDir imports the public keys of the PEM files (*.pem) of a directory,
+// with a given trust level. The identity of a key is given by its
+// "identity" header, or by the name of its file otherwise.
+// Keys already present are skipped, and so are keys whose identity is bound
+// to another public key. An error is only returned if the directory cannot
+// be read.
+func importDir(keyRing *keyring.KeyRing, dir string, lvl keyring.TrustLevel) (batchResults, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var results batchResults
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".pem" {
+			continue
+		}
+
+		identity := strings.TrimSuffix(f.Name(), ".pem")
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			results.add(identity, batchInvalid, err.Error())
+			continue
+		}
+
+		block, _ := pem.Decode(data)
+		if block == nil {
+			results.add(identity, batchInvalid, f.Name()+": no PEM block")
+			continue
+		}
+		if id := block.Headers["identity"]; id != "" {
+			identity = id
+		}
+
+		outcome, detail := importKey(keyRing, identity, lvl, data)
+		results.add(identity, outcome, detail)
+	}
+	return results, nil
+}
+
+func importKey(keyRing *keyring.KeyRing, identity string, lvl keyring.TrustLevel, data []byte) (outcome, detail string) {
+	if identity == keyRing.Identity() {
+		return batchSkipped, "local identity"
+	}
+
+	// Checked in a scratch keyring first, so that keys bound to another
+	// identity are not overwritten
+	scratch, err := keyring.NewKeyRing("", keyRing.Crypto())
+	if err != nil {
+		return batchInvalid, err.Error()
+	}
+	err = scratch.Import(data, identity, lvl)
+	if err != nil {
+		return batchInvalid, err.Error()
+	}
+	public, _, err := scratch.GetPublic(identity)
+	if err != nil {
+		return batchInvalid, err.Error()
+	}
+
+	if current, _, err := keyRing.GetPublic(identity); err == nil {
+		if bytes.Equal(current, public) {
+			return batchSkipped, "already present"
+		}
+		return batchInvalid, "identity bound to another public key"
+	}
+
+	err = keyRing.Import(data, identity, lvl)
+	if err != nil {
+		return batchInvalid, err.Error()
+	}
+	return batchDone, "imported (" + keyring.Fingerprint(public) + ")"
+}
+
+// signAll signs every public key whose local trust level is at least min,
+// according to this trust level. Keys already signed at their current trust
+// level are skipped.
+func signAll(keyRing *keyring.KeyRing, min keyring.TrustLevel) batchResults {
+	var results batchResults
+	for _, k := range keyRing.ListPublic() {
+		identity, _, trust := k.Info()
+		if identity == keyRing.Identity() {
+			continue
+		}
+
+		if trust < min {
+			results.add(identity, batchSkipped, "trust "+trust.String()+" is below "+min.String())
+			continue
+		}
+
+		if s, ok := keyRing.GetSignatures(identity)[keyRing.Identity()]; ok && s.Trust == trust {
+			results.add(identity, batchSkipped, "already signed")
+			continue
+		}
+
+		err := keyRing.AddSignature(identity, keyRing.Identity(), nil)
+		if err != nil {
+			results.add(identity, batchInvalid, err.Error())
+			continue
+		}
+		results.add(identity, batchDone, "signed with "+trust.String()+" trust")
+	}
+	return results
+}
+
+// trustAll sets the local trust level of every public key.
+func trustAll(keyRing *keyring.KeyRing, lvl keyring.TrustLevel) batchResults {
+	var results batchResults
+	for _, k := range keyRing.ListPublic() {
+		identity, data, trust := k.Info()
+		if identity == keyRing.Identity() {
+			continue
+		}
+
+		if trust == lvl {
+			results.add(identity, batchSkipped, "already "+lvl.String())
+			continue
+		}
+
+		err := keyRing.AddPublic(identity, lvl, data)
+		if err != nil {
+			results.add(identity, batchInvalid, err.Error())
+			continue
+		}
+		results.add(identity, batchDone, trust.String()+" → "+lvl.String())
+	}
+	return results
+}
+
+// confirm asks a yes/no question on the terminal.
+func confirm(question string) bool {
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		check(errors.New("cannot ask for confirmation, use --yes"))
+	}
+
+	fmt.Fprint(os.Stderr, question+" [y/N] ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		check(err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// runBatch saves the keyring if some keys have been modified, prints the
+// results and exits with an error if the operation failed for some keys.
+func runBatch(keyRing *keyring.KeyRing, results batchResults) {
+	if results.count(batchDone) > 0 {
+		saveKeyRing(keyRing)
+	}
+	results.print(os.Stdout)
+	check(results.err())
+}
+
+var importDirTrust *string
+
+var keysImportDirCmd = &cobra.Command{
+	Use:   "import-dir [dir]",
+	Short: "Import the public keys of every PEM file of a directory",
+	Long: `Import the public keys of every PEM file (*.pem) of a directory.
+
+The identity of each key is read from its "identity" header (set when it
+has been exported from another keyring), or is the name of its file without
+extension. Keys already present are skipped, as well as invalid files and
+keys whose identity is already bound to another public key.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		keyRing := getKeyRing()
+		lvl, err := keyring.ParseTrust(*importDirTrust)
+		check(err)
+
+		results, err := importDir(keyRing, args[0], lvl)
+		check(err)
+		runBatch(keyRing, results)
+	},
+}
+
+func init() {
+	importDirTrust = keysImportDirCmd.Flags().StringP("trust", "t", "low", "public keys local trust ("+strTrustLevel+")")
+	keysCmd.AddCommand(keysImportDirCmd)
+}
@@ -0,0 +1,140 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/keyring"
+)
+
+// outcomes returns the outcome of the results by identity.
+func outcomes(results batchResults) map[string]string {
+	m := make(map[string]string, len(results))
+	for _, r := range results {
+		m[r.Identity] = r.Outcome
+	}
+	return m
+}
+
+func TestKeysBatch(t *testing.T) {
+	defer memguard.DestroyAll()
+	keyRing := getTestKeyRing(t) // alice
+
+	password, err := memguard.NewImmutableFromBytes([]byte("password"))
+	require.Nil(t, err)
+
+	dir, err := ioutil.TempDir("", "keys")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	// Fixtures: exports of new keys (erin's one is exported by dave, with
+	// an identity header), of known keys, and invalid files
+	rings := make(map[string]*keyring.KeyRing)
+	for _, identity := range []string{"bob", "carol", "dave", "erin"} {
+		k, err := keyring.NewKeyRing(identity, "ed25519")
+		require.Nil(t, err)
+		require.Nil(t, k.CreatePrivate(password))
+		rings[identity] = k
+	}
+	bob, err := keyRing.Export("bob")
+	require.Nil(t, err)
+	carol, err := rings["carol"].Export("carol") // not the key known by alice
+	require.Nil(t, err)
+	dave, err := rings["dave"].Export("dave")
+	require.Nil(t, err)
+	erin, err := rings["erin"].Export("erin")
+	require.Nil(t, err)
+	require.Nil(t, rings["dave"].Import(erin, "erin", keyring.TrustLOW))
+	erin, err = rings["dave"].Export("erin")
+	require.Nil(t, err)
+	self, err := keyRing.Export("alice")
+	require.Nil(t, err)
+
+	fixtures := map[string][]byte{
+		"bob.pem":       bob,
+		"carol.pem":     carol,
+		"dave.pem":      dave,
+		"from-dave.pem": erin,
+		"alice.pem":     self,
+		"corrupt.pem":   []byte("-----BEGIN PNYXDB PUBLIC KEY-----\nnot base64\n-----END PNYXDB PUBLIC KEY-----\n"),
+		"truncated.pem": dave[:len(dave)/2],
+		"README":        []byte("not a key"),
+	}
+	for name, data := range fixtures {
+		require.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), data, 0600))
+	}
+
+	results, err := importDir(keyRing, dir, keyring.TrustHIGH)
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{
+		"alice":     batchSkipped,
+		"bob":       batchSkipped,
+		"carol":     batchInvalid,
+		"corrupt":   batchInvalid,
+		"dave":      batchDone,
+		"erin":      batchDone,
+		"truncated": batchInvalid,
+	}, outcomes(results))
+	require.EqualError(t, results.err(), "3 invalid keys")
+
+	data, trust, err := keyRing.GetPublic("erin")
+	require.Nil(t, err)
+	require.Equal(t, keyring.TrustHIGH, trust)
+	expected, _, _ := rings["erin"].GetPublic("erin")
+	require.Equal(t, expected, data)
+	data, _, _ = keyRing.GetPublic("carol")
+	other, _, _ := rings["carol"].GetPublic("carol")
+	require.NotEqual(t, other, data, "known keys should not be replaced")
+	require.Contains(t, results, batchResult{Identity: "carol", Outcome: batchInvalid, Detail: "identity bound to another public key"})
+
+	buf := &bytes.Buffer{}
+	results.print(buf)
+	require.Contains(t, buf.String(), "2 done, 2 skipped, 3 invalid")
+
+	_, err = importDir(keyRing, filepath.Join(dir, "missing"), keyring.TrustHIGH)
+	require.NotNil(t, err)
+
+	t.Run("sign", func(t *testing.T) {
+		results := signAll(keyRing, keyring.TrustHIGH)
+		require.Equal(t, map[string]string{
+			"bob":   batchSkipped, // already signed
+			"carol": batchSkipped, // not trusted
+			"dave":  batchDone,
+			"erin":  batchDone,
+		}, outcomes(results))
+		require.Nil(t, results.err())
+		require.Nil(t, keyRing.Trusted("dave"))
+
+		results = signAll(keyRing, keyring.TrustHIGH)
+		require.Equal(t, 0, results.count(batchDone), "keys should not be signed twice")
+	})
+
+	t.Run("trust", func(t *testing.T) {
+		results := trustAll(keyRing, keyring.TrustLOW)
+		require.Equal(t, 4, results.count(batchDone))
+		results = trustAll(keyRing, keyring.TrustLOW)
+		require.Equal(t, 4, results.count(batchSkipped))
+
+		_, trust, _ := keyRing.GetPublic("bob")
+		require.Equal(t, keyring.TrustLOW, trust)
+		_, trust, _ = keyRing.GetPublic("alice")
+		require.Equal(t, keyring.TrustULTIMATE, trust, "local trust should not change")
+
+		// Signatures are made again at the new trust level
+		results = signAll(keyRing, keyring.TrustLOW)
+		require.Equal(t, 4, results.count(batchDone))
+	})
+}
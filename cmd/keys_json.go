@@ -0,0 +1,151 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/technicolor-research/pnyxdb/keyring"
+)
+
+// jsonKey is the representation of a key printed by the keys commands
+// when --json is set. Its fields are part of the command line interface,
+// and shall not be renamed.
+type jsonKey struct {
+	Identity        string            `json:"identity"`
+	Self            bool              `json:"self"`
+	Fingerprint     string            `json:"fingerprint"`
+	FingerprintFull string            `json:"fingerprint_full"`
+	PublicKey       string            `json:"public_key"`
+	EncryptionKey   string            `json:"encryption_key,omitempty"`
+	Trust           string            `json:"trust"`
+	EffectiveTrust  string            `json:"effective_trust"`
+	Certified       bool              `json:"certified"`
+	Status          string            `json:"status"` // certified, insufficient_trust, expired or revoked
+	Expires         *time.Time        `json:"expires,omitempty"`
+	Meta            map[string]string `json:"meta,omitempty"` // unauthenticated, see keyring.KeyRing.SetMeta
+	Signatures      []jsonSignature   `json:"signatures"`
+	PEM             string            `json:"pem,omitempty"` // only set by export
+}
+
+type jsonSignature struct {
+	Signer string `json:"signer"`
+	Trust  string `json:"trust"`
+}
+
+// newJSONKey collects the information about a key of the keyring.
+func newJSONKey(keyRing *keyring.KeyRing, identity string) (*jsonKey, error) {
+	data, trust, err := keyRing.GetPublic(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	effectiveTrust, err := keyRing.EffectiveTrust(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &jsonKey{
+		Identity:        identity,
+		Self:            identity == keyRing.Identity(),
+		Fingerprint:     keyring.Fingerprint(data),
+		FingerprintFull: keyring.FingerprintFull(data),
+		PublicKey:       fmt.Sprintf("%X", data),
+		Trust:           trust.String(),
+		EffectiveTrust:  effectiveTrust.String(),
+		Signatures:      []jsonSignature{},
+	}
+
+	if encryption, err := keyRing.EncryptionKey(identity); err == nil {
+		k.EncryptionKey = fmt.Sprintf("%X", encryption)
+	}
+
+	switch err := keyRing.Trusted(identity).(type) {
+	case nil:
+		k.Certified = true
+		k.Status = "certified"
+	case *keyring.ErrInsufficientTrust:
+		k.Status = "insufficient_trust"
+	case *keyring.ErrKeyExpired:
+		k.Status = "expired"
+	case *keyring.ErrKeyRevoked:
+		k.Status = "revoked"
+	default:
+		return nil, err
+	}
+
+	expiry, err := keyRing.Expiry(identity)
+	if err != nil {
+		return nil, err
+	}
+	if !expiry.IsZero() {
+		k.Expires = &expiry
+	}
+
+	meta, err := keyRing.GetMeta(identity)
+	if err != nil {
+		return nil, err
+	}
+	if len(meta) > 0 {
+		k.Meta = meta
+	}
+
+	for signer, s := range keyRing.GetSignatures(identity) {
+		k.Signatures = append(k.Signatures, jsonSignature{Signer: signer, Trust: s.Trust.String()})
+	}
+	sort.Slice(k.Signatures, func(i, j int) bool {
+		return k.Signatures[i].Signer < k.Signatures[j].Signer
+	})
+
+	return k, nil
+}
+
+// writeKeysJSON writes the JSON representation of every key of the keyring,
+// sorted by identity.
+func writeKeysJSON(w io.Writer, keyRing *keyring.KeyRing) error {
+	keys := []*jsonKey{}
+	for _, listed := range keyRing.ListPublic() {
+		identity, _, _ := listed.Info()
+		k, err := newJSONKey(keyRing, identity)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, k)
+	}
+	return writeJSON(w, keys)
+}
+
+// writeKeyJSON writes the JSON representation of a key, along with its
+// export when withPEM is set.
+func writeKeyJSON(w io.Writer, keyRing *keyring.KeyRing, identity string, withPEM bool) error {
+	k, err := newJSONKey(keyRing, identity)
+	if err != nil {
+		return err
+	}
+
+	if withPEM {
+		data, err := keyRing.Export(identity)
+		if err != nil {
+			return err
+		}
+		k.PEM = string(data)
+	}
+
+	return writeJSON(w, k)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,108 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/awnumar/memguard"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/keyring"
+)
+
+// getTestKeyRing returns the keyring of alice, who trusts bob and has
+// signed his key, and knows carol without trusting her.
+func getTestKeyRing(t *testing.T) *keyring.KeyRing {
+	password, err := memguard.NewImmutableFromBytes([]byte("password"))
+	require.Nil(t, err)
+
+	rings := make(map[string]*keyring.KeyRing)
+	for _, identity := range []string{"alice", "bob", "carol"} {
+		k, err := keyring.NewKeyRing(identity, "ed25519")
+		require.Nil(t, err)
+		require.Nil(t, k.CreatePrivate(password))
+		rings[identity] = k
+	}
+
+	alice := rings["alice"]
+	for identity, trust := range map[string]keyring.TrustLevel{"bob": keyring.TrustHIGH, "carol": keyring.TrustNONE} {
+		data, err := rings[identity].Export(identity)
+		require.Nil(t, err)
+		require.Nil(t, alice.Import(data, identity, trust))
+	}
+
+	require.Nil(t, alice.AddSignature("bob", "alice", nil))
+	return alice
+}
+
+func TestWriteKeysJSON(t *testing.T) {
+	defer memguard.DestroyAll()
+	keyRing := getTestKeyRing(t)
+
+	buf := &bytes.Buffer{}
+	require.Nil(t, writeKeysJSON(buf, keyRing))
+
+	var raw []map[string]interface{}
+	require.Nil(t, json.Unmarshal(buf.Bytes(), &raw))
+	require.Len(t, raw, 3)
+	for _, field := range []string{"identity", "self", "fingerprint", "public_key", "trust", "effective_trust", "certified", "status", "signatures"} {
+		require.Contains(t, raw[0], field)
+	}
+	require.NotContains(t, raw[0], "pem")
+
+	var keys []jsonKey
+	require.Nil(t, json.Unmarshal(buf.Bytes(), &keys))
+	require.Exactly(t, []string{"alice", "bob", "carol"}, []string{keys[0].Identity, keys[1].Identity, keys[2].Identity})
+
+	require.True(t, keys[0].Self)
+	require.Exactly(t, "ultimate", keys[0].Trust)
+
+	bob := keys[1]
+	data, _, err := keyRing.GetPublic("bob")
+	require.Nil(t, err)
+	require.False(t, bob.Self)
+	require.Exactly(t, keyring.Fingerprint(data), bob.Fingerprint)
+	require.Exactly(t, keyring.FingerprintFull(data), bob.FingerprintFull)
+	require.Exactly(t, "high", bob.Trust)
+	require.Exactly(t, "high", bob.EffectiveTrust)
+	require.True(t, bob.Certified)
+	require.Exactly(t, "certified", bob.Status)
+	require.Exactly(t, []jsonSignature{{Signer: "alice", Trust: "high"}}, bob.Signatures)
+	require.Nil(t, bob.Expires)
+
+	carol := keys[2]
+	require.Exactly(t, "none", carol.Trust)
+	require.Exactly(t, "none", carol.EffectiveTrust)
+	require.False(t, carol.Certified)
+	require.Exactly(t, "insufficient_trust", carol.Status)
+	require.Empty(t, carol.Signatures)
+	require.Nil(t, carol.Meta)
+}
+
+func TestWriteKeyJSON(t *testing.T) {
+	defer memguard.DestroyAll()
+	keyRing := getTestKeyRing(t)
+	require.Nil(t, keyRing.SetMeta("bob", keyring.MetaName, "Bob"))
+
+	buf := &bytes.Buffer{}
+	require.Nil(t, writeKeyJSON(buf, keyRing, "bob", true))
+
+	var key jsonKey
+	require.Nil(t, json.Unmarshal(buf.Bytes(), &key))
+	require.Exactly(t, "bob", key.Identity)
+	require.Exactly(t, map[string]string{keyring.MetaName: "Bob"}, key.Meta)
+
+	export, err := keyRing.Export("bob")
+	require.Nil(t, err)
+	require.Exactly(t, string(export), key.PEM)
+
+	require.NotNil(t, writeKeyJSON(&bytes.Buffer{}, keyRing, "dave", false), "unknown identities should fail")
+}
@@ -0,0 +1,29 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxTrustDepth(t *testing.T) {
+	defer viper.Reset()
+
+	viper.Set("keyring", "alice.pem")
+	require.Equal(t, 0, maxTrustDepth(), "chains of any length by default")
+
+	viper.Set("trust.maxdepth", 3)
+	require.Equal(t, 3, maxTrustDepth(), "the deprecated setting should still be read")
+
+	viper.Set("keyring.maxdepth", 2)
+	require.Equal(t, 2, maxTrustDepth(), "keyring.maxdepth should take precedence")
+}
@@ -0,0 +1,133 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	metrics "github.com/libp2p/go-libp2p-metrics"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/keyring"
+	"github.com/technicolor-research/pnyxdb/network/gossipsub"
+)
+
+func init() {
+	addNetwork("gossipsub", "github.com/libp2p/go-libp2p-pubsub", newGossipsubNetwork)
+}
+
+// hostKey returns the private key identifying the libp2p host, which is
+// the private key of the keyring. Since it is not available when the
+// signatures are delegated to an agent, or usable by libp2p (bls12381), a
+// distinct key is stored in the file given by `p2p.key` in those cases, and
+// generated on first use.
+func hostKey(keyRing *keyring.KeyRing) (crypto.PrivKey, error) {
+	if private := keyRing.GetPrivate(); private != nil && keyRing.Crypto() != "bls12381" {
+		unmarshal := crypto.UnmarshalEd25519PrivateKey
+		if keyRing.Crypto() == "secp256k1" {
+			unmarshal = crypto.UnmarshalSecp256k1PrivateKey
+		}
+
+		// The host keeps its own copy, since the keyring may be locked later on
+		return unmarshal(append([]byte(nil), private...))
+	}
+
+	path := viper.GetString("p2p.key")
+	if path == "" {
+		return nil, errors.New("missing 'p2p.key' from configuration file, required without usable private key in memory")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		return crypto.UnmarshalPrivateKey(data)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	sk, _, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	if err != nil {
+		return nil, err
+	}
+	data, err = crypto.MarshalPrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	return sk, ioutil.WriteFile(path, data, 0600)
+}
+
+func newGossipsubNetwork(ctx context.Context, keyRing *keyring.KeyRing) (consensus.Network, error) {
+	sk, err := hostKey(keyRing)
+	if err != nil {
+		return nil, err
+	}
+
+	reporter := metrics.NewBandwidthCounter()
+
+	host, err := libp2p.New(
+		ctx,
+		libp2p.Identity(sk),
+		libp2p.ListenAddrStrings(viper.GetString("p2p.listen")),
+		libp2p.BandwidthReporter(reporter),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	params := gossipsub.Defaults(host)
+	params.BootstrapAddrs = viper.GetStringSlice("p2p.peers")
+	rq := viper.GetInt("recoveryQuorum")
+	if rq > 0 {
+		params.RecoveryQuorum = uint(rq)
+	}
+
+	network, err := gossipsub.New(params)
+	if err != nil {
+		return nil, err
+	}
+
+	go startReporter(ctx, reporter)
+
+	for _, addr := range host.Addrs() {
+		zap.L().Info("Listening",
+			zap.String("type", "P2P"),
+			zap.String("address", addr.String()+"/p2p/"+host.ID().Pretty()),
+		)
+	}
+
+	return network, nil
+}
+
+func startReporter(ctx context.Context, reporter *metrics.BandwidthCounter) {
+	for {
+		select {
+		case <-time.After(10 * time.Second):
+			s := reporter.GetBandwidthTotals()
+			/*zap.L().Debug("BandwidthTotal",
+				zap.Int64("in", s.TotalIn),
+				zap.Int64("out", s.TotalOut),
+			)
+			*/
+			zap.L().Info("Bandwidth",
+				zap.Float64("in", s.RateIn),
+				zap.Float64("out", s.RateOut),
+			)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
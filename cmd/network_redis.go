@@ -0,0 +1,36 @@
+//go:build redis
+// +build redis
+
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/viper"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/keyring"
+	"github.com/technicolor-research/pnyxdb/network/redis"
+)
+
+// The redis network is a centralized, demonstration-only network adapter.
+// It is only compiled with the "redis" build tag.
+func init() {
+	addNetwork("redis", "github.com/gomodule/redigo", newRedisNetwork)
+}
+
+func newRedisNetwork(ctx context.Context, _ *keyring.KeyRing) (consensus.Network, error) {
+	return redis.New(
+		viper.GetString("redis.address"),
+		viper.GetString("redis.stream"),
+		viper.GetInt("redis.database"),
+	)
+}
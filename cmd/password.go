@@ -0,0 +1,147 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/awnumar/memguard"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+var passwordFile *string
+
+func init() {
+	passwordFile = RootCmd.PersistentFlags().String("password-file", "", "file holding the password of the private key on its first line")
+}
+
+// getPassword returns the password of the private key, read from the
+// --password-file flag, the PASSWORD environment variable, or prompted for.
+func getPassword() *memguard.LockedBuffer {
+	return readPassword("password", *passwordFile, "Password: ")
+}
+
+// getNewPassword reads a password to protect a private key like
+// readPassword, but asks for a confirmation when it is prompted for.
+func getNewPassword(key, file, prompt string) *memguard.LockedBuffer {
+	prompted := file == "" && viper.GetString(key) == ""
+	password := readPassword(key, file, prompt)
+	if !prompted {
+		return password
+	}
+
+	confirmation := readPassword(key, "", "Confirm "+strings.ToLower(prompt))
+	defer confirmation.Destroy()
+	if !bytes.Equal(password.Buffer(), confirmation.Buffer()) {
+		check(errors.New("passwords do not match"))
+	}
+	return password
+}
+
+// readPassword reads a password from a file if any, from the configuration
+// key (typically set through the environment), or prompts for it if stdin is
+// a terminal.
+func readPassword(key, file, prompt string) *memguard.LockedBuffer {
+	password, err := tryReadPassword(key, file, prompt)
+	check(err)
+	return password
+}
+
+// tryReadPassword is like readPassword, but returns errors instead of
+// exiting. The configuration key is cleared once read.
+func tryReadPassword(key, file, prompt string) (*memguard.LockedBuffer, error) {
+	password := []byte(viper.GetString(key))
+	viper.Set(key, nil)
+
+	if file != "" {
+		memguard.WipeBytes(password)
+		var err error
+		password, err = readPasswordFile(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(password) == 0 {
+		fd := int(os.Stdin.Fd())
+		if !terminal.IsTerminal(fd) {
+			return nil, fmt.Errorf("please provide a password through `%s` environment variable", strings.ToUpper(key))
+		}
+
+		fmt.Fprint(os.Stderr, prompt)
+		var err error
+		password, err = terminal.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return memguard.NewImmutableFromBytes(password)
+}
+
+// readMnemonic reads a recovery phrase from stdin, prompting for it without
+// echo if stdin is a terminal. It returns an empty string if none is given.
+func readMnemonic() string {
+	fd := int(os.Stdin.Fd())
+	if terminal.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "Recovery phrase (leave empty to generate one): ")
+		mnemonic, err := terminal.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		check(err)
+		return string(mnemonic)
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != io.EOF {
+		check(err)
+	}
+	return strings.TrimSpace(line)
+}
+
+// readPasswordFile returns the first line of a file, which must not be
+// accessible by other users.
+func readPasswordFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return nil, fmt.Errorf("password file %s is accessible by other users (permissions %#o, expected 0600)", path, perm)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	line := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line = data[:i]
+	}
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	if len(line) == 0 {
+		memguard.WipeBytes(data)
+		return nil, fmt.Errorf("password file %s is empty", path)
+	}
+
+	password := make([]byte, len(line))
+	copy(password, line)
+	memguard.WipeBytes(data)
+	return password, nil
+}
@@ -0,0 +1,48 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPasswordFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pnyxdb")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	write := func(name, content string, perm os.FileMode) string {
+		path := filepath.Join(dir, name)
+		require.Nil(t, ioutil.WriteFile(path, []byte(content), perm))
+		require.Nil(t, os.Chmod(path, perm))
+		return path
+	}
+
+	password, err := readPasswordFile(write("single", "secret", 0600))
+	require.Nil(t, err)
+	require.Equal(t, "secret", string(password))
+
+	password, err = readPasswordFile(write("lines", "secret\r\nignored\n", 0400))
+	require.Nil(t, err)
+	require.Equal(t, "secret", string(password), "should only read the first line")
+
+	_, err = readPasswordFile(write("shared", "secret\n", 0640))
+	require.NotNil(t, err, "should reject files readable by other users")
+
+	_, err = readPasswordFile(write("empty", "\nsecret\n", 0600))
+	require.NotNil(t, err, "should reject empty passwords")
+
+	_, err = readPasswordFile(filepath.Join(dir, "missing"))
+	require.NotNil(t, err)
+}
@@ -21,11 +21,18 @@ import (
 var cfgFile *string
 var cfgErr error
 
+// Version is the version of the binary, reported to API clients. It can be
+// set at build time:
+//
+//	go build -ldflags "-X github.com/technicolor-research/pnyxdb/cmd.Version=1.0.0"
+var Version = "dev"
+
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
-	Use:   "pnyxdb",
-	Short: "PnyxDB, a Lightweight Leaderless Democratic Byzantine Fault Tolerant Consortium Database.",
-	Long:  ``,
+	Use:     "pnyxdb",
+	Short:   "PnyxDB, a Lightweight Leaderless Democratic Byzantine Fault Tolerant Consortium Database.",
+	Long:    ``,
+	Version: Version,
 }
 
 func init() {
@@ -57,6 +64,14 @@ func initConfig() {
 		viper.Set("db.driver", "boltdb")
 	}
 
+	if !viper.IsSet("p2p.driver") {
+		viper.Set("p2p.driver", "gossipsub")
+	}
+
+	if !viper.IsSet("redis.stream") {
+		viper.Set("redis.stream", "pnyxdb")
+	}
+
 	// Init logging
 	logEncoder := zapcore.EncoderConfig{
 		TimeKey:        "T",
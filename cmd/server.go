@@ -12,55 +12,43 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/awnumar/memguard"
-	libp2p "github.com/libp2p/go-libp2p"
-	crypto "github.com/libp2p/go-libp2p-crypto"
-	metrics "github.com/libp2p/go-libp2p-metrics"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 
 	"github.com/technicolor-research/pnyxdb/consensus"
 	"github.com/technicolor-research/pnyxdb/consensus/bbc"
-	"github.com/technicolor-research/pnyxdb/network/gossipsub"
+	"github.com/technicolor-research/pnyxdb/consensus/bbc/threshold"
+	"github.com/technicolor-research/pnyxdb/keyring"
+	"github.com/technicolor-research/pnyxdb/metrics"
+	"github.com/technicolor-research/pnyxdb/network/unreliable"
 	"github.com/technicolor-research/pnyxdb/server"
 	"github.com/technicolor-research/pnyxdb/storage/boltdb"
+	"github.com/technicolor-research/pnyxdb/storage/cached"
+	"github.com/technicolor-research/pnyxdb/storage/verified"
 )
 
-type driverConstructor func(string) (consensus.Store, error)
+// defaultStopTimeout is the time given to the engine to apply its applicable
+// queries on stop, unless consensus.stoptimeout is set.
+const defaultStopTimeout = 30 * time.Second
 
 var fullSync *string
 var dumpFile *string
 var recoveryKeys *[]string
-var storeDrivers map[string]driverConstructor
+var noMigrate *bool
 
 func init() {
-	addDriver("boltdb", boltdb.New)
-}
-
-func addDriver(name string, c driverConstructor) {
-	if storeDrivers == nil {
-		storeDrivers = make(map[string]driverConstructor)
-	}
-
-	storeDrivers[name] = c
-}
-
-func getDriver(name string, path string) (consensus.Store, error) {
-	if storeDrivers == nil || storeDrivers[name] == nil {
-		fmt.Fprintln(os.Stderr, "Available database drivers:")
-		for k := range storeDrivers {
-			fmt.Fprintln(os.Stderr, "  *", k)
-		}
-		return nil, errors.New("unknown database driver: " + name)
-	}
-
-	return storeDrivers[name](path)
+	addDriver("boltdb", "github.com/coreos/bbolt", func(path string) (consensus.Store, error) {
+		return boltdb.Open(path, boltdb.Options{NoMigrate: *noMigrate})
+	})
 }
 
 var serverCmd = &cobra.Command{
@@ -73,68 +61,193 @@ var serverCmd = &cobra.Command{
 
 		store, err := getDriver(viper.GetString("db.driver"), viper.GetString("db.path"))
 		check(err)
+		if rate := verifyReadsRate(); rate > 0 {
+			store = verified.New(store, rate)
+		}
+		if size := viper.GetInt("db.cache"); size > 0 {
+			store = cached.New(store, size)
+		}
+		keyRingStore = store
 
 		ctx, cancel := context.WithCancel(context.Background())
-		go func() {
-			c := make(chan os.Signal, 2)
-			signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-			for range c {
-				cancel()
-				_ = store.Close()
-				_ = zap.L().Sync()
-				memguard.SafeExit(0)
-			}
-		}()
+		running := make(chan *consensus.Engine, 1)
+		go stopOnSignal(cancel, store, running)
 
 		keyRing := getKeyRing()
-		check(keyRing.UnlockPrivate(getPassword()))
+		unlockKeyRing(keyRing)
+		if keyRing.LegacyPrivate() {
+			zap.L().Warn("LegacyPrivateKey", zap.String("hint", "run `pnyxdb keys upgrade` to re-encrypt the private key"))
+		}
+		keyRing.SetAutoLock(viper.GetDuration("autolock"))
+
+		reloadPeriod := 10 * time.Second
+		if viper.IsSet("trust.reloadperiod") {
+			reloadPeriod = viper.GetDuration("trust.reloadperiod")
+		}
+		if reloadPeriod > 0 {
+			go watchKeyRing(ctx, keyRing, reloadPeriod)
+		}
 
-		sk, err := crypto.UnmarshalEd25519PrivateKey(keyRing.GetPrivate())
+		network, err := getNetwork(ctx, viper.GetString("p2p.driver"), keyRing)
 		check(err)
 
-		reporter := metrics.NewBandwidthCounter()
+		var faults *unreliable.Network
+		if viper.GetBool("p2p.faultinjection.enabled") {
+			if !viper.GetBool("staging") {
+				check(errors.New("p2p.faultinjection requires a staging node (staging: true)"))
+			}
 
-		host, err := libp2p.New(
-			ctx,
-			libp2p.Identity(sk),
-			libp2p.ListenAddrStrings(viper.GetString("p2p.listen")),
-			libp2p.BandwidthReporter(reporter),
-		)
-		check(err)
-		params := gossipsub.Defaults(host)
-		params.BootstrapAddrs = viper.GetStringSlice("p2p.peers")
-		rq := viper.GetInt("recoveryQuorum")
-		if rq > 0 {
-			params.RecoveryQuorum = uint(rq)
+			faults = unreliable.New(network, unreliable.Parameters{
+				Seed:          viper.GetInt64("p2p.faultinjection.seed"),
+				MinLatency:    viper.GetDuration("p2p.faultinjection.minlatency"),
+				MedianLatency: viper.GetDuration("p2p.faultinjection.medianlatency"),
+				MaxLatency:    viper.GetDuration("p2p.faultinjection.maxlatency"),
+				Loss:          viper.GetFloat64("p2p.faultinjection.loss"),
+				Duplication:   viper.GetFloat64("p2p.faultinjection.duplication"),
+			})
+			check(faults.Parameters().Validate())
+			network = faults.WithManagers()
+			zap.L().Warn("FaultInjection", zap.Any("parameters", faults.Parameters()))
 		}
 
-		network, err := gossipsub.New(params)
+		ve, err := getBBCEngine(viper.GetString("consensus.bbc"), network, keyRing, n)
 		check(err)
 
-		go startReporter(ctx, reporter)
+		engine := consensus.NewEngine(store, network, ve, keyRing, w)
+		engine.UnlockFunc = func() error {
+			password, err := tryReadPassword("password", *passwordFile, "Password (the private key has been locked): ")
+			if err != nil {
+				return err
+			}
+			defer password.Destroy()
+			return keyRing.UnlockPrivate(password)
+		}
 
-		for _, addr := range host.Addrs() {
-			zap.L().Info("Listening",
-				zap.String("type", "P2P"),
-				zap.String("address", addr.String()+"/p2p/"+host.ID().Pretty()),
-			)
+		var quotas []struct {
+			Prefix string
+			Bytes  int64
+		}
+		check(viper.UnmarshalKey("policy.quotas", &quotas))
+		for _, q := range quotas {
+			engine.SetQuota(q.Prefix, q.Bytes)
 		}
 
-		ve, err := bbc.NewVetoEngine(network, keyRing, n)
-		check(err)
+		var writers []consensus.WriteRule
+		check(viper.UnmarshalKey("policy.writers", &writers))
+		for _, r := range writers {
+			engine.AddWriteRule(r)
+		}
 
-		engine := consensus.NewEngine(store, network, ve, keyRing, w)
+		var retention []struct {
+			Prefix string
+			Age    time.Duration
+		}
+		check(viper.UnmarshalKey("policy.retention", &retention))
+		for _, r := range retention {
+			engine.SetRetention(r.Prefix, r.Age)
+		}
+		if len(retention) > 0 {
+			engine.RetentionPeriod = time.Hour
+			if viper.IsSet("policy.retentionperiod") {
+				engine.RetentionPeriod = viper.GetDuration("policy.retentionperiod")
+			}
+			engine.RetentionMaxKeys = viper.GetInt("policy.retentionmaxkeys")
+		}
+
+		engine.CheckpointMinBatch = viper.GetInt("checkpoint.minbatch")
+		engine.CheckpointMaxBatch = viper.GetInt("checkpoint.maxbatch")
+		engine.ProofSummarySize = viper.GetInt("checkpoint.proofsummarysize")
+		engine.StatusRetention = viper.GetDuration("api.statusretention")
+		engine.ExpirySweepPeriod = viper.GetDuration("db.expirysweep")
+		engine.NodeStatusPeriod = viper.GetDuration("status.period")
+		engine.BroadcastTimeout = viper.GetDuration("p2p.broadcasttimeout")
+		engine.MaxQueryTimeout = viper.GetDuration("consensus.maxquerytimeout")
+		engine.MemoryBudget = int64(viper.GetSizeInBytes("consensus.memorybudget"))
+		engine.NodeVersion = Version
+		engine.RecoveryPolicy = consensus.RecoveryPolicy{
+			MaxAttempts:      viper.GetInt("recovery.maxattempts"),
+			Backoff:          viper.GetDuration("recovery.backoff"),
+			MaxBackoff:       viper.GetDuration("recovery.maxbackoff"),
+			BreakerThreshold: viper.GetInt("recovery.breakerthreshold"),
+			BreakerCooldown:  viper.GetDuration("recovery.breakercooldown"),
+		}
+
+		if viper.IsSet("policy.distrust") {
+			engine.DistrustPolicy, err = consensus.ParseDistrustPolicy(viper.GetString("policy.distrust"))
+			check(err)
+		}
+
+		engine.Admins = viper.GetStringSlice("policy.admins.identities")
+		engine.AdminQuorum = viper.GetInt("policy.admins.quorum")
+
+		if path := viper.GetString("events.journal"); path != "" {
+			engine.Journal, err = consensus.OpenJournal(
+				path,
+				viper.GetInt64("events.maxsize"),
+				viper.GetDuration("events.maxage"),
+			)
+			check(err)
+		}
+
+		if period := viper.GetDuration("beacon.period"); period > 0 {
+			engine.ClusterClock = consensus.NewClusterClock(period)
+			if viper.IsSet("beacon.emitters") {
+				engine.ClusterClock.Emitters = viper.GetInt("beacon.emitters")
+			}
+			if viper.IsSet("beacon.maxskew") {
+				engine.ClusterClock.MaxSkew = viper.GetDuration("beacon.maxskew")
+			}
+		}
 
 		if *dumpFile != "" {
 			check(loadDump(engine))
 			go startDumper(ctx, engine)
+			engine.PersistFunc = func() error { return writeDump(engine) }
+		}
+
+		if listen := viper.GetString("metrics.listen"); listen != "" {
+			registry := &metrics.Registry{
+				Help:    consensus.MetricsHelp(),
+				Collect: engine.CollectMetrics,
+			}
+			engine.Metrics = registry
+			go serveMetrics(listen, registry)
 		}
 
 		check(engine.Run(ctx))
+		running <- engine
 
 		srv := &server.Server{
-			Engine: engine,
-			Listen: viper.GetString("api.listen"),
+			Engine:        engine,
+			Listen:        viper.GetString("api.listen"),
+			DebugListen:   viper.GetString("api.httpdebug.listen"),
+			Version:       Version,
+			MaxTimeout:    viper.GetDuration("api.maxtimeout"),
+			MaxOperations: viper.GetInt("api.maxoperations"),
+			MaxValueSize:  viper.GetInt("api.maxvaluesize"),
+			Faults:        faults,
+		}
+		if viper.GetBool("api.requestlog.enabled") {
+			srv.RequestLog = &server.RequestLogger{
+				SampleRate:    viper.GetInt("api.requestlog.sample"),
+				SlowThreshold: viper.GetDuration("api.requestlog.slow"),
+				Metrics:       engine.Metrics,
+			}
+		}
+
+		if srv.DebugListen != "" {
+			zap.L().Info("Listening",
+				zap.String("type", "HTTPDebug"),
+				zap.String("address", srv.DebugListen),
+			)
+			go func() {
+				if err := srv.ServeDebug(); err != nil {
+					zap.L().Error("Unable to listen",
+						zap.String("type", "HTTPDebug"),
+						zap.Error(err),
+					)
+				}
+			}()
 		}
 
 		zap.L().Info("Listening",
@@ -154,24 +267,94 @@ var serverCmd = &cobra.Command{
 	},
 }
 
-func startReporter(ctx context.Context, reporter *metrics.BandwidthCounter) {
-	for {
-		select {
-		case <-time.After(10 * time.Second):
-			s := reporter.GetBandwidthTotals()
-			/*zap.L().Debug("BandwidthTotal",
-				zap.Int64("in", s.TotalIn),
-				zap.Int64("out", s.TotalOut),
-			)
-			*/
-			zap.L().Info("Bandwidth",
-				zap.Float64("in", s.RateIn),
-				zap.Float64("out", s.RateOut),
-			)
-		case <-ctx.Done():
-			return
+// serveMetrics serves the metrics of the node on /metrics.
+func serveMetrics(listen string, registry *metrics.Registry) {
+	zap.L().Info("Listening",
+		zap.String("type", "Metrics"),
+		zap.String("address", listen),
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
+	if err := http.ListenAndServe(listen, mux); err != nil {
+		zap.L().Error("Unable to listen",
+			zap.String("type", "Metrics"),
+			zap.Error(err),
+		)
+	}
+}
+
+// verifyReadsRate returns the proportion of the reads from the database
+// verified against their version: db.verifyreads is either a boolean or a
+// probability.
+func verifyReadsRate() float64 {
+	if verify, ok := viper.Get("db.verifyreads").(bool); ok {
+		if verify {
+			return 1
+		}
+		return 0
+	}
+	return viper.GetFloat64("db.verifyreads")
+}
+
+// getBBCEngine returns the engine deciding the checkpoints, veto by default.
+// The threshold engine decides with the votes of a majority of the n nodes,
+// unless consensus.threshold is set.
+func getBBCEngine(name string, network consensus.Network, keyRing *keyring.KeyRing, n int) (consensus.BBCEngine, error) {
+	timeouts := bbc.Timeouts{
+		Round:     viper.GetDuration("checkpoint.roundtimeout"),
+		Deadline:  viper.GetDuration("checkpoint.deadline"),
+		Broadcast: viper.GetDuration("p2p.broadcasttimeout"),
+	}
+
+	switch name {
+	case "", "veto":
+		return bbc.NewVetoEngineWithTimeouts(network, keyRing, n, timeouts)
+	case "threshold":
+		votes := n/2 + 1
+		if viper.IsSet("consensus.threshold") {
+			votes = viper.GetInt("consensus.threshold")
+		}
+		return threshold.New(network, keyRing, votes, timeouts)
+	}
+
+	return nil, fmt.Errorf("unknown BBC engine: %s (veto or threshold)", name)
+}
+
+// stopOnSignal stops the node on SIGINT or SIGTERM. Once the engine is
+// received from running, it is stopped gracefully (see consensus.Engine.Stop)
+// for at most consensus.stoptimeout, unless a second signal is received.
+func stopOnSignal(cancel context.CancelFunc, store consensus.Store, running <-chan *consensus.Engine) {
+	c := make(chan os.Signal, 2)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	<-c
+
+	select {
+	case engine := <-running:
+		timeout := defaultStopTimeout
+		if viper.IsSet("consensus.stoptimeout") {
+			timeout = viper.GetDuration("consensus.stoptimeout")
 		}
+
+		ctx, stop := context.WithTimeout(context.Background(), timeout)
+		go func() {
+			select {
+			case <-c:
+				zap.L().Warn("EngineStop", zap.String("hint", "second signal, the remaining queries are abandoned"))
+				stop()
+			case <-ctx.Done():
+			}
+		}()
+
+		_ = engine.Stop(ctx) // logged by the engine
+		stop()
+	default: // still starting
 	}
+
+	cancel()
+	_ = store.Close()
+	_ = zap.L().Sync()
+	memguard.SafeExit(0)
 }
 
 func startDumper(ctx context.Context, e *consensus.Engine) {
@@ -184,26 +367,48 @@ func startDumper(ctx context.Context, e *consensus.Engine) {
 		}
 
 		from := time.Now()
-		file, err := os.Create(*dumpFile)
-		if err != nil {
-			zap.L().Error("DumpCreate",
-				zap.Error(err),
-			)
+		if err := writeDump(e); err != nil {
 			time.Sleep(5 * time.Second) // backoff to avoid infinite loops
 			continue
 		}
+		zap.L().Debug("DumpWrite", zap.Duration("duration", time.Since(from)))
+	}
+}
 
-		err = e.Dump(file)
-		_ = file.Close()
-		if err != nil {
-			zap.L().Error("DumpWrite",
-				zap.Error(err),
-			)
-			continue
-		}
+// dumpMutex serializes the writes of the dump file, by the dumper and on stop.
+var dumpMutex sync.Mutex
 
-		zap.L().Debug("DumpWrite", zap.Duration("duration", time.Since(from)))
+// writeDump writes the dump of an engine to a temporary file, renamed to the
+// dump file once complete, so that an interrupted write does not corrupt the
+// previous dump.
+func writeDump(e *consensus.Engine) error {
+	dumpMutex.Lock()
+	defer dumpMutex.Unlock()
+
+	tmp := *dumpFile + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		zap.L().Error("DumpCreate",
+			zap.Error(err),
+		)
+		return err
+	}
+
+	err = e.Dump(file)
+	if err == nil {
+		err = file.Sync()
 	}
+	_ = file.Close()
+	if err == nil {
+		err = os.Rename(tmp, *dumpFile)
+	}
+	if err != nil {
+		zap.L().Error("DumpWrite",
+			zap.Error(err),
+		)
+		_ = os.Remove(tmp)
+	}
+	return err
 }
 
 func loadDump(e *consensus.Engine) error {
@@ -224,7 +429,10 @@ func startRecovery(eng *consensus.Engine) {
 	// Wait for startup
 	time.Sleep(10 * time.Second)
 	for _, key := range *recoveryKeys {
-		eng.Recover(key)
+		err := eng.Recover(key)
+		if err != nil {
+			zap.L().Warn("RecoveryAbort", zap.String("key", key), zap.Error(err))
+		}
 	}
 }
 
@@ -235,4 +443,5 @@ func init() {
 	dumpFile = serverCmd.Flags().StringP("dump", "d", ".dump.p", "file used to retrieve processus state")
 	recoveryKeys = serverCmd.Flags().StringSliceP(
 		"recover", "r", nil, "set of keys to recover at startup from random peers")
+	noMigrate = serverCmd.Flags().Bool("no-migrate", false, "refuse to start instead of upgrading a database written by an older version")
 }
@@ -0,0 +1,126 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package consensus
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Policy compliance errors
+var (
+	ErrUnknownPolicy = errors.New("no write rule for this policy")
+	ErrWriteDenied   = errors.New("emitter is not allowed to write this key")
+)
+
+// AnyIdentity matches every emitter in a WriteRule.
+const AnyIdentity = "*"
+
+// WriteRule allows an identity to write the keys under some prefixes, with
+// queries of a given policy (see Query.Policy).
+type WriteRule struct {
+	Policy   string
+	Identity string   // or AnyIdentity
+	Prefixes []string // the empty prefix matches every key
+}
+
+// aclTracker holds the write rules, by policy and identity.
+// The zero value is ready to use, and allows every write.
+type aclTracker struct {
+	sync.Mutex
+	rules map[string]map[string][]string
+}
+
+// AddWriteRule allows the identity of the rule to write the keys under its
+// prefixes. Once a rule has been added, the engine only endorses queries
+// whose policy has rules, and whose operations are all allowed to their
+// emitter by one of them; PRUNE operations are governed by retention
+// policies instead (see SetRetention).
+//
+// Every node of a network should use the same rules, since a query must be
+// endorsed by a quorum of nodes.
+// This function is thread-safe.
+func (eng *Engine) AddWriteRule(r WriteRule) {
+	t := &eng.acl
+	t.Lock()
+	defer t.Unlock()
+
+	if t.rules == nil {
+		t.rules = make(map[string]map[string][]string)
+	}
+	if t.rules[r.Policy] == nil {
+		t.rules[r.Policy] = make(map[string][]string)
+	}
+	t.rules[r.Policy][r.Identity] = append(t.rules[r.Policy][r.Identity], r.Prefixes...)
+}
+
+// WriteRules returns the configured write rules, sorted by policy and
+// identity.
+// This function is thread-safe.
+func (eng *Engine) WriteRules() []WriteRule {
+	t := &eng.acl
+	t.Lock()
+	defer t.Unlock()
+
+	var rules []WriteRule
+	for policy, identities := range t.rules {
+		for identity, prefixes := range identities {
+			rules = append(rules, WriteRule{
+				Policy:   policy,
+				Identity: identity,
+				Prefixes: append([]string(nil), prefixes...),
+			})
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Policy != rules[j].Policy {
+			return rules[i].Policy < rules[j].Policy
+		}
+		return rules[i].Identity < rules[j].Identity
+	})
+	return rules
+}
+
+// check returns an error if the emitter of q is not allowed to perform one
+// of its operations, along with the key of this operation.
+// This function is thread-safe.
+func (t *aclTracker) check(q *Query) (key string, err error) {
+	t.Lock()
+	defer t.Unlock()
+
+	if len(t.rules) == 0 {
+		return "", nil
+	}
+
+	identities, ok := t.rules[q.Policy]
+	if !ok {
+		return "", ErrUnknownPolicy
+	}
+
+	for _, op := range q.Operations {
+		if op.Op == Operation_PRUNE {
+			continue
+		}
+		if !hasAnyPrefix(op.Key, identities[q.Emitter]) && !hasAnyPrefix(op.Key, identities[AnyIdentity]) {
+			return op.Key, ErrWriteDenied
+		}
+	}
+	return "", nil
+}
+
+func hasAnyPrefix(key string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAclTracker_Check(t *testing.T) {
+	eng := NewEngine(newMemoryStore(), nil, nil, nil, 1)
+	query := func(policy, emitter string, ops ...*Operation) *Query {
+		q := NewQuery()
+		q.Policy = policy
+		q.Emitter = emitter
+		q.Operations = ops
+		return q
+	}
+	set := func(key string) *Operation {
+		return &Operation{Key: key, Op: Operation_SET}
+	}
+
+	_, err := eng.acl.check(query("none", "alice", set("anything")))
+	require.Nil(t, err, "no rule should allow every write")
+
+	eng.AddWriteRule(WriteRule{Policy: "none", Identity: "alice", Prefixes: []string{"users/alice/"}})
+	eng.AddWriteRule(WriteRule{Policy: "none", Identity: AnyIdentity, Prefixes: []string{"public/"}})
+	eng.AddWriteRule(WriteRule{Policy: "admin", Identity: "bob", Prefixes: []string{""}})
+
+	cases := []struct {
+		q   *Query
+		key string
+		err error
+	}{
+		{query("none", "alice", set("users/alice/a"), set("public/a")), "", nil},
+		{query("none", "bob", set("public/a")), "", nil},
+		{query("none", "bob", set("public/a"), set("users/alice/a")), "users/alice/a", ErrWriteDenied},
+		{query("none", "alice", set("users/bob/a")), "users/bob/a", ErrWriteDenied},
+		{query("none", "alice", &Operation{Key: "metrics/a", Op: Operation_PRUNE}), "", nil},
+		{query("admin", "bob", set("users/alice/a")), "", nil},
+		{query("admin", "alice", set("users/alice/a")), "users/alice/a", ErrWriteDenied},
+		{query("other", "alice", set("users/alice/a")), "", ErrUnknownPolicy},
+	}
+	for i, c := range cases {
+		key, err := eng.acl.check(c.q)
+		require.Exactly(t, c.err, err, "case %d", i)
+		require.Exactly(t, c.key, key, "case %d", i)
+	}
+
+	require.Equal(t, []WriteRule{
+		{Policy: "admin", Identity: "bob", Prefixes: []string{""}},
+		{Policy: "none", Identity: AnyIdentity, Prefixes: []string{"public/"}},
+		{Policy: "none", Identity: "alice", Prefixes: []string{"users/alice/"}},
+	}, eng.WriteRules())
+}
+
+func TestEngine_PolicyCompliance(t *testing.T) {
+	const n, quorum = 4, 3
+	engines, stop := startEngines(t, n, quorum)
+	defer stop()
+
+	// Node 3 does not enforce any rule, and endorses every query
+	for _, eng := range engines[:n-1] {
+		for _, e := range engines {
+			eng.AddWriteRule(WriteRule{
+				Policy:   "none",
+				Identity: e.Identity(),
+				Prefixes: []string{"users/" + e.Identity() + "/"},
+			})
+		}
+	}
+
+	submit := func(eng *Engine, key string) *Query {
+		q := NewQuery()
+		q.SetTimeout(time.Minute)
+		q.Operations = []*Operation{{Key: key, Op: Operation_SET, Data: []byte(key)}}
+		require.Nil(t, eng.Submit(q))
+		return q
+	}
+	stored := func(eng *Engine, key string) bool {
+		eng.Store.Lock()
+		defer eng.Store.Unlock()
+		_, v, _ := eng.Store.Get(key)
+		return v != nil && v != NoVersion
+	}
+
+	own := "users/" + engines[3].Identity() + "/a"
+	other := "users/" + engines[1].Identity() + "/a"
+	denied := submit(engines[3], other)
+	submit(engines[3], own)
+
+	for i := 0; ; i++ {
+		var count int
+		for _, eng := range engines {
+			if stored(eng, own) {
+				count++
+			}
+		}
+		if count == n {
+			break
+		}
+		require.True(t, i < 500, "allowed query should be committed by every node")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Every endorsement emitted before the allowed commit has been received
+	time.Sleep(100 * time.Millisecond)
+	for i, eng := range engines {
+		require.False(t, stored(eng, other), "node %d committed a denied query", i)
+
+		eng.qs.RLock()
+		info := eng.qs.queries[denied.Uuid]
+		require.NotNil(t, info, "node %d", i)
+		require.True(t, len(info.Endorsements) < quorum, "node %d", i)
+		eng.qs.RUnlock()
+	}
+}
@@ -0,0 +1,149 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package consensus
+
+import (
+	"crypto/sha512"
+	"errors"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/technicolor-research/pnyxdb/keyring"
+	"go.uber.org/zap"
+)
+
+// Administration errors
+var (
+	ErrNoAdmins    = errors.New("no administrator is configured")
+	ErrAdminQuorum = errors.New("not enough valid administrator signatures")
+)
+
+// Hash returns a fixed-size hash of the statement of an administrator
+// drop, that is without its signatures.
+// Passed by value because of internal modifications.
+func (d AdminDrop) Hash() ([]byte, error) {
+	d.Signatures = nil
+	b := proto.NewBuffer(nil)
+	b.SetDeterministic(true)
+	err := b.Marshal(&d)
+	hash := sha512.Sum512(b.Bytes())
+	return hash[:], err
+}
+
+// SignAdminDrop adds the signature of the identity of the keyring to a
+// drop statement, replacing its previous signature if any.
+func SignAdminDrop(d *AdminDrop, kr *keyring.KeyRing) error {
+	hash, err := d.Hash()
+	if err != nil {
+		return err
+	}
+
+	signature, err := kr.Sign(hash)
+	if err != nil {
+		return err
+	}
+
+	admin := kr.Identity()
+	for _, s := range d.Signatures {
+		if s.Admin == admin {
+			s.Signature = signature
+			return nil
+		}
+	}
+
+	d.Signatures = append(d.Signatures, &AdminSignature{Admin: admin, Signature: signature})
+	return nil
+}
+
+// VerifyAdminDrop checks that a drop statement is signed by at least quorum
+// distinct administrators, trusted by the keyring. Every administrator must
+// sign if quorum is zero.
+func VerifyAdminDrop(d *AdminDrop, kr *keyring.KeyRing, admins []string, quorum int) error {
+	if len(admins) == 0 {
+		return ErrNoAdmins
+	}
+	if quorum <= 0 || quorum > len(admins) {
+		quorum = len(admins)
+	}
+
+	isAdmin := make(map[string]bool, len(admins))
+	for _, admin := range admins {
+		isAdmin[admin] = true
+	}
+
+	hash, err := d.Hash()
+	if err != nil {
+		return err
+	}
+
+	signers := make(map[string]bool)
+	for _, s := range d.Signatures {
+		if !isAdmin[s.Admin] {
+			return fmt.Errorf("signature of %s: not an administrator", s.Admin)
+		}
+
+		err = kr.Verify(s.Admin, hash, s.Signature)
+		if err != nil {
+			return fmt.Errorf("signature of %s: %v", s.Admin, err)
+		}
+		signers[s.Admin] = true
+	}
+
+	if len(signers) < quorum {
+		return ErrAdminQuorum
+	}
+	return nil
+}
+
+// SubmitAdminDrop checks a drop statement signed by administrators, and
+// broadcasts it to the network, which drops the query if it is still
+// pending.
+func (eng *Engine) SubmitAdminDrop(d *AdminDrop) error {
+	if eng.stopped() {
+		return ErrEngineStopped
+	}
+
+	err := VerifyAdminDrop(d, eng.KeyRing, eng.Admins, eng.AdminQuorum)
+	if err != nil {
+		return err
+	}
+
+	err = eng.broadcast(d)
+	if err == nil {
+		eng.handleAdminDrop(d)
+	}
+	return err
+}
+
+func (eng *Engine) handleAdminDrop(d *AdminDrop) {
+	err := VerifyAdminDrop(d, eng.KeyRing, eng.Admins, eng.AdminQuorum)
+	if err != nil {
+		zap.L().Warn("AdminDrop",
+			zap.String("uuid", d.Uuid),
+			zap.Error(err),
+		)
+		return
+	}
+
+	admins := make([]string, len(d.Signatures))
+	for i, s := range d.Signatures {
+		admins[i] = s.Admin
+	}
+
+	dropped := eng.qs.DropPending(d.Uuid)
+	zap.L().Warn("AdminDrop",
+		zap.String("uuid", d.Uuid),
+		zap.String("reason", d.Reason),
+		zap.Strings("admins", admins),
+		zap.Bool("dropped", dropped),
+	)
+
+	if dropped {
+		eng.flushDecisions()
+	}
+}
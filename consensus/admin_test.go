@@ -0,0 +1,123 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_AdminDrop(t *testing.T) {
+	const quorum = 2
+	keyrings := tests.GetTestKeyRings(t, 4)
+	signers := make([]*Engine, len(keyrings))
+	for i, kr := range keyrings {
+		signers[i] = NewEngine(nil, nil, nil, kr, quorum)
+	}
+
+	query := func(emitter int, timeout time.Duration) *Query {
+		q := NewQuery()
+		q.SetTimeout(timeout)
+		q.Emitter = keyrings[emitter].Identity()
+		q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: []byte(q.Uuid)}}
+		require.Nil(t, signers[emitter].signQuery(q))
+		return q
+	}
+
+	network := &recordingNetwork{}
+	eng := NewEngine(newMemoryStore(), network, passBBC{}, keyrings[0], quorum)
+	eng.Admins = []string{"1", "2", "3"}
+	eng.AdminQuorum = 2
+	eng.MaxQueryTimeout = 48 * time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.Nil(t, eng.Run(ctx))
+
+	// The stuck query wedges key "a" for a day: conflicting queries are not
+	// endorsed before it is committed or dropped
+	stuck, next := query(1, 24*time.Hour), query(2, time.Minute)
+	eng.handleQuery(stuck)
+	go eng.handleQuery(next)
+	e := &Endorsement{Uuid: next.Uuid, Emitter: keyrings[3].Identity()}
+	require.Nil(t, signers[3].signEndorsement(e))
+	eng.handleEndorsement(e)
+
+	state := func(uuid string) queryState {
+		eng.qs.RLock()
+		defer eng.qs.RUnlock()
+		return eng.qs.queries[uuid].State
+	}
+
+	statement := func(admins ...int) *AdminDrop {
+		d := &AdminDrop{Uuid: stuck.Uuid, Reason: "test"}
+		for _, admin := range admins {
+			require.Nil(t, SignAdminDrop(d, keyrings[admin]))
+		}
+		return d
+	}
+
+	// Insufficient statements are refused, and ignored when received
+	duplicated := statement(1)
+	duplicated.Signatures = append(duplicated.Signatures, duplicated.Signatures[0])
+	tampered := statement(1, 2)
+	tampered.Uuid = next.Uuid
+
+	for name, d := range map[string]*AdminDrop{
+		"single":     statement(1),
+		"resigned":   statement(1, 1),
+		"duplicated": duplicated,
+		"non-admin":  statement(0, 1, 2),
+		"tampered":   tampered,
+	} {
+		require.NotNil(t, eng.SubmitAdminDrop(d), name)
+		eng.handleAdminDrop(d)
+		require.Exactly(t, qPending, state(stuck.Uuid), name)
+		require.Exactly(t, qPending, state(next.Uuid), name)
+	}
+	require.Exactly(t, ErrAdminQuorum, eng.SubmitAdminDrop(statement(3)))
+	require.Exactly(t, ErrNoAdmins, VerifyAdminDrop(statement(1, 2), keyrings[0], nil, 0))
+	require.Exactly(t, ErrAdminQuorum, VerifyAdminDrop(statement(1, 2), keyrings[0], eng.Admins, 0), "every administrator by default")
+
+	// A complete statement drops the stuck query, which lets the other one
+	// be endorsed and committed
+	d := statement(2, 3)
+	require.Nil(t, eng.SubmitAdminDrop(d))
+	require.Exactly(t, qDropped, state(stuck.Uuid))
+
+	for i := 0; state(next.Uuid) != qCommitted; i++ {
+		require.True(t, i < 100, "query should be committed once the stuck one is dropped")
+		time.Sleep(10 * time.Millisecond)
+
+		network.Lock()
+		for _, m := range network.messages { // own endorsements
+			if e, ok := m.(*Endorsement); ok && e.Uuid == next.Uuid {
+				go eng.handleEndorsement(e)
+			}
+		}
+		network.Unlock()
+	}
+
+	value, _, _ := eng.Store.Get("a")
+	require.Exactly(t, []byte(next.Uuid), value)
+
+	network.Lock()
+	require.Contains(t, network.messages, d, "statement should be broadcast")
+	network.Unlock()
+
+	// Committed queries are never dropped
+	d = &AdminDrop{Uuid: next.Uuid}
+	require.Nil(t, SignAdminDrop(d, keyrings[1]))
+	require.Nil(t, SignAdminDrop(d, keyrings[2]))
+	require.Nil(t, eng.SubmitAdminDrop(d))
+	require.Exactly(t, qCommitted, state(next.Uuid))
+}
@@ -0,0 +1,194 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package consensus
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/technicolor-research/pnyxdb/keyring"
+	"go.uber.org/zap"
+)
+
+// Aggregated endorsement errors
+var (
+	ErrEmptyAggregate    = errors.New("aggregated endorsement is empty")
+	ErrMixedAggregate    = errors.New("aggregated endorsement spans several queries")
+	ErrDuplicateEndorser = errors.New("aggregated endorsement has several endorsements of the same emitter")
+)
+
+// verify checks the aggregated signature of the endorsements against a
+// keyring, whose crypto engine must support aggregation. The endorsements
+// must endorse the same query, and be emitted by distinct trusted identities.
+// The hashes of the endorsements are memoized by hashes, if not nil.
+func (a *AggregatedEndorsement) verify(kr *keyring.KeyRing, hashes *HashCache) error {
+	if len(a.Endorsements) == 0 {
+		return ErrEmptyAggregate
+	}
+
+	uuid := a.Endorsements[0].Uuid
+	emitters := make([]string, len(a.Endorsements))
+	digests := make([][]byte, len(a.Endorsements))
+	seen := make(map[string]bool, len(a.Endorsements))
+	for i, e := range a.Endorsements {
+		if e.Uuid != uuid {
+			return ErrMixedAggregate
+		}
+		if seen[e.Emitter] {
+			return ErrDuplicateEndorser
+		}
+		seen[e.Emitter] = true
+
+		hash, err := hashes.Hash(e)
+		if err != nil {
+			return err
+		}
+		emitters[i], digests[i] = e.Emitter, hash
+	}
+
+	return kr.VerifyAggregate(emitters, digests, a.Signature)
+}
+
+// involves returns true if one of the endorsements is emitted by one of the
+// provided identities.
+func (a *AggregatedEndorsement) involves(identities map[string]bool) bool {
+	for _, e := range a.Endorsements {
+		if identities[e.Emitter] {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAggregate(aggregates []*AggregatedEndorsement, a *AggregatedEndorsement) bool {
+	for _, a2 := range aggregates {
+		if a2 == a {
+			return true
+		}
+	}
+	return false
+}
+
+func (eng *Engine) handleAggregate(a *AggregatedEndorsement) {
+	err := a.verify(eng.KeyRing, eng.hashes)
+	if err != nil {
+		zap.L().Debug("Aggregate",
+			zap.Int("endorsements", len(a.Endorsements)),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if !eng.admitsOrphan(a.Endorsements[0].Uuid) {
+		eng.rejectOrphans(len(a.Endorsements))
+		return
+	}
+
+	_, inserted := eng.qs.AddAggregate(a)
+	for _, e := range inserted {
+		eng.emit(EngineEvent{Type: EventEndorsed, Uuid: e.Uuid, Emitter: e.Emitter})
+	}
+	eng.checkState(a.Endorsements[0].Uuid)
+}
+
+// aggregateProofs replaces the endorsement proofs of each query by a single
+// aggregate, if the crypto engine of the keyring supports it, so that vetoes
+// carry one signature per query instead of one per endorser.
+//
+// Aggregates cannot be split: received aggregates are merged when they do not
+// share endorsers, and kept aside otherwise. Signed endorsements already
+// covered by an aggregate are dropped.
+func (eng *Engine) aggregateProofs(proofs []*Proof) []*Proof {
+	if eng.KeyRing == nil || !eng.KeyRing.CanAggregate() {
+		return proofs
+	}
+
+	type group struct {
+		endorsements []*Endorsement
+		signatures   [][]byte
+		covered      map[string]bool
+	}
+
+	groups := make(map[string]*group)
+	var order []string
+	getGroup := func(uuid string) *group {
+		g, ok := groups[uuid]
+		if !ok {
+			g = &group{covered: make(map[string]bool)}
+			groups[uuid] = g
+			order = append(order, uuid)
+		}
+		return g
+	}
+
+	var out, overlapping []*Proof
+	for _, p := range proofs { // aggregates first, they are the least flexible
+		a := p.GetAggregate()
+		if a == nil || len(a.Endorsements) == 0 {
+			continue
+		}
+
+		g := getGroup(a.Endorsements[0].Uuid)
+		if a.involves(g.covered) {
+			overlapping = append(overlapping, p)
+			continue
+		}
+
+		for _, e := range a.Endorsements {
+			g.covered[e.Emitter] = true
+		}
+		g.endorsements = append(g.endorsements, a.Endorsements...)
+		g.signatures = append(g.signatures, a.Signature)
+	}
+
+	for _, p := range proofs {
+		if p.GetAggregate() != nil {
+			continue
+		}
+
+		e := p.GetEndorsement()
+		if e == nil {
+			out = append(out, p)
+			continue
+		}
+
+		g := getGroup(e.Uuid)
+		if g.covered[e.Emitter] {
+			continue
+		}
+		g.covered[e.Emitter] = true
+
+		unsigned := proto.Clone(e).(*Endorsement)
+		unsigned.Signature = nil
+		g.endorsements = append(g.endorsements, unsigned)
+		g.signatures = append(g.signatures, e.Signature)
+	}
+
+	out = append(out, overlapping...)
+	for _, uuid := range order {
+		g := groups[uuid]
+		if len(g.signatures) == 0 {
+			continue
+		}
+
+		signature, err := eng.KeyRing.Aggregate(g.signatures)
+		if err != nil {
+			zap.L().Warn("Aggregate",
+				zap.String("uuid", uuid),
+				zap.Error(err),
+			)
+			return proofs
+		}
+
+		out = append(out, &Proof{Content: &Proof_Aggregate{&AggregatedEndorsement{
+			Endorsements: g.endorsements,
+			Signature:    signature,
+		}}})
+	}
+	return out
+}
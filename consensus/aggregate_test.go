@@ -0,0 +1,121 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_AggregateProofs(t *testing.T) {
+	const quorum = 3
+	keyrings := tests.GetTestKeyRingsCrypto(t, 4, "bls12381")
+	signers := make([]*Engine, len(keyrings))
+	for i, kr := range keyrings {
+		signers[i] = NewEngine(newMemoryStore(), &recordingNetwork{}, passBBC{}, kr, quorum)
+	}
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Emitter = keyrings[1].Identity()
+	q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: []byte("a")}}
+	require.Nil(t, signers[1].signQuery(q))
+
+	endorsements := make([]*Endorsement, len(keyrings))
+	for i := range endorsements {
+		endorsements[i] = &Endorsement{Uuid: q.Uuid, Emitter: keyrings[i].Identity()}
+		require.Nil(t, signers[i].signEndorsement(endorsements[i]))
+	}
+
+	// The veto of node 0 carries a single aggregated signature
+	vetoer := signers[0]
+	vetoer.qs.AddQuery(q)
+	for _, e := range endorsements[1:] {
+		vetoer.qs.AddEndorsement(e)
+	}
+	choice, proofs := vetoer.qs.CheckpointChoice([]string{q.Uuid})
+	require.False(t, choice)
+	require.Len(t, proofs, 4)
+
+	proofs = vetoer.aggregateProofs(proofs)
+	require.Len(t, proofs, 2)
+	require.Exactly(t, q, proofs[0].GetQuery())
+	a := proofs[1].GetAggregate()
+	require.NotNil(t, a)
+	require.Len(t, a.Endorsements, 3)
+	for _, e := range a.Endorsements {
+		require.Empty(t, e.Signature)
+	}
+	require.NotEmpty(t, endorsements[1].Signature, "stored endorsements must not be modified")
+	require.Nil(t, a.verify(keyrings[3], nil))
+
+	tampered := proto.Clone(a).(*AggregatedEndorsement)
+	tampered.Endorsements[0].Conditions = []string{"forged"}
+	require.NotNil(t, tampered.verify(keyrings[3], nil))
+	tampered = proto.Clone(a).(*AggregatedEndorsement)
+	tampered.Endorsements[1] = tampered.Endorsements[0]
+	require.Exactly(t, ErrDuplicateEndorser, tampered.verify(keyrings[3], nil))
+	require.Exactly(t, ErrEmptyAggregate, (&AggregatedEndorsement{}).verify(keyrings[3], nil))
+
+	// Aggregates received before their query are kept until it arrives, and
+	// count toward the quorum like their endorsements
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, passBBC{}, keyrings[2], quorum)
+	eng.handleAggregate(a)
+	eng.qs.AddQuery(q)
+	eng.checkState(q.Uuid)
+	_, v, _ := eng.Store.Get("a")
+	require.NotEqual(t, NoVersion, v, "aggregate should commit the query")
+
+	signed, aggregates := eng.qs.CommitEndorsements(q.Uuid)
+	require.Empty(t, signed)
+	require.Exactly(t, []*AggregatedEndorsement{a}, aggregates)
+
+	cert, err := eng.certify(q, signed, aggregates)
+	require.Nil(t, err)
+	require.Nil(t, VerifyCertificate(cert, keyrings[0], quorum))
+	require.Exactly(t, ErrInsufficientEndorsements, VerifyCertificate(cert, keyrings[0], quorum+1))
+
+	// Disjoint aggregates and endorsements are merged, overlapping ones are
+	// kept aside, and endorsements already aggregated are dropped
+	partial := vetoer.aggregateProofs([]*Proof{
+		{Content: &Proof_Endorsement{endorsements[0]}},
+		{Content: &Proof_Endorsement{endorsements[1]}},
+	})[0].GetAggregate()
+	require.Len(t, partial.Endorsements, 2)
+
+	proofs = vetoer.aggregateProofs([]*Proof{
+		{Content: &Proof_Query{q}},
+		{Content: &Proof_Aggregate{partial}},
+		{Content: &Proof_Aggregate{a}},
+		{Content: &Proof_Endorsement{endorsements[1]}},
+		{Content: &Proof_Endorsement{endorsements[2]}},
+	})
+	require.Len(t, proofs, 3)
+	require.Exactly(t, q, proofs[0].GetQuery())
+	require.Exactly(t, a, proofs[1].GetAggregate())
+	merged := proofs[2].GetAggregate()
+	require.Len(t, merged.Endorsements, 3)
+	require.Nil(t, merged.verify(keyrings[3], nil))
+
+	// Aggregates survive the wire, as proofs of a veto
+	raw, err := proto.Marshal(proofs[2])
+	require.Nil(t, err)
+	decoded := new(Proof)
+	require.Nil(t, proto.Unmarshal(raw, decoded))
+	require.True(t, proto.Equal(proofs[2], decoded))
+	require.Nil(t, decoded.GetAggregate().verify(keyrings[3], nil))
+
+	// Other crypto engines keep individual endorsements
+	ed25519 := NewEngine(nil, nil, nil, tests.GetTestKeyRings(t, 1)[0], quorum)
+	proofs = []*Proof{{Content: &Proof_Endorsement{endorsements[0]}}}
+	require.Exactly(t, proofs, ed25519.aggregateProofs(proofs))
+}
@@ -0,0 +1,111 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+)
+
+// AppliedPrefix is the prefix of the keys marking the queries applied by the
+// node. These keys are local to the node.
+//
+// Markers are written in the same batch as the values of the query, so that
+// operations which are not idempotent (such as CONCAT) are never executed
+// twice, even if the query is committed again after a restart.
+const AppliedPrefix = "_applied/"
+
+// Like endorsement records, markers are spread over a fixed number of keys,
+// and pruned appliedMarkerHorizon after the deadline of their query when
+// their key is written. Queries committed later than that are not protected.
+const (
+	appliedMarkerSlots   = 256
+	appliedMarkerHorizon = 1 * time.Hour
+)
+
+func appliedMarkerKey(uuid string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(uuid))
+	return fmt.Sprintf("%s%02x", AppliedPrefix, h.Sum32()%appliedMarkerSlots)
+}
+
+// loadAppliedMarkers returns the markers stored in the key of a query, and
+// true if the query has already been applied.
+// unsafe
+func (eng *Engine) loadAppliedMarkers(uuid string) (*AppliedMarkers, bool, error) {
+	markers := &AppliedMarkers{}
+	data, version, err := eng.Store.Get(appliedMarkerKey(uuid))
+	if version == NoVersion {
+		return markers, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = proto.Unmarshal(data, markers)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, m := range markers.Markers {
+		if m.Uuid == uuid {
+			return markers, true, nil
+		}
+	}
+	return markers, false, nil
+}
+
+// markApplied adds the marker of q to markers, after having pruned the
+// expired ones, and returns the value to store in the key of q.
+func (eng *Engine) markApplied(markers *AppliedMarkers, q *Query) ([]byte, error) {
+	limit := eng.now().Add(-appliedMarkerHorizon)
+	kept := markers.Markers[:0]
+	for _, m := range markers.Markers {
+		deadline, err := ptypes.Timestamp(m.Deadline)
+		if err == nil && !deadline.Before(limit) {
+			kept = append(kept, m)
+		}
+	}
+
+	markers.Markers = append(kept, &AppliedMarkers_Marker{
+		Uuid:     q.Uuid,
+		Deadline: q.Deadline,
+	})
+	return proto.Marshal(markers)
+}
+
+// applying marks a query as being applied by the calling goroutine. It
+// returns false if another goroutine is already applying it.
+// This function is thread-safe.
+func (eng *Engine) applying(uuid string) bool {
+	eng.applyMutex.Lock()
+	defer eng.applyMutex.Unlock()
+
+	if eng.inflightApply == nil {
+		eng.inflightApply = make(map[string]struct{})
+	}
+
+	if _, ok := eng.inflightApply[uuid]; ok {
+		return false
+	}
+	eng.inflightApply[uuid] = struct{}{}
+	return true
+}
+
+// applied releases a query marked by applying.
+// This function is thread-safe.
+func (eng *Engine) applied(uuid string) {
+	eng.applyMutex.Lock()
+	defer eng.applyMutex.Unlock()
+	delete(eng.inflightApply, uuid)
+}
@@ -0,0 +1,210 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/consensus/operations"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_ApplyOnce(t *testing.T) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	store := newMemoryStore()
+	require.Nil(t, store.Set("a", []byte("base"), NewVersion([]byte("base"))))
+
+	start := func() (*Engine, context.CancelFunc) {
+		eng := NewEngine(store, &recordingNetwork{}, passBBC{}, kr, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		require.Nil(t, eng.Run(ctx))
+		return eng, cancel
+	}
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Operations = []*Operation{{Key: "a", Op: Operation_CONCAT, Data: []byte("+x")}}
+	q.Emitter = kr.Identity()
+
+	eng, stop := start()
+	require.Nil(t, eng.signQuery(q))
+	eng.handleQuery(q)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			eng.checkState(q.Uuid)
+		}()
+		go func() {
+			defer wg.Done()
+			if err := eng.apply(q.Uuid); err != nil { // as if the query was committed again
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	stop()
+
+	// The query store is lost on restart, the query is committed again
+	restarted, stop := start()
+	defer stop()
+	restarted.handleQuery(q)
+
+	store.Lock()
+	defer store.Unlock()
+	value, _, err := store.Get("a")
+	require.Nil(t, err)
+	require.Equal(t, "base+x", string(value), "the query should be applied exactly once")
+
+	list, err := store.List()
+	require.Nil(t, err)
+	require.Contains(t, list, appliedMarkerKey(q.Uuid))
+	require.True(t, IsLocalKey(appliedMarkerKey(q.Uuid)))
+}
+
+// batchStore records the keys of every batch written to the store.
+type batchStore struct {
+	*memoryStore
+	batches [][]string
+}
+
+func (s *batchStore) SetBatch(keys []string, values [][]byte, versions []*Version) error {
+	s.batches = append(s.batches, keys)
+	return s.memoryStore.SetBatch(keys, values, versions)
+}
+
+func TestEngine_ApplyOrder(t *testing.T) {
+	qs := newQueryStore()
+	store := &batchStore{memoryStore: newMemoryStore()}
+	e := &Engine{Store: store, qs: qs}
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	for _, key := range []string{"c", "a", "d", "b"} {
+		q.Operations = append(q.Operations, &Operation{Key: key, Op: Operation_SET, Data: []byte(key)})
+	}
+	qs.AddQuery(q)
+
+	require.Nil(t, e.apply(q.Uuid))
+	require.Len(t, store.batches, 1)
+	require.Equal(t, []string{"a", "b", "c", "d", appliedMarkerKey(q.Uuid)}, store.batches[0])
+}
+
+func TestEngine_ApplyFailure(t *testing.T) {
+	qs := newQueryStore()
+	qs.threshold = 1
+	store := &batchStore{memoryStore: newMemoryStore()}
+	require.Nil(t, store.Set("a", []byte("text"), NewVersion([]byte("text"))))
+	e := &Engine{Store: store, qs: qs}
+
+	// The second operation fails on a type mismatch
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Operations = []*Operation{
+		{Key: "b", Op: Operation_SET, Data: []byte("1")},
+		{Key: "a", Op: Operation_ADD, Data: []byte("1")},
+	}
+	qs.AddQuery(q)
+	qs.AddEndorsement(&Endorsement{Uuid: q.Uuid, Emitter: "bob"})
+	e.checkState(q.Uuid)
+
+	require.Equal(t, operations.ErrNotNumeric, qs.ApplyError(q.Uuid))
+	require.Empty(t, store.batches, "no partial write must reach the store")
+
+	value, version, err := store.Get("b")
+	require.Nil(t, err)
+	require.Nil(t, value)
+	require.Equal(t, NoVersion, version)
+
+	_, done, err := e.loadAppliedMarkers(q.Uuid)
+	require.Nil(t, err)
+	require.False(t, done, "a failed query must not be marked as applied")
+}
+
+func TestEngine_ApplyDelete(t *testing.T) {
+	qs := newQueryStore()
+	store := &batchStore{memoryStore: newMemoryStore()}
+	for _, key := range []string{"a", "b"} {
+		require.Nil(t, store.Set(key, []byte(key), NewVersion([]byte(key))))
+	}
+	e := &Engine{Store: store, qs: qs}
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Operations = []*Operation{
+		{Key: "a", Op: Operation_DELETE},
+		{Key: "b", Op: Operation_DELETE},
+		{Key: "b", Op: Operation_SET, Data: []byte("b2")},
+		{Key: "c", Op: Operation_DELETE},
+	}
+	qs.AddQuery(q)
+
+	require.Nil(t, e.apply(q.Uuid))
+	require.Len(t, store.batches, 1)
+	require.Equal(t, []string{"b", appliedMarkerKey(q.Uuid)}, store.batches[0])
+
+	for _, key := range []string{"a", "c"} {
+		value, version, err := store.Get(key)
+		require.Nil(t, err)
+		require.Nil(t, value)
+		require.Equal(t, NoVersion, version)
+
+		res, err := e.recoveryHandler(&RecoveryRequest{Key: key})
+		require.Nil(t, err)
+		require.Equal(t, NoVersion, res.Version)
+		require.Nil(t, res.Data)
+	}
+
+	value, _, err := store.Get("b")
+	require.Nil(t, err)
+	require.Equal(t, []byte("b2"), value)
+}
+
+// deletingStore records the keys removed by every batch, see BatchDeleter.
+type deletingStore struct {
+	batchStore
+	deletions [][]string
+}
+
+func (s *deletingStore) UpdateBatch(keys []string, values [][]byte, versions []*Version, deleted []string) error {
+	s.deletions = append(s.deletions, deleted)
+	for _, key := range deleted {
+		_ = s.memoryStore.Delete(key)
+	}
+	return s.SetBatch(keys, values, versions)
+}
+
+func TestEngine_ApplyDeleteBatch(t *testing.T) {
+	qs := newQueryStore()
+	store := &deletingStore{batchStore: batchStore{memoryStore: newMemoryStore()}}
+	require.Nil(t, store.Set("a", []byte("a"), NewVersion([]byte("a"))))
+	e := &Engine{Store: store, qs: qs}
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Operations = []*Operation{
+		{Key: "a", Op: Operation_DELETE},
+		{Key: "b", Op: Operation_SET, Data: []byte("b")},
+	}
+	qs.AddQuery(q)
+
+	require.Nil(t, e.apply(q.Uuid))
+	require.Equal(t, [][]string{{"b", appliedMarkerKey(q.Uuid)}}, store.batches)
+	require.Equal(t, [][]string{{"a"}}, store.deletions, "deletions should be part of the batch")
+
+	_, version, err := store.Get("a")
+	require.Nil(t, err)
+	require.Equal(t, NoVersion, version)
+}
@@ -0,0 +1,219 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+// Package threshold implements a signature-threshold Binary Byzantine
+// Consensus, simpler than the veto procedure of package bbc, for small
+// consortia of trusted nodes.
+//
+// Each node signs and broadcasts its vote for a checkpoint, and decides once
+// threshold signed votes for the same value have been collected. Those votes
+// are the proof of the decision. A single node cannot block a checkpoint, so
+// the threshold should be a majority of the nodes for every node to reach the
+// same decision.
+package threshold
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/consensus/bbc"
+	"github.com/technicolor-research/pnyxdb/keyring"
+)
+
+// ErrInvalidThreshold is returned when creating an engine with a threshold
+// lower than 1.
+var ErrInvalidThreshold = errors.New("the threshold of votes must be positive")
+
+type thresholdEngine struct {
+	*keyring.KeyRing
+
+	n         consensus.Network
+	threshold int
+	timeouts  bbc.Timeouts
+}
+
+// New returns a BBCEngine deciding a checkpoint once threshold matching
+// votes have been collected. The local vote is broadcast again after each
+// round, and Execute returns consensus.ErrBBCTimeout after the deadline.
+func New(n consensus.Network, k *keyring.KeyRing, threshold int, t bbc.Timeouts) (consensus.BBCEngine, error) {
+	if threshold < 1 {
+		return nil, ErrInvalidThreshold
+	}
+
+	return &thresholdEngine{
+		KeyRing:   k,
+		n:         n,
+		threshold: threshold,
+		timeouts:  t.WithDefaults(),
+	}, nil
+}
+
+func (te thresholdEngine) Execute(
+	ctx context.Context,
+	id string,
+	choice bool,
+	proofs []*consensus.Proof,
+) (bool, []*consensus.Proof, error) {
+	return te.ExecuteVerified(ctx, id, choice, proofs, nil)
+}
+
+// ExecuteVerified runs the consensus like Execute, ignoring the votes against
+// the checkpoint whose proofs are rejected by verify. Only the signatures of
+// the votes are checked if verify is nil.
+func (te thresholdEngine) ExecuteVerified(
+	ctx context.Context,
+	id string,
+	choice bool,
+	proofs []*consensus.Proof,
+	verify consensus.ProofVerifier,
+) (bool, []*consensus.Proof, error) {
+	local := &consensus.Vote{
+		Identifier: id,
+		Emitter:    te.Identity(),
+		Choice:     choice,
+		Proofs:     proofs,
+	}
+
+	hash, err := local.Hash()
+	if err != nil {
+		return false, nil, err
+	}
+
+	local.Signature, err = te.KeyRing.Sign(hash)
+	if err != nil {
+		return false, nil, err
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, te.timeouts.Deadline)
+	defer cancel()
+
+	acceptor := func(m proto.Message) bool {
+		v, ok := m.(*consensus.Vote)
+		return ok && v.Identifier == id
+	}
+
+	// Listen before voting, not to miss the votes answering ours
+	votes := te.n.Accept(ctx, acceptor)
+	err = te.broadcast(ctx, local)
+	if err != nil {
+		return false, nil, err
+	}
+
+	tally := newTally()
+	if decided, ok := tally.add(local, te.threshold); ok {
+		return decided, tally.proofs(decided), nil
+	}
+
+	round := time.NewTicker(te.timeouts.Round)
+	defer round.Stop()
+
+	for {
+		var m proto.Message
+		var ok bool
+		select {
+		case m, ok = <-votes:
+		case <-round.C:
+			_ = te.broadcast(ctx, local)
+			continue
+		}
+
+		if !ok {
+			if parent.Err() != nil {
+				return false, nil, parent.Err()
+			}
+			return false, nil, consensus.ErrBBCTimeout
+		}
+
+		v := m.(*consensus.Vote)
+		if tally.voted(v.Emitter) {
+			continue
+		}
+
+		hash, err := v.Hash()
+		if err != nil {
+			continue
+		}
+
+		err = te.KeyRing.Verify(v.Emitter, hash, v.Signature)
+		if err != nil {
+			continue
+		}
+
+		if !v.Choice && verify != nil {
+			if err = verify(v.Proofs); err != nil {
+				zap.L().Warn("Vote",
+					zap.String("id", id),
+					zap.String("emitter", v.Emitter),
+					zap.Error(err),
+				)
+				continue
+			}
+		}
+
+		if decided, ok := tally.add(v, te.threshold); ok {
+			// The peers which missed some votes can decide with ours
+			for _, v := range tally.votes[decided] {
+				_ = te.broadcast(ctx, v)
+			}
+			return decided, tally.proofs(decided), nil
+		}
+	}
+}
+
+// broadcast sends a vote, giving up after the broadcast timeout or once ctx is
+// done.
+func (te thresholdEngine) broadcast(ctx context.Context, v *consensus.Vote) error {
+	ctx, cancel := context.WithTimeout(ctx, te.timeouts.Broadcast)
+	defer cancel()
+	return te.n.Broadcast(ctx, v)
+}
+
+// tally holds the first valid vote of each node for a checkpoint, a node
+// changing its vote being ignored.
+type tally struct {
+	emitters map[string]bool
+	votes    map[bool][]*consensus.Vote
+}
+
+func newTally() *tally {
+	return &tally{
+		emitters: make(map[string]bool),
+		votes:    make(map[bool][]*consensus.Vote),
+	}
+}
+
+// voted returns true if a vote of emitter has already been counted.
+func (t *tally) voted(emitter string) bool {
+	return t.emitters[emitter]
+}
+
+// add counts a vote, and returns the decision once threshold votes have been
+// counted for its value.
+func (t *tally) add(v *consensus.Vote, threshold int) (decision bool, ok bool) {
+	if t.emitters[v.Emitter] {
+		return false, false
+	}
+
+	t.emitters[v.Emitter] = true
+	t.votes[v.Choice] = append(t.votes[v.Choice], v)
+	return v.Choice, len(t.votes[v.Choice]) >= threshold
+}
+
+// proofs returns the votes counted for decision, as proofs.
+func (t *tally) proofs(decision bool) []*consensus.Proof {
+	proofs := make([]*consensus.Proof, len(t.votes[decision]))
+	for i, v := range t.votes[decision] {
+		proofs[i] = &consensus.Proof{Content: &consensus.Proof_Vote{Vote: v}}
+	}
+	return proofs
+}
@@ -0,0 +1,241 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package threshold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/consensus/bbc"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+// hub delivers every broadcast message to the acceptors of every node,
+// including the sender. Like a stream, the messages broadcast before a call
+// to Accept are delivered too.
+type hub struct {
+	sync.Mutex
+	messages    []proto.Message
+	subscribers []subscriber
+}
+
+type subscriber struct {
+	ctx      context.Context
+	acceptor consensus.MessageAcceptor
+	out      chan proto.Message
+}
+
+func (h *hub) Close() error { return nil }
+
+func (h *hub) Broadcast(ctx context.Context, m proto.Message) error {
+	h.Lock()
+	defer h.Unlock()
+	h.messages = append(h.messages, m)
+	for _, s := range h.subscribers {
+		if s.ctx.Err() == nil && s.acceptor(m) {
+			select {
+			case s.out <- m:
+			default: // lost message
+			}
+		}
+	}
+	return nil
+}
+
+func (h *hub) Accept(ctx context.Context, acceptor consensus.MessageAcceptor) <-chan proto.Message {
+	in := make(chan proto.Message, 1024)
+	h.Lock()
+	for _, m := range h.messages {
+		if acceptor(m) && len(in) < cap(in) {
+			in <- m
+		}
+	}
+	h.subscribers = append(h.subscribers, subscriber{ctx, acceptor, in})
+	h.Unlock()
+
+	out := make(chan proto.Message)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case m := <-in:
+				select {
+				case out <- m:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func runThresholdEngine(t *testing.T, choices []bool, threshold int, proof *consensus.Proof, expected bool) {
+	n := &hub{}
+	id := strconv.Itoa(int(time.Now().UnixNano()))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	keyrings := tests.GetTestKeyRings(t, len(choices))
+
+	for i := 0; i < len(choices); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			te, err := New(n, keyrings[i], threshold, bbc.Timeouts{Round: 50 * time.Millisecond})
+			require.Nil(t, err, "should create a correct threshold engine")
+
+			var proofs []*consensus.Proof
+			if !choices[i] {
+				proofs = append(proofs, proof)
+			}
+
+			decision, dp, err := te.Execute(ctx, id, choices[i], proofs)
+			require.Nil(t, err, "execute should not result in an error")
+			require.Equal(t, expected, decision, fmt.Sprintf("decision %d is invalid", i))
+
+			// The decision proof is made of threshold votes for the decision
+			require.Len(t, dp, threshold, fmt.Sprintf("decision proof %d is invalid", i))
+			emitters := make(map[string]bool)
+			for _, p := range dp {
+				v := p.GetVote()
+				require.NotNil(t, v)
+				require.Equal(t, id, v.Identifier)
+				require.Equal(t, expected, v.Choice)
+				hash, err := v.Hash()
+				require.Nil(t, err)
+				require.Nil(t, keyrings[i].Verify(v.Emitter, hash, v.Signature), "votes should be signed")
+				emitters[v.Emitter] = true
+
+				if !expected {
+					require.Len(t, v.Proofs, 1)
+					require.Equal(t, proof.GetQuery().Uuid, v.Proofs[0].GetQuery().Uuid)
+				}
+			}
+			require.Len(t, emitters, threshold, "votes should come from distinct nodes")
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+func TestThresholdEngine(t *testing.T) {
+	choices := make([]bool, 10)
+	proof := &consensus.Proof{
+		Content: &consensus.Proof_Query{
+			Query: consensus.NewQuery(),
+		},
+	}
+
+	t.Run("CompleteDisagreement", func(t *testing.T) {
+		runThresholdEngine(t, choices, 6, proof, false)
+	})
+
+	t.Run("CompleteAgreement", func(t *testing.T) {
+		for i := range choices {
+			choices[i] = true
+		}
+		runThresholdEngine(t, choices, 6, nil, true)
+	})
+
+	t.Run("OneAgainst", func(t *testing.T) {
+		choices[4] = false
+		runThresholdEngine(t, choices, 6, proof, true)
+	})
+}
+
+func TestThresholdEngine_Invalid(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 1)
+	_, err := New(&hub{}, keyrings[0], 0, bbc.Timeouts{})
+	require.Exactly(t, ErrInvalidThreshold, err)
+}
+
+func TestThresholdEngine_Timeout(t *testing.T) {
+	n := &hub{}
+	keyrings := tests.GetTestKeyRings(t, 4)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Two votes for each value never reach the threshold
+	var wg sync.WaitGroup
+	for i := range keyrings {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			te, err := New(n, keyrings[i], 3, bbc.Timeouts{
+				Round:    10 * time.Millisecond,
+				Deadline: 200 * time.Millisecond,
+			})
+			require.Nil(t, err)
+
+			_, _, err = te.Execute(ctx, "checkpoint", i%2 == 0, nil)
+			require.Exactly(t, consensus.ErrBBCTimeout, err)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestThresholdEngine_VerifyProofs(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 3)
+	vote := func(i int, c bool) *consensus.Vote {
+		v := &consensus.Vote{Identifier: "checkpoint", Emitter: keyrings[i].Identity(), Choice: c}
+		hash, err := v.Hash()
+		require.Nil(t, err)
+		v.Signature, err = keyrings[i].Sign(hash)
+		require.Nil(t, err)
+		return v
+	}
+
+	// The votes of the other nodes are sent again at every round
+	run := func(votes []*consensus.Vote, verify consensus.ProofVerifier) (bool, []*consensus.Proof, error) {
+		n := &hub{}
+		te, err := New(n, keyrings[0], 2, bbc.Timeouts{Round: 10 * time.Millisecond, Deadline: 300 * time.Millisecond})
+		require.Nil(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		go func() {
+			for ctx.Err() == nil {
+				for _, v := range votes {
+					_ = n.Broadcast(ctx, v)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+
+		return te.(consensus.VerifyingBBCEngine).ExecuteVerified(ctx, "checkpoint", true, nil, verify)
+	}
+
+	against := []*consensus.Vote{vote(1, false), vote(2, false)}
+	decision, dp, err := run(against, nil)
+	require.Nil(t, err)
+	require.False(t, decision, "should honor votes without verifier")
+	require.Len(t, dp, 2)
+
+	decision, _, err = run(against, func(proofs []*consensus.Proof) error { return nil })
+	require.Nil(t, err)
+	require.False(t, decision)
+
+	_, _, err = run(against, func(proofs []*consensus.Proof) error { return errors.New("bogus") })
+	require.Exactly(t, consensus.ErrBBCTimeout, err, "should ignore votes with invalid proofs")
+
+	forged := vote(1, false)
+	forged.Emitter = keyrings[2].Identity()
+	_, _, err = run([]*consensus.Vote{vote(1, false), forged}, nil)
+	require.Exactly(t, consensus.ErrBBCTimeout, err, "should ignore votes with invalid signatures")
+}
@@ -9,22 +9,63 @@
 // Package bbc implements a very simple Byzantine Broadcast Consensus algorithm.
 //
 // This BBC algorithm supports the Veto procedure, as expected by main consensus.
+// See package threshold for an alternative suited to small trusted consortia.
 package bbc
 
 import (
 	"context"
 	"crypto/sha512"
+	"time"
 
 	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+
 	"github.com/technicolor-research/pnyxdb/consensus"
 	"github.com/technicolor-research/pnyxdb/keyring"
 )
 
+// Default timeouts of the BBC engines.
+const (
+	DefaultRoundTimeout = 2 * time.Second
+	DefaultDeadline     = time.Minute
+)
+
+// Timeouts bound the duration of the consensus when peers do not answer,
+// because they crashed or their messages are lost.
+type Timeouts struct {
+	// Round is the period after which the local choice is broadcast again,
+	// until a decision is reached (DefaultRoundTimeout if zero).
+	Round time.Duration
+	// Deadline is the duration after which Execute gives up, with
+	// consensus.ErrBBCTimeout (DefaultDeadline if zero).
+	Deadline time.Duration
+	// Broadcast bounds the time spent handing a message to the network, like
+	// Engine.BroadcastTimeout (consensus.DefaultBroadcastTimeout if zero).
+	Broadcast time.Duration
+}
+
+// WithDefaults returns the timeouts, replacing the unset ones by their
+// default value.
+func (t Timeouts) WithDefaults() Timeouts {
+	if t.Round <= 0 {
+		t.Round = DefaultRoundTimeout
+	}
+	if t.Deadline <= 0 {
+		t.Deadline = DefaultDeadline
+	}
+	if t.Broadcast <= 0 {
+		t.Broadcast = consensus.DefaultBroadcastTimeout
+	}
+	return t
+}
+
 type vetoEngine struct {
 	*keyring.KeyRing
 
 	n         consensus.Network
 	threshold int
+	timeouts  Timeouts
+	hashes    *consensus.HashCache // choices are broadcast again after each round
 }
 
 // NewVetoEngine returns a BBCEngine that works as a BV-broadcast
@@ -32,10 +73,18 @@ type vetoEngine struct {
 // Asynchronous Binary Byzantine Consensus (ACM 2015) with a Veto
 // variant.
 func NewVetoEngine(n consensus.Network, k *keyring.KeyRing, threshold int) (consensus.BBCEngine, error) {
+	return NewVetoEngineWithTimeouts(n, k, threshold, Timeouts{})
+}
+
+// NewVetoEngineWithTimeouts returns a BBCEngine like NewVetoEngine, with
+// custom timeouts.
+func NewVetoEngineWithTimeouts(n consensus.Network, k *keyring.KeyRing, threshold int, t Timeouts) (consensus.BBCEngine, error) {
 	return &vetoEngine{
 		KeyRing:   k,
 		n:         n,
 		threshold: threshold,
+		timeouts:  t.WithDefaults(),
+		hashes:    consensus.NewHashCache(consensus.DefaultHashCacheSize),
 	}, nil
 }
 
@@ -44,6 +93,19 @@ func (ve vetoEngine) Execute(
 	id string,
 	choice bool,
 	proofs []*consensus.Proof,
+) (decision bool, dp []*consensus.Proof, err error) {
+	return ve.ExecuteVerified(ctx, id, choice, proofs, nil)
+}
+
+// ExecuteVerified runs the consensus like Execute, ignoring the vetoes whose
+// proofs are rejected by verify. Only the signatures of the choices are
+// checked if verify is nil.
+func (ve vetoEngine) ExecuteVerified(
+	ctx context.Context,
+	id string,
+	choice bool,
+	proofs []*consensus.Proof,
+	verify consensus.ProofVerifier,
 ) (decision bool, dp []*consensus.Proof, err error) {
 	c := &Choice{
 		Identifier: id,
@@ -62,25 +124,48 @@ func (ve vetoEngine) Execute(
 		return
 	}
 
-	err = ve.n.Broadcast(c)
+	err = ve.broadcast(ctx, c)
 	if err != nil {
 		return
 	}
 
 	sentF := !choice
 	receivedT := make(map[string]bool)
-	ctx, cancel := context.WithCancel(ctx)
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, ve.timeouts.Deadline)
 	defer cancel()
 
 	acceptor := func(m proto.Message) bool {
 		c, ok := m.(*Choice)
 		return ok && c.Identifier == id
-		// TODO verify proofs
 	}
 
-	for m := range ve.n.Accept(ctx, acceptor) {
+	// The local choice is broadcast again after each round, for the peers
+	// which missed it
+	round := time.NewTicker(ve.timeouts.Round)
+	defer round.Stop()
+
+	local := c
+	choices := ve.n.Accept(ctx, acceptor)
+	for {
+		var m proto.Message
+		var ok bool
+		select {
+		case m, ok = <-choices:
+		case <-round.C:
+			_ = ve.broadcast(ctx, local)
+			continue
+		}
+
+		if !ok {
+			if parent.Err() != nil {
+				return false, nil, parent.Err()
+			}
+			return false, nil, consensus.ErrBBCTimeout
+		}
+
 		c := m.(*Choice)
-		hash, err = c.Hash()
+		hash, err = ve.hashes.Hash(c)
 		if err != nil {
 			continue
 		}
@@ -91,8 +176,19 @@ func (ve vetoEngine) Execute(
 		}
 
 		if !c.Choice {
+			if verify != nil {
+				if err = verify(c.Proofs); err != nil {
+					zap.L().Warn("Veto",
+						zap.String("id", id),
+						zap.String("emitter", c.Emitter),
+						zap.Error(err),
+					)
+					continue
+				}
+			}
+
 			if !sentF {
-				err = ve.n.Broadcast(c)
+				err = ve.broadcast(ctx, c)
 				if err == nil {
 					sentF = true
 				}
@@ -104,18 +200,27 @@ func (ve vetoEngine) Execute(
 
 		receivedT[c.Emitter] = true
 		if len(receivedT) == ve.threshold { // Threshold reached
-			break
+			return true, nil, nil
 		}
 	}
-
-	return true, nil, nil
 }
 
 // Hash returns a fixed-size hash of the (unsigned) version of the choice
 // Passed by value because of internal modifications.
+// Proofs are marshalled deterministically, since queries contain maps.
 func (c Choice) Hash() ([]byte, error) {
 	c.Signature = nil
-	raw, err := proto.Marshal(&c)
-	hash := sha512.Sum512(raw)
+	b := proto.NewBuffer(nil)
+	b.SetDeterministic(true)
+	err := b.Marshal(&c)
+	hash := sha512.Sum512(b.Bytes())
 	return hash[:], err
 }
+
+// broadcast sends a choice, giving up after the broadcast timeout or once ctx
+// is done.
+func (ve vetoEngine) broadcast(ctx context.Context, c *Choice) error {
+	ctx, cancel := context.WithTimeout(ctx, ve.timeouts.Broadcast)
+	defer cancel()
+	return ve.n.Broadcast(ctx, c)
+}
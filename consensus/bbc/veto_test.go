@@ -10,12 +10,15 @@ package bbc
 
 import (
 	"context"
+	"errors"
 	fmt "fmt"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/require"
 	"github.com/technicolor-research/pnyxdb/consensus"
 	"github.com/technicolor-research/pnyxdb/network/redis"
@@ -85,3 +88,136 @@ func TestVetoEngine(t *testing.T) {
 		runVetoEngine(t, choices, proof, false)
 	})
 }
+
+// scriptedNetwork delivers a fixed sequence of messages to every acceptor,
+// and counts broadcasts.
+type scriptedNetwork struct {
+	messages   []proto.Message
+	broadcasts int32
+}
+
+func (n *scriptedNetwork) Close() error { return nil }
+
+func (n *scriptedNetwork) Broadcast(ctx context.Context, m proto.Message) error {
+	atomic.AddInt32(&n.broadcasts, 1)
+	return nil
+}
+
+func (n *scriptedNetwork) Accept(ctx context.Context, acceptor consensus.MessageAcceptor) <-chan proto.Message {
+	out := make(chan proto.Message)
+	go func() {
+		defer close(out)
+		for _, m := range n.messages {
+			if !acceptor(m) {
+				continue
+			}
+			select {
+			case out <- m:
+			case <-ctx.Done():
+				return
+			}
+		}
+		<-ctx.Done()
+	}()
+	return out
+}
+
+// deadlineNetwork records the time left to each broadcast.
+type deadlineNetwork struct {
+	scriptedNetwork
+	left time.Duration
+}
+
+func (n *deadlineNetwork) Broadcast(ctx context.Context, m proto.Message) error {
+	deadline, _ := ctx.Deadline()
+	n.left = time.Until(deadline)
+	return nil
+}
+
+func TestVetoEngine_BroadcastTimeout(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 1)
+	n := &deadlineNetwork{}
+
+	ve, err := NewVetoEngineWithTimeouts(n, keyrings[0], 1, Timeouts{Broadcast: 50 * time.Millisecond})
+	require.Nil(t, err)
+	require.Nil(t, ve.(*vetoEngine).broadcast(context.Background(), &Choice{}))
+	require.True(t, n.left > 0 && n.left <= 50*time.Millisecond, "the configured timeout should be used")
+
+	ve, err = NewVetoEngine(n, keyrings[0], 1)
+	require.Nil(t, err)
+	require.Nil(t, ve.(*vetoEngine).broadcast(context.Background(), &Choice{}))
+	require.True(t, n.left > 50*time.Millisecond && n.left <= consensus.DefaultBroadcastTimeout)
+}
+
+func TestVetoEngine_VerifyProofs(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 3)
+	choice := func(i int, c bool, proofs ...*consensus.Proof) *Choice {
+		ch := &Choice{Identifier: "checkpoint", Emitter: keyrings[i].Identity(), Choice: c, Proofs: proofs}
+		hash, err := ch.Hash()
+		require.Nil(t, err)
+		ch.Signature, err = keyrings[i].Sign(hash)
+		require.Nil(t, err)
+		return ch
+	}
+
+	bogus := &consensus.Proof{Content: &consensus.Proof_Query{Query: consensus.NewQuery()}}
+	n := &scriptedNetwork{messages: []proto.Message{
+		choice(1, false, bogus),
+		choice(1, true),
+		choice(2, true),
+	}}
+	ve, err := NewVetoEngine(n, keyrings[0], 2)
+	require.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	decision, dp, err := ve.Execute(ctx, "checkpoint", true, nil)
+	require.Nil(t, err)
+	require.False(t, decision, "should honor vetoes without verifier")
+	require.Len(t, dp, 1)
+
+	reject := func(proofs []*consensus.Proof) error { return errors.New("bogus") }
+	decision, dp, err = ve.(consensus.VerifyingBBCEngine).ExecuteVerified(ctx, "checkpoint", true, nil, reject)
+	require.Nil(t, err)
+	require.True(t, decision, "should ignore vetoes with invalid proofs")
+	require.Empty(t, dp)
+
+	accept := func(proofs []*consensus.Proof) error { return nil }
+	decision, _, err = ve.(consensus.VerifyingBBCEngine).ExecuteVerified(ctx, "checkpoint", true, nil, accept)
+	require.Nil(t, err)
+	require.False(t, decision)
+}
+
+func TestVetoEngine_Timeout(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 3)
+	c := &Choice{Identifier: "checkpoint", Emitter: keyrings[1].Identity(), Choice: true}
+	hash, err := c.Hash()
+	require.Nil(t, err)
+	c.Signature, err = keyrings[1].Sign(hash)
+	require.Nil(t, err)
+
+	// The third node never answers
+	n := &scriptedNetwork{messages: []proto.Message{c}}
+	ve, err := NewVetoEngineWithTimeouts(n, keyrings[0], 2, Timeouts{
+		Round:    10 * time.Millisecond,
+		Deadline: 200 * time.Millisecond,
+	})
+	require.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err = ve.Execute(ctx, "checkpoint", true, nil)
+	require.Exactly(t, consensus.ErrBBCTimeout, err)
+	require.True(t, time.Since(start) < 2*time.Second, "should give up at the deadline")
+	require.True(t, atomic.LoadInt32(&n.broadcasts) > 1, "should broadcast the local choice again")
+
+	// Cancellation is not a timeout
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = ve.Execute(canceled, "checkpoint", true, nil)
+	require.NotNil(t, err)
+	require.NotEqual(t, consensus.ErrBBCTimeout, err)
+}
@@ -0,0 +1,311 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"go.uber.org/zap"
+)
+
+// beaconWindow is the number of rounds during which a beacon is used to
+// compute the cluster time.
+const beaconWindow = 6
+
+// ClusterClock computes a cluster time from signed timestamps (TimeBeacon),
+// periodically broadcast by a rotating subset of the nodes.
+//
+// Query deadlines are checked against the cluster time rather than against
+// the local clock, so that a node with a skewed clock still agrees with the
+// other nodes on expiry decisions. The cluster time is the local time
+// corrected by the median offset of the recent beacons, once outliers have
+// been rejected. When not enough beacons are available, the local time is
+// used as is.
+//
+// ClusterClock is thread-safe.
+type ClusterClock struct {
+	Period     time.Duration    // interval between two rounds of beacons
+	Emitters   int              // number of nodes broadcasting a beacon at each round
+	MinSamples int              // minimum number of recent beacons required to compute the cluster time
+	MaxOutlier time.Duration    // beacons whose offset is farther from the median are rejected
+	MaxSkew    time.Duration    // divergence between local and cluster time raising an alert
+	Clock      func() time.Time // local clock, time.Now if nil
+
+	mutex   sync.Mutex
+	samples map[string]beaconSample // latest beacon by emitter
+	skewed  bool
+}
+
+type beaconSample struct {
+	offset   time.Duration // emitter time - local time
+	emitted  time.Time     // emitter time
+	received time.Time     // local time
+}
+
+// NewClusterClock returns a ClusterClock with default parameters.
+func NewClusterClock(period time.Duration) *ClusterClock {
+	return &ClusterClock{
+		Period:     period,
+		Emitters:   3,
+		MinSamples: 3,
+		MaxOutlier: 2 * time.Second,
+		MaxSkew:    500 * time.Millisecond,
+	}
+}
+
+func (c *ClusterClock) local() time.Time {
+	if c.Clock == nil {
+		return time.Now()
+	}
+	return c.Clock()
+}
+
+// Now returns the current cluster time, or the local time if not enough
+// beacons have been received.
+func (c *ClusterClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.local()
+	offset, _ := c.offset(now)
+	return now.Add(offset)
+}
+
+// Offset returns the difference between the cluster time and the local
+// time, and whether enough beacons are available to compute it.
+func (c *ClusterClock) Offset() (time.Duration, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.offset(c.local())
+}
+
+// offset computes the cluster offset from the recent samples.
+// unsafe
+func (c *ClusterClock) offset(now time.Time) (time.Duration, bool) {
+	limit := now.Add(-beaconWindow * c.Period)
+	offsets := make([]time.Duration, 0, len(c.samples))
+	for _, s := range c.samples {
+		if s.received.After(limit) {
+			offsets = append(offsets, s.offset)
+		}
+	}
+
+	if len(offsets) == 0 || len(offsets) < c.MinSamples {
+		return 0, false
+	}
+
+	m := median(offsets)
+	kept := offsets[:0]
+	for _, o := range offsets {
+		if d := o - m; d <= c.MaxOutlier && d >= -c.MaxOutlier {
+			kept = append(kept, o)
+		}
+	}
+
+	if len(kept) < c.MinSamples {
+		return 0, false
+	}
+	return median(kept), true
+}
+
+func median(values []time.Duration) time.Duration {
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+// add records a beacon emitted at time t, and returns false if the beacon is
+// not more recent than the last one of the same emitter.
+func (c *ClusterClock) add(emitter string, t time.Time) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if last, ok := c.samples[emitter]; ok && !t.After(last.emitted) {
+		return false
+	}
+
+	now := c.local()
+	if c.samples == nil {
+		c.samples = make(map[string]beaconSample)
+	}
+	c.samples[emitter] = beaconSample{
+		offset:   t.Sub(now),
+		emitted:  t,
+		received: now,
+	}
+
+	c.checkSkew(now)
+	return true
+}
+
+// checkSkew raises an alert when the local clock diverges from the cluster time.
+// unsafe
+func (c *ClusterClock) checkSkew(now time.Time) {
+	offset, ok := c.offset(now)
+	if !ok {
+		return
+	}
+
+	skewed := offset > c.MaxSkew || offset < -c.MaxSkew
+	if skewed && !c.skewed {
+		zap.L().Warn("ClockSkew",
+			zap.Time("local", now),
+			zap.Time("cluster", now.Add(offset)),
+			zap.Duration("offset", offset),
+		)
+	} else if !skewed && c.skewed {
+		zap.L().Info("ClockSkew",
+			zap.String("state", "recovered"),
+			zap.Duration("offset", offset),
+		)
+	}
+	c.skewed = skewed
+}
+
+// selected returns true if identity shall broadcast a beacon at the given
+// round.
+func (c *ClusterClock) selected(identity string, members []string, round int64) bool {
+	return isSelected(identity, members, round, c.Emitters)
+}
+
+// isSelected returns true if identity is one of the count members selected
+// at the given round. Selected members are the ones with the lowest
+// round-dependent hashes, so that every node computes the same rotating
+// subset.
+func isSelected(identity string, members []string, round int64, count int) bool {
+	if len(members) <= count {
+		return true
+	}
+
+	rank := func(member string) []byte {
+		h := sha256.New()
+		_ = binary.Write(h, binary.BigEndian, round)
+		_, _ = h.Write([]byte(member))
+		return h.Sum(nil)
+	}
+
+	own := rank(identity)
+	var before int
+	for _, m := range members {
+		if m != identity && bytes.Compare(rank(m), own) < 0 {
+			before++
+		}
+	}
+	return before < count
+}
+
+// Hash returns a fixed-size hash of the (unsigned) version of the beacon.
+// Passed by value because of internal modifications.
+func (tb TimeBeacon) Hash() ([]byte, error) {
+	tb.Signature = nil
+	raw, err := proto.Marshal(&tb)
+	hash := sha512.Sum512(raw)
+	return hash[:], err
+}
+
+// now returns the time against which deadlines are checked.
+func (eng *Engine) now() time.Time {
+	if eng.ClusterClock == nil {
+		return eng.clock().Now()
+	}
+	return eng.ClusterClock.Now()
+}
+
+func (eng *Engine) runBeacon(ctx context.Context) {
+	go func() {
+		acceptor := func(m proto.Message) bool {
+			_, ok := m.(*TimeBeacon)
+			return ok
+		}
+
+		for m := range eng.Network.Accept(ctx, acceptor) {
+			eng.handleBeacon(m.(*TimeBeacon))
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(eng.ClusterClock.Period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				round := eng.ClusterClock.Now().UnixNano() / int64(eng.ClusterClock.Period)
+				if eng.ClusterClock.selected(eng.Identity(), eng.members(), round) {
+					eng.emitBeacon()
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// members returns the identities of the keyring.
+func (eng *Engine) members() []string {
+	keys := eng.KeyRing.ListPublic()
+	members := make([]string, len(keys))
+	for i, k := range keys {
+		members[i], _, _ = k.Info()
+	}
+	return members
+}
+
+func (eng *Engine) emitBeacon() {
+	now := eng.ClusterClock.local()
+	ts, err := ptypes.TimestampProto(now)
+	if err != nil {
+		return
+	}
+
+	tb := &TimeBeacon{
+		Emitter: eng.Identity(),
+		Time:    ts,
+	}
+	err = eng.signBeacon(tb)
+	if err != nil {
+		return
+	}
+
+	eng.ClusterClock.add(tb.Emitter, now)
+	_ = eng.broadcast(tb)
+}
+
+func (eng *Engine) handleBeacon(tb *TimeBeacon) {
+	if tb.Emitter == eng.Identity() {
+		return
+	}
+
+	err := eng.verifyBeacon(tb)
+	if err != nil {
+		zap.L().Debug("Invalid beacon",
+			zap.String("emitter", tb.Emitter),
+			zap.Error(err),
+		)
+		return
+	}
+
+	t, err := ptypes.Timestamp(tb.Time)
+	if err != nil {
+		return
+	}
+	eng.ClusterClock.add(tb.Emitter, t)
+}
@@ -0,0 +1,138 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestClusterClock_Offset(t *testing.T) {
+	now := time.Now()
+	c := NewClusterClock(time.Second)
+	c.Clock = func() time.Time { return now }
+
+	require.True(t, c.add("a", now.Add(1*time.Second)))
+	require.True(t, c.add("b", now.Add(2*time.Second)))
+	_, ok := c.Offset()
+	require.False(t, ok, "should require MinSamples beacons")
+	require.Exactly(t, now, c.Now(), "should fall back to local time")
+
+	require.True(t, c.add("c", now.Add(3*time.Second)))
+	offset, ok := c.Offset()
+	require.True(t, ok)
+	require.Exactly(t, 2*time.Second, offset)
+
+	require.False(t, c.add("c", now.Add(3*time.Second)), "should reject replayed beacons")
+
+	require.True(t, c.add("d", now.Add(time.Hour)))
+	offset, _ = c.Offset()
+	require.Exactly(t, 2*time.Second, offset, "should reject outliers")
+
+	now = now.Add(beaconWindow * time.Second)
+	_, ok = c.Offset()
+	require.False(t, ok, "should discard old beacons")
+}
+
+func TestClusterClock_Selected(t *testing.T) {
+	c := NewClusterClock(time.Second)
+	members := []string{"a", "b", "c", "d", "e", "f", "g"}
+
+	rotated := false
+	var first []string
+	for round := int64(0); round < 10; round++ {
+		var emitters []string
+		for _, m := range members {
+			if c.selected(m, members, round) {
+				emitters = append(emitters, m)
+			}
+		}
+
+		require.Len(t, emitters, c.Emitters)
+		if first == nil {
+			first = emitters
+		} else if !rotated {
+			rotated = !equalStrings(first, emitters)
+		}
+	}
+	require.True(t, rotated, "emitters should rotate")
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEngine_ClusterTime(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	defer zap.ReplaceGlobals(zap.New(core))()
+
+	const n = 5
+	const skew = 10 * time.Second
+
+	keyrings := tests.GetTestKeyRings(t, n)
+	h := &hub{}
+	engines := make([]*Engine, n)
+	for i := range engines {
+		engines[i] = NewEngine(newMemoryStore(), h.join(), nil, keyrings[i], n)
+		engines[i].ClusterClock = NewClusterClock(20 * time.Millisecond)
+		engines[i].ClusterClock.Emitters = n
+	}
+	engines[0].ClusterClock.Clock = func() time.Time { return time.Now().Add(skew) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, eng := range engines {
+		require.Nil(t, eng.Run(ctx))
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		offset, ok := engines[0].ClusterClock.Offset()
+		if ok && offset < -skew+time.Second {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("skewed node did not converge to cluster time", offset, ok)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	q := NewQuery()
+	q.SetTimeout(5 * time.Second)
+	require.True(t, q.ExpiredAt(engines[0].ClusterClock.local()), "query should be expired according to the skewed clock")
+	for i, eng := range engines {
+		require.True(t, eng.canEndorse(q), "node %d should not consider the query expired", i)
+	}
+
+	q.Deadline, _ = ptypes.TimestampProto(time.Now().Add(-time.Second))
+	for i, eng := range engines {
+		require.False(t, eng.canEndorse(q), "node %d should consider the query expired", i)
+	}
+
+	require.NotZero(t, logs.FilterMessage("ClockSkew").Len(), "clock skew should be logged")
+	for _, e := range logs.FilterMessage("ClockSkew").All() {
+		require.Exactly(t, zapcore.WarnLevel, e.Level)
+	}
+}
@@ -0,0 +1,104 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// DefaultBroadcastTimeout bounds the time spent handing a message to the
+// network, see Engine.BroadcastTimeout.
+const DefaultBroadcastTimeout = 10 * time.Second
+
+// broadcast sends m through the network, giving up after BroadcastTimeout or
+// once the engine is stopped.
+// This function is thread-safe.
+func (eng *Engine) broadcast(m proto.Message) error {
+	ctx := eng.runContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	timeout := eng.BroadcastTimeout
+	if timeout <= 0 {
+		timeout = DefaultBroadcastTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return eng.Network.Broadcast(ctx, m)
+}
+
+// LegacyNetwork is the Network interface of previous releases, whose
+// Broadcast does not take a context.
+//
+// Deprecated: implement Network instead. LegacyNetwork and FromLegacyNetwork
+// will be removed in the next release.
+type LegacyNetwork interface {
+	io.Closer
+
+	Broadcast(m proto.Message) error
+	Accept(ctx context.Context, acceptor MessageAcceptor) <-chan proto.Message
+}
+
+// FromLegacyNetwork adapts a LegacyNetwork to the Network interface. When the
+// context is done first, Broadcast returns its error while the legacy call
+// goes on in the background. Optional managers of n are kept.
+//
+// Deprecated: implement Network instead.
+func FromLegacyNetwork(n LegacyNetwork) Network {
+	l := legacyNetwork{n}
+	rec, isRec := n.(RecoveryManager)
+	psm, isPsm := n.(PendingSyncManager)
+
+	switch {
+	case isRec && isPsm:
+		return struct {
+			legacyNetwork
+			RecoveryManager
+			PendingSyncManager
+		}{l, rec, psm}
+	case isRec:
+		return struct {
+			legacyNetwork
+			RecoveryManager
+		}{l, rec}
+	case isPsm:
+		return struct {
+			legacyNetwork
+			PendingSyncManager
+		}{l, psm}
+	default:
+		return l
+	}
+}
+
+type legacyNetwork struct {
+	LegacyNetwork
+}
+
+func (l legacyNetwork) Broadcast(ctx context.Context, m proto.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- l.LegacyNetwork.Broadcast(m) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
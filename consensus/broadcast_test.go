@@ -0,0 +1,89 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+// stuckNetwork is a transport whose broadcasts never complete.
+type stuckNetwork struct {
+	recordingNetwork
+}
+
+func (n *stuckNetwork) Broadcast(ctx context.Context, _ proto.Message) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestEngine_BroadcastTimeout(t *testing.T) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	eng := NewEngine(newMemoryStore(), &stuckNetwork{}, nil, kr, 1)
+	eng.BroadcastTimeout = 50 * time.Millisecond
+
+	noHang(t, "Submit on a stuck transport", func() {
+		q := NewQuery()
+		q.SetTimeout(time.Minute)
+		require.Equal(t, context.DeadlineExceeded, eng.Submit(q))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.Nil(t, eng.Run(ctx))
+	cancel()
+	eng.BroadcastTimeout = time.Hour
+
+	noHang(t, "Submit on a stuck transport once stopped", func() {
+		require.NotNil(t, eng.broadcast(NewQuery()))
+	})
+}
+
+// legacy is a network implementing the previous Broadcast signature.
+type legacy struct {
+	recordingNetwork
+	stuck chan struct{} // blocks broadcasts if not nil
+}
+
+func (l *legacy) Broadcast(m proto.Message) error {
+	if l.stuck != nil {
+		<-l.stuck
+	}
+	return l.recordingNetwork.Broadcast(context.Background(), m)
+}
+
+type legacyRecovery struct {
+	legacy
+	RecoveryManager
+}
+
+func TestFromLegacyNetwork(t *testing.T) {
+	l := &legacy{}
+	n := FromLegacyNetwork(l)
+	require.Nil(t, n.Broadcast(context.Background(), NewQuery()))
+	require.Len(t, l.messages, 1)
+
+	l.stuck = make(chan struct{})
+	defer close(l.stuck)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	noHang(t, "Broadcast on a stuck legacy transport", func() {
+		require.Equal(t, context.DeadlineExceeded, n.Broadcast(ctx, NewQuery()))
+	})
+
+	_, ok := n.(RecoveryManager)
+	require.False(t, ok)
+	_, ok = FromLegacyNetwork(&legacyRecovery{}).(RecoveryManager)
+	require.True(t, ok, "optional managers should be kept")
+}
@@ -0,0 +1,138 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"crypto/sha512"
+	"errors"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/technicolor-research/pnyxdb/keyring"
+)
+
+// Certificate errors
+var (
+	ErrCertificateQuery         = errors.New("certificate has no query")
+	ErrInsufficientEndorsements = errors.New("not enough valid endorsements in certificate")
+)
+
+// Hash returns a fixed-size hash of the (unsigned) version of the certificate.
+// Passed by value because of internal modifications.
+func (c CommitCertificate) Hash() ([]byte, error) {
+	c.Signature = nil
+	b := proto.NewBuffer(nil)
+	b.SetDeterministic(true)
+	err := b.Marshal(&c)
+	hash := sha512.Sum512(b.Bytes())
+	return hash[:], err
+}
+
+// VerifyCertificate checks a commit certificate against a keyring, without
+// any access to the network: the signatures of the query, of the endorsements
+// (possibly aggregated) and of the attesting node must be valid, their
+// signers must be trusted by the keyring, and at least threshold distinct
+// nodes must have endorsed the query.
+//
+// The conditions of the endorsements are not checked, since proving that the
+// conflicting queries have been dropped would require the checkpoint
+// decisions of the network.
+func VerifyCertificate(c *CommitCertificate, kr *keyring.KeyRing, threshold int) error {
+	q := c.Query
+	if q == nil {
+		return ErrCertificateQuery
+	}
+
+	hash, err := q.Hash()
+	if err != nil {
+		return err
+	}
+
+	err = kr.Verify(q.Emitter, hash, q.Signature)
+	if err != nil {
+		return fmt.Errorf("query %s: %v", q.Uuid, err)
+	}
+
+	endorsers := make(map[string]bool)
+	for _, e := range c.Endorsements {
+		if e.Uuid != q.Uuid {
+			return fmt.Errorf("endorsement of %s: endorses query %s instead of %s", e.Emitter, e.Uuid, q.Uuid)
+		}
+
+		hash, err = e.Hash()
+		if err != nil {
+			return err
+		}
+
+		err = kr.Verify(e.Emitter, hash, e.Signature)
+		if err != nil {
+			return fmt.Errorf("endorsement of %s: %v", e.Emitter, err)
+		}
+		endorsers[e.Emitter] = true
+	}
+
+	for _, a := range c.Aggregates {
+		if a.Endorsements[0].Uuid != q.Uuid {
+			return fmt.Errorf("aggregated endorsement: endorses query %s instead of %s", a.Endorsements[0].Uuid, q.Uuid)
+		}
+
+		err = a.verify(kr, nil)
+		if err != nil {
+			return fmt.Errorf("aggregated endorsement: %v", err)
+		}
+
+		for _, e := range a.Endorsements {
+			endorsers[e.Emitter] = true
+		}
+	}
+
+	if threshold < 1 || len(endorsers) < threshold {
+		return ErrInsufficientEndorsements
+	}
+
+	hash, err = c.Hash()
+	if err != nil {
+		return err
+	}
+
+	err = kr.Verify(c.Attester, hash, c.Signature)
+	if err != nil {
+		return fmt.Errorf("attestation of %s: %v", c.Attester, err)
+	}
+	return nil
+}
+
+// certify returns the certificate of a committed query, attested by the
+// local node, from the endorsements gathered for its commit. The certificate
+// holds copies of the messages, which may still be in use by the engine.
+func (eng *Engine) certify(q *Query, endorsements []*Endorsement, aggregates []*AggregatedEndorsement) (*CommitCertificate, error) {
+	c := &CommitCertificate{
+		Query:     proto.Clone(q).(*Query),
+		Threshold: uint32(eng.quorum),
+		Attester:  eng.Identity(),
+		Time:      ptypes.TimestampNow(),
+	}
+
+	for _, e := range endorsements {
+		c.Endorsements = append(c.Endorsements, proto.Clone(e).(*Endorsement))
+	}
+
+	for _, a := range aggregates {
+		c.Aggregates = append(c.Aggregates, proto.Clone(a).(*AggregatedEndorsement))
+	}
+
+	hash, err := c.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	c.Signature, err = eng.sign(hash)
+	return c, err
+}
@@ -0,0 +1,85 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_CommitCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pnyxdb")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	const n, quorum = 3, 2
+	journal, err := OpenJournal(filepath.Join(dir, "events"), 0, 0)
+	require.Nil(t, err)
+	defer func() { _ = journal.Close() }()
+
+	engines, stop := startEngines(t, n, quorum, func(i int, eng *Engine) {
+		if i == 0 {
+			eng.Journal = journal
+		}
+	})
+	defer stop()
+
+	q := NewQuery()
+	q.SetTimeout(time.Second)
+	q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: []byte("a")}}
+	require.Nil(t, engines[1].Submit(q))
+
+	var cert *CommitCertificate
+	eventually(t, func() bool {
+		cert, _ = journal.Certificate(q.Uuid)
+		return cert != nil
+	}, "query should be committed")
+
+	require.Exactly(t, q.Uuid, cert.Query.Uuid)
+	require.Exactly(t, engines[0].Identity(), cert.Attester)
+	require.Exactly(t, uint32(quorum), cert.Threshold)
+
+	// Offline verification, with the keyring of another node
+	kr := engines[2].KeyRing
+	require.Nil(t, VerifyCertificate(cert, kr, quorum))
+
+	// The certificate survives a round trip to its serialized form
+	data, err := proto.Marshal(cert)
+	require.Nil(t, err)
+	decoded := &CommitCertificate{}
+	require.Nil(t, proto.Unmarshal(data, decoded))
+	require.Nil(t, VerifyCertificate(decoded, kr, quorum))
+
+	tampered := proto.Clone(cert).(*CommitCertificate)
+	tampered.Endorsements[0].Conditions = []string{"forged"}
+	require.NotNil(t, VerifyCertificate(tampered, kr, quorum), "should detect a tampered endorsement")
+
+	tampered = proto.Clone(cert).(*CommitCertificate)
+	tampered.Endorsements[1] = tampered.Endorsements[0]
+	require.Exactly(t, ErrInsufficientEndorsements, VerifyCertificate(tampered, kr, quorum), "should not count duplicated endorsements")
+
+	tampered = proto.Clone(cert).(*CommitCertificate)
+	tampered.Query.Operations[0].Data = []byte("b")
+	require.NotNil(t, VerifyCertificate(tampered, kr, quorum), "should detect a tampered query")
+
+	tampered = proto.Clone(cert).(*CommitCertificate)
+	tampered.Endorsements = tampered.Endorsements[:quorum-1]
+	require.NotNil(t, VerifyCertificate(tampered, kr, quorum), "should detect a tampered attestation")
+
+	require.Exactly(t, ErrInsufficientEndorsements, VerifyCertificate(cert, kr, n+1))
+
+	_, err = engines[0].Journal.Certificate("unknown")
+	require.Exactly(t, ErrNoCertificate, err)
+}
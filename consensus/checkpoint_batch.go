@@ -0,0 +1,154 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bluele/gcache"
+)
+
+// checkpointBatch sizes the batches of queries proposed by the checkpoints
+// started locally. A vetoed batch is wasted, since none of its queries is
+// dropped, while small batches waste BBC rounds when every query is garbage:
+// the size is halved after each vetoed batch, and increased by one after each
+// dropped batch, within bounds.
+type checkpointBatch struct {
+	sync.Mutex
+	size, min, max int
+	started        gcache.Cache // identifiers of the checkpoints started locally
+}
+
+func newCheckpointBatch() *checkpointBatch {
+	return &checkpointBatch{
+		size:    checkpointRoutineSelect,
+		min:     1,
+		max:     checkpointRoutineBatch,
+		started: gcache.New(1024).LRU().Build(),
+	}
+}
+
+// setBounds sets the minimum and maximum sizes of the batches, ignoring
+// values lower than 1.
+func (b *checkpointBatch) setBounds(min, max int) {
+	b.Lock()
+	defer b.Unlock()
+
+	if min >= 1 {
+		b.min = min
+	}
+	if max >= 1 {
+		b.max = max
+	}
+	if b.max < b.min {
+		b.max = b.min
+	}
+	b.size = clamp(b.size, b.min, b.max)
+}
+
+// Size returns the current size of the batches.
+func (b *checkpointBatch) Size() int {
+	b.Lock()
+	defer b.Unlock()
+	return b.size
+}
+
+// start registers a checkpoint started locally.
+func (b *checkpointBatch) start(id string) {
+	_ = b.started.SetWithExpire(id, true, time.Minute)
+}
+
+// decided adapts the size of the batches to the decision of a checkpoint,
+// if it has been started locally.
+func (b *checkpointBatch) decided(id string, dropped bool) {
+	if !b.started.Remove(id) {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	if dropped {
+		b.size = clamp(b.size+1, b.min, b.max)
+	} else {
+		b.size = clamp(b.size/2, b.min, b.max)
+	}
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// selectBatch returns at most size queries taken from groups, without
+// splitting a group unless it is larger than size by itself, and the
+// remaining queries.
+func selectBatch(groups [][]string, size int) (batch, remaining []string) {
+	for _, g := range groups {
+		switch {
+		case len(batch)+len(g) <= size:
+			batch = append(batch, g...)
+		case len(batch) == 0:
+			batch = append(batch, g[:size]...)
+			remaining = append(remaining, g[size:]...)
+		default:
+			remaining = append(remaining, g...)
+		}
+	}
+	return
+}
+
+// ConflictGroups partitions queries into groups of queries linked by the
+// conditions of their endorsements, so that they can be checkpointed
+// together: when one of them is applicable, the others are usually garbage.
+// Groups are sorted, and ordered by their first query.
+func (qs *queryStore) ConflictGroups(queries []string) [][]string {
+	parent := make(map[string]string, len(queries))
+	for _, uuid := range queries {
+		parent[uuid] = uuid
+	}
+
+	var find func(string) string
+	find = func(uuid string) string {
+		if parent[uuid] != uuid {
+			parent[uuid] = find(parent[uuid])
+		}
+		return parent[uuid]
+	}
+
+	qs.RLock()
+	for _, uuid := range queries {
+		for _, d := range qs.queries[uuid].Dependents {
+			if _, ok := parent[d]; ok {
+				parent[find(d)] = find(uuid)
+			}
+		}
+	}
+	qs.RUnlock()
+
+	byRoot := make(map[string][]string)
+	for _, uuid := range queries {
+		root := find(uuid)
+		byRoot[root] = append(byRoot[root], uuid)
+	}
+
+	groups := make([][]string, 0, len(byRoot))
+	for _, g := range byRoot {
+		sort.Strings(g)
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups
+}
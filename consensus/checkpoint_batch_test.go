@@ -0,0 +1,123 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// simulateBatches runs checkpoints on a workload where each query is
+// applicable with probability p, so that a batch is vetoed as soon as one of
+// its queries is applicable, and returns the average size of the last
+// batches.
+func simulateBatches(b *checkpointBatch, p float64, rounds int) float64 {
+	r := rand.New(rand.NewSource(1))
+	var total int
+	for i := 0; i < rounds; i++ {
+		size := b.Size()
+		dropped := true
+		for j := 0; j < size; j++ {
+			if r.Float64() < p {
+				dropped = false
+			}
+		}
+
+		id := fmt.Sprint(i)
+		b.start(id)
+		b.decided(id, dropped)
+		if i >= rounds/2 {
+			total += size
+		}
+	}
+	return float64(total) / float64(rounds-rounds/2)
+}
+
+func TestCheckpointBatch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		p        float64
+		min, max float64
+	}{
+		{"garbage", 0, 100, 100},
+		{"mostly garbage", 0.01, 8, 30},
+		{"mixed", 0.1, 1, 5},
+		{"mostly applicable", 0.5, 1, 2},
+		{"applicable", 1, 1, 1},
+	}
+
+	for _, tc := range testCases {
+		c := tc
+		t.Run(c.name, func(t *testing.T) {
+			avg := simulateBatches(newCheckpointBatch(), c.p, 2000)
+			require.True(t, avg >= c.min && avg <= c.max, "average batch size %f out of [%f, %f]", avg, c.min, c.max)
+		})
+	}
+
+	t.Run("bounds", func(t *testing.T) {
+		b := newCheckpointBatch()
+		b.setBounds(5, 20)
+		require.Equal(t, 20, b.Size())
+		require.Equal(t, float64(5), simulateBatches(b, 1, 100))
+		require.Equal(t, float64(20), simulateBatches(b, 0, 100))
+
+		b.setBounds(50, 10)
+		require.Equal(t, 50, b.Size(), "maximum should not be lower than minimum")
+	})
+
+	t.Run("remote checkpoints", func(t *testing.T) {
+		b := newCheckpointBatch()
+		b.decided("remote", false)
+		require.Equal(t, checkpointRoutineSelect, b.Size(), "should ignore checkpoints started by other nodes")
+
+		b.start("local")
+		b.decided("local", false)
+		b.decided("local", false)
+		require.Equal(t, checkpointRoutineSelect/2, b.Size(), "should count each decision once")
+	})
+}
+
+func TestSelectBatch(t *testing.T) {
+	groups := [][]string{{"a", "b"}, {"c", "d", "e"}, {"f"}}
+
+	batch, remaining := selectBatch(groups, 3)
+	require.Equal(t, []string{"a", "b", "f"}, batch, "groups should not be split")
+	require.Equal(t, []string{"c", "d", "e"}, remaining)
+
+	batch, remaining = selectBatch(groups[1:], 2)
+	require.Equal(t, []string{"c", "d"}, batch, "large groups should be split")
+	require.Equal(t, []string{"e", "f"}, remaining)
+
+	batch, remaining = selectBatch(groups, 10)
+	require.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, batch)
+	require.Empty(t, remaining)
+}
+
+func TestQueryStore_ConflictGroups(t *testing.T) {
+	qs := newQueryStore()
+	queries := make([]*Query, 5)
+	for i := range queries {
+		queries[i] = NewQuery()
+		queries[i].Uuid = fmt.Sprint(i)
+	}
+
+	// 0 <- 3 <- 1, and 2 <- 4 (endorsed with the other one as condition)
+	qs.AddEndorsement(&Endorsement{Emitter: "a", Uuid: "3", Conditions: []string{"0"}})
+	for _, q := range queries {
+		qs.AddQuery(q)
+	}
+	qs.AddEndorsement(&Endorsement{Emitter: "a", Uuid: "1", Conditions: []string{"3", "unknown"}})
+	qs.AddEndorsement(&Endorsement{Emitter: "a", Uuid: "4", Conditions: []string{"2"}})
+
+	require.Equal(t, [][]string{{"0", "1", "3"}, {"2", "4"}}, qs.ConflictGroups([]string{"4", "3", "2", "1", "0"}))
+	require.Equal(t, [][]string{{"0"}, {"1"}, {"4"}}, qs.ConflictGroups([]string{"4", "1", "0"}), "only given queries should link groups")
+}
@@ -0,0 +1,83 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"math"
+	"sync"
+)
+
+// epochTracker holds the checkpoint epoch of the node, which distinguishes
+// successive checkpoints of the same batch of queries.
+//
+// Nodes agree on the epoch without any exchange: a node moves to the next
+// epoch when it sees a StartCheckpoint of that epoch, or concludes a
+// checkpoint of its current epoch or a later one, and saves it in dumps.
+// Concurrent initiations of the same checkpoint usually share their epoch,
+// and thus their identifier, while a batch checkpointed again once a round
+// has concluded gets a new identifier. A node with a stale epoch still takes
+// part in the checkpoints started by the others, and catches up with their
+// epoch one step at a time; rounds of the same batch at different epochs are
+// merely redundant, since each one is an agreement of its own.
+//
+// The epoch never moves by more than one, since StartCheckpoint messages are
+// not signed: a hostile epoch could otherwise push every node to the end of
+// the range, and overflow it.
+type epochTracker struct {
+	sync.Mutex
+	epoch uint64
+}
+
+func (t *epochTracker) get() uint64 {
+	t.Lock()
+	defer t.Unlock()
+	return t.epoch
+}
+
+// observe adopts epoch if it is the next one, and returns true if so.
+func (t *epochTracker) observe(epoch uint64) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.epoch == math.MaxUint64 || epoch != t.epoch+1 {
+		return false
+	}
+	t.epoch = epoch
+	return true
+}
+
+// concluded moves to the next epoch once a checkpoint of the current epoch,
+// or of a later one, has concluded, and returns true if so.
+func (t *epochTracker) concluded(epoch uint64) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.epoch == math.MaxUint64 || epoch < t.epoch {
+		return false
+	}
+	t.epoch++
+	return true
+}
+
+// restore adopts the epoch saved in a dump, if it is higher than the current
+// one.
+func (t *epochTracker) restore(epoch uint64) {
+	t.Lock()
+	defer t.Unlock()
+
+	if epoch > t.epoch {
+		t.epoch = epoch
+	}
+}
+
+// CheckpointEpoch returns the epoch of the checkpoints started locally.
+// This function is thread-safe.
+func (eng *Engine) CheckpointEpoch() uint64 {
+	return eng.epoch.get()
+}
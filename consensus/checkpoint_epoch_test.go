@@ -0,0 +1,105 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+// roundsBBC vetoes every checkpoint, and reports the identifier of each
+// executed round.
+type roundsBBC chan string
+
+func (b roundsBBC) Execute(ctx context.Context, id string, choice bool, proofs []*Proof) (bool, []*Proof, error) {
+	b <- id
+	return false, nil, nil
+}
+
+func TestCheckpointID(t *testing.T) {
+	a := checkpointID(0, []string{"b", "a"})
+	require.Equal(t, a, checkpointID(0, []string{"a", "b"}), "identifiers should not depend on the order of the queries")
+	require.Equal(t, "2-fb8e20fc2e4c3f248c60c39bd652f3c1347298bb977b8b4d5903b85055620603", a, "epoch 0 should keep the identifiers predating epochs")
+	require.NotEqual(t, a, checkpointID(1, []string{"a", "b"}))
+	require.NotEqual(t, checkpointID(1, []string{"a", "b"}), checkpointID(2, []string{"a", "b"}))
+}
+
+func TestEngine_CheckpointEpoch(t *testing.T) {
+	rounds := make(roundsBBC, 10)
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, rounds, tests.GetTestKeyRings(t, 1)[0], 2)
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: []byte("1")}}
+	eng.handleQuery(q)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// checkpoint runs a checkpoint of q at epoch, and returns the identifier
+	// of the round once concluded, or an empty one if no round was executed.
+	checkpoint := func(epoch uint64, concluded uint64) string {
+		eng.handleCheckpoint(ctx, &StartCheckpoint{Epoch: epoch, Queries: []string{q.Uuid}})
+
+		var id string
+		select {
+		case id = <-rounds:
+		case <-time.After(100 * time.Millisecond):
+			return ""
+		}
+
+		eventually(t, func() bool { return eng.CheckpointEpoch() == concluded }, "epoch %d should be reached", concluded)
+		return id
+	}
+
+	require.Equal(t, uint64(0), eng.CheckpointEpoch())
+	first := checkpoint(0, 1)
+	require.NotEmpty(t, first)
+	require.Empty(t, checkpoint(0, 1), "duplicate initiations of a round should be ignored")
+
+	second := checkpoint(eng.CheckpointEpoch(), 2)
+	require.NotEmpty(t, second, "the same queries should be checkpointed again at the next epoch")
+	require.NotEqual(t, first, second)
+
+	// Rounds started by nodes with a stale epoch still take place
+	eng.checkpoints.Purge()
+	require.Equal(t, first, checkpoint(0, 2))
+
+	// The next epoch is adopted, but the epoch never moves by more than one
+	require.NotEmpty(t, checkpoint(3, 4))
+	require.NotEmpty(t, checkpoint(7, 5))
+	require.NotEmpty(t, checkpoint(math.MaxUint64, 6), "hostile epochs should not overflow")
+
+	buffer := &bytes.Buffer{}
+	require.Nil(t, eng.Dump(buffer))
+	restarted := NewEngine(newMemoryStore(), &recordingNetwork{}, rounds, tests.GetTestKeyRings(t, 1)[0], 2)
+	require.Nil(t, restarted.Load(buffer))
+	require.Equal(t, uint64(6), restarted.CheckpointEpoch(), "epoch should be saved in dumps")
+}
+
+func TestEpochTracker_Bounds(t *testing.T) {
+	var tracker epochTracker
+	require.False(t, tracker.observe(math.MaxUint64))
+	require.False(t, tracker.observe(2))
+	require.True(t, tracker.observe(1))
+	require.True(t, tracker.concluded(math.MaxUint64))
+	require.False(t, tracker.concluded(0), "stale checkpoints should not move the epoch")
+	require.Equal(t, uint64(2), tracker.get())
+
+	tracker.restore(math.MaxUint64)
+	require.False(t, tracker.observe(0), "the epoch should not wrap around")
+	require.False(t, tracker.concluded(math.MaxUint64))
+	require.Equal(t, uint64(math.MaxUint64), tracker.get())
+}
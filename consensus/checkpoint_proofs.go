@@ -0,0 +1,150 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"errors"
+)
+
+// Veto proof errors
+var (
+	ErrNoProof         = errors.New("veto carries no proof")
+	ErrIrrelevantProof = errors.New("veto proof does not concern a query of the checkpoint")
+	ErrInvalidProof    = errors.New("veto proof is malformed")
+	ErrScarceProof     = errors.New("veto proof holds the endorsements of less than a quorum of trusted emitters")
+	ErrMissingProof    = errors.New("veto proofs listed by the summary could not be fetched")
+)
+
+// vetoVerifier returns the verifier of the proofs of the vetoes received for
+// the checkpoint of queries. A veto is only honored if it proves that one of
+// these queries may be applied: its proofs must hold the query, signed by its
+// emitter, and endorsements of that query only, each correctly signed, from
+// at least a quorum of distinct trusted emitters; or a summary of such
+// proofs, see verifySummary.
+func (eng *Engine) vetoVerifier(ctx context.Context, queries []string) ProofVerifier {
+	checkpoint := make(map[string]bool, len(queries))
+	for _, uuid := range queries {
+		checkpoint[uuid] = true
+	}
+
+	return func(proofs []*Proof) error {
+		if len(proofs) == 0 {
+			return ErrNoProof
+		}
+
+		// The subject of the veto comes first, see queryStore.CheckpointChoice
+		var uuid string
+		switch {
+		case proofs[0].GetQuery() != nil:
+			q := proofs[0].GetQuery()
+			uuid = q.Uuid
+			if !checkpoint[uuid] {
+				return ErrIrrelevantProof
+			}
+			if err := eng.verifyQuery(q); err != nil {
+				return err
+			}
+		case proofs[0].GetSummary() != nil:
+			s := proofs[0].GetSummary()
+			if !checkpoint[s.Uuid] {
+				return ErrIrrelevantProof
+			}
+			if len(proofs) > 1 || len(s.Emitters) != len(s.Hashes) {
+				return ErrInvalidProof
+			}
+			return eng.verifySummary(ctx, s)
+		default:
+			return ErrInvalidProof
+		}
+
+		emitters := make(map[string]bool)
+		endorsed := func(emitter string) error {
+			if emitters[emitter] {
+				return ErrDuplicateEndorser
+			}
+			emitters[emitter] = true
+			return nil
+		}
+
+		for _, p := range proofs[1:] {
+			var err error
+			switch {
+			case p.GetEndorsement() != nil:
+				e := p.GetEndorsement()
+				if e.Uuid != uuid {
+					return ErrIrrelevantProof
+				}
+				if err = endorsed(e.Emitter); err == nil {
+					err = eng.verifyEndorsement(e)
+				}
+			case p.GetAggregate() != nil:
+				a := p.GetAggregate()
+				if len(a.Endorsements) > 0 && a.Endorsements[0].Uuid != uuid {
+					return ErrIrrelevantProof
+				}
+				for _, e := range a.Endorsements {
+					if err = endorsed(e.Emitter); err != nil {
+						break
+					}
+				}
+				if err == nil {
+					err = a.verify(eng.KeyRing, eng.hashes)
+				}
+			default:
+				err = ErrInvalidProof
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		// Like CheckpointChoice, which only vetoes applicable queries
+		return eng.checkQuorum(emitters)
+	}
+}
+
+// checkQuorum returns ErrScarceProof unless at least a quorum of the provided
+// emitters are trusted.
+func (eng *Engine) checkQuorum(emitters map[string]bool) error {
+	var trusted int
+	for emitter := range emitters {
+		if eng.KeyRing.Trusted(emitter) == nil {
+			trusted++
+		}
+	}
+	if trusted < eng.quorum {
+		return ErrScarceProof
+	}
+	return nil
+}
+
+// verifySummary checks the proofs listed by a summary: it must list the
+// endorsements of at least a quorum of distinct trusted emitters, and the
+// query and these endorsements must have been verified, once fetched from the
+// peers if they are unknown locally (see fetchProofs).
+func (eng *Engine) verifySummary(ctx context.Context, s *ProofSummary) error {
+	emitters := make(map[string]bool, len(s.Emitters))
+	for _, emitter := range s.Emitters {
+		emitters[emitter] = true
+	}
+	if err := eng.checkQuorum(emitters); err != nil {
+		return err
+	}
+
+	if !eng.missingProofs(s) {
+		return nil
+	}
+
+	eng.fetchProofs(ctx, s)
+	if eng.missingProofs(s) {
+		return ErrMissingProof
+	}
+	return nil
+}
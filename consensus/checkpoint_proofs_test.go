@@ -0,0 +1,101 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_VetoVerifier(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 3)
+	engines := make([]*Engine, len(keyrings))
+	for i, kr := range keyrings {
+		engines[i] = NewEngine(newMemoryStore(), &recordingNetwork{}, passBBC{}, kr, 2)
+	}
+
+	signed := func() *Query {
+		q := NewQuery()
+		q.SetTimeout(time.Minute)
+		q.Emitter = engines[1].Identity()
+		q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: []byte("a")}}
+		require.Nil(t, engines[1].signQuery(q))
+		return q
+	}
+	endorse := func(q *Query, i int) *Endorsement {
+		e := &Endorsement{Uuid: q.Uuid, Emitter: engines[i].Identity()}
+		require.Nil(t, engines[i].signEndorsement(e))
+		return e
+	}
+	query := func(q *Query) *Proof { return &Proof{Content: &Proof_Query{q}} }
+	endorsement := func(e *Endorsement) *Proof { return &Proof{Content: &Proof_Endorsement{e}} }
+
+	q, other := signed(), signed()
+	verify := engines[0].vetoVerifier(context.Background(), []string{q.Uuid, "unrelated"})
+
+	require.Nil(t, verify([]*Proof{query(q), endorsement(endorse(q, 1)), endorsement(endorse(q, 2))}))
+	require.Exactly(t, ErrScarceProof, verify([]*Proof{query(q)}), "should reject queries without endorsements")
+	require.Exactly(t, ErrScarceProof, verify([]*Proof{query(q), endorsement(endorse(q, 1))}), "should require a quorum of endorsements")
+	require.Exactly(t, ErrDuplicateEndorser, verify([]*Proof{query(q), endorsement(endorse(q, 1)), endorsement(endorse(q, 1))}), "should reject duplicate emitters")
+	summary := func(emitters ...int) []*Proof {
+		s := &ProofSummary{Uuid: q.Uuid}
+		for _, i := range emitters {
+			s.Emitters = append(s.Emitters, engines[i].Identity())
+			s.Hashes = append(s.Hashes, signatureHash(endorse(q, i).Signature))
+		}
+		return []*Proof{{Content: &Proof_Summary{s}}}
+	}
+	require.Exactly(t, ErrScarceProof, verify(summary()), "should reject summaries without endorsements")
+	require.Exactly(t, ErrScarceProof, verify(summary(1, 1)), "should count distinct emitters")
+	require.Exactly(t, ErrMissingProof, verify(summary(1, 2)), "should reject summaries whose proofs cannot be fetched")
+
+	// Summaries are honored once their query and endorsements are verified
+	engines[0].handleQuery(q)
+	engines[0].handleEndorsement(endorse(q, 1))
+	require.Exactly(t, ErrMissingProof, verify(summary(1, 2)))
+	engines[0].handleEndorsement(endorse(q, 2))
+	require.Nil(t, verify(summary(1, 2)))
+
+	require.Exactly(t, ErrNoProof, verify(nil))
+	require.Exactly(t, ErrIrrelevantProof, verify([]*Proof{query(other), endorsement(endorse(other, 1)), endorsement(endorse(other, 2))}), "should reject queries outside of the checkpoint")
+	require.Exactly(t, ErrIrrelevantProof, verify([]*Proof{query(q), endorsement(endorse(other, 2))}), "should reject endorsements of other queries")
+	require.Exactly(t, ErrIrrelevantProof, verify([]*Proof{{Content: &Proof_Summary{&ProofSummary{Uuid: other.Uuid}}}}))
+	require.Exactly(t, ErrInvalidProof, verify([]*Proof{endorsement(endorse(q, 2))}), "should require the query first")
+	require.Exactly(t, ErrInvalidProof, verify([]*Proof{query(q), endorsement(endorse(q, 1)), {}}))
+	require.Exactly(t, ErrInvalidProof, verify([]*Proof{{Content: &Proof_Summary{&ProofSummary{Uuid: q.Uuid, Emitters: []string{"x"}}}}}))
+
+	forged := endorse(q, 2)
+	forged.Emitter = engines[1].Identity()
+	require.NotNil(t, verify([]*Proof{query(q), endorsement(forged)}), "should check the signatures of endorsements")
+
+	unsigned := NewQuery()
+	unsigned.Emitter = engines[1].Identity()
+	require.NotNil(t, engines[0].vetoVerifier(context.Background(), []string{unsigned.Uuid})([]*Proof{query(unsigned)}), "should check the signature of the query")
+}
+
+func TestEngine_HandleDecisionProofs(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 2)
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, passBBC{}, keyrings[0], 2)
+	emitter := NewEngine(newMemoryStore(), &recordingNetwork{}, passBBC{}, keyrings[1], 2)
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Emitter = emitter.Identity()
+	q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: []byte("a")}}
+	require.Nil(t, emitter.signQuery(q))
+
+	// The proofs of a vote against the checkpoint are processed like a veto
+	vote := &Vote{Identifier: "checkpoint", Emitter: emitter.Identity(), Proofs: []*Proof{{Content: &Proof_Query{q}}}}
+	eng.handleDecisionProofs(context.Background(), "checkpoint", []*Proof{{Content: &Proof_Vote{vote}}})
+	require.NotNil(t, eng.qs.GetQuery(q.Uuid), "should learn the query of the vote")
+}
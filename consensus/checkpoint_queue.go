@@ -0,0 +1,137 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package consensus
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// checkpointQueue holds the queries waiting for a checkpoint, the one with the
+// oldest deadline first. A query is queued at most once, hence the queue
+// never holds more queries than the query store, and queuing never blocks.
+// The zero value is not ready to use, see newCheckpointQueue.
+type checkpointQueue struct {
+	sync.Mutex
+	entries checkpointHeap
+	queued  map[string]bool
+	ready   chan struct{} // signaled when a query is queued
+	urgent  bool          // the next batch must be started right away, see expedite
+}
+
+type checkpointEntry struct {
+	uuid     string
+	deadline time.Time
+}
+
+// checkpointHeap orders entries by deadline, then by UUID.
+type checkpointHeap []checkpointEntry
+
+func (h checkpointHeap) Len() int      { return len(h) }
+func (h checkpointHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h checkpointHeap) Less(i, j int) bool {
+	if !h[i].deadline.Equal(h[j].deadline) {
+		return h[i].deadline.Before(h[j].deadline)
+	}
+	return h[i].uuid < h[j].uuid
+}
+
+func (h *checkpointHeap) Push(x interface{}) { *h = append(*h, x.(checkpointEntry)) }
+func (h *checkpointHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+func newCheckpointQueue() *checkpointQueue {
+	return &checkpointQueue{
+		queued: make(map[string]bool),
+		ready:  make(chan struct{}, 1),
+	}
+}
+
+// push queues a query, unless it is queued already, and returns true if it
+// has been added.
+// This function is thread-safe.
+func (cq *checkpointQueue) push(uuid string, deadline time.Time) bool {
+	cq.Lock()
+	defer cq.Unlock()
+
+	if cq.queued[uuid] {
+		return false
+	}
+	cq.queued[uuid] = true
+	heap.Push(&cq.entries, checkpointEntry{uuid: uuid, deadline: deadline})
+	cq.signal()
+	return true
+}
+
+// signal wakes up the consumer of the queue, if it is not already awoken.
+// This function is thread-safe.
+func (cq *checkpointQueue) signal() {
+	select {
+	case cq.ready <- struct{}{}:
+	default:
+	}
+}
+
+// expedite requests the consumer of the queue to start the next batch right
+// away, instead of waiting for it to fill up.
+// This function is thread-safe.
+func (cq *checkpointQueue) expedite() {
+	cq.Lock()
+	cq.urgent = true
+	cq.Unlock()
+	cq.signal()
+}
+
+// expedited returns true, once, if expedite has been called since it last
+// returned true.
+// This function is thread-safe.
+func (cq *checkpointQueue) expedited() bool {
+	cq.Lock()
+	defer cq.Unlock()
+	urgent := cq.urgent
+	cq.urgent = false
+	return urgent
+}
+
+// pop removes at most n queries from the queue, oldest deadline first.
+// This function is thread-safe.
+func (cq *checkpointQueue) pop(n int) []string {
+	cq.Lock()
+	defer cq.Unlock()
+
+	var uuids []string
+	for ; n > 0 && cq.entries.Len() > 0; n-- {
+		e := heap.Pop(&cq.entries).(checkpointEntry)
+		delete(cq.queued, e.uuid)
+		uuids = append(uuids, e.uuid)
+	}
+	return uuids
+}
+
+// Len returns the number of queued queries.
+// This function is thread-safe.
+func (cq *checkpointQueue) Len() int {
+	cq.Lock()
+	defer cq.Unlock()
+	return cq.entries.Len()
+}
+
+// clear empties the queue.
+// This function is thread-safe.
+func (cq *checkpointQueue) clear() {
+	cq.Lock()
+	defer cq.Unlock()
+	cq.entries = nil
+	cq.urgent = false
+	cq.queued = make(map[string]bool)
+}
@@ -0,0 +1,142 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestCheckpointQueue(t *testing.T) {
+	cq := newCheckpointQueue()
+	now := time.Now()
+
+	require.True(t, cq.push("b", now))
+	require.True(t, cq.push("c", now.Add(time.Second)))
+	require.True(t, cq.push("a", now))
+	require.True(t, cq.push("unknown", time.Time{}))
+	require.False(t, cq.push("b", now.Add(-time.Hour)), "queries should be queued once")
+	require.Equal(t, 4, cq.Len())
+
+	select {
+	case <-cq.ready:
+	default:
+		t.Fatal("the consumer should be signaled")
+	}
+
+	require.Equal(t, []string{"unknown", "a"}, cq.pop(2))
+	require.True(t, cq.push("a", now), "popped queries can be queued again")
+	require.Equal(t, []string{"a", "b", "c"}, cq.pop(10))
+	require.Empty(t, cq.pop(10))
+
+	cq.push("d", now)
+	cq.clear()
+	require.Zero(t, cq.Len())
+	require.True(t, cq.push("d", now))
+}
+
+func TestEngine_CheckpointFlood(t *testing.T) {
+	const count = 10000
+
+	clock := tests.NewManualClock(time.Now())
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, passBBC{}, tests.GetTestKeyRings(t, 1)[0], 1)
+	eng.Clock = clock
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.Nil(t, eng.Run(ctx))
+
+	// Each endorsement is conditioned by an expired conflicting query,
+	// which calls for a checkpoint
+	endorsements := make([]*Endorsement, count)
+	for i := range endorsements {
+		expired := NewQuery()
+		expired.SetTimeout(-time.Hour)
+		expired.Operations = []*Operation{{Key: fmt.Sprint("k", i), Op: Operation_SET, Data: []byte(fmt.Sprint(i))}}
+		eng.qs.AddQuery(expired)
+
+		q := NewQuery()
+		q.SetTimeout(time.Minute)
+		q.Operations = []*Operation{{Key: fmt.Sprint("k", i), Op: Operation_SET, Data: []byte(fmt.Sprint(i))}}
+		eng.qs.AddQuery(q)
+
+		e := &Endorsement{Uuid: q.Uuid, Emitter: eng.Identity(), Conditions: []string{expired.Uuid}}
+		require.Nil(t, eng.signEndorsement(e))
+		endorsements[i] = e
+	}
+
+	// Checkpoints are started by batches, with a cooldown: since the clock is
+	// not advanced, at most one batch is consumed, and handleEndorsement
+	// would never return if it waited for checkpoints
+	for _, e := range endorsements {
+		eng.handleEndorsement(e)
+		eng.checkState(e.Uuid) // duplicates are queued once
+	}
+	require.True(t, eng.PendingCheckpoints() >= count-checkpointRoutineBatch)
+	require.True(t, eng.PendingCheckpoints() <= count)
+
+	eventually(t, func() bool { return eng.PendingCheckpoints() == count-checkpointRoutineBatch }, "queued checkpoints should be consumed by batches")
+
+	// The next batch is consumed once the cooldown has elapsed
+	clock.Advance(checkpointRoutineCooldown)
+	eventually(t, func() bool { return eng.PendingCheckpoints() != count-checkpointRoutineBatch }, "queued checkpoints should be consumed after the cooldown")
+	require.True(t, eng.PendingCheckpoints() >= count-2*checkpointRoutineBatch)
+}
+
+// timeoutBBC reaches no decision the first time a checkpoint is executed,
+// and drops its queries afterwards.
+type timeoutBBC struct {
+	calls    int32
+	executed chan string
+}
+
+func (b *timeoutBBC) Execute(ctx context.Context, id string, choice bool, proofs []*Proof) (bool, []*Proof, error) {
+	defer func() { b.executed <- id }()
+	if atomic.AddInt32(&b.calls, 1) == 1 {
+		return false, nil, ErrBBCTimeout
+	}
+	return true, nil, nil
+}
+
+func TestEngine_CheckpointTimeout(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 2)
+	bbc := &timeoutBBC{executed: make(chan string, 2)}
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, bbc, keyrings[0], 2)
+	emitter := NewEngine(newMemoryStore(), &recordingNetwork{}, passBBC{}, keyrings[1], 2)
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Emitter = emitter.Identity()
+	q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: []byte("a")}}
+	require.Nil(t, emitter.signQuery(q))
+	eng.handleQuery(q)
+	eng.pendingCheckpoints.clear()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sc := &StartCheckpoint{Queries: []string{q.Uuid}}
+	eng.handleCheckpoint(ctx, sc)
+	id := <-bbc.executed
+
+	// The pending query is queued again, and the checkpoint can be restarted
+	eventually(t, func() bool { return eng.PendingCheckpoints() > 0 }, "query should be queued again")
+	require.Equal(t, []string{q.Uuid}, eng.pendingCheckpoints.pop(1))
+
+	eng.handleCheckpoint(ctx, sc)
+	require.Equal(t, id, <-bbc.executed)
+	eventually(t, func() bool {
+		s, _ := eng.QueryStatus(q.Uuid)
+		return s.State == StateDropped
+	}, "query should be dropped")
+}
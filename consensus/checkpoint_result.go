@@ -0,0 +1,240 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"crypto/sha512"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+)
+
+const (
+	checkpointRetention       = 60 * time.Second // of the checkpoints not decided yet
+	checkpointResultRetention = 10 * time.Minute // of the decisions, sent back to late nodes
+	checkpointResendInterval  = time.Second      // between two CheckpointResult of the same checkpoint
+)
+
+// checkpointState is the state of a checkpoint in Engine.checkpoints.
+type checkpointState struct {
+	sync.Mutex
+	started  bool              // the BBC engine has been run locally
+	cancel   func()            // stops the BBC engine once decided by CheckpointResult messages
+	decided  bool              // by the BBC engine or by CheckpointResult messages
+	result   *CheckpointResult // signed local decision, sent back to late nodes
+	resent   time.Time
+	received map[string]bool // decisions of the CheckpointResult messages, by emitter
+}
+
+// start marks the checkpoint as started, and returns false if it had
+// already been started or decided.
+func (c *checkpointState) start(cancel func()) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.started || c.decided {
+		return false
+	}
+	c.started = true
+	c.cancel = cancel
+	return true
+}
+
+// decide records the local decision of the checkpoint, and returns false if
+// it had already been decided by CheckpointResult messages.
+func (c *checkpointState) decide(result *CheckpointResult) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.decided {
+		return false
+	}
+	c.decided = true
+	c.result = result
+	return true
+}
+
+// resend returns the local decision if it may be sent back at t.
+func (c *checkpointState) resend(t time.Time) *CheckpointResult {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.result == nil || t.Sub(c.resent) < checkpointResendInterval {
+		return nil
+	}
+	c.resent = t
+	return c.result
+}
+
+// receive records the decision of a CheckpointResult, and returns true if
+// quorum emitters agree on it for the first time. The BBC engine run
+// locally is stopped then.
+func (c *checkpointState) receive(emitter string, decision bool, quorum int) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.decided {
+		return false
+	}
+	if c.received == nil {
+		c.received = make(map[string]bool)
+	}
+	c.received[emitter] = decision
+
+	var n int
+	for _, d := range c.received {
+		if d == decision {
+			n++
+		}
+	}
+	if n < quorum {
+		return false
+	}
+
+	c.decided = true
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return true
+}
+
+func (c *checkpointState) isDecided() bool {
+	c.Lock()
+	defer c.Unlock()
+	return c.decided
+}
+
+// Hash returns a fixed-size hash of the (unsigned) version of the result.
+// Passed by value because of internal modifications.
+// Proofs are marshalled deterministically, since queries contain maps.
+func (r CheckpointResult) Hash() ([]byte, error) {
+	r.Signature = nil
+	b := proto.NewBuffer(nil)
+	b.SetDeterministic(true)
+	err := b.Marshal(&r)
+	hash := sha512.Sum512(b.Bytes())
+	return hash[:], err
+}
+
+// checkpoint returns the entry of a checkpoint, created if needed.
+// This function is thread-safe.
+func (eng *Engine) checkpoint(id string) *checkpointState {
+	eng.checkpointsMutex.Lock()
+	defer eng.checkpointsMutex.Unlock()
+
+	if c, err := eng.checkpoints.GetIFPresent(id); err == nil {
+		return c.(*checkpointState)
+	}
+
+	c := &checkpointState{}
+	_ = eng.checkpoints.SetWithExpire(id, c, checkpointRetention)
+	return c
+}
+
+// checkpointResultQuorum returns the number of matching CheckpointResult
+// messages needed to apply the decision of a checkpoint.
+func (eng *Engine) checkpointResultQuorum() int {
+	if eng.CheckpointQuorum > 0 {
+		return eng.CheckpointQuorum
+	}
+	return eng.quorum
+}
+
+// recordCheckpointResult keeps the local decision of a checkpoint, signed,
+// so that it can be sent back to the nodes starting it late. It returns
+// false if the checkpoint has already been decided by CheckpointResult
+// messages.
+func (eng *Engine) recordCheckpointResult(id string, c *checkpointState, sc *StartCheckpoint, decision bool, proofs []*Proof) bool {
+	r := &CheckpointResult{
+		Epoch:    sc.Epoch,
+		Queries:  sc.Queries,
+		Emitter:  eng.Identity(),
+		Decision: decision,
+		Proofs:   proofs,
+	}
+
+	err := eng.signCheckpointResult(r)
+	if err != nil {
+		zap.L().Warn("CheckpointResult",
+			zap.String("id", id),
+			zap.Error(err),
+		)
+		r = nil // still decided, but not sent back
+	}
+
+	if !c.decide(r) {
+		return false
+	}
+
+	eng.checkpointsMutex.Lock()
+	_ = eng.checkpoints.SetWithExpire(id, c, checkpointResultRetention)
+	eng.checkpointsMutex.Unlock()
+	return true
+}
+
+// resendCheckpointResult sends back the local decision of a checkpoint
+// which has been started again, by a node which missed it.
+func (eng *Engine) resendCheckpointResult(id string, c *checkpointState) {
+	r := c.resend(eng.now())
+	if r == nil {
+		return
+	}
+
+	zap.L().Debug("Checkpoint",
+		zap.String("id", id),
+		zap.String("state", "resend"),
+		zap.Bool("decision", r.Decision),
+	)
+	go func() { _ = eng.broadcast(r) }()
+}
+
+// handleCheckpointResult applies the decision of a checkpoint once
+// CheckpointQuorum nodes have sent it back, unless it has been
+// decided locally.
+func (eng *Engine) handleCheckpointResult(ctx context.Context, r *CheckpointResult) {
+	if len(r.Queries) == 0 || r.Emitter == eng.Identity() {
+		return
+	}
+
+	err := eng.verifyCheckpointResult(r)
+	if err != nil {
+		return
+	}
+
+	id := checkpointID(r.Epoch, r.Queries)
+	c := eng.checkpoint(id)
+	if c.isDecided() {
+		return
+	}
+
+	if !r.Decision { // the proofs are verified on their own
+		eng.handleDecisionProofs(ctx, id, r.Proofs)
+	}
+
+	if !c.receive(r.Emitter, r.Decision, eng.checkpointResultQuorum()) {
+		return
+	}
+
+	zap.L().Debug("Checkpoint",
+		zap.String("id", id),
+		zap.String("state", "result"),
+		zap.Bool("decision", r.Decision),
+	)
+
+	eng.batch.decided(id, r.Decision)
+	eng.epoch.concluded(r.Epoch) // persisted along with the event
+	eng.emit(EngineEvent{Type: EventCheckpointDecided, Checkpoint: id, Queries: r.Queries, Decision: r.Decision})
+	if r.Decision {
+		eng.qs.CheckpointDrop(r.Queries)
+		eng.flushDecisions()
+	}
+}
@@ -0,0 +1,102 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+type countingBBC struct {
+	calls int32
+}
+
+func (b *countingBBC) Execute(ctx context.Context, id string, choice bool, proofs []*Proof) (bool, []*Proof, error) {
+	atomic.AddInt32(&b.calls, 1)
+	return choice, proofs, nil
+}
+
+// blockingBBC never decides, as if the other nodes had already concluded.
+type blockingBBC struct{}
+
+func (blockingBBC) Execute(ctx context.Context, id string, choice bool, proofs []*Proof) (bool, []*Proof, error) {
+	<-ctx.Done()
+	return false, nil, ctx.Err()
+}
+
+func TestEngine_CheckpointResult(t *testing.T) {
+	const n, quorum = 3, 3
+	keyrings := tests.GetTestKeyRings(t, n)
+	h := &hub{}
+	bbc := &countingBBC{}
+	engines := make([]*Engine, n)
+	for i := range engines {
+		engines[i] = NewEngine(newMemoryStore(), h.join(), bbc, keyrings[i], quorum)
+		engines[i].CheckpointQuorum = 2
+	}
+	late := engines[2]
+	late.BBCEngine = blockingBBC{}
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Emitter = engines[0].Identity()
+	q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: []byte("a")}}
+	require.Nil(t, engines[0].signQuery(q))
+
+	waitDropped := func(eng *Engine) {
+		eventually(t, func() bool {
+			s, _ := eng.QueryStatus(q.Uuid)
+			return s.State == StateDropped
+		}, "query should be dropped by "+eng.Identity())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, eng := range engines[:2] {
+		require.Nil(t, eng.Run(ctx))
+	}
+	h.waitSubscribers(t, 4*(n-1)) // queries, endorsements, checkpoints and node statuses
+	for _, eng := range engines[:2] {
+		eng.handleQuery(proto.Clone(q).(*Query))
+	}
+
+	// The query cannot be committed without the late node, and is dropped
+	require.Nil(t, engines[0].broadcast(&StartCheckpoint{Queries: []string{q.Uuid}}))
+	waitDropped(engines[0])
+	waitDropped(engines[1])
+	require.Exactly(t, int32(2), atomic.LoadInt32(&bbc.calls))
+
+	// The late node starts the same checkpoint, and applies the decision
+	// sent back by the others
+	require.Nil(t, late.Run(ctx))
+	h.waitSubscribers(t, 4*n)
+	late.handleQuery(proto.Clone(q).(*Query))
+	s, _ := late.QueryStatus(q.Uuid)
+	require.Exactly(t, StatePending, s.State)
+
+	r := &CheckpointResult{Queries: []string{q.Uuid}, Emitter: engines[1].Identity(), Decision: true}
+	require.Nil(t, engines[1].signCheckpointResult(r))
+	forged := proto.Clone(r).(*CheckpointResult)
+	forged.Emitter = engines[0].Identity()
+	late.handleCheckpointResult(ctx, forged)
+	late.handleCheckpointResult(ctx, r)
+	late.handleCheckpointResult(ctx, r)
+	s, _ = late.QueryStatus(q.Uuid)
+	require.Exactly(t, StatePending, s.State, "a single valid result should not be enough")
+
+	require.Nil(t, late.broadcast(&StartCheckpoint{Queries: []string{q.Uuid}}))
+	waitDropped(late)
+	require.Exactly(t, int32(2), atomic.LoadInt32(&bbc.calls), "the checkpoint should not be run again")
+}
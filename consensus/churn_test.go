@@ -0,0 +1,317 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/keyring"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+const churnStopDelay = 50 * time.Millisecond // lets the handlers of a stopped engine return
+const churnQueryTimeout = 2 * time.Second
+
+// churnSeedEnv replays the random choices of a churn test, given the seed
+// it logged.
+const churnSeedEnv = "PNYXDB_CHURN_SEED"
+
+func churnSeed(t *testing.T) int64 {
+	value := os.Getenv(churnSeedEnv)
+	if value == "" {
+		return time.Now().UnixNano()
+	}
+
+	seed, err := strconv.ParseInt(value, 10, 64)
+	require.Nil(t, err, churnSeedEnv)
+	return seed
+}
+
+// historyStore is a memoryStore recording the versions applied to each key,
+// so that the commits of several nodes can be compared. Local keys are not
+// recorded, since every node writes them on its own.
+type historyStore struct {
+	*memoryStore
+	history map[string][]string
+}
+
+func newHistoryStore() *historyStore {
+	return &historyStore{
+		memoryStore: newMemoryStore(),
+		history:     make(map[string][]string),
+	}
+}
+
+func (s *historyStore) Set(key string, value []byte, version *Version) error {
+	if !IsLocalKey(key) {
+		s.history[key] = append(s.history[key], string(version.Hash))
+	}
+	return s.memoryStore.Set(key, value, version)
+}
+
+func (s *historyStore) SetBatch(keys []string, values [][]byte, versions []*Version) error {
+	for i, k := range keys {
+		_ = s.Set(k, values[i], versions[i])
+	}
+	return nil
+}
+
+// churnNode is a node of a cluster, along with the state it keeps across
+// restarts.
+type churnNode struct {
+	store  *historyStore
+	dump   []byte
+	engine *Engine // nil while stopped
+	cancel context.CancelFunc
+}
+
+// cluster runs engines over a syncHub, and controls their lifecycle to
+// simulate membership churn. Random choices are driven by its seed, which is
+// logged so that failures can be replayed. It is used by tests.
+type cluster struct {
+	t        *testing.T
+	h        *syncHub
+	rng      *rand.Rand
+	keyrings []*keyring.KeyRing
+	quorum   int
+	nodes    []*churnNode
+	step     int
+}
+
+func newCluster(t *testing.T, n, quorum int, seed int64) *cluster {
+	t.Logf("Churn seed %d, replay with %s=%d", seed, churnSeedEnv, seed)
+	c := &cluster{
+		t:        t,
+		h:        &syncHub{},
+		rng:      rand.New(rand.NewSource(seed)),
+		keyrings: tests.GetTestKeyRings(t, n),
+		quorum:   quorum,
+		nodes:    make([]*churnNode, n),
+	}
+
+	for i := range c.nodes {
+		c.nodes[i] = &churnNode{store: newHistoryStore()}
+		c.StartNode(i)
+	}
+	c.settle()
+	return c
+}
+
+// StartNode restarts a stopped node with its persisted store and dump.
+func (c *cluster) StartNode(i int) {
+	node := c.nodes[i]
+	require.Nil(c.t, node.engine, "node %d is already running", i)
+
+	eng := NewEngine(node.store, c.h.join(fmt.Sprint(i)), passBBC{}, c.keyrings[i], c.quorum)
+	if node.dump != nil {
+		require.Nil(c.t, eng.Load(bytes.NewReader(node.dump)))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.Nil(c.t, eng.Run(ctx))
+	node.engine, node.cancel = eng, cancel
+}
+
+// StopNode disconnects a node and halts its engine, keeping its store and a
+// dump of its state for StartNode.
+func (c *cluster) StopNode(i int) {
+	node := c.nodes[i]
+	require.NotNil(c.t, node.engine, "node %d is already stopped", i)
+
+	node.cancel()
+	time.Sleep(churnStopDelay)
+
+	var buf bytes.Buffer
+	require.Nil(c.t, node.engine.Dump(&buf))
+	node.dump = buf.Bytes()
+	node.engine = nil
+}
+
+// close stops every running node.
+func (c *cluster) close() {
+	for _, node := range c.nodes {
+		if node.engine != nil {
+			node.cancel()
+		}
+	}
+}
+
+// up returns the running nodes.
+func (c *cluster) up() []int {
+	var up []int
+	for i, node := range c.nodes {
+		if node.engine != nil {
+			up = append(up, i)
+		}
+	}
+	return up
+}
+
+// settle waits until every running node listens to the hub.
+func (c *cluster) settle() {
+	c.h.waitSubscribers(c.t, 4*len(c.up())) // queries, endorsements, checkpoints and node statuses
+}
+
+// submit submits a query setting a unique value to key, from a random
+// running node.
+func (c *cluster) submit(key string) *Query {
+	c.step++
+	up := c.up()
+	require.NotEmpty(c.t, up)
+
+	q := NewQuery()
+	q.SetTimeout(churnQueryTimeout)
+	q.Operations = []*Operation{{Key: key, Op: Operation_SET, Data: []byte(fmt.Sprint("v", c.step))}}
+	require.Nil(c.t, c.nodes[up[c.rng.Intn(len(up))]].engine.Submit(q))
+	return q
+}
+
+func (c *cluster) committed(i int, q *Query) bool {
+	store := c.nodes[i].store
+	store.Lock()
+	defer store.Unlock()
+
+	value, _, _ := store.Get(q.Operations[0].Key)
+	return bytes.Equal(value, q.Operations[0].Data)
+}
+
+// requireLiveness submits a query, and checks that every running node
+// commits it if at least quorum nodes are running, or that none does
+// otherwise.
+func (c *cluster) requireLiveness() {
+	q := c.submit(fmt.Sprint("live", c.step))
+	up := c.up()
+
+	if len(up) < c.quorum {
+		for !q.Expired() {
+			time.Sleep(10 * time.Millisecond)
+		}
+		for _, i := range up {
+			require.False(c.t, c.committed(i, q), "query committed by node %d without quorum", i)
+		}
+		return
+	}
+
+	for _, i := range up {
+		for !c.committed(i, q) {
+			require.False(c.t, q.Expired(), "query not committed by node %d with %d/%d nodes up", i, len(up), len(c.nodes))
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+// submitConflicting submits two conflicting queries from random running
+// nodes, without waiting for their outcome.
+func (c *cluster) submitConflicting() {
+	key := fmt.Sprint("conflict", c.step)
+	c.submit(key)
+	c.submit(key)
+}
+
+// requireSafety checks that no two nodes committed conflicting queries: for
+// every key, nodes must apply versions in the same order. Nodes may miss
+// commits while they are stopped, or be late.
+func (c *cluster) requireSafety() {
+	type order struct{ key, before, after string }
+	seen := make(map[order]int)
+
+	for i, node := range c.nodes {
+		node.store.Lock()
+		for key, h := range node.store.history {
+			for x := range h {
+				for _, after := range h[x+1:] {
+					seen[order{key, h[x], after}] = i
+				}
+			}
+		}
+		node.store.Unlock()
+	}
+
+	for o, i := range seen {
+		j, ok := seen[order{o.key, o.after, o.before}]
+		require.False(c.t, ok, "nodes %d and %d committed conflicting queries on %s", i, j, o.key)
+	}
+}
+
+// churnEvent stops or starts a node.
+type churnEvent struct {
+	node int
+	up   bool
+}
+
+// rollingRestarts returns a schedule restarting every node once, one at a
+// time, in a random order.
+func rollingRestarts(rng *rand.Rand, n int) []churnEvent {
+	var schedule []churnEvent
+	for _, i := range rng.Perm(n) {
+		schedule = append(schedule, churnEvent{i, false}, churnEvent{i, true})
+	}
+	return schedule
+}
+
+// nodeLoss returns a schedule stopping f random nodes for good.
+func nodeLoss(rng *rand.Rand, n, f int) []churnEvent {
+	var schedule []churnEvent
+	for _, i := range rng.Perm(n)[:f] {
+		schedule = append(schedule, churnEvent{i, false})
+	}
+	return schedule
+}
+
+// run applies a schedule, checking liveness and submitting conflicting
+// queries after each event.
+func (c *cluster) run(schedule []churnEvent) {
+	for _, e := range schedule {
+		if e.up {
+			c.StartNode(e.node)
+		} else {
+			c.StopNode(e.node)
+		}
+		c.settle()
+
+		c.requireLiveness()
+		c.submitConflicting()
+	}
+}
+
+func TestEngine_ChurnRollingRestarts(t *testing.T) {
+	const n, f = 7, 2
+	c := newCluster(t, n, n-f, churnSeed(t))
+	defer c.close()
+
+	c.run(rollingRestarts(c.rng, n))
+	c.requireLiveness()
+	c.requireSafety()
+}
+
+func TestEngine_ChurnNodeLoss(t *testing.T) {
+	const n, f = 7, 2
+	c := newCluster(t, n, n-f, churnSeed(t))
+	defer c.close()
+
+	c.run(nodeLoss(c.rng, n, f))
+	for i := 0; i < 3; i++ {
+		c.requireLiveness()
+		c.submitConflicting()
+	}
+
+	// One more loss breaks the quorum
+	up := c.up()
+	c.StopNode(up[c.rng.Intn(len(up))])
+	c.requireLiveness()
+	c.requireSafety()
+}
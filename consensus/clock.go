@@ -0,0 +1,40 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import "time"
+
+// Clock is the source of local time of an engine. Tests replace it to control
+// time, see tests.ManualClock.
+type Clock interface {
+	Now() time.Time
+	// After waits for d to elapse, like time.After.
+	After(d time.Duration) <-chan time.Time
+	// NewTimer returns a channel receiving the time once d has elapsed, and
+	// a function stopping the timer, like the Stop method of time.Timer.
+	NewTimer(d time.Duration) (c <-chan time.Time, stop func() bool)
+}
+
+// realClock is the clock of the operating system.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	t := time.NewTimer(d)
+	return t.C, t.Stop
+}
+
+// clock returns the clock of the engine, the real clock unless Clock is set.
+func (eng *Engine) clock() Clock {
+	if eng.Clock == nil {
+		return realClock{}
+	}
+	return eng.Clock
+}
@@ -0,0 +1,57 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultMaxQueryTimeout bounds the deadlines of queries, unless
+// Engine.MaxQueryTimeout is set.
+const DefaultMaxQueryTimeout = 10 * time.Minute
+
+// Errors returned for queries whose deadline is out of bounds, see
+// Engine.CheckDeadline.
+var (
+	ErrDeadlineTooFar = errors.New("query deadline is too far in the future")
+	ErrDeadlineTooOld = errors.New("query deadline has passed for too long")
+)
+
+// QueryTimeoutLimit returns the maximum distance between the deadline of a
+// query and the current time, negative if deadlines are not bounded.
+func (eng *Engine) QueryTimeoutLimit() time.Duration {
+	if eng.MaxQueryTimeout == 0 {
+		return DefaultMaxQueryTimeout
+	}
+	return eng.MaxQueryTimeout
+}
+
+// CheckDeadline returns an error if the deadline of q is more than
+// MaxQueryTimeout away from the current time, in the future or in the past.
+// Queries without deadline are too old. Such queries are rejected by the
+// engine: they would otherwise block their conflicting queries until their
+// deadline, or only be received once every node has forgotten them.
+// This function is thread-safe.
+func (eng *Engine) CheckDeadline(q *Query) error {
+	max := eng.QueryTimeoutLimit()
+	if max < 0 {
+		return nil
+	}
+
+	now := eng.now()
+	deadline := q.DeadlineTime()
+	switch {
+	case q.Deadline == nil || deadline.Before(now.Add(-max)):
+		return ErrDeadlineTooOld
+	case deadline.After(now.Add(max)):
+		return ErrDeadlineTooFar
+	}
+	return nil
+}
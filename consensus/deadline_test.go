@@ -0,0 +1,49 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_CheckDeadline(t *testing.T) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, nil, kr, 1)
+
+	query := func(timeout time.Duration) *Query {
+		q := NewQuery()
+		q.SetTimeout(timeout)
+		q.Emitter = kr.Identity()
+		require.Nil(t, eng.signQuery(q))
+		return q
+	}
+
+	require.Nil(t, eng.CheckDeadline(query(time.Minute)))
+	require.Nil(t, eng.CheckDeadline(query(-time.Minute)), "recently expired queries are still accepted")
+	require.Exactly(t, ErrDeadlineTooFar, eng.CheckDeadline(query(365*24*time.Hour)))
+	require.Exactly(t, ErrDeadlineTooOld, eng.CheckDeadline(query(-time.Hour)))
+	require.Exactly(t, ErrDeadlineTooOld, eng.CheckDeadline(NewQuery()), "queries without deadline are rejected")
+	require.Exactly(t, ErrDeadlineTooFar, eng.Submit(query(time.Hour)))
+
+	// Rejected queries are not stored, and do not block conflicting queries
+	far := query(time.Hour)
+	eng.handleQuery(far)
+	_, err := eng.QueryStatus(far.Uuid)
+	require.Exactly(t, ErrUnknownQuery, err)
+
+	eng.MaxQueryTimeout = 2 * time.Hour
+	require.Nil(t, eng.CheckDeadline(query(time.Hour)))
+	eng.MaxQueryTimeout = -1
+	require.Nil(t, eng.CheckDeadline(query(365*24*time.Hour)))
+	require.Nil(t, eng.CheckDeadline(NewQuery()))
+}
@@ -0,0 +1,172 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"go.uber.org/zap"
+)
+
+// DecidedPrefix is the prefix of the keys recording the queries committed or
+// dropped by the node. These keys are local to the node.
+//
+// Since the state of the query store is lost on restart unless a dump is
+// loaded, a restarted node would otherwise handle a decided query replayed
+// by a peer as a new one: it could endorse a query it has already dropped,
+// or execute the operations of a committed query again once its applied
+// marker has been pruned.
+const DecidedPrefix = "_decided/"
+
+// Like applied markers, decisions are spread over a fixed number of keys, and
+// pruned when their key is written, decidedHorizon after the deadline of their
+// query or MaxQueryTimeout if longer, since older queries are rejected anyway.
+// The decisions of queries unknown to the node are kept as long after they
+// have been taken.
+const (
+	decidedSlots   = 256
+	decidedHorizon = 1 * time.Hour
+)
+
+func decidedKey(uuid string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(uuid))
+	return fmt.Sprintf("%s%02x", DecidedPrefix, h.Sum32()%decidedSlots)
+}
+
+// unsafe
+func (eng *Engine) loadDecided(key string) (*DecidedQueries, error) {
+	decided := &DecidedQueries{}
+	data, version, err := eng.Store.Get(key)
+	if version == NoVersion {
+		return decided, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decided, proto.Unmarshal(data, decided)
+}
+
+// decidedLimit returns the time before which decisions are pruned, for the
+// deadline of their query.
+func (eng *Engine) decidedLimit() time.Time {
+	horizon := decidedHorizon
+	if limit := eng.QueryTimeoutLimit(); limit > horizon {
+		horizon = limit
+	}
+	return eng.now().Add(-horizon)
+}
+
+// persistDecisions writes the decisions taken by the query store since the
+// last call.
+// This function locks the store.
+func (eng *Engine) persistDecisions() error {
+	decisions := eng.qs.Decisions()
+	eng.countDecisions(decisions)
+	eng.emitDecisions(decisions)
+	if len(decisions) == 0 {
+		return nil
+	}
+
+	bySlot := make(map[string][]decision)
+	for _, d := range decisions {
+		key := decidedKey(d.uuid)
+		bySlot[key] = append(bySlot[key], d)
+	}
+
+	limit := eng.decidedLimit()
+	eng.Store.Lock()
+	defer eng.Store.Unlock()
+
+	for key, decisions := range bySlot {
+		decided, err := eng.loadDecided(key)
+		if err != nil {
+			return err
+		}
+
+		known := make(map[string]bool)
+		kept := decided.Decisions[:0]
+		for _, d := range decided.Decisions {
+			deadline, err := ptypes.Timestamp(d.Deadline)
+			if err == nil && !deadline.Before(limit) {
+				kept = append(kept, d)
+				known[d.Uuid] = true
+			}
+		}
+		decided.Decisions = kept
+
+		for _, d := range decisions {
+			if known[d.uuid] {
+				continue
+			}
+			known[d.uuid] = true
+
+			deadline := d.deadline
+			if deadline.IsZero() {
+				deadline = d.resolved
+			}
+			ts, err := ptypes.TimestampProto(deadline)
+			if err != nil {
+				return err
+			}
+			decided.Decisions = append(decided.Decisions, &DecidedQueries_Decision{
+				Uuid:      d.uuid,
+				Deadline:  ts,
+				Committed: d.committed,
+			})
+		}
+
+		data, err := proto.Marshal(decided)
+		if err != nil {
+			return err
+		}
+		err = eng.Store.Set(key, data, NewVersion(data))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decided returns true if the node has committed or dropped the query,
+// possibly before a restart, and persisted its decision. Unreadable decisions
+// are logged and ignored.
+// This function locks the store.
+func (eng *Engine) decided(uuid string) bool {
+	eng.Store.Lock()
+	defer eng.Store.Unlock()
+
+	decided, err := eng.loadDecided(decidedKey(uuid))
+	if err != nil {
+		zap.L().Warn("DecidedQueries",
+			zap.String("uuid", uuid),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	for _, d := range decided.Decisions {
+		if d.Uuid == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+// flushDecisions persists the decisions, logging failures, which are not
+// retried.
+func (eng *Engine) flushDecisions() {
+	if err := eng.persistDecisions(); err != nil {
+		zap.L().Warn("DecidedQueries", zap.Error(err))
+	}
+}
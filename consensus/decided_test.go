@@ -0,0 +1,95 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_DecidedReplay(t *testing.T) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	store := newMemoryStore()
+
+	run := func() (*Engine, context.CancelFunc) {
+		h := &hub{}
+		eng := NewEngine(store, h.join(), passBBC{}, kr, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		require.Nil(t, eng.Run(ctx))
+		h.waitSubscribers(t, 4) // queries, endorsements, checkpoints and node statuses
+		return eng, cancel
+	}
+	get := func() string {
+		store.Lock()
+		defer store.Unlock()
+		value, _, err := store.Get("k")
+		require.Nil(t, err)
+		return string(value)
+	}
+
+	eng, cancel := run()
+	q := concat("k", "a", nil)
+	q.SetTimeout(time.Minute)
+	s, err := eng.SubmitAndWait(context.Background(), q)
+	require.Nil(t, err)
+	require.True(t, s.Applied)
+	cancel()
+
+	// The node restarts without dump, and its applied marker has been pruned
+	store.Lock()
+	require.Nil(t, store.Set(appliedMarkerKey(q.Uuid), nil, NewVersion(nil)))
+	store.Unlock()
+
+	eng, cancel = run()
+	defer cancel()
+	require.True(t, eng.decided(q.Uuid))
+
+	// The query is replayed by a peer
+	eng.handleQuery(q)
+	_, err = eng.QueryStatus(q.Uuid)
+	require.Equal(t, ErrUnknownQuery, err)
+	require.Equal(t, "a", get())
+
+	// Other queries are still processed
+	s, err = eng.SubmitAndWait(context.Background(), concat("k", "b", nil))
+	require.Nil(t, err)
+	require.True(t, s.Applied)
+	require.Equal(t, "ab", get())
+}
+
+func TestEngine_DecidedPruning(t *testing.T) {
+	clock := tests.NewManualClock(time.Now())
+	eng := &Engine{Store: newMemoryStore(), qs: newQueryStore(), Clock: clock}
+
+	q := concat("k", "a", nil)
+	eng.qs.AddQuery(q)
+	require.True(t, eng.qs.DropPending(q.Uuid))
+	require.Nil(t, eng.persistDecisions())
+	require.True(t, eng.decided(q.Uuid))
+	require.True(t, IsLocalKey(decidedKey(q.Uuid)))
+
+	// Decisions are pruned when their key is written again, once their query
+	// would be rejected as too old
+	clock.Advance(DefaultMaxQueryTimeout + decidedHorizon + time.Minute)
+	other := concat("k", "b", nil)
+	for decidedKey(other.Uuid) != decidedKey(q.Uuid) {
+		other = concat("k", "b", nil)
+	}
+	other.Deadline, _ = ptypes.TimestampProto(clock.Now().Add(time.Second))
+	eng.qs.AddQuery(other)
+	require.True(t, eng.qs.DropPending(other.Uuid))
+	require.Nil(t, eng.persistDecisions())
+	require.False(t, eng.decided(q.Uuid))
+	require.True(t, eng.decided(other.Uuid))
+}
@@ -0,0 +1,114 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// DistrustPolicy defines how the engine handles the pending queries and
+// endorsements of an identity that is not trusted anymore by its keyring
+// (removed, revoked or not signed by enough peers).
+type DistrustPolicy byte
+
+// Available distrust policies.
+const (
+	// DistrustStrict drops the pending queries of the identity, and discards
+	// its endorsements of the other pending queries.
+	DistrustStrict DistrustPolicy = iota
+	// DistrustGrandfather keeps the queries and endorsements received before
+	// the change, only the new ones are rejected.
+	DistrustGrandfather
+)
+
+// ParseDistrustPolicy returns the policy matching its name ("strict" or
+// "grandfather").
+func ParseDistrustPolicy(policy string) (DistrustPolicy, error) {
+	switch policy {
+	case "strict":
+		return DistrustStrict, nil
+	case "grandfather":
+		return DistrustGrandfather, nil
+	default:
+		return 0, fmt.Errorf("unknown distrust policy: %s", policy)
+	}
+}
+
+// String returns the name of the policy.
+func (p DistrustPolicy) String() string {
+	switch p {
+	case DistrustStrict:
+		return "strict"
+	case DistrustGrandfather:
+		return "grandfather"
+	default:
+		return fmt.Sprintf("DistrustPolicy(%d)", p)
+	}
+}
+
+// watchKeyRing re-validates the pending queries each time the keyring is
+// modified, and handles the quarantined queries whose emitter has been
+// added, until ctx is done.
+func (eng *Engine) watchKeyRing(ctx context.Context) {
+	changes, release := eng.KeyRing.Watch()
+
+	go func() {
+		defer release()
+		for {
+			select {
+			case <-changes:
+				eng.revalidate()
+				eng.releaseQuarantine()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// revalidate applies the distrust policy to the emitters of the pending
+// queries and endorsements that are not trusted anymore.
+func (eng *Engine) revalidate() {
+	if eng.DistrustPolicy != DistrustStrict {
+		return
+	}
+
+	distrusted := make(map[string]bool)
+	for emitter := range eng.qs.Emitters() {
+		if eng.KeyRing.Trusted(emitter) != nil {
+			distrusted[emitter] = true
+		}
+	}
+
+	if len(distrusted) == 0 {
+		return
+	}
+
+	dropped, discarded := eng.qs.Distrust(distrusted)
+	identities := make([]string, 0, len(distrusted))
+	for identity := range distrusted {
+		identities = append(identities, identity)
+	}
+
+	zap.L().Warn("Distrusted",
+		zap.Strings("identities", identities),
+		zap.Strings("dropped", dropped),
+		zap.Int("discardedEndorsements", discarded),
+	)
+
+	// Discarded endorsements may unblock conflicting queries
+	eng.flushDecisions()
+	eng.markActive()
+	for _, uuid := range eng.qs.PendingQueries() {
+		eng.checkState(uuid)
+	}
+}
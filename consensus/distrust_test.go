@@ -0,0 +1,104 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestParseDistrustPolicy(t *testing.T) {
+	for _, p := range []DistrustPolicy{DistrustStrict, DistrustGrandfather} {
+		p2, err := ParseDistrustPolicy(p.String())
+		require.Nil(t, err)
+		require.Exactly(t, p, p2)
+	}
+
+	_, err := ParseDistrustPolicy("unknown")
+	require.NotNil(t, err)
+}
+
+func TestEngine_Distrust(t *testing.T) {
+	const quorum = 3
+	keyrings := tests.GetTestKeyRings(t, 4)
+	signers := make([]*Engine, len(keyrings))
+	for i, kr := range keyrings {
+		signers[i] = NewEngine(nil, nil, nil, kr, quorum)
+	}
+
+	query := func(emitter int, key string) *Query {
+		q := NewQuery()
+		q.SetTimeout(time.Minute)
+		q.Emitter = keyrings[emitter].Identity()
+		q.Operations = []*Operation{{Key: key, Op: Operation_SET, Data: []byte(key)}}
+		require.Nil(t, signers[emitter].signQuery(q))
+		return q
+	}
+
+	endorsement := func(emitter int, q *Query) *Endorsement {
+		e := &Endorsement{Uuid: q.Uuid, Emitter: keyrings[emitter].Identity()}
+		require.Nil(t, signers[emitter].signEndorsement(e))
+		return e
+	}
+
+	stored := func(eng *Engine, key string) bool {
+		_, v, _ := eng.Store.Get(key)
+		return v != nil && v != NoVersion
+	}
+
+	state := func(eng *Engine, uuid string) queryState {
+		eng.qs.RLock()
+		defer eng.qs.RUnlock()
+		return eng.qs.queries[uuid].State
+	}
+
+	// Node 3 is removed from the keyrings of nodes 0 and 1 while one of its
+	// queries (qa) is pending, and after having endorsed another one (qb).
+	for self, policy := range []DistrustPolicy{DistrustStrict, DistrustGrandfather} {
+		other := 1 - self
+		network := &recordingNetwork{}
+		eng := NewEngine(newMemoryStore(), network, passBBC{}, keyrings[self], quorum)
+		eng.DistrustPolicy = policy
+
+		ctx, cancel := context.WithCancel(context.Background())
+		require.Nil(t, eng.Run(ctx))
+
+		qa, qb := query(3, "a"), query(2, "b")
+		eng.handleQuery(qa)
+		eng.handleQuery(qb)
+		network.Lock()
+		for _, m := range network.messages { // own endorsements
+			if e, ok := m.(*Endorsement); ok {
+				eng.handleEndorsement(e)
+			}
+		}
+		network.Unlock()
+		eng.handleEndorsement(endorsement(2, qa))
+		eng.handleEndorsement(endorsement(3, qb))
+		require.False(t, stored(eng, "a") || stored(eng, "b"), "queries should not be committed before quorum")
+
+		keyrings[self].RemovePublic("3")
+		if policy == DistrustStrict {
+			eventually(t, func() bool { return state(eng, qa.Uuid) == qDropped }, "query of the removed emitter should be dropped")
+		}
+
+		eng.handleEndorsement(endorsement(other, qa))
+		eng.handleEndorsement(endorsement(other, qb))
+
+		grandfathered := policy == DistrustGrandfather
+		require.Exactly(t, grandfathered, stored(eng, "a"), "%s: commit of the removed emitter's query", policy)
+		require.Exactly(t, grandfathered, stored(eng, "b"), "%s: commit using the removed emitter's endorsement", policy)
+
+		cancel()
+	}
+}
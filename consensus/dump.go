@@ -0,0 +1,200 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"io"
+	"sort"
+	"time"
+)
+
+// DumpFile is the content of a dump written by Engine.Dump, which can be
+// inspected and modified offline, without an Engine.
+type DumpFile struct {
+	qs    *queryStore
+	state dumpState
+}
+
+// ReadDump reads a dump written by Engine.Dump.
+func ReadDump(r io.Reader) (*DumpFile, error) {
+	d := &DumpFile{qs: newQueryStore()}
+
+	var err error
+	d.state, err = decodeDump(r, d.qs)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Write writes the dump, to be loaded by Engine.Load. Dumps made before
+// usage counters or modification times were introduced are written with
+// empty ones, which are rebuilt or tracked again by the engine alike.
+func (d *DumpFile) Write(w io.Writer) error {
+	return encodeDump(w, d.qs, func() dumpState { return d.state })
+}
+
+// DumpSummary summarizes the content of a dump.
+type DumpSummary struct {
+	States              map[QueryState]int // number of queries by state
+	PendingEndorsements int                // endorsements received before their query
+	OldestDeadline      time.Time          // zero if no query has been received
+	NewestDeadline      time.Time
+}
+
+// Summary returns a summary of the dump.
+func (d *DumpFile) Summary() DumpSummary {
+	d.qs.RLock()
+	defer d.qs.RUnlock()
+
+	s := DumpSummary{
+		States:              make(map[QueryState]int),
+		PendingEndorsements: len(d.qs.pendingEndorsements),
+	}
+
+	for _, qi := range d.qs.queries {
+		s.States[qi.state()]++
+
+		if qi.Query == nil || qi.Deadline == nil {
+			continue
+		}
+
+		deadline := qi.DeadlineTime()
+		if s.OldestDeadline.IsZero() || deadline.Before(s.OldestDeadline) {
+			s.OldestDeadline = deadline
+		}
+		if deadline.After(s.NewestDeadline) {
+			s.NewestDeadline = deadline
+		}
+	}
+
+	return s
+}
+
+// DumpedQuery describes a query held by a dump.
+type DumpedQuery struct {
+	*Query     // nil if committed through a checkpoint without being received
+	Status     QueryStatus
+	Endorsers  []string // sorted
+	Dependents []string // queries endorsed on condition of this one, sorted
+}
+
+// UUIDs returns the identifiers of the queries held by the dump, sorted.
+func (d *DumpFile) UUIDs() []string {
+	d.qs.RLock()
+	defer d.qs.RUnlock()
+
+	uuids := make([]string, 0, len(d.qs.queries))
+	for uuid := range d.qs.queries {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+	return uuids
+}
+
+// Query returns the details of a query held by the dump.
+func (d *DumpFile) Query(uuid string) (DumpedQuery, bool) {
+	s, ok := d.qs.Status(uuid)
+	if !ok {
+		return DumpedQuery{}, false
+	}
+
+	d.qs.RLock()
+	defer d.qs.RUnlock()
+
+	qi := d.qs.queries[uuid]
+	dq := DumpedQuery{
+		Query:      qi.Query,
+		Status:     s,
+		Dependents: append([]string(nil), qi.Dependents...),
+	}
+	for _, e := range qi.Endorsements {
+		dq.Endorsers = append(dq.Endorsers, e.Emitter)
+	}
+	sort.Strings(dq.Endorsers)
+	sort.Strings(dq.Dependents)
+	return dq, true
+}
+
+// Strip removes queries from the dump, along with their endorsements and the
+// references other queries hold to them, and returns how many of them have
+// been removed. Queries endorsed on condition of a removed query may never
+// become applicable.
+func (d *DumpFile) Strip(uuids ...string) (n int) {
+	d.qs.Lock()
+	defer d.qs.Unlock()
+
+	removed := make(map[string]bool, len(uuids))
+	for _, uuid := range uuids {
+		if _, ok := d.qs.queries[uuid]; ok {
+			delete(d.qs.queries, uuid)
+			n++
+		}
+		removed[uuid] = true
+	}
+
+	for uuid, qi := range d.qs.queries {
+		dependents := withoutUUIDs(qi.Dependents, removed)
+		if len(dependents) != len(qi.Dependents) {
+			qi.Dependents = dependents
+			d.qs.queries[uuid] = qi
+		}
+	}
+
+	for uuid, dependents := range d.qs.pendingDependencies {
+		dependents = withoutUUIDs(dependents, removed)
+		if removed[uuid] || len(dependents) == 0 {
+			delete(d.qs.pendingDependencies, uuid)
+		} else {
+			d.qs.pendingDependencies[uuid] = dependents
+		}
+	}
+
+	pendingEndorsements := d.qs.pendingEndorsements[:0]
+	for _, pe := range d.qs.pendingEndorsements {
+		if !removed[pe.Uuid] {
+			pendingEndorsements = append(pendingEndorsements, pe)
+		}
+	}
+	d.qs.pendingEndorsements = pendingEndorsements
+
+	return n
+}
+
+// StripResolved removes the dropped queries, and the queries whose deadline
+// is reached at t, like Strip. Committed queries that have not been applied
+// yet are kept, since their values would otherwise be lost. It returns the
+// identifiers of the removed queries, sorted.
+func (d *DumpFile) StripResolved(t time.Time) []string {
+	d.qs.RLock()
+	var uuids []string
+	for uuid, qi := range d.qs.queries {
+		if qi.State == qCommitted && !qi.settled() {
+			continue
+		}
+		if qi.State == qDropped || qi.Query != nil && qi.ExpiredAt(t) {
+			uuids = append(uuids, uuid)
+		}
+	}
+	d.qs.RUnlock()
+
+	sort.Strings(uuids)
+	d.Strip(uuids...)
+	return uuids
+}
+
+func withoutUUIDs(uuids []string, removed map[string]bool) []string {
+	res := uuids[:0]
+	for _, uuid := range uuids {
+		if !removed[uuid] {
+			res = append(res, uuid)
+		}
+	}
+	return res
+}
@@ -0,0 +1,108 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpFile(t *testing.T) {
+	qs := newQueryStore()
+	qs.threshold = 2
+	e := &Engine{qs: qs}
+
+	queries := make([]*Query, 5)
+	for i := range queries {
+		queries[i] = NewQuery()
+		queries[i].SetTimeout(time.Minute)
+		qs.AddQuery(queries[i])
+	}
+	pending, expired, dropped, committed, unapplied := queries[0], queries[1], queries[2], queries[3], queries[4]
+	expired.SetTimeout(-time.Minute)
+	unapplied.SetTimeout(-time.Minute)
+
+	qs.AddEndorsement(&Endorsement{Emitter: "b", Uuid: pending.Uuid})
+	qs.AddEndorsement(&Endorsement{Emitter: "a", Uuid: pending.Uuid, Conditions: []string{dropped.Uuid}})
+	qs.AddEndorsement(&Endorsement{Emitter: "a", Uuid: "ghost"})
+	qs.DropPending(dropped.Uuid)
+	qs.Lock()
+	qs.commit(committed.Uuid)
+	qs.commit(unapplied.Uuid)
+	qs.Unlock()
+	qs.SetApplyResult(committed.Uuid, nil)
+
+	buffer := &bytes.Buffer{}
+	require.Nil(t, e.Dump(buffer))
+
+	d, err := ReadDump(buffer)
+	require.Nil(t, err)
+
+	s := d.Summary()
+	require.Equal(t, map[QueryState]int{StatePending: 2, StateCommitted: 2, StateDropped: 1}, s.States)
+	require.Equal(t, 1, s.PendingEndorsements)
+	require.Equal(t, expired.DeadlineTime(), s.OldestDeadline)
+	require.False(t, s.NewestDeadline.Before(pending.DeadlineTime()))
+
+	uuids := make([]string, len(queries))
+	for i, q := range queries {
+		uuids[i] = q.Uuid
+	}
+	sort.Strings(uuids)
+	require.Equal(t, uuids, d.UUIDs())
+
+	q, ok := d.Query(pending.Uuid)
+	require.True(t, ok)
+	require.Equal(t, pending.Uuid, q.Uuid)
+	require.Equal(t, StatePending, q.Status.State)
+	require.Equal(t, []string{"a", "b"}, q.Endorsers)
+
+	q, ok = d.Query(dropped.Uuid)
+	require.True(t, ok)
+	require.Equal(t, StateDropped, q.Status.State)
+	require.Equal(t, []string{pending.Uuid}, q.Dependents)
+
+	_, ok = d.Query("ghost")
+	require.False(t, ok)
+
+	removed := d.StripResolved(time.Now())
+	expected := []string{expired.Uuid, dropped.Uuid}
+	sort.Strings(expected)
+	require.Equal(t, expected, removed, "committed queries not applied yet should be kept")
+	require.Equal(t, 0, d.Strip("ghost"), "only pending endorsements refer to this query")
+
+	s = d.Summary()
+	require.Equal(t, map[QueryState]int{StatePending: 1, StateCommitted: 2}, s.States)
+	require.Equal(t, 0, s.PendingEndorsements)
+
+	buffer.Reset()
+	require.Nil(t, d.Write(buffer))
+
+	e2 := &Engine{qs: newQueryStore()}
+	require.Nil(t, e2.Load(buffer))
+
+	for _, q := range []*Query{expired, dropped} {
+		_, ok := e2.qs.Status(q.Uuid)
+		require.False(t, ok)
+	}
+
+	st, ok := e2.qs.Status(pending.Uuid)
+	require.True(t, ok)
+	require.Equal(t, StatePending, st.State)
+	require.Equal(t, 2, st.Endorsements)
+
+	st, ok = e2.qs.Status(unapplied.Uuid)
+	require.True(t, ok)
+	require.Equal(t, StateCommitted, st.State)
+	require.False(t, st.Applied)
+}
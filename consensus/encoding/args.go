@@ -0,0 +1,57 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package encoding
+
+import "errors"
+
+// ErrInvalidArgs is returned when the arguments of an operation cannot be
+// decoded.
+var ErrInvalidArgs = errors.New("invalid operation arguments")
+
+// EncodeArgs returns the binary representation of the arguments of an
+// operation taking several of them. Each argument is prefixed by its length,
+// as the elements of a set, but arguments may be empty.
+func EncodeArgs(args ...[]byte) []byte {
+	size := 0
+	for _, a := range args {
+		size += 8 + len(a)
+	}
+
+	data := make([]byte, 0, size)
+	for _, a := range args {
+		data = append(data, uint64ToBytes(uint64(len(a)))...)
+		data = append(data, a...)
+	}
+	return data
+}
+
+// DecodeArgs parses the binary representation of exactly n arguments, as
+// returned by EncodeArgs.
+func DecodeArgs(data []byte, n int) ([][]byte, error) {
+	args := make([][]byte, 0, n)
+	l := uint64(len(data))
+	for i := uint64(0); i < l; {
+		if i+8 > l || len(args) == n {
+			return nil, ErrInvalidArgs
+		}
+
+		length := bytesToUint64(data[i : i+8])
+		if length > l-i-8 {
+			return nil, ErrInvalidArgs
+		}
+
+		args = append(args, data[i+8:i+8+length])
+		i += 8 + length
+	}
+
+	if len(args) != n {
+		return nil, ErrInvalidArgs
+	}
+	return args, nil
+}
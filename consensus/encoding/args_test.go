@@ -0,0 +1,35 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package encoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArgs(t *testing.T) {
+	data := EncodeArgs([]byte("pattern"), nil, []byte{0x00})
+	args, err := DecodeArgs(data, 3)
+	require.Nil(t, err)
+	require.Equal(t, [][]byte{[]byte("pattern"), {}, {0x00}}, args)
+
+	_, err = DecodeArgs(data, 2)
+	require.Equal(t, ErrInvalidArgs, err, "should reject extra arguments")
+	_, err = DecodeArgs(data, 4)
+	require.Equal(t, ErrInvalidArgs, err, "should reject missing arguments")
+	_, err = DecodeArgs(data[:len(data)-1], 3)
+	require.Equal(t, ErrInvalidArgs, err, "should reject truncated data")
+	_, err = DecodeArgs(data[:3], 1)
+	require.Equal(t, ErrInvalidArgs, err, "should reject truncated length")
+
+	args, err = DecodeArgs(nil, 0)
+	require.Nil(t, err)
+	require.Empty(t, args)
+}
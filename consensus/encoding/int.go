@@ -0,0 +1,56 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package encoding
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrNotInteger is returned when parsing a value that is not a decimal integer.
+var ErrNotInteger = errors.New("non-integer value")
+
+// Int holds an arbitrary-precision integer, internally backed by Go's big.Int.
+// Contrary to Float, arithmetic is exact, and cannot overflow.
+type Int struct {
+	*big.Int
+}
+
+// NewInt returns a new integer with 0 value.
+func NewInt() *Int {
+	return &Int{Int: big.NewInt(0)}
+}
+
+// MarshalBinary returns the decimal representation of an integer.
+func (i *Int) MarshalBinary() (data []byte, err error) {
+	return i.MarshalText()
+}
+
+// UnmarshalBinary parses the decimal representation of an integer.
+// Floats, even without a fractional part, are rejected.
+func (i *Int) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		i.Int = big.NewInt(0)
+		return nil
+	}
+
+	bi, ok := new(big.Int).SetString(string(data), 10)
+	if !ok {
+		return ErrNotInteger
+	}
+
+	i.Int = bi
+	return nil
+}
+
+// Add returns a new Int from the addition of i and j.
+func (i *Int) Add(j *Int) *Int {
+	bi := new(big.Int).Add(i.Int, j.Int)
+	return &Int{Int: bi}
+}
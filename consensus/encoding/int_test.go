@@ -0,0 +1,37 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package encoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInt(t *testing.T) {
+	i := NewInt()
+	require.Nil(t, i.UnmarshalBinary(nil))
+	require.Equal(t, int64(0), i.Int64())
+
+	for _, data := range []string{"42", "-42", "+42", "123456789012345678901234567890"} {
+		require.Nil(t, i.UnmarshalBinary([]byte(data)), data)
+	}
+
+	for _, data := range []string{"1.5", "1e3", "0x10", "1_000", " 1", "hello"} {
+		require.Equal(t, ErrNotInteger, i.UnmarshalBinary([]byte(data)), data)
+	}
+
+	a, b := NewInt(), NewInt()
+	require.Nil(t, a.UnmarshalBinary([]byte("9223372036854775807")))
+	require.Nil(t, b.UnmarshalBinary([]byte("1")))
+	sum, err := a.Add(b).MarshalBinary()
+	require.Nil(t, err)
+	require.Equal(t, "9223372036854775808", string(sum), "integers should not overflow")
+	require.Equal(t, "9223372036854775807", a.String(), "operands should not be modified")
+}
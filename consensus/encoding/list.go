@@ -0,0 +1,194 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package encoding
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+)
+
+// Error constants for Lists
+var (
+	ErrNotList = errors.New("not a list")
+)
+
+// listMarker starts every list. Like tombstones, lists cannot be mistaken for
+// sets or floats.
+var listMarker = []byte("\x00\x00\x00\x00\x00\x00\x00\x00pnyxdb:list")
+
+// ListElement is an element of a list, along with the key ordering it among
+// the elements pushed at the same end.
+type ListElement struct {
+	Key   []byte
+	Value []byte
+}
+
+// List holds an ordered list. Its elements pushed at the head come first,
+// sorted by decreasing keys, followed by the elements pushed at the tail,
+// sorted by increasing keys: the order of the elements does not depend on the
+// order of the pushes, as long as their keys are distinct.
+//
+// It is absolutely NOT thread-safe.
+type List struct {
+	Elements []ListElement
+	Head     int // number of elements pushed at the head
+}
+
+// NewList returns a new empty List.
+func NewList() *List {
+	return &List{}
+}
+
+// IsList returns whether data is the representation of a non-empty list.
+func IsList(data []byte) bool {
+	return len(data) >= len(listMarker)+8 && bytes.HasPrefix(data, listMarker)
+}
+
+// Len returns the number of elements of the list.
+func (l *List) Len() int {
+	return len(l.Elements)
+}
+
+// PushHead inserts an element among the ones pushed at the head, before the
+// ones with a lower or equal key.
+func (l *List) PushHead(key, value []byte) {
+	head := l.Elements[:l.Head]
+	i := sort.Search(len(head), func(i int) bool {
+		return bytes.Compare(head[i].Key, key) <= 0
+	})
+	l.insert(i, key, value)
+	l.Head++
+}
+
+// PushTail inserts an element among the ones pushed at the tail, after the
+// ones with a lower or equal key.
+func (l *List) PushTail(key, value []byte) {
+	tail := l.Elements[l.Head:]
+	i := sort.Search(len(tail), func(i int) bool {
+		return bytes.Compare(tail[i].Key, key) > 0
+	})
+	l.insert(l.Head+i, key, value)
+}
+
+func (l *List) insert(i int, key, value []byte) {
+	l.Elements = append(l.Elements, ListElement{})
+	copy(l.Elements[i+1:], l.Elements[i:])
+	l.Elements[i] = ListElement{Key: key, Value: value}
+}
+
+// PopHead removes the first element of the list, if any.
+func (l *List) PopHead() (value []byte, ok bool) {
+	if len(l.Elements) == 0 {
+		return nil, false
+	}
+
+	value = l.Elements[0].Value
+	l.Elements = l.Elements[1:]
+	if l.Head > 0 {
+		l.Head--
+	}
+	return value, true
+}
+
+// Range returns the elements from start to stop, both included. Negative
+// indexes count from the end of the list, -1 being the last element.
+func (l *List) Range(start, stop int) [][]byte {
+	n := len(l.Elements)
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+
+	var values [][]byte
+	for i := start; i <= stop; i++ {
+		values = append(values, l.Elements[i].Value)
+	}
+	return values
+}
+
+// MarshalBinary returns the binary representation of a list, which is empty
+// for an empty list.
+func (l *List) MarshalBinary() (data []byte, err error) {
+	if len(l.Elements) == 0 {
+		return nil, nil
+	}
+
+	data = append(data, listMarker...)
+	data = append(data, uint64ToBytes(uint64(l.Head))...)
+	for _, e := range l.Elements {
+		data = append(data, uint64ToBytes(uint64(len(e.Key)))...)
+		data = append(data, e.Key...)
+		data = append(data, uint64ToBytes(uint64(len(e.Value)))...)
+		data = append(data, e.Value...)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary parses the binary representation of a list.
+// Empty data is an empty list.
+func (l *List) UnmarshalBinary(data []byte) error {
+	l.Elements, l.Head = nil, 0
+	if len(data) == 0 {
+		return nil
+	}
+
+	if !IsList(data) {
+		return ErrNotList
+	}
+
+	data = data[len(listMarker):]
+	head := bytesToUint64(data[:8])
+	data = data[8:]
+
+	var elements []ListElement
+	for len(data) > 0 {
+		key, rest, ok := readChunk(data)
+		if !ok {
+			return ErrNotList
+		}
+		value, rest, ok := readChunk(rest)
+		if !ok {
+			return ErrNotList
+		}
+
+		elements = append(elements, ListElement{Key: key, Value: value})
+		data = rest
+	}
+
+	if head > uint64(len(elements)) {
+		return ErrNotList
+	}
+
+	l.Elements, l.Head = elements, int(head)
+	return nil
+}
+
+// readChunk reads a length-prefixed chunk of data.
+func readChunk(data []byte) (chunk, rest []byte, ok bool) {
+	if len(data) < 8 {
+		return nil, nil, false
+	}
+
+	length := bytesToUint64(data[:8])
+	if length > uint64(len(data)-8) {
+		return nil, nil, false
+	}
+
+	end := 8 + int(length)
+	return append([]byte(nil), data[8:end]...), data[end:], true
+}
@@ -0,0 +1,133 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package encoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func values(l *List) []string {
+	var res []string
+	for _, e := range l.Elements {
+		res = append(res, string(e.Value))
+	}
+	return res
+}
+
+func TestList_Push(t *testing.T) {
+	l := NewList()
+	l.PushTail([]byte("2"), []byte("c"))
+	l.PushTail([]byte("1"), []byte("b"))
+	l.PushHead([]byte("1"), []byte("a"))
+	l.PushHead([]byte("2"), []byte("z"))
+	l.PushTail([]byte("3"), []byte("d"))
+
+	require.Equal(t, []string{"z", "a", "b", "c", "d"}, values(l))
+	require.Equal(t, 2, l.Head)
+	require.Equal(t, 5, l.Len())
+
+	// Equal keys keep the push order
+	l.PushTail([]byte("3"), []byte("e"))
+	l.PushHead([]byte("2"), []byte("y"))
+	require.Equal(t, []string{"y", "z", "a", "b", "c", "d", "e"}, values(l))
+}
+
+func TestList_Push_OrderIndependent(t *testing.T) {
+	keys := []string{"c", "a", "d", "b"}
+
+	a, b := NewList(), NewList()
+	for i := range keys {
+		a.PushTail([]byte(keys[i]), []byte(keys[i]))
+		a.PushHead([]byte(keys[i]), []byte(keys[i]+"'"))
+
+		j := len(keys) - i - 1
+		b.PushHead([]byte(keys[j]), []byte(keys[j]+"'"))
+		b.PushTail([]byte(keys[j]), []byte(keys[j]))
+	}
+
+	da, _ := a.MarshalBinary()
+	db, _ := b.MarshalBinary()
+	require.Equal(t, da, db)
+	require.Equal(t, []string{"d'", "c'", "b'", "a'", "a", "b", "c", "d"}, values(a))
+}
+
+func TestList_PopHead(t *testing.T) {
+	l := NewList()
+	_, ok := l.PopHead()
+	require.False(t, ok)
+
+	l.PushHead([]byte("1"), []byte("a"))
+	l.PushTail([]byte("1"), []byte("b"))
+
+	v, ok := l.PopHead()
+	require.True(t, ok)
+	require.Equal(t, []byte("a"), v)
+	require.Equal(t, 0, l.Head)
+
+	v, ok = l.PopHead()
+	require.True(t, ok)
+	require.Equal(t, []byte("b"), v)
+	require.Equal(t, 0, l.Len())
+}
+
+func TestList_Range(t *testing.T) {
+	l := NewList()
+	for _, v := range []string{"a", "b", "c", "d"} {
+		l.PushTail([]byte(v), []byte(v))
+	}
+
+	cases := []struct {
+		start, stop int
+		expected    []string
+	}{
+		{0, -1, []string{"a", "b", "c", "d"}},
+		{0, 0, []string{"a"}},
+		{1, 2, []string{"b", "c"}},
+		{-2, -1, []string{"c", "d"}},
+		{-10, 10, []string{"a", "b", "c", "d"}},
+		{2, 1, nil},
+		{5, 10, nil},
+	}
+
+	for _, c := range cases {
+		var res []string
+		for _, v := range l.Range(c.start, c.stop) {
+			res = append(res, string(v))
+		}
+		require.Equal(t, c.expected, res, "%d %d", c.start, c.stop)
+	}
+}
+
+func TestList_Binary(t *testing.T) {
+	l := NewList()
+	data, err := l.MarshalBinary()
+	require.Nil(t, err)
+	require.Empty(t, data)
+
+	l.PushHead([]byte("k1"), []byte{})
+	l.PushTail([]byte("k2"), []byte{0x00, 0x01})
+	data, err = l.MarshalBinary()
+	require.Nil(t, err)
+	require.True(t, IsList(data))
+
+	m := NewList()
+	require.Nil(t, m.UnmarshalBinary(data))
+	require.Equal(t, l.Head, m.Head)
+	require.Equal(t, 2, m.Len())
+	require.Equal(t, []byte("k2"), m.Elements[1].Key)
+	require.Equal(t, []byte{0x00, 0x01}, m.Elements[1].Value)
+
+	require.Nil(t, m.UnmarshalBinary(nil))
+	require.Equal(t, 0, m.Len())
+
+	require.Equal(t, ErrNotList, m.UnmarshalBinary([]byte("hello")))
+	require.Equal(t, ErrNotList, m.UnmarshalBinary(data[:len(data)-1]))
+}
@@ -0,0 +1,110 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package encoding
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+)
+
+// Error constants for Maps
+var (
+	ErrNotMap = errors.New("not a map")
+)
+
+// mapMarker starts every map, like listMarker for lists.
+var mapMarker = []byte("\x00\x00\x00\x00\x00\x00\x00\x00pnyxdb:map")
+
+// Map holds a map of fields to values. Fields are serialized in sorted
+// order, so that the representation of a map does not depend on the order in
+// which its fields have been set.
+//
+// It is absolutely NOT thread-safe.
+type Map struct {
+	Fields map[string][]byte
+}
+
+// NewMap returns a new empty Map.
+func NewMap() *Map {
+	return &Map{Fields: make(map[string][]byte)}
+}
+
+// IsMap returns whether data is the representation of a non-empty map.
+func IsMap(data []byte) bool {
+	return len(data) > len(mapMarker) && bytes.HasPrefix(data, mapMarker)
+}
+
+// Set sets the value of a field.
+func (m *Map) Set(field string, value []byte) {
+	m.Fields[field] = value
+}
+
+// Delete removes a field, and returns whether it was present.
+func (m *Map) Delete(field string) bool {
+	_, ok := m.Fields[field]
+	delete(m.Fields, field)
+	return ok
+}
+
+// Names returns the fields of the map, sorted.
+func (m *Map) Names() []string {
+	names := make([]string, 0, len(m.Fields))
+	for field := range m.Fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MarshalBinary returns the binary representation of a map, which is empty
+// for an empty map.
+func (m *Map) MarshalBinary() (data []byte, err error) {
+	if len(m.Fields) == 0 {
+		return nil, nil
+	}
+
+	data = append(data, mapMarker...)
+	for _, field := range m.Names() {
+		data = append(data, EncodeArgs([]byte(field), m.Fields[field])...)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary parses the binary representation of a map.
+// Empty data is an empty map.
+func (m *Map) UnmarshalBinary(data []byte) error {
+	m.Fields = make(map[string][]byte)
+	if len(data) == 0 {
+		return nil
+	}
+
+	if !IsMap(data) {
+		return ErrNotMap
+	}
+
+	data = data[len(mapMarker):]
+	fields := make(map[string][]byte)
+	for len(data) > 0 {
+		field, rest, ok := readChunk(data)
+		if !ok {
+			return ErrNotMap
+		}
+		value, rest, ok := readChunk(rest)
+		if !ok {
+			return ErrNotMap
+		}
+
+		fields[string(field)] = value
+		data = rest
+	}
+
+	m.Fields = fields
+	return nil
+}
@@ -0,0 +1,68 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package encoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMap(t *testing.T) {
+	m := NewMap()
+	m.Set("b", []byte("2"))
+	m.Set("a", []byte("1"))
+	m.Set("c", nil)
+	require.Equal(t, []string{"a", "b", "c"}, m.Names())
+
+	require.True(t, m.Delete("c"))
+	require.False(t, m.Delete("c"))
+	require.False(t, m.Delete("unknown"))
+
+	m.Set("b", []byte("two"))
+	require.Equal(t, map[string][]byte{"a": []byte("1"), "b": []byte("two")}, m.Fields)
+}
+
+func TestMap_Binary(t *testing.T) {
+	m := NewMap()
+	data, err := m.MarshalBinary()
+	require.Nil(t, err)
+	require.Empty(t, data)
+
+	// The representation does not depend on the order of the updates
+	m.Set("b", []byte{0x00, 0x01})
+	m.Set("a", []byte{})
+	m2 := NewMap()
+	m2.Set("a", []byte{})
+	m2.Set("b", []byte{0x00, 0x01})
+
+	data, err = m.MarshalBinary()
+	require.Nil(t, err)
+	require.True(t, IsMap(data))
+	data2, _ := m2.MarshalBinary()
+	require.Equal(t, data, data2)
+
+	m3 := NewMap()
+	require.Nil(t, m3.UnmarshalBinary(data))
+	require.Len(t, m3.Fields, 2)
+	require.Empty(t, m3.Fields["a"])
+	require.Equal(t, []byte{0x00, 0x01}, m3.Fields["b"])
+
+	require.Nil(t, m3.UnmarshalBinary(nil))
+	require.Empty(t, m3.Fields)
+
+	require.Equal(t, ErrNotMap, m3.UnmarshalBinary([]byte("hello")))
+	require.Equal(t, ErrNotMap, m3.UnmarshalBinary(data[:len(data)-1]))
+
+	l := NewList()
+	l.PushTail([]byte("k"), []byte("v"))
+	list, _ := l.MarshalBinary()
+	require.False(t, IsMap(list))
+	require.False(t, IsList(data))
+}
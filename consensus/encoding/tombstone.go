@@ -0,0 +1,74 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package encoding
+
+import (
+	"bytes"
+	"errors"
+	"time"
+)
+
+// Error constants for Tombstones
+var (
+	ErrNotTombstone = errors.New("not a tombstone")
+)
+
+// tombstoneMarker starts every tombstone. Since a set element cannot be
+// empty, and a float cannot start with a null byte, it cannot be mistaken
+// for a set or a float.
+var tombstoneMarker = []byte("\x00\x00\x00\x00\x00\x00\x00\x00pnyxdb:tombstone")
+
+// Tombstone replaces the value of a soft-deleted key, keeping the previous
+// value so that it can be restored until the grace period expires.
+type Tombstone struct {
+	Expires time.Time
+	Value   []byte
+}
+
+// deletionExpiry is the expiry of deletions, before any logical time.
+var deletionExpiry = time.Unix(0, -1)
+
+// Deletion returns the tombstone of a permanently deleted key. It cannot be
+// restored, and is never stored: the key is removed instead.
+func Deletion() []byte {
+	data, _ := (&Tombstone{Expires: deletionExpiry}).MarshalBinary()
+	return data
+}
+
+// IsDeletion returns whether data is the tombstone of a permanently deleted key.
+func IsDeletion(data []byte) bool {
+	t := &Tombstone{}
+	return t.UnmarshalBinary(data) == nil && t.Expires.Equal(deletionExpiry) && len(t.Value) == 0
+}
+
+// IsTombstone returns whether data is the value of a soft-deleted key.
+func IsTombstone(data []byte) bool {
+	return len(data) >= len(tombstoneMarker)+8 && bytes.HasPrefix(data, tombstoneMarker)
+}
+
+// MarshalBinary returns the binary representation of a tombstone.
+func (t *Tombstone) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 0, len(tombstoneMarker)+8+len(t.Value))
+	data = append(data, tombstoneMarker...)
+	data = append(data, uint64ToBytes(uint64(t.Expires.UnixNano()))...)
+	return append(data, t.Value...), nil
+}
+
+// UnmarshalBinary parses the binary representation of a tombstone.
+func (t *Tombstone) UnmarshalBinary(data []byte) error {
+	if !IsTombstone(data) {
+		return ErrNotTombstone
+	}
+
+	data = data[len(tombstoneMarker):]
+	t.Expires = time.Unix(0, int64(bytesToUint64(data[:8])))
+	t.Value = make([]byte, len(data[8:]))
+	copy(t.Value, data[8:])
+	return nil
+}
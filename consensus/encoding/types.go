@@ -0,0 +1,236 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package encoding
+
+import (
+	"encoding/hex"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Type is the detected type of a stored value.
+type Type string
+
+// Available types.
+const (
+	TypeEmpty Type = "empty"
+	TypeFloat Type = "float"
+	TypeSet   Type = "set"
+	TypeList  Type = "list"
+	TypeMap   Type = "map"
+	TypeRaw   Type = "raw"
+)
+
+// maxFloatProbe bounds the size of values that are probed as floats,
+// to avoid parsing large textual values.
+const maxFloatProbe = 256
+
+// TypeOf returns the most probable type of a stored value.
+// Values are not typed in the database: this function only probes the
+// available decoders, without allocating the decoded value.
+func TypeOf(data []byte) Type {
+	if len(data) == 0 {
+		return TypeEmpty
+	}
+
+	if len(data) <= maxFloatProbe {
+		if _, ok := new(big.Float).SetString(string(data)); ok {
+			return TypeFloat
+		}
+	}
+
+	if IsList(data) {
+		return TypeList
+	}
+
+	if IsMap(data) {
+		return TypeMap
+	}
+
+	if isSet(data) {
+		return TypeSet
+	}
+
+	return TypeRaw
+}
+
+// isSet checks that data is a sequence of non-empty length-prefixed elements.
+func isSet(data []byte) bool {
+	l := uint64(len(data))
+	for i := uint64(0); i < l; {
+		if i+8 > l {
+			return false
+		}
+
+		length := bytesToUint64(data[i : i+8])
+		if length == 0 || length > l-i-8 {
+			return false
+		}
+
+		i += 8 + length
+	}
+	return true
+}
+
+// Preview returns a human-readable representation of a stored value of type
+// t, built from its first limit bytes at most. Raw values that are not
+// printable text are represented in hexadecimal. A trailing "…" indicates
+// that the preview has been truncated.
+func Preview(data []byte, t Type, limit int) string {
+	switch t {
+	case TypeEmpty:
+		return ""
+	case TypeSet:
+		return previewSet(data, limit)
+	case TypeList:
+		return previewList(data, limit)
+	case TypeMap:
+		return previewMap(data, limit)
+	case TypeFloat:
+		return previewText(data, limit)
+	}
+
+	if isPrintable(data) {
+		return strconv.Quote(previewText(data, limit))
+	}
+
+	truncated := len(data) > limit
+	if truncated {
+		data = data[:limit]
+	}
+
+	str := hex.EncodeToString(data)
+	if truncated {
+		str += "…"
+	}
+	return str
+}
+
+func previewText(data []byte, limit int) string {
+	if len(data) <= limit {
+		return string(data)
+	}
+
+	// Do not cut a multi-byte character
+	for limit > 0 && !utf8.RuneStart(data[limit]) {
+		limit--
+	}
+	return string(data[:limit]) + "…"
+}
+
+func previewSet(data []byte, limit int) string {
+	s := NewSet()
+	if s.UnmarshalBinary(data) != nil {
+		return ""
+	}
+
+	elements := make([]string, 0, len(s.Elements))
+	for e := range s.Elements {
+		elements = append(elements, e)
+	}
+	sort.Strings(elements)
+
+	var b strings.Builder
+	b.WriteString("{")
+	writeElements(&b, elements, limit)
+	b.WriteString("}")
+	return b.String()
+}
+
+func previewList(data []byte, limit int) string {
+	l := NewList()
+	if l.UnmarshalBinary(data) != nil {
+		return ""
+	}
+
+	elements := make([]string, 0, l.Len())
+	for _, e := range l.Elements {
+		elements = append(elements, string(e.Value))
+	}
+
+	var b strings.Builder
+	b.WriteString("[")
+	writeElements(&b, elements, limit)
+	b.WriteString("]")
+	return b.String()
+}
+
+func previewMap(data []byte, limit int) string {
+	m := NewMap()
+	if m.UnmarshalBinary(data) != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("{")
+	var size int
+	for i, field := range m.Names() {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+
+		value := m.Fields[field]
+		if size+len(field)+len(value) > limit {
+			b.WriteString("…")
+			break
+		}
+		size += len(field) + len(value)
+
+		b.WriteString(previewElement([]byte(field)))
+		b.WriteString(": ")
+		b.WriteString(previewElement(value))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// writeElements writes a comma-separated preview of elements, truncated after
+// limit bytes.
+func writeElements(b *strings.Builder, elements []string, limit int) {
+	var size int
+	for i, e := range elements {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+
+		if size+len(e) > limit {
+			b.WriteString("…")
+			break
+		}
+		size += len(e)
+
+		b.WriteString(previewElement([]byte(e)))
+	}
+}
+
+// previewElement quotes printable data, and represents other data in
+// hexadecimal.
+func previewElement(data []byte) string {
+	if isPrintable(data) {
+		return strconv.Quote(string(data))
+	}
+	return "0x" + hex.EncodeToString(data)
+}
+
+func isPrintable(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package encoding
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeOf(t *testing.T) {
+	s := NewSet()
+	_, _ = s.Add([]byte("bob"))
+	_, _ = s.Add([]byte("alice"))
+	set, _ := s.MarshalBinary()
+
+	l := NewList()
+	l.PushTail([]byte("k"), []byte("bob"))
+	list, _ := l.MarshalBinary()
+
+	m := NewMap()
+	m.Set("name", []byte("bob"))
+	mdata, _ := m.MarshalBinary()
+
+	f, _ := NewFloat().Add(&Float{Float: NewFloat().SetFloat64(12.5)}).MarshalBinary()
+
+	cases := []struct {
+		data []byte
+		t    Type
+	}{
+		{nil, TypeEmpty},
+		{[]byte{}, TypeEmpty},
+		{[]byte("42"), TypeFloat},
+		{[]byte("-1.5e3"), TypeFloat},
+		{f, TypeFloat},
+		{set, TypeSet},
+		{list, TypeList},
+		{mdata, TypeMap},
+		{[]byte("hello world"), TypeRaw},
+		{[]byte{0x01, 0x02, 0x03}, TypeRaw},
+		{set[:len(set)-1], TypeRaw},
+		{make([]byte, 16), TypeRaw}, // empty elements are not allowed in sets
+		{[]byte(strings.Repeat("1", maxFloatProbe+1)), TypeRaw},
+	}
+
+	for _, c := range cases {
+		require.Equal(t, c.t, TypeOf(c.data), "%q", c.data)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	require.Equal(t, "", Preview(nil, TypeEmpty, 10))
+	require.Equal(t, "42", Preview([]byte("42"), TypeFloat, 10))
+	require.Equal(t, "3.14…", Preview([]byte("3.14159"), TypeFloat, 4))
+
+	require.Equal(t, `"hello"`, Preview([]byte("hello"), TypeRaw, 10))
+	require.Equal(t, `"hel…"`, Preview([]byte("hello"), TypeRaw, 3))
+	require.Equal(t, `"h…"`, Preview([]byte("hé"), TypeRaw, 2), "should not cut multi-byte characters")
+
+	require.Equal(t, "00ff10", Preview([]byte{0x00, 0xff, 0x10}, TypeRaw, 10), "binary values preview as hex")
+	require.Equal(t, "00ff…", Preview([]byte{0x00, 0xff, 0x10}, TypeRaw, 2))
+
+	s := NewSet()
+	_, _ = s.Add([]byte("bob"))
+	_, _ = s.Add([]byte("alice"))
+	_, _ = s.Add([]byte{0x00, 0x01})
+	set, _ := s.MarshalBinary()
+	require.Equal(t, `{0x0001, "alice", "bob"}`, Preview(set, TypeSet, 100))
+	require.Equal(t, `{0x0001, "alice", …}`, Preview(set, TypeSet, 8))
+
+	l := NewList()
+	l.PushTail([]byte("b"), []byte("bob"))
+	l.PushHead([]byte("a"), []byte("alice"))
+	list, _ := l.MarshalBinary()
+	require.Equal(t, `["alice", "bob"]`, Preview(list, TypeList, 100))
+	require.Equal(t, `["alice", …]`, Preview(list, TypeList, 6))
+
+	m := NewMap()
+	m.Set("name", []byte("bob"))
+	m.Set("age", []byte("42"))
+	m.Set("key", []byte{0x00})
+	mdata, _ := m.MarshalBinary()
+	require.Equal(t, `{"age": "42", "key": 0x00, "name": "bob"}`, Preview(mdata, TypeMap, 100))
+	require.Equal(t, `{"age": "42", …}`, Preview(mdata, TypeMap, 6))
+}
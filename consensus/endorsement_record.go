@@ -0,0 +1,176 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"go.uber.org/zap"
+)
+
+// EndorsedPrefix is the prefix of the keys recording the endorsements
+// emitted by the node. These keys are local to the node.
+//
+// Since the state of the query store is lost on restart unless a dump is
+// loaded, a restarted node could endorse a query again with other
+// conditions, which is seen as equivocation by its peers. Records allow it
+// to emit the same endorsement again, or to abstain.
+const EndorsedPrefix = "_endorsed/"
+
+// Records are spread over a fixed number of keys by query, so that they do
+// not accumulate in stores without deletion. Since queries are never
+// endorsed after their deadline, records are pruned endorsementRecordHorizon
+// after it (which covers the drift of the clocks), when their key is written
+// or every endorsementRecordPeriod.
+const (
+	endorsementRecordSlots   = 256
+	endorsementRecordHorizon = 10 * time.Minute
+	endorsementRecordPeriod  = 10 * time.Minute
+)
+
+// errEndorsementMismatch is returned by recordEndorsement when another
+// endorsement has already been emitted for the query.
+var errEndorsementMismatch = errors.New("another endorsement has been emitted for this query")
+
+func endorsementRecordKey(uuid string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(uuid))
+	return endorsementRecordSlot(h.Sum32() % endorsementRecordSlots)
+}
+
+func endorsementRecordSlot(i uint32) string {
+	return fmt.Sprintf("%s%02x", EndorsedPrefix, i)
+}
+
+// unsafe
+func (eng *Engine) loadEndorsementRecords(key string) (*EndorsementRecords, error) {
+	records := &EndorsementRecords{}
+	data, version, err := eng.Store.Get(key)
+	if version == NoVersion {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return records, proto.Unmarshal(data, records)
+}
+
+// unsafe
+func (eng *Engine) saveEndorsementRecords(key string, records *EndorsementRecords) error {
+	data, err := proto.Marshal(records)
+	if err != nil {
+		return err
+	}
+	return eng.Store.Set(key, data, NewVersion(data))
+}
+
+// pruneEndorsementRecordsIn removes the expired records, and returns true if
+// there was any.
+func (eng *Engine) pruneEndorsementRecordsIn(records *EndorsementRecords) bool {
+	limit := eng.now().Add(-endorsementRecordHorizon)
+	kept := records.Records[:0]
+	for _, r := range records.Records {
+		deadline, err := ptypes.Timestamp(r.Deadline)
+		if err == nil && !deadline.Before(limit) {
+			kept = append(kept, r)
+		}
+	}
+
+	pruned := len(kept) < len(records.Records)
+	records.Records = kept
+	return pruned
+}
+
+// recordEndorsement durably records an endorsement before it is emitted.
+// It returns errEndorsementMismatch if another endorsement has already been
+// recorded for the query, in which case the node shall abstain. Recording
+// the same endorsement again is a no-op.
+// This function locks the store.
+func (eng *Engine) recordEndorsement(q *Query, e *Endorsement) error {
+	hash, err := e.Hash()
+	if err != nil {
+		return err
+	}
+
+	eng.Store.Lock()
+	defer eng.Store.Unlock()
+
+	key := endorsementRecordKey(q.Uuid)
+	records, err := eng.loadEndorsementRecords(key)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records.Records {
+		if r.Uuid != q.Uuid {
+			continue
+		}
+
+		if bytes.Equal(r.Hash, hash) {
+			return nil
+		}
+		return errEndorsementMismatch
+	}
+
+	eng.pruneEndorsementRecordsIn(records)
+	records.Records = append(records.Records, &EndorsementRecords_Record{
+		Uuid:     q.Uuid,
+		Deadline: q.Deadline,
+		Hash:     hash,
+	})
+	return eng.saveEndorsementRecords(key, records)
+}
+
+// pruneEndorsementRecords removes the expired records from every key.
+// This function locks the store.
+func (eng *Engine) pruneEndorsementRecords() error {
+	eng.Store.Lock()
+	defer eng.Store.Unlock()
+
+	for i := uint32(0); i < endorsementRecordSlots; i++ {
+		key := endorsementRecordSlot(i)
+		records, err := eng.loadEndorsementRecords(key)
+		if err != nil {
+			return err
+		}
+
+		if eng.pruneEndorsementRecordsIn(records) {
+			err = eng.saveEndorsementRecords(key, records)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// endorsementRecordWorker periodically prunes the endorsement records.
+func (eng *Engine) endorsementRecordWorker(ctx context.Context) {
+	ticker := time.NewTicker(endorsementRecordPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := eng.pruneEndorsementRecords()
+			if err != nil {
+				zap.L().Warn("EndorsementRecords", zap.Error(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_EndorsementRecords(t *testing.T) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	store := newMemoryStore()
+	signer := NewEngine(newMemoryStore(), &recordingNetwork{}, nil, kr, 2)
+
+	query := func(key string, timeout time.Duration) *Query {
+		q := NewQuery()
+		q.SetTimeout(timeout)
+		q.Operations = []*Operation{{Key: key, Op: Operation_SET, Data: []byte(q.Uuid)}}
+		q.Emitter = kr.Identity()
+		require.Nil(t, signer.signQuery(q))
+		return q
+	}
+
+	// start runs a node on the store, which is kept across restarts, and
+	// returns a function stopping it and returning its encoded endorsements
+	start := func() (*Engine, func() map[string][]byte) {
+		network := &recordingNetwork{}
+		eng := NewEngine(store, network, passBBC{}, kr, 2) // never committed alone
+		ctx, cancel := context.WithCancel(context.Background())
+		require.Nil(t, eng.Run(ctx))
+
+		return eng, func() map[string][]byte {
+			cancel()
+			network.Lock()
+			defer network.Unlock()
+
+			endorsements := make(map[string][]byte)
+			for _, m := range network.messages {
+				if e, ok := m.(*Endorsement); ok {
+					data, err := proto.Marshal(e)
+					require.Nil(t, err)
+					endorsements[e.Uuid] = data
+				}
+			}
+			return endorsements
+		}
+	}
+
+	// c expires before q is endorsed, q is therefore endorsed with c as
+	// condition, while p does not conflict with any query
+	c, q, p := query("a", 50*time.Millisecond), query("a", time.Minute), query("b", time.Minute)
+	eng, stop := start()
+	eng.handleQuery(c)
+	eng.handleQuery(q)
+	eng.handleQuery(p)
+	before := stop()
+	require.Len(t, before, 3)
+	e := &Endorsement{}
+	require.Nil(t, proto.Unmarshal(before[q.Uuid], e))
+	require.Equal(t, []string{c.Uuid}, e.Conditions)
+
+	restarted, stop := start()
+	restarted.handleQuery(p)
+	restarted.handleQuery(q) // c is unknown after the restart
+	after := stop()
+
+	require.Equal(t, before[p.Uuid], after[p.Uuid], "the same endorsement should be emitted again")
+	require.NotContains(t, after, q.Uuid, "should abstain instead of endorsing with other conditions")
+
+	t.Run("pruning", func(t *testing.T) {
+		eng := NewEngine(store, &recordingNetwork{}, passBBC{}, kr, 2)
+		require.Nil(t, eng.pruneEndorsementRecords())
+		require.Equal(t, errEndorsementMismatch, eng.recordEndorsement(q, &Endorsement{Uuid: q.Uuid}), "records should be kept until the deadline")
+
+		eng.ClusterClock = &ClusterClock{Clock: func() time.Time {
+			return time.Now().Add(time.Minute + endorsementRecordHorizon)
+		}}
+		require.Nil(t, eng.pruneEndorsementRecords())
+
+		store.Lock()
+		defer store.Unlock()
+		list, err := store.List()
+		require.Nil(t, err)
+		for key := range list {
+			if IsLocalKey(key) {
+				records, err := eng.loadEndorsementRecords(key)
+				require.Nil(t, err)
+				require.Empty(t, records.Records, "expired records should be pruned")
+			}
+		}
+	})
+}
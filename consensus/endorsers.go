@@ -0,0 +1,92 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"crypto/sha256"
+	"sort"
+
+	"github.com/technicolor-research/pnyxdb/keyring"
+)
+
+// EndorserInfo describes an endorsement of a query, without its signature.
+type EndorserInfo struct {
+	Emitter     string
+	Conditions  []string
+	Fingerprint string // of the signature, or of the aggregated signature
+	Aggregated  bool   // the endorsement was received in an aggregate
+}
+
+// Endorsers returns the endorsements of a query, sorted by emitter.
+// While the query is known by the engine, every endorsement received for it
+// is listed. Afterwards, the endorsements are read from its commit
+// certificate, as long as the journal retains it, and certified is true:
+// only the endorsements that committed the query are listed then.
+// ErrUnknownQuery is returned if neither source knows the query.
+// This function is thread-safe.
+func (eng *Engine) Endorsers(uuid string) (endorsers []EndorserInfo, certified bool, err error) {
+	endorsers, ok := eng.qs.EndorsementInfos(uuid)
+	if ok {
+		return endorsers, false, nil
+	}
+
+	if eng.Journal == nil {
+		return nil, false, ErrUnknownQuery
+	}
+
+	c, err := eng.Journal.Certificate(uuid)
+	if err == ErrNoCertificate {
+		return nil, false, ErrUnknownQuery
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, e := range c.Endorsements {
+		endorsers = append(endorsers, endorserInfo(e, nil))
+	}
+	for _, a := range c.Aggregates {
+		for _, e := range a.Endorsements {
+			endorsers = append(endorsers, endorserInfo(e, a))
+		}
+	}
+	sortEndorsers(endorsers)
+	return endorsers, true, nil
+}
+
+// endorserInfo copies the metadata of e, which has been received in a if
+// not nil.
+func endorserInfo(e *Endorsement, a *AggregatedEndorsement) EndorserInfo {
+	info := EndorserInfo{
+		Emitter:     e.Emitter,
+		Conditions:  append([]string(nil), e.Conditions...),
+		Fingerprint: signatureFingerprint(e.Signature),
+	}
+	if a != nil {
+		info.Fingerprint = signatureFingerprint(a.Signature)
+		info.Aggregated = true
+	}
+	return info
+}
+
+func sortEndorsers(endorsers []EndorserInfo) {
+	sort.Slice(endorsers, func(i, j int) bool {
+		return endorsers[i].Emitter < endorsers[j].Emitter
+	})
+}
+
+// signatureFingerprint returns a short representation of a signature, to tell
+// endorsements apart, or the empty string if there is no signature.
+func signatureFingerprint(signature []byte) string {
+	if len(signature) == 0 {
+		return ""
+	}
+	hash := sha256.Sum256(signature)
+	return keyring.Fingerprint(hash[:])
+}
@@ -0,0 +1,82 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Endorsers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pnyxdb")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	const n = 3
+	journal, err := OpenJournal(filepath.Join(dir, "events"), 0, 0)
+	require.Nil(t, err)
+	defer func() { _ = journal.Close() }()
+
+	identities := make([]string, n)
+	engines, stop := startEngines(t, n, n, func(i int, eng *Engine) {
+		if i == 0 {
+			eng.Journal = journal
+		}
+		identities[i] = eng.Identity()
+	})
+	defer stop()
+	sort.Strings(identities)
+
+	_, _, err = engines[0].Endorsers("unknown")
+	require.Exactly(t, ErrUnknownQuery, err)
+
+	q := NewQuery()
+	q.SetTimeout(time.Second)
+	q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: []byte("a")}}
+	require.Nil(t, engines[1].Submit(q))
+
+	var cert *CommitCertificate
+	eventually(t, func() bool {
+		cert, _ = journal.Certificate(q.Uuid)
+		return cert != nil
+	}, "query should be committed")
+	require.Len(t, cert.Endorsements, n)
+
+	check := func(endorsers []EndorserInfo) {
+		require.Len(t, endorsers, n)
+		for i, e := range endorsers {
+			require.Exactly(t, identities[i], e.Emitter)
+			require.Empty(t, e.Conditions)
+			require.False(t, e.Aggregated)
+			for _, ce := range cert.Endorsements {
+				if ce.Emitter == e.Emitter {
+					require.Exactly(t, signatureFingerprint(ce.Signature), e.Fingerprint)
+				}
+			}
+		}
+	}
+
+	endorsers, certified, err := engines[0].Endorsers(q.Uuid)
+	require.Nil(t, err)
+	require.False(t, certified)
+	check(endorsers)
+
+	// Once forgotten, the endorsements are read from the certificate
+	require.Equal(t, 1, engines[0].qs.Forget(time.Now().Add(time.Hour), time.Now().Add(time.Hour)))
+	endorsers, certified, err = engines[0].Endorsers(q.Uuid)
+	require.Nil(t, err)
+	require.True(t, certified)
+	check(endorsers)
+}
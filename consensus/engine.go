@@ -12,24 +12,34 @@ package consensus
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"runtime"
 	"sort"
 	"sync"
 	"time"
 
 	"github.com/bluele/gcache"
 	"github.com/golang/protobuf/proto"
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
 	"github.com/technicolor-research/pnyxdb/consensus/operations"
 	"github.com/technicolor-research/pnyxdb/keyring"
 	"go.uber.org/zap"
 )
 
-const loopDuration = 100 * time.Millisecond
 const checkpointRoutineTimeout = 3 * time.Second
 const checkpointRoutineBatch = 100
-const checkpointRoutineSelect = 30
+const checkpointRoutineSelect = 30                       // initial size of the batches, see checkpointBatch
 const checkpointRoutineCooldown = 100 * time.Millisecond // limit checkpoints to 10 requests / sec max
 
+// Engine lifecycle errors
+var (
+	ErrEngineStopped = errors.New("engine is stopped")
+	ErrQueueFull     = errors.New("engine queue is full")
+	ErrBackpressure  = errors.New("engine is over its memory budget, retry later")
+)
+
 // Engine is the main consensus engine that can process queries and endorsements
 type Engine struct {
 	Store
@@ -37,15 +47,66 @@ type Engine struct {
 	BBCEngine
 	*keyring.KeyRing
 
-	ctx                context.Context
+	ctx                context.Context // nil until Run is called, protected by runMutex
+	cancel             func()          // cancels ctx, protected by runMutex
+	stopping           bool            // set by Stop, protected by runMutex
+	runMutex           sync.RWMutex
 	qs                 *queryStore
-	checkpoints        gcache.Cache
-	hashes             gcache.Cache
-	quorum             int // minimum number of endorsement required for applicable state
-	endorsementMutex   sync.Mutex
-	pendingCheckpoints chan string
+	checkpoints        gcache.Cache // *checkpointState by checkpoint identifier
+	checkpointsMutex   sync.Mutex   // serializes the creation of the entries of checkpoints
+	hashes             *HashCache
+	quorum             int             // minimum number of endorsement required for applicable state
+	endorsementMutex   endorsementLock // see lockorder.go
+	pendingCheckpoints *checkpointQueue
+	batch              *checkpointBatch
+	epoch              epochTracker
 	pendingRecovery    chan string
-	ActivityProbe      chan bool // will receive data when some activity requires persistence
+	recoveries         recoveryTracker
+	quotas             quotaTracker
+	retention          retentionTracker
+	acl                aclTracker
+	nodeStatus         nodeStatusTracker
+	watches            watchTracker
+	events             eventTracker
+	supervisor         supervisorTracker
+	quarantine         quarantineTracker
+	overlay            speculativeOverlay
+	loopHook           func(string)   // called with the name of a supervised loop at each of its steps, injects panics in tests
+	ActivityProbe      chan bool      // deprecated, signaled on each event requiring persistence, see Events
+	Journal            *Journal       // optional, receives every locally applied commit
+	Clock              Clock          // optional, the local time, the clock of the operating system if nil
+	ClusterClock       *ClusterClock  // optional, anchors deadlines to the cluster time
+	DistrustPolicy     DistrustPolicy // handling of pending queries when their emitter is not trusted anymore
+	Admins             []string       // identities allowed to sign AdminDrop statements
+	AdminQuorum        int            // minimum number of administrators signing an AdminDrop, all of them if zero
+	RetentionPeriod    time.Duration  // interval between two retention rounds, disabled if zero (see SetRetention)
+	RetentionMaxKeys   int            // maximum number of keys pruned by a retention query
+	ExpirySweepPeriod  time.Duration  // interval between two removals of expired keys, DefaultExpirySweepPeriod if zero, never if negative
+	CheckpointMinBatch int            // minimum number of queries proposed by a checkpoint, 1 if zero
+	CheckpointMaxBatch int            // maximum number of queries proposed by a checkpoint, 100 if zero
+	CheckpointQuorum   int            // matching CheckpointResult messages applying a checkpoint missed by the node, the quorum of endorsements if zero
+	RecoveryPolicy     RecoveryPolicy // retries of the keys asked through Recover
+	NodeStatusPeriod   time.Duration  // interval between two NodeStatus broadcasts, disabled if zero
+	NodeVersion        string         // reported by NodeStatus
+	WatchBuffer        int            // updates buffered for each watcher (see Watch), DefaultWatchBuffer if zero
+	EventBuffer        int            // events buffered for each subscriber (see Events), DefaultEventBuffer if zero
+	StatusRetention    time.Duration  // committed and dropped queries are forgotten once resolved and expired for this long, never if zero
+	ProofSummarySize   int            // veto proofs larger than this (in bytes) are summarized, DefaultProofSummarySize if zero, never if negative
+	BroadcastTimeout   time.Duration  // maximum time to hand a message to the network, DefaultBroadcastTimeout if zero
+	MaxQueryTimeout    time.Duration  // queries whose deadline is further away, in the future or in the past, are rejected, DefaultMaxQueryTimeout if zero, never if negative
+	QuarantineSize     int            // queries of emitters missing from the keyring kept until their key is imported, DefaultQuarantineSize if zero, none if negative
+	MaxRestarts        int            // restarts of a loop of the engine after a panic (see Failed), DefaultMaxRestarts if zero, never if negative
+	MemoryBudget       int64          // serialized size of the pending queries and endorsements above which new queries are rejected, unlimited if zero (see MemoryUsage)
+	UnlockFunc         func() error   // optional, unlocks the keyring when it has been locked, see sign
+	PersistFunc        func() error   // optional, persists the state of the engine (see Dump) once stopped by Stop
+	Metrics            Metrics        // optional, receives the measures of the engine, see CollectMetrics
+	unlockMutex        sync.Mutex
+	applyMutex         sync.Mutex
+	inflightApply      map[string]struct{} // queries being applied, protected by applyMutex
+	rejectedQueries    uint64              // atomic, queries of peers rejected by the memory budget
+	rejectedOrphans    uint64              // atomic, endorsements of unknown queries rejected by the memory budget
+	collectedDrops     uint64              // atomic, messages dropped by the network as of the last CollectMetrics
+	collectedEvents    uint64              // atomic, events dropped as of the last CollectMetrics
 }
 
 // NewEngine TODO
@@ -53,24 +114,46 @@ func NewEngine(s Store, n Network, bbc BBCEngine, k *keyring.KeyRing, q int) *En
 	qs := newQueryStore()
 	qs.threshold = q
 	return &Engine{
-		Store:              s,
+		Store:              checkedStore(s),
 		Network:            n,
 		BBCEngine:          bbc,
 		KeyRing:            k,
 		qs:                 qs,
 		checkpoints:        gcache.New(1024).LRU().Build(),
-		hashes:             gcache.New(1024).LFU().Build(),
+		hashes:             NewHashCache(DefaultHashCacheSize),
 		quorum:             q,
-		pendingCheckpoints: make(chan string, 1024),
+		pendingCheckpoints: newCheckpointQueue(),
+		batch:              newCheckpointBatch(),
 		pendingRecovery:    make(chan string, 1024),
 		ActivityProbe:      make(chan bool, 1),
 	}
 }
 
 // Submit submits a new query to the network of processes.
+// Queries whose operations cannot succeed are rejected with an
+// *OperationError, see Query.Validate, and those whose deadline is out of
+// bounds with the error of CheckDeadline. ErrBackpressure is returned while
+// the engine is over its MemoryBudget.
 func (eng *Engine) Submit(q *Query) error {
+	if !eng.accepting() {
+		return ErrEngineStopped
+	}
+	if !eng.admits(q) {
+		return ErrBackpressure
+	}
+
+	err := q.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = eng.CheckDeadline(q)
+	if err != nil {
+		return err
+	}
+
 	q.Emitter = eng.KeyRing.Identity()
-	err := eng.signQuery(q)
+	err = eng.signQuery(q)
 	if err != nil {
 		return err
 	}
@@ -79,115 +162,249 @@ func (eng *Engine) Submit(q *Query) error {
 		zap.String("uuid", q.Uuid),
 	)
 
-	err = eng.Network.Broadcast(q)
+	err = eng.broadcast(q)
 	if err == nil {
-		go eng.handleQuery(q)
+		eng.count(MetricQueriesSubmitted, 1)
+		eng.spawn(taskQuery, func() { eng.handleQuery(q) })
 	}
 	return err
 }
 
-// Run starts the engine in a non-blocking way.
-func (eng *Engine) Run(ctx context.Context) error {
-	eng.ctx = ctx
-	go func() {
-		acceptor := func(m proto.Message) bool {
-			_, ok := m.(*Query)
-			return ok
+// SubmitBatch submits several queries, and returns the error of each
+// submission, in order. It behaves like successive calls to Submit, except
+// that the queries are signed concurrently before being broadcast.
+func (eng *Engine) SubmitBatch(queries []*Query) []error {
+	errs := make([]error, len(queries))
+	if !eng.accepting() {
+		for i := range errs {
+			errs[i] = ErrEngineStopped
+		}
+		return errs
+	}
+
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if errs[i] = queries[i].Validate(); errs[i] != nil {
+					continue
+				}
+				if errs[i] = eng.CheckDeadline(queries[i]); errs[i] != nil {
+					continue
+				}
+				queries[i].Emitter = eng.KeyRing.Identity()
+				errs[i] = eng.signQuery(queries[i])
+			}
+		}()
+	}
+	for i := range queries {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	used := eng.qs.Memory()
+	for i, q := range queries {
+		if errs[i] != nil {
+			continue
 		}
 
-		for m := range eng.Network.Accept(ctx, acceptor) {
-			go eng.handleQuery(m.(*Query))
+		// The queries of the batch are not inserted yet
+		size := messageSize(q)
+		if eng.MemoryBudget > 0 && used+size > eng.MemoryBudget {
+			errs[i] = ErrBackpressure
+			continue
 		}
-	}()
+		used += size
 
-	go func() {
-		acceptor := func(m proto.Message) bool {
-			_, ok := m.(*Endorsement)
-			return ok
+		zap.L().Debug("Submit",
+			zap.String("uuid", q.Uuid),
+		)
+
+		errs[i] = eng.broadcast(q)
+		if errs[i] == nil {
+			eng.count(MetricQueriesSubmitted, 1)
+			eng.spawn(taskQuery, func() { eng.handleQuery(q) })
 		}
+	}
+	return errs
+}
 
-		for m := range eng.Network.Accept(ctx, acceptor) {
-			eng.handleEndorsement(m.(*Endorsement))
+// Snapshot returns the versions currently stored for the given keys. The
+// keys are read atomically: no commit can be applied in the middle of the
+// reads. Missing and expired keys are mapped to an empty version (see
+// NoVersion). The result is typically used as the Requirements of a query.
+// This function is thread-safe.
+func (eng *Engine) Snapshot(keys []string) (map[string]*Version, error) {
+	eng.Store.Lock()
+	defer eng.Store.Unlock()
+
+	versions := make(map[string]*Version, len(keys))
+	for _, key := range keys {
+		_, v, err := eng.getAt(key, eng.now())
+		if err != nil && v != NoVersion {
+			return nil, err
 		}
-	}()
+
+		if v == nil || v == NoVersion {
+			v = &Version{}
+		}
+		versions[key] = v
+	}
+	return versions, nil
+}
+
+// Run starts the engine in a non-blocking way.
+func (eng *Engine) Run(ctx context.Context) error {
+	err := eng.rebuildQuotas()
+	if err != nil {
+		return err
+	}
+
+	err = eng.recoverJournal()
+	if err != nil {
+		return err
+	}
+
+	eng.batch.setBounds(eng.CheckpointMinBatch, eng.CheckpointMaxBatch)
+	clock := eng.clock()
+	eng.qs.setClock(clock.Now)
+	if eng.ClusterClock != nil && eng.ClusterClock.Clock == nil {
+		eng.ClusterClock.Clock = clock.Now
+	}
+
+	ctx, cancel := context.WithCancel(ctx) // see Stop
+	eng.runMutex.Lock()
+	eng.ctx = ctx
+	eng.cancel = cancel
+	eng.runMutex.Unlock()
 
 	go func() {
-		acceptor := func(m proto.Message) bool {
-			_, ok := m.(*StartCheckpoint)
-			return ok
+		<-ctx.Done()
+		eng.drain()
+	}()
+
+	acceptor := func(m proto.Message) bool {
+		_, ok := m.(*Query)
+		return ok
+	}
+	eng.supervise(ctx, loopQueries, eng.acceptLoop(ctx, loopQueries, acceptor, func(m proto.Message) {
+		eng.count(MetricQueriesReceived, 1)
+		q := m.(*Query)
+		eng.spawn(taskQuery, func() { eng.handleQuery(q) })
+	}))
+
+	acceptor = func(m proto.Message) bool {
+		switch m.(type) {
+		case *Endorsement, *AggregatedEndorsement:
+			return true
 		}
+		return false
+	}
+	eng.supervise(ctx, loopEndorsements, eng.acceptLoop(ctx, loopEndorsements, acceptor, func(m proto.Message) {
+		if a, ok := m.(*AggregatedEndorsement); ok {
+			eng.count(MetricEndorsementsReceived, float64(len(a.Endorsements)))
+			eng.handleAggregate(a)
+		} else {
+			eng.count(MetricEndorsementsReceived, 1)
+			eng.handleEndorsement(m.(*Endorsement))
+		}
+	}))
 
-		for m := range eng.Network.Accept(ctx, acceptor) {
+	acceptor = func(m proto.Message) bool { // admin drops are decisions too
+		switch m.(type) {
+		case *StartCheckpoint, *CheckpointResult, *AdminDrop:
+			return true
+		}
+		return false
+	}
+	eng.supervise(ctx, loopCheckpoints, eng.acceptLoop(ctx, loopCheckpoints, acceptor, func(m proto.Message) {
+		switch m := m.(type) {
+		case *AdminDrop:
+			eng.handleAdminDrop(m)
+		case *CheckpointResult:
+			eng.handleCheckpointResult(ctx, m)
+		default:
 			eng.handleCheckpoint(ctx, m.(*StartCheckpoint))
 		}
-	}()
+	}))
 
-	go func() {
-		timer := time.NewTimer(checkpointRoutineTimeout)
-		var pending []string
-
-		start := func(expired bool) {
-			if len(pending) > 0 {
-				// Sort by id and submit only the first
-				sort.Strings(pending)
-				n := checkpointRoutineSelect
-				if len(pending) < n {
-					n = len(pending)
-				}
+	// The batch being checkpointed survives the restarts of the batcher
+	timer, stopTimer := clock.NewTimer(checkpointRoutineTimeout)
+	var pending []string
 
-				_ = eng.Network.Broadcast(&StartCheckpoint{Queries: pending[:n]})
-				pending = pending[n:]
-				zap.L().Debug("Checkpoint",
-					zap.String("state", "pool"),
-					zap.Int("sent", n),
-					zap.Int("remaining", len(pending)),
-				)
+	start := func(expired bool) {
+		for _, c := range eng.pendingCheckpoints.pop(checkpointRoutineBatch - len(pending)) {
+			pending = addToSet(pending, c)
+		}
 
-				// Introduce some arbitrary cooldown to avoid network contention
-				time.Sleep(checkpointRoutineCooldown)
-			}
+		if len(pending) > 0 {
+			// Submit the first groups of related queries, by id
+			size := eng.batch.Size()
+			var batch []string
+			batch, pending = selectBatch(eng.qs.ConflictGroups(pending), size)
 
-			if !expired && !timer.Stop() {
-				<-timer.C
-			}
-			timer.Reset(checkpointRoutineTimeout)
+			epoch := eng.epoch.get()
+			eng.batch.start(checkpointID(epoch, batch))
+			_ = eng.broadcast(&StartCheckpoint{Epoch: epoch, Queries: batch})
+			zap.L().Debug("Checkpoint",
+				zap.String("state", "pool"),
+				zap.Int("sent", len(batch)),
+				zap.Int("batch", size),
+				zap.Int("remaining", len(pending)),
+				zap.Int("queued", eng.pendingCheckpoints.Len()),
+			)
+
+			// Introduce some arbitrary cooldown to avoid network contention
+			<-clock.After(checkpointRoutineCooldown)
+		}
+
+		if !expired {
+			stopTimer()
 		}
+		timer, stopTimer = clock.NewTimer(checkpointRoutineTimeout)
+	}
+
+	eng.supervise(ctx, loopBatcher, func() {
+		stopTimer() // the timer may have expired during a panic
+		timer, stopTimer = clock.NewTimer(checkpointRoutineTimeout)
 
 		for {
 			select {
 			case <-ctx.Done():
-				if !timer.Stop() {
-					<-timer.C
-				}
+				stopTimer()
 				return
-			case c := <-eng.pendingCheckpoints:
-				pending = addToSet(pending, c)
-				if len(pending) == checkpointRoutineBatch {
+			case <-eng.pendingCheckpoints.ready:
+				eng.step(loopBatcher)
+				if len(pending)+eng.pendingCheckpoints.Len() >= checkpointRoutineBatch || eng.overBudget() || eng.pendingCheckpoints.expedited() {
 					start(false)
+					eng.pendingCheckpoints.signal() // more batches may be ready
 				}
-			case <-timer.C:
+			case <-timer:
+				eng.step(loopBatcher)
 				start(true)
 			}
 		}
-	}()
+	})
 
 	// Garbage collection mechanism
 	// TODO optimize
-	go func() {
-		var i int
+	var i int
+	eng.supervise(ctx, loopGC, func() {
 		for {
 			i++
 			select {
-			case <-time.After(100 * time.Millisecond):
+			case <-clock.After(100 * time.Millisecond):
+				eng.step(loopGC)
+				eng.flushDecisions() // queries dropped by checkpoints, administrators or the distrust policy
+				eng.reclaimMemory()
 				if false && i == 5 { // TODO check this experimental attempt
 					i = 0
-					out := eng.qs.OutdatedQueries()
-					for _, c := range out {
-						select {
-						case eng.pendingCheckpoints <- c:
-						case <-ctx.Done():
-							return
-						}
+					for _, c := range eng.qs.OutdatedQueries() {
+						eng.queueCheckpoint(c)
 					}
 				} else {
 					for _, uuid := range eng.qs.PendingQueries() {
@@ -198,20 +415,55 @@ func (eng *Engine) Run(ctx context.Context) error {
 				return
 			}
 		}
-	}()
+	})
+
+	if eng.ClusterClock != nil {
+		eng.runBeacon(ctx)
+	}
+
+	eng.watchKeyRing(ctx)
+	eng.runNodeStatus(ctx)
 
 	rec, ok := eng.Network.(RecoveryManager)
 	if ok {
 		rec.AcceptRecovery(ctx, eng.recoveryHandler)
 		zap.L().Info("Recovery", zap.String("handler", "ready"))
 	}
-	go eng.recoveryWorker(ctx)
+	eng.supervise(ctx, loopRecovery, func() { eng.recoveryWorker(ctx) })
+	go eng.endorsementRecordWorker(ctx)
+
+	psm, ok := eng.Network.(PendingSyncManager)
+	if ok {
+		psm.AcceptPendingSync(ctx, eng.pendingSyncHandler)
+		go eng.pendingSyncWorker(ctx, psm)
+	}
+
+	if eng.RetentionPeriod > 0 {
+		go eng.retentionWorker(ctx)
+	}
+
+	if eng.StatusRetention > 0 {
+		go eng.statusWorker(ctx)
+	}
+
+	if eng.ExpirySweepPeriod >= 0 {
+		go eng.expiryWorker(ctx)
+	}
 
 	return nil
 }
 
 func (eng *Engine) handleQuery(q *Query) {
+	if !eng.accepting() {
+		zap.L().Debug("Stopping engine", zap.String("uuid", q.Uuid))
+		return
+	}
+
 	err := eng.verifyQuery(q)
+	if isUnknownIdentity(err) {
+		eng.quarantineQuery(q)
+		return
+	}
 	if err != nil {
 		zap.L().Warn("Invalid query",
 			zap.String("uuid", q.Uuid),
@@ -220,57 +472,146 @@ func (eng *Engine) handleQuery(q *Query) {
 		return
 	}
 
+	err = eng.CheckDeadline(q)
+	if err != nil {
+		zap.L().Warn("Invalid query",
+			zap.String("uuid", q.Uuid),
+			zap.Error(err),
+		)
+		return
+	}
+
+	// Other nodes reach the same decision, the query cannot be committed
+	err = q.Validate()
+	if err != nil {
+		zap.L().Warn("Invalid query",
+			zap.String("uuid", q.Uuid),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if eng.outdated(q) { // possibly forgotten, do not process it again
+		zap.L().Debug("Outdated query", zap.String("uuid", q.Uuid))
+		return
+	}
+
+	if eng.qs.GetQuery(q.Uuid) == nil {
+		if eng.decided(q.Uuid) { // replayed after a restart, or forgotten
+			zap.L().Debug("Decided query", zap.String("uuid", q.Uuid))
+			return
+		}
+		if q.Emitter != eng.KeyRing.Identity() && !eng.admits(q) { // submitted queries are admitted by Submit
+			eng.rejectQuery(q)
+			return
+		}
+	}
+
 	inserted := eng.qs.AddQuery(q)
 	if !inserted {
 		return
 	}
 
-	defer eng.markActive()
+	eng.emit(EngineEvent{Type: EventQueryReceived, Uuid: q.Uuid, Emitter: q.Emitter})
 	eng.checkState(q.Uuid)
 
 	for {
 		eng.endorsementMutex.Lock()
 		if eng.canEndorse(q) {
-			conflictingQueries := eng.qs.GetConflicting(q)
-			if len(conflictingQueries) == 0 {
-				eng.endorse(q, nil)
+			conflictingQueries, preceding, withdrawn := eng.qs.GetConflicting(q)
+			if len(conflictingQueries) == 0 && len(preceding) == 0 {
+				eng.endorse(q, withdrawn)
 				eng.endorsementMutex.Unlock()
 				return
 			}
 
-			allExpired := true
+			// Wait for the earliest expiry of a conflicting query, unless
+			// one of them is committed or dropped before. Conflicting
+			// queries not endorsed yet are endorsed first if they precede
+			// q, whatever the order of reception, so that the nodes endorse
+			// the same query; those the node cannot endorse are ignored.
+			// Queries whose local endorsement has been withdrawn do not hold
+			// q back, but remain conditions of its endorsement.
+			now := eng.now()
+			wake := q.DeadlineTime()
+			var pending []string
 			for _, c := range conflictingQueries {
-				if !c.Expired() {
-					allExpired = false
-					break
+				if !c.ExpiredAt(now) {
+					pending = append(pending, c.Uuid)
+					if d := c.DeadlineTime(); d.Before(wake) {
+						wake = d
+					}
+				}
+			}
+			for _, c := range preceding {
+				if eng.canEndorse(c) {
+					pending = append(pending, c.Uuid)
+					if d := c.DeadlineTime(); d.Before(wake) {
+						wake = d
+					}
 				}
 			}
 
-			if allExpired {
-				eng.endorse(q, conflictingQueries)
+			if len(pending) == 0 {
+				eng.endorse(q, append(conflictingQueries, withdrawn...))
 				eng.endorsementMutex.Unlock()
 				return
 			}
+
+			changed, release := eng.qs.Watch(pending)
+			eng.endorsementMutex.Unlock()
+			stopped := eng.waitUntil(wake, changed)
+			release()
+			if stopped {
+				return
+			}
 		} else {
 			eng.endorsementMutex.Unlock()
 			return
 		}
+	}
+}
+
+// waitUntil blocks until t is reached (in cluster time) or the channel is closed.
+// It returns true if the engine has been stopped in the meantime.
+func (eng *Engine) waitUntil(t time.Time, c <-chan struct{}) (stopped bool) {
+	var done <-chan struct{} // nil until Run is called
+	if ctx := eng.runContext(); ctx != nil {
+		done = ctx.Done()
+	}
+
+	timer, stop := eng.clock().NewTimer(t.Sub(eng.now()))
+	defer stop()
 
-		eng.endorsementMutex.Unlock()
-		time.Sleep(loopDuration) // TODO smarter wake-up?
+	select {
+	case <-timer:
+	case <-c:
+	case <-done:
+		return true
 	}
+	return false
 }
 
 func (eng *Engine) handleEndorsement(e *Endorsement) {
 	// Verify signature
 	err := eng.verifyEndorsement(e)
+	if isUnknownIdentity(err) {
+		eng.unknownEmitter("endorsement", e.Emitter, e.Uuid)
+	}
 	if err != nil {
 		return
 	}
 
-	eng.qs.AddEndorsement(e)
+	if !eng.admitsOrphan(e.Uuid) {
+		eng.rejectOrphans(1)
+		return
+	}
+
+	_, inserted := eng.qs.AddEndorsement(e)
+	if inserted {
+		eng.emit(EngineEvent{Type: EventEndorsed, Uuid: e.Uuid, Emitter: e.Emitter})
+	}
 	eng.checkState(e.Uuid)
-	eng.markActive()
 }
 
 func (eng *Engine) handleCheckpoint(ctx context.Context, sc *StartCheckpoint) {
@@ -278,96 +619,308 @@ func (eng *Engine) handleCheckpoint(ctx context.Context, sc *StartCheckpoint) {
 		return
 	}
 
-	// Compute checkpoint identifier
-	sort.Strings(sc.Queries)
-	hash := sha256.New()
-	for _, uuid := range sc.Queries {
-		_, _ = hash.Write([]byte(uuid))
+	if eng.epoch.observe(sc.Epoch) {
+		eng.markActive()
 	}
 
-	sum := fmt.Sprintf("%d-%x", len(sc.Queries), hash.Sum(nil))
-	_, err := eng.checkpoints.GetIFPresent(sum)
+	sum := checkpointID(sc.Epoch, sc.Queries)
+	c := eng.checkpoint(sum)
+	ctx, cancel := context.WithCancel(ctx)
+	if !c.start(cancel) { // started late by another node, which may have missed the decision
+		cancel()
+		eng.resendCheckpointResult(sum, c)
+		return
+	}
+	eng.emit(EngineEvent{Type: EventCheckpointStarted, Checkpoint: sum, Queries: sc.Queries})
 
-	// TODO check if we need to resend confirmation?
-	if err != nil {
-		_ = eng.checkpoints.SetWithExpire(sum, true, 60*time.Second)
-		choice, proofs := eng.qs.CheckpointChoice(sc.Queries)
+	choice, proofs := eng.qs.CheckpointChoice(sc.Queries)
+	proofs = eng.checkpointProofs(proofs)
+
+	zap.L().Debug("Checkpoint",
+		zap.String("id", sum),
+		zap.Uint64("epoch", sc.Epoch),
+		zap.String("state", "start"),
+		zap.Bool("choice", choice),
+	)
+
+	eng.spawn(taskCheckpoint, func() {
+		defer cancel()
+		if eng.UnlockFunc != nil && eng.KeyRing.Locked() {
+			_ = eng.unlock() // the BBC engine signs its choices
+		}
+
+		var decision bool
+		var decisionProofs []*Proof
+		var err error
+		eng.count(MetricCheckpointRounds, 1)
+		started := eng.clock().Now()
+		if vbbc, ok := eng.BBCEngine.(VerifyingBBCEngine); ok {
+			decision, decisionProofs, err = vbbc.ExecuteVerified(ctx, sum, choice, proofs, eng.vetoVerifier(ctx, sc.Queries))
+		} else {
+			decision, decisionProofs, err = eng.BBCEngine.Execute(ctx, sum, choice, proofs)
+		}
+		if err == nil {
+			eng.observe(MetricCheckpointDuration, eng.clock().Now().Sub(started).Seconds())
+			if !eng.recordCheckpointResult(sum, c, sc, decision, decisionProofs) {
+				return // applied from the results of the other nodes
+			}
+			eng.batch.decided(sum, decision)
+			eng.epoch.concluded(sc.Epoch) // persisted along with the event
+			eng.emit(EngineEvent{Type: EventCheckpointDecided, Checkpoint: sum, Queries: sc.Queries, Decision: decision})
+		} else if c.isDecided() {
+			return // stopped once applied from the results of the other nodes
+		} else if err == ErrBBCTimeout {
+			eng.count(MetricCheckpointTimeouts, 1)
+			eng.retryCheckpoint(sum, sc.Queries)
+		}
 
 		zap.L().Debug("Checkpoint",
 			zap.String("id", sum),
-			zap.String("state", "start"),
-			zap.Bool("choice", choice),
+			zap.String("state", "end"),
+			zap.Bool("decision", decision),
 		)
 
-		go func() {
-			decision, decisionProofs, _ := eng.BBCEngine.Execute(ctx, sum, choice, proofs)
+		if !decision && choice { // Unexpected veto encountered, process proofs
+			eng.handleDecisionProofs(ctx, sum, decisionProofs)
+		}
 
-			zap.L().Debug("Checkpoint",
-				zap.String("id", sum),
-				zap.String("state", "end"),
-				zap.Bool("decision", decision),
+		if decision {
+			eng.qs.CheckpointDrop(sc.Queries)
+			eng.flushDecisions()
+		}
+	})
+}
+
+// handleDecisionProofs processes the proofs of a checkpoint decided against
+// the local choice. The proofs of votes are those of the votes against the
+// checkpoint (see Vote).
+func (eng *Engine) handleDecisionProofs(ctx context.Context, id string, proofs []*Proof) {
+	for _, proof := range proofs {
+		if q := proof.GetQuery(); q != nil {
+			eng.handleQuery(q)
+		} else if e := proof.GetEndorsement(); e != nil {
+			eng.handleEndorsement(e)
+		} else if a := proof.GetAggregate(); a != nil {
+			eng.handleAggregate(a)
+		} else if s := proof.GetSummary(); s != nil {
+			eng.fetchProofs(ctx, s)
+		} else if v := proof.GetVote(); v != nil {
+			eng.handleDecisionProofs(ctx, id, v.Proofs)
+		} else {
+			zap.L().Warn("Invalid checkpoint proof",
+				zap.String("id", id),
+				zap.Any("proof", proof),
 			)
+		}
+	}
+}
 
-			if !decision && choice { // Unexpected veto encountered, process proofs
-				for _, proof := range decisionProofs {
-					if q := proof.GetQuery(); q != nil {
-						eng.handleQuery(q)
-					} else if e := proof.GetEndorsement(); e != nil {
-						eng.handleEndorsement(e)
-					} else {
-						zap.L().Warn("Invalid checkpoint proof",
-							zap.String("id", sum),
-							zap.Any("proof", proof),
-						)
-					}
-				}
-			}
+// retryCheckpoint queues again the pending queries of a checkpoint which
+// reached no decision, and forgets the checkpoint so that it can be
+// started again.
+func (eng *Engine) retryCheckpoint(id string, queries []string) {
+	zap.L().Warn("Checkpoint",
+		zap.String("id", id),
+		zap.String("state", "timeout"),
+	)
 
-			if decision {
-				eng.qs.CheckpointDrop(sc.Queries)
-				eng.markActive()
-			}
-		}()
+	eng.checkpoints.Remove(id)
+	eng.batch.decided(id, false)
+	for _, uuid := range queries {
+		if s, ok := eng.qs.Status(uuid); ok && s.State == StatePending {
+			eng.queueCheckpoint(uuid)
+		}
+	}
+}
+
+// checkpointID returns the identifier of the checkpoint of a batch of
+// queries at epoch, sorting the batch. Epoch 0 keeps the identifiers of the
+// nodes predating epochs.
+func checkpointID(epoch uint64, queries []string) string {
+	sort.Strings(queries)
+	hash := sha256.New()
+	if epoch > 0 {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], epoch)
+		_, _ = hash.Write(b[:])
 	}
+	for _, uuid := range queries {
+		_, _ = hash.Write([]byte(uuid))
+	}
+	return fmt.Sprintf("%d-%x", len(queries), hash.Sum(nil))
+}
+
+// CheckpointBatchSize returns the current maximum number of queries proposed
+// by the checkpoints started locally, see CheckpointMinBatch.
+// This function is thread-safe.
+func (eng *Engine) CheckpointBatchSize() int {
+	return eng.batch.Size()
+}
+
+// Quorum returns the number of endorsements required to commit a query.
+func (eng *Engine) Quorum() int {
+	return eng.quorum
 }
 
 func (eng *Engine) checkState(uuid string) {
 	commit, checkpoint := eng.qs.CheckState(uuid)
+	eng.flushDecisions()
 	if commit {
-		eng.apply(uuid)
-		eng.markActive()
+		_ = eng.apply(uuid) // failures are recorded by the query store
 		for _, uuid := range eng.qs.PendingQueries() {
 			eng.checkState(uuid)
 		}
 	}
 
-	if len(checkpoint) > 0 {
-		for _, c := range checkpoint {
-			select {
-			case eng.pendingCheckpoints <- c:
-			case <-eng.ctx.Done():
-				return
-			}
+	if eng.stopped() {
+		return
+	}
+	for _, c := range checkpoint {
+		eng.queueCheckpoint(c)
+	}
+}
+
+// queueCheckpoint queues a query for a checkpoint, without blocking. Queries
+// unknown to the node come first, then the oldest deadline first.
+func (eng *Engine) queueCheckpoint(uuid string) {
+	var deadline time.Time
+	if q := eng.qs.GetQuery(uuid); q != nil {
+		deadline = q.DeadlineTime()
+	}
+	eng.pendingCheckpoints.push(uuid, deadline)
+}
+
+// PendingCheckpoints returns the number of queries waiting for a checkpoint
+// to be started by the node.
+// This function is thread-safe.
+func (eng *Engine) PendingCheckpoints() int {
+	return eng.pendingCheckpoints.Len()
+}
+
+// runContext returns the context given to Run, or nil if the engine has not
+// been started yet.
+// This function is thread-safe.
+func (eng *Engine) runContext() context.Context {
+	eng.runMutex.RLock()
+	defer eng.runMutex.RUnlock()
+	return eng.ctx
+}
+
+// stopped returns true once the context given to Run is done.
+// This function is thread-safe.
+func (eng *Engine) stopped() bool {
+	ctx := eng.runContext()
+	return ctx != nil && ctx.Err() != nil
+}
+
+// enqueue sends value to one of the internal queues of the engine.
+// Once running, the call blocks until the value is consumed or the engine is
+// stopped. Before Run, values are buffered until the queue is full, so
+// that no caller can ever hang on a queue without consumer.
+// This function is thread-safe.
+func (eng *Engine) enqueue(queue chan string, value string) error {
+	ctx := eng.runContext()
+	if ctx == nil {
+		select {
+		case queue <- value:
+			return nil
+		default:
+			return ErrQueueFull
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ErrEngineStopped
+	}
+
+	select {
+	case queue <- value:
+		return nil
+	case <-ctx.Done():
+		return ErrEngineStopped
+	}
+}
+
+// drain discards the values remaining in the internal queues once the
+// engine is stopped. Producers never block after that point, see enqueue.
+func (eng *Engine) drain() {
+	eng.pendingCheckpoints.clear()
+	for empty := false; !empty; {
+		select {
+		case <-eng.pendingRecovery:
+		default:
+			empty = true
 		}
 	}
 }
 
 func (eng *Engine) canEndorse(q *Query) bool {
-	if q.Expired() {
+	if q.ExpiredAt(eng.now()) {
 		return false
 	}
 
 	eng.Store.Lock()
 	defer eng.Store.Unlock()
 	for k, v := range q.Requirements {
-		_, v2, err := eng.Store.Get(k)
+		_, v2, err := eng.getAt(k, q.DeadlineTime())
 		if err != nil || v2.Matches(v) != nil {
 			return false
 		}
 	}
 
-	// TODO policy compliance
-	return true
+	// Expected values are checked again when the query is applied
+	if swaps(q) {
+		if _, _, err := eng.execute(q); err == operations.ErrUnexpected {
+			return false
+		}
+	}
+
+	if key, err := eng.acl.check(q); err != nil {
+		zap.L().Warn("PolicyViolation",
+			zap.String("uuid", q.Uuid),
+			zap.String("emitter", q.Emitter),
+			zap.String("policy", q.Policy),
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	// Invalid queries are dropped when received, this is a safeguard
+	if writesLocalKeys(q) {
+		zap.L().Warn("ReservedKey",
+			zap.String("uuid", q.Uuid),
+			zap.String("emitter", q.Emitter),
+		)
+		return false
+	}
+
+	if !eng.allowsPruning(q) {
+		return false
+	}
+
+	if !eng.quotas.active() {
+		return true
+	}
+
+	// Quotas are checked against committed values only: concurrent queries
+	// on the same prefix may still overshoot a quota once.
+	values, sizes, err := eng.execute(q)
+	if err != nil {
+		return true // the query will fail to apply, and therefore cannot use any quota
+	}
+
+	return eng.quotas.allows(sizes, valueSizes(values))
+}
+
+// swaps returns true if a query holds a CAS operation.
+func swaps(q *Query) bool {
+	for _, op := range q.Operations {
+		if op.Op == Operation_CAS {
+			return true
+		}
+	}
+	return false
 }
 
 func (eng *Engine) endorse(q *Query, conditions []*Query) {
@@ -375,6 +928,7 @@ func (eng *Engine) endorse(q *Query, conditions []*Query) {
 	for i, c := range conditions {
 		cstr[i] = c.Uuid
 	}
+	sort.Strings(cstr) // the same endorsement can be emitted again, see recordEndorsement
 
 	zap.L().Debug("Endorsed",
 		zap.String("uuid", q.Uuid),
@@ -385,54 +939,255 @@ func (eng *Engine) endorse(q *Query, conditions []*Query) {
 		Uuid:       q.Uuid,
 		Emitter:    eng.Identity(),
 		Conditions: cstr,
+		Follows:    eng.qs.Followed(q),
 	}
-	err := eng.signEndorsement(e)
+	err := eng.recordEndorsement(q, e)
+	if err != nil {
+		zap.L().Warn("Abstain",
+			zap.String("uuid", q.Uuid),
+			zap.Error(err),
+		)
+		return
+	}
+
+	err = eng.signEndorsement(e)
 	if err != nil {
 		return
 	}
 
 	eng.qs.Endorse(q.Uuid)
-	_ = eng.Network.Broadcast(e)
+	if eng.broadcast(e) == nil {
+		eng.count(MetricEndorsementsSent, 1)
+	}
+	go eng.withdrawOnExpiry(q)
 }
 
-func (eng *Engine) apply(uuid string) {
-	eng.Store.Lock()
-	defer eng.Store.Unlock()
+// withdrawOnExpiry waits until an endorsed query is old, unless it is
+// committed or dropped before, then withdraws the local endorsement if the
+// query is not applicable (see queryStore.Withdraw) and checkpoints it
+// without waiting for a batch of checkpoints to fill up.
+func (eng *Engine) withdrawOnExpiry(q *Query) {
+	changed, release := eng.qs.Watch([]string{q.Uuid})
+	stopped := eng.waitUntil(q.DeadlineTime().Add(deltaOld), changed)
+	release()
+	if stopped || !eng.qs.Withdraw(q.Uuid) {
+		return
+	}
+
+	zap.L().Debug("Withdrawn", zap.String("uuid", q.Uuid))
+	eng.queueCheckpoint(q.Uuid)
+	eng.pendingCheckpoints.expedite()
+}
 
+// apply executes a committed query against the store. A query is applied at
+// most once, see AppliedPrefix.
+// Either every value written by the query reaches the store, in key order, or
+// none of them: an error is returned if an operation cannot be executed or if
+// the store fails, and the query is not marked as applied. ErrUnknownQuery is
+// returned if the query has been committed without being received.
+// Operations whose nonce has already been applied to their key are skipped,
+// see NoncePrefix.
+// Keys deleted by the query are removed in the same batch as the values
+// written, if the store is a BatchDeleter, or before them otherwise, so that a
+// failure in between only leaves the query to be applied again; they are
+// reported with NoVersion. The commit event of the query is written in the
+// same batch when the journal is enabled, see JournalPrefix.
+// The result is recorded by the query store, once the store is unlocked, and
+// reported by an EventApplied, emitted with the store still locked once the
+// values are written so that watchers receive them in the order of the writes.
+func (eng *Engine) apply(uuid string) (err error) {
+	if !eng.applying(uuid) {
+		return nil
+	}
+	var emitted bool
+	defer func() {
+		eng.qs.SetApplyResult(uuid, err)
+		eng.applied(uuid)
+		if !emitted {
+			eng.emit(EngineEvent{Type: EventApplied, Uuid: uuid, Err: err})
+		}
+	}()
+
+	// The query store must not be accessed once the store is locked.
 	q := eng.qs.GetQuery(uuid)
 	if q == nil {
-		return
+		return ErrUnknownQuery
 	}
 
-	values := make(map[string]*operations.Value)
-	for _, op := range q.Operations {
-		value, ok := values[op.Key]
-		if !ok {
-			data, v, err := eng.Store.Get(op.Key)
-			if err != nil && v != NoVersion {
-				return
-			}
+	var endorsements []*Endorsement
+	var aggregates []*AggregatedEndorsement
+	if eng.Journal != nil && eng.KeyRing != nil {
+		endorsements, aggregates = eng.qs.CommitEndorsements(uuid)
+	}
+
+	eng.Store.Lock()
+	defer eng.Store.Unlock()
+
+	markers, done, err := eng.loadAppliedMarkers(uuid)
+	if err != nil {
+		zap.L().Error("AppliedMarkers",
+			zap.String("uuid", uuid),
+			zap.Error(err),
+		)
+		return err
+	}
+	if done {
+		zap.L().Debug("AlreadyApplied",
+			zap.String("uuid", uuid),
+		)
+		return nil
+	}
+
+	executed, nonces, err := eng.skipAppliedNonces(q)
+	if err != nil {
+		zap.L().Error("AppliedNonces",
+			zap.String("uuid", uuid),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	values, sizes, err := eng.execute(executed)
+	if err != nil {
+		zap.L().Error("ApplyFailed",
+			zap.String("uuid", uuid),
+			zap.String("emitter", q.Emitter),
+			zap.Error(err),
+		)
+		return err
+	}
 
-			values[op.Key] = operations.NewValue(data)
-			value = values[op.Key]
+	// Local keys are never written, even if other nodes endorsed the query
+	for k := range values {
+		if IsLocalKey(k) {
+			delete(values, k)
+			delete(sizes, k)
 		}
+	}
+
+	// Every node writes the keys in the same order
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 
-		err := op.Exec(value)
+	committed := eng.now()
+	var written, deleted []string
+	var rawValues [][]byte
+	var writtenVersions []*Version
+	versions := make([]*Version, len(keys))
+	for i, k := range keys {
+		if encoding.IsDeletion(values[k].Raw) {
+			deleted = append(deleted, k)
+			versions[i] = NoVersion
+			continue
+		}
+
+		written = append(written, k)
+		rawValues = append(rawValues, values[k].Raw)
+		versions[i] = NewVersion(values[k].Raw)
+		versions[i].setExpiry(values[k].Expiry)
+		versions[i].setProvenance(q, committed)
+		writtenVersions = append(writtenVersions, versions[i])
+	}
+
+	marker, err := eng.markApplied(markers, q)
+	if err != nil {
+		zap.L().Error("AppliedMarkers",
+			zap.String("uuid", uuid),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	localKeys, localValues, err := encodeNonces(nonces)
+	if err != nil {
+		zap.L().Error("AppliedNonces",
+			zap.String("uuid", uuid),
+			zap.Error(err),
+		)
+		return err
+	}
+	localKeys = append(localKeys, appliedMarkerKey(uuid))
+	localValues = append(localValues, marker)
+
+	var event *CommitEvent
+	if eng.Journal != nil {
+		var data []byte
+		event, data, err = eng.journalEvent(q, keys, versions, endorsements, aggregates)
 		if err != nil {
-			return
+			zap.L().Error("Journal",
+				zap.String("uuid", uuid),
+				zap.Error(err),
+			)
+			return err
 		}
+		localKeys = append(localKeys, journalPendingKey)
+		localValues = append(localValues, data)
+	}
+	localVersions := make([]*Version, len(localValues))
+	for i, v := range localValues {
+		localVersions[i] = NewVersion(v)
 	}
 
-	keys := make([]string, len(values))
-	rawValues := make([][]byte, len(values))
-	versions := make([]*Version, len(values))
+	err = UpdateBatch(
+		eng.Store,
+		append(written, localKeys...),
+		append(rawValues, localValues...),
+		append(writtenVersions, localVersions...),
+		deleted,
+	)
+	if err != nil {
+		zap.L().Error("ApplyFailed",
+			zap.String("uuid", uuid),
+			zap.Error(err),
+		)
+		return err
+	}
 
-	var i int
+	eng.quotas.update(sizes, valueSizes(values))
+	eng.overlay.written(uuid)
+	eng.retention.touch(written)
+	eng.retention.forget(deleted)
+	eng.nodeStatus.committed(eng.clock().Now())
+	eng.emit(EngineEvent{Type: EventApplied, Uuid: q.Uuid, Emitter: q.Emitter, Keys: keys, Versions: versions})
+	emitted = true
+	if event != nil {
+		eng.appendJournal(event)
+	}
+	return nil
+}
+
+// execute runs the operations of q against the current content of the store,
+// without modifying it (see Query.Execute). It returns the resulting values,
+// along with the sizes of the values before execution.
+// unsafe
+func (eng *Engine) execute(q *Query) (map[string]*operations.Value, map[string]int, error) {
+	sizes := make(map[string]int)
+	values, err := q.execute(func(key string) ([]byte, *Version, error) {
+		data, v, err := eng.get(key)
+		if err != nil && v != NoVersion {
+			return nil, nil, err
+		}
+
+		sizes[key] = len(data) // expired values are still stored
+		return data, v, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return values, sizes, nil
+}
+
+func valueSizes(values map[string]*operations.Value) map[string]int {
+	sizes := make(map[string]int, len(values))
 	for k, v := range values {
-		keys[i] = k
-		rawValues[i] = v.Raw
-		versions[i] = NewVersion(v.Raw)
-		i++
+		sizes[k] = len(v.Raw)
+		if encoding.IsDeletion(v.Raw) {
+			sizes[k] = 0 // removed from the store
+		}
 	}
-	_ = eng.Store.SetBatch(keys, rawValues, versions)
+	return sizes
 }
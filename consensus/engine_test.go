@@ -0,0 +1,674 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/awnumar/memguard"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+	"github.com/technicolor-research/pnyxdb/keyring"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+// noHang fails the test if f does not return in a reasonable amount of time.
+func noHang(t *testing.T, msg string, f func()) {
+	done := make(chan struct{})
+	go func() {
+		f()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("call is hanging:", msg)
+	}
+}
+
+// eventually fails the test if cond does not hold within a second.
+func eventually(t *testing.T, cond func() bool, msgAndArgs ...interface{}) {
+	for i := 0; !cond(); i++ {
+		require.True(t, i < 100, msgAndArgs...)
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// startEngines runs n engines connected by a hub, with memory stores and
+// BBC engines deciding their local choice. The setup functions are called
+// on every engine before it is started. The returned function stops them.
+func startEngines(t *testing.T, n, quorum int, setup ...func(i int, eng *Engine)) ([]*Engine, context.CancelFunc) {
+	keyrings := tests.GetTestKeyRings(t, n)
+	h := &hub{}
+	engines := make([]*Engine, n)
+	for i := range engines {
+		engines[i] = NewEngine(newMemoryStore(), h.join(), passBBC{}, keyrings[i], quorum)
+		for _, f := range setup {
+			f(i, engines[i])
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for _, eng := range engines {
+		require.Nil(t, eng.Run(ctx))
+	}
+	h.waitSubscribers(t, 4*n) // queries, endorsements, checkpoints and node statuses
+	return engines, cancel
+}
+
+// passBBC is a BBCEngine deciding the local choice, as if every node agreed.
+type passBBC struct{}
+
+func (passBBC) Execute(ctx context.Context, id string, choice bool, proofs []*Proof) (bool, []*Proof, error) {
+	return choice, proofs, nil
+}
+
+func TestEngine_ShutdownSafety(t *testing.T) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, nil, kr, 1)
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: []byte("1")}}
+
+	// Before Run
+	noHang(t, "Submit before Run", func() {
+		require.Nil(t, eng.Submit(q))
+	})
+	noHang(t, "checkState before Run", func() {
+		eng.checkState(q.Uuid)
+		eng.checkState("unknown")
+	})
+	noHang(t, "Recover before Run", func() {
+		var err error
+		for i := 0; i <= cap(eng.pendingRecovery) && err == nil; i++ {
+			err = eng.Recover("a")
+		}
+		require.Equal(t, ErrQueueFull, err)
+	})
+	noHang(t, "queueCheckpoint before Run", func() {
+		for i := 0; i < 2048; i++ {
+			eng.queueCheckpoint("c")
+		}
+		require.Equal(t, 1, eng.PendingCheckpoints(), "queries should be queued once")
+		eng.checkState(q.Uuid)
+	})
+
+	// After cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	require.Nil(t, eng.Run(ctx))
+	cancel()
+
+	noHang(t, "Submit after cancellation", func() {
+		require.Equal(t, ErrEngineStopped, eng.Submit(NewQuery()))
+	})
+	noHang(t, "Recover after cancellation", func() {
+		require.Equal(t, ErrEngineStopped, eng.Recover("a"))
+	})
+	noHang(t, "enqueue after cancellation", func() {
+		require.Equal(t, ErrEngineStopped, eng.enqueue(eng.pendingRecovery, "a"))
+		eng.checkState(q.Uuid)
+	})
+
+	eventually(t, func() bool {
+		return eng.PendingCheckpoints() == 0 && len(eng.pendingRecovery) == 0
+	}, "queues should be drained after cancellation")
+}
+
+func TestEngine_UnlockFunc(t *testing.T) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	password, _ := memguard.NewImmutableFromBytes([]byte("password"))
+	defer password.Destroy()
+	require.Nil(t, kr.ReEncryptPrivate(password))
+	require.Nil(t, kr.LockPrivate())
+
+	network := &recordingNetwork{}
+	eng := NewEngine(newMemoryStore(), network, nil, kr, 1)
+
+	submit := func() error {
+		q := NewQuery()
+		q.SetTimeout(time.Minute)
+		return eng.Submit(q)
+	}
+	require.Equal(t, keyring.ErrKeyRingLocked, submit(), "should fail without UnlockFunc")
+
+	var calls int32
+	eng.UnlockFunc = func() error {
+		atomic.AddInt32(&calls, 1)
+		return kr.UnlockPrivate(password)
+	}
+
+	queries := []*Query{NewQuery(), NewQuery(), NewQuery()}
+	for _, q := range queries {
+		q.SetTimeout(time.Minute)
+	}
+	errs := eng.SubmitBatch(queries)
+	require.Equal(t, []error{nil, nil, nil}, errs)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls), "concurrent signatures should unlock once")
+	require.False(t, kr.Locked())
+
+	require.Nil(t, kr.LockPrivate())
+	eng.UnlockFunc = func() error { return errors.New("no password") }
+	require.EqualError(t, submit(), "no password")
+}
+
+// BenchmarkEngine_EndorsementBehindConflict measures the endorsement latency
+// of a query blocked behind a conflicting query which is about to expire.
+func BenchmarkEngine_EndorsementBehindConflict(b *testing.B) {
+	kr := tests.GetTestKeyRings(b, 1)[0]
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, nil, kr, 1)
+	const expiry = 2 * time.Millisecond
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		key := fmt.Sprint("key", i)
+
+		c := NewQuery()
+		c.SetTimeout(expiry)
+		c.Operations = []*Operation{{Key: key, Op: Operation_SET, Data: []byte("c")}}
+		eng.qs.AddQuery(c)
+		eng.qs.Endorse(c.Uuid)
+
+		q := NewQuery()
+		q.SetTimeout(time.Minute)
+		q.Operations = []*Operation{{Key: key, Op: Operation_SET, Data: []byte("q")}}
+		q.Emitter = kr.Identity()
+		require.Nil(b, eng.signQuery(q))
+		b.StartTimer()
+
+		eng.handleQuery(q)
+	}
+}
+
+// TestEngine_EndorsementAfterResolution checks that a query blocked behind a
+// conflicting query is endorsed as soon as the conflict is committed or
+// dropped, without waiting for the conflicting deadline.
+func TestEngine_EndorsementAfterResolution(t *testing.T) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, nil, kr, 1)
+
+	resolutions := map[string]func(uuid string){
+		"commit": func(uuid string) {
+			eng.qs.Lock()
+			eng.qs.commit(uuid)
+			eng.qs.Unlock()
+		},
+		"drop": func(uuid string) {
+			require.True(t, eng.qs.DropPending(uuid))
+		},
+	}
+
+	for name, resolve := range resolutions {
+		t.Run(name, func(t *testing.T) {
+			key := "key-" + name
+
+			c := NewQuery()
+			c.SetTimeout(time.Minute)
+			c.Operations = []*Operation{{Key: key, Op: Operation_SET, Data: []byte("c")}}
+			eng.qs.AddQuery(c)
+			eng.qs.Endorse(c.Uuid)
+
+			q := NewQuery()
+			q.SetTimeout(time.Minute)
+			q.Operations = []*Operation{{Key: key, Op: Operation_SET, Data: []byte("q")}}
+			q.Emitter = kr.Identity()
+			require.Nil(t, eng.signQuery(q))
+
+			done := make(chan time.Time, 1)
+			go func() {
+				eng.handleQuery(q)
+				done <- time.Now()
+			}()
+
+			// Wait for the query to be blocked behind c
+			for i := 0; ; i++ {
+				require.True(t, i < 200, "query should wait for the conflict")
+				eng.qs.RLock()
+				blocked := len(eng.qs.waiters[c.Uuid]) > 0
+				eng.qs.RUnlock()
+				if blocked {
+					break
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			start := time.Now()
+			resolve(c.Uuid)
+
+			select {
+			case end := <-done:
+				latency := end.Sub(start)
+				t.Log("time to endorse:", latency)
+				require.True(t, latency < 50*time.Millisecond, "endorsement should follow the resolution, took %v", latency)
+			case <-time.After(2 * time.Second):
+				t.Fatal("query has not been endorsed after the resolution of the conflict")
+			}
+
+			eng.qs.RLock()
+			require.True(t, eng.qs.queries[q.Uuid].Endorsed)
+			eng.qs.RUnlock()
+		})
+	}
+}
+
+func TestEngine_SnapshotRequirements(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pnyxdb")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	journal, err := OpenJournal(filepath.Join(dir, "events"), 0, 0)
+	require.Nil(t, err)
+	defer func() { _ = journal.Close() }()
+
+	engines, stop := startEngines(t, 1, 1, func(_ int, eng *Engine) { eng.Journal = journal })
+	defer stop()
+	eng := engines[0]
+
+	const timeout = 200 * time.Millisecond
+	submit := func(requirements map[string]*Version, values map[string]string) *Query {
+		q := NewQuery()
+		q.SetTimeout(timeout)
+		q.Requirements = requirements
+		for key, value := range values {
+			q.Operations = append(q.Operations, &Operation{Key: key, Op: Operation_SET, Data: []byte(value)})
+		}
+		require.Nil(t, eng.Submit(q))
+		return q
+	}
+
+	committed := func(q *Query) bool {
+		ctx, cancel := context.WithDeadline(context.Background(), q.DeadlineTime())
+		defer cancel()
+
+		var since uint64
+		for {
+			events, _ := eng.Journal.Replay(since)
+			for _, e := range events {
+				if e.Uuid == q.Uuid {
+					return true
+				}
+				since = e.Sequence
+			}
+			if eng.Journal.Wait(ctx, since) != nil {
+				return false
+			}
+		}
+	}
+
+	// Sequential cases
+	snapshot, err := eng.Snapshot([]string{"a", "b"})
+	require.Nil(t, err)
+	require.Nil(t, snapshot["a"].Matches(NoVersion), "missing keys should map to the empty version")
+	require.True(t, committed(submit(snapshot, map[string]string{"a": "a", "b": "b"})), "should apply up-to-date requirements")
+
+	snapshot, err = eng.Snapshot([]string{"a", "b"})
+	require.Nil(t, err)
+	require.True(t, committed(submit(nil, map[string]string{"b": "w"})))
+	require.False(t, committed(submit(snapshot, map[string]string{"a": "a2", "b": "b2"})), "should reject stale requirements")
+
+	// Concurrent writer churning "b": the queries of the writer and the
+	// transactions may conflict and wait for each other until a checkpoint,
+	// hence only the consistency of applied transactions is checked.
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			case <-time.After(2 * time.Millisecond):
+				submit(nil, map[string]string{"b": fmt.Sprint("w", i)})
+			}
+		}
+	}()
+
+	txs := make(map[string]*Query)
+	for i := 0; i < 30; i++ {
+		snapshot, err := eng.Snapshot([]string{"a", "b"})
+		require.Nil(t, err)
+		require.Len(t, snapshot, 2)
+
+		q := submit(snapshot, map[string]string{"a": fmt.Sprint("a", i), "b": fmt.Sprint("b", i)})
+		txs[q.Uuid] = q
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(done)
+	<-stopped
+	time.Sleep(2 * timeout)
+
+	// Replay the commits: a transaction is only applied when the versions
+	// of its requirements are still the ones of the snapshot.
+	events, err := eng.Journal.Replay(0)
+	require.Nil(t, err)
+
+	versions := map[string]*Version{}
+	var applied int
+	for _, e := range events {
+		if tx, ok := txs[e.Uuid]; ok {
+			require.Len(t, versions, 2)
+			applied++
+			for key, v := range tx.Requirements {
+				require.Nil(t, versions[key].Matches(v), "transaction %s applied with stale requirement on %s", e.Uuid, key)
+			}
+		}
+
+		for i, key := range e.Keys {
+			versions[key] = e.Versions[i]
+		}
+	}
+
+	stored, err := eng.Snapshot([]string{"a", "b"})
+	require.Nil(t, err)
+	for key, v := range versions {
+		require.Nil(t, stored[key].Matches(v))
+	}
+	t.Logf("%d/%d transactions applied, %d commits", applied, len(txs), len(events))
+}
+
+func TestEngine_SoftDelete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pnyxdb")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	journal, err := OpenJournal(filepath.Join(dir, "events"), 0, 0)
+	require.Nil(t, err)
+	defer func() { _ = journal.Close() }()
+
+	engines, stop := startEngines(t, 1, 1, func(_ int, eng *Engine) { eng.Journal = journal })
+	defer stop()
+	eng := engines[0]
+
+	var since uint64
+	apply := func(op Operation_Op, data string) *Version {
+		q := NewQuery()
+		q.SetTimeout(time.Second)
+		q.Operations = []*Operation{{Key: "a", Op: op, Data: []byte(data)}}
+		require.Nil(t, eng.Submit(q))
+
+		ctx, cancel := context.WithDeadline(context.Background(), q.DeadlineTime())
+		defer cancel()
+		for {
+			events, _ := eng.Journal.Replay(since)
+			for _, e := range events {
+				since = e.Sequence
+				if e.Uuid == q.Uuid {
+					return e.Versions[0]
+				}
+			}
+			require.Nil(t, eng.Journal.Wait(ctx, since), "query should be committed")
+		}
+	}
+
+	v1 := apply(Operation_SET, "hello")
+	deleted := apply(Operation_SOFTDELETE, "1h")
+	require.NotNil(t, deleted.Matches(v1))
+
+	data, v, err := eng.Store.Get("a")
+	require.Nil(t, err)
+	require.Nil(t, v.Matches(deleted))
+	require.True(t, encoding.IsTombstone(data))
+
+	restored := apply(Operation_RESTORE, "")
+	require.Nil(t, restored.Matches(v1), "restore should bring back the version preceding the deletion")
+
+	data, v, err = eng.Store.Get("a")
+	require.Nil(t, err)
+	require.Nil(t, v.Matches(v1))
+	require.Exactly(t, []byte("hello"), data)
+}
+
+func TestEngine_ConcurrentCAS(t *testing.T) {
+	const n = 3
+	clock := tests.NewManualClock(time.Now())
+	engines, stop := startEngines(t, n, 2, func(_ int, eng *Engine) {
+		eng.Clock = clock
+		require.Nil(t, eng.Store.Set("lock", []byte("free"), NewVersion([]byte("free"))))
+	})
+	defer stop()
+	clock.WaitTimers(2 * n) // checkpoint routines and garbage collectors
+
+	// Every node submits a swap from the same value
+	owners := []string{"alice", "bob", "carol"}
+	var queries []*Query
+	for i, owner := range owners {
+		q := NewQuery()
+		q.Deadline, _ = ptypes.TimestampProto(clock.Now().Add(time.Duration(i+1) * 200 * time.Millisecond))
+		q.Operations = []*Operation{{Key: "lock", Op: Operation_CAS, Data: encoding.EncodeArgs([]byte("free"), []byte(owner))}}
+		require.Nil(t, engines[i].Submit(q))
+		queries = append(queries, q)
+	}
+
+	// Losing swaps stay pending, the winner must be committed everywhere
+	settled := func() bool {
+		if !clock.Now().After(queries[n-1].DeadlineTime()) {
+			return false
+		}
+		for _, eng := range engines {
+			var committed bool
+			for _, q := range queries {
+				if s, _ := eng.QueryStatus(q.Uuid); s.State == StateCommitted {
+					committed = true
+				}
+			}
+			if !committed {
+				return false
+			}
+		}
+		return true
+	}
+	for i := 0; !settled(); i++ {
+		require.True(t, i < 100, "a swap should be committed")
+		clock.Advance(100 * time.Millisecond)
+		clock.WaitTimers(2 * n)
+	}
+
+	var applied int
+	for _, q := range queries {
+		s, err := engines[0].QueryStatus(q.Uuid)
+		require.Nil(t, err)
+		if s.Applied {
+			applied++
+		}
+	}
+	require.Equal(t, 1, applied, "exactly one swap must succeed")
+
+	var winner []byte
+	for _, eng := range engines {
+		eng.Store.Lock()
+		value, _, err := eng.Store.Get("lock")
+		eng.Store.Unlock()
+		require.Nil(t, err)
+		require.Contains(t, owners, string(value))
+		if winner == nil {
+			winner = value
+		}
+		require.Equal(t, winner, value, "every node must hold the same owner")
+	}
+}
+
+func TestEngine_Provenance(t *testing.T) {
+	engines, stop := startEngines(t, 1, 1)
+	defer stop()
+	eng := engines[0]
+
+	before := time.Now()
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: []byte("hello")}}
+	s, err := eng.SubmitAndWait(context.Background(), q)
+	require.Nil(t, err)
+	require.True(t, s.Applied)
+
+	_, v, err := eng.Store.Get("a")
+	require.Nil(t, err)
+	p := v.GetProvenance()
+	require.NotNil(t, p)
+	require.Exactly(t, q.Uuid, p.Uuid)
+	require.Exactly(t, eng.Identity(), p.Emitter)
+	committed, err := ptypes.Timestamp(p.Committed)
+	require.Nil(t, err)
+	require.False(t, committed.Before(before.Truncate(time.Second)))
+}
+
+func TestEngine_ConflictArbitration(t *testing.T) {
+	const n, quorum, rounds = 4, 3, 5
+	clock := tests.NewManualClock(time.Now())
+	engines, stop := startEngines(t, n, quorum, func(_ int, eng *Engine) { eng.Clock = clock })
+	defer stop()
+	clock.WaitTimers(2 * n) // checkpoint routines and garbage collectors
+
+	set := func(key string, timeout time.Duration) *Query {
+		q := NewQuery()
+		q.Deadline, _ = ptypes.TimestampProto(clock.Now().Add(timeout))
+		q.Operations = []*Operation{{Key: key, Op: Operation_SET, Data: []byte(q.Uuid)}}
+		return q
+	}
+
+	// In each round, two conflicting queries are held back on every node by
+	// a query endorsed locally, until it expires: the nodes then endorse
+	// the same one, instead of the one they received first, and both are
+	// eventually committed.
+	first := make([]*Query, rounds)
+	second := make([]*Query, rounds)
+	for i := range first {
+		key := fmt.Sprint("k", i)
+		blocker := set(key, 300*time.Millisecond)
+		for _, eng := range engines {
+			eng.qs.AddQuery(blocker)
+			eng.qs.Endorse(blocker.Uuid)
+		}
+
+		first[i], second[i] = set(key, time.Minute), set(key, time.Minute)
+		if second[i].Precedes(first[i]) {
+			first[i], second[i] = second[i], first[i]
+		}
+
+		// The later query is received first by some nodes
+		require.Nil(t, engines[i%n].Submit(second[i]))
+		require.Nil(t, engines[(i+1)%n].Submit(first[i]))
+	}
+
+	committed := func(eng *Engine, q *Query) bool {
+		s, _ := eng.QueryStatus(q.Uuid)
+		return s.State == StateCommitted && s.Applied
+	}
+	settled := func() bool {
+		for _, eng := range engines {
+			for i := range second {
+				if !committed(eng, second[i]) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	for i := 0; !settled() && i < 100; i++ {
+		clock.Advance(100 * time.Millisecond)
+		clock.WaitTimers(2 * n)
+		for j := 0; !settled() && j < 10; j++ {
+			time.Sleep(10 * time.Millisecond) // messages are delivered in real time
+		}
+	}
+
+	for _, eng := range engines {
+		for i := range first {
+			require.True(t, committed(eng, first[i]), "the preceding query should be committed")
+			require.True(t, committed(eng, second[i]), "the other query should be committed after it")
+
+			eng.Store.Lock()
+			value, _, err := eng.Store.Get(fmt.Sprint("k", i))
+			eng.Store.Unlock()
+			require.Nil(t, err)
+			require.Equal(t, second[i].Uuid, string(value))
+		}
+	}
+}
+
+func TestEngine_Withdrawal(t *testing.T) {
+	const n, quorum = 4, 3
+	keyrings := tests.GetTestKeyRings(t, n)
+	clock := tests.NewManualClock(time.Now())
+
+	set := func(timeout time.Duration) *Query {
+		q := NewQuery()
+		q.Deadline, _ = ptypes.TimestampProto(clock.Now().Add(timeout))
+		q.Operations = []*Operation{{Key: "k", Op: Operation_SET, Data: []byte(q.Uuid)}}
+		return q
+	}
+	expiring, next := set(300*time.Millisecond), set(time.Minute)
+
+	// Every node endorses the expiring query, but the endorsements are lost
+	h := &hub{}
+	engines := make([]*Engine, n)
+	for i := range engines {
+		network := &lossyHubNetwork{hubNetwork: h.join(), drop: func(m proto.Message) bool {
+			e, ok := m.(*Endorsement)
+			return ok && e.Uuid == expiring.Uuid
+		}}
+		engines[i] = NewEngine(newMemoryStore(), network, passBBC{}, keyrings[i], quorum)
+		engines[i].Clock = clock
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	start := clock.Now()
+	for _, eng := range engines {
+		require.Nil(t, eng.Run(ctx))
+	}
+	h.waitSubscribers(t, 4*n) // queries, endorsements, checkpoints and node statuses
+	clock.WaitTimers(2 * n)   // checkpoint routines and garbage collectors
+
+	require.Nil(t, engines[0].Submit(expiring))
+	for _, eng := range engines {
+		eventually(t, func() bool { return eng.qs.GetQuery(expiring.Uuid) != nil }, "query should be received")
+	}
+	require.Nil(t, engines[1].Submit(next))
+
+	// The next query is endorsed on the condition that the expiring one is
+	// dropped, which is checkpointed as soon as it is old, before the first
+	// batch of checkpoints is due.
+	committed := func(eng *Engine, q *Query) bool {
+		s, _ := eng.QueryStatus(q.Uuid)
+		return s.State == StateCommitted && s.Applied
+	}
+	settled := func() bool {
+		for _, eng := range engines {
+			if !committed(eng, next) {
+				return false
+			}
+		}
+		return true
+	}
+	for !settled() && clock.Now().Sub(start) < checkpointRoutineTimeout-100*time.Millisecond {
+		clock.Advance(100 * time.Millisecond)
+		clock.WaitTimers(2 * n)
+		for j := 0; !settled() && j < 10; j++ {
+			time.Sleep(10 * time.Millisecond) // messages are delivered in real time
+		}
+	}
+
+	for _, eng := range engines {
+		require.True(t, committed(eng, next), "the next query should be committed promptly")
+		s, _ := eng.QueryStatus(expiring.Uuid)
+		require.Equal(t, StateDropped, s.State)
+	}
+}
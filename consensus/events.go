@@ -0,0 +1,203 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultEventBuffer is the number of events buffered for each subscriber,
+// see Engine.Events.
+const DefaultEventBuffer = 1024
+
+// EventType is the type of an EngineEvent.
+type EventType string
+
+// Available event types
+const (
+	EventQueryReceived     EventType = "query_received"     // a query has been inserted in the query store, including the queries submitted locally
+	EventEndorsed          EventType = "endorsed"           // an endorsement of a query has been received, possibly within an aggregate
+	EventCommitted         EventType = "committed"          // a query has been committed, it is applied right after
+	EventApplied           EventType = "applied"            // a committed query has been written to the store, or failed to (see Err)
+	EventDropped           EventType = "dropped"            // a query has been dropped
+	EventCheckpointStarted EventType = "checkpoint_started" // the node has started to decide a checkpoint
+	EventCheckpointDecided EventType = "checkpoint_decided" // a checkpoint has been decided, its queries are dropped if Decision is true
+	EventRecoveryDone      EventType = "recovery_done"      // a key has been recovered from the peers
+	EventKeysExpired       EventType = "keys_expired"       // expired keys have been removed from the store
+)
+
+// EngineEvent describes a step of the lifecycle of the queries, checkpoints
+// and keys of an engine. Only the fields relevant to its type are set.
+type EngineEvent struct {
+	Type       EventType
+	Time       time.Time  // local time of the event
+	Uuid       string     // of the query
+	Emitter    string     // of the query or of the endorsement
+	Checkpoint string     // identifier of the checkpoint
+	Queries    []string   // of the checkpoint
+	Decision   bool       // of the checkpoint
+	Keys       []string   // written by an applied query, removed once expired, or recovered
+	Versions   []*Version // of Keys, NoVersion for the deleted ones
+	Err        error      // set when a committed query could not be applied
+	Dropped    uint64     // events of the subscriber dropped since the previous one delivered
+}
+
+type eventSubscriber struct {
+	c       chan EngineEvent
+	dropped uint64
+}
+
+// eventListener is called synchronously for each event, and must neither
+// block nor acquire any lock.
+type eventListener struct {
+	handle func(EngineEvent)
+}
+
+// eventTracker dispatches the events of the engine to its subscribers and
+// listeners.
+//
+// eventTracker is thread-safe.
+type eventTracker struct {
+	mutex       sync.Mutex
+	subscribers map[*eventSubscriber]struct{}
+	listeners   map[*eventListener]struct{}
+	dropped     uint64 // by every subscriber, since the start
+}
+
+func (t *eventTracker) subscribe(buffer int) *eventSubscriber {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	s := &eventSubscriber{c: make(chan EngineEvent, buffer)}
+	if t.subscribers == nil {
+		t.subscribers = make(map[*eventSubscriber]struct{})
+	}
+	t.subscribers[s] = struct{}{}
+	return s
+}
+
+func (t *eventTracker) unsubscribe(s *eventSubscriber) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.subscribers, s)
+	close(s.c)
+}
+
+// listen registers a listener, and returns the function removing it.
+func (t *eventTracker) listen(handle func(EngineEvent)) func() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	l := &eventListener{handle: handle}
+	if t.listeners == nil {
+		t.listeners = make(map[*eventListener]struct{})
+	}
+	t.listeners[l] = struct{}{}
+
+	return func() {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		delete(t.listeners, l)
+	}
+}
+
+// publish calls the listeners, and sends the event to the subscribers
+// without blocking: the event is dropped for the subscribers whose buffer
+// is full.
+func (t *eventTracker) publish(ev EngineEvent) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for l := range t.listeners {
+		l.handle(ev)
+	}
+
+	for s := range t.subscribers {
+		ev.Dropped = s.dropped
+		select {
+		case s.c <- ev:
+			s.dropped = 0
+		default:
+			if s.dropped == 0 {
+				zap.L().Warn("EventOverflow",
+					zap.String("type", string(ev.Type)),
+					zap.Int("buffer", cap(s.c)),
+				)
+			}
+			s.dropped++
+			t.dropped++
+		}
+	}
+}
+
+// emit dispatches an event to the watchers of the keys it writes, to the
+// listeners and subscribers of the events, and signals the ActivityProbe.
+// Since the event is handled synchronously, it can be emitted while holding
+// any lock of the engine.
+func (eng *Engine) emit(ev EngineEvent) {
+	if ev.Time.IsZero() {
+		ev.Time = eng.clock().Now()
+	}
+
+	switch ev.Type {
+	case EventApplied:
+		eng.watches.publish(ev.Uuid, ev.Keys, ev.Versions)
+	case EventKeysExpired:
+		eng.watches.publish("", ev.Keys, ev.Versions)
+	}
+
+	eng.events.publish(ev)
+	eng.markActive()
+}
+
+// emitDecisions emits the events of the queries committed and dropped.
+func (eng *Engine) emitDecisions(decisions []decision) {
+	for _, d := range decisions {
+		t := EventDropped
+		if d.committed {
+			t = EventCommitted
+		}
+		eng.emit(EngineEvent{Type: t, Time: d.resolved, Uuid: d.uuid})
+	}
+}
+
+// Events returns a channel receiving the events of the engine, until ctx is
+// done. The channel is then closed.
+//
+// Events are buffered (see EventBuffer), and dropped when the buffer is full
+// rather than slowing down the engine; the number of events dropped before
+// an event is reported by its Dropped field.
+// This function is thread-safe.
+func (eng *Engine) Events(ctx context.Context) <-chan EngineEvent {
+	buffer := eng.EventBuffer
+	if buffer <= 0 {
+		buffer = DefaultEventBuffer
+	}
+
+	s := eng.events.subscribe(buffer)
+	go func() {
+		<-ctx.Done()
+		eng.events.unsubscribe(s)
+	}()
+	return s.c
+}
+
+// DroppedEvents returns the number of events dropped because of slow
+// subscribers, since the start of the engine.
+// This function is thread-safe.
+func (eng *Engine) DroppedEvents() uint64 {
+	eng.events.mutex.Lock()
+	defer eng.events.mutex.Unlock()
+	return eng.events.dropped
+}
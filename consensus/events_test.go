@@ -0,0 +1,146 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_Events(t *testing.T) {
+	engines, stop := startEngines(t, 1, 1)
+	defer stop()
+	eng := engines[0]
+
+	ectx, ecancel := context.WithCancel(context.Background())
+	events := eng.Events(ectx)
+
+	q := concat("k", "a", nil)
+	q.SetTimeout(time.Minute)
+	s, err := eng.SubmitAndWait(context.Background(), q)
+	require.Nil(t, err)
+	require.True(t, s.Applied)
+	select {
+	case <-eng.ActivityProbe:
+	default:
+		require.FailNow(t, "events should signal the activity probe")
+	}
+
+	var types []EventType
+	for len(types) < 4 {
+		ev := <-events
+		require.Equal(t, q.Uuid, ev.Uuid)
+		require.False(t, ev.Time.IsZero())
+		require.Zero(t, ev.Dropped)
+		types = append(types, ev.Type)
+
+		switch ev.Type {
+		case EventQueryReceived, EventEndorsed:
+			require.Equal(t, eng.Identity(), ev.Emitter)
+		case EventApplied:
+			require.Nil(t, ev.Err)
+			require.Equal(t, []string{"k"}, ev.Keys)
+			require.Nil(t, ev.Versions[0].Matches(NewVersion([]byte("a"))))
+		}
+	}
+	require.Equal(t, []EventType{EventQueryReceived, EventEndorsed, EventCommitted, EventApplied}, types)
+
+	ecancel()
+	for range events { // closed once the context is done
+	}
+}
+
+func TestEngine_EventsInserted(t *testing.T) {
+	keyrings := tests.GetTestKeyRingsCrypto(t, 3, "bls12381")
+	signers := make([]*Engine, len(keyrings))
+	for i, kr := range keyrings {
+		signers[i] = NewEngine(nil, nil, nil, kr, 3)
+	}
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Emitter = keyrings[1].Identity()
+	require.Nil(t, signers[1].signQuery(q))
+	endorsements := make([]*Endorsement, 2)
+	for i := range endorsements {
+		endorsements[i] = &Endorsement{Uuid: q.Uuid, Emitter: keyrings[i].Identity()}
+		require.Nil(t, signers[i].signEndorsement(endorsements[i]))
+	}
+	a := signers[0].aggregateProofs([]*Proof{
+		{Content: &Proof_Endorsement{endorsements[0]}},
+		{Content: &Proof_Endorsement{endorsements[1]}},
+	})[0].GetAggregate()
+	require.NotNil(t, a)
+
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, passBBC{}, keyrings[2], 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := eng.Events(ctx)
+	next := func() EngineEvent {
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(time.Second):
+			require.FailNow(t, "an event should have been emitted")
+			return EngineEvent{}
+		}
+	}
+
+	// Only the endorsements inserted from an aggregate are reported
+	eng.qs.AddQuery(q)
+	eng.handleEndorsement(endorsements[0])
+	eng.handleAggregate(a)
+	for _, e := range endorsements {
+		ev := next()
+		require.Equal(t, EventEndorsed, ev.Type)
+		require.Equal(t, e.Emitter, ev.Emitter)
+	}
+
+	// Queries committed without being received cannot be applied
+	uuid := NewQuery().Uuid
+	require.Exactly(t, ErrUnknownQuery, eng.apply(uuid))
+	ev := next()
+	require.Equal(t, EventApplied, ev.Type)
+	require.Equal(t, uuid, ev.Uuid)
+	require.Exactly(t, ErrUnknownQuery, ev.Err)
+}
+
+func TestEngine_EventsDropped(t *testing.T) {
+	qs := newQueryStore()
+	e := &Engine{Store: newMemoryStore(), qs: qs, EventBuffer: 1}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := e.Events(ctx)
+
+	// Queries dropped by a checkpoint or an administrator are reported once
+	// their decision is flushed
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	qs.AddQuery(q)
+	require.True(t, qs.DropPending(q.Uuid))
+	e.flushDecisions()
+
+	ev := <-events
+	require.Equal(t, EventDropped, ev.Type)
+	require.Equal(t, q.Uuid, ev.Uuid)
+
+	// Slow subscribers never block the engine
+	for i := 0; i < 3; i++ {
+		e.emit(EngineEvent{Type: EventRecoveryDone, Keys: []string{"k"}})
+	}
+	require.Zero(t, (<-events).Dropped)
+	require.EqualValues(t, 2, e.DroppedEvents())
+
+	e.emit(EngineEvent{Type: EventRecoveryDone, Keys: []string{"k"}})
+	require.EqualValues(t, 2, (<-events).Dropped, "the next event must report the dropped ones")
+}
@@ -0,0 +1,119 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package consensus
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultExpirySweepPeriod is the interval between two removals of expired
+// keys, unless Engine.ExpirySweepPeriod is set.
+const DefaultExpirySweepPeriod = time.Minute
+
+// ErrExpired is returned when reading a key whose value has expired.
+var ErrExpired = errors.New("key expired")
+
+// GetLive returns the value and the version stored for a key, like the Get
+// method of the store, unless the value has expired: it is then reported as
+// missing, with NoVersion and ErrExpired. Expiry is checked against the
+// cluster time, if any.
+func (eng *Engine) GetLive(key string) ([]byte, *Version, error) {
+	return eng.getAt(key, eng.now())
+}
+
+// getAt reads a key as seen at time t, see GetLive.
+func (eng *Engine) getAt(key string, t time.Time) ([]byte, *Version, error) {
+	value, v, err := eng.get(key)
+	if err == nil && v.ExpiredAt(t) {
+		return nil, NoVersion, ErrExpired
+	}
+	return value, v, err
+}
+
+// expiryWorker periodically removes the expired keys from the store.
+func (eng *Engine) expiryWorker(ctx context.Context) {
+	period := eng.ExpirySweepPeriod
+	if period == 0 {
+		period = DefaultExpirySweepPeriod
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			removed, err := eng.sweepExpired()
+			if err != nil {
+				zap.L().Warn("ExpirySweep", zap.Error(err))
+			} else if len(removed) > 0 {
+				zap.L().Debug("ExpirySweep", zap.Strings("keys", removed))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sweepExpired deletes the keys which have expired for every query that may
+// still be applied, and returns them sorted. Each node removes them on its
+// own, without consensus: queries already see them as missing from the
+// deadline of their transaction (see Query.Execute), hence the deletion is
+// not observable as long as no query with an earlier deadline remains to be
+// applied. Removed keys are reported to watchers with NoVersion, and without
+// query UUID.
+func (eng *Engine) sweepExpired() ([]string, error) {
+	horizon := eng.now()
+	if oldest, ok := eng.qs.OldestUnsettled(); ok && oldest.Before(horizon) {
+		horizon = oldest
+	}
+
+	eng.Store.Lock()
+	defer eng.Store.Unlock()
+
+	list, err := eng.Store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for key, v := range list {
+		if v.ExpiredAt(horizon) && !IsLocalKey(key) {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+
+	before := make(map[string]int, len(removed))
+	after := make(map[string]int, len(removed))
+	versions := make([]*Version, len(removed))
+	for i, key := range removed {
+		data, _, _ := eng.Store.Get(key)
+		if err = eng.Store.Delete(key); err != nil {
+			removed = removed[:i]
+			break
+		}
+
+		before[key] = len(data)
+		after[key] = 0
+		versions[i] = NoVersion
+	}
+
+	eng.quotas.update(before, after)
+	eng.retention.forget(removed)
+	if len(removed) > 0 {
+		eng.overlay.reset()
+		eng.emit(EngineEvent{Type: EventKeysExpired, Keys: removed, Versions: versions[:len(removed)]})
+	}
+	return removed, err
+}
@@ -0,0 +1,120 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_Expiry(t *testing.T) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	store := newMemoryStore()
+	h := &hub{}
+	eng := NewEngine(store, h.join(), passBBC{}, kr, 1)
+	eng.ExpirySweepPeriod = -1 // swept by the test
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.Nil(t, eng.Run(ctx))
+	h.waitSubscribers(t, 4) // queries, endorsements, checkpoints and node statuses
+
+	expired := func(key, value string) {
+		v := NewVersion([]byte(value))
+		v.setExpiry(time.Now().Add(-time.Second))
+		store.Lock()
+		defer store.Unlock()
+		require.Nil(t, store.Set(key, []byte(value), v))
+	}
+
+	commit := func(ops ...*Operation) *Query {
+		q := NewQuery()
+		q.SetTimeout(time.Minute)
+		q.Operations = ops
+		s, err := eng.SubmitAndWait(ctx, q)
+		require.Nil(t, err)
+		require.True(t, s.Applied)
+		return q
+	}
+
+	q := commit(&Operation{Key: "lock", Op: Operation_SET, Data: []byte("alice"), Ttl: ptypes.DurationProto(time.Hour)})
+	value, v, err := eng.GetLive("lock")
+	require.Nil(t, err)
+	require.Equal(t, "alice", string(value))
+	require.True(t, v.ExpiryTime().Equal(q.DeadlineTime().Add(time.Hour)))
+
+	t.Run("read", func(t *testing.T) {
+		expired("stale", "old")
+		_, v, err := eng.GetLive("stale")
+		require.Equal(t, ErrExpired, err)
+		require.Equal(t, NoVersion, v)
+
+		versions, err := eng.Snapshot([]string{"stale", "lock"})
+		require.Nil(t, err)
+		require.Empty(t, versions["stale"].Hash)
+		require.Nil(t, versions["lock"].Matches(NewVersion([]byte("alice"))))
+
+		// Requirements are checked at the deadline of the query
+		q := NewQuery()
+		q.Requirements["lock"] = versions["lock"]
+		q.SetTimeout(time.Minute)
+		require.True(t, eng.canEndorse(q))
+		q.SetTimeout(2 * time.Hour)
+		require.False(t, eng.canEndorse(q))
+	})
+
+	t.Run("write", func(t *testing.T) {
+		expired("stale", "old")
+		commit(&Operation{Key: "stale", Op: Operation_CONCAT, Data: []byte("new")})
+		value, v, err := eng.GetLive("stale")
+		require.Nil(t, err)
+		require.Equal(t, "new", string(value))
+		require.Nil(t, v.Expiry)
+
+		q := commit(&Operation{Key: "stale", Op: Operation_EXPIRE, Data: []byte("1m")})
+		_, v, err = eng.GetLive("stale")
+		require.Nil(t, err)
+		require.True(t, v.ExpiryTime().Equal(q.DeadlineTime().Add(time.Minute)))
+	})
+
+	t.Run("sweep", func(t *testing.T) {
+		updates := eng.Watch(ctx, "gone")
+		expired("gone", "old")
+
+		// Not removed while an earlier query may still read it
+		pending := NewQuery()
+		pending.Deadline, _ = ptypes.TimestampProto(time.Now().Add(-time.Hour))
+		require.True(t, eng.qs.AddQuery(pending))
+		removed, err := eng.sweepExpired()
+		require.Nil(t, err)
+		require.Empty(t, removed)
+
+		require.True(t, eng.qs.DropPending(pending.Uuid))
+		removed, err = eng.sweepExpired()
+		require.Nil(t, err)
+		require.Equal(t, []string{"gone"}, removed)
+
+		store.Lock()
+		_, v, _ := store.Get("gone")
+		store.Unlock()
+		require.Equal(t, NoVersion, v)
+
+		u := <-updates
+		require.Equal(t, "gone", u.Key)
+		require.Empty(t, u.Uuid)
+		require.Equal(t, NoVersion, u.Version)
+
+		_, v, err = eng.GetLive("lock")
+		require.Nil(t, err, "live keys are kept")
+	})
+}
@@ -0,0 +1,70 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"github.com/bluele/gcache"
+)
+
+// DefaultHashCacheSize is the number of hashes memoized by the engine.
+const DefaultHashCacheSize = 1024
+
+// Hasher is implemented by signed messages, whose hash covers everything but
+// their signature.
+type Hasher interface {
+	Hash() ([]byte, error)
+}
+
+// HashCache memoizes the hashes of signed messages, so that a message signed
+// then verified, or verified several times, is marshalled only once. Hashes
+// are keyed by the pointer of their message: messages must not be modified
+// once hashed unless invalidated, which only happens before signing them.
+// Since the cache holds references to the last messages hashed, its size
+// should remain small.
+// A nil HashCache computes every hash.
+// This type is thread-safe.
+type HashCache struct {
+	cache gcache.Cache
+}
+
+// NewHashCache returns a cache holding the hashes of the last size messages.
+func NewHashCache(size int) *HashCache {
+	return &HashCache{
+		cache: gcache.New(size).LRU().Build(),
+	}
+}
+
+// Hash returns the hash of m, which must be a pointer.
+func (hc *HashCache) Hash(m Hasher) ([]byte, error) {
+	if hc == nil {
+		return m.Hash()
+	}
+
+	if hash, err := hc.cache.GetIFPresent(m); err == nil {
+		return hash.([]byte), nil
+	}
+
+	hash, err := m.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	_ = hc.cache.Set(m, hash)
+	return hash, nil
+}
+
+// Invalidate marks the hash of m as dirty, so that it is computed again on
+// next use. It must be called whenever m is modified after being hashed.
+func (hc *HashCache) Invalidate(m Hasher) {
+	if hc == nil {
+		return
+	}
+
+	hc.cache.Remove(m)
+}
@@ -0,0 +1,82 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestHashCache(t *testing.T) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, nil, kr, 1)
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Emitter = kr.Identity()
+	q.Operations = []*Operation{{Key: "k", Op: Operation_SET, Data: []byte("a")}}
+	require.Nil(t, eng.signQuery(q))
+	require.Nil(t, eng.verifyQuery(q))
+
+	// Modified before being signed again
+	q.Operations[0].Data = []byte("b")
+	require.Nil(t, eng.signQuery(q))
+	require.Nil(t, eng.verifyQuery(q))
+	expected, err := q.Hash()
+	require.Nil(t, err)
+	hash, err := eng.hashes.Hash(q)
+	require.Nil(t, err)
+	require.Equal(t, expected, hash)
+
+	// A copy with the same UUID and signature but other operations is hashed on its own
+	forged := *q
+	forged.Operations = []*Operation{{Key: "k", Op: Operation_SET, Data: []byte("c")}}
+	require.NotNil(t, eng.verifyQuery(&forged))
+
+	e := &Endorsement{Uuid: q.Uuid, Emitter: kr.Identity()}
+	require.Nil(t, eng.signEndorsement(e))
+	e.Conditions = []string{"other"}
+	require.Nil(t, eng.signEndorsement(e))
+	require.Nil(t, eng.verifyEndorsement(e))
+
+	var nilCache *HashCache
+	hash, err = nilCache.Hash(q)
+	require.Nil(t, err)
+	require.Equal(t, expected, hash)
+	nilCache.Invalidate(q)
+}
+
+// BenchmarkEngine_verifyQuery measures the verification of a large query
+// already verified once, which only costs its signature check.
+func BenchmarkEngine_verifyQuery(b *testing.B) {
+	kr := tests.GetTestKeyRings(b, 1)[0]
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, nil, kr, 1)
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Emitter = kr.Identity()
+	q.Operations = []*Operation{{Key: "k", Op: Operation_SET, Data: make([]byte, 4<<20)}}
+	require.Nil(b, eng.signQuery(q))
+
+	b.Run("Cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			require.Nil(b, eng.verifyQuery(q))
+		}
+	})
+
+	b.Run("Uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			eng.hashes.Invalidate(q)
+			require.Nil(b, eng.verifyQuery(q))
+		}
+	})
+}
@@ -0,0 +1,56 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package consensus
+
+import (
+	"bytes"
+	"crypto/sha512"
+
+	"go.uber.org/zap"
+)
+
+// ErrCorruptValue is returned by stores verifying their reads when the value
+// stored for a key does not match its version, see VerifyValue.
+type ErrCorruptValue struct {
+	Key string
+}
+
+// Error returns error's string value.
+func (e ErrCorruptValue) Error() string {
+	return "corrupt value for key " + e.Key + ": it does not match its version"
+}
+
+// VerifyValue returns ErrCorruptValue if value does not match the hash of
+// its version v. Missing keys and versions without hash are not checked.
+func VerifyValue(key string, value []byte, v *Version) error {
+	if v == nil || v == NoVersion || len(v.Hash) == 0 {
+		return nil
+	}
+
+	h := sha512.Sum512(value)
+	if !bytes.Equal(h[:], v.Hash) {
+		return ErrCorruptValue{Key: key}
+	}
+	return nil
+}
+
+// get returns the value and the version stored for a key, like the Get
+// method of the store. Corrupt values are queued for recovery from the
+// peers, unless they are local to the node.
+func (eng *Engine) get(key string) ([]byte, *Version, error) {
+	value, v, err := eng.Store.Get(key)
+	if _, ok := err.(ErrCorruptValue); ok {
+		zap.L().Error("CorruptValue", zap.String("key", key))
+		if !IsLocalKey(key) {
+			if err := eng.Recover(key); err != nil {
+				zap.L().Warn("Recovery", zap.String("key", key), zap.Error(err))
+			}
+		}
+	}
+	return value, v, err
+}
@@ -0,0 +1,66 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package consensus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+// verifyingStore is a memoryStore verifying every read.
+type verifyingStore struct {
+	*memoryStore
+}
+
+func (s verifyingStore) Get(key string) ([]byte, *Version, error) {
+	value, v, err := s.memoryStore.Get(key)
+	if err == nil {
+		err = VerifyValue(key, value, v)
+	}
+	return value, v, err
+}
+
+func TestVerifyValue(t *testing.T) {
+	require.Nil(t, VerifyValue("a", []byte("a"), NewVersion([]byte("a"))))
+	require.Nil(t, VerifyValue("a", nil, NoVersion))
+	require.Nil(t, VerifyValue("a", []byte("a"), &Version{}))
+	require.Exactly(t, ErrCorruptValue{Key: "a"}, VerifyValue("a", []byte("b"), NewVersion([]byte("a"))))
+}
+
+func TestEngine_CorruptValue(t *testing.T) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	network := &recoveryNetwork{}
+	store := verifyingStore{newMemoryStore()}
+	eng := NewEngine(store, network, nil, kr, 1)
+
+	local := appliedMarkerKey("uuid")
+	for _, key := range []string{"a", local} {
+		require.Nil(t, store.Set(key, []byte("value"), NewVersion([]byte("value"))))
+		store.values[key] = []byte("valuf") // bit rot
+	}
+
+	_, _, err := eng.GetLive("a")
+	require.Exactly(t, ErrCorruptValue{Key: "a"}, err)
+	require.Equal(t, 1, eng.RecoveryStatus().Pending, "corrupt values should be recovered")
+
+	_, _, err = eng.get(local)
+	require.Exactly(t, ErrCorruptValue{Key: local}, err)
+	require.Equal(t, 1, eng.RecoveryStatus().Pending, "local keys cannot be recovered")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.Nil(t, eng.Run(ctx))
+
+	waitRecovery(t, eng, "corrupt value should be recovered", func(r RecoveryReport) bool {
+		return r.Successes == 1
+	})
+	require.True(t, recovered(t, eng, "a"))
+}
@@ -10,6 +10,7 @@ package consensus
 
 import (
 	"context"
+	"errors"
 	"io"
 	"sync"
 
@@ -27,18 +28,68 @@ type Store interface {
 	Set(key string, value []byte, version *Version) error
 	// SetBatch executes the given "Set" operations in a atomic way.
 	SetBatch(keys []string, values [][]byte, versions []*Version) error
+	// Delete removes the specified key, if it exists.
+	Delete(key string) error
 	// List returns the map of keys with their values.
 	List() (map[string]*Version, error)
 }
 
+// BatchDeleter is an interface that can optionally be proposed by Stores to
+// remove keys in the same atomic batch as "Set" operations.
+type BatchDeleter interface {
+	// UpdateBatch executes the given "Set" operations, and removes the deleted
+	// keys, in an atomic way.
+	UpdateBatch(keys []string, values [][]byte, versions []*Version, deleted []string) error
+}
+
+// UpdateBatch executes the given "Set" operations and removes the deleted keys
+// from s, atomically if s is a BatchDeleter. Otherwise, the keys are removed
+// one by one before the "Set" operations are executed.
+func UpdateBatch(s Store, keys []string, values [][]byte, versions []*Version, deleted []string) error {
+	if b, ok := s.(BatchDeleter); ok {
+		return b.UpdateBatch(keys, values, versions, deleted)
+	}
+
+	for _, key := range deleted {
+		if err := s.Delete(key); err != nil {
+			return err
+		}
+	}
+	return s.SetBatch(keys, values, versions)
+}
+
 // Network is the interface network adapters must implement.
 type Network interface {
 	io.Closer
 
-	Broadcast(m proto.Message) error
+	// Broadcast sends a message to every node, on a best-effort basis: it
+	// returns once the message is handed to the transport, which does not
+	// guarantee its delivery, or with the error of ctx if the transport is
+	// not ready before ctx is done.
+	Broadcast(ctx context.Context, m proto.Message) error
+
+	// Accept returns a channel delivering the incoming messages for which
+	// acceptor returns true. Each message accepted is delivered at most once
+	// per channel, and acceptor must not block.
+	//
+	// Receiving messages never waits for the consumers: each channel buffers a
+	// bounded number of messages (DefaultAcceptBuffer unless configured
+	// otherwise), and the messages received while it is full are dropped,
+	// counted and reported by AcceptDrops if the network is an AcceptDropper.
+	//
+	// The channel is closed promptly once ctx is done or the network closed,
+	// possibly before its buffered messages are read; no goroutine of the
+	// subscription is left behind afterwards.
 	Accept(ctx context.Context, acceptor MessageAcceptor) <-chan proto.Message
 }
 
+// AcceptDropper is an interface that can optionally be proposed by Networks to
+// report the number of incoming messages dropped because an Accept channel was
+// full, since the network was created.
+type AcceptDropper interface {
+	AcceptDrops() uint64
+}
+
 // RecoveryManager is a interface that can optionally be proposed by Networks for
 // key recovery support (after a crash or network partition).
 type RecoveryManager interface {
@@ -49,10 +100,38 @@ type RecoveryManager interface {
 // RecoveryHandler is a callback used by the RecoveryManager.
 type RecoveryHandler func(*RecoveryRequest) (*RecoveryResponse, error)
 
+// PendingSyncManager is a interface that can optionally be proposed by Networks
+// to fetch the queries still pending at some peers, so that a node missing some
+// broadcasts (after a crash or network partition) can still endorse them.
+// Peers are designated by opaque identifiers.
+type PendingSyncManager interface {
+	PendingSyncPeers() []string
+	RequestPendingSync(ctx context.Context, peer string, req *PendingSyncRequest) (*PendingSyncResponse, error)
+	AcceptPendingSync(ctx context.Context, handler PendingSyncHandler)
+}
+
+// PendingSyncHandler is a callback used by the PendingSyncManager.
+type PendingSyncHandler func(*PendingSyncRequest) (*PendingSyncResponse, error)
+
 // MessageAcceptor is a filter that can be used to filter incoming proto messages.
 type MessageAcceptor func(proto.Message) bool
 
+// ErrBBCTimeout is returned by BBC engines which reached no decision in time,
+// usually because too few peers answered.
+var ErrBBCTimeout = errors.New("no decision reached by the binary consensus in time")
+
 // BBCEngine is the interface for binary Byzantine consensus engine.
 type BBCEngine interface {
 	Execute(context.Context, string, bool, []*Proof) (bool, []*Proof, error)
 }
+
+// ProofVerifier checks the proofs of a veto received during a checkpoint.
+type ProofVerifier func([]*Proof) error
+
+// VerifyingBBCEngine is a BBCEngine able to check the proofs of the vetoes it
+// receives: vetoes whose proofs are rejected by the verifier are ignored.
+// The engine uses ExecuteVerified instead of Execute when available.
+type VerifyingBBCEngine interface {
+	BBCEngine
+	ExecuteVerified(context.Context, string, bool, []*Proof, ProofVerifier) (bool, []*Proof, error)
+}
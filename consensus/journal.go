@@ -0,0 +1,416 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"go.uber.org/zap"
+)
+
+// Journal errors
+var (
+	ErrJournalClosed = errors.New("journal is closed")
+	ErrJournalGap    = errors.New("requested events have been discarded by journal retention")
+	ErrNoCertificate = errors.New("no certificate retained by journal for this query")
+)
+
+// JournalPrefix is the prefix of the keys keeping the journal consistent with
+// the store. These keys are local to the node.
+//
+// The commit event of a query is written in the same batch as the values of
+// the query, before being appended to the journal: if the node stops in
+// between, the event is appended when the engine is started again, and skipped
+// by Append if it had been appended already.
+const JournalPrefix = "_journal/"
+
+const journalPendingKey = JournalPrefix + "pending"
+
+// Journal is a bounded, append-only, on-disk log of commit events.
+//
+// Every commit applied by the local engine is appended to the journal with a
+// strictly increasing sequence number, specific to the local node. Consumers
+// are expected to persist the sequence number of the last event they have
+// processed, and to resume from it with Replay after a restart: this gives
+// an at-least-once delivery guarantee, as long as the events have not been
+// discarded by the retention policy in the meantime (see ErrJournalGap).
+//
+// Retention is bounded by MaxSize (in bytes) and MaxAge. The most recent
+// event is always kept, so that the sequence survives restarts.
+//
+// Journal is thread-safe.
+type Journal struct {
+	MaxSize int64
+	MaxAge  time.Duration
+
+	path   string
+	mutex  sync.Mutex
+	file   *os.File
+	size   int64
+	events []journalEntry // retained events, in sequence order
+	last   uint64
+	notify chan struct{} // closed and replaced at each append
+}
+
+type journalEntry struct {
+	event *CommitEvent
+	size  int64
+}
+
+// OpenJournal opens (or creates) the journal stored at path.
+// A partially written record at the end of the file, which may be left
+// by a crash, is discarded.
+func OpenJournal(path string, maxSize int64, maxAge time.Duration) (*Journal, error) {
+	j := &Journal{
+		MaxSize: maxSize,
+		MaxAge:  maxAge,
+		path:    path,
+		notify:  make(chan struct{}),
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	valid, err := j.load(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	err = f.Truncate(valid)
+	if err == nil {
+		_, err = f.Seek(valid, io.SeekStart)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	j.file = f
+	j.size = valid
+	return j, nil
+}
+
+// load reads every complete record from r, and returns the offset of the
+// end of the last complete record.
+func (j *Journal) load(r io.Reader) (int64, error) {
+	br := bufio.NewReader(r)
+	var offset int64
+	for {
+		l, err := binary.ReadUvarint(br)
+		if err != nil {
+			return offset, nil // clean or torn end of file
+		}
+
+		data := make([]byte, l)
+		_, err = io.ReadFull(br, data)
+		if err != nil {
+			return offset, nil // torn record
+		}
+
+		event := &CommitEvent{}
+		err = proto.Unmarshal(data, event)
+		if err != nil {
+			return offset, err
+		}
+
+		size := int64(uvarintSize(l)) + int64(l)
+		offset += size
+		j.events = append(j.events, journalEntry{event: event, size: size})
+		j.last = event.Sequence
+	}
+}
+
+func uvarintSize(x uint64) int {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return binary.PutUvarint(buf, x)
+}
+
+func encodeEntry(event *CommitEvent) ([]byte, error) {
+	data, err := proto.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(data))
+	n := binary.PutUvarint(buf, uint64(len(data)))
+	return append(buf[:n], data...), nil
+}
+
+// Append assigns the next sequence number to the event and persists it. An
+// event which already holds a sequence number that has been appended is
+// skipped, see JournalPrefix.
+func (j *Journal) Append(event *CommitEvent) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.file == nil {
+		return ErrJournalClosed
+	}
+
+	if event.Sequence != 0 && event.Sequence <= j.last {
+		return nil
+	}
+	event.Sequence = j.last + 1
+	if event.Time == nil {
+		event.Time = ptypes.TimestampNow()
+	}
+
+	data, err := encodeEntry(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = j.file.Write(data)
+	if err != nil {
+		return err
+	}
+
+	err = j.file.Sync()
+	if err != nil {
+		return err
+	}
+
+	j.size += int64(len(data))
+	j.last = event.Sequence
+	j.events = append(j.events, journalEntry{event: event, size: int64(len(data))})
+
+	close(j.notify)
+	j.notify = make(chan struct{})
+
+	return j.compact()
+}
+
+// compact enforces the retention policy, rewriting the journal if needed.
+// unsafe
+func (j *Journal) compact() error {
+	drop := 0
+	size := j.size
+
+	if j.MaxAge > 0 {
+		limit := time.Now().Add(-j.MaxAge)
+		for drop < len(j.events)-1 {
+			t, err := ptypes.Timestamp(j.events[drop].event.Time)
+			if err == nil && t.After(limit) {
+				break
+			}
+			size -= j.events[drop].size
+			drop++
+		}
+	}
+
+	if j.MaxSize > 0 && size > j.MaxSize {
+		// shrink to 3/4 of the limit to avoid rewriting at each append
+		target := j.MaxSize * 3 / 4
+		for drop < len(j.events)-1 && size > target {
+			size -= j.events[drop].size
+			drop++
+		}
+	}
+
+	if drop == 0 {
+		return nil
+	}
+
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for _, e := range j.events[drop:] {
+		data, err := encodeEntry(e.event)
+		if err == nil {
+			_, err = w.Write(data)
+		}
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+
+	err = w.Flush()
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, j.path)
+	}
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	_ = j.file.Close()
+	j.file = f
+	j.size = size
+	j.events = append([]journalEntry(nil), j.events[drop:]...)
+	return nil
+}
+
+// Sequence returns the sequence number of the last appended event.
+func (j *Journal) Sequence() uint64 {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.last
+}
+
+// Replay returns every retained event whose sequence number is strictly
+// greater than since. If some of those events have already been discarded,
+// the remaining ones are returned along with ErrJournalGap.
+func (j *Journal) Replay(since uint64) ([]*CommitEvent, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	var err error
+	if since < j.last && len(j.events) > 0 && j.events[0].event.Sequence > since+1 {
+		err = ErrJournalGap
+	}
+
+	var events []*CommitEvent
+	for _, e := range j.events {
+		if e.event.Sequence > since {
+			events = append(events, e.event)
+		}
+	}
+	return events, err
+}
+
+// Certificate returns the commit certificate of a query, if its commit event
+// is still retained.
+func (j *Journal) Certificate(uuid string) (*CommitCertificate, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	for i := len(j.events) - 1; i >= 0; i-- {
+		e := j.events[i].event
+		if e.Uuid == uuid && e.Certificate != nil {
+			return e.Certificate, nil
+		}
+	}
+	return nil, ErrNoCertificate
+}
+
+// Wait blocks until an event with a sequence number strictly greater than
+// since is available, or the context is done.
+func (j *Journal) Wait(ctx context.Context, since uint64) error {
+	for {
+		j.mutex.Lock()
+		last, notify, closed := j.last, j.notify, j.file == nil
+		j.mutex.Unlock()
+
+		if last > since {
+			return nil
+		}
+		if closed {
+			return ErrJournalClosed
+		}
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Close closes the underlying file.
+func (j *Journal) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+
+	err := j.file.Close()
+	j.file = nil
+	close(j.notify)
+	j.notify = make(chan struct{})
+	return err
+}
+
+// journalEvent returns the commit event of an applied query, numbered after
+// the last event of the journal, along with its encoding to be written under
+// journalPendingKey.
+// unsafe
+func (eng *Engine) journalEvent(q *Query, keys []string, versions []*Version, endorsements []*Endorsement, aggregates []*AggregatedEndorsement) (*CommitEvent, []byte, error) {
+	var certificate *CommitCertificate
+	if eng.KeyRing != nil {
+		var err error
+		certificate, err = eng.certify(q, endorsements, aggregates)
+		if err != nil {
+			zap.L().Warn("Certificate",
+				zap.String("uuid", q.Uuid),
+				zap.Error(err),
+			)
+			certificate = nil
+		}
+	}
+
+	event := &CommitEvent{
+		Sequence:    eng.Journal.Sequence() + 1,
+		Time:        ptypes.TimestampNow(),
+		Uuid:        q.Uuid,
+		Emitter:     q.Emitter,
+		Keys:        keys,
+		Versions:    versions,
+		Certificate: certificate,
+	}
+	data, err := proto.Marshal(event)
+	return event, data, err
+}
+
+// appendJournal appends a commit event to the journal, logging failures.
+func (eng *Engine) appendJournal(event *CommitEvent) {
+	err := eng.Journal.Append(event)
+	if err != nil {
+		zap.L().Error("Journal",
+			zap.String("uuid", event.Uuid),
+			zap.Error(err),
+		)
+	}
+}
+
+// recoverJournal appends the commit event written along with the last
+// applied query, in case the node stopped before appending it to the journal.
+func (eng *Engine) recoverJournal() error {
+	if eng.Journal == nil {
+		return nil
+	}
+
+	eng.Store.Lock()
+	data, version, err := eng.Store.Get(journalPendingKey)
+	eng.Store.Unlock()
+	if version == NoVersion {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	event := &CommitEvent{}
+	err = proto.Unmarshal(data, event)
+	if err != nil {
+		return err
+	}
+	return eng.Journal.Append(event)
+}
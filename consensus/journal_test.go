@@ -0,0 +1,206 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func tempJournal(t *testing.T, maxSize int64, maxAge time.Duration) (*Journal, string, func()) {
+	dir, err := ioutil.TempDir("", "pnyxdb-journal")
+	require.Nil(t, err)
+
+	path := filepath.Join(dir, "events")
+	j, err := OpenJournal(path, maxSize, maxAge)
+	require.Nil(t, err)
+
+	return j, path, func() {
+		_ = j.Close()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestJournal_AppendReplay(t *testing.T) {
+	j, path, done := tempJournal(t, 0, 0)
+	defer done()
+
+	for i := 0; i < 10; i++ {
+		require.Nil(t, j.Append(&CommitEvent{Uuid: fmt.Sprint(i)}))
+	}
+	require.Equal(t, uint64(10), j.Sequence())
+
+	events, err := j.Replay(4)
+	require.Nil(t, err)
+	require.Len(t, events, 6)
+	for i, e := range events {
+		require.Equal(t, uint64(5+i), e.Sequence)
+		require.Equal(t, fmt.Sprint(4+i), e.Uuid)
+	}
+
+	// reopen, with a torn record at the end of the file
+	require.Nil(t, j.Close())
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.Nil(t, err)
+	_, err = f.Write([]byte{0x20, 0x01, 0x02})
+	require.Nil(t, err)
+	require.Nil(t, f.Close())
+
+	j2, err := OpenJournal(path, 0, 0)
+	require.Nil(t, err)
+	defer func() { _ = j2.Close() }()
+
+	require.Equal(t, uint64(10), j2.Sequence(), "sequence should survive restarts")
+	require.Nil(t, j2.Append(&CommitEvent{Uuid: "10"}))
+	events, err = j2.Replay(0)
+	require.Nil(t, err)
+	require.Len(t, events, 11)
+	require.Equal(t, uint64(11), events[10].Sequence)
+}
+
+func TestJournal_Retention(t *testing.T) {
+	j, path, done := tempJournal(t, 512, 0)
+	defer done()
+
+	for i := 0; i < 100; i++ {
+		require.Nil(t, j.Append(&CommitEvent{Uuid: fmt.Sprintf("%032d", i)}))
+	}
+
+	info, err := os.Stat(path)
+	require.Nil(t, err)
+	require.True(t, info.Size() <= 512)
+
+	events, err := j.Replay(0)
+	require.Equal(t, ErrJournalGap, err)
+	require.NotEmpty(t, events)
+	require.Equal(t, uint64(100), events[len(events)-1].Sequence)
+
+	_, err = j.Replay(99)
+	require.Nil(t, err, "no gap when asking for retained events only")
+
+	// age-based retention always keeps the last event
+	j.MaxAge = time.Nanosecond
+	require.Nil(t, j.Append(&CommitEvent{}))
+	events, err = j.Replay(100)
+	require.Nil(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, uint64(101), j.Sequence())
+}
+
+func TestJournal_Wait(t *testing.T) {
+	j, _, done := tempJournal(t, 0, 0)
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, j.Wait(ctx, 0))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = j.Append(&CommitEvent{})
+	}()
+	require.Nil(t, j.Wait(context.Background(), 0))
+}
+
+// TestEngine_JournalConsumerCrash commits queries while a consumer
+// regularly crashes, and checks that no commit is ever missed on resume.
+func TestEngine_JournalConsumerCrash(t *testing.T) {
+	j, _, done := tempJournal(t, 0, 0)
+	defer done()
+
+	qs := newQueryStore()
+	e := &Engine{Store: newMemoryStore(), qs: qs, Journal: j}
+
+	const total = 50
+	var uuids []string
+	go func() {
+		for i := 0; i < total; i++ {
+			q := NewQuery()
+			q.Operations = []*Operation{{Key: fmt.Sprint("k", i%5), Op: Operation_SET, Data: []byte{byte(i)}}}
+			qs.AddQuery(q)
+			uuids = append(uuids, q.Uuid)
+			if err := e.apply(q.Uuid); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+
+	seen := make(map[string]bool)
+	var acked uint64
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for round := 0; acked < total; round++ {
+		require.Nil(t, j.Wait(ctx, acked))
+		events, err := j.Replay(acked)
+		require.Nil(t, err)
+		require.Equal(t, acked+1, events[0].Sequence, "resume must not skip any event")
+
+		for i, e := range events {
+			seen[e.Uuid] = true
+			if round%2 == 1 && i == len(events)/2 {
+				break // crash before acknowledging the remaining events
+			}
+			acked = e.Sequence
+		}
+	}
+
+	require.Len(t, seen, total)
+	for _, uuid := range uuids {
+		require.True(t, seen[uuid])
+	}
+}
+
+func TestEngine_JournalEngineCrash(t *testing.T) {
+	j, path, done := tempJournal(t, 0, 0)
+	defer done()
+
+	qs := newQueryStore()
+	e := &Engine{Store: newMemoryStore(), qs: qs, Journal: j}
+
+	apply := func() string {
+		q := NewQuery()
+		q.Operations = []*Operation{{Key: "k", Op: Operation_SET, Data: []byte(q.Uuid)}}
+		qs.AddQuery(q)
+		require.Nil(t, e.apply(q.Uuid))
+		return q.Uuid
+	}
+	first := apply()
+
+	// The node stops once the values are written, before the event is appended
+	require.Nil(t, j.Close())
+	second := apply()
+
+	j, err := OpenJournal(path, 0, 0)
+	require.Nil(t, err)
+	defer j.Close()
+	e.Journal = j
+	require.Nil(t, e.recoverJournal())
+	require.Nil(t, e.recoverJournal(), "recovering twice should be harmless")
+
+	events, err := j.Replay(0)
+	require.Nil(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, first, events[0].Uuid)
+	require.Equal(t, second, events[1].Uuid)
+	require.Equal(t, uint64(2), events[1].Sequence)
+
+	third := apply()
+	events, err = j.Replay(2)
+	require.Nil(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, third, events[0].Uuid)
+}
@@ -0,0 +1,74 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"errors"
+	"strings"
+)
+
+// KeyRingPrefix is the prefix of the keys holding the local keyring in a
+// Store, see SaveKeyRing. These keys are local to each node: queries writing
+// them are never endorsed, and they are ignored by quotas and retention
+// policies.
+const KeyRingPrefix = "_keyring/"
+
+const keyRingKey = KeyRingPrefix + "pem"
+
+// ErrNoKeyRing is returned by LoadKeyRing when no keyring has been saved.
+var ErrNoKeyRing = errors.New("no keyring saved in store")
+
+// ErrReservedKey is returned when a query writes a key local to the nodes.
+var ErrReservedKey = errors.New("key is reserved to the nodes")
+
+// LoadKeyRing returns the marshaled keyring saved in a store by SaveKeyRing.
+// This function locks the store.
+func LoadKeyRing(s Store) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	data, version, err := s.Get(keyRingKey)
+	if version == NoVersion {
+		return nil, ErrNoKeyRing
+	}
+	return data, err
+}
+
+// SaveKeyRing saves a marshaled keyring, as returned by
+// keyring.KeyRing.MarshalBinary, in a store.
+// This function locks the store.
+func SaveKeyRing(s Store, data []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.Set(keyRingKey, data, NewVersion(data))
+}
+
+// IsLocalKey returns true if a key is local to the node, and shall not be
+// exposed to clients. The prefixes of these keys are reserved to the nodes:
+// queries writing them are invalid (see Query.Validate), never endorsed,
+// and never applied, so that only the node itself writes them to its store.
+func IsLocalKey(key string) bool {
+	return strings.HasPrefix(key, KeyRingPrefix) ||
+		strings.HasPrefix(key, EndorsedPrefix) ||
+		strings.HasPrefix(key, AppliedPrefix) ||
+		strings.HasPrefix(key, DecidedPrefix) ||
+		strings.HasPrefix(key, NoncePrefix) ||
+		strings.HasPrefix(key, JournalPrefix)
+}
+
+// writesLocalKeys returns true if an operation of a query writes a key
+// local to the node.
+func writesLocalKeys(q *Query) bool {
+	for _, op := range q.Operations {
+		if IsLocalKey(op.Key) {
+			return true
+		}
+	}
+	return false
+}
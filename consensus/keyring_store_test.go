@@ -0,0 +1,90 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyRingStore(t *testing.T) {
+	s := newMemoryStore()
+	_, err := LoadKeyRing(s)
+	require.Equal(t, ErrNoKeyRing, err)
+
+	require.Nil(t, SaveKeyRing(s, []byte("keyring")))
+	data, err := LoadKeyRing(s)
+	require.Nil(t, err)
+	require.Equal(t, []byte("keyring"), data)
+
+	require.True(t, IsLocalKey(keyRingKey))
+	require.False(t, IsLocalKey("keyring"))
+}
+
+func TestEngine_LocalKeys(t *testing.T) {
+	qs := newQueryStore()
+	e := &Engine{Store: newMemoryStore(), qs: qs}
+	require.Nil(t, SaveKeyRing(e.Store, []byte("keyring")))
+
+	q := quotaQuery(qs, keyRingKey, "forged")
+	q.Operations = append(q.Operations, &Operation{Key: "a", Op: Operation_SET, Data: []byte("a")})
+	require.False(t, e.canEndorse(q), "writes to local keys must not be endorsed")
+
+	// Committed anyway by other nodes
+	require.Nil(t, e.apply(q.Uuid))
+	data, err := LoadKeyRing(e.Store)
+	require.Nil(t, err)
+	require.Equal(t, []byte("keyring"), data, "local keys must never be written")
+
+	e.Store.Lock()
+	value, _, err := e.Store.Get("a")
+	e.Store.Unlock()
+	require.Nil(t, err)
+	require.Equal(t, []byte("a"), value, "other keys must be written")
+}
+
+func TestEngine_ReservedKeys(t *testing.T) {
+	engines, stop := startEngines(t, 1, 1)
+	defer stop()
+	eng := engines[0]
+
+	forged := NewQuery()
+	forged.SetTimeout(time.Minute)
+	forged.Operations = []*Operation{{Key: AppliedPrefix + "forged", Op: Operation_SET, Data: []byte("forged")}}
+	err := eng.Submit(forged)
+	require.IsType(t, &OperationError{}, err)
+	require.Equal(t, ErrReservedKey, err.(*OperationError).Err)
+
+	// Signed by a member bypassing Submit, and gossiped
+	forged.Emitter = eng.Identity()
+	require.Nil(t, eng.signQuery(forged))
+	eng.handleQuery(forged)
+	_, err = eng.QueryStatus(forged.Uuid)
+	require.Equal(t, ErrUnknownQuery, err, "invalid queries must be dropped when received")
+
+	// Internal writers are not affected
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: []byte("a")}}
+	s, err := eng.SubmitAndWait(context.Background(), q)
+	require.Nil(t, err)
+	require.True(t, s.Applied)
+
+	eng.Store.Lock()
+	defer eng.Store.Unlock()
+	_, v, err := eng.Store.Get(appliedMarkerKey(q.Uuid))
+	require.Nil(t, err)
+	require.NotEqual(t, NoVersion, v, "the applied marker should be written")
+	_, v, err = eng.Store.Get(AppliedPrefix + "forged")
+	require.Nil(t, err)
+	require.Equal(t, NoVersion, v)
+}
@@ -0,0 +1,130 @@
+//go:build lockcheck
+// +build lockcheck
+
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// held records the levels of the locks held by each goroutine, in
+// acquisition order. It also holds the settings of the deadlock detector:
+// a goroutine waiting for a lock for longer than timeout is reported to
+// onDeadlock, along with the stacks of every goroutine.
+var held = struct {
+	sync.Mutex
+	levels     map[int64][]lockLevel
+	timeout    time.Duration
+	onDeadlock func(report string)
+}{
+	levels:     make(map[int64][]lockLevel),
+	timeout:    30 * time.Second,
+	onDeadlock: func(report string) { panic(report) },
+}
+
+func goroutineID() int64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	buf = buf[:bytes.IndexByte(buf, ' ')]
+	id, _ := strconv.ParseInt(string(buf), 10, 64)
+	return id
+}
+
+// lockAcquire checks that no lock of the same or a higher level is held
+// by the current goroutine before calling lock, and watches for deadlocks
+// while lock is blocked.
+func lockAcquire(level lockLevel, lock func()) {
+	id := goroutineID()
+
+	held.Lock()
+	levels := held.levels[id]
+	if n := len(levels); n > 0 && levels[n-1] >= level {
+		held.Unlock()
+		panic(fmt.Sprintf("lock order violation: acquiring %s while holding %s", level, levels[n-1]))
+	}
+	held.levels[id] = append(levels, level)
+	timeout, onDeadlock := held.timeout, held.onDeadlock
+	held.Unlock()
+
+	watchdog := time.AfterFunc(timeout, func() {
+		buf := make([]byte, 1<<20)
+		buf = buf[:runtime.Stack(buf, true)]
+		onDeadlock(fmt.Sprintf("goroutine %d waiting for %s lock for %s:\n%s", id, level, timeout, buf))
+	})
+	lock()
+	watchdog.Stop()
+}
+
+// lockRelease forgets the latest lock of the given level held by the
+// current goroutine.
+func lockRelease(level lockLevel) {
+	id := goroutineID()
+
+	held.Lock()
+	defer held.Unlock()
+
+	levels := held.levels[id]
+	for i := len(levels) - 1; i >= 0; i-- {
+		if levels[i] == level {
+			levels = append(levels[:i], levels[i+1:]...)
+			break
+		}
+	}
+
+	if len(levels) == 0 {
+		delete(held.levels, id)
+	} else {
+		held.levels[id] = levels
+	}
+}
+
+// orderedStore checks the acquisitions of the lock of a store.
+type orderedStore struct {
+	Store
+}
+
+func (s orderedStore) Lock() {
+	lockAcquire(levelStore, s.Store.Lock)
+}
+
+func (s orderedStore) Unlock() {
+	s.Store.Unlock()
+	lockRelease(levelStore)
+}
+
+// UpdateBatch forwards the batch to the store, atomically if it is a
+// BatchDeleter, so that wrapping a store does not change how it is written.
+func (s orderedStore) UpdateBatch(keys []string, values [][]byte, versions []*Version, deleted []string) error {
+	return UpdateBatch(s.Store, keys, values, versions, deleted)
+}
+
+// CollectMetrics collects the measures of the store if it reports them, see
+// MetricsCollector.
+func (s orderedStore) CollectMetrics(m Metrics) {
+	if c, ok := s.Store.(MetricsCollector); ok {
+		c.CollectMetrics(m)
+	}
+}
+
+func checkedStore(s Store) Store {
+	switch s.(type) {
+	case nil, orderedStore:
+		return s // engines may share a store
+	default:
+		return orderedStore{s}
+	}
+}
@@ -0,0 +1,126 @@
+//go:build lockcheck
+// +build lockcheck
+
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockOrder(t *testing.T) {
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, passBBC{}, nil, 1)
+
+	// Allowed orders, including skipped levels
+	eng.endorsementMutex.Lock()
+	eng.qs.RLock()
+	eng.Store.Lock()
+	eng.Store.Unlock()
+	eng.qs.RUnlock()
+	eng.endorsementMutex.Unlock()
+
+	eng.endorsementMutex.Lock()
+	eng.Store.Lock()
+	eng.Store.Unlock()
+	eng.endorsementMutex.Unlock()
+
+	// Violations
+	eng.Store.Lock()
+	require.Panics(t, func() { eng.qs.GetQuery("q") }, "should not access the query store with the store locked")
+	require.Panics(t, func() { eng.endorsementMutex.Lock() })
+	eng.Store.Unlock()
+
+	eng.qs.Lock()
+	require.Panics(t, func() { eng.qs.RLock() }, "should not acquire the query store twice")
+	eng.qs.Unlock()
+
+	// The state of the goroutine is consistent after violations
+	eng.endorsementMutex.Lock()
+	eng.qs.Lock()
+	eng.qs.Unlock()
+	eng.endorsementMutex.Unlock()
+
+	held.Lock()
+	defer held.Unlock()
+	require.Len(t, held.levels[goroutineID()], 0)
+}
+
+func TestLockOrder_Deadlock(t *testing.T) {
+	reports := make(chan string, 1)
+	setDetector := func(timeout time.Duration, onDeadlock func(string)) (time.Duration, func(string)) {
+		held.Lock()
+		defer held.Unlock()
+		timeout, held.timeout = held.timeout, timeout
+		onDeadlock, held.onDeadlock = held.onDeadlock, onDeadlock
+		return timeout, onDeadlock
+	}
+	defer setDetector(setDetector(50*time.Millisecond, func(report string) {
+		select {
+		case reports <- report:
+		default:
+		}
+	}))
+
+	var l endorsementLock
+	l.Lock()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		l.Lock()
+		l.Unlock()
+	}()
+
+	select {
+	case report := <-reports:
+		require.True(t, strings.Contains(report, "waiting for endorsement lock"), report)
+	case <-time.After(2 * time.Second):
+		t.Fatal("deadlock should be reported")
+	}
+
+	l.Unlock()
+	wg.Wait()
+}
+
+// collectingStore reports the number of keys it holds, see MetricsCollector.
+type collectingStore struct {
+	*memoryStore
+}
+
+func (s collectingStore) CollectMetrics(m Metrics) {
+	keys, _ := s.List()
+	m.Set("store_keys", float64(len(keys)))
+}
+
+func TestLockOrder_OptionalInterfaces(t *testing.T) {
+	store := &deletingStore{batchStore: batchStore{memoryStore: newMemoryStore()}}
+	eng := NewEngine(store, &recordingNetwork{}, passBBC{}, nil, 1)
+
+	_, ok := eng.Store.(BatchDeleter)
+	require.True(t, ok, "the checked store should keep batching deletions")
+	eng.Store.Lock()
+	require.Nil(t, UpdateBatch(eng.Store, []string{"b"}, [][]byte{[]byte("b")}, []*Version{NewVersion([]byte("b"))}, []string{"a"}))
+	eng.Store.Unlock()
+	require.Equal(t, [][]string{{"a"}}, store.deletions)
+
+	collecting := collectingStore{newMemoryStore()}
+	require.Nil(t, collecting.Set("a", []byte("a"), NewVersion([]byte("a"))))
+	eng = NewEngine(collecting, &recordingNetwork{}, passBBC{}, nil, 1)
+	m := newFakeMetrics()
+	eng.Metrics = m
+	eng.CollectMetrics()
+	require.Equal(t, float64(1), m.get("store_keys"))
+}
@@ -0,0 +1,90 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import "sync"
+
+// The engine relies on three main locks, which must always be acquired in
+// the following order, possibly skipping some of them:
+//
+//	1. Engine.endorsementMutex, serializing endorsement decisions;
+//	2. the queryStore lock, protecting the state of known queries;
+//	3. the Store lock, protecting committed values and their versions.
+//
+// Every other lock (quotaTracker, retentionTracker, aclTracker, recoveryTracker,
+// nodeStatusTracker, watchTracker, eventTracker, epochTracker, quarantineTracker, Journal,
+// ClusterClock, KeyRing, runMutex, applyMutex) is a leaf: it may be taken while holding any of the above, but
+// no lock is ever acquired while holding it.
+// unlockMutex only wraps calls to the KeyRing.
+//
+// The order is verified at runtime when building with the lockcheck tag:
+//
+//	go test -tags lockcheck ./consensus/
+//
+// Such builds panic on the first acquisition out of order, and report the
+// goroutines stuck on a lock for a long time. Regular builds
+// compile the checks out.
+
+type lockLevel int
+
+const (
+	levelEndorsement lockLevel = iota + 1
+	levelQueryStore
+	levelStore
+)
+
+func (l lockLevel) String() string {
+	switch l {
+	case levelEndorsement:
+		return "endorsement"
+	case levelQueryStore:
+		return "queryStore"
+	case levelStore:
+		return "store"
+	default:
+		return "unknown"
+	}
+}
+
+// endorsementLock is the mutex of Engine.endorsementMutex.
+type endorsementLock struct {
+	mutex sync.Mutex
+}
+
+func (l *endorsementLock) Lock() {
+	lockAcquire(levelEndorsement, l.mutex.Lock)
+}
+
+func (l *endorsementLock) Unlock() {
+	l.mutex.Unlock()
+	lockRelease(levelEndorsement)
+}
+
+// queryStoreLock is the read-write mutex embedded in queryStore.
+type queryStoreLock struct {
+	mutex sync.RWMutex
+}
+
+func (l *queryStoreLock) Lock() {
+	lockAcquire(levelQueryStore, l.mutex.Lock)
+}
+
+func (l *queryStoreLock) Unlock() {
+	l.mutex.Unlock()
+	lockRelease(levelQueryStore)
+}
+
+func (l *queryStoreLock) RLock() {
+	lockAcquire(levelQueryStore, l.mutex.RLock)
+}
+
+func (l *queryStoreLock) RUnlock() {
+	l.mutex.RUnlock()
+	lockRelease(levelQueryStore)
+}
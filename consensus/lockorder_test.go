@@ -0,0 +1,148 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestEngine_ConcurrentHotPaths runs conflicting queries against readers of
+// every lock of the engine. Run it with the lockcheck tag to verify the lock
+// ordering of the exercised paths, see lockorder.go.
+func TestEngine_ConcurrentHotPaths(t *testing.T) {
+	const (
+		n       = 3
+		writers = 4
+		queries = 20
+	)
+	journals := make([]*Journal, n)
+	for i := range journals {
+		var done func()
+		journals[i], _, done = tempJournal(t, 0, 0)
+		defer done()
+	}
+
+	engines, stop := startEngines(t, n, n, func(i int, eng *Engine) {
+		eng.Journal = journals[i] // commits are certified while the store is locked
+		eng.SetQuota("k", 1<<20)
+		eng.SetRetention("k", time.Hour)
+	})
+	defer stop()
+
+	// Every fourth query also writes a key shared by all writers
+	var keys []string
+	for w := 0; w < writers; w++ {
+		for i := 0; i < queries; i++ {
+			keys = append(keys, fmt.Sprintf("k%d-%d", w, i))
+		}
+	}
+	keys = append(keys, "k")
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for _, eng := range engines {
+		readers.Add(1)
+		go func(eng *Engine) {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if _, err := eng.Snapshot(keys); err != nil {
+					t.Error(err)
+				}
+				if _, err := eng.ExpiredKeys(0); err != nil {
+					t.Error(err)
+				}
+				if err := eng.Dump(ioutil.Discard); err != nil {
+					t.Error(err)
+				}
+				_ = eng.qs.PendingSummary(eng.now(), 0)
+				time.Sleep(time.Millisecond) // leave some processor time to the engines
+			}
+		}(eng)
+	}
+
+	var submitters sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		submitters.Add(1)
+		go func(w int) {
+			defer submitters.Done()
+			eng := engines[w%n]
+			for i := 0; i < queries; i++ {
+				key := fmt.Sprintf("k%d-%d", w, i)
+				snapshot, err := eng.Snapshot([]string{key})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				q := NewQuery()
+				q.SetTimeout(time.Second)
+				q.Requirements = snapshot
+				q.Operations = []*Operation{{Key: key, Op: Operation_SET, Data: []byte(key)}}
+				if i%4 == 0 {
+					q.Operations = append(q.Operations, &Operation{Key: "k", Op: Operation_SET, Data: []byte(key)})
+				}
+				if err := eng.Submit(q); err != nil {
+					t.Error(err)
+				}
+			}
+		}(w)
+	}
+
+	noHang(t, "submissions", submitters.Wait)
+
+	// Every node should eventually commit some queries, and hold the same
+	// versions, while the readers are still running.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var ok bool
+		noHang(t, "convergence check", func() { ok = converged(t, engines, keys) })
+		if ok {
+			break
+		}
+		require.True(t, time.Now().Before(deadline), "nodes should converge")
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	close(done)
+	noHang(t, "readers", readers.Wait)
+}
+
+func converged(t *testing.T, engines []*Engine, keys []string) bool {
+	reference, err := engines[0].Snapshot(keys)
+	require.Nil(t, err)
+
+	for _, eng := range engines {
+		events, err := eng.Journal.Replay(0)
+		require.Nil(t, err)
+		if len(events) == 0 {
+			return false
+		}
+
+		snapshot, err := eng.Snapshot(keys)
+		require.Nil(t, err)
+		for _, key := range keys {
+			if snapshot[key].Matches(reference[key]) != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,353 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+)
+
+// memoryLogSample is the number of queries rejected by the memory budget
+// between two logs.
+const memoryLogSample = 100
+
+const (
+	orphanRetention = 10 * time.Minute // endorsements of unknown queries are forgotten once received for this long
+	orphanMax       = 4096             // endorsements of unknown queries kept, those of the queries first endorsed are forgotten first
+)
+
+// MemoryUsage describes the memory held by the pending consensus state, see
+// Engine.MemoryBudget.
+type MemoryUsage struct {
+	Used     int64  // serialized size of the pending queries and endorsements, in bytes
+	Budget   int64  // unlimited if zero
+	Rejected uint64 // queries of peers rejected because of the budget since the start
+	Orphans  uint64 // endorsements of unknown queries rejected because of the budget since the start
+}
+
+func messageSize(m proto.Message) int64 {
+	return int64(proto.Size(m))
+}
+
+// memorySize returns the serialized size of a query and its endorsements.
+func (qi queryInfo) memorySize() int64 {
+	var size int64
+	if qi.Query != nil {
+		size += messageSize(qi.Query)
+	}
+	for _, e := range qi.Endorsements {
+		size += messageSize(e.Endorsement)
+	}
+	return size
+}
+
+// recountMemory computes the memory held by the pending queries, with their
+// endorsements, and by the endorsements of unknown queries, which are
+// recounted too (see recountOrphans). The count is otherwise maintained as
+// queries are inserted and resolved.
+// unsafe
+func (qs *queryStore) recountMemory() {
+	qs.memory = 0
+	for _, qi := range qs.queries {
+		if qi.State == qPending {
+			qs.memory += qi.memorySize()
+		}
+	}
+	for _, e := range qs.pendingEndorsements {
+		qs.memory += messageSize(e)
+	}
+	for _, a := range qs.pendingAggregates {
+		for _, e := range a.Endorsements {
+			qs.memory += messageSize(e)
+		}
+	}
+	qs.recountOrphans()
+}
+
+// orphan describes the endorsements of an unknown query.
+type orphan struct {
+	received time.Time // of the first endorsement
+	count    int
+	size     int64
+}
+
+// orphaned records the reception of n endorsements of an unknown query, of
+// the given serialized size, and forgets the endorsements of the unknown
+// queries first endorsed beyond orphanMax.
+// unsafe
+func (qs *queryStore) orphaned(uuid string, n int, size int64) {
+	if qs.orphans == nil {
+		qs.orphans = make(map[string]orphan)
+	}
+	o, ok := qs.orphans[uuid]
+	if !ok {
+		o.received = qs.now()
+		qs.orphanOrder = append(qs.orphanOrder, uuid)
+	}
+	o.count += n
+	o.size += size
+	qs.orphans[uuid] = o
+	qs.orphanCount += n
+
+	removed := make(map[string]bool)
+	count := qs.orphanCount
+	for _, uuid := range qs.orphanOrder {
+		if count <= orphanMax {
+			break
+		}
+		if o, ok := qs.orphans[uuid]; ok && !removed[uuid] {
+			removed[uuid] = true
+			count -= o.count
+		}
+	}
+	qs.removeOrphans(removed)
+}
+
+// adopted forgets the reception of the endorsements of a query, once
+// received. The endorsements themselves are handed to the query by AddQuery.
+// unsafe
+func (qs *queryStore) adopted(uuid string) {
+	if o, ok := qs.orphans[uuid]; ok {
+		qs.orphanCount -= o.count
+		delete(qs.orphans, uuid)
+		qs.compactOrphans()
+	}
+}
+
+// ForgetOrphans forgets the endorsements of unknown queries received for more
+// than retention, and then those of the queries first endorsed while the
+// memory exceeds budget, unless budget is zero. It returns how many
+// endorsements have been forgotten.
+// This function is thread-safe.
+func (qs *queryStore) ForgetOrphans(retention time.Duration, budget int64) int {
+	qs.Lock()
+	defer qs.Unlock()
+
+	since := qs.now().Add(-retention)
+	memory := qs.memory
+	removed := make(map[string]bool)
+	for _, uuid := range qs.orphanOrder {
+		o, ok := qs.orphans[uuid]
+		if !ok || removed[uuid] {
+			continue
+		}
+		if !o.received.Before(since) && (budget <= 0 || memory < budget) {
+			break
+		}
+		removed[uuid] = true
+		memory -= o.size
+	}
+	return qs.removeOrphans(removed)
+}
+
+// removeOrphans forgets the endorsements of the provided unknown queries,
+// and returns how many of them have been forgotten.
+// unsafe
+func (qs *queryStore) removeOrphans(removed map[string]bool) (n int) {
+	if len(removed) > 0 {
+		pendingEndorsements := qs.pendingEndorsements[:0]
+		for _, pe := range qs.pendingEndorsements {
+			if !removed[pe.Uuid] {
+				pendingEndorsements = append(pendingEndorsements, pe)
+			}
+		}
+		qs.pendingEndorsements = pendingEndorsements
+
+		pendingAggregates := qs.pendingAggregates[:0]
+		for _, pa := range qs.pendingAggregates {
+			if !removed[pa.Endorsements[0].Uuid] {
+				pendingAggregates = append(pendingAggregates, pa)
+			}
+		}
+		qs.pendingAggregates = pendingAggregates
+
+		for uuid := range removed {
+			o := qs.orphans[uuid]
+			qs.memory -= o.size
+			qs.orphanCount -= o.count
+			n += o.count
+			delete(qs.orphans, uuid)
+		}
+	}
+
+	qs.compactOrphans()
+	return n
+}
+
+// compactOrphans removes the queries received or forgotten from the order of
+// the unknown queries, lazily: from its front, and from the whole order once
+// they are the majority.
+// unsafe
+func (qs *queryStore) compactOrphans() {
+	for len(qs.orphanOrder) > 0 {
+		if _, ok := qs.orphans[qs.orphanOrder[0]]; ok {
+			break
+		}
+		qs.orphanOrder = qs.orphanOrder[1:]
+	}
+	if len(qs.orphanOrder) <= 2*len(qs.orphans) {
+		return
+	}
+
+	seen := make(map[string]bool, len(qs.orphans))
+	order := make([]string, 0, len(qs.orphans))
+	for _, uuid := range qs.orphanOrder {
+		if _, ok := qs.orphans[uuid]; ok && !seen[uuid] {
+			seen[uuid] = true
+			order = append(order, uuid)
+		}
+	}
+	qs.orphanOrder = order
+}
+
+// recountOrphans computes the endorsements of each unknown query from the
+// pending ones, keeping the known receptions.
+// unsafe
+func (qs *queryStore) recountOrphans() {
+	orphans := make(map[string]orphan)
+	var order []string
+	add := func(e *Endorsement) {
+		o, ok := orphans[e.Uuid]
+		if !ok {
+			o.received = qs.now()
+			if known, ok := qs.orphans[e.Uuid]; ok {
+				o.received = known.received
+			}
+			order = append(order, e.Uuid)
+		}
+		o.count++
+		o.size += messageSize(e)
+		orphans[e.Uuid] = o
+	}
+	for _, e := range qs.pendingEndorsements {
+		add(e)
+	}
+	for _, a := range qs.pendingAggregates {
+		for _, e := range a.Endorsements {
+			add(e)
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return orphans[order[i]].received.Before(orphans[order[j]].received)
+	})
+	qs.orphans, qs.orphanOrder, qs.orphanCount = orphans, order, 0
+	for _, o := range orphans {
+		qs.orphanCount += o.count
+	}
+}
+
+// Memory returns the serialized size of the pending queries and endorsements.
+// This function is thread-safe.
+func (qs *queryStore) Memory() int64 {
+	qs.RLock()
+	defer qs.RUnlock()
+	return qs.memory
+}
+
+// OldestPending returns the pending queries with the earliest deadlines, at
+// most limit of them.
+func (qs *queryStore) OldestPending(limit int) []string {
+	qs.RLock()
+	defer qs.RUnlock()
+
+	var pending []*Query
+	for _, qi := range qs.queries {
+		if qi.State == qPending && qi.Query != nil {
+			pending = append(pending, qi.Query)
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].DeadlineTime().Before(pending[j].DeadlineTime())
+	})
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+
+	uuids := make([]string, len(pending))
+	for i, q := range pending {
+		uuids[i] = q.Uuid
+	}
+	return uuids
+}
+
+// admits returns false if the engine cannot hold a new query without
+// exceeding its memory budget.
+// This function is thread-safe.
+func (eng *Engine) admits(q *Query) bool {
+	return eng.MemoryBudget <= 0 || eng.qs.Memory()+messageSize(q) <= eng.MemoryBudget
+}
+
+// overBudget returns true if the pending consensus state exceeds the memory
+// budget of the engine.
+// This function is thread-safe.
+func (eng *Engine) overBudget() bool {
+	return eng.MemoryBudget > 0 && eng.qs.Memory() >= eng.MemoryBudget
+}
+
+// admitsOrphan returns false if the query of an endorsement is unknown
+// while the engine exceeds its memory budget: such endorsements cannot be
+// counted until the query is received.
+// This function is thread-safe.
+func (eng *Engine) admitsOrphan(uuid string) bool {
+	return !eng.overBudget() || eng.qs.Known(uuid)
+}
+
+// rejectQuery counts a query of a peer rejected because of the memory budget.
+func (eng *Engine) rejectQuery(q *Query) {
+	eng.count(MetricQueriesRejected, 1)
+	rejected := atomic.AddUint64(&eng.rejectedQueries, 1)
+	if rejected%memoryLogSample == 1 {
+		zap.L().Warn("MemoryBudget",
+			zap.String("uuid", q.Uuid),
+			zap.Int64("used", eng.qs.Memory()),
+			zap.Int64("budget", eng.MemoryBudget),
+			zap.Uint64("rejected", rejected),
+		)
+	}
+}
+
+// rejectOrphans counts endorsements of unknown queries rejected because of
+// the memory budget.
+func (eng *Engine) rejectOrphans(n int) {
+	eng.count(MetricEndorsementsRejected, float64(n))
+	atomic.AddUint64(&eng.rejectedOrphans, uint64(n))
+}
+
+// reclaimMemory forgets the old endorsements of unknown queries, and those
+// needed to get back under the memory budget. While the engine is still over
+// its budget, it queues checkpoints for the oldest pending queries, since a
+// checkpoint commits or drops them.
+func (eng *Engine) reclaimMemory() {
+	if n := eng.qs.ForgetOrphans(orphanRetention, eng.MemoryBudget); n > 0 {
+		zap.L().Debug("ForgetOrphans", zap.Int("count", n))
+	}
+	if !eng.overBudget() {
+		return
+	}
+	for _, uuid := range eng.qs.OldestPending(checkpointRoutineBatch) {
+		eng.queueCheckpoint(uuid)
+	}
+}
+
+// MemoryUsage returns the memory held by the pending consensus state.
+// This function is thread-safe.
+func (eng *Engine) MemoryUsage() MemoryUsage {
+	return MemoryUsage{
+		Used:     eng.qs.Memory(),
+		Budget:   eng.MemoryBudget,
+		Rejected: atomic.LoadUint64(&eng.rejectedQueries),
+		Orphans:  atomic.LoadUint64(&eng.rejectedOrphans),
+	}
+}
@@ -0,0 +1,221 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestQueryStore_Memory(t *testing.T) {
+	qs := newQueryStore()
+	check := func(expected int64) {
+		require.Equal(t, expected, qs.Memory())
+		qs.Lock()
+		qs.recountMemory()
+		qs.Unlock()
+		require.Equal(t, expected, qs.Memory(), "the count should match a recount")
+	}
+
+	p, q := NewQuery(), NewQuery()
+	ep := &Endorsement{Uuid: p.Uuid, Emitter: "1"}
+	eq := &Endorsement{Uuid: q.Uuid, Emitter: "1"}
+	duplicate := &Endorsement{Uuid: q.Uuid, Emitter: "1"}
+
+	// Endorsements of unknown queries are accounted for
+	qs.AddEndorsement(eq)
+	qs.AddEndorsement(duplicate)
+	check(messageSize(eq) + messageSize(duplicate))
+	qs.AddQuery(q)
+	check(messageSize(q) + messageSize(eq))
+
+	qs.AddQuery(p)
+	qs.AddEndorsement(ep)
+	check(messageSize(q) + messageSize(eq) + messageSize(p) + messageSize(ep))
+
+	// Resolved queries are released
+	qs.Lock()
+	qs.commit(p.Uuid)
+	qs.Unlock()
+	check(messageSize(q) + messageSize(eq))
+	qs.AddEndorsement(&Endorsement{Uuid: p.Uuid, Emitter: "2"})
+	check(messageSize(q) + messageSize(eq))
+
+	require.True(t, qs.DropPending(q.Uuid))
+	check(0)
+}
+
+func TestEngine_MemoryBudget(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 2)
+	signer := NewEngine(nil, nil, nil, keyrings[1], 2)
+	h := &hub{}
+	eng := NewEngine(newMemoryStore(), h.join(), passBBC{}, keyrings[0], 2)
+
+	query := func() *Query {
+		q := NewQuery()
+		q.SetTimeout(time.Minute)
+		q.Operations = []*Operation{{Key: q.Uuid, Op: Operation_SET, Data: make([]byte, 64*1024)}}
+		return q
+	}
+	remote := func() *Query {
+		q := query()
+		q.Emitter = keyrings[1].Identity()
+		require.Nil(t, signer.signQuery(q))
+		return q
+	}
+	known := func(q *Query) bool {
+		_, err := eng.QueryStatus(q.Uuid)
+		return err == nil
+	}
+
+	// The queries of peers are accepted until the budget is reached
+	eng.MemoryBudget = 3 * messageSize(remote())
+	for i := 0; i < 3; i++ {
+		q := remote()
+		eng.handleQuery(q)
+		require.True(t, known(q))
+	}
+	require.True(t, eng.overBudget())
+
+	q := remote()
+	eng.handleQuery(q)
+	require.False(t, known(q), "queries should be rejected once over budget")
+	require.Equal(t, uint64(1), eng.MemoryUsage().Rejected)
+	require.Equal(t, ErrBackpressure, eng.Submit(query()))
+	errs := eng.SubmitBatch([]*Query{query()})
+	require.Equal(t, ErrBackpressure, errs[0])
+
+	// Checkpoints drop the pending queries, which never get enough
+	// endorsements
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.Nil(t, eng.Run(ctx))
+	h.waitSubscribers(t, 4) // queries, endorsements, checkpoints and node statuses
+
+	for i := 0; i < 200 && eng.MemoryUsage().Used > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Zero(t, eng.MemoryUsage().Used, "checkpoints should reclaim the memory")
+
+	q = remote()
+	eng.handleQuery(q)
+	require.True(t, known(q), "queries should be accepted again")
+	require.Nil(t, eng.Submit(query()))
+}
+
+func TestQueryStore_Orphans(t *testing.T) {
+	qs := newQueryStore()
+	clock := tests.NewManualClock(time.Now())
+	qs.setClock(clock.Now)
+	recount := func() int64 {
+		qs.Lock()
+		defer qs.Unlock()
+		memory := qs.memory
+		qs.recountMemory()
+		return memory
+	}
+
+	// The endorsements of the queries first endorsed are forgotten beyond
+	// orphanMax
+	first := NewQuery()
+	qs.AddEndorsement(&Endorsement{Uuid: first.Uuid, Emitter: "1"})
+	qs.AddAggregate(&AggregatedEndorsement{Endorsements: []*Endorsement{
+		{Uuid: first.Uuid, Emitter: "2"},
+		{Uuid: first.Uuid, Emitter: "3"},
+	}})
+	clock.Advance(time.Millisecond)
+	for i := 0; i < orphanMax-3; i++ {
+		qs.AddEndorsement(&Endorsement{Uuid: NewQuery().Uuid, Emitter: "1"})
+	}
+	require.Len(t, qs.orphans, orphanMax-2)
+	require.Contains(t, qs.orphans, first.Uuid)
+
+	qs.AddEndorsement(&Endorsement{Uuid: NewQuery().Uuid, Emitter: "1"})
+	require.NotContains(t, qs.orphans, first.Uuid)
+	require.Len(t, qs.pendingEndorsements, orphanMax-2)
+	require.Empty(t, qs.pendingAggregates)
+	require.Equal(t, qs.Memory(), recount(), "forgotten endorsements should be released")
+
+	// Received queries are not orphans anymore
+	q := NewQuery()
+	qs.AddEndorsement(&Endorsement{Uuid: q.Uuid, Emitter: "1"})
+	qs.AddQuery(q)
+	require.NotContains(t, qs.orphans, q.Uuid)
+
+	// Old orphans are forgotten
+	clock.Advance(orphanRetention + time.Millisecond)
+	require.Equal(t, orphanMax-2, qs.ForgetOrphans(orphanRetention, 0))
+	require.Empty(t, qs.pendingEndorsements)
+	require.Empty(t, qs.orphans)
+	require.Equal(t, messageSize(q)+messageSize(&Endorsement{Uuid: q.Uuid, Emitter: "1"}), qs.Memory())
+}
+
+func TestEngine_MemoryOrphans(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 2)
+	signer := NewEngine(nil, nil, nil, keyrings[1], 2)
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, passBBC{}, keyrings[0], 2)
+	clock := tests.NewManualClock(time.Now())
+	eng.qs.setClock(clock.Now)
+	m := newFakeMetrics()
+	eng.Metrics = m
+
+	orphan := func() *Endorsement {
+		e := &Endorsement{Uuid: NewQuery().Uuid, Emitter: keyrings[1].Identity()}
+		require.Nil(t, signer.signEndorsement(e))
+		return e
+	}
+	known := func(e *Endorsement) bool {
+		eng.qs.RLock()
+		defer eng.qs.RUnlock()
+		_, ok := eng.qs.orphans[e.Uuid]
+		return ok
+	}
+
+	// The endorsements of unknown queries are accepted until the budget is
+	// reached
+	eng.MemoryBudget = 3 * messageSize(orphan())
+	var orphans []*Endorsement
+	for i := 0; i < 3; i++ {
+		e := orphan()
+		eng.handleEndorsement(e)
+		require.True(t, known(e))
+		orphans = append(orphans, e)
+		clock.Advance(time.Millisecond)
+	}
+	require.True(t, eng.overBudget())
+
+	e := orphan()
+	eng.handleEndorsement(e)
+	require.False(t, known(e), "orphans should be rejected once over budget")
+	require.Equal(t, uint64(1), eng.MemoryUsage().Orphans)
+
+	// The orphans first received are forgotten to get back under budget
+	eng.reclaimMemory()
+	require.False(t, eng.overBudget())
+	require.False(t, known(orphans[0]))
+	require.True(t, known(orphans[1]))
+	require.True(t, known(orphans[2]))
+
+	eng.handleEndorsement(e)
+	require.True(t, known(e), "orphans should be accepted again")
+
+	// Old orphans are forgotten, whatever the budget
+	eng.MemoryBudget = 0
+	clock.Advance(orphanRetention + time.Millisecond)
+	eng.reclaimMemory()
+	require.Zero(t, eng.MemoryUsage().Used)
+
+	eng.CollectMetrics()
+	require.Equal(t, 1.0, m.get(MetricEndorsementsRejected))
+	require.Equal(t, 0.0, m.get(MetricMemoryBudget))
+}
@@ -0,0 +1,188 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Names of the metrics reported by the engine, the networks and the API
+// server, see Metrics.
+const (
+	MetricQueriesSubmitted     = "pnyxdb_queries_submitted_total"
+	MetricQueriesReceived      = "pnyxdb_queries_received_total"
+	MetricQueriesCommitted     = "pnyxdb_queries_committed_total"
+	MetricQueriesDropped       = "pnyxdb_queries_dropped_total"
+	MetricQueriesExpired       = "pnyxdb_queries_expired_total"
+	MetricQueriesRejected      = "pnyxdb_queries_rejected_total"
+	MetricQueriesStored        = "pnyxdb_queries_stored"
+	MetricQueriesPending       = "pnyxdb_queries_pending"
+	MetricEndorsementsSent     = "pnyxdb_endorsements_sent_total"
+	MetricEndorsementsReceived = "pnyxdb_endorsements_received_total"
+	MetricEndorsementsRejected = "pnyxdb_endorsements_rejected_total"
+	MetricCheckpointRounds     = "pnyxdb_checkpoint_rounds_total"
+	MetricCheckpointTimeouts   = "pnyxdb_checkpoint_timeouts_total"
+	MetricCheckpointDuration   = "pnyxdb_checkpoint_duration_seconds"
+	MetricCheckpointsPending   = "pnyxdb_checkpoints_pending"
+	MetricCheckpointBatchSize  = "pnyxdb_checkpoint_batch_size"
+	MetricRecoveryAttempts     = "pnyxdb_recovery_attempts_total"
+	MetricMemoryUsed           = "pnyxdb_memory_used_bytes"
+	MetricMemoryBudget         = "pnyxdb_memory_budget_bytes"
+	MetricNetworkPeers         = "pnyxdb_network_peers"
+	MetricNetworkReceived      = "pnyxdb_network_messages_received_total"
+	MetricNetworkPublished     = "pnyxdb_network_messages_published_total"
+	MetricNetworkInvalid       = "pnyxdb_network_messages_invalid_total"
+	MetricNetworkDropped       = "pnyxdb_network_messages_dropped_total"
+	MetricEventsDropped        = "pnyxdb_events_dropped_total"
+	MetricStoreCorruptions     = "pnyxdb_store_corruptions_total"
+	MetricStoreCacheHits       = "pnyxdb_store_cache_hits_total"
+	MetricStoreCacheMisses     = "pnyxdb_store_cache_misses_total"
+	MetricStoreCacheEntries    = "pnyxdb_store_cache_entries"
+	MetricStoreCacheBytes      = "pnyxdb_store_cache_bytes"
+	MetricEngineRestarts       = "pnyxdb_engine_restarts"    // labeled by loop, see Engine.Restarts
+	MetricAPIRequests          = "pnyxdb_api_requests_total" // labeled by method and code
+)
+
+// MetricsHelp returns the description of each metric, by name.
+func MetricsHelp() map[string]string {
+	return map[string]string{
+		MetricQueriesSubmitted:     "Queries submitted by the node and broadcast.",
+		MetricQueriesReceived:      "Queries received from the network.",
+		MetricQueriesCommitted:     "Queries committed by the node.",
+		MetricQueriesDropped:       "Queries dropped by the node, including the expired ones.",
+		MetricQueriesExpired:       "Queries dropped once their deadline was reached.",
+		MetricQueriesRejected:      "Queries of peers rejected because of the memory budget.",
+		MetricQueriesStored:        "Queries held by the query store, whatever their state.",
+		MetricQueriesPending:       "Queries neither committed nor dropped yet.",
+		MetricEndorsementsSent:     "Endorsements broadcast by the node.",
+		MetricEndorsementsReceived: "Endorsements received from the network, including those of aggregates.",
+		MetricEndorsementsRejected: "Endorsements of unknown queries rejected because of the memory budget.",
+		MetricCheckpointRounds:     "Checkpoints executed by the node.",
+		MetricCheckpointTimeouts:   "Checkpoints which reached no decision in time.",
+		MetricCheckpointDuration:   "Time taken by the checkpoints to reach a decision.",
+		MetricCheckpointsPending:   "Queries waiting for a checkpoint to be started by the node.",
+		MetricCheckpointBatchSize:  "Maximum number of queries proposed by the checkpoints started by the node.",
+		MetricRecoveryAttempts:     "Attempts to recover a key from the peers.",
+		MetricMemoryUsed:           "Serialized size of the pending queries and endorsements.",
+		MetricMemoryBudget:         "Serialized size of the pending queries and endorsements above which the queries of peers are rejected, unlimited if zero.",
+		MetricNetworkPeers:         "Peers the node is connected to.",
+		MetricNetworkReceived:      "Messages received from the network.",
+		MetricNetworkPublished:     "Messages published to the network.",
+		MetricNetworkInvalid:       "Messages received from the network which could not be decoded.",
+		MetricNetworkDropped:       "Messages received from the network and dropped before the engine accepted them, see AcceptDropper.",
+		MetricEventsDropped:        "Events of the engine dropped because of slow subscribers.",
+		MetricStoreCorruptions:     "Values read from the store which did not match their version.",
+		MetricStoreCacheHits:       "Reads of the store served from the cache of recently read values.",
+		MetricStoreCacheMisses:     "Reads of the store forwarded by the cache to the database.",
+		MetricStoreCacheEntries:    "Values held by the cache of recently read values.",
+		MetricStoreCacheBytes:      "Approximate size of the values held by the cache of recently read values.",
+		MetricEngineRestarts:       "Restarts of the loops of the engine after a panic, and panics of its message handlers.",
+		MetricAPIRequests:          "Requests served by the API, by method and status code.",
+	}
+}
+
+// Metrics receives the measures of the engine, and of its network and its
+// store if they are MetricsCollectors. Counters only increase, and observations are distributed
+// in histograms. Implementations must be thread-safe.
+type Metrics interface {
+	Count(name string, delta float64)
+	Observe(name string, value float64)
+	Set(name string, value float64) // gauges
+}
+
+// MetricsCollector is an interface that can optionally be proposed by Networks
+// and Stores to report their own measures along with those of the engine.
+type MetricsCollector interface {
+	// CollectMetrics reports the measures taken since the previous call,
+	// and the current value of the gauges.
+	CollectMetrics(m Metrics)
+}
+
+func (eng *Engine) count(name string, delta float64) {
+	if eng.Metrics != nil {
+		eng.Metrics.Count(name, delta)
+	}
+}
+
+func (eng *Engine) observe(name string, value float64) {
+	if eng.Metrics != nil {
+		eng.Metrics.Observe(name, value)
+	}
+}
+
+// countTotal counts the increase of a total since the previous call, whose
+// value is kept in collected.
+func (eng *Engine) countTotal(name string, total uint64, collected *uint64) {
+	eng.count(name, float64(total)-float64(atomic.SwapUint64(collected, total)))
+}
+
+// countDecisions counts the queries committed and dropped. Queries dropped at
+// or after their deadline are counted as expired too.
+func (eng *Engine) countDecisions(decisions []decision) {
+	for _, d := range decisions {
+		if d.committed {
+			eng.count(MetricQueriesCommitted, 1)
+			continue
+		}
+		eng.count(MetricQueriesDropped, 1)
+		if !d.deadline.IsZero() && !d.resolved.Before(d.deadline) {
+			eng.count(MetricQueriesExpired, 1)
+		}
+	}
+}
+
+// CollectMetrics sets the gauges of the engine, and collects the measures of
+// its network and its store. It is typically called before the metrics are exported, and
+// does nothing if the engine has no Metrics.
+// This function is thread-safe.
+func (eng *Engine) CollectMetrics() {
+	m := eng.Metrics
+	if m == nil {
+		return
+	}
+
+	stored, pending := eng.qs.Size()
+	m.Set(MetricQueriesStored, float64(stored))
+	m.Set(MetricQueriesPending, float64(pending))
+	m.Set(MetricCheckpointsPending, float64(eng.PendingCheckpoints()))
+	m.Set(MetricCheckpointBatchSize, float64(eng.CheckpointBatchSize()))
+	for loop, n := range eng.Restarts() {
+		m.Set(fmt.Sprintf("%s{loop=%q}", MetricEngineRestarts, loop), float64(n))
+	}
+	m.Set(MetricMemoryUsed, float64(eng.qs.Memory()))
+	m.Set(MetricMemoryBudget, float64(eng.MemoryBudget))
+
+	eng.countTotal(MetricEventsDropped, eng.DroppedEvents(), &eng.collectedEvents)
+	if d, ok := eng.Network.(AcceptDropper); ok {
+		eng.countTotal(MetricNetworkDropped, d.AcceptDrops(), &eng.collectedDrops)
+	}
+
+	if c, ok := eng.Network.(MetricsCollector); ok {
+		c.CollectMetrics(m)
+	}
+	if c, ok := eng.Store.(MetricsCollector); ok {
+		c.CollectMetrics(m)
+	}
+}
+
+// Size returns the number of queries held by the store, and how many of them
+// are pending.
+// This function is thread-safe.
+func (qs *queryStore) Size() (stored, pending int) {
+	qs.RLock()
+	defer qs.RUnlock()
+
+	for _, qi := range qs.queries {
+		if qi.State == qPending {
+			pending++
+		}
+	}
+	return len(qs.queries), pending
+}
@@ -0,0 +1,143 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMetrics records the counters and gauges, and the number of
+// observations of each histogram.
+type fakeMetrics struct {
+	sync.Mutex
+	values       map[string]float64
+	observations map[string]int
+}
+
+func newFakeMetrics() *fakeMetrics {
+	return &fakeMetrics{values: make(map[string]float64), observations: make(map[string]int)}
+}
+
+func (m *fakeMetrics) Count(name string, delta float64) {
+	m.Lock()
+	defer m.Unlock()
+	m.values[name] += delta
+}
+
+func (m *fakeMetrics) Observe(name string, value float64) {
+	m.Lock()
+	defer m.Unlock()
+	m.observations[name]++
+}
+
+func (m *fakeMetrics) Set(name string, value float64) {
+	m.Lock()
+	defer m.Unlock()
+	m.values[name] = value
+}
+
+func (m *fakeMetrics) get(name string) float64 {
+	m.Lock()
+	defer m.Unlock()
+	return m.values[name]
+}
+
+func TestEngine_Metrics(t *testing.T) {
+	m := newFakeMetrics()
+	engines, stop := startEngines(t, 1, 1, func(_ int, eng *Engine) { eng.Metrics = m })
+	defer stop()
+	eng := engines[0]
+
+	q := concat("k", "a", nil)
+	q.SetTimeout(time.Minute)
+	s, err := eng.SubmitAndWait(context.Background(), q)
+	require.Nil(t, err)
+	require.True(t, s.Applied)
+
+	// The query and the endorsement of the node come back from the network
+	for start := time.Now(); m.get(MetricQueriesReceived) == 0 && time.Since(start) < time.Second; {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.Equal(t, 1.0, m.get(MetricQueriesSubmitted))
+	require.Equal(t, 1.0, m.get(MetricQueriesReceived))
+	require.Equal(t, 1.0, m.get(MetricEndorsementsSent))
+	require.Equal(t, 1.0, m.get(MetricEndorsementsReceived))
+	require.Equal(t, 1.0, m.get(MetricQueriesCommitted))
+	require.Equal(t, 0.0, m.get(MetricQueriesDropped))
+
+	eng.CollectMetrics()
+	require.Equal(t, 1.0, m.get(MetricQueriesStored))
+	require.Equal(t, 0.0, m.get(MetricQueriesPending))
+	require.Equal(t, 0.0, m.get(MetricMemoryUsed), "committed queries should be released")
+	require.Equal(t, float64(eng.CheckpointBatchSize()), m.get(MetricCheckpointBatchSize))
+}
+
+// droppingNetwork reports messages dropped by Accept.
+type droppingNetwork struct {
+	recordingNetwork
+	drops uint64
+}
+
+func (n *droppingNetwork) AcceptDrops() uint64 {
+	return atomic.LoadUint64(&n.drops)
+}
+
+func TestEngine_MetricsTotals(t *testing.T) {
+	network := &droppingNetwork{}
+	eng := NewEngine(newMemoryStore(), network, nil, nil, 1)
+	eng.MemoryBudget = 1024
+	m := newFakeMetrics()
+	eng.Metrics = m
+
+	// Totals are counted once, as they increase
+	atomic.StoreUint64(&network.drops, 3)
+	eng.events.mutex.Lock()
+	eng.events.dropped = 2
+	eng.events.mutex.Unlock()
+	eng.CollectMetrics()
+	eng.CollectMetrics()
+	require.Equal(t, 3.0, m.get(MetricNetworkDropped))
+	require.Equal(t, 2.0, m.get(MetricEventsDropped))
+
+	atomic.AddUint64(&network.drops, 1)
+	eng.CollectMetrics()
+	require.Equal(t, 4.0, m.get(MetricNetworkDropped))
+	require.Equal(t, 2.0, m.get(MetricEventsDropped))
+	require.Equal(t, 1024.0, m.get(MetricMemoryBudget))
+}
+
+func TestEngine_MetricsDecisions(t *testing.T) {
+	eng := NewEngine(nil, nil, nil, nil, 1)
+	m := newFakeMetrics()
+	eng.Metrics = m
+
+	now := time.Now()
+	eng.countDecisions([]decision{
+		{uuid: "committed", deadline: now, resolved: now.Add(-time.Second), committed: true},
+		{uuid: "dropped", deadline: now, resolved: now.Add(-time.Second)},
+		{uuid: "expired", deadline: now, resolved: now},
+		{uuid: "unknown", resolved: now},
+	})
+	require.Equal(t, 1.0, m.get(MetricQueriesCommitted))
+	require.Equal(t, 3.0, m.get(MetricQueriesDropped))
+	require.Equal(t, 1.0, m.get(MetricQueriesExpired))
+
+	// Engines without metrics measure nothing
+	eng.Metrics = nil
+	eng.countDecisions([]decision{{uuid: "committed", committed: true}})
+	eng.CollectMetrics()
+	require.Equal(t, 1.0, m.get(MetricQueriesCommitted))
+}
@@ -0,0 +1,197 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// recordingNetwork is a Network that records broadcast messages without
+// delivering them to anyone. It is used by tests.
+type recordingNetwork struct {
+	sync.Mutex
+	messages []proto.Message
+}
+
+func (n *recordingNetwork) Close() error {
+	return nil
+}
+
+func (n *recordingNetwork) Broadcast(_ context.Context, m proto.Message) error {
+	n.Lock()
+	defer n.Unlock()
+	n.messages = append(n.messages, m)
+	return nil
+}
+
+func (n *recordingNetwork) Accept(ctx context.Context, acceptor MessageAcceptor) <-chan proto.Message {
+	c := make(chan proto.Message)
+	go func() {
+		<-ctx.Done()
+		close(c)
+	}()
+	return c
+}
+
+// hub connects hubNetworks together: messages broadcast by one node are
+// delivered to every node, including itself (like gossipsub). It is used by
+// tests.
+type hub struct {
+	sync.Mutex
+	subscribers []*hubSubscriber
+}
+
+type hubSubscriber struct {
+	acceptor MessageAcceptor
+	c        chan proto.Message
+	ctx      context.Context
+}
+
+type hubNetwork struct {
+	*hub
+}
+
+// waitSubscribers waits until n subscribers have joined the hub, since
+// engines subscribe asynchronously once started. Subscribers of stopped
+// engines are not counted.
+func (h *hub) waitSubscribers(t *testing.T, n int) {
+	for i := 0; ; i++ {
+		h.Lock()
+		var count int
+		for _, s := range h.subscribers {
+			if s.ctx.Err() == nil {
+				count++
+			}
+		}
+		h.Unlock()
+
+		if count >= n {
+			return
+		}
+		if i == 100 {
+			t.Fatal("missing hub subscribers", count, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (h *hub) join() *hubNetwork {
+	return &hubNetwork{hub: h}
+}
+
+func (n *hubNetwork) Close() error {
+	return nil
+}
+
+func (n *hubNetwork) Broadcast(_ context.Context, m proto.Message) error {
+	n.Lock()
+	defer n.Unlock()
+
+	for _, s := range n.subscribers {
+		if s.ctx.Err() != nil || !s.acceptor(m) {
+			continue
+		}
+
+		// Each node receives its own copy, as if it was decoded from the wire
+		go func(s *hubSubscriber, m proto.Message) {
+			select {
+			case s.c <- m:
+			case <-s.ctx.Done():
+			}
+		}(s, proto.Clone(m))
+	}
+	return nil
+}
+
+func (n *hubNetwork) Accept(ctx context.Context, acceptor MessageAcceptor) <-chan proto.Message {
+	n.Lock()
+	defer n.Unlock()
+
+	s := &hubSubscriber{
+		acceptor: acceptor,
+		c:        make(chan proto.Message),
+		ctx:      ctx,
+	}
+	n.subscribers = append(n.subscribers, s)
+	return s.c
+}
+
+// lossyHubNetwork is a hubNetwork which never delivers the messages rejected
+// by drop.
+type lossyHubNetwork struct {
+	*hubNetwork
+	drop func(proto.Message) bool
+}
+
+func (n *lossyHubNetwork) Broadcast(ctx context.Context, m proto.Message) error {
+	if n.drop(m) {
+		return nil
+	}
+	return n.hubNetwork.Broadcast(ctx, m)
+}
+
+// syncHub connects syncHubNetworks, which are hubNetworks serving their
+// pending queries to each other. It is used by tests.
+type syncHub struct {
+	hub
+	handlers map[string]PendingSyncHandler
+	contexts map[string]context.Context
+}
+
+type syncHubNetwork struct {
+	*hubNetwork
+	sh   *syncHub
+	name string
+}
+
+func (h *syncHub) join(name string) *syncHubNetwork {
+	return &syncHubNetwork{hubNetwork: h.hub.join(), sh: h, name: name}
+}
+
+func (n *syncHubNetwork) PendingSyncPeers() []string {
+	n.sh.Lock()
+	defer n.sh.Unlock()
+
+	var peers []string
+	for name, ctx := range n.sh.contexts {
+		if name != n.name && ctx.Err() == nil {
+			peers = append(peers, name)
+		}
+	}
+	return peers
+}
+
+func (n *syncHubNetwork) RequestPendingSync(ctx context.Context, peer string, req *PendingSyncRequest) (*PendingSyncResponse, error) {
+	n.sh.Lock()
+	handler := n.sh.handlers[peer]
+	n.sh.Unlock()
+
+	if handler == nil {
+		return nil, errors.New("unknown peer")
+	}
+	return handler(req)
+}
+
+func (n *syncHubNetwork) AcceptPendingSync(ctx context.Context, handler PendingSyncHandler) {
+	n.sh.Lock()
+	defer n.sh.Unlock()
+
+	if n.sh.handlers == nil {
+		n.sh.handlers = make(map[string]PendingSyncHandler)
+		n.sh.contexts = make(map[string]context.Context)
+	}
+	n.sh.handlers[n.name] = handler
+	n.sh.contexts[n.name] = ctx
+}
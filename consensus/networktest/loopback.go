@@ -0,0 +1,52 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package networktest
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+// Loopback is a network delivering the messages of a single node to itself.
+type Loopback struct {
+	subs consensus.Subscriptions
+}
+
+// NewLoopback returns a loopback network, with Accept channels of buffer
+// messages.
+func NewLoopback(buffer int) *Loopback {
+	return &Loopback{subs: consensus.Subscriptions{Buffer: buffer}}
+}
+
+// Broadcast delivers a copy of m.
+func (l *Loopback) Broadcast(ctx context.Context, m proto.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.subs.Deliver(proto.Clone(m))
+	return nil
+}
+
+// Accept returns a channel delivering the messages accepted by acceptor.
+func (l *Loopback) Accept(ctx context.Context, acceptor consensus.MessageAcceptor) <-chan proto.Message {
+	return l.subs.Subscribe(ctx, acceptor)
+}
+
+// AcceptDrops returns the number of messages dropped by Accept.
+func (l *Loopback) AcceptDrops() uint64 {
+	return l.subs.AcceptDrops()
+}
+
+// Close closes the channels returned by Accept.
+func (l *Loopback) Close() error {
+	l.subs.Close()
+	return nil
+}
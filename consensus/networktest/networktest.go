@@ -0,0 +1,188 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+// Package networktest checks that network implementations fulfill the
+// contract of consensus.Network.
+//
+// It shall not be included in the final package.
+package networktest
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+// Config describes the networks under test.
+type Config struct {
+	// New returns a network receiving its own broadcasts, with Accept
+	// channels of Buffer messages. It is closed by the suite.
+	New    func(t *testing.T) consensus.Network
+	Buffer int
+
+	// Timeout is the time given to a broadcast message to be received, one
+	// second if zero.
+	Timeout time.Duration
+}
+
+func (c Config) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return time.Second
+	}
+	return c.Timeout
+}
+
+// RunConformance runs the conformance suite against the networks of c.
+func RunConformance(t *testing.T, c Config) {
+	require.True(t, c.Buffer > 0, "the capacity of the Accept channels is required")
+
+	t.Run("Delivery", func(t *testing.T) { testDelivery(t, c) })
+	t.Run("Overflow", func(t *testing.T) { testOverflow(t, c) })
+	t.Run("Unsubscription", func(t *testing.T) { testUnsubscription(t, c) })
+	t.Run("Close", func(t *testing.T) { testClose(t, c) })
+}
+
+func isQuery(m proto.Message) bool {
+	_, ok := m.(*consensus.Query)
+	return ok
+}
+
+func isEndorsement(m proto.Message) bool {
+	_, ok := m.(*consensus.Endorsement)
+	return ok
+}
+
+func receive(t *testing.T, c <-chan proto.Message, timeout time.Duration) proto.Message {
+	select {
+	case m, ok := <-c:
+		require.True(t, ok, "the channel should not be closed")
+		return m
+	case <-time.After(timeout):
+		require.FailNow(t, "no message received in time")
+		return nil
+	}
+}
+
+// waitClosed drains c until it is closed.
+func waitClosed(t *testing.T, c <-chan proto.Message, timeout time.Duration) {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case _, ok := <-c:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			require.FailNow(t, "the channel should be closed")
+		}
+	}
+}
+
+func testDelivery(t *testing.T, c Config) {
+	n := c.New(t)
+	defer func() { _ = n.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queries := n.Accept(ctx, isQuery)
+	endorsements := n.Accept(ctx, isEndorsement)
+
+	q := consensus.NewQuery()
+	e := &consensus.Endorsement{Uuid: q.Uuid, Emitter: "emitter"}
+	require.Nil(t, n.Broadcast(ctx, e))
+	require.Nil(t, n.Broadcast(ctx, q))
+
+	m := receive(t, queries, c.timeout())
+	require.Equal(t, q.Uuid, m.(*consensus.Query).Uuid, "only accepted messages should be delivered")
+	m = receive(t, endorsements, c.timeout())
+	require.Equal(t, e.Uuid, m.(*consensus.Endorsement).Uuid)
+}
+
+// testOverflow checks that a channel not read does not prevent the delivery
+// of messages to other channels.
+func testOverflow(t *testing.T, c Config) {
+	n := c.New(t)
+	defer func() { _ = n.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stalled := n.Accept(ctx, isQuery)
+	queries := n.Accept(ctx, isQuery)
+
+	// Messages are sent one at a time, for the consumer of queries to keep up
+	count := 2*c.Buffer + 10
+	for i := 0; i < count; i++ {
+		q := consensus.NewQuery()
+		require.Nil(t, n.Broadcast(ctx, q))
+		m := receive(t, queries, c.timeout())
+		require.Equal(t, q.Uuid, m.(*consensus.Query).Uuid, "messages should be delivered despite a full channel")
+	}
+
+	// The full channel may still be receiving messages delayed by the network
+	d, ok := n.(consensus.AcceptDropper)
+	deadline := time.Now().Add(c.timeout())
+	for ok && d.AcceptDrops() < uint64(count-c.Buffer) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Len(t, stalled, c.Buffer, "the full channel should have buffered messages")
+	if ok {
+		require.True(t, d.AcceptDrops() >= uint64(count-c.Buffer), "dropped messages should be counted")
+	}
+}
+
+func testUnsubscription(t *testing.T, c Config) {
+	n := c.New(t)
+	defer func() { _ = n.Close() }()
+
+	// Let the network start its own goroutines
+	time.Sleep(50 * time.Millisecond)
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	channels := make([]<-chan proto.Message, 50)
+	for i := range channels {
+		channels[i] = n.Accept(ctx, isQuery)
+	}
+	require.Nil(t, n.Broadcast(context.Background(), consensus.NewQuery()))
+	receive(t, channels[0], c.timeout())
+
+	cancel()
+	for _, ch := range channels {
+		waitClosed(t, ch, time.Second)
+	}
+
+	var after int
+	for i := 0; i < 100; i++ {
+		after = runtime.NumGoroutine()
+		if after <= before+len(channels)/10 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	require.True(t, after <= before+len(channels)/10, "subscriptions should not leak goroutines")
+
+	// The network is still usable
+	queries := n.Accept(context.Background(), isQuery)
+	q := consensus.NewQuery()
+	require.Nil(t, n.Broadcast(context.Background(), q))
+	require.Equal(t, q.Uuid, receive(t, queries, c.timeout()).(*consensus.Query).Uuid)
+}
+
+func testClose(t *testing.T, c Config) {
+	n := c.New(t)
+	queries := n.Accept(context.Background(), isQuery)
+	require.Nil(t, n.Close())
+	waitClosed(t, queries, time.Second)
+}
@@ -0,0 +1,22 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package networktest
+
+import (
+	"testing"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+func TestLoopback_Conformance(t *testing.T) {
+	RunConformance(t, Config{
+		New:    func(*testing.T) consensus.Network { return NewLoopback(16) },
+		Buffer: 16,
+	})
+}
@@ -0,0 +1,297 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"crypto/sha512"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"go.uber.org/zap"
+)
+
+// DefaultNodeStatusPeriod is the interval between two NodeStatus broadcasts
+// expected from other nodes, when NodeStatusPeriod is not set.
+const DefaultNodeStatusPeriod = 30 * time.Second
+
+// nodeStatusWindow is the number of periods during which the status of a
+// node is reported, if no fresher one is received.
+const nodeStatusWindow = 3
+
+// maxNodeStatuses bounds the number of nodes whose status is kept.
+const maxNodeStatuses = 1024
+
+// nodeStatusTracker keeps the latest status received from every node, along
+// with the local information needed to build the status of the node.
+//
+// nodeStatusTracker is thread-safe.
+type nodeStatusTracker struct {
+	mutex      sync.Mutex
+	started    time.Time
+	lastCommit time.Time
+	statuses   map[string]nodeStatusSample // by emitter
+}
+
+type nodeStatusSample struct {
+	status   *NodeStatus
+	sent     time.Time // emitter time
+	received time.Time // local time
+}
+
+func (t *nodeStatusTracker) start(now time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.started = now
+}
+
+func (t *nodeStatusTracker) committed(now time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.lastCommit = now
+}
+
+func (t *nodeStatusTracker) local() (started, lastCommit time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.started, t.lastCommit
+}
+
+// limited returns true if a status of the emitter has been received less
+// than half a period ago.
+func (t *nodeStatusTracker) limited(emitter string, now time.Time, period time.Duration) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.limitedInternal(emitter, now, period)
+}
+
+func (t *nodeStatusTracker) limitedInternal(emitter string, now time.Time, period time.Duration) bool { // unsafe
+	last, ok := t.statuses[emitter]
+	return ok && now.Sub(last.received) < period/2
+}
+
+// add records the status of a node, emitted at time sent, and returns false
+// if it has been rejected, because it is rate-limited or not more recent
+// than the last one of the same emitter.
+func (t *nodeStatusTracker) add(ns *NodeStatus, sent, now time.Time, period time.Duration) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.limitedInternal(ns.Emitter, now, period) {
+		return false
+	}
+
+	last, ok := t.statuses[ns.Emitter]
+	if ok && !sent.After(last.sent) {
+		return false
+	}
+
+	if t.statuses == nil {
+		t.statuses = make(map[string]nodeStatusSample)
+	}
+	if !ok && len(t.statuses) >= maxNodeStatuses {
+		t.evict(now.Add(-nodeStatusWindow * period))
+	}
+	t.statuses[ns.Emitter] = nodeStatusSample{status: ns, sent: sent, received: now}
+	return true
+}
+
+// evict removes the statuses received before limit, or the oldest one if
+// all of them are fresh.
+// unsafe
+func (t *nodeStatusTracker) evict(limit time.Time) {
+	var oldest string
+	for emitter, s := range t.statuses {
+		if s.received.Before(limit) {
+			delete(t.statuses, emitter)
+			continue
+		}
+		if oldest == "" || s.received.Before(t.statuses[oldest].received) {
+			oldest = emitter
+		}
+	}
+
+	if len(t.statuses) >= maxNodeStatuses {
+		delete(t.statuses, oldest)
+	}
+}
+
+// list returns the statuses received since limit.
+func (t *nodeStatusTracker) list(limit time.Time) []*NodeStatus {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var list []*NodeStatus
+	for _, s := range t.statuses {
+		if !s.received.Before(limit) {
+			list = append(list, s.status)
+		}
+	}
+	return list
+}
+
+// Hash returns a fixed-size hash of the (unsigned) version of the status.
+// Passed by value because of internal modifications.
+func (ns NodeStatus) Hash() ([]byte, error) {
+	ns.Signature = nil
+	raw, err := proto.Marshal(&ns)
+	hash := sha512.Sum512(raw)
+	return hash[:], err
+}
+
+// statusPeriod returns the interval between two NodeStatus broadcasts.
+func (eng *Engine) statusPeriod() time.Duration {
+	if eng.NodeStatusPeriod > 0 {
+		return eng.NodeStatusPeriod
+	}
+	return DefaultNodeStatusPeriod
+}
+
+// NodeStatus returns the current status of the node, unsigned.
+// This function is thread-safe.
+func (eng *Engine) NodeStatus() (*NodeStatus, error) {
+	started, lastCommit := eng.nodeStatus.local()
+	now := eng.now()
+	ts, err := ptypes.TimestampProto(now)
+	if err != nil {
+		return nil, err
+	}
+
+	ns := &NodeStatus{
+		Emitter:            eng.Identity(),
+		Time:               ts,
+		Version:            eng.NodeVersion,
+		Pending:            uint32(len(eng.qs.PendingQueries())),
+		PendingCheckpoints: uint32(eng.PendingCheckpoints()),
+	}
+	if !started.IsZero() {
+		ns.Uptime = uint64(eng.clock().Now().Sub(started) / time.Second)
+	}
+	if !lastCommit.IsZero() {
+		ns.LastCommit, err = ptypes.TimestampProto(lastCommit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	eng.Store.Lock()
+	list, err := eng.Store.List()
+	eng.Store.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	ns.Keys = uint64(len(list))
+	return ns, nil
+}
+
+// ClusterStatus returns the status of the node, followed by the latest
+// statuses broadcast by the other nodes, sorted by emitter. Statuses that
+// have not been refreshed for a few periods are omitted.
+// This function is thread-safe.
+func (eng *Engine) ClusterStatus() ([]*NodeStatus, error) {
+	own, err := eng.NodeStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	others := eng.nodeStatus.list(eng.clock().Now().Add(-nodeStatusWindow * eng.statusPeriod()))
+	sort.Slice(others, func(i, j int) bool { return others[i].Emitter < others[j].Emitter })
+	return append([]*NodeStatus{own}, others...), nil
+}
+
+func (eng *Engine) runNodeStatus(ctx context.Context) {
+	eng.nodeStatus.start(eng.clock().Now())
+
+	go func() {
+		acceptor := func(m proto.Message) bool {
+			_, ok := m.(*NodeStatus)
+			return ok
+		}
+
+		for m := range eng.Network.Accept(ctx, acceptor) {
+			eng.handleNodeStatus(m.(*NodeStatus))
+		}
+	}()
+
+	if eng.NodeStatusPeriod <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(eng.NodeStatusPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				eng.emitNodeStatus()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (eng *Engine) emitNodeStatus() {
+	ns, err := eng.NodeStatus()
+	if err == nil {
+		err = eng.signNodeStatus(ns)
+	}
+	if err != nil {
+		zap.L().Warn("NodeStatus", zap.Error(err))
+		return
+	}
+
+	_ = eng.broadcast(ns)
+}
+
+func (eng *Engine) handleNodeStatus(ns *NodeStatus) {
+	if ns.Emitter == eng.Identity() {
+		return
+	}
+
+	// Flooding emitters are rejected before checking their signatures
+	period := eng.statusPeriod()
+	now := eng.clock().Now()
+	if eng.nodeStatus.limited(ns.Emitter, now, period) {
+		zap.L().Debug("Invalid node status",
+			zap.String("emitter", ns.Emitter),
+			zap.String("reason", "rateLimited"),
+		)
+		return
+	}
+
+	err := eng.verifyNodeStatus(ns)
+	if err != nil {
+		zap.L().Debug("Invalid node status",
+			zap.String("emitter", ns.Emitter),
+			zap.Error(err),
+		)
+		return
+	}
+
+	sent, err := ptypes.Timestamp(ns.Time)
+	if err != nil || sent.Before(eng.now().Add(-nodeStatusWindow*period)) {
+		zap.L().Debug("Invalid node status",
+			zap.String("emitter", ns.Emitter),
+			zap.String("reason", "outdated"),
+		)
+		return
+	}
+
+	if !eng.nodeStatus.add(ns, sent, now, period) {
+		zap.L().Debug("Invalid node status",
+			zap.String("emitter", ns.Emitter),
+			zap.String("reason", "rateLimited"),
+		)
+	}
+}
@@ -0,0 +1,117 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_ClusterStatus(t *testing.T) {
+	const n = 3
+	const period = 100 * time.Millisecond
+
+	keyrings := tests.GetTestKeyRings(t, n)
+	h := &hub{}
+	engines := make([]*Engine, n)
+	for i := range engines {
+		engines[i] = NewEngine(newMemoryStore(), h.join(), passBBC{}, keyrings[i], n)
+		engines[i].NodeStatusPeriod = period
+		engines[i].NodeVersion = "test"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	start := time.Now()
+	for _, eng := range engines {
+		require.Nil(t, eng.Run(ctx))
+	}
+
+	for i, eng := range engines {
+		for {
+			nodes, err := eng.ClusterStatus()
+			require.Nil(t, err)
+			require.Equal(t, eng.Identity(), nodes[0].Emitter, "own status must come first")
+			if len(nodes) == n {
+				for _, ns := range nodes[1:] {
+					require.NotEqual(t, eng.Identity(), ns.Emitter)
+					require.Equal(t, "test", ns.Version)
+				}
+				break
+			}
+
+			if time.Since(start) > 3*period {
+				t.Fatalf("node %d only reports %d statuses after %s", i, len(nodes), time.Since(start))
+			}
+			time.Sleep(period / 10)
+		}
+	}
+}
+
+func TestEngine_HandleNodeStatus(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 2)
+	emitter := NewEngine(newMemoryStore(), nil, nil, keyrings[0], 2)
+	eng := NewEngine(newMemoryStore(), nil, nil, keyrings[1], 2)
+	eng.NodeStatusPeriod = time.Minute
+
+	ns, err := emitter.NodeStatus()
+	require.Nil(t, err)
+	ns.Keys = 42
+	require.Nil(t, emitter.signNodeStatus(ns))
+
+	forged := *ns
+	forged.Keys = 1
+	eng.handleNodeStatus(&forged)
+	require.Empty(t, eng.nodeStatus.list(time.Time{}), "forged status must be rejected")
+
+	outdated, err := emitter.NodeStatus()
+	require.Nil(t, err)
+	outdated.Time, _ = ptypes.TimestampProto(time.Now().Add(-time.Hour))
+	require.Nil(t, emitter.signNodeStatus(outdated))
+	eng.handleNodeStatus(outdated)
+	require.Empty(t, eng.nodeStatus.list(time.Time{}), "outdated status must be rejected")
+
+	eng.handleNodeStatus(ns)
+	list := eng.nodeStatus.list(time.Time{})
+	require.Len(t, list, 1)
+	require.Equal(t, uint64(42), list[0].Keys)
+
+	again, err := emitter.NodeStatus()
+	require.Nil(t, err)
+	require.Nil(t, emitter.signNodeStatus(again))
+	eng.handleNodeStatus(again)
+	require.Equal(t, uint64(42), eng.nodeStatus.list(time.Time{})[0].Keys, "status must be rate-limited")
+}
+
+func TestNodeStatusTracker(t *testing.T) {
+	const period = time.Second
+	var tracker nodeStatusTracker
+	now := time.Now()
+
+	ns := &NodeStatus{Emitter: "a"}
+	require.True(t, tracker.add(ns, now, now, period))
+	require.False(t, tracker.add(ns, now.Add(time.Millisecond), now.Add(period/4), period), "rate limited")
+	require.False(t, tracker.add(ns, now, now.Add(period), period), "replayed")
+	require.True(t, tracker.add(ns, now.Add(period), now.Add(period), period))
+
+	require.Len(t, tracker.list(now.Add(period)), 1)
+	require.Empty(t, tracker.list(now.Add(2*period)), "stale statuses must be omitted")
+
+	for i := 0; i < 2*maxNodeStatuses; i++ {
+		at := now.Add(time.Duration(i) * time.Millisecond)
+		require.True(t, tracker.add(&NodeStatus{Emitter: fmt.Sprint("node", i)}, at, at, period))
+	}
+	require.Len(t, tracker.statuses, maxNodeStatuses)
+}
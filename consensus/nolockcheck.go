@@ -0,0 +1,18 @@
+//go:build !lockcheck
+// +build !lockcheck
+
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+func lockAcquire(_ lockLevel, lock func()) { lock() }
+
+func lockRelease(lockLevel) {}
+
+func checkedStore(s Store) Store { return s }
@@ -0,0 +1,155 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"bytes"
+	"crypto/rand"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// NoncePrefix is the prefix of the keys holding the nonces applied to each
+// key, followed by the key itself. These keys are local to the node.
+//
+// Operations carrying a nonce are skipped when their nonce has already been
+// applied to their key, so that operations which are not idempotent (such
+// as CONCAT) are applied once even if they are submitted again in another
+// query, for instance by a client retrying through another node. Only the
+// latest nonceHistory nonces of each key are kept.
+const NoncePrefix = "_nonces/"
+
+const (
+	nonceHistory = 64
+	nonceSize    = 16
+)
+
+// NewNonce returns a random nonce for an operation.
+func NewNonce() []byte {
+	nonce := make([]byte, nonceSize)
+	_, _ = rand.Read(nonce)
+	return nonce
+}
+
+// Idempotent returns true if applying the operation several times is the
+// same as applying it once. Other operations should carry a nonce.
+func (o *Operation) Idempotent() bool {
+	switch o.Op {
+	case Operation_CONCAT, Operation_ADD, Operation_MUL, Operation_INCR, Operation_DECR,
+		Operation_LPUSH, Operation_RPUSH, Operation_LPOP:
+		return false
+	default:
+		return true
+	}
+}
+
+func nonceKey(key string) string {
+	return NoncePrefix + key
+}
+
+// skipAppliedNonces returns a copy of q without the operations whose nonce
+// has already been applied to their key, along with the updated nonces of
+// the keys, to be stored with the values of q.
+// unsafe
+func (eng *Engine) skipAppliedNonces(q *Query) (*Query, map[string]*AppliedNonces, error) {
+	var histories map[string]*AppliedNonces
+	var ops []*Operation
+	for i, op := range q.Operations {
+		if len(op.Nonce) == 0 {
+			if ops != nil {
+				ops = append(ops, op)
+			}
+			continue
+		}
+
+		if histories == nil {
+			histories = make(map[string]*AppliedNonces)
+		}
+		h, ok := histories[op.Key]
+		if !ok {
+			var err error
+			h, err = eng.loadNonces(op.Key)
+			if err != nil {
+				return nil, nil, err
+			}
+			histories[op.Key] = h
+		}
+
+		if !h.contains(op.Nonce) {
+			h.Nonces = append(h.Nonces, op.Nonce)
+			if ops != nil {
+				ops = append(ops, op)
+			}
+			continue
+		}
+
+		if ops == nil {
+			ops = append(make([]*Operation, 0, len(q.Operations)), q.Operations[:i]...)
+		}
+	}
+
+	for _, h := range histories {
+		if len(h.Nonces) > nonceHistory {
+			h.Nonces = h.Nonces[len(h.Nonces)-nonceHistory:]
+		}
+	}
+
+	if ops == nil {
+		return q, histories, nil
+	}
+
+	filtered := *q
+	filtered.Operations = ops
+	return &filtered, histories, nil
+}
+
+// encodeNonces returns the keys and values holding the nonces of several
+// keys, in key order.
+func encodeNonces(histories map[string]*AppliedNonces) ([]string, [][]byte, error) {
+	keys := make([]string, 0, len(histories))
+	for key := range histories {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		var err error
+		values[i], err = proto.Marshal(histories[key])
+		if err != nil {
+			return nil, nil, err
+		}
+		keys[i] = nonceKey(key)
+	}
+	return keys, values, nil
+}
+
+// loadNonces returns the nonces applied to a key.
+// unsafe
+func (eng *Engine) loadNonces(key string) (*AppliedNonces, error) {
+	h := &AppliedNonces{}
+	data, version, err := eng.Store.Get(nonceKey(key))
+	if version == NoVersion {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return h, proto.Unmarshal(data, h)
+}
+
+func (h *AppliedNonces) contains(nonce []byte) bool {
+	for _, n := range h.Nonces {
+		if bytes.Equal(n, nonce) {
+			return true
+		}
+	}
+	return false
+}
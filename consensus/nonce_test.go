@@ -0,0 +1,113 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func concat(key, data string, nonce []byte) *Query {
+	q := NewQuery()
+	q.SetTimeout(time.Second)
+	q.Operations = []*Operation{{Key: key, Op: Operation_CONCAT, Data: []byte(data), Nonce: nonce}}
+	return q
+}
+
+func TestEngine_NonceReplay(t *testing.T) {
+	engines, stop := startEngines(t, 1, 1)
+	defer stop()
+	eng := engines[0]
+
+	get := func(key string) string {
+		eng.Store.Lock()
+		defer eng.Store.Unlock()
+		value, _, err := eng.Store.Get(key)
+		require.Nil(t, err)
+		return string(value)
+	}
+
+	nonce := NewNonce()
+	q := concat("k", "a", nonce)
+	s, err := eng.SubmitAndWait(context.Background(), q)
+	require.Nil(t, err)
+	require.True(t, s.Applied)
+	require.Equal(t, "a", get("k"))
+
+	// The query is received again
+	eng.handleQuery(q)
+	require.Equal(t, "a", get("k"))
+
+	// The operation is submitted again in another query, as a client retrying
+	// through another node would
+	s, err = eng.SubmitAndWait(context.Background(), concat("k", "a", nonce))
+	require.Nil(t, err)
+	require.Equal(t, StateCommitted, s.State)
+	require.True(t, s.Applied)
+	require.Equal(t, "a", get("k"), "an operation must be applied once per nonce")
+
+	// The query is applied again once its marker has been pruned
+	eng.Store.Lock()
+	require.Nil(t, eng.Store.Set(appliedMarkerKey(q.Uuid), nil, NewVersion(nil)))
+	eng.Store.Unlock()
+	require.Nil(t, eng.apply(q.Uuid))
+	require.Equal(t, "a", get("k"))
+
+	// Operations without nonce are not protected
+	_, err = eng.SubmitAndWait(context.Background(), concat("k", "b", nil))
+	require.Nil(t, err)
+	_, err = eng.SubmitAndWait(context.Background(), concat("k", "b", nil))
+	require.Nil(t, err)
+	require.Equal(t, "abb", get("k"))
+}
+
+func TestEngine_NonceHistory(t *testing.T) {
+	qs := newQueryStore()
+	e := &Engine{Store: newMemoryStore(), qs: qs}
+	apply := func(q *Query) {
+		qs.AddQuery(q)
+		require.Nil(t, e.apply(q.Uuid))
+	}
+
+	first := NewNonce()
+	apply(concat("k", "a", first))
+	for i := 0; i < nonceHistory; i++ {
+		apply(concat("k", "", NewNonce()))
+	}
+
+	h, err := e.loadNonces("k")
+	require.Nil(t, err)
+	require.Len(t, h.Nonces, nonceHistory, "history must be bounded")
+	require.False(t, h.contains(first))
+
+	apply(concat("k", "a", first))
+	value, _, err := e.Store.Get("k")
+	require.Nil(t, err)
+	require.Equal(t, "aa", string(value), "forgotten nonces are applied again")
+
+	// Several operations of a query may share a nonce
+	q := NewQuery()
+	q.SetTimeout(time.Second)
+	nonce := NewNonce()
+	q.Operations = []*Operation{
+		{Key: "k", Op: Operation_CONCAT, Data: []byte("b"), Nonce: nonce},
+		{Key: "l", Op: Operation_CONCAT, Data: []byte("b"), Nonce: nonce},
+		{Key: "k", Op: Operation_CONCAT, Data: []byte("c"), Nonce: nonce},
+	}
+	apply(q)
+	value, _, err = e.Store.Get("k")
+	require.Nil(t, err)
+	require.Equal(t, "aab", string(value), "a nonce is applied once per key")
+	value, _, err = e.Store.Get("l")
+	require.Nil(t, err)
+	require.Equal(t, "b", string(value))
+}
@@ -11,7 +11,11 @@ package consensus
 import (
 	"bytes"
 	"errors"
+	"time"
 
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
 	"github.com/technicolor-research/pnyxdb/consensus/operations"
 )
 
@@ -24,13 +28,35 @@ const (
 	ParallelTypeDEFAULT ParallelType = 0x01 << iota
 	ParallelTypeDISALLOWDIFFERENT
 	ParallelTypeDISALLOWEQUAL
+	ParallelTypeFIELDS // map operations on different fields are parallel, other flags apply to the same field
 )
 
 // ParallelMatrix is used to know which operation can be run in parallel on a specific object.
+// Missing pairs are conflicting: in particular, SOFTDELETE, RESTORE and PRUNE conflict with
+// every operation on the same key, so that the network decides whether a RESTORE comes before
+// or after a concurrent SET. Likewise, REPLACE, TRUNCATE and SETRANGE do not commute with
+// SET, nor with each other. INCR and DECR commute, since integers cannot overflow, and so
+// do MIN with MIN and MAX with MAX. LPUSH and RPUSH commute, since list elements are
+// ordered by transaction, but LPOP conflicts with every operation. HSET and HDEL commute
+// on different fields, and on the same field only if they write the same value (HDEL
+// commutes with itself). DELETE only commutes with itself, and CAS conflicts with every
+// operation, itself included, so that at most one of concurrent swaps is committed.
+// EXPIRE, and operations with a TTL, conflict with every operation on the same key (see
+// CheckConflict), since the expiry of the key is set by the last one.
 var ParallelMatrix = map[Operation_Op]map[Operation_Op]ParallelType{
 	Operation_SET: {Operation_SET: ParallelTypeDISALLOWDIFFERENT},
 	Operation_ADD: {Operation_ADD: ParallelTypeDEFAULT},
 	Operation_MUL: {Operation_MUL: ParallelTypeDEFAULT},
+	Operation_INCR: {
+		Operation_INCR: ParallelTypeDEFAULT,
+		Operation_DECR: ParallelTypeDEFAULT,
+	},
+	Operation_DECR: {
+		Operation_DECR: ParallelTypeDEFAULT,
+		Operation_INCR: ParallelTypeDEFAULT,
+	},
+	Operation_MIN: {Operation_MIN: ParallelTypeDEFAULT},
+	Operation_MAX: {Operation_MAX: ParallelTypeDEFAULT},
 	Operation_SADD: {
 		Operation_SADD: ParallelTypeDEFAULT,
 		Operation_SREM: ParallelTypeDISALLOWEQUAL,
@@ -39,17 +65,59 @@ var ParallelMatrix = map[Operation_Op]map[Operation_Op]ParallelType{
 		Operation_SREM: ParallelTypeDEFAULT,
 		Operation_SADD: ParallelTypeDISALLOWEQUAL,
 	},
+	Operation_LPUSH: {
+		Operation_LPUSH: ParallelTypeDEFAULT,
+		Operation_RPUSH: ParallelTypeDEFAULT,
+	},
+	Operation_RPUSH: {
+		Operation_RPUSH: ParallelTypeDEFAULT,
+		Operation_LPUSH: ParallelTypeDEFAULT,
+	},
+	Operation_HSET: {
+		Operation_HSET: ParallelTypeFIELDS | ParallelTypeDISALLOWDIFFERENT,
+		Operation_HDEL: ParallelTypeFIELDS | ParallelTypeDISALLOWDIFFERENT,
+	},
+	Operation_HDEL: {
+		Operation_HDEL: ParallelTypeDEFAULT,
+		Operation_HSET: ParallelTypeFIELDS | ParallelTypeDISALLOWDIFFERENT,
+	},
+	Operation_DELETE: {Operation_DELETE: ParallelTypeDEFAULT},
 }
 
 var runners = map[Operation_Op]operations.Runner{
-	Operation_SET:    operations.Set,
-	Operation_CONCAT: operations.Append,
-	Operation_ADD:    operations.Add,
-	Operation_MUL:    operations.Mul,
-	Operation_SADD:   operations.Sadd,
-	Operation_SREM:   operations.Srem,
+	Operation_SET:      operations.Set,
+	Operation_CONCAT:   operations.Append,
+	Operation_REPLACE:  operations.Replace,
+	Operation_TRUNCATE: operations.Truncate,
+	Operation_SETRANGE: operations.SetRange,
+	Operation_CAS:      operations.CompareAndSwap,
+	Operation_EXPIRE:   operations.Expire,
+	Operation_ADD:      operations.Add,
+	Operation_MUL:      operations.Mul,
+	Operation_INCR:     operations.Incr,
+	Operation_DECR:     operations.Decr,
+	Operation_MIN:      operations.Min,
+	Operation_MAX:      operations.Max,
+	Operation_SADD:     operations.Sadd,
+	Operation_SREM:     operations.Srem,
+	Operation_LPUSH:    operations.Lpush,
+	Operation_RPUSH:    operations.Rpush,
+	Operation_LPOP:     operations.Lpop,
+	Operation_HSET:     operations.Hset,
+	Operation_HDEL:     operations.Hdel,
+
+	Operation_SOFTDELETE: operations.SoftDelete,
+	Operation_RESTORE:    operations.Restore,
+	Operation_PRUNE:      operations.Prune,
+	Operation_DELETE:     operations.Delete,
 }
 
+var (
+	errNotImplemented = errors.New("operation not yet implemented")
+	// ErrTTLNotSupported is returned by operations given a TTL they do not support.
+	ErrTTLNotSupported = errors.New("TTL only supported by SET, CONCAT, REPLACE, TRUNCATE, SETRANGE and CAS")
+)
+
 // CheckConflict returns an error if two operations cannot be executed in parallel.
 func (o *Operation) CheckConflict(o2 *Operation) error {
 	err := errors.New("non-parallel operations " + o.Op.String() + " / " + o2.Op.String())
@@ -57,6 +125,10 @@ func (o *Operation) CheckConflict(o2 *Operation) error {
 		return nil
 	}
 
+	if o.Ttl != nil || o2.Ttl != nil {
+		return err
+	}
+
 	if ParallelMatrix[o.Op] == nil {
 		return err
 	}
@@ -70,6 +142,14 @@ func (o *Operation) CheckConflict(o2 *Operation) error {
 		return nil // bypass further checks
 	}
 
+	if ParallelTypeFIELDS&t > 0 {
+		f1, ok1 := o.field()
+		f2, ok2 := o2.field()
+		if ok1 && ok2 && !bytes.Equal(f1, f2) {
+			return nil
+		}
+	}
+
 	equal := bytes.Equal(o.Data, o2.Data)
 	if equal && ParallelTypeDISALLOWEQUAL&t > 0 {
 		return err
@@ -82,12 +162,76 @@ func (o *Operation) CheckConflict(o2 *Operation) error {
 	return nil
 }
 
+// field returns the map field written by HSET and HDEL operations.
+func (o *Operation) field() ([]byte, bool) {
+	switch o.Op {
+	case Operation_HSET:
+		args, err := encoding.DecodeArgs(o.Data, 2)
+		if err != nil {
+			return nil, false
+		}
+		return args[0], true
+	case Operation_HDEL:
+		return o.Data, true
+	default:
+		return nil, false
+	}
+}
+
 // Exec returns the result of the given operation against stored data.
+// Soft-deleted keys are seen as empty by every operation but RESTORE, SOFTDELETE, PRUNE,
+// DELETE and EXPIRE, so that writing to a soft-deleted key rewrites it.
+// The expiry of the value is set by operations with a TTL, and removed by SET without TTL:
+// other operations keep it.
 func (o *Operation) Exec(v *operations.Value) error {
 	r, implemented := runners[o.Op]
 	if !implemented {
-		return errors.New("operation not yet implemented")
+		return errNotImplemented
+	}
+
+	switch o.Op {
+	case Operation_SOFTDELETE, Operation_RESTORE, Operation_PRUNE, Operation_DELETE, Operation_EXPIRE:
+		return r(o.Data, v)
+	}
+
+	if encoding.IsTombstone(v.Raw) {
+		_ = operations.Set(nil, v)
+		v.Expiry = time.Time{}
+	}
+
+	err := r(o.Data, v)
+	if err == nil && encoding.IsTombstone(v.Raw) {
+		return operations.ErrReservedContent
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case o.Ttl != nil:
+		ttl, err := o.ttl()
+		if err != nil {
+			return err
+		}
+		v.Expiry = v.Time.Add(ttl)
+	case o.Op == Operation_SET:
+		v.Expiry = time.Time{}
+	}
+	return nil
+}
+
+// ttl returns the TTL of o, or an error if it is not a positive duration, or
+// if o does not support it.
+func (o *Operation) ttl() (time.Duration, error) {
+	switch o.Op {
+	case Operation_SET, Operation_CONCAT, Operation_REPLACE, Operation_TRUNCATE, Operation_SETRANGE, Operation_CAS:
+	default:
+		return 0, ErrTTLNotSupported
 	}
 
-	return r(o.Data, v)
+	ttl, err := ptypes.Duration(o.Ttl)
+	if err != nil || ttl <= 0 {
+		return 0, operations.ErrInvalidTTL
+	}
+	return ttl, nil
 }
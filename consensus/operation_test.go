@@ -11,7 +11,10 @@ package consensus
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/ptypes"
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
 	"github.com/technicolor-research/pnyxdb/consensus/operations"
 
 	"github.com/stretchr/testify/require"
@@ -62,6 +65,110 @@ func TestOperation_CheckConflict(t *testing.T) {
 		op2 := &Operation{Key: "a", Op: Operation_SREM, Data: []byte("hey")}
 		ko(t, op1, op2)
 	})
+	t.Run("SET RESTORE", func(t *testing.T) {
+		op1 := &Operation{Key: "b", Op: Operation_SET, Data: []byte("hello")}
+		op2 := &Operation{Key: "b", Op: Operation_RESTORE}
+		ko(t, op1, op2)
+	})
+	t.Run("SOFTDELETE SOFTDELETE", func(t *testing.T) {
+		op1 := &Operation{Key: "b", Op: Operation_SOFTDELETE, Data: []byte("1h")}
+		op2 := &Operation{Key: "b", Op: Operation_SOFTDELETE, Data: []byte("1h")}
+		ko(t, op1, op2)
+	})
+	t.Run("CAS", func(t *testing.T) {
+		cas := &Operation{Key: "c", Op: Operation_CAS, Data: encoding.EncodeArgs([]byte("a"), []byte("b"))}
+		ko(t, cas, &Operation{Key: "c", Op: Operation_CAS, Data: cas.Data})
+		for _, op := range []Operation_Op{Operation_SET, Operation_CONCAT, Operation_ADD, Operation_SADD, Operation_DELETE} {
+			ko(t, cas, &Operation{Key: "c", Op: op, Data: []byte("1")})
+		}
+		ok(t, cas, &Operation{Key: "d", Op: Operation_CAS, Data: cas.Data})
+	})
+	t.Run("INCR DECR", func(t *testing.T) {
+		incr := &Operation{Key: "i", Op: Operation_INCR, Data: []byte("1")}
+		ok(t, incr, &Operation{Key: "i", Op: Operation_INCR, Data: []byte("1")})
+		ok(t, incr, &Operation{Key: "i", Op: Operation_DECR, Data: []byte("2")})
+		ok(t, &Operation{Key: "i", Op: Operation_DECR, Data: []byte("2")}, incr)
+		for _, op := range []Operation_Op{Operation_SET, Operation_ADD, Operation_MUL} {
+			ko(t, incr, &Operation{Key: "i", Op: op, Data: []byte("1")})
+		}
+	})
+	t.Run("MIN MAX", func(t *testing.T) {
+		min := &Operation{Key: "m", Op: Operation_MIN, Data: []byte("1")}
+		max := &Operation{Key: "m", Op: Operation_MAX, Data: []byte("1")}
+		ok(t, min, &Operation{Key: "m", Op: Operation_MIN, Data: []byte("2")})
+		ok(t, max, &Operation{Key: "m", Op: Operation_MAX, Data: []byte("2")})
+		ko(t, min, max)
+		ko(t, max, min)
+		ko(t, min, &Operation{Key: "m", Op: Operation_SET, Data: []byte("2")})
+		ko(t, max, &Operation{Key: "m", Op: Operation_ADD, Data: []byte("2")})
+	})
+	t.Run("LPUSH RPUSH LPOP", func(t *testing.T) {
+		lpush := &Operation{Key: "l", Op: Operation_LPUSH, Data: []byte("a")}
+		rpush := &Operation{Key: "l", Op: Operation_RPUSH, Data: []byte("a")}
+		lpop := &Operation{Key: "l", Op: Operation_LPOP}
+		ok(t, lpush, &Operation{Key: "l", Op: Operation_LPUSH, Data: []byte("b")})
+		ok(t, rpush, &Operation{Key: "l", Op: Operation_RPUSH, Data: []byte("b")})
+		ok(t, lpush, rpush)
+		ko(t, lpop, &Operation{Key: "l", Op: Operation_LPOP})
+		for _, op := range []*Operation{lpush, rpush, {Key: "l", Op: Operation_SET, Data: []byte("a")}} {
+			ko(t, lpop, op)
+		}
+		ok(t, lpop, &Operation{Key: "k", Op: Operation_LPOP})
+	})
+	t.Run("HSET HDEL", func(t *testing.T) {
+		hset := func(field, value string) *Operation {
+			return &Operation{Key: "h", Op: Operation_HSET, Data: encoding.EncodeArgs([]byte(field), []byte(value))}
+		}
+		hdel := func(field string) *Operation {
+			return &Operation{Key: "h", Op: Operation_HDEL, Data: []byte(field)}
+		}
+
+		ok(t, hset("a", "1"), hset("b", "2"))
+		ok(t, hset("a", "1"), hset("a", "1"))
+		ko(t, hset("a", "1"), hset("a", "2"))
+		ok(t, hset("a", "1"), hdel("b"))
+		ko(t, hset("a", "1"), hdel("a"))
+		ok(t, hdel("a"), hdel("a"))
+		ok(t, hdel("a"), hdel("b"))
+		ko(t, hset("a", "1"), &Operation{Key: "h", Op: Operation_HSET, Data: []byte("invalid")})
+		ko(t, hset("a", "1"), &Operation{Key: "h", Op: Operation_SET, Data: []byte("a")})
+		ko(t, hdel("a"), &Operation{Key: "h", Op: Operation_DELETE})
+	})
+	t.Run("DELETE", func(t *testing.T) {
+		del := &Operation{Key: "d", Op: Operation_DELETE}
+		ok(t, del, &Operation{Key: "d", Op: Operation_DELETE})
+		for _, op := range []Operation_Op{Operation_SET, Operation_CONCAT, Operation_ADD, Operation_MUL} {
+			ko(t, del, &Operation{Key: "d", Op: op, Data: []byte("1")})
+		}
+	})
+	t.Run("string operations", func(t *testing.T) {
+		ops := []*Operation{
+			{Key: "s", Op: Operation_SET, Data: []byte("hello")},
+			{Key: "s", Op: Operation_REPLACE, Data: encoding.EncodeArgs([]byte("l"), []byte("L"), []byte("-1"))},
+			{Key: "s", Op: Operation_TRUNCATE, Data: []byte("3")},
+			{Key: "s", Op: Operation_SETRANGE, Data: encoding.EncodeArgs([]byte("1"), []byte("a"))},
+		}
+		for i, op1 := range ops[1:] {
+			for _, op2 := range ops[:i+2] {
+				ko(t, op1, op2)
+			}
+		}
+
+		op1 := &Operation{Key: "s", Op: Operation_TRUNCATE, Data: []byte("3")}
+		op2 := &Operation{Key: "t", Op: Operation_TRUNCATE, Data: []byte("3")}
+		ok(t, op1, op2)
+	})
+	t.Run("TTL", func(t *testing.T) {
+		ttl := ptypes.DurationProto(time.Minute)
+		ko(t, &Operation{Key: "x", Op: Operation_SET, Data: []byte("1"), Ttl: ttl}, &Operation{Key: "x", Op: Operation_SET, Data: []byte("1")})
+		ko(t, &Operation{Key: "x", Op: Operation_SET, Data: []byte("1"), Ttl: ttl}, &Operation{Key: "x", Op: Operation_SET, Data: []byte("1"), Ttl: ttl})
+		ok(t, &Operation{Key: "x", Op: Operation_SET, Data: []byte("1"), Ttl: ttl}, &Operation{Key: "y", Op: Operation_SET, Data: []byte("1")})
+
+		expire := &Operation{Key: "x", Op: Operation_EXPIRE, Data: []byte("1m")}
+		ko(t, expire, &Operation{Key: "x", Op: Operation_EXPIRE, Data: []byte("1m")})
+		ko(t, expire, &Operation{Key: "x", Op: Operation_INCR, Data: []byte("1")})
+		ok(t, expire, &Operation{Key: "y", Op: Operation_INCR, Data: []byte("1")})
+	})
 }
 
 func TestOperation_Exec_Simple(t *testing.T) {
@@ -104,3 +211,449 @@ func TestOperation_Exec_Simple(t *testing.T) {
 		})
 	}
 }
+
+func TestOperation_Exec_Counter(t *testing.T) {
+	incr := func(delta string) *Operation { return &Operation{Op: Operation_INCR, Data: []byte(delta)} }
+	decr := func(delta string) *Operation { return &Operation{Op: Operation_DECR, Data: []byte(delta)} }
+
+	cases := []struct {
+		op   *Operation
+		data string
+		res  string
+		err  error
+	}{
+		{incr("1"), "", "1", nil},
+		{incr("5"), "-2", "3", nil},
+		{decr("5"), "2", "-3", nil},
+		{decr("-5"), "2", "7", nil},
+		{incr("1"), "9223372036854775807", "9223372036854775808", nil},
+		{decr("1"), "-9223372036854775808", "-9223372036854775809", nil},
+		{decr("-9223372036854775808"), "0", "9223372036854775808", nil},
+		{incr("9223372036854775808"), "0", "0", operations.ErrInvalidDelta},
+		{incr("1.5"), "0", "0", operations.ErrInvalidDelta},
+		{incr(""), "0", "0", operations.ErrInvalidDelta},
+		{incr("1"), "1.5", "1.5", operations.ErrNotInteger},
+		{incr("1"), "1e+06", "1e+06", operations.ErrNotInteger},
+		{incr("1"), "hello", "hello", operations.ErrNotInteger},
+	}
+
+	for _, c := range cases {
+		value := operations.NewValue([]byte(c.data))
+		err := c.op.Exec(value)
+		require.Equal(t, c.err, err, "%s %s on %q", c.op.Op, c.op.Data, c.data)
+		require.Equal(t, c.res, string(value.Raw), "%s %s on %q", c.op.Op, c.op.Data, c.data)
+	}
+
+	t.Run("mixed with floats", func(t *testing.T) {
+		value := operations.NewValue(nil)
+		require.Nil(t, incr("2").Exec(value))
+		require.Nil(t, (&Operation{Op: Operation_ADD, Data: []byte("0.5")}).Exec(value))
+		require.Equal(t, operations.ErrNotInteger, incr("1").Exec(value), "the counter has been written as a float")
+		require.Equal(t, "2.5", string(value.Raw))
+
+		require.Nil(t, (&Operation{Op: Operation_ADD, Data: []byte("0.5")}).Exec(value))
+		require.Nil(t, incr("1").Exec(value))
+		require.Equal(t, "4", string(value.Raw))
+	})
+}
+
+func TestOperation_Exec_MinMax(t *testing.T) {
+	min := func(data string) *Operation { return &Operation{Op: Operation_MIN, Data: []byte(data)} }
+	max := func(data string) *Operation { return &Operation{Op: Operation_MAX, Data: []byte(data)} }
+
+	cases := []struct {
+		op   *Operation
+		data string
+		res  string
+		err  error
+	}{
+		{min("3"), "", "3", nil},
+		{max("-2.5"), "", "-2.5", nil},
+		{min("3"), "5", "3", nil},
+		{min("7"), "5", "5", nil},
+		{max("7"), "5", "7", nil},
+		{max("3"), "5", "5", nil},
+		{max("2.5"), "10", "10", nil},
+		{min("1e3"), "999.5", "999.5", nil},
+		{max("9223372036854775808"), "9223372036854775807", "9223372036854775808", nil},
+		{min("100000000000000000000000000001"), "100000000000000000000000000000", "100000000000000000000000000000", nil},
+		{max("x"), "5", "5", operations.ErrNotNumeric},
+		{max("x"), "", "", operations.ErrNotNumeric},
+		{min(""), "", "", operations.ErrNotNumeric},
+		{min("1"), "hello", "hello", operations.ErrNotNumeric},
+	}
+
+	for _, c := range cases {
+		value := operations.NewValue([]byte(c.data))
+		err := c.op.Exec(value)
+		require.Equal(t, c.err, err, "%s %s on %q", c.op.Op, c.op.Data, c.data)
+		require.Equal(t, c.res, string(value.Raw), "%s %s on %q", c.op.Op, c.op.Data, c.data)
+	}
+
+	t.Run("commutative", func(t *testing.T) {
+		pairs := [][2]string{{"1", "1.0"}, {"2", "10"}, {"1e3", "1000"}, {"-0", "0"}}
+		for _, p := range pairs {
+			for _, op := range []func(string) *Operation{min, max} {
+				a, b := operations.NewValue(nil), operations.NewValue(nil)
+				require.Nil(t, op(p[0]).Exec(a))
+				require.Nil(t, op(p[1]).Exec(a))
+				require.Nil(t, op(p[1]).Exec(b))
+				require.Nil(t, op(p[0]).Exec(b))
+				require.Equal(t, string(a.Raw), string(b.Raw), "%s %v", op("").Op, p)
+			}
+		}
+	})
+}
+
+func TestOperation_Exec_List(t *testing.T) {
+	lpush := func(data string) *Operation { return &Operation{Op: Operation_LPUSH, Data: []byte(data)} }
+	rpush := func(data string) *Operation { return &Operation{Op: Operation_RPUSH, Data: []byte(data)} }
+	lpop := func(count string) *Operation { return &Operation{Op: Operation_LPOP, Data: []byte(count)} }
+
+	elements := func(v *operations.Value) []string {
+		l, err := v.List()
+		require.Nil(t, err)
+		var res []string
+		for _, data := range l.Range(0, -1) {
+			res = append(res, string(data))
+		}
+		return res
+	}
+
+	value := operations.NewValue(nil)
+	for _, op := range []*Operation{rpush("b"), rpush("c"), lpush("a"), lpush("z")} {
+		require.Nil(t, op.Exec(value))
+	}
+	require.Equal(t, []string{"z", "a", "b", "c"}, elements(value), "pushes of a single transaction keep their order")
+
+	require.Nil(t, lpop("").Exec(value))
+	require.Equal(t, []string{"a", "b", "c"}, elements(value))
+	require.Nil(t, lpop("2").Exec(value))
+	require.Equal(t, []string{"c"}, elements(value))
+	require.Nil(t, lpop("5").Exec(value))
+	require.Empty(t, value.Raw)
+	require.Nil(t, lpop("").Exec(value), "popping an empty list is a no-op")
+
+	require.Equal(t, operations.ErrInvalidPopCount, lpop("0").Exec(value))
+	require.Equal(t, operations.ErrInvalidPopCount, lpop("x").Exec(value))
+	require.Equal(t, operations.ErrNotValidList, rpush("a").Exec(operations.NewValue([]byte("hello"))))
+	require.Equal(t, operations.ErrNotValidList, lpop("").Exec(operations.NewValue([]byte("42"))))
+
+	t.Run("concurrent pushes", func(t *testing.T) {
+		deadline := time.Now().Add(time.Minute)
+		queries := []*Query{
+			{Uuid: "1", Emitter: "alice", Operations: []*Operation{{Key: "l", Op: Operation_RPUSH, Data: []byte("a1")}}},
+			{Uuid: "2", Emitter: "alice", Operations: []*Operation{{Key: "l", Op: Operation_RPUSH, Data: []byte("a2")}}},
+			{Uuid: "1", Emitter: "bob", Operations: []*Operation{
+				{Key: "l", Op: Operation_RPUSH, Data: []byte("b1")},
+				{Key: "l", Op: Operation_LPUSH, Data: []byte("b0")},
+			}},
+			{Uuid: "3", Emitter: "alice", Operations: []*Operation{{Key: "l", Op: Operation_RPUSH, Data: []byte("a0")}}},
+		}
+		for i, q := range queries {
+			d := deadline
+			if i == len(queries)-1 {
+				d = d.Add(-time.Second) // earlier transaction
+			}
+			q.Deadline, _ = ptypes.TimestampProto(d)
+		}
+
+		apply := func(order []int) *operations.Value {
+			var data []byte
+			for _, i := range order {
+				values, err := queries[i].Execute(func(string) ([]byte, error) { return data, nil })
+				require.Nil(t, err)
+				data = values["l"].Raw
+			}
+			return operations.NewValue(data)
+		}
+
+		a := apply([]int{0, 1, 2, 3})
+		b := apply([]int{3, 2, 1, 0})
+		c := apply([]int{2, 0, 3, 1})
+		require.Equal(t, a.Raw, b.Raw)
+		require.Equal(t, a.Raw, c.Raw)
+		require.Equal(t, []string{"b0", "a0", "a1", "a2", "b1"}, elements(a))
+	})
+}
+
+func TestOperation_Exec_Map(t *testing.T) {
+	hset := func(field, value string) *Operation {
+		return &Operation{Op: Operation_HSET, Data: encoding.EncodeArgs([]byte(field), []byte(value))}
+	}
+	hdel := func(field string) *Operation { return &Operation{Op: Operation_HDEL, Data: []byte(field)} }
+
+	fields := func(v *operations.Value) map[string]string {
+		m, err := v.Map()
+		require.Nil(t, err)
+		res := make(map[string]string)
+		for field, value := range m.Fields {
+			res[field] = string(value)
+		}
+		return res
+	}
+
+	value := operations.NewValue(nil)
+	for _, op := range []*Operation{hset("name", "bob"), hset("age", "42"), hset("name", "alice"), hdel("unknown")} {
+		require.Nil(t, op.Exec(value))
+	}
+	require.Equal(t, map[string]string{"name": "alice", "age": "42"}, fields(value))
+
+	require.Nil(t, hdel("age").Exec(value))
+	require.Nil(t, hdel("name").Exec(value))
+	require.Empty(t, value.Raw)
+
+	require.Equal(t, encoding.ErrInvalidArgs, (&Operation{Op: Operation_HSET, Data: []byte("name")}).Exec(value))
+	require.Equal(t, operations.ErrNotValidMap, hset("a", "1").Exec(operations.NewValue([]byte("hello"))))
+	require.Equal(t, operations.ErrNotValidMap, hdel("a").Exec(operations.NewValue([]byte("42"))))
+
+	t.Run("commutative", func(t *testing.T) {
+		// Parallel updates committed by concurrent queries
+		updates := []*Operation{hset("a", "1"), hset("b", "2"), hdel("c"), hset("d", "4")}
+		initial := operations.NewValue(nil)
+		require.Nil(t, hset("c", "3").Exec(initial))
+
+		apply := func(order []int) []byte {
+			v := operations.NewValue(initial.Raw)
+			for _, i := range order {
+				require.Nil(t, updates[i].Exec(v))
+			}
+			return v.Raw
+		}
+
+		expected := apply([]int{0, 1, 2, 3})
+		require.Equal(t, expected, apply([]int{3, 2, 1, 0}))
+		require.Equal(t, expected, apply([]int{2, 0, 3, 1}))
+		require.Equal(t, map[string]string{"a": "1", "b": "2", "d": "4"}, fields(operations.NewValue(expected)))
+	})
+}
+
+func TestOperation_Exec_Expiry(t *testing.T) {
+	now := time.Now()
+	value := operations.NewValue(nil)
+	value.Time = now
+
+	set := &Operation{Op: Operation_SET, Data: []byte("1"), Ttl: ptypes.DurationProto(time.Minute)}
+	require.Nil(t, set.Exec(value))
+	require.Equal(t, now.Add(time.Minute), value.Expiry)
+
+	// Kept by other operations
+	require.Nil(t, (&Operation{Op: Operation_INCR, Data: []byte("1")}).Exec(value))
+	require.Equal(t, "2", string(value.Raw))
+	require.Equal(t, now.Add(time.Minute), value.Expiry)
+
+	// Set without changing the value
+	require.Nil(t, (&Operation{Op: Operation_EXPIRE, Data: []byte("1h")}).Exec(value))
+	require.Equal(t, "2", string(value.Raw))
+	require.Equal(t, now.Add(time.Hour), value.Expiry)
+	require.Equal(t, operations.ErrInvalidTTL, (&Operation{Op: Operation_EXPIRE, Data: []byte("-1s")}).Exec(value))
+
+	// Removed by SET without TTL
+	require.Nil(t, (&Operation{Op: Operation_SET, Data: []byte("3")}).Exec(value))
+	require.True(t, value.Expiry.IsZero())
+
+	// Only SET-style operations accept a TTL
+	incr := &Operation{Op: Operation_INCR, Data: []byte("1"), Ttl: ptypes.DurationProto(time.Minute)}
+	require.Equal(t, ErrTTLNotSupported, incr.Exec(value))
+	concat := &Operation{Op: Operation_CONCAT, Data: []byte("0"), Ttl: ptypes.DurationProto(-time.Minute)}
+	require.Equal(t, operations.ErrInvalidTTL, concat.Exec(value))
+
+	t.Run("query", func(t *testing.T) {
+		q := NewQuery()
+		q.Deadline, _ = ptypes.TimestampProto(now)
+		q.Operations = []*Operation{{Key: "a", Op: Operation_INCR, Data: []byte("1")}}
+
+		stored := NewVersion([]byte("41"))
+		get := func(string) ([]byte, *Version, error) { return []byte("41"), stored, nil }
+
+		stored.setExpiry(now.Add(time.Nanosecond))
+		values, err := q.execute(get)
+		require.Nil(t, err)
+		require.Equal(t, "42", string(values["a"].Raw))
+		require.True(t, values["a"].Expiry.Equal(now.Add(time.Nanosecond)))
+
+		// Expired at the deadline of the query
+		stored.setExpiry(now)
+		values, err = q.execute(get)
+		require.Nil(t, err)
+		require.Equal(t, "1", string(values["a"].Raw))
+		require.True(t, values["a"].Expiry.IsZero())
+	})
+}
+
+func TestOperation_Exec_String(t *testing.T) {
+	replace := func(pattern, replacement, n string) *Operation {
+		return &Operation{Op: Operation_REPLACE, Data: encoding.EncodeArgs([]byte(pattern), []byte(replacement), []byte(n))}
+	}
+	truncate := func(length string) *Operation {
+		return &Operation{Op: Operation_TRUNCATE, Data: []byte(length)}
+	}
+	setRange := func(offset string, data []byte) *Operation {
+		return &Operation{Op: Operation_SETRANGE, Data: encoding.EncodeArgs([]byte(offset), data)}
+	}
+	cas := func(expected, data string) *Operation {
+		return &Operation{Op: Operation_CAS, Data: encoding.EncodeArgs([]byte(expected), []byte(data))}
+	}
+
+	type execCase struct {
+		name        string
+		op          *Operation
+		data        []byte
+		resExpected []byte
+		errExpected error
+	}
+	testCases := []execCase{
+		{"replace all", replace("l", "LL", "-1"), []byte("hello"), []byte("heLLLLo"), nil},
+		{"replace first", replace("l", "", "1"), []byte("hello"), []byte("helo"), nil},
+		{"replace none", replace("l", "L", "0"), []byte("hello"), []byte("hello"), nil},
+		{"replace missing", replace("x", "y", "-1"), []byte("hello"), []byte("hello"), nil},
+		{"replace empty value", replace("x", "y", "-1"), nil, nil, nil},
+		{"replace empty pattern", replace("", "y", "-1"), []byte("hello"), nil, operations.ErrEmptyPattern},
+		{"replace invalid count", replace("l", "L", "all"), []byte("hello"), nil, operations.ErrInvalidCount},
+		{"replace invalid args", &Operation{Op: Operation_REPLACE, Data: []byte("l")}, []byte("hello"), nil, encoding.ErrInvalidArgs},
+		{"replace too large", replace("\x00", string(make([]byte, 1<<20)), "-1"), make([]byte, 65), nil, operations.ErrTooLarge},
+
+		{"truncate", truncate("2"), []byte("hello"), []byte("he"), nil},
+		{"truncate to zero", truncate("0"), []byte("hello"), []byte{}, nil},
+		{"truncate shorter", truncate("10"), []byte("hello"), []byte("hello"), nil},
+		{"truncate empty value", truncate("10"), nil, nil, nil},
+		{"truncate negative", truncate("-1"), []byte("hello"), nil, operations.ErrInvalidLength},
+
+		{"setrange", setRange("1", []byte("ipp")), []byte("hello"), []byte("hippo"), nil},
+		{"setrange extends", setRange("3", []byte("icopter")), []byte("hello"), []byte("helicopter"), nil},
+		{"setrange zero-fill", setRange("7", []byte("!")), []byte("hello"), []byte("hello\x00\x00!"), nil},
+		{"setrange empty value", setRange("2", []byte("a")), nil, []byte("\x00\x00a"), nil},
+		{"setrange empty data", setRange("7", nil), []byte("hello"), []byte("hello\x00\x00"), nil},
+		{"setrange invalid offset", setRange("-1", []byte("a")), []byte("hello"), nil, operations.ErrInvalidLength},
+		{"setrange too large", setRange("67108864", []byte("a")), nil, nil, operations.ErrTooLarge},
+		{"setrange overflow", setRange("9223372036854775807", []byte("a")), nil, nil, operations.ErrTooLarge},
+
+		{"cas", cas("hello", "world"), []byte("hello"), []byte("world"), nil},
+		{"cas empty value", cas("", "world"), nil, []byte("world"), nil},
+		{"cas unexpected", cas("hell", "world"), []byte("hello"), nil, operations.ErrUnexpected},
+		{"cas invalid args", &Operation{Op: Operation_CAS, Data: []byte("hello")}, []byte("hello"), nil, encoding.ErrInvalidArgs},
+	}
+
+	for _, tc := range testCases {
+		c := tc
+		t.Run(c.name, func(t *testing.T) {
+			original := append([]byte(nil), c.data...)
+			value := operations.NewValue(c.data)
+			err := c.op.Exec(value)
+			require.Exactly(t, c.errExpected, err)
+			if err == nil {
+				require.Exactly(t, c.resExpected, value.Raw)
+			}
+			require.Exactly(t, original, append([]byte(nil), c.data...), "stored value must not be modified in place")
+		})
+	}
+
+	t.Run("truncated value is not shared", func(t *testing.T) {
+		data := []byte("hello")
+		value := operations.NewValue(data)
+		require.Nil(t, truncate("2").Exec(value))
+		require.Nil(t, (&Operation{Op: Operation_CONCAT, Data: []byte("y")}).Exec(value))
+		require.Exactly(t, []byte("hey"), value.Raw)
+		require.Exactly(t, []byte("hello"), data)
+	})
+}
+
+func TestOperation_Exec_SoftDelete(t *testing.T) {
+	now := time.Now()
+	del := &Operation{Op: Operation_SOFTDELETE, Data: []byte("1h")}
+	restore := &Operation{Op: Operation_RESTORE}
+
+	value := operations.NewValue([]byte("hello"))
+	value.Time = now
+	before := NewVersion(value.Raw)
+
+	require.Nil(t, del.Exec(value))
+	deleted := value.Raw
+	require.NotNil(t, NewVersion(deleted).Matches(before))
+	require.Exactly(t, operations.ErrDeleted, del.Exec(value))
+
+	value.Time = now.Add(time.Hour)
+	require.Nil(t, restore.Exec(value))
+	require.Exactly(t, []byte("hello"), value.Raw)
+	require.Nil(t, NewVersion(value.Raw).Matches(before), "restored version should match the version before deletion")
+	require.Exactly(t, operations.ErrNotDeleted, restore.Exec(value))
+
+	t.Run("expired", func(t *testing.T) {
+		value := operations.NewValue(deleted)
+		value.Time = now.Add(time.Hour + time.Nanosecond)
+		require.Exactly(t, operations.ErrRestoreExpired, restore.Exec(value))
+	})
+
+	t.Run("rewritten", func(t *testing.T) {
+		value := operations.NewValue(deleted)
+		value.Time = now
+		require.Nil(t, (&Operation{Op: Operation_SET, Data: []byte("world")}).Exec(value))
+		require.Exactly(t, []byte("world"), value.Raw)
+		require.Exactly(t, operations.ErrNotDeleted, restore.Exec(value))
+	})
+
+	t.Run("deleted keys are empty", func(t *testing.T) {
+		value := operations.NewValue(deleted)
+		require.Nil(t, (&Operation{Op: Operation_ADD, Data: []byte("2")}).Exec(value))
+		require.Exactly(t, []byte("2"), value.Raw)
+	})
+
+	t.Run("reserved content", func(t *testing.T) {
+		value := operations.NewValue(nil)
+		op := &Operation{Op: Operation_SET, Data: deleted}
+		require.Exactly(t, operations.ErrReservedContent, op.Exec(value))
+	})
+
+	t.Run("invalid grace period", func(t *testing.T) {
+		value := operations.NewValue([]byte("hello"))
+		op := &Operation{Op: Operation_SOFTDELETE, Data: []byte("-1h")}
+		require.Exactly(t, operations.ErrInvalidGrace, op.Exec(value))
+	})
+}
+
+func TestOperation_Exec_Prune(t *testing.T) {
+	value := operations.NewValue([]byte("hello"))
+	value.Time = time.Now()
+	prune := &Operation{Op: Operation_PRUNE, Data: NewVersion(value.Raw).Hash}
+
+	t.Run("rewritten", func(t *testing.T) {
+		value := operations.NewValue([]byte("world"))
+		require.Nil(t, prune.Exec(value))
+		require.Exactly(t, []byte("world"), value.Raw)
+	})
+
+	require.Nil(t, prune.Exec(value))
+	require.True(t, encoding.IsTombstone(value.Raw))
+	pruned := value.Raw
+
+	require.Nil(t, prune.Exec(value), "pruning a deleted key should be a no-op")
+	require.Exactly(t, pruned, value.Raw)
+	require.Exactly(t, operations.ErrRestoreExpired, (&Operation{Op: Operation_RESTORE}).Exec(value))
+}
+
+func TestOperation_Exec_Delete(t *testing.T) {
+	del := &Operation{Op: Operation_DELETE}
+
+	for _, raw := range [][]byte{nil, []byte("hello")} {
+		value := operations.NewValue(raw)
+		require.Nil(t, del.Exec(value))
+		require.True(t, encoding.IsDeletion(value.Raw))
+	}
+
+	t.Run("soft-deleted", func(t *testing.T) {
+		value := operations.NewValue([]byte("hello"))
+		value.Time = time.Now()
+		require.Nil(t, (&Operation{Op: Operation_SOFTDELETE, Data: []byte("1h")}).Exec(value))
+		require.False(t, encoding.IsDeletion(value.Raw))
+		require.Nil(t, del.Exec(value))
+		require.True(t, encoding.IsDeletion(value.Raw))
+		require.Exactly(t, operations.ErrRestoreExpired, (&Operation{Op: Operation_RESTORE}).Exec(value))
+	})
+
+	t.Run("rewritten", func(t *testing.T) {
+		value := operations.NewValue([]byte("hello"))
+		require.Nil(t, del.Exec(value))
+		require.Nil(t, (&Operation{Op: Operation_CONCAT, Data: []byte("world")}).Exec(value))
+		require.Exactly(t, []byte("world"), value.Raw)
+	})
+}
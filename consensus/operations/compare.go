@@ -0,0 +1,81 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package operations
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+)
+
+// comparePrec is the precision of floats compared by MIN and MAX, larger
+// than the one of encoding.Float so that distinct decimals seldom compare
+// equal.
+const comparePrec = 512
+
+// compare returns the sign of a - b, comparing integers exactly, and other
+// numbers as floats. Equal numbers are ordered by their representation, so
+// that MIN and MAX commute even on "1" and "1.0".
+func compare(a, b []byte) (int, error) {
+	var c int
+	ia, ib := encoding.NewInt(), encoding.NewInt()
+	if ia.UnmarshalBinary(a) == nil && ib.UnmarshalBinary(b) == nil {
+		c = ia.Cmp(ib.Int)
+	} else {
+		fa, _, err := big.ParseFloat(string(a), 10, comparePrec, big.ToNearestEven)
+		if err != nil {
+			return 0, ErrNotNumeric
+		}
+		fb, _, err := big.ParseFloat(string(b), 10, comparePrec, big.ToNearestEven)
+		if err != nil {
+			return 0, ErrNotNumeric
+		}
+		c = fa.Cmp(fb)
+	}
+
+	if c == 0 {
+		c = bytes.Compare(a, b)
+	}
+	return c, nil
+}
+
+func keep(input []byte, current *Value, sign int) error {
+	if len(input) == 0 {
+		return ErrNotNumeric
+	}
+
+	if len(current.Raw) > 0 {
+		c, err := compare(input, current.Raw)
+		if err != nil {
+			return err
+		}
+		if c*sign <= 0 {
+			return nil
+		}
+	} else if _, err := compare(input, input); err != nil {
+		return err
+	}
+
+	current.reset()
+	current.Raw = append([]byte(nil), input...)
+	return nil
+}
+
+// Min keeps the smaller of the current value and the input, compared as
+// numbers. The input is kept as is on empty values.
+func Min(input []byte, current *Value) error {
+	return keep(input, current, -1)
+}
+
+// Max keeps the larger of the current value and the input, compared as
+// numbers. The input is kept as is on empty values.
+func Max(input []byte, current *Value) error {
+	return keep(input, current, 1)
+}
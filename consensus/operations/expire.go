@@ -0,0 +1,39 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package operations
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidTTL is returned when a time-to-live is not a positive duration.
+var ErrInvalidTTL = errors.New("invalid time-to-live")
+
+// ParseTTL parses the input of Expire, a positive duration such as "30s".
+func ParseTTL(input []byte) (time.Duration, error) {
+	ttl, err := time.ParseDuration(string(input))
+	if err != nil || ttl <= 0 {
+		return 0, ErrInvalidTTL
+	}
+	return ttl, nil
+}
+
+// Expire leaves the current value unchanged, but makes it expire once the
+// time-to-live given as input (see ParseTTL) has elapsed since the logical
+// time of the transaction. As with other operations, a missing value is seen
+// as empty.
+func Expire(input []byte, current *Value) error {
+	ttl, err := ParseTTL(input)
+	if err != nil {
+		return err
+	}
+
+	current.Expiry = current.Time.Add(ttl)
+	return nil
+}
@@ -18,6 +18,7 @@ func floatGeneric(input []byte, current *Value, add bool) error {
 		return ErrNotNumeric
 	}
 
+	current.reset() // other decoded values are outdated
 	if add {
 		current.vfloat = a.Add(b)
 	} else {
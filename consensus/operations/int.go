@@ -0,0 +1,50 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package operations
+
+import (
+	"strconv"
+
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+)
+
+func intGeneric(input []byte, current *Value, sign int64) error {
+	delta, err := strconv.ParseInt(string(input), 10, 64)
+	if err != nil {
+		return ErrInvalidDelta
+	}
+
+	i, err := current.Int()
+	if err != nil {
+		return ErrNotInteger
+	}
+
+	d := encoding.NewInt()
+	d.SetInt64(delta)
+	if sign < 0 {
+		d.Neg(d.Int)
+	}
+
+	current.reset() // other decoded values are outdated
+	current.vint = i.Add(d)
+	current.Raw, err = current.vint.MarshalBinary()
+	return err
+}
+
+// Incr adds the input, a signed 64-bit decimal integer, to the current
+// integer value.
+func Incr(input []byte, current *Value) error {
+	return intGeneric(input, current, 1)
+}
+
+// Decr subtracts the input, a signed 64-bit decimal integer, from the current
+// integer value.
+func Decr(input []byte, current *Value) error {
+	return intGeneric(input, current, -1)
+}
@@ -0,0 +1,92 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package operations
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+)
+
+// ErrInvalidPopCount is returned by Lpop if its input is not a positive count.
+var ErrInvalidPopCount = errors.New("count is not a positive integer")
+
+// orderKey returns the key ordering the elements pushed by the transaction of
+// current: by logical time, then by tag. Keys of distinct transactions differ,
+// so that concurrent pushes lead to the same list whatever the order in which
+// they are applied.
+func orderKey(current *Value) []byte {
+	key := make([]byte, 8, 8+len(current.Tag))
+	// Flip the sign bit so that the byte order matches the numeric one
+	binary.BigEndian.PutUint64(key, uint64(current.Time.UnixNano())^(1<<63))
+	return append(key, current.Tag...)
+}
+
+func pushGeneric(input []byte, current *Value, head bool) error {
+	l, err := current.List()
+	if err != nil {
+		return ErrNotValidList
+	}
+
+	value := append([]byte(nil), input...)
+	if head {
+		l.PushHead(orderKey(current), value)
+	} else {
+		l.PushTail(orderKey(current), value)
+	}
+
+	current.reset() // other decoded values are outdated
+	current.vlist = l
+	current.Raw, err = l.MarshalBinary()
+	return err
+}
+
+// Lpush inserts the input at the head of the current list. Elements pushed by
+// concurrent transactions are ordered by deadline, then by emitter and UUID:
+// the latest transaction comes first.
+func Lpush(input []byte, current *Value) error {
+	return pushGeneric(input, current, true)
+}
+
+// Rpush inserts the input at the tail of the current list. Elements pushed by
+// concurrent transactions are ordered by deadline, then by emitter and UUID:
+// the latest transaction comes last.
+func Rpush(input []byte, current *Value) error {
+	return pushGeneric(input, current, false)
+}
+
+// Lpop removes elements from the head of the current list. The input is the
+// number of elements to remove, 1 if empty. The removed elements are dropped:
+// they shall be read before.
+func Lpop(input []byte, current *Value) error {
+	count := 1
+	if len(input) > 0 {
+		n, err := strconv.Atoi(string(input))
+		if err != nil || n <= 0 {
+			return ErrInvalidPopCount
+		}
+		count = n
+	}
+
+	l, err := current.List()
+	if err != nil {
+		return ErrNotValidList
+	}
+
+	for i := 0; i < count; i++ {
+		if _, ok := l.PopHead(); !ok {
+			break
+		}
+	}
+
+	current.reset() // other decoded values are outdated
+	current.vlist = l
+	current.Raw, err = l.MarshalBinary()
+	return err
+}
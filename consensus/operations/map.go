@@ -0,0 +1,47 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package operations
+
+import "github.com/technicolor-research/pnyxdb/consensus/encoding"
+
+// Hset sets a field of the current map. The input holds the field and its
+// value, encoded with encoding.EncodeArgs.
+func Hset(input []byte, current *Value) error {
+	args, err := encoding.DecodeArgs(input, 2)
+	if err != nil {
+		return err
+	}
+
+	m, err := current.Map()
+	if err != nil {
+		return ErrNotValidMap
+	}
+
+	m.Set(string(args[0]), append([]byte(nil), args[1]...))
+
+	current.reset() // other decoded values are outdated
+	current.vmap = m
+	current.Raw, err = m.MarshalBinary()
+	return err
+}
+
+// Hdel removes the field given as input from the current map, if present.
+func Hdel(input []byte, current *Value) error {
+	m, err := current.Map()
+	if err != nil {
+		return ErrNotValidMap
+	}
+
+	m.Delete(string(input))
+
+	current.reset() // other decoded values are outdated
+	current.vmap = m
+	current.Raw, err = m.MarshalBinary()
+	return err
+}
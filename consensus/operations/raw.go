@@ -8,6 +8,14 @@
 
 package operations
 
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+)
+
 // Set sets the output value to the input value raw data.
 func Set(input []byte, current *Value) error {
 	current.reset()
@@ -21,3 +29,119 @@ func Append(input []byte, current *Value) error {
 	current.Raw = append(current.Raw, input...)
 	return nil
 }
+
+// MaxRawSize bounds the size of the values produced by Replace and SetRange,
+// so that a single operation cannot exhaust the memory of the nodes.
+const MaxRawSize = 64 << 20
+
+// Errors returned by operations on raw values.
+var (
+	ErrInvalidLength = errors.New("invalid length or offset")
+	ErrInvalidCount  = errors.New("invalid replacement count")
+	ErrEmptyPattern  = errors.New("empty pattern")
+	ErrTooLarge      = errors.New("resulting value too large")
+	ErrUnexpected    = errors.New("unexpected current value")
+)
+
+// Replace replaces the occurrences of a pattern in the current value.
+// The input holds three arguments encoded by encoding.EncodeArgs: the
+// pattern, the replacement, and the maximum number of replacements in
+// decimal, a negative count replacing every occurrence.
+func Replace(input []byte, current *Value) error {
+	args, err := encoding.DecodeArgs(input, 3)
+	if err != nil {
+		return err
+	}
+
+	pattern, replacement := args[0], args[1]
+	if len(pattern) == 0 {
+		return ErrEmptyPattern
+	}
+
+	n, err := strconv.Atoi(string(args[2]))
+	if err != nil {
+		return ErrInvalidCount
+	}
+
+	count := bytes.Count(current.Raw, pattern)
+	if n >= 0 && n < count {
+		count = n
+	}
+	if int64(len(current.Raw))+int64(count)*int64(len(replacement)-len(pattern)) > MaxRawSize {
+		return ErrTooLarge
+	}
+
+	raw := bytes.Replace(current.Raw, pattern, replacement, count)
+	current.reset()
+	current.Raw = raw
+	return nil
+}
+
+// Truncate cuts the current value to the length given as input, in
+// decimal. Shorter values are left untouched.
+func Truncate(input []byte, current *Value) error {
+	length, err := strconv.ParseUint(string(input), 10, 63)
+	if err != nil {
+		return ErrInvalidLength
+	}
+
+	if uint64(len(current.Raw)) <= length {
+		return nil
+	}
+
+	raw := current.Raw[:length:length] // appending must not overwrite the original value
+	current.reset()
+	current.Raw = raw
+	return nil
+}
+
+// SetRange overwrites the current value from an offset. The input holds two
+// arguments encoded by encoding.EncodeArgs: the offset in decimal, and the
+// data to write. The value is extended if needed, and filled with zeros when
+// the offset is beyond its end.
+func SetRange(input []byte, current *Value) error {
+	args, err := encoding.DecodeArgs(input, 2)
+	if err != nil {
+		return err
+	}
+
+	offset, err := strconv.ParseUint(string(args[0]), 10, 63)
+	if err != nil {
+		return ErrInvalidLength
+	}
+
+	data := args[1]
+	if offset > MaxRawSize || offset+uint64(len(data)) > MaxRawSize {
+		return ErrTooLarge
+	}
+
+	size := int(offset) + len(data)
+	if size < len(current.Raw) {
+		size = len(current.Raw)
+	}
+
+	raw := make([]byte, size)
+	copy(raw, current.Raw)
+	copy(raw[offset:], data)
+	current.reset()
+	current.Raw = raw
+	return nil
+}
+
+// CompareAndSwap replaces the current value, provided that it is equal to an
+// expected value. The input holds two arguments encoded by
+// encoding.EncodeArgs: the expected value and the new value.
+func CompareAndSwap(input []byte, current *Value) error {
+	args, err := encoding.DecodeArgs(input, 2)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(current.Raw, args[0]) {
+		return ErrUnexpected
+	}
+
+	current.reset()
+	current.Raw = args[1]
+	return nil
+}
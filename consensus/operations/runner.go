@@ -17,6 +17,10 @@ type Runner func(input []byte, current *Value) error
 
 // Errors returned when an operation does not match stored datatype.
 var (
-	ErrNotNumeric  = errors.New("non-numeric value")
-	ErrNotValidSet = errors.New("non-valid set")
+	ErrNotNumeric   = errors.New("non-numeric value")
+	ErrNotInteger   = errors.New("non-integer value")
+	ErrInvalidDelta = errors.New("delta is not a 64-bit integer")
+	ErrNotValidSet  = errors.New("non-valid set")
+	ErrNotValidList = errors.New("non-valid list")
+	ErrNotValidMap  = errors.New("non-valid map")
 )
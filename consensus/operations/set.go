@@ -23,6 +23,9 @@ func setGeneric(input []byte, current *Value, add bool) error {
 	if err != nil {
 		return err
 	}
+
+	current.reset() // other decoded values are outdated
+	current.vset = s
 	current.Raw, err = s.MarshalBinary()
 	return err
 }
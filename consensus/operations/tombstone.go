@@ -0,0 +1,102 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package operations
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"errors"
+	"time"
+
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+)
+
+// Errors returned by reversible deletions.
+var (
+	ErrDeleted         = errors.New("key already deleted")
+	ErrNotDeleted      = errors.New("key not deleted, or rewritten since its deletion")
+	ErrRestoreExpired  = errors.New("grace period of deleted key expired")
+	ErrInvalidGrace    = errors.New("invalid grace period")
+	ErrReservedContent = errors.New("value reserved to deleted keys")
+)
+
+// SoftDelete replaces the current value by a tombstone, which can be restored
+// until the grace period given as input (a duration such as "24h") has
+// elapsed since the logical time of the transaction.
+func SoftDelete(input []byte, current *Value) error {
+	if encoding.IsTombstone(current.Raw) {
+		return ErrDeleted
+	}
+
+	grace, err := time.ParseDuration(string(input))
+	if err != nil || grace < 0 {
+		return ErrInvalidGrace
+	}
+
+	t := &encoding.Tombstone{
+		Expires: current.Time.Add(grace),
+		Value:   current.Raw,
+	}
+
+	raw, err := t.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	current.reset()
+	current.Raw = raw
+	return nil
+}
+
+// Restore replaces a tombstone by the value it holds. The input is ignored.
+func Restore(_ []byte, current *Value) error {
+	t := &encoding.Tombstone{}
+	if t.UnmarshalBinary(current.Raw) != nil {
+		return ErrNotDeleted
+	}
+
+	if current.Time.After(t.Expires) {
+		return ErrRestoreExpired
+	}
+
+	current.reset()
+	current.Raw = t.Value
+	return nil
+}
+
+// Prune replaces the current value by a tombstone that cannot be restored,
+// provided that the value has not been modified since it was selected for
+// pruning: the input is the SHA-512 hash of the value (its version). Values
+// that have been rewritten or deleted in the meantime are left untouched,
+// without error, so that the other keys pruned by the same query are still
+// deleted.
+func Prune(input []byte, current *Value) error {
+	h := sha512.Sum512(current.Raw)
+	if encoding.IsTombstone(current.Raw) || !bytes.Equal(h[:], input) {
+		return nil
+	}
+
+	t := &encoding.Tombstone{Expires: time.Unix(0, 0)} // already expired, without value to restore
+	raw, err := t.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	current.reset()
+	current.Raw = raw
+	return nil
+}
+
+// Delete replaces the current value, whatever it is, by the tombstone of a
+// permanently deleted key (see encoding.Deletion). The input is ignored.
+func Delete(_ []byte, current *Value) error {
+	current.reset()
+	current.Raw = encoding.Deletion()
+	return nil
+}
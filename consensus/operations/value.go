@@ -8,16 +8,33 @@
 
 package operations
 
-import "github.com/technicolor-research/pnyxdb/consensus/encoding"
+import (
+	"time"
+
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+)
 
 // Value holds the data that shall be used by operations.
 // One value, and only one, shall be used per key on a given transaction.
 // See the Runner interface for an example of usage.
 type Value struct {
 	Raw []byte
+	// Time is the logical time of the transaction, used by time-dependent
+	// operations. It must not depend on the local clock, since every node
+	// shall obtain the same output.
+	Time time.Time
+	// Tag identifies the transaction among the concurrent ones. Together with
+	// Time, it orders the elements pushed to lists by concurrent transactions.
+	Tag []byte
+	// Expiry is the logical time from which the value is seen as missing,
+	// or the zero time if it does not expire.
+	Expiry time.Time
 
 	vfloat *encoding.Float
+	vint   *encoding.Int
 	vset   *encoding.Set
+	vlist  *encoding.List
+	vmap   *encoding.Map
 }
 
 // NewValue returns a new value.
@@ -27,7 +44,10 @@ func NewValue(raw []byte) *Value {
 
 func (v *Value) reset() {
 	v.vfloat = nil
+	v.vint = nil
 	v.vset = nil
+	v.vlist = nil
+	v.vmap = nil
 }
 
 // Float lazily returns the current float value.
@@ -46,6 +66,22 @@ func (v *Value) Float() (*encoding.Float, error) {
 	return vfloat, nil
 }
 
+// Int lazily returns the current integer value.
+func (v *Value) Int() (*encoding.Int, error) {
+	if v.vint != nil {
+		return v.vint, nil
+	}
+
+	vint := encoding.NewInt()
+	err := vint.UnmarshalBinary(v.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	v.vint = vint
+	return vint, nil
+}
+
 // Set lazily returns the current set value.
 func (v *Value) Set() (*encoding.Set, error) {
 	if v.vset != nil {
@@ -61,3 +97,35 @@ func (v *Value) Set() (*encoding.Set, error) {
 	v.vset = vset
 	return vset, nil
 }
+
+// List lazily returns the current list value.
+func (v *Value) List() (*encoding.List, error) {
+	if v.vlist != nil {
+		return v.vlist, nil
+	}
+
+	vlist := encoding.NewList()
+	err := vlist.UnmarshalBinary(v.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	v.vlist = vlist
+	return vlist, nil
+}
+
+// Map lazily returns the current map value.
+func (v *Value) Map() (*encoding.Map, error) {
+	if v.vmap != nil {
+		return v.vmap, nil
+	}
+
+	vmap := encoding.NewMap()
+	err := vmap.UnmarshalBinary(v.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	v.vmap = vmap
+	return vmap, nil
+}
@@ -0,0 +1,206 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"go.uber.org/zap"
+)
+
+const pendingSyncPeriod = time.Second       // interval between two checks of the peers
+const pendingSyncPeers = 3                  // number of peers asked for their pending queries
+const pendingSyncTimeout = 10 * time.Second // per request
+const pendingSyncMaxList = 1024             // queries listed per response
+const pendingSyncMaxQueries = 128           // queries fetched per request
+const pendingSyncMaxBytes = 4 << 20         // size of fetched queries per response, unless a single query is larger
+
+// pendingSyncHandler serves the pending queries of the local node: either
+// their list, or the content of some of them. The content of queries that are
+// not pending anymore is also served to peers fetching proofs (see
+// fetchProofs). Responses are truncated to bounded sizes, and the requester
+// asks again for what is missing.
+func (eng *Engine) pendingSyncHandler(req *PendingSyncRequest) (*PendingSyncResponse, error) {
+	if len(req.Uuids) == 0 {
+		return &PendingSyncResponse{
+			Pending: eng.qs.PendingSummary(eng.now(), pendingSyncMaxList),
+		}, nil
+	}
+
+	uuids := req.Uuids
+	if len(uuids) > pendingSyncMaxQueries {
+		uuids = uuids[:pendingSyncMaxQueries]
+	}
+
+	res := &PendingSyncResponse{}
+	var size int
+	for _, uuid := range uuids {
+		var q *Query
+		var endorsements []*Endorsement
+		var aggregates []*AggregatedEndorsement
+		if req.Proofs {
+			q, endorsements, aggregates = eng.qs.ProofContent(uuid)
+		} else {
+			q, endorsements = eng.qs.PendingContent(uuid)
+		}
+		if q == nil {
+			continue
+		}
+
+		s := proto.Size(q)
+		for _, e := range endorsements {
+			s += proto.Size(e)
+		}
+		for _, a := range aggregates {
+			s += proto.Size(a)
+		}
+		if len(res.Queries) > 0 && size+s > pendingSyncMaxBytes {
+			break
+		}
+
+		size += s
+		res.Queries = append(res.Queries, q)
+		res.Endorsements = append(res.Endorsements, endorsements...)
+		res.Aggregates = append(res.Aggregates, aggregates...)
+	}
+	return res, nil
+}
+
+// pendingSyncWorker synchronizes the pending queries every time a new peer
+// is available, which happens on startup and when a partition heals. The
+// first check is delayed, to let the engine subscribe to the network.
+func (eng *Engine) pendingSyncWorker(ctx context.Context, psm PendingSyncManager) {
+	ticker := time.NewTicker(pendingSyncPeriod)
+	defer ticker.Stop()
+
+	known := make(map[string]bool)
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		peers := psm.PendingSyncPeers()
+		current := make(map[string]bool, len(peers))
+		var joined bool
+		for _, p := range peers {
+			current[p] = true
+			joined = joined || !known[p]
+		}
+		known = current
+
+		if joined {
+			eng.syncPending(ctx, psm, peers)
+		}
+	}
+}
+
+// syncPending asks a few peers for their pending queries, and fetches the
+// ones that are unknown locally and not expired yet.
+func (eng *Engine) syncPending(ctx context.Context, psm PendingSyncManager, peers []string) {
+	if len(peers) > pendingSyncPeers {
+		perm := rand.Perm(len(peers))
+		selected := make([]string, pendingSyncPeers)
+		for i := range selected {
+			selected[i] = peers[perm[i]]
+		}
+		peers = selected
+	}
+
+	now := eng.now()
+	missing := make(map[string][]string) // by peer
+	seen := make(map[string]bool)
+	for _, p := range peers {
+		subctx, cancel := context.WithTimeout(ctx, pendingSyncTimeout)
+		res, err := psm.RequestPendingSync(subctx, p, &PendingSyncRequest{})
+		cancel()
+		if err != nil {
+			zap.L().Warn("PendingSync", zap.String("peer", p), zap.Error(err))
+			continue
+		}
+
+		for _, pq := range res.Pending {
+			if seen[pq.Uuid] || eng.qs.GetQuery(pq.Uuid) != nil {
+				continue
+			}
+
+			deadline, err := ptypes.Timestamp(pq.Deadline)
+			if err != nil || !deadline.After(now) {
+				continue
+			}
+
+			seen[pq.Uuid] = true
+			missing[p] = append(missing[p], pq.Uuid)
+		}
+	}
+
+	for p, uuids := range missing {
+		eng.fetchPending(ctx, psm, p, uuids)
+	}
+}
+
+// fetchPending fetches some pending queries from a peer, with their
+// endorsements, and processes them as if they had been broadcast.
+func (eng *Engine) fetchPending(ctx context.Context, psm PendingSyncManager, peer string, uuids []string) {
+	var fetched int
+	for len(uuids) > 0 {
+		n := len(uuids)
+		if n > pendingSyncMaxQueries {
+			n = pendingSyncMaxQueries
+		}
+
+		subctx, cancel := context.WithTimeout(ctx, pendingSyncTimeout)
+		res, err := psm.RequestPendingSync(subctx, peer, &PendingSyncRequest{Uuids: uuids[:n]})
+		cancel()
+		if err != nil {
+			zap.L().Warn("PendingSync", zap.String("peer", peer), zap.Error(err))
+			return
+		}
+
+		requested := make(map[string]bool, n)
+		for _, uuid := range uuids[:n] {
+			requested[uuid] = true
+		}
+
+		returned := make(map[string]bool)
+		for _, q := range res.Queries {
+			if requested[q.Uuid] && !returned[q.Uuid] {
+				returned[q.Uuid] = true
+				q := q
+				eng.spawn(taskQuery, func() { eng.handleQuery(q) })
+			}
+		}
+		for _, e := range res.Endorsements {
+			if returned[e.Uuid] {
+				eng.handleEndorsement(e)
+			}
+		}
+		fetched += len(returned)
+
+		// A truncated response is followed by a new request for the
+		// remaining queries; the ones that are not pending anymore at
+		// the peer are skipped.
+		var next []string
+		if len(returned) > 0 {
+			for _, uuid := range uuids[:n] {
+				if !returned[uuid] {
+					next = append(next, uuid)
+				}
+			}
+		}
+		uuids = append(next, uuids[n:]...)
+	}
+
+	zap.L().Info("PendingSync", zap.String("peer", peer), zap.Int("fetched", fetched))
+}
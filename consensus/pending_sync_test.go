@@ -0,0 +1,103 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_PendingSync(t *testing.T) {
+	// Every endorsement is required, so that queries cannot be committed
+	// while one node is offline.
+	const n = 4
+	keyrings := tests.GetTestKeyRings(t, n)
+	h := &syncHub{}
+	engines := make([]*Engine, n)
+	for i := range engines {
+		engines[i] = NewEngine(newMemoryStore(), h.join(fmt.Sprint(i)), passBBC{}, keyrings[i], n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, eng := range engines[:n-1] {
+		require.Nil(t, eng.Run(ctx))
+	}
+	h.waitSubscribers(t, 4*(n-1)) // queries, endorsements, checkpoints and node statuses
+
+	get := func(eng *Engine, key string) []byte {
+		eng.Store.Lock()
+		defer eng.Store.Unlock()
+		value, _, _ := eng.Store.Get(key)
+		return value
+	}
+
+	var queries []*Query
+	for i := 0; i < 5; i++ {
+		q := NewQuery()
+		q.SetTimeout(3 * time.Second)
+		q.Operations = []*Operation{{Key: fmt.Sprint("k", i), Op: Operation_SET, Data: []byte("v")}}
+		require.Nil(t, engines[i%(n-1)].Submit(q))
+		queries = append(queries, q)
+	}
+
+	// Let the online nodes exchange their endorsements
+	time.Sleep(200 * time.Millisecond)
+	for _, q := range queries {
+		require.Nil(t, get(engines[0], q.Operations[0].Key), "query should wait for the offline node")
+		require.Nil(t, engines[n-1].qs.GetQuery(q.Uuid))
+	}
+
+	// The node comes back, fetches the pending queries and endorses them
+	require.Nil(t, engines[n-1].Run(ctx))
+	for _, q := range queries {
+		for _, eng := range engines {
+			for get(eng, q.Operations[0].Key) == nil {
+				require.False(t, q.Expired(), "query should be committed before its deadline")
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}
+}
+
+func TestEngine_PendingSyncHandler(t *testing.T) {
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, passBBC{}, tests.GetTestKeyRings(t, 1)[0], 1)
+
+	var uuids []string
+	for i := 0; i < pendingSyncMaxQueries+10; i++ {
+		q := NewQuery()
+		q.SetTimeout(time.Duration(i+1) * time.Second)
+		q.Operations = []*Operation{{Key: fmt.Sprint("k", i), Op: Operation_SET, Data: make([]byte, pendingSyncMaxBytes/100)}}
+		eng.qs.AddQuery(q)
+		uuids = append(uuids, q.Uuid)
+	}
+
+	expired := NewQuery()
+	expired.SetTimeout(-time.Second)
+	eng.qs.AddQuery(expired)
+
+	res, err := eng.pendingSyncHandler(&PendingSyncRequest{})
+	require.Nil(t, err)
+	require.Len(t, res.Pending, len(uuids), "expired queries should not be listed")
+	require.Exactly(t, uuids[len(uuids)-1], res.Pending[0].Uuid, "latest deadlines should come first")
+
+	res, err = eng.pendingSyncHandler(&PendingSyncRequest{Uuids: uuids})
+	require.Nil(t, err)
+	require.True(t, len(res.Queries) < 100, "responses should be bounded in size")
+	require.Exactly(t, uuids[0], res.Queries[0].Uuid)
+
+	res, err = eng.pendingSyncHandler(&PendingSyncRequest{Uuids: []string{"unknown"}})
+	require.Nil(t, err)
+	require.Len(t, res.Queries, 0)
+}
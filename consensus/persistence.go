@@ -12,19 +12,137 @@ import (
 	"bytes"
 	"encoding/gob"
 	"errors"
+	"fmt"
 	"io"
+	"strconv"
+	"time"
 )
 
-var dumpHeader = []byte(" PNYXDB_DUMP_V1 ")
+// dumpVersion is the format of the dumps written by encodeDump, recorded in
+// their header.
+const dumpVersion = 1
+
+var dumpHeader = []byte(fmt.Sprintf(" PNYXDB_DUMP_V%d ", dumpVersion))
+
+var errDumpHeader = errors.New("invalid dump header")
+var errDumpVersion = errors.New("unsupported dump format, the dump has been written by a newer version")
+
+// dumpDecoders read the content following the header of a dump, by format
+// version. A change of format adds a decoder, so that older dumps can still
+// be loaded.
+var dumpDecoders = map[int]func(*gob.Decoder, *queryStore) (dumpState, error){
+	1: decodeDumpV1,
+}
+
+// dumpState is the state of an engine saved along with its query store.
+// Fields missing from older dumps are left nil or zero.
+type dumpState struct {
+	epoch uint64
+}
 
 // Dump stores the current state of an engine, to be later loaded with Load.
 func (e *Engine) Dump(w io.Writer) error {
-	return e.qs.Dump(w)
+	return encodeDump(w, e.qs, func() dumpState {
+		return dumpState{
+			epoch: e.epoch.get(),
+		}
+	})
 }
 
 // Load loads the state of an engine from a dump file.
 func (e *Engine) Load(r io.Reader) error {
-	return e.qs.Load(r)
+	state, err := decodeDump(r, e.qs)
+	if err != nil {
+		return err
+	}
+
+	e.epoch.restore(state.epoch)
+	return nil
+}
+
+// encodeDump writes the header of a dump, followed by the query store and
+// the state returned by snapshot, which is called once the query store is
+// written.
+func encodeDump(w io.Writer, qs *queryStore, snapshot func() dumpState) error {
+	_, err := w.Write(dumpHeader)
+	if err != nil {
+		return err
+	}
+
+	encoder := gob.NewEncoder(w)
+	err = qs.encode(encoder)
+	if err != nil {
+		return err
+	}
+
+	// Quota usage counters and modification times are not saved anymore
+	// (see rebuildQuotas and Provenance.Modified), empty maps keep the dumps
+	// readable by older versions
+	state := snapshot()
+	err = encoder.Encode(map[string]int64{})
+	if err != nil {
+		return err
+	}
+
+	err = encoder.Encode(map[string]time.Time{})
+	if err != nil {
+		return err
+	}
+
+	return encoder.Encode(state.epoch)
+}
+
+// decodeDump reads a dump written by encodeDump, or by an older version,
+// into qs, and returns the state saved along with it.
+func decodeDump(r io.Reader, qs *queryStore) (state dumpState, err error) {
+	version, err := readDumpHeader(r)
+	if err != nil {
+		return state, err
+	}
+
+	decode, ok := dumpDecoders[version]
+	if !ok {
+		return state, errDumpVersion
+	}
+	return decode(gob.NewDecoder(r), qs)
+}
+
+// decodeDumpV1 reads the content of a dump of the first format.
+func decodeDumpV1(decoder *gob.Decoder, qs *queryStore) (state dumpState, err error) {
+	err = qs.decode(decoder)
+	if err != nil {
+		return state, err
+	}
+
+	// Dumps made before quotas were introduced do not have usage counters,
+	// which are ignored anyway.
+	var usage map[string]int64
+	err = decoder.Decode(&usage)
+	if err == io.EOF {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+
+	// Nor do dumps made before retention policies were introduced have
+	// modification times, which are now read from the store.
+	var modified map[string]time.Time
+	err = decoder.Decode(&modified)
+	if err == io.EOF {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+
+	// Nor checkpoint epochs, which then start from the ones seen in the
+	// cluster.
+	err = decoder.Decode(&state.epoch)
+	if err == io.EOF {
+		return state, nil
+	}
+	return state, err
 }
 
 func (e *Engine) markActive() {
@@ -34,17 +152,50 @@ func (e *Engine) markActive() {
 	}
 }
 
+// readDumpHeader reads the header of a dump, and returns its format version.
+func readDumpHeader(r io.Reader) (int, error) {
+	initBuf := make([]byte, len(dumpHeader))
+	_, err := io.ReadFull(r, initBuf)
+	if err != nil {
+		return 0, err
+	}
+
+	prefix := []byte(" PNYXDB_DUMP_V")
+	if !bytes.HasPrefix(initBuf, prefix) || !bytes.HasSuffix(initBuf, []byte(" ")) {
+		return 0, errDumpHeader
+	}
+
+	version, err := strconv.Atoi(string(initBuf[len(prefix) : len(initBuf)-1]))
+	if err != nil || version < 1 {
+		return 0, errDumpHeader
+	}
+	return version, nil
+}
+
 func (qs *queryStore) Dump(w io.Writer) error {
-	encoder := gob.NewEncoder(w)
 	_, err := w.Write(dumpHeader)
 	if err != nil {
 		return err
 	}
+	return qs.encode(gob.NewEncoder(w))
+}
+
+func (qs *queryStore) Load(r io.Reader) error {
+	version, err := readDumpHeader(r)
+	if err != nil {
+		return err
+	}
+	if _, ok := dumpDecoders[version]; !ok {
+		return errDumpVersion
+	}
+	return qs.decode(gob.NewDecoder(r))
+}
 
+func (qs *queryStore) encode(encoder *gob.Encoder) error {
 	qs.RLock()
 	defer qs.RUnlock()
 
-	err = encoder.Encode(qs.queries)
+	err := encoder.Encode(qs.queries)
 	if err != nil {
 		return err
 	}
@@ -62,23 +213,11 @@ func (qs *queryStore) Dump(w io.Writer) error {
 	return nil
 }
 
-func (qs *queryStore) Load(r io.Reader) error {
-	initBuf := make([]byte, len(dumpHeader))
-	_, err := io.ReadFull(r, initBuf)
-	if err != nil {
-		return err
-	}
-
-	if !bytes.Equal(initBuf, dumpHeader) {
-		return errors.New("invalid dump header")
-	}
-
-	decoder := gob.NewDecoder(r)
-
+func (qs *queryStore) decode(decoder *gob.Decoder) error {
 	qs.Lock()
 	defer qs.Unlock()
 
-	err = decoder.Decode(&qs.queries)
+	err := decoder.Decode(&qs.queries)
 	if err != nil {
 		return err
 	}
@@ -93,5 +232,6 @@ func (qs *queryStore) Load(r io.Reader) error {
 		return err
 	}
 
+	qs.recountMemory()
 	return nil
 }
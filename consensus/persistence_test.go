@@ -50,3 +50,20 @@ func TestEngine_Dump(t *testing.T) {
 
 	require.True(t, qs2.isApplicable(q.Uuid))
 }
+
+func TestEngine_DumpVersion(t *testing.T) {
+	e := &Engine{qs: newQueryStore()}
+	buffer := &bytes.Buffer{}
+	require.Nil(t, e.Dump(buffer))
+
+	version, err := readDumpHeader(bytes.NewReader(buffer.Bytes()))
+	require.Nil(t, err)
+	require.Equal(t, dumpVersion, version)
+
+	dump := buffer.Bytes()
+	newer := append([]byte(" PNYXDB_DUMP_V9 "), dump[len(dumpHeader):]...)
+	require.Exactly(t, errDumpVersion, e.Load(bytes.NewReader(newer)))
+
+	invalid := append([]byte(" PNYXDB_DUMP_VX "), dump[len(dumpHeader):]...)
+	require.Exactly(t, errDumpHeader, e.Load(bytes.NewReader(invalid)))
+}
@@ -0,0 +1,156 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"math/rand"
+
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+)
+
+// DefaultProofSummarySize is the size of veto proofs above which they are
+// summarized, unless Engine.ProofSummarySize is set.
+const DefaultProofSummarySize = 16 << 10
+
+// checkpointProofs returns the proofs sent along with a veto. Large proofs
+// are replaced by a ProofSummary when the network lets the receivers fetch
+// them from their peers, since the query and its endorsements are usually
+// known by most of them already.
+func (eng *Engine) checkpointProofs(proofs []*Proof) []*Proof {
+	aggregated := eng.aggregateProofs(proofs)
+
+	limit := eng.ProofSummarySize
+	if limit == 0 {
+		limit = DefaultProofSummarySize
+	}
+	if _, ok := eng.Network.(PendingSyncManager); !ok || limit < 0 || len(proofs) == 0 {
+		return aggregated
+	}
+
+	var size int
+	for _, p := range aggregated {
+		size += proto.Size(p)
+	}
+	if size <= limit {
+		return aggregated
+	}
+
+	// Aggregates built for this veto cannot be fetched from peers, the
+	// summary lists the stored endorsements instead.
+	s := &ProofSummary{}
+	for _, p := range proofs {
+		switch {
+		case p.GetQuery() != nil:
+			s.Uuid = p.GetQuery().Uuid
+		case p.GetEndorsement() != nil:
+			e := p.GetEndorsement()
+			s.Emitters = append(s.Emitters, e.Emitter)
+			s.Hashes = append(s.Hashes, signatureHash(e.Signature))
+		case p.GetAggregate() != nil:
+			a := p.GetAggregate()
+			for _, e := range a.Endorsements {
+				s.Emitters = append(s.Emitters, e.Emitter)
+				s.Hashes = append(s.Hashes, signatureHash(a.Signature))
+			}
+		}
+	}
+
+	zap.L().Debug("ProofSummary",
+		zap.String("uuid", s.Uuid),
+		zap.Int("size", size),
+		zap.Int("endorsements", len(s.Emitters)),
+	)
+	return []*Proof{{Content: &Proof_Summary{s}}}
+}
+
+func signatureHash(signature []byte) []byte {
+	hash := sha256.Sum256(signature)
+	return hash[:]
+}
+
+// missingProofs returns true if the query of a summary, or one of the
+// listed endorsements, is unknown locally.
+func (eng *Engine) missingProofs(s *ProofSummary) bool {
+	endorsers, ok := eng.qs.Endorsers(s.Uuid)
+	if !ok {
+		return true
+	}
+
+	for _, emitter := range s.Emitters {
+		if !endorsers[emitter] {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchProofs fetches the proofs listed by a summary from the peers, until
+// none of them is missing locally, and processes them like embedded proofs.
+// Only the proofs matching the summary are processed.
+func (eng *Engine) fetchProofs(ctx context.Context, s *ProofSummary) {
+	psm, ok := eng.Network.(PendingSyncManager)
+	if !ok || len(s.Emitters) != len(s.Hashes) {
+		zap.L().Warn("Invalid checkpoint proof", zap.Any("proof", s))
+		return
+	}
+
+	hashes := make(map[string][]byte, len(s.Emitters))
+	for i, emitter := range s.Emitters {
+		hashes[emitter] = s.Hashes[i]
+	}
+	listed := func(emitter string, signature []byte) bool {
+		hash, ok := hashes[emitter]
+		return ok && bytes.Equal(hash, signatureHash(signature))
+	}
+
+	peers := psm.PendingSyncPeers()
+	for _, i := range rand.Perm(len(peers)) {
+		if !eng.missingProofs(s) {
+			return
+		}
+
+		subctx, cancel := context.WithTimeout(ctx, pendingSyncTimeout)
+		res, err := psm.RequestPendingSync(subctx, peers[i], &PendingSyncRequest{
+			Uuids:  []string{s.Uuid},
+			Proofs: true,
+		})
+		cancel()
+		if err != nil {
+			zap.L().Warn("ProofFetch", zap.String("peer", peers[i]), zap.Error(err))
+			continue
+		}
+
+		for _, q := range res.Queries {
+			if q.Uuid == s.Uuid {
+				eng.handleQuery(q)
+			}
+		}
+		for _, e := range res.Endorsements {
+			if e.Uuid == s.Uuid && listed(e.Emitter, e.Signature) {
+				eng.handleEndorsement(e)
+			}
+		}
+	aggregates:
+		for _, a := range res.Aggregates {
+			for _, e := range a.Endorsements {
+				if e.Uuid != s.Uuid || !listed(e.Emitter, a.Signature) {
+					continue aggregates
+				}
+			}
+			eng.handleAggregate(a)
+		}
+	}
+
+	if eng.missingProofs(s) {
+		zap.L().Warn("ProofFetch", zap.String("uuid", s.Uuid), zap.String("state", "incomplete"))
+	}
+}
@@ -0,0 +1,128 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package consensus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+// proofPeers lets a node fetch proofs from other engines, without receiving
+// their broadcasts.
+type proofPeers struct {
+	recordingNetwork
+	handlers map[string]PendingSyncHandler
+}
+
+func (n *proofPeers) PendingSyncPeers() []string {
+	var peers []string
+	for name := range n.handlers {
+		peers = append(peers, name)
+	}
+	return peers
+}
+
+func (n *proofPeers) RequestPendingSync(ctx context.Context, peer string, req *PendingSyncRequest) (*PendingSyncResponse, error) {
+	handler, ok := n.handlers[peer]
+	if !ok {
+		return nil, errors.New("unknown peer")
+	}
+	return handler(req)
+}
+
+func (n *proofPeers) AcceptPendingSync(ctx context.Context, handler PendingSyncHandler) {}
+
+// vetoBBC always decides a veto, with the given proofs.
+type vetoBBC struct {
+	proofs []*Proof
+}
+
+func (b vetoBBC) Execute(ctx context.Context, id string, choice bool, proofs []*Proof) (bool, []*Proof, error) {
+	return false, b.proofs, nil
+}
+
+func TestEngine_ProofSummary(t *testing.T) {
+	const quorum = 3
+	keyrings := tests.GetTestKeyRings(t, 4)
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Emitter = keyrings[1].Identity()
+	q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: make([]byte, 1024)}}
+	signers := make([]*Engine, len(keyrings))
+	for i, kr := range keyrings {
+		signers[i] = NewEngine(newMemoryStore(), &recordingNetwork{}, passBBC{}, kr, quorum)
+	}
+	require.Nil(t, signers[1].signQuery(q))
+
+	// Node 0 has committed the query, and vetoes a checkpoint including it
+	vetoer := NewEngine(newMemoryStore(), &proofPeers{}, passBBC{}, keyrings[0], quorum)
+	vetoer.ProofSummarySize = 512
+	vetoer.handleQuery(q)
+	for _, signer := range signers[1:] {
+		e := &Endorsement{Uuid: q.Uuid, Emitter: signer.Identity()}
+		require.Nil(t, signer.signEndorsement(e))
+		vetoer.handleEndorsement(e)
+	}
+	_, v, _ := vetoer.Store.Get("a")
+	require.NotEqual(t, NoVersion, v)
+
+	choice, proofs := vetoer.qs.CheckpointChoice([]string{q.Uuid})
+	require.False(t, choice)
+	summarized := vetoer.checkpointProofs(proofs)
+	require.Len(t, summarized, 1)
+	s := summarized[0].GetSummary()
+	require.NotNil(t, s)
+	require.Exactly(t, q.Uuid, s.Uuid)
+	require.Len(t, s.Emitters, quorum)
+
+	vetoer.ProofSummarySize = -1
+	require.Len(t, vetoer.checkpointProofs(proofs), quorum+1, "proofs should be embedded when summaries are disabled")
+
+	// Node 2 only knows the query, and fetches the endorsements after the
+	// veto, from a peer that does not know them and from node 0
+	lagging := NewEngine(newMemoryStore(), &proofPeers{}, passBBC{}, keyrings[2], quorum)
+	network := &proofPeers{handlers: map[string]PendingSyncHandler{
+		"lagging": lagging.pendingSyncHandler,
+		"vetoer":  vetoer.pendingSyncHandler,
+	}}
+	decider := NewEngine(newMemoryStore(), network, vetoBBC{summarized}, keyrings[3], quorum)
+	decider.handleQuery(q)
+	_, v, _ = decider.Store.Get("a")
+	require.Exactly(t, NoVersion, v)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	decider.handleCheckpoint(ctx, &StartCheckpoint{Queries: []string{q.Uuid}})
+
+	for i := 0; ; i++ {
+		decider.Store.Lock()
+		_, v, _ = decider.Store.Get("a")
+		decider.Store.Unlock()
+		if v != NoVersion {
+			break
+		}
+		require.True(t, i < 200, "decider should fetch the proofs and commit the query")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Endorsements that are not listed by the summary are ignored
+	other := NewEngine(newMemoryStore(), &proofPeers{handlers: map[string]PendingSyncHandler{
+		"vetoer": vetoer.pendingSyncHandler,
+	}}, passBBC{}, keyrings[2], quorum)
+	partial := &ProofSummary{Uuid: q.Uuid, Emitters: s.Emitters[:1], Hashes: s.Hashes[:1]}
+	other.fetchProofs(ctx, partial)
+	endorsers, ok := other.qs.Endorsers(q.Uuid)
+	require.True(t, ok)
+	require.Exactly(t, map[string]bool{s.Emitters[0]: true}, endorsers)
+}
@@ -0,0 +1,260 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/technicolor-research/pnyxdb/keyring"
+)
+
+// DefaultQuarantineSize is the number of queries of unknown emitters kept by
+// the engine, unless Engine.QuarantineSize is set.
+const DefaultQuarantineSize = 64
+
+const (
+	unknownEmitterRetention = time.Hour // unknown emitters silent for longer are forgotten
+	unknownEmitterLogSample = 100       // one message out of this many is logged for each unknown emitter
+	unknownEmitterMax       = 1024      // unknown emitters tracked, the least recently seen ones are forgotten first
+)
+
+// UnknownEmitter describes an identity missing from the keyring, whose
+// messages have been received recently.
+type UnknownEmitter struct {
+	Identity    string
+	Messages    uint64 // queries and endorsements received
+	Quarantined int    // queries waiting for the key of the identity
+	FirstSeen   time.Time
+	LastSeen    time.Time
+}
+
+// quarantineTracker keeps the recent queries of unknown emitters, so that
+// they can be handled once the key of their emitter is imported, and counts
+// the messages of these emitters.
+type quarantineTracker struct {
+	sync.Mutex
+	queries  []*Query // oldest first
+	emitters map[string]*UnknownEmitter
+}
+
+// seen records a message of an unknown emitter, and returns true if it must
+// be logged. Emitters silent since retention are forgotten, as well as the
+// least recently seen ones beyond unknownEmitterMax.
+func (t *quarantineTracker) seen(emitter string, now time.Time) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.emitters == nil {
+		t.emitters = make(map[string]*UnknownEmitter)
+	}
+	quarantined := t.quarantined()
+	t.prune(now.Add(-unknownEmitterRetention), quarantined)
+
+	u, ok := t.emitters[emitter]
+	if !ok {
+		if len(t.emitters) >= unknownEmitterMax {
+			t.evict(quarantined)
+		}
+		u = &UnknownEmitter{Identity: emitter, FirstSeen: now}
+		t.emitters[emitter] = u
+	}
+	u.Messages++
+	u.LastSeen = now
+	return u.Messages%unknownEmitterLogSample == 1
+}
+
+// quarantined returns the number of quarantined queries of each emitter.
+// unsafe
+func (t *quarantineTracker) quarantined() map[string]int {
+	quarantined := make(map[string]int)
+	for _, q := range t.queries {
+		quarantined[q.Emitter]++
+	}
+	return quarantined
+}
+
+// prune forgets the emitters seen before since, unless some of their queries
+// are quarantined.
+// unsafe
+func (t *quarantineTracker) prune(since time.Time, quarantined map[string]int) {
+	for identity, u := range t.emitters {
+		if u.LastSeen.Before(since) && quarantined[identity] == 0 {
+			delete(t.emitters, identity)
+		}
+	}
+}
+
+// evict forgets the least recently seen emitter, preferably one without
+// quarantined queries.
+// unsafe
+func (t *quarantineTracker) evict(quarantined map[string]int) {
+	first := func(u, v *UnknownEmitter) bool {
+		if idle := quarantined[u.Identity] == 0; idle != (quarantined[v.Identity] == 0) {
+			return idle
+		}
+		return u.LastSeen.Before(v.LastSeen)
+	}
+
+	var oldest *UnknownEmitter
+	for _, u := range t.emitters {
+		if oldest == nil || first(u, oldest) {
+			oldest = u
+		}
+	}
+	if oldest != nil {
+		delete(t.emitters, oldest.Identity)
+	}
+}
+
+// add keeps q, evicting the oldest queries beyond size.
+func (t *quarantineTracker) add(q *Query, size int) {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, known := range t.queries {
+		if known.Uuid == q.Uuid {
+			return
+		}
+	}
+
+	t.queries = append(t.queries, q)
+	if len(t.queries) > size {
+		t.queries = t.queries[len(t.queries)-size:]
+	}
+}
+
+// identities returns the unknown emitters.
+func (t *quarantineTracker) identities() []string {
+	t.Lock()
+	defer t.Unlock()
+
+	identities := make([]string, 0, len(t.emitters))
+	for identity := range t.emitters {
+		identities = append(identities, identity)
+	}
+	return identities
+}
+
+// release removes and returns the queries of emitters which are not unknown
+// anymore, forgetting these emitters.
+func (t *quarantineTracker) release(known map[string]bool) []*Query {
+	t.Lock()
+	defer t.Unlock()
+
+	var released, kept []*Query
+	for _, q := range t.queries {
+		if known[q.Emitter] {
+			released = append(released, q)
+		} else {
+			kept = append(kept, q)
+		}
+	}
+	t.queries = kept
+
+	for identity := range known {
+		delete(t.emitters, identity)
+	}
+	return released
+}
+
+// report returns the emitters seen since t, sorted by identity, and forgets
+// the others.
+func (t *quarantineTracker) report(since time.Time) []UnknownEmitter {
+	t.Lock()
+	defer t.Unlock()
+
+	quarantined := t.quarantined()
+	t.prune(since, quarantined)
+
+	emitters := make([]UnknownEmitter, 0, len(t.emitters))
+	for identity, u := range t.emitters {
+		e := *u
+		e.Quarantined = quarantined[identity]
+		emitters = append(emitters, e)
+	}
+
+	sort.Slice(emitters, func(i, j int) bool {
+		return emitters[i].Identity < emitters[j].Identity
+	})
+	return emitters
+}
+
+// isUnknownIdentity returns true if err reports an identity missing from
+// the keyring.
+func isUnknownIdentity(err error) bool {
+	_, ok := err.(*keyring.ErrUnknownIdentity)
+	return ok
+}
+
+// unknownEmitter records a message of an identity missing from the keyring,
+// logging the first one and then a sample of the others.
+func (eng *Engine) unknownEmitter(kind, emitter, uuid string) {
+	if !eng.quarantine.seen(emitter, eng.clock().Now()) {
+		return
+	}
+
+	zap.L().Warn("UnknownEmitter",
+		zap.String("kind", kind),
+		zap.String("emitter", emitter),
+		zap.String("uuid", uuid),
+	)
+}
+
+// quarantineQuery keeps a query whose emitter is missing from the keyring,
+// until the key of the emitter is imported.
+func (eng *Engine) quarantineQuery(q *Query) {
+	eng.unknownEmitter("query", q.Emitter, q.Uuid)
+
+	size := eng.QuarantineSize
+	if size == 0 {
+		size = DefaultQuarantineSize
+	}
+	if size > 0 {
+		eng.quarantine.add(q, size)
+	}
+}
+
+// releaseQuarantine handles again the quarantined queries whose emitter has
+// been added to the keyring.
+func (eng *Engine) releaseQuarantine() {
+	// The keyring is not accessed while the tracker is locked
+	known := make(map[string]bool)
+	for _, identity := range eng.quarantine.identities() {
+		if !isUnknownIdentity(eng.KeyRing.Trusted(identity)) {
+			known[identity] = true
+		}
+	}
+	if len(known) == 0 {
+		return
+	}
+
+	released := eng.quarantine.release(known)
+
+	for _, q := range released {
+		zap.L().Info("Unquarantined",
+			zap.String("uuid", q.Uuid),
+			zap.String("emitter", q.Emitter),
+		)
+		q := q
+		eng.spawn(taskQuery, func() { eng.handleQuery(q) })
+	}
+}
+
+// UnknownEmitters returns the identities missing from the keyring whose
+// queries or endorsements have been received recently, sorted by identity,
+// so that operators know which keys to import. Identities are listed as
+// long as some of their queries are kept in quarantine.
+// This function is thread-safe.
+func (eng *Engine) UnknownEmitters() []UnknownEmitter {
+	return eng.quarantine.report(eng.clock().Now().Add(-unknownEmitterRetention))
+}
@@ -0,0 +1,105 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/keyring"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_Quarantine(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 2)
+	signer := NewEngine(nil, nil, nil, keyrings[1], 1)
+	identity := keyrings[1].Identity()
+	public, _, err := keyrings[0].GetPublic(identity)
+	require.Nil(t, err)
+	keyrings[0].RemovePublic(identity)
+
+	network := &recordingNetwork{}
+	eng := NewEngine(newMemoryStore(), network, passBBC{}, keyrings[0], 2)
+	eng.QuarantineSize = 2
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.Nil(t, eng.Run(ctx))
+
+	query := func() *Query {
+		q := NewQuery()
+		q.SetTimeout(time.Minute)
+		q.Emitter = identity
+		q.Operations = []*Operation{{Key: q.Uuid, Op: Operation_SET, Data: []byte("v")}}
+		require.Nil(t, signer.signQuery(q))
+		return q
+	}
+
+	// Only the latest queries are kept
+	evicted, q1, q2 := query(), query(), query()
+	for _, q := range []*Query{evicted, q1, q2, q2} {
+		eng.handleQuery(q)
+		_, err = eng.QueryStatus(q.Uuid)
+		require.Exactly(t, ErrUnknownQuery, err)
+	}
+	e := &Endorsement{Uuid: q1.Uuid, Emitter: identity}
+	require.Nil(t, signer.signEndorsement(e))
+	eng.handleEndorsement(e)
+
+	unknown := eng.UnknownEmitters()
+	require.Len(t, unknown, 1)
+	require.Equal(t, identity, unknown[0].Identity)
+	require.Equal(t, uint64(5), unknown[0].Messages)
+	require.Equal(t, 2, unknown[0].Quarantined)
+
+	// The quarantined queries are handled once the key is imported
+	require.Nil(t, keyrings[0].AddPublic(identity, keyring.TrustHIGH, public))
+	endorsed := func(uuid string) bool {
+		network.Lock()
+		defer network.Unlock()
+		for _, m := range network.messages {
+			if e, ok := m.(*Endorsement); ok && e.Uuid == uuid {
+				return true
+			}
+		}
+		return false
+	}
+	eventually(t, func() bool { return endorsed(q1.Uuid) && endorsed(q2.Uuid) }, "quarantined queries should be endorsed once their emitter is known")
+
+	_, err = eng.QueryStatus(evicted.Uuid)
+	require.Exactly(t, ErrUnknownQuery, err)
+	require.Empty(t, eng.UnknownEmitters())
+}
+
+func TestQuarantineTracker_Bounds(t *testing.T) {
+	var tracker quarantineTracker
+	now := time.Now()
+	q := NewQuery()
+	q.Emitter = "quarantined"
+	tracker.add(q, 1)
+	tracker.seen(q.Emitter, now)
+
+	// The least recently seen emitters are evicted first, except the ones
+	// with quarantined queries
+	for i := 0; i < unknownEmitterMax+10; i++ {
+		tracker.seen(fmt.Sprintf("emitter%d", i), now.Add(time.Duration(i)*time.Millisecond))
+	}
+	require.Len(t, tracker.emitters, unknownEmitterMax)
+	require.Contains(t, tracker.emitters, q.Emitter)
+	require.NotContains(t, tracker.emitters, "emitter10")
+	require.Contains(t, tracker.emitters, "emitter11")
+
+	// Silent emitters are forgotten on the next message
+	tracker.seen("late", now.Add(2*unknownEmitterRetention))
+	require.Len(t, tracker.emitters, 2)
+	require.Contains(t, tracker.emitters, q.Emitter)
+	require.Contains(t, tracker.emitters, "late")
+}
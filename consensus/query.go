@@ -15,6 +15,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	uuid "github.com/satori/go.uuid"
+	"github.com/technicolor-research/pnyxdb/consensus/operations"
 )
 
 // NewQuery instanciates a new empty query.
@@ -87,21 +88,93 @@ func (q *Query) Expired() bool {
 	return q.ExpiredSince(0)
 }
 
-// ExpiredSince returns true if a query deadline have been reached for at least d duration.
-func (q *Query) ExpiredSince(d time.Duration) bool {
+// ExpiredAt returns true if a query deadline is reached at time t.
+func (q *Query) ExpiredAt(t time.Time) bool {
 	if q == nil || q.Deadline == nil {
 		return true
 	}
 
-	limit := time.Now().Add(-1 * d)
-	return !q.DeadlineTime().After(limit)
+	return !q.DeadlineTime().After(t)
+}
+
+// Precedes returns true if q comes before q2 in the arbitration order of
+// conflicting queries: earliest deadline first, then lowest UUID. Every node
+// agrees on this order, whatever the order in which it received the queries.
+func (q *Query) Precedes(q2 *Query) bool {
+	d, d2 := q.DeadlineTime(), q2.DeadlineTime()
+	if !d.Equal(d2) {
+		return d.Before(d2)
+	}
+	return q.Uuid < q2.Uuid
+}
+
+// ExpiredSince returns true if a query deadline have been reached for at least d duration.
+// Engines check deadlines against their own clock instead, with ExpiredAt.
+func (q *Query) ExpiredSince(d time.Duration) bool {
+	return q.ExpiredAt(time.Now().Add(-d))
+}
+
+// Execute runs the operations of q against the values read through get, and
+// returns the resulting values by key, without writing them. get is called
+// once per key, and shall return nil for missing keys.
+// Operations are executed at the deadline of q, which every node agrees on:
+// since q is committed before its deadline, a RESTORE accepted against it has
+// not been applied after the end of the grace period.
+func (q *Query) Execute(get func(key string) ([]byte, error)) (map[string]*operations.Value, error) {
+	return q.execute(func(key string) ([]byte, *Version, error) {
+		data, err := get(key)
+		return data, nil, err
+	})
+}
+
+// execute is Execute, get also returning the version of each value: values
+// expired at the deadline of q are seen as missing, and the others keep their
+// expiry unless an operation changes it.
+func (q *Query) execute(get func(key string) ([]byte, *Version, error)) (map[string]*operations.Value, error) {
+	deadline := q.DeadlineTime()
+	values := make(map[string]*operations.Value)
+	for _, op := range q.Operations {
+		value, ok := values[op.Key]
+		if !ok {
+			data, version, err := get(op.Key)
+			if err != nil {
+				return nil, err
+			}
+
+			if version.ExpiredAt(deadline) {
+				data, version = nil, nil
+			}
+			value = q.newValue(data)
+			value.Expiry = version.ExpiryTime()
+			values[op.Key] = value
+		}
+
+		err := op.Exec(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// newValue returns a value holding data, as read by the operations of q.
+func (q *Query) newValue(data []byte) *operations.Value {
+	value := operations.NewValue(data)
+	value.Time = q.DeadlineTime()
+	value.Tag = []byte(q.Emitter + "\x00" + q.Uuid)
+	return value
 }
 
 // Hash returns a fixed-size hash of the (unsigned) version of the query.
 // Passed by value because of internal modifications.
+// Requirements are marshalled in key order, so that the hash does not
+// depend on the map iteration order.
 func (q Query) Hash() ([]byte, error) {
 	q.Signature = nil
-	raw, err := proto.Marshal(&q)
-	hash := sha512.Sum512(raw)
+	b := proto.NewBuffer(nil)
+	b.SetDeterministic(true)
+	err := b.Marshal(&q)
+	hash := sha512.Sum512(b.Bytes())
 	return hash[:], err
 }
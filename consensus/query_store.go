@@ -9,10 +9,12 @@
 package consensus
 
 import (
-	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"go.uber.org/zap"
 )
 
@@ -44,29 +46,148 @@ type queryInfo struct {
 	Dependents   []string
 	State        queryState
 	Endorsed     bool
+	Withdrawn    bool // the local endorsement has been withdrawn, see Withdraw
 	Applied      bool
+	ApplyError   error     // set when a committed query could not be applied
+	Written      bool      // committed values have been written to the store
+	Resolved     time.Time // when the query has been committed or dropped
 	cachedInfo
 }
 
 type endorsementInfo struct {
 	*Endorsement
+	Aggregate *AggregatedEndorsement // set for unsigned endorsements received in an aggregate
 	cachedInfo
 }
 
 type queryStore struct {
-	sync.RWMutex
+	queryStoreLock // see lockorder.go
 
 	queries             map[string]queryInfo
 	pendingDependencies map[string][]string
 	pendingEndorsements []*Endorsement
+	pendingAggregates   []*AggregatedEndorsement
+	speculative         []string   // applicable queries not written yet, in the order they became applicable
+	decisions           []decision // committed and dropped queries not persisted yet, see Engine.persistDecisions
+	memory              int64      // serialized size of the pending queries and endorsements, see recountMemory
 	threshold           int
+	waiters             map[string][]*waiter // by query, woken up when the query is committed or dropped
+	orphans             map[string]orphan    // pending endorsements of each unknown query, see ForgetOrphans
+	orphanOrder         []string             // unknown queries, first endorsed first, along with some received or forgotten ones
+	orphanCount         int                  // pending endorsements of the unknown queries
+	clock               func() time.Time     // the local time, time.Now if nil
+}
+
+// decision is a query committed or dropped by the node.
+type decision struct {
+	uuid      string
+	deadline  time.Time // zero if the query is unknown
+	resolved  time.Time
+	committed bool
+}
+
+// waiter is a channel closed at most once, possibly registered for several queries.
+type waiter struct {
+	c    chan struct{}
+	once sync.Once
+}
+
+func (w *waiter) wake() {
+	w.once.Do(func() { close(w.c) })
 }
 
 func newQueryStore() *queryStore {
 	return &queryStore{
 		queries:             make(map[string]queryInfo),
 		pendingDependencies: make(map[string][]string),
+		waiters:             make(map[string][]*waiter),
+	}
+}
+
+// setClock sets the source of local time of the store, see Engine.Clock.
+func (qs *queryStore) setClock(now func() time.Time) {
+	qs.Lock()
+	defer qs.Unlock()
+	qs.clock = now
+}
+
+func (qs *queryStore) now() time.Time { // unsafe
+	if qs.clock == nil {
+		return time.Now()
 	}
+	return qs.clock()
+}
+
+// Watch returns a channel that is closed as soon as one of the provided
+// queries is not pending anymore (committed or dropped). The returned
+// function must be called to release the channel once it is not needed.
+func (qs *queryStore) Watch(uuids []string) (<-chan struct{}, func()) {
+	return qs.watch(uuids, func(qi queryInfo) bool { return qi.State != qPending })
+}
+
+// settled returns true if the query is dropped, or committed and applied
+// (successfully or not).
+func (qi queryInfo) settled() bool {
+	return qi.State == qDropped || qi.State == qCommitted && (qi.Written || qi.ApplyError != nil)
+}
+
+// state returns the state of the query, as reported by QueryStatus.
+func (qi queryInfo) state() QueryState {
+	switch qi.State {
+	case qCommitted:
+		return StateCommitted
+	case qDropped:
+		return StateDropped
+	default:
+		return StatePending
+	}
+}
+
+// watch registers a waiter for the queries, woken up immediately if one of
+// them is already done.
+func (qs *queryStore) watch(uuids []string, done func(queryInfo) bool) (<-chan struct{}, func()) {
+	qs.Lock()
+	defer qs.Unlock()
+
+	w := &waiter{c: make(chan struct{})}
+	for _, uuid := range uuids {
+		if done(qs.queries[uuid]) {
+			w.wake()
+			return w.c, func() {}
+		}
+	}
+
+	for _, uuid := range uuids {
+		qs.waiters[uuid] = append(qs.waiters[uuid], w)
+	}
+
+	return w.c, func() {
+		qs.Lock()
+		defer qs.Unlock()
+
+		for _, uuid := range uuids {
+			list := qs.waiters[uuid]
+			for i, w2 := range list {
+				if w2 == w {
+					list = append(list[:i], list[i+1:]...)
+					break
+				}
+			}
+
+			if len(list) == 0 {
+				delete(qs.waiters, uuid)
+			} else {
+				qs.waiters[uuid] = list
+			}
+		}
+	}
+}
+
+func (qs *queryStore) notify(uuid string) { // unsafe
+	for _, w := range qs.waiters[uuid] {
+		w.wake()
+	}
+	delete(qs.waiters, uuid)
 }
 
 func (qs *queryStore) AddQuery(q *Query) (inserted bool) {
@@ -78,6 +199,8 @@ func (qs *queryStore) AddQuery(q *Query) (inserted bool) {
 	}
 
 	qi := queryInfo{Query: q}
+	qs.memory += messageSize(q)
+	qs.adopted(q.Uuid)
 
 	// Trick from https://github.com/golang/go/wiki/SliceTricks#filtering-without-allocating
 	pendingEndorsements := qs.pendingEndorsements[:0]
@@ -88,8 +211,26 @@ func (qs *queryStore) AddQuery(q *Query) (inserted bool) {
 			pendingEndorsements = append(pendingEndorsements, pe)
 			continue
 		}
-		_, qi = qs.addEndorsementInternal(pe, qi)
+		var ok bool
+		if ok, qi = qs.addEndorsementInternal(pe, nil, qi); !ok {
+			qs.memory -= messageSize(pe)
+		}
+	}
+
+	pendingAggregates := qs.pendingAggregates[:0]
+	for _, pa := range qs.pendingAggregates {
+		if pa.Endorsements[0].Uuid != q.Uuid {
+			pendingAggregates = append(pendingAggregates, pa)
+			continue
+		}
+		for _, e := range pa.Endorsements {
+			var ok bool
+			if ok, qi = qs.addEndorsementInternal(e, pa, qi); !ok {
+				qs.memory -= messageSize(e)
+			}
+		}
 	}
+	qs.pendingAggregates = pendingAggregates
 
 	qi.Dependents = qs.pendingDependencies[q.Uuid]
 	delete(qs.pendingDependencies, q.Uuid)
@@ -108,6 +249,15 @@ func (qs *queryStore) GetQuery(uuid string) *Query {
 	return qi.Query
 }
 
+// Known returns true if the query is known, even if only its decision is.
+func (qs *queryStore) Known(uuid string) bool {
+	qs.RLock()
+	defer qs.RUnlock()
+
+	_, ok := qs.queries[uuid]
+	return ok
+}
+
 func (qs *queryStore) AddEndorsement(e *Endorsement) (pending bool, inserted bool) {
 	qs.Lock()
 	defer qs.Unlock()
@@ -115,16 +265,55 @@ func (qs *queryStore) AddEndorsement(e *Endorsement) (pending bool, inserted boo
 	qi, ok := qs.queries[e.Uuid]
 	if !ok {
 		qs.pendingEndorsements = append(qs.pendingEndorsements, e)
+		qs.memory += messageSize(e)
+		qs.orphaned(e.Uuid, 1, messageSize(e))
 		pending = true
 		return
 	}
 
-	inserted, qi = qs.addEndorsementInternal(e, qi)
+	inserted, qi = qs.addEndorsementInternal(e, nil, qi)
+	if inserted && qi.State == qPending {
+		qs.memory += messageSize(e)
+	}
 	qs.cascadeMark(qi)
 	return
 }
 
-func (qs *queryStore) addEndorsementInternal(e *Endorsement, qi queryInfo) (bool, queryInfo) { // unsafe
+// AddAggregate adds the endorsements of a verified aggregate, like
+// AddEndorsement, and returns those inserted. Endorsers whose endorsement is
+// already known are skipped.
+func (qs *queryStore) AddAggregate(a *AggregatedEndorsement) (pending bool, inserted []*Endorsement) {
+	qs.Lock()
+	defer qs.Unlock()
+
+	qi, ok := qs.queries[a.Endorsements[0].Uuid]
+	if !ok {
+		qs.pendingAggregates = append(qs.pendingAggregates, a)
+		var size int64
+		for _, e := range a.Endorsements {
+			size += messageSize(e)
+		}
+		qs.memory += size
+		qs.orphaned(a.Endorsements[0].Uuid, len(a.Endorsements), size)
+		pending = true
+		return
+	}
+
+	for _, e := range a.Endorsements {
+		var ok bool
+		ok, qi = qs.addEndorsementInternal(e, a, qi)
+		if ok {
+			inserted = append(inserted, e)
+			if qi.State == qPending {
+				qs.memory += messageSize(e)
+			}
+		}
+	}
+	qs.cascadeMark(qi)
+	return
+}
+
+func (qs *queryStore) addEndorsementInternal(e *Endorsement, a *AggregatedEndorsement, qi queryInfo) (bool, queryInfo) { // unsafe
 	// Is there already an endorsement from the emitter?
 	for _, e2 := range qi.Endorsements {
 		if e.Emitter == e2.Emitter {
@@ -142,47 +331,64 @@ func (qs *queryStore) addEndorsementInternal(e *Endorsement, qi queryInfo) (bool
 		}
 	}
 
-	qi.Endorsements = append(qi.Endorsements, endorsementInfo{Endorsement: e})
+	qi.Endorsements = append(qi.Endorsements, endorsementInfo{Endorsement: e, Aggregate: a})
 	return true, qi
 }
 
+// cascadeMark stores qi and marks its applicability as stale, along with the
+// applicability of the queries endorsed on condition of it, transitively.
+// Queries already marked do not propagate further, and each query is visited
+// at most once, so that cyclic dependencies terminate.
 func (qs *queryStore) cascadeMark(qi queryInfo) { // unsafe
 	if qi.Query == nil {
-		fmt.Println("!!!!!")
+		zap.L().Warn("Invalid query info", zap.String("reason", "missing query"))
 		return
 	}
-
-	marked := !qi.Fresh()
-	qi.Mark()
 	qs.queries[qi.Uuid] = qi
 
-	// Do not propagate if already marked
-	if marked {
-		return
-	}
+	visited := make(map[string]bool)
+	for queue := []string{qi.Uuid}; len(queue) > 0; queue = queue[1:] {
+		uuid := queue[0]
+		qi, ok := qs.queries[uuid]
+		if !ok || visited[uuid] {
+			continue
+		}
+		visited[uuid] = true
 
-	for _, uuid := range qi.Dependents {
-		qid, ok := qs.queries[uuid]
-		if !ok {
+		if qi.Query == nil {
+			zap.L().Warn("Invalid query info",
+				zap.String("uuid", uuid),
+				zap.String("reason", "missing query"),
+			)
 			continue
 		}
 
-		for i, e := range qid.Endorsements {
-			var contains bool
-			for _, c := range e.Conditions {
-				if c == qi.Uuid {
-					contains = true
-					break
-				}
+		marked := !qi.Fresh()
+		qi.Mark()
+		qs.queries[uuid] = qi
+
+		// Do not propagate if already marked
+		if marked {
+			continue
+		}
+
+		for _, dep := range qi.Dependents {
+			qid, ok := qs.queries[dep]
+			if !ok {
+				continue
 			}
 
-			if contains {
-				e.Mark()
-				qid.Endorsements[i] = e
+			for i, e := range qid.Endorsements {
+				for _, c := range e.Conditions {
+					if c == uuid {
+						e.Mark()
+						qid.Endorsements[i] = e
+						break
+					}
+				}
 			}
+			queue = append(queue, dep)
 		}
-
-		qs.cascadeMark(qid)
 	}
 }
 
@@ -235,7 +441,27 @@ func (qs *queryStore) isApplicable(uuid string) bool { // unsafe
 	return result
 }
 
-func (qs *queryStore) GetConflicting(q *Query) (cq []*Query) {
+// Followed returns the committed queries conflicting with q, sorted, which
+// are listed by the endorsements of q, see Endorsement.Follows.
+func (qs *queryStore) Followed(q *Query) []string {
+	qs.RLock()
+	defer qs.RUnlock()
+
+	var follows []string
+	for uuid, q2 := range qs.queries {
+		if q2.State == qCommitted && q2.Query != nil && uuid != q.Uuid && q.CheckConflict(q2.Query) != nil {
+			follows = append(follows, uuid)
+		}
+	}
+	sort.Strings(follows)
+	return follows
+}
+
+// GetConflicting returns the pending queries conflicting with q which have
+// been endorsed locally, those which have not been endorsed yet but precede
+// q (see Query.Precedes), and those whose local endorsement has been
+// withdrawn (see Withdraw).
+func (qs *queryStore) GetConflicting(q *Query) (cq, preceding, withdrawn []*Query) {
 	if q == nil || qs == nil {
 		return
 	}
@@ -248,16 +474,20 @@ func (qs *queryStore) GetConflicting(q *Query) (cq []*Query) {
 			continue // same query
 		}
 
-		if q2.State != qPending {
-			continue // query has already been processed
+		if q2.State != qPending || q2.Query == nil {
+			continue // query has already been processed, or is unknown
 		}
 
-		if !q2.Endorsed {
-			continue // query has not been endorsed locally
+		if q.CheckConflict(q2.Query) == nil {
+			continue
 		}
 
-		if q.CheckConflict(q2.Query) != nil {
+		if q2.Endorsed {
 			cq = append(cq, q2.Query)
+		} else if q2.Withdrawn {
+			withdrawn = append(withdrawn, q2.Query)
+		} else if q2.Precedes(q) {
+			preceding = append(preceding, q2.Query)
 		}
 	}
 
@@ -279,6 +509,7 @@ func (qs *queryStore) CheckState(uuid string) (commit bool, checkpoint []string)
 	}
 
 	n := 0
+	var follows []string
 	for _, e := range qs.queries[uuid].Endorsements {
 		definitelyValid := true
 		for _, c := range e.Conditions {
@@ -286,7 +517,7 @@ func (qs *queryStore) CheckState(uuid string) (commit bool, checkpoint []string)
 			if !ok || qi.State != qDropped {
 				definitelyValid = false
 
-				old := !ok || !qs.isApplicable(c) && qi.ExpiredSince(deltaOld)
+				old := !ok || !qs.isApplicable(c) && qi.ExpiredAt(qs.now().Add(-deltaOld))
 				if old {
 					checkpoint = addToSet(checkpoint, c)
 				}
@@ -297,10 +528,13 @@ func (qs *queryStore) CheckState(uuid string) (commit bool, checkpoint []string)
 
 		if definitelyValid {
 			n++
+			for _, f := range e.Follows {
+				follows = addToSet(follows, f)
+			}
 		}
 	}
 
-	if n >= qs.threshold { // TODO per policy threshold
+	if n >= qs.threshold && !qs.awaits(uuid, follows) { // TODO per policy threshold
 		commit = true
 		qs.commit(uuid)
 	}
@@ -308,6 +542,61 @@ func (qs *queryStore) CheckState(uuid string) (commit bool, checkpoint []string)
 	return commit, checkpoint
 }
 
+// awaits returns true if one of the queries that the endorsers of uuid
+// committed before it is neither committed nor dropped by the node yet, see
+// Endorsement.Follows. Queries still unknown once uuid is old are not waited
+// for anymore, since a faulty endorser could list any identifier.
+func (qs *queryStore) awaits(uuid string, follows []string) bool { // unsafe
+	old := qs.queries[uuid].ExpiredAt(qs.now().Add(-deltaOld))
+	for _, f := range follows {
+		qi, ok := qs.queries[f]
+		if !ok || qi.Query == nil {
+			if !old {
+				return true
+			}
+			continue
+		}
+		if qi.State == qPending {
+			return true
+		}
+	}
+	return false
+}
+
+// CommitEndorsements returns the endorsements of a query whose conditions
+// are all dropped, sorted by emitter. Once the query is committed, these are
+// the endorsements that reached the threshold. Endorsements only known
+// through an aggregate are replaced by their aggregates.
+func (qs *queryStore) CommitEndorsements(uuid string) (endorsements []*Endorsement, aggregates []*AggregatedEndorsement) {
+	qs.RLock()
+	defer qs.RUnlock()
+
+	for _, e := range qs.queries[uuid].Endorsements {
+		definitelyValid := true
+		for _, c := range e.Conditions {
+			if qs.queries[c].State != qDropped {
+				definitelyValid = false
+				break
+			}
+		}
+
+		if !definitelyValid {
+			continue
+		}
+
+		if e.Aggregate == nil {
+			endorsements = append(endorsements, e.Endorsement)
+		} else if !containsAggregate(aggregates, e.Aggregate) {
+			aggregates = append(aggregates, e.Aggregate)
+		}
+	}
+
+	sort.Slice(endorsements, func(i, j int) bool {
+		return endorsements[i].Emitter < endorsements[j].Emitter
+	})
+	return endorsements, aggregates
+}
+
 func (qs *queryStore) PendingQueries() []string {
 	qs.RLock()
 	defer qs.RUnlock()
@@ -322,6 +611,138 @@ func (qs *queryStore) PendingQueries() []string {
 	return out
 }
 
+// OldestUnsettled returns the earliest deadline of the queries which may
+// still be applied: pending queries, and committed queries not applied yet.
+// ok is false if there is none.
+func (qs *queryStore) OldestUnsettled() (deadline time.Time, ok bool) {
+	qs.RLock()
+	defer qs.RUnlock()
+
+	for _, qi := range qs.queries {
+		if qi.settled() {
+			continue
+		}
+
+		if t := qi.DeadlineTime(); !ok || t.Before(deadline) {
+			deadline, ok = t, true
+		}
+	}
+	return
+}
+
+// PendingSummary returns the pending queries that are not expired at now,
+// latest deadlines first (they leave more time to endorse), and at most
+// limit of them.
+func (qs *queryStore) PendingSummary(now time.Time, limit int) []*PendingQuery {
+	qs.RLock()
+	var pending []*Query
+	for _, qi := range qs.queries {
+		if qi.State == qPending && !qi.ExpiredAt(now) {
+			pending = append(pending, qi.Query)
+		}
+	}
+	qs.RUnlock()
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].DeadlineTime().After(pending[j].DeadlineTime())
+	})
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+
+	out := make([]*PendingQuery, len(pending))
+	for i, q := range pending {
+		deadline, _ := ptypes.TimestampProto(q.DeadlineTime())
+		out[i] = &PendingQuery{Uuid: q.Uuid, Deadline: deadline}
+	}
+	return out
+}
+
+// PendingContent returns copies of a pending query and of its signed
+// endorsements, or nil if the query is unknown or not pending anymore.
+func (qs *queryStore) PendingContent(uuid string) (*Query, []*Endorsement) {
+	qs.RLock()
+	defer qs.RUnlock()
+
+	qi, ok := qs.queries[uuid]
+	if !ok || qi.State != qPending {
+		return nil, nil
+	}
+
+	endorsements, _ := qi.proofs()
+	return proto.Clone(qi.Query).(*Query), endorsements
+}
+
+// ProofContent returns a copy of a query, whatever its state, along with its
+// endorsements and the aggregates holding the unsigned ones.
+func (qs *queryStore) ProofContent(uuid string) (*Query, []*Endorsement, []*AggregatedEndorsement) {
+	qs.RLock()
+	defer qs.RUnlock()
+
+	qi, ok := qs.queries[uuid]
+	if !ok {
+		return nil, nil, nil
+	}
+
+	endorsements, aggregates := qi.proofs()
+	return proto.Clone(qi.Query).(*Query), endorsements, aggregates
+}
+
+// Endorsers returns the emitters of the known endorsements of a query, or
+// false if the query is unknown.
+func (qs *queryStore) Endorsers(uuid string) (map[string]bool, bool) {
+	qs.RLock()
+	defer qs.RUnlock()
+
+	qi, ok := qs.queries[uuid]
+	if !ok {
+		return nil, false
+	}
+
+	emitters := make(map[string]bool, len(qi.Endorsements))
+	for _, e := range qi.Endorsements {
+		emitters[e.Emitter] = true
+	}
+	return emitters, true
+}
+
+// EndorsementInfos returns copies of the metadata of the known endorsements
+// of a query, sorted by emitter, or false if the query is unknown.
+func (qs *queryStore) EndorsementInfos(uuid string) ([]EndorserInfo, bool) {
+	qs.RLock()
+	defer qs.RUnlock()
+
+	qi, ok := qs.queries[uuid]
+	if !ok {
+		return nil, false
+	}
+
+	infos := make([]EndorserInfo, len(qi.Endorsements))
+	for i, e := range qi.Endorsements {
+		infos[i] = endorserInfo(e.Endorsement, e.Aggregate)
+	}
+	sortEndorsers(infos)
+	return infos, true
+}
+
+// proofs returns copies of the signed endorsements of the query, and of the
+// aggregates holding the other ones.
+func (qi queryInfo) proofs() (endorsements []*Endorsement, aggregates []*AggregatedEndorsement) {
+	endorsements = make([]*Endorsement, 0, len(qi.Endorsements))
+	for _, e := range qi.Endorsements {
+		if e.Aggregate == nil {
+			endorsements = append(endorsements, proto.Clone(e.Endorsement).(*Endorsement))
+		} else if !containsAggregate(aggregates, e.Aggregate) {
+			aggregates = append(aggregates, e.Aggregate)
+		}
+	}
+
+	for i, a := range aggregates {
+		aggregates[i] = proto.Clone(a).(*AggregatedEndorsement)
+	}
+	return
+}
+
 func (qs *queryStore) OutdatedQueries() []string {
 	qs.Lock()
 	defer qs.Unlock()
@@ -350,10 +771,19 @@ func (qs *queryStore) CheckpointChoice(queries []string) (choice bool, proofs []
 
 			qi, _ := qs.queries[uuid]
 			proofs = []*Proof{{Content: &Proof_Query{qi.Query}}}
+
+			var aggregates []*AggregatedEndorsement
 			for _, ei := range qi.Endorsements {
-				proofs = append(proofs, &Proof{
-					Content: &Proof_Endorsement{ei.Endorsement},
-				})
+				if ei.Aggregate == nil {
+					proofs = append(proofs, &Proof{
+						Content: &Proof_Endorsement{ei.Endorsement},
+					})
+				} else if !containsAggregate(aggregates, ei.Aggregate) {
+					aggregates = append(aggregates, ei.Aggregate)
+					proofs = append(proofs, &Proof{
+						Content: &Proof_Aggregate{ei.Aggregate},
+					})
+				}
 			}
 
 			return false, proofs
@@ -363,6 +793,19 @@ func (qs *queryStore) CheckpointChoice(queries []string) (choice bool, proofs []
 	return true, nil
 }
 
+// DropPending drops a query, unless it is unknown or not pending anymore.
+func (qs *queryStore) DropPending(uuid string) bool {
+	qs.Lock()
+	defer qs.Unlock()
+
+	if qi, ok := qs.queries[uuid]; !ok || qi.State != qPending {
+		return false
+	}
+
+	qs.drop(uuid)
+	return true
+}
+
 func (qs *queryStore) CheckpointDrop(queries []string) {
 	qs.Lock()
 	defer qs.Unlock()
@@ -372,6 +815,185 @@ func (qs *queryStore) CheckpointDrop(queries []string) {
 	}
 }
 
+// Emitters returns the emitters of the pending queries and endorsements.
+func (qs *queryStore) Emitters() map[string]bool {
+	qs.RLock()
+	defer qs.RUnlock()
+
+	emitters := make(map[string]bool)
+	for _, qi := range qs.queries {
+		if qi.State != qPending {
+			continue
+		}
+
+		emitters[qi.Emitter] = true
+		for _, e := range qi.Endorsements {
+			emitters[e.Emitter] = true
+		}
+	}
+
+	for _, e := range qs.pendingEndorsements {
+		emitters[e.Emitter] = true
+	}
+
+	for _, a := range qs.pendingAggregates {
+		for _, e := range a.Endorsements {
+			emitters[e.Emitter] = true
+		}
+	}
+
+	return emitters
+}
+
+// Distrust drops the pending queries emitted by one of the provided
+// identities, and discards their endorsements of the other pending queries.
+// Committed queries are left untouched.
+func (qs *queryStore) Distrust(identities map[string]bool) (dropped []string, discarded int) {
+	qs.Lock()
+	defer qs.Unlock()
+
+	for uuid, qi := range qs.queries {
+		if qi.State != qPending {
+			continue
+		}
+
+		if identities[qi.Emitter] {
+			qs.drop(uuid)
+			dropped = append(dropped, uuid)
+			continue
+		}
+
+		// Aggregates cannot be split: their other endorsements are discarded too
+		endorsements := make([]endorsementInfo, 0, len(qi.Endorsements))
+		for _, e := range qi.Endorsements {
+			if !identities[e.Emitter] && (e.Aggregate == nil || !e.Aggregate.involves(identities)) {
+				endorsements = append(endorsements, e)
+			}
+		}
+
+		if len(endorsements) == len(qi.Endorsements) {
+			continue
+		}
+
+		discarded += len(qi.Endorsements) - len(endorsements)
+		qi.Endorsements = endorsements
+		qi.Set(false) // force marking cascade, the applicability may have changed
+		qs.cascadeMark(qi)
+	}
+
+	pendingEndorsements := qs.pendingEndorsements[:0]
+	for _, pe := range qs.pendingEndorsements {
+		if identities[pe.Emitter] {
+			discarded++
+		} else {
+			pendingEndorsements = append(pendingEndorsements, pe)
+		}
+	}
+	qs.pendingEndorsements = pendingEndorsements
+
+	pendingAggregates := qs.pendingAggregates[:0]
+	for _, pa := range qs.pendingAggregates {
+		if pa.involves(identities) {
+			discarded += len(pa.Endorsements)
+		} else {
+			pendingAggregates = append(pendingAggregates, pa)
+		}
+	}
+	qs.pendingAggregates = pendingAggregates
+	qs.recountMemory()
+
+	return dropped, discarded
+}
+
+// SetApplyResult records whether a committed query has been applied, or the
+// reason why it could not be.
+func (qs *queryStore) SetApplyResult(uuid string, err error) {
+	qs.Lock()
+	defer qs.Unlock()
+
+	qi, ok := qs.queries[uuid]
+	if !ok {
+		return
+	}
+
+	qi.ApplyError = err
+	qi.Written = err == nil
+	qs.queries[uuid] = qi
+	if qi.Written {
+		qs.speculative = removeFromSet(qs.speculative, uuid)
+	}
+	qs.notify(uuid)
+}
+
+// ApplyError returns the error of the last attempt to apply a committed query,
+// nil if it has been applied or is not committed.
+func (qs *queryStore) ApplyError(uuid string) error {
+	qs.RLock()
+	defer qs.RUnlock()
+	return qs.queries[uuid].ApplyError
+}
+
+// Status returns the status of a known query.
+func (qs *queryStore) Status(uuid string) (QueryStatus, bool) {
+	qs.RLock()
+	defer qs.RUnlock()
+
+	qi, ok := qs.queries[uuid]
+	if !ok {
+		return QueryStatus{}, false
+	}
+
+	s := QueryStatus{
+		State:        qi.state(),
+		Endorsements: len(qi.Endorsements),
+		Resolved:     qi.Resolved,
+		Applied:      qi.Written,
+		ApplyError:   qi.ApplyError,
+	}
+	if qi.Query != nil && qi.Deadline != nil {
+		s.Deadline = qi.DeadlineTime()
+	}
+	return s, true
+}
+
+// Forget removes the queries resolved before the provided time, whose
+// deadline is also before the provided one, and returns how many of them
+// have been removed. Queries still needed are kept: committed queries not
+// applied yet, and queries some pending query depends on.
+func (qs *queryStore) Forget(resolved, deadline time.Time) (n int) {
+	qs.Lock()
+	defer qs.Unlock()
+
+	for uuid, qi := range qs.queries {
+		if qi.State == qPending || qi.Resolved.IsZero() || !qi.Resolved.Before(resolved) {
+			continue
+		}
+		if qi.Query != nil && !qi.ExpiredAt(deadline) {
+			continue
+		}
+		if !qi.settled() {
+			continue
+		}
+
+		var needed bool
+		for _, dep := range qi.Dependents {
+			if d, ok := qs.queries[dep]; ok && d.State == qPending {
+				needed = true
+				break
+			}
+		}
+		if needed {
+			continue
+		}
+
+		delete(qs.queries, uuid)
+		qs.speculative = removeFromSet(qs.speculative, uuid)
+		n++
+	}
+
+	return
+}
+
 func (qs *queryStore) Endorse(uuid string) {
 	qs.Lock()
 	defer qs.Unlock()
@@ -385,15 +1007,49 @@ func (qs *queryStore) Endorse(uuid string) {
 	qs.queries[uuid] = qi
 }
 
+// Withdraw withdraws the local endorsement of a pending query which is not
+// applicable, and returns true if it was endorsed. The caller ensures that
+// the query is old, that is expired for deltaOld, as CheckState does before
+// checkpointing a condition.
+// The endorsement itself cannot be recalled, the query is still a condition
+// of the endorsements of the queries conflicting with it until a checkpoint
+// drops it.
+func (qs *queryStore) Withdraw(uuid string) bool {
+	qs.Lock()
+	defer qs.Unlock()
+
+	if qs.isApplicable(uuid) {
+		return false
+	}
+	qi, ok := qs.queries[uuid]
+	if !ok || !qi.Endorsed || qi.State != qPending {
+		return false
+	}
+
+	qi.Endorsed, qi.Withdrawn = false, true
+	qs.queries[uuid] = qi
+	return true
+}
+
 func (qs *queryStore) drop(uuid string) { // unsafe
 	qi, ok := qs.queries[uuid]
 	if !ok {
 		qi = queryInfo{}
 	}
+	if qi.State == qPending {
+		qs.memory -= qi.memorySize()
+	}
 
 	qi.State = qDropped
+	if qi.Resolved.IsZero() {
+		qi.Resolved = qs.now()
+	}
+	qs.decide(uuid, qi, false)
+	qi.Applied = false
+	qs.speculative = removeFromSet(qs.speculative, uuid)
 	qi.Set(false)
 	qs.cascadeMark(qi)
+	qs.notify(uuid)
 
 	zap.L().Debug("Dropped",
 		zap.String("uuid", uuid),
@@ -405,9 +1061,17 @@ func (qs *queryStore) commit(uuid string) { // unsafe
 	if !ok {
 		qi = queryInfo{}
 	}
+	if qi.State == qPending {
+		qs.memory -= qi.memorySize()
+	}
 
 	qi.State = qCommitted
+	if qi.Resolved.IsZero() {
+		qi.Resolved = qs.now()
+	}
+	qs.decide(uuid, qi, true)
 	qs.queries[uuid] = qi
+	qs.notify(uuid)
 
 	// Drop dependents synchronously
 	for _, dep := range qi.Dependents {
@@ -419,7 +1083,29 @@ func (qs *queryStore) commit(uuid string) { // unsafe
 	)
 }
 
-// TODO add (real) speculative execution
+// decide records the decision of a query, to be persisted.
+// unsafe
+func (qs *queryStore) decide(uuid string, qi queryInfo, committed bool) {
+	d := decision{uuid: uuid, resolved: qi.Resolved, committed: committed}
+	if qi.Query != nil {
+		d.deadline = qi.DeadlineTime()
+	}
+	qs.decisions = append(qs.decisions, d)
+}
+
+// Decisions returns and forgets the decisions recorded since the last call.
+func (qs *queryStore) Decisions() []decision {
+	qs.Lock()
+	defer qs.Unlock()
+
+	decisions := qs.decisions
+	qs.decisions = nil
+	return decisions
+}
+
+// checkSpeculativeState adds the query to the speculative queries once it
+// becomes applicable, and rolls it back when it stops being so.
+// unsafe
 func (qs *queryStore) checkSpeculativeState(uuid string, applicable bool) {
 	qi, ok := qs.queries[uuid]
 	if !ok {
@@ -431,18 +1117,59 @@ func (qs *queryStore) checkSpeculativeState(uuid string, applicable bool) {
 			zap.String("uuid", uuid),
 		)
 		qi.Applied = false
+		qs.speculative = removeFromSet(qs.speculative, uuid)
 	}
 
-	if applicable && !qi.Applied {
+	if applicable && !qi.Applied && !qi.Written {
 		zap.L().Debug("Applied",
 			zap.String("uuid", uuid),
 		)
 		qi.Applied = true
+		qs.speculative = addToSet(qs.speculative, uuid)
 	}
 
 	qs.queries[uuid] = qi
 }
 
+// Speculative returns the queries whose effects are not written to the store
+// yet, although they are applicable or committed: committed queries first,
+// since they are written before the others, then in the order they became
+// applicable. Queries which are no longer applicable are rolled back first,
+// since the commit or the drop of their conditions does not check them again.
+func (qs *queryStore) Speculative() []*Query {
+	qs.Lock()
+	defer qs.Unlock()
+
+	var committed, queries []*Query
+	speculative := qs.speculative[:0]
+	for _, uuid := range qs.speculative {
+		qi, ok := qs.queries[uuid]
+		if !ok || qi.Query == nil || qi.Written {
+			continue
+		}
+
+		if !qs.isApplicable(uuid) {
+			zap.L().Debug("Rollbacked",
+				zap.String("uuid", uuid),
+			)
+			qi = qs.queries[uuid]
+			qi.Applied = false
+			qs.queries[uuid] = qi
+			continue
+		}
+
+		speculative = append(speculative, uuid)
+		if qi.State == qCommitted {
+			committed = append(committed, qi.Query)
+		} else {
+			queries = append(queries, qi.Query)
+		}
+	}
+	qs.speculative = speculative
+
+	return append(committed, queries...)
+}
+
 func addToSet(set []string, value string) []string {
 	for _, v := range set {
 		if value == v {
@@ -452,3 +1179,13 @@ func addToSet(set []string, value string) []string {
 
 	return append(set, value)
 }
+
+func removeFromSet(set []string, value string) []string {
+	for i, v := range set {
+		if value == v {
+			return append(set[:i], set[i+1:]...)
+		}
+	}
+
+	return set
+}
@@ -14,8 +14,12 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
 )
 
 func TestQueryStore_AddEndorsement(t *testing.T) {
@@ -191,3 +195,193 @@ func BenchmarkQueryStore_AddEndorsement(b *testing.B) {
 		qs.AddEndorsement(&Endorsement{Emitter: strconv.Itoa(i), Uuid: q.Uuid})
 	}
 }
+
+func TestQueryStore_Watch(t *testing.T) {
+	qs := newQueryStore()
+	q1, q2 := NewQuery(), NewQuery()
+	qs.AddQuery(q1)
+	qs.AddQuery(q2)
+
+	closed := func(c <-chan struct{}) bool {
+		select {
+		case <-c:
+			return true
+		default:
+			return false
+		}
+	}
+
+	c, release := qs.Watch([]string{q1.Uuid, q2.Uuid})
+	require.False(t, closed(c))
+
+	qs.Lock()
+	qs.commit(q2.Uuid)
+	qs.Unlock()
+	require.True(t, closed(c), "should be woken up on commit")
+	require.Len(t, qs.waiters, 1, "only q1 waiter is remaining before release")
+	release()
+	require.Len(t, qs.waiters, 0)
+
+	c, _ = qs.Watch([]string{q1.Uuid, q2.Uuid})
+	require.True(t, closed(c), "should be closed immediately if a query is not pending")
+
+	c, release = qs.Watch([]string{q1.Uuid})
+	qs.Lock()
+	qs.drop(q1.Uuid)
+	qs.Unlock()
+	require.True(t, closed(c), "should be woken up on drop")
+	release()
+	require.Len(t, qs.waiters, 0)
+}
+
+func TestQueryStore_GetConflicting(t *testing.T) {
+	qs := newQueryStore()
+	qs.threshold = 1 // queries without endorsements are not applicable
+	set := func(timeout time.Duration) *Query {
+		q := NewQuery()
+		q.SetTimeout(timeout)
+		q.Operations = []*Operation{{Key: "k", Op: Operation_SET, Data: []byte(q.Uuid)}}
+		qs.AddQuery(q)
+		return q
+	}
+
+	early, endorsed, q, late := set(time.Second), set(time.Hour), set(time.Minute), set(time.Hour)
+	other := NewQuery()
+	other.SetTimeout(time.Second)
+	other.Operations = []*Operation{{Key: "other", Op: Operation_SET}}
+	qs.AddQuery(other)
+	qs.Endorse(endorsed.Uuid)
+
+	require.True(t, early.Precedes(q))
+	require.False(t, q.Precedes(early))
+	tied := proto.Clone(q).(*Query)
+	tied.Uuid = q.Uuid + "0"
+	require.True(t, q.Precedes(tied), "the UUID should break ties")
+	require.False(t, q.Precedes(q))
+
+	conflicting, preceding, withdrawn := qs.GetConflicting(q)
+	require.Equal(t, []*Query{endorsed}, conflicting, "endorsed queries are reported whatever their order")
+	require.Equal(t, []*Query{early}, preceding, "later queries not endorsed yet are ignored")
+	require.NotContains(t, preceding, late)
+	require.Empty(t, withdrawn)
+
+	require.False(t, qs.Withdraw(early.Uuid), "only endorsed queries can be withdrawn")
+	require.True(t, qs.Withdraw(endorsed.Uuid))
+	require.False(t, qs.Withdraw(endorsed.Uuid), "the endorsement is withdrawn once")
+	conflicting, preceding, withdrawn = qs.GetConflicting(q)
+	require.Empty(t, conflicting)
+	require.Equal(t, []*Query{early}, preceding)
+	require.Equal(t, []*Query{endorsed}, withdrawn)
+
+	qs.Lock()
+	qs.drop(early.Uuid)
+	qs.Unlock()
+	_, preceding, _ = qs.GetConflicting(q)
+	require.Empty(t, preceding, "resolved queries are ignored")
+}
+
+func TestQueryStore_Follows(t *testing.T) {
+	qs := newQueryStore()
+	qs.threshold = 2
+	clock := tests.NewManualClock(time.Now())
+	qs.setClock(clock.Now)
+	set := func() *Query {
+		q := NewQuery()
+		q.Deadline, _ = ptypes.TimestampProto(clock.Now().Add(time.Minute))
+		q.Operations = []*Operation{{Key: "k", Op: Operation_SET, Data: []byte(q.Uuid)}}
+		qs.AddQuery(q)
+		return q
+	}
+	endorse := func(q *Query, follows ...string) {
+		for _, emitter := range []string{"a", "b"} {
+			qs.AddEndorsement(&Endorsement{Uuid: q.Uuid, Emitter: emitter, Follows: follows})
+		}
+	}
+
+	// The endorsers of next have committed first, whose endorsements are late
+	first, next := set(), set()
+	require.Empty(t, qs.Followed(next))
+	endorse(next, first.Uuid)
+	commit, _ := qs.CheckState(next.Uuid)
+	require.False(t, commit, "next should wait for the query committed before it")
+
+	endorse(first)
+	commit, _ = qs.CheckState(first.Uuid)
+	require.True(t, commit)
+	require.Equal(t, []string{first.Uuid}, qs.Followed(next))
+	commit, _ = qs.CheckState(next.Uuid)
+	require.True(t, commit)
+
+	// Unknown queries are waited for until the query is old
+	last := set()
+	endorse(last, "unknown")
+	commit, _ = qs.CheckState(last.Uuid)
+	require.False(t, commit)
+	clock.Advance(time.Minute + deltaOld + time.Millisecond)
+	commit, _ = qs.CheckState(last.Uuid)
+	require.True(t, commit)
+}
+
+func TestQueryStore_cascadeMark(t *testing.T) {
+	// fresh sets the applicability of every query and endorsement as computed
+	fresh := func(qs *queryStore) {
+		for uuid, qi := range qs.queries {
+			qi.Set(true)
+			for i := range qi.Endorsements {
+				qi.Endorsements[i].Set(true)
+			}
+			qs.queries[uuid] = qi
+		}
+	}
+	// stale counts the queries marked along with their conditional endorsements
+	stale := func(qs *queryStore) (n int) {
+		for _, qi := range qs.queries {
+			marked := !qi.Fresh()
+			for _, e := range qi.Endorsements {
+				marked = marked && (len(e.Conditions) == 0 || !e.Fresh())
+			}
+			if marked {
+				n++
+			}
+		}
+		return
+	}
+
+	t.Run("chain", func(t *testing.T) {
+		const depth = 50000
+		qs := newQueryStore()
+		queries := make([]*Query, depth)
+		for i := range queries {
+			queries[i] = NewQuery()
+			qs.AddQuery(queries[i])
+		}
+		for i, q := range queries {
+			e := &Endorsement{Uuid: q.Uuid, Emitter: "a"}
+			if i > 0 {
+				e.Conditions = []string{queries[i-1].Uuid}
+			}
+			qs.AddEndorsement(e)
+		}
+
+		fresh(qs)
+		noHang(t, "the cascade should terminate", func() {
+			qs.AddEndorsement(&Endorsement{Uuid: queries[0].Uuid, Emitter: "b"})
+		})
+		require.Equal(t, depth, stale(qs), "every query of the chain should be marked")
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		qs := newQueryStore()
+		a, b := NewQuery(), NewQuery()
+		qs.AddQuery(a)
+		qs.AddQuery(b)
+		qs.AddEndorsement(&Endorsement{Uuid: a.Uuid, Emitter: "a", Conditions: []string{b.Uuid}})
+		qs.AddEndorsement(&Endorsement{Uuid: b.Uuid, Emitter: "a", Conditions: []string{a.Uuid}})
+
+		fresh(qs)
+		noHang(t, "the cascade should terminate", func() {
+			qs.AddEndorsement(&Endorsement{Uuid: a.Uuid, Emitter: "b"})
+		})
+		require.Equal(t, 2, stale(qs))
+	})
+}
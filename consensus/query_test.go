@@ -12,23 +12,130 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/ptypes"
 	"github.com/stretchr/testify/require"
+
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+	"github.com/technicolor-research/pnyxdb/consensus/operations"
+	"github.com/technicolor-research/pnyxdb/tests"
 )
 
 func TestQueryTimeout(t *testing.T) {
-	d := 50 * time.Millisecond
+	d := time.Minute
 	q := NewQuery()
 	q.SetTimeout(d)
 	require.False(t, q.Expired())
 	require.False(t, q.ExpiredSince(d))
 
-	time.Sleep(d)
+	deadline := q.DeadlineTime()
+	require.False(t, q.ExpiredAt(deadline.Add(-time.Nanosecond)))
+	require.True(t, q.ExpiredAt(deadline))
+	require.True(t, q.ExpiredAt(deadline.Add(d)))
 
+	q.SetTimeout(-d / 2)
 	require.True(t, q.Expired())
 	require.False(t, q.ExpiredSince(d))
 
-	time.Sleep(d)
-
+	q.SetTimeout(-2 * d)
 	require.True(t, q.Expired())
 	require.True(t, q.ExpiredSince(d))
+
+	require.True(t, (*Query)(nil).ExpiredAt(time.Time{}))
+}
+
+func TestQueryHash_Requirements(t *testing.T) {
+	q := NewQuery()
+	q.Requirements = make(map[string]*Version)
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		q.Requirements[key] = NewVersion([]byte(key))
+	}
+
+	hash, err := q.Hash()
+	require.Nil(t, err)
+	for i := 0; i < 20; i++ {
+		h, err := q.Hash()
+		require.Nil(t, err)
+		require.Exactly(t, hash, h, "hash should not depend on map iteration order")
+	}
+}
+
+func TestQuery_Validate(t *testing.T) {
+	op := func(key string, o Operation_Op, data string) *Operation {
+		return &Operation{Key: key, Op: o, Data: []byte(data)}
+	}
+	cas := func(key, expected, data string) *Operation {
+		return &Operation{Key: key, Op: Operation_CAS, Data: encoding.EncodeArgs([]byte(expected), []byte(data))}
+	}
+
+	valid := [][]*Operation{
+		nil,
+		{op("a", Operation_SET, "1"), op("a", Operation_ADD, "2")},
+		{op("a", Operation_SADD, "x"), op("a", Operation_SADD, "y"), op("a", Operation_SREM, "x")},
+		{op("a", Operation_SADD, "x"), op("a", Operation_SREM, "x"), op("a", Operation_ADD, "1")}, // possibly emptied
+		{op("a", Operation_INCR, "1"), op("a", Operation_ADD, "0.5"), op("a", Operation_INCR, "1")},
+		{op("a", Operation_SADD, "x"), op("a", Operation_SET, "1"), op("a", Operation_ADD, "1")},
+		{op("a", Operation_CONCAT, "x"), op("a", Operation_SADD, "x")}, // unknown content
+		{op("a", Operation_DELETE, ""), op("a", Operation_SADD, "x")},
+		{cas("a", "free", "1"), op("a", Operation_INCR, "1")},
+		{op("a", Operation_SADD, "x"), op("b", Operation_ADD, "1")},
+		{op("a", Operation_RPUSH, "x"), op("a", Operation_LPUSH, "y"), op("a", Operation_LPOP, "2")},
+		{op("a", Operation_LPOP, ""), op("a", Operation_SADD, "x")}, // possibly emptied
+		{{Key: "a", Op: Operation_HSET, Data: encoding.EncodeArgs([]byte("f"), []byte("v"))}, op("a", Operation_HDEL, "f"), op("a", Operation_RPUSH, "x")},
+		{{Key: "a", Op: Operation_CAS, Data: encoding.EncodeArgs(nil, []byte("1")), Ttl: ptypes.DurationProto(time.Second)}, op("a", Operation_EXPIRE, "1m")},
+	}
+	for _, ops := range valid {
+		q := NewQuery()
+		q.Operations = ops
+		require.Nil(t, q.Validate(), "%v", ops)
+	}
+
+	invalid := []struct {
+		ops   []*Operation
+		index int
+		err   error
+	}{
+		{[]*Operation{op("a", Operation_SET, "hello"), op("a", Operation_SADD, "x")}, 1, operations.ErrNotValidSet},
+		{[]*Operation{op("a", Operation_SADD, "x"), op("a", Operation_ADD, "1")}, 1, operations.ErrNotNumeric},
+		{[]*Operation{op("a", Operation_MUL, "2"), op("a", Operation_SREM, "x")}, 1, operations.ErrNotValidSet},
+		{[]*Operation{op("a", Operation_RPUSH, "x"), op("a", Operation_SADD, "x")}, 1, operations.ErrNotValidSet},
+		{[]*Operation{op("a", Operation_SADD, "x"), op("a", Operation_LPUSH, "x")}, 1, operations.ErrNotValidList},
+		{[]*Operation{op("a", Operation_INCR, "1"), op("a", Operation_LPOP, "")}, 1, operations.ErrNotValidList},
+		{[]*Operation{op("a", Operation_LPUSH, "x"), op("a", Operation_MAX, "1")}, 1, operations.ErrNotNumeric},
+		{[]*Operation{op("a", Operation_SADD, "x"), op("a", Operation_HDEL, "f")}, 1, operations.ErrNotValidMap},
+		{[]*Operation{{Key: "a", Op: Operation_HSET, Data: encoding.EncodeArgs([]byte("f"), []byte("v"))}, op("a", Operation_INCR, "1")}, 1, operations.ErrNotNumeric},
+		{[]*Operation{op("a", Operation_SET, "1.5"), op("b", Operation_SET, "1"), op("b", Operation_INCR, "1"), op("a", Operation_INCR, "1")}, 3, operations.ErrNotInteger},
+		{[]*Operation{cas("a", "free", "alice"), cas("a", "free", "bob")}, 1, operations.ErrUnexpected},
+		{[]*Operation{op("a", Operation_CAS, "bad")}, 0, encoding.ErrInvalidArgs},
+		{[]*Operation{op("a", Operation_SET, string(encoding.Deletion()))}, 0, operations.ErrReservedContent},
+		{[]*Operation{op("a", Operation_Op(99), "")}, 0, errNotImplemented},
+		{[]*Operation{op("a", Operation_SET, "1"), op(AppliedPrefix+"forged", Operation_SET, "1")}, 1, ErrReservedKey},
+		{[]*Operation{op("a", Operation_SADD, "x"), {Key: "a", Op: Operation_SADD, Data: []byte("y"), Ttl: ptypes.DurationProto(time.Second)}}, 1, ErrTTLNotSupported},
+		{[]*Operation{{Key: "a", Op: Operation_SET, Data: []byte("1"), Ttl: ptypes.DurationProto(0)}}, 0, operations.ErrInvalidTTL},
+		{[]*Operation{op("a", Operation_INCR, "1"), op("a", Operation_EXPIRE, "soon")}, 1, operations.ErrInvalidTTL},
+	}
+	for _, c := range invalid {
+		q := NewQuery()
+		q.Operations = c.ops
+		err := q.Validate()
+		require.IsType(t, &OperationError{}, err, "%v", c.ops)
+		opErr := err.(*OperationError)
+		require.Equal(t, c.index, opErr.Index, "%v", c.ops)
+		require.Equal(t, c.ops[c.index].Key, opErr.Key)
+		require.Equal(t, c.ops[c.index].Op, opErr.Op)
+		require.Equal(t, c.err, opErr.Err, "%v", c.ops)
+	}
+}
+
+func TestEngine_SubmitInvalid(t *testing.T) {
+	network := &recordingNetwork{}
+	eng := NewEngine(newMemoryStore(), network, nil, tests.GetTestKeyRings(t, 1)[0], 1)
+
+	q := NewQuery()
+	q.Operations = []*Operation{
+		{Key: "a", Op: Operation_SADD, Data: []byte("x")},
+		{Key: "a", Op: Operation_ADD, Data: []byte("1")},
+	}
+	require.IsType(t, &OperationError{}, eng.Submit(q))
+	require.IsType(t, &OperationError{}, eng.SubmitBatch([]*Query{q})[0])
+	require.Empty(t, network.messages, "invalid queries should not be broadcast")
 }
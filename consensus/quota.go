@@ -0,0 +1,179 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// QuotaUsage describes the storage footprint of a key prefix.
+type QuotaUsage struct {
+	Prefix string
+	Used   int64 // in bytes, sum of the sizes of values stored under Prefix
+	Limit  int64 // in bytes
+}
+
+// quotaTracker maintains the usage counters of the prefixes having a quota.
+// Each key is accounted to the longest configured prefix it matches.
+// The zero value is ready to use, and enforces no quota.
+type quotaTracker struct {
+	sync.Mutex
+	limits map[string]int64
+	usage  map[string]int64
+}
+
+// SetQuota bounds the total size of the values stored under prefix.
+// A zero or negative limit removes the quota.
+// This function is thread-safe.
+func (eng *Engine) SetQuota(prefix string, limit int64) {
+	t := &eng.quotas
+	t.Lock()
+	defer t.Unlock()
+
+	if limit <= 0 {
+		delete(t.limits, prefix)
+		return
+	}
+
+	if t.limits == nil {
+		t.limits = make(map[string]int64)
+	}
+	t.limits[prefix] = limit
+}
+
+// QuotaStatus returns the current usage of every prefix having a quota,
+// sorted by prefix.
+// This function is thread-safe.
+func (eng *Engine) QuotaStatus() []QuotaUsage {
+	t := &eng.quotas
+	t.Lock()
+	defer t.Unlock()
+
+	status := make([]QuotaUsage, 0, len(t.limits))
+	for prefix, limit := range t.limits {
+		status = append(status, QuotaUsage{
+			Prefix: prefix,
+			Used:   t.usage[prefix],
+			Limit:  limit,
+		})
+	}
+
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Prefix < status[j].Prefix
+	})
+	return status
+}
+
+// active returns true if at least one quota is configured.
+// This function is thread-safe.
+func (t *quotaTracker) active() bool {
+	t.Lock()
+	defer t.Unlock()
+	return len(t.limits) > 0
+}
+
+// owner returns the longest configured prefix matching key.
+// unsafe
+func (t *quotaTracker) owner(key string) (prefix string, ok bool) {
+	for p := range t.limits {
+		if strings.HasPrefix(key, p) && (!ok || len(p) > len(prefix)) {
+			prefix, ok = p, true
+		}
+	}
+	return
+}
+
+// deltas returns the usage variation of each prefix, given the previous and
+// next sizes of the values of some keys.
+// unsafe
+func (t *quotaTracker) deltas(before, after map[string]int) map[string]int64 {
+	d := make(map[string]int64)
+	for key, size := range after {
+		prefix, ok := t.owner(key)
+		if ok {
+			d[prefix] += int64(size - before[key])
+		}
+	}
+	return d
+}
+
+// allows returns true if the size variations keep every prefix under its quota.
+// Variations that reduce the usage of a prefix are always allowed, even if
+// the prefix is still over its quota afterwards.
+// This function is thread-safe.
+func (t *quotaTracker) allows(before, after map[string]int) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	for prefix, delta := range t.deltas(before, after) {
+		if delta > 0 && t.usage[prefix]+delta > t.limits[prefix] {
+			return false
+		}
+	}
+	return true
+}
+
+// update accounts the size variations of committed values.
+// This function is thread-safe.
+func (t *quotaTracker) update(before, after map[string]int) {
+	t.Lock()
+	defer t.Unlock()
+
+	for prefix, delta := range t.deltas(before, after) {
+		if t.usage == nil {
+			t.usage = make(map[string]int64)
+		}
+		t.usage[prefix] += delta
+	}
+}
+
+// rebuildQuotas computes from the store the usage of every prefix having a
+// quota. Usage counters are not persisted, since they could not be kept
+// consistent with the store after a crash, and the nodes must agree on them
+// to endorse the same queries.
+func (eng *Engine) rebuildQuotas() error {
+	eng.Store.Lock()
+	defer eng.Store.Unlock()
+
+	t := &eng.quotas
+	t.Lock()
+	defer t.Unlock()
+
+	usage := make(map[string]int64, len(t.limits))
+	for prefix := range t.limits {
+		usage[prefix] = 0
+	}
+	if len(usage) == 0 {
+		t.usage = usage
+		return nil
+	}
+
+	list, err := eng.Store.List()
+	if err != nil {
+		return err
+	}
+
+	for key := range list {
+		prefix, ok := t.owner(key)
+		if !ok || IsLocalKey(key) {
+			continue
+		}
+
+		data, _, err := eng.get(key)
+		if err != nil {
+			return err
+		}
+		usage[prefix] += int64(len(data))
+	}
+
+	t.usage = usage
+	return nil
+}
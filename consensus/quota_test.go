@@ -0,0 +1,72 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func quotaQuery(qs *queryStore, key string, data string) *Query {
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Operations = []*Operation{{Key: key, Op: Operation_SET, Data: []byte(data)}}
+	qs.AddQuery(q)
+	return q
+}
+
+func TestEngine_Quotas(t *testing.T) {
+	qs := newQueryStore()
+	e := &Engine{Store: newMemoryStore(), qs: qs}
+	e.SetQuota("alice/", 10)
+	e.SetQuota("alice/large/", 100)
+	require.Nil(t, e.rebuildQuotas())
+
+	fill := quotaQuery(qs, "alice/a", "12345678")
+	require.True(t, e.canEndorse(fill))
+	require.Nil(t, e.apply(fill.Uuid))
+	require.Equal(t, []QuotaUsage{
+		{Prefix: "alice/", Used: 8, Limit: 10},
+		{Prefix: "alice/large/", Used: 0, Limit: 100},
+	}, e.QuotaStatus())
+
+	over := quotaQuery(qs, "alice/b", "1234")
+	require.False(t, e.canEndorse(over), "writes over quota must not be endorsed")
+	require.False(t, e.canEndorse(over), "writes over quota must never be endorsed")
+
+	require.True(t, e.canEndorse(quotaQuery(qs, "alice/large/b", "1234")), "longest prefix owns the key")
+	require.True(t, e.canEndorse(quotaQuery(qs, "bob/b", "1234")), "keys without quota are not bounded")
+
+	shrink := NewQuery()
+	shrink.SetTimeout(time.Minute)
+	shrink.Operations = []*Operation{{Key: "alice/a", Op: Operation_DELETE}}
+	qs.AddQuery(shrink)
+	require.True(t, e.canEndorse(shrink), "deletes are always allowed")
+	require.Nil(t, e.apply(shrink.Uuid))
+	require.Equal(t, int64(0), e.QuotaStatus()[0].Used, "deletes must reclaim quota")
+
+	require.True(t, e.canEndorse(over), "writes should resume once quota is reclaimed")
+	require.Nil(t, e.apply(over.Uuid))
+	require.Equal(t, int64(4), e.QuotaStatus()[0].Used)
+
+	// Usage is rebuilt from the store, even if a dump is older than the store
+	buffer := &bytes.Buffer{}
+	require.Nil(t, e.Dump(buffer))
+	more := quotaQuery(qs, "alice/c", "12")
+	require.Nil(t, e.apply(more.Uuid))
+
+	e2 := &Engine{Store: e.Store, qs: newQueryStore()}
+	e2.SetQuota("alice/", 10)
+	require.Nil(t, e2.Load(buffer))
+	require.Nil(t, e2.rebuildQuotas())
+	require.Equal(t, int64(6), e2.QuotaStatus()[0].Used)
+}
@@ -10,6 +10,9 @@ package consensus
 
 import (
 	"context"
+	"math/rand"
+	"sort"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -18,13 +21,29 @@ import (
 // Recover allows to ask the engine to recover one key from other peers.
 // This might be useful after being disconnected from the network.
 //
-// This is an asynchronous process.
-func (eng *Engine) Recover(key string) {
-	eng.pendingRecovery <- key
+// This is an asynchronous process, and this call never blocks: it fails with
+// ErrEngineStopped once the engine is stopped, or with ErrQueueFull when too
+// many recoveries are already pending.
+func (eng *Engine) Recover(key string) error {
+	if eng.stopped() {
+		return ErrEngineStopped
+	}
+
+	select {
+	case eng.pendingRecovery <- key:
+		return nil
+	default:
+		return ErrQueueFull
+	}
 }
 
+// recoveryHandler answers with the value of a key. Missing keys, such as
+// deleted ones, are answered with NoVersion and without data.
 func (eng *Engine) recoveryHandler(req *RecoveryRequest) (*RecoveryResponse, error) {
-	value, version, err := eng.Store.Get(req.GetKey())
+	value, version, err := eng.get(req.GetKey())
+	if version == NoVersion {
+		return &RecoveryResponse{Key: req.GetKey(), Version: NoVersion}, nil
+	}
 	return &RecoveryResponse{
 		Key:     req.GetKey(),
 		Version: version,
@@ -32,48 +51,398 @@ func (eng *Engine) recoveryHandler(req *RecoveryRequest) (*RecoveryResponse, err
 	}, err
 }
 
+// recoveryWorker attempts the recovery of keys one at a time, following the
+// RecoveryPolicy of the engine.
 func (eng *Engine) recoveryWorker(ctx context.Context) {
-	retry := func(key string) {
-		select {
-		case eng.pendingRecovery <- key:
-		default:
-			zap.L().Warn("RecoveryAbort", zap.String("key", key), zap.String("reason", "queueFull"))
-		}
-	}
+	t := &eng.recoveries
+	policy := eng.RecoveryPolicy.withDefaults()
+	clock := eng.clock()
 
 	for {
-		time.Sleep(time.Second)
-		select {
-		case key := <-eng.pendingRecovery:
-			rec, ok := eng.Network.(RecoveryManager)
-			if !ok {
-				zap.L().Warn("Recovery", zap.Bool("unsupported", true))
-				break
-			}
+		eng.step(loopRecovery)
 
-			subctx, cancel := context.WithTimeout(ctx, 30*time.Second)
-			res, err := rec.RequestRecovery(subctx, key)
-			if err != nil {
-				zap.L().Warn("RecoveryRetry", zap.String("key", key), zap.Error(err))
-				cancel()
-				retry(key)
-				break
+		// Keys submitted in the meantime are scheduled first
+		for empty := false; !empty; {
+			select {
+			case key := <-eng.pendingRecovery:
+				t.schedule(key, clock.Now())
+			default:
+				empty = true
 			}
+		}
 
-			eng.Store.Lock()
-			err = eng.Store.Set(key, res.GetData(), res.GetVersion())
-			eng.Store.Unlock()
+		key, wait := t.next(clock.Now())
+		if key != "" {
+			eng.recoverKey(ctx, key, policy)
+			continue
+		}
 
-			if err != nil {
-				zap.L().Warn("RecoveryRetry", zap.String("key", key), zap.Error(err))
-				retry(key)
-			} else {
-				zap.L().Info("RecoverySuccess", zap.String("key", key))
-			}
-			cancel()
+		var wake <-chan time.Time
+		var stop func() bool
+		if wait >= 0 {
+			wake, stop = clock.NewTimer(wait)
+		}
 
+		select {
+		case key := <-eng.pendingRecovery:
+			t.schedule(key, clock.Now())
+		case <-wake:
 		case <-ctx.Done():
+		}
+		if stop != nil {
+			stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// recoverKey attempts the recovery of a single key.
+func (eng *Engine) recoverKey(ctx context.Context, key string, policy RecoveryPolicy) {
+	t := &eng.recoveries
+	rec, ok := eng.Network.(RecoveryManager)
+	if !ok {
+		zap.L().Warn("Recovery", zap.Bool("unsupported", true))
+		t.forget(key)
+		return
+	}
+
+	eng.count(MetricRecoveryAttempts, 1)
+	subctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	res, err := rec.RequestRecovery(subctx, key)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		t.failure(key, err, true, eng.clock().Now(), policy)
+		return
+	}
+	t.reachable()
+
+	deleted := res.GetVersion().Matches(NoVersion) == nil
+	if !deleted {
+		// A peer may not verify its reads
+		if err = VerifyValue(key, res.GetData(), res.GetVersion()); err != nil {
+			t.failure(key, err, false, eng.clock().Now(), policy)
 			return
 		}
 	}
+
+	eng.Store.Lock()
+	if deleted {
+		err = eng.Store.Delete(key)
+	} else {
+		err = eng.Store.Set(key, res.GetData(), res.GetVersion())
+	}
+	eng.overlay.reset()
+	eng.Store.Unlock()
+	if err != nil {
+		t.failure(key, err, false, eng.clock().Now(), policy)
+		return
+	}
+
+	if deleted {
+		eng.retention.forget([]string{key})
+	} else {
+		eng.retention.touch([]string{key})
+		eng.retention.track(map[string]*Version{key: res.GetVersion()}, eng.now())
+	}
+	t.success(key)
+	zap.L().Info("RecoverySuccess", zap.String("key", key))
+
+	version := res.GetVersion()
+	if deleted {
+		version = NoVersion
+	}
+	eng.emit(EngineEvent{Type: EventRecoveryDone, Keys: []string{key}, Versions: []*Version{version}})
+}
+
+// RecoveryPolicy bounds the retries of the keys asked through Recover.
+// Zero fields take their value from DefaultRecoveryPolicy.
+type RecoveryPolicy struct {
+	MaxAttempts      int           // attempts per key before giving up, see DeadLetter
+	Backoff          time.Duration // delay before the first retry, doubled after each failure
+	MaxBackoff       time.Duration // upper bound of the delay between two retries
+	BreakerThreshold int           // consecutive network failures pausing every recovery
+	BreakerCooldown  time.Duration // pause before probing the network again
+}
+
+// DefaultRecoveryPolicy is the policy used for unset fields of Engine.RecoveryPolicy.
+var DefaultRecoveryPolicy = RecoveryPolicy{
+	MaxAttempts:      10,
+	Backoff:          time.Second,
+	MaxBackoff:       5 * time.Minute,
+	BreakerThreshold: 5,
+	BreakerCooldown:  30 * time.Second,
+}
+
+func (p RecoveryPolicy) withDefaults() RecoveryPolicy {
+	d := DefaultRecoveryPolicy
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = d.MaxAttempts
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = d.Backoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = d.MaxBackoff
+	}
+	if p.BreakerThreshold <= 0 {
+		p.BreakerThreshold = d.BreakerThreshold
+	}
+	if p.BreakerCooldown <= 0 {
+		p.BreakerCooldown = d.BreakerCooldown
+	}
+	return p
+}
+
+// backoff returns the delay before the next attempt of a key which failed
+// the given number of times: the delay doubles after each failure, and a
+// random jitter spreads the retries of keys failing together.
+func (p RecoveryPolicy) backoff(failures int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < failures && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// BreakerState is the state of the circuit breaker of recoveries.
+type BreakerState string
+
+// Circuit breaker states
+const (
+	BreakerClosed   BreakerState = "closed"    // recoveries are attempted
+	BreakerOpen     BreakerState = "open"      // recoveries are paused after network failures
+	BreakerHalfOpen BreakerState = "half-open" // the next attempt probes the network
+)
+
+// DeadLetter describes a key whose recovery exhausted its budget.
+type DeadLetter struct {
+	Key      string
+	Attempts int
+	Err      string // last error
+	Time     time.Time
+}
+
+// RecoveryReport describes the activity of the recovery worker.
+type RecoveryReport struct {
+	Breaker         BreakerState
+	Pending         int    // keys waiting for an attempt
+	Retries         uint64 // failed attempts scheduled again
+	Successes       uint64
+	Failures        uint64 // failed attempts, including network failures
+	BreakerOpenings uint64
+	DeadLetters     []DeadLetter // sorted by key
+}
+
+// recoveryAttempt is the retry state of a key.
+type recoveryAttempt struct {
+	failures int
+	next     time.Time
+}
+
+// recoveryTracker schedules the recovery of keys. It is only modified by
+// the recovery worker, except for dead letters sent back to the queue.
+// The zero value is ready to use.
+type recoveryTracker struct {
+	sync.Mutex
+	scheduled map[string]*recoveryAttempt
+	dead      map[string]DeadLetter
+	state     BreakerState // closed if empty
+	failures  int          // consecutive network failures
+	openUntil time.Time
+	report    RecoveryReport // counters only
+}
+
+// schedule adds a key to recover as soon as possible. A key already waiting
+// for a retry keeps its state, while a dead letter gets a new budget.
+func (t *recoveryTracker) schedule(key string, now time.Time) {
+	t.Lock()
+	defer t.Unlock()
+
+	if _, ok := t.scheduled[key]; ok {
+		return
+	}
+	if t.scheduled == nil {
+		t.scheduled = make(map[string]*recoveryAttempt)
+	}
+	delete(t.dead, key)
+	t.scheduled[key] = &recoveryAttempt{next: now}
+}
+
+// next returns the next key to attempt, or the delay until a key is due,
+// negative if there is nothing to wait for.
+func (t *recoveryTracker) next(now time.Time) (key string, wait time.Duration) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.state == BreakerOpen {
+		if now.Before(t.openUntil) {
+			return "", t.openUntil.Sub(now)
+		}
+		t.state = BreakerHalfOpen
+		zap.L().Info("RecoveryBreaker", zap.String("state", string(t.state)))
+	}
+
+	var earliest time.Time
+	for k, a := range t.scheduled {
+		if key == "" || a.next.Before(earliest) {
+			key, earliest = k, a.next
+		}
+	}
+
+	switch {
+	case key == "":
+		return "", -1
+	case earliest.After(now):
+		return "", earliest.Sub(now)
+	default:
+		return key, 0
+	}
+}
+
+// reachable records that the network answered a recovery request.
+func (t *recoveryTracker) reachable() {
+	t.Lock()
+	defer t.Unlock()
+
+	t.failures = 0
+	if t.state != "" && t.state != BreakerClosed {
+		t.state = BreakerClosed
+		zap.L().Info("RecoveryBreaker", zap.String("state", string(t.state)))
+	}
+}
+
+func (t *recoveryTracker) success(key string) {
+	t.Lock()
+	defer t.Unlock()
+
+	delete(t.scheduled, key)
+	t.report.Successes++
+}
+
+// failure records a failed attempt, which opens the breaker after too many
+// consecutive network failures, and schedules a retry unless the key has
+// exhausted its budget.
+func (t *recoveryTracker) failure(key string, err error, network bool, now time.Time, p RecoveryPolicy) {
+	t.Lock()
+	defer t.Unlock()
+
+	a, ok := t.scheduled[key]
+	if !ok {
+		return
+	}
+	a.failures++
+	t.report.Failures++
+
+	if network {
+		t.failures++
+		if t.state == BreakerHalfOpen || (t.state != BreakerOpen && t.failures >= p.BreakerThreshold) {
+			t.state = BreakerOpen
+			t.openUntil = now.Add(p.BreakerCooldown)
+			t.report.BreakerOpenings++
+			zap.L().Warn("RecoveryBreaker",
+				zap.String("state", string(t.state)),
+				zap.Int("failures", t.failures),
+				zap.Duration("cooldown", p.BreakerCooldown),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if a.failures >= p.MaxAttempts {
+		delete(t.scheduled, key)
+		if t.dead == nil {
+			t.dead = make(map[string]DeadLetter)
+		}
+		t.dead[key] = DeadLetter{
+			Key:      key,
+			Attempts: a.failures,
+			Err:      err.Error(),
+			Time:     now,
+		}
+		zap.L().Warn("RecoveryDeadLetter", zap.String("key", key), zap.Int("attempts", a.failures), zap.Error(err))
+		return
+	}
+
+	a.next = now.Add(p.backoff(a.failures))
+	t.report.Retries++
+	zap.L().Debug("RecoveryRetry", zap.String("key", key), zap.Time("next", a.next), zap.Error(err))
+}
+
+// forget removes a key without counting an attempt.
+func (t *recoveryTracker) forget(key string) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.scheduled, key)
+}
+
+// RecoveryStatus returns the state of the recovery worker, along with the
+// keys whose recovery failed too many times.
+// This function is thread-safe.
+func (eng *Engine) RecoveryStatus() RecoveryReport {
+	t := &eng.recoveries
+	t.Lock()
+	defer t.Unlock()
+
+	r := t.report
+	r.Breaker = t.state
+	if r.Breaker == "" {
+		r.Breaker = BreakerClosed
+	}
+	r.Pending = len(t.scheduled) + len(eng.pendingRecovery)
+
+	r.DeadLetters = make([]DeadLetter, 0, len(t.dead))
+	for _, d := range t.dead {
+		r.DeadLetters = append(r.DeadLetters, d)
+	}
+	sort.Slice(r.DeadLetters, func(i, j int) bool {
+		return r.DeadLetters[i].Key < r.DeadLetters[j].Key
+	})
+	return r
+}
+
+// RetryDeadLetters submits again the provided dead letters, or all of them if
+// no key is provided, with a new budget. It returns the number of keys sent
+// back to the recovery queue, and fails like Recover; keys not sent are kept
+// in the dead letters.
+// This function is thread-safe.
+func (eng *Engine) RetryDeadLetters(keys ...string) (int, error) {
+	t := &eng.recoveries
+	t.Lock()
+	var letters []DeadLetter
+	if len(keys) == 0 {
+		for _, d := range t.dead {
+			letters = append(letters, d)
+		}
+	} else {
+		for _, key := range keys {
+			if d, ok := t.dead[key]; ok {
+				letters = append(letters, d)
+			}
+		}
+	}
+	for _, d := range letters {
+		delete(t.dead, d.Key)
+	}
+	t.Unlock()
+
+	for i, d := range letters {
+		err := eng.Recover(d.Key)
+		if err != nil {
+			t.Lock()
+			for _, d := range letters[i:] {
+				t.dead[d.Key] = d
+			}
+			t.Unlock()
+			return i, err
+		}
+	}
+	return len(letters), nil
 }
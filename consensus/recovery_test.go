@@ -0,0 +1,204 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+var errUnreachable = errors.New("unreachable")
+
+// recoveryNetwork is a RecoveryManager which can be made unreachable, and
+// records the recovery attempts.
+type recoveryNetwork struct {
+	recordingNetwork
+	mutex    sync.Mutex
+	down     bool
+	attempts map[string][]time.Time
+}
+
+func (n *recoveryNetwork) RequestRecovery(ctx context.Context, key string) (*RecoveryResponse, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.attempts == nil {
+		n.attempts = make(map[string][]time.Time)
+	}
+	n.attempts[key] = append(n.attempts[key], time.Now())
+
+	if n.down {
+		return nil, errUnreachable
+	}
+	return &RecoveryResponse{Key: key, Data: []byte("recovered"), Version: NewVersion([]byte("recovered"))}, nil
+}
+
+func (n *recoveryNetwork) AcceptRecovery(ctx context.Context, handler RecoveryHandler) {}
+
+func (n *recoveryNetwork) setDown(down bool) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.down = down
+}
+
+func (n *recoveryNetwork) count() (total int) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for _, a := range n.attempts {
+		total += len(a)
+	}
+	return
+}
+
+func (n *recoveryNetwork) intervals(key string) (d []time.Duration) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	a := n.attempts[key]
+	for i := 1; i < len(a); i++ {
+		d = append(d, a[i].Sub(a[i-1]))
+	}
+	return
+}
+
+func startRecoveryEngine(t *testing.T, policy RecoveryPolicy) (*Engine, *recoveryNetwork, context.CancelFunc) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	network := &recoveryNetwork{down: true}
+	eng := NewEngine(newMemoryStore(), network, nil, kr, 1)
+	eng.RecoveryPolicy = policy
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.Nil(t, eng.Run(ctx))
+	return eng, network, cancel
+}
+
+// waitRecovery fails the test if cond does not hold in a reasonable amount of time.
+func waitRecovery(t *testing.T, eng *Engine, msg string, cond func(r RecoveryReport) bool) RecoveryReport {
+	for i := 0; ; i++ {
+		r := eng.RecoveryStatus()
+		if cond(r) {
+			return r
+		}
+		require.True(t, i < 500, msg)
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func recovered(t *testing.T, eng *Engine, key string) bool {
+	eng.Store.Lock()
+	defer eng.Store.Unlock()
+	value, _, err := eng.Store.Get(key)
+	return err == nil && string(value) == "recovered"
+}
+
+func TestRecoveryPolicy_Backoff(t *testing.T) {
+	p := RecoveryPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second}.withDefaults()
+	require.Equal(t, DefaultRecoveryPolicy.MaxAttempts, p.MaxAttempts)
+
+	for i := 0; i < 100; i++ {
+		for failures, max := range []time.Duration{0, 1, 2, 4, 8, 10, 10, 10} {
+			if failures == 0 {
+				continue
+			}
+			d := p.backoff(failures)
+			max *= time.Second
+			require.True(t, d >= max/2 && d <= max, "%d failures: %v not in [%v, %v]", failures, d, max/2, max)
+		}
+	}
+}
+
+func TestEngine_RecoveryBackoff(t *testing.T) {
+	const backoff = 10 * time.Millisecond
+	eng, network, cancel := startRecoveryEngine(t, RecoveryPolicy{
+		MaxAttempts:      5,
+		Backoff:          backoff,
+		MaxBackoff:       time.Second,
+		BreakerThreshold: 100,
+	})
+	defer cancel()
+
+	require.Nil(t, eng.Recover("a"))
+	r := waitRecovery(t, eng, "key should exhaust its budget", func(r RecoveryReport) bool {
+		return len(r.DeadLetters) > 0
+	})
+	require.Equal(t, "a", r.DeadLetters[0].Key)
+	require.Equal(t, 5, r.DeadLetters[0].Attempts)
+	require.Equal(t, errUnreachable.Error(), r.DeadLetters[0].Err)
+	require.Equal(t, uint64(5), r.Failures)
+	require.Equal(t, uint64(4), r.Retries)
+	require.Equal(t, 0, r.Pending)
+	require.Equal(t, BreakerClosed, r.Breaker)
+
+	intervals := network.intervals("a")
+	require.Len(t, intervals, 4)
+	for i, d := range intervals {
+		min := (backoff << uint(i)) / 2
+		require.True(t, d >= min, "retry %d after %v, expected at least %v", i+1, d, min)
+	}
+
+	network.setDown(false)
+	n, err := eng.RetryDeadLetters("unknown")
+	require.Nil(t, err)
+	require.Equal(t, 0, n)
+	n, err = eng.RetryDeadLetters()
+	require.Nil(t, err)
+	require.Equal(t, 1, n)
+
+	r = waitRecovery(t, eng, "dead letter should be recovered", func(r RecoveryReport) bool {
+		return r.Successes == 1
+	})
+	require.Empty(t, r.DeadLetters)
+	require.True(t, recovered(t, eng, "a"))
+}
+
+func TestEngine_RecoveryBreaker(t *testing.T) {
+	const cooldown = 200 * time.Millisecond
+	eng, network, cancel := startRecoveryEngine(t, RecoveryPolicy{
+		MaxAttempts:      1000,
+		Backoff:          time.Millisecond,
+		MaxBackoff:       2 * time.Millisecond,
+		BreakerThreshold: 3,
+		BreakerCooldown:  cooldown,
+	})
+	defer cancel()
+
+	keys := []string{"a", "b", "c", "d"}
+	for _, key := range keys {
+		require.Nil(t, eng.Recover(key))
+	}
+
+	waitRecovery(t, eng, "breaker should open", func(r RecoveryReport) bool {
+		return r.Breaker == BreakerOpen
+	})
+	require.Equal(t, 3, network.count(), "attempts should stop once the breaker is open")
+
+	// A failed probe opens the breaker again
+	r := waitRecovery(t, eng, "breaker should open after the probe", func(r RecoveryReport) bool {
+		return r.BreakerOpenings == 2
+	})
+	require.Equal(t, BreakerOpen, r.Breaker)
+	require.Equal(t, 4, network.count(), "a single probe should be attempted")
+	require.Equal(t, len(keys), r.Pending)
+	require.Empty(t, r.DeadLetters)
+
+	network.setDown(false)
+	r = waitRecovery(t, eng, "keys should be recovered once the fault clears", func(r RecoveryReport) bool {
+		return r.Successes == uint64(len(keys))
+	})
+	require.Equal(t, BreakerClosed, r.Breaker)
+	require.Equal(t, 0, r.Pending)
+	for _, key := range keys {
+		require.True(t, recovered(t, eng, key), key)
+	}
+}
@@ -0,0 +1,353 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+	"go.uber.org/zap"
+)
+
+const retentionQueryTimeout = time.Minute
+
+// DefaultRetentionMaxKeys is the maximum number of keys pruned by a retention
+// query, unless Engine.RetentionMaxKeys is set.
+const DefaultRetentionMaxKeys = 100
+
+// RetentionPolicy describes the maximum age of the values stored under a
+// key prefix.
+type RetentionPolicy struct {
+	Prefix string
+	Age    time.Duration
+}
+
+// ExpiredKey describes a key whose value is older than its retention policy.
+type ExpiredKey struct {
+	Key      string
+	Prefix   string    // of the retention policy
+	Modified time.Time // logical time of the last commit
+	Version  *Version
+}
+
+// retentionTracker holds the retention policies. Each key is governed by the
+// longest configured prefix it matches.
+// The logical time of the last commit of a key is stored in the provenance of
+// its version (see Provenance.Modified); keys without one, such as those
+// written before it was recorded, are tracked from the time they are seen.
+// The zero value is ready to use, and prunes nothing.
+type retentionTracker struct {
+	sync.Mutex
+	ages     map[string]time.Duration
+	seen     map[string]time.Time // by key, for keys whose last commit is unknown
+	inflight map[string]time.Time // deadline of the pending query pruning a key
+}
+
+// SetRetention bounds the age of the values stored under prefix: once a key
+// has not been modified for longer than age, it is deleted by a PRUNE
+// operation, submitted through consensus by the node selected for the
+// current round (see RetentionPeriod). A zero or negative age removes the
+// policy.
+//
+// Every node of a network should use the same policies, since nodes only
+// endorse PRUNE operations on keys covered by their own policies.
+// This function is thread-safe.
+func (eng *Engine) SetRetention(prefix string, age time.Duration) {
+	t := &eng.retention
+	t.Lock()
+	defer t.Unlock()
+
+	if age <= 0 {
+		delete(t.ages, prefix)
+		return
+	}
+
+	if t.ages == nil {
+		t.ages = make(map[string]time.Duration)
+	}
+	t.ages[prefix] = age
+}
+
+// RetentionPolicies returns the configured retention policies, sorted by
+// prefix.
+// This function is thread-safe.
+func (eng *Engine) RetentionPolicies() []RetentionPolicy {
+	t := &eng.retention
+	t.Lock()
+	defer t.Unlock()
+
+	policies := make([]RetentionPolicy, 0, len(t.ages))
+	for prefix, age := range t.ages {
+		policies = append(policies, RetentionPolicy{Prefix: prefix, Age: age})
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Prefix < policies[j].Prefix
+	})
+	return policies
+}
+
+// ExpiredKeys returns at most limit keys that have outlived their retention
+// policy, oldest first; a zero or negative limit returns every expired key.
+// Deleted keys are omitted, as well as keys whose last commit is unknown,
+// such as recovered keys, until they have been seen for the age of their
+// policy by the retention worker.
+// It has no side effect, and can be used as a dry-run of retention queries.
+// This function is thread-safe.
+func (eng *Engine) ExpiredKeys(limit int) ([]ExpiredKey, error) {
+	eng.Store.Lock()
+	defer eng.Store.Unlock()
+	return eng.expiredKeys(limit, false)
+}
+
+// expiredKeys lists expired keys, optionally omitting the keys that are
+// already being pruned.
+// unsafe
+func (eng *Engine) expiredKeys(limit int, skipInflight bool) ([]ExpiredKey, error) {
+	list, err := eng.Store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	now := eng.now()
+	var expired []ExpiredKey
+
+	t := &eng.retention
+	t.Lock()
+	for key, version := range list {
+		prefix, ok := t.owner(key)
+		if !ok || IsLocalKey(key) {
+			continue
+		}
+
+		modified, ok := t.modifiedAt(key, version)
+		if !ok || now.Sub(modified) <= t.ages[prefix] {
+			continue
+		}
+
+		if deadline, ok := t.inflight[key]; skipInflight && ok && deadline.After(now) {
+			continue
+		}
+
+		expired = append(expired, ExpiredKey{
+			Key:      key,
+			Prefix:   prefix,
+			Modified: modified,
+			Version:  version,
+		})
+	}
+	t.Unlock()
+
+	sort.Slice(expired, func(i, j int) bool {
+		if !expired[i].Modified.Equal(expired[j].Modified) {
+			return expired[i].Modified.Before(expired[j].Modified)
+		}
+		return expired[i].Key < expired[j].Key
+	})
+
+	// Deleted keys are filtered last, since values are only read for expired keys
+	kept := expired[:0]
+	for _, e := range expired {
+		if limit > 0 && len(kept) == limit {
+			break
+		}
+
+		value, _, err := eng.get(e.Key)
+		if err == nil && !encoding.IsTombstone(value) {
+			kept = append(kept, e)
+		}
+	}
+	return kept, nil
+}
+
+// owner returns the longest configured prefix matching key.
+// unsafe
+func (t *retentionTracker) owner(key string) (prefix string, ok bool) {
+	for p := range t.ages {
+		if strings.HasPrefix(key, p) && (!ok || len(p) > len(prefix)) {
+			prefix, ok = p, true
+		}
+	}
+	return
+}
+
+// modifiedAt returns the logical time of the last commit of a key, given its
+// version, and false if it is unknown.
+// unsafe
+func (t *retentionTracker) modifiedAt(key string, version *Version) (time.Time, bool) {
+	if m := version.GetProvenance().GetModified(); m != nil {
+		modified, err := ptypes.Timestamp(m)
+		return modified, err == nil
+	}
+
+	modified, ok := t.seen[key]
+	return modified, ok
+}
+
+// touch stops waiting for the pruning of written keys, whose versions now
+// record their logical time.
+// This function is thread-safe.
+func (t *retentionTracker) touch(keys []string) {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, key := range keys {
+		delete(t.seen, key)
+		delete(t.inflight, key)
+	}
+}
+
+// forget stops tracking deleted keys.
+// This function is thread-safe.
+func (t *retentionTracker) forget(keys []string) {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, key := range keys {
+		delete(t.seen, key)
+		delete(t.inflight, key)
+	}
+}
+
+// track records the keys whose last commit is unknown, as if they had just
+// been modified.
+// This function is thread-safe.
+func (t *retentionTracker) track(keys map[string]*Version, at time.Time) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.seen == nil {
+		t.seen = make(map[string]time.Time)
+	}
+	for key, version := range keys {
+		if _, ok := t.modifiedAt(key, version); !ok {
+			t.seen[key] = at
+		}
+	}
+}
+
+// allowsPruning returns false if q prunes keys that are not covered by a
+// retention policy, or that had not outlived it at the deadline of q, so that
+// every node reaches the same decision.
+// unsafe
+func (eng *Engine) allowsPruning(q *Query) bool {
+	t := &eng.retention
+	for _, op := range q.Operations {
+		if op.Op != Operation_PRUNE {
+			continue
+		}
+
+		_, version, err := eng.get(op.Key)
+		if err != nil {
+			return false
+		}
+
+		t.Lock()
+		prefix, covered := t.owner(op.Key)
+		modified, known := t.modifiedAt(op.Key, version)
+		age := t.ages[prefix]
+		t.Unlock()
+
+		if !covered || !known || q.DeadlineTime().Sub(modified) <= age {
+			return false
+		}
+	}
+	return true
+}
+
+// retentionWorker periodically submits a query pruning the expired keys, if
+// the local node is the initiator of the current round. Initiators are
+// selected deterministically from the cluster time, so that nodes do not
+// compete for the same keys.
+func (eng *Engine) retentionWorker(ctx context.Context) {
+	eng.Store.Lock()
+	list, err := eng.Store.List()
+	eng.Store.Unlock()
+	if err == nil {
+		eng.retention.track(list, eng.now())
+	}
+
+	ticker := time.NewTicker(eng.RetentionPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			round := eng.now().UnixNano() / int64(eng.RetentionPeriod)
+			if isSelected(eng.Identity(), eng.members(), round, 1) {
+				eng.prune()
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// prune submits a query deleting the oldest expired keys, at most
+// RetentionMaxKeys of them.
+func (eng *Engine) prune() {
+	limit := eng.RetentionMaxKeys
+	if limit <= 0 {
+		limit = DefaultRetentionMaxKeys
+	}
+
+	eng.Store.Lock()
+	expired, err := eng.expiredKeys(limit, true)
+	eng.Store.Unlock()
+	if err != nil {
+		zap.L().Warn("Retention", zap.Error(err))
+		return
+	}
+	if len(expired) == 0 {
+		return
+	}
+
+	q := NewQuery()
+	deadline := eng.now().Add(retentionQueryTimeout)
+	q.Deadline, err = ptypes.TimestampProto(deadline)
+	if err != nil {
+		return
+	}
+
+	keys := make([]string, len(expired))
+	for i, e := range expired {
+		keys[i] = e.Key
+		q.Operations = append(q.Operations, &Operation{
+			Key:  e.Key,
+			Op:   Operation_PRUNE,
+			Data: e.Version.Hash,
+		})
+	}
+
+	err = eng.Submit(q)
+	if err != nil {
+		zap.L().Warn("Retention", zap.Error(err))
+		return
+	}
+
+	zap.L().Info("Retention",
+		zap.String("uuid", q.Uuid),
+		zap.Strings("keys", keys),
+	)
+
+	t := &eng.retention
+	t.Lock()
+	defer t.Unlock()
+	if t.inflight == nil {
+		t.inflight = make(map[string]time.Time)
+	}
+	for _, key := range keys {
+		t.inflight[key] = deadline
+	}
+}
@@ -0,0 +1,138 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_Retention(t *testing.T) {
+	const n = 3
+	keyrings := tests.GetTestKeyRings(t, n)
+
+	var mutex sync.Mutex
+	now := time.Now()
+	clock := func() time.Time {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return now
+	}
+
+	h := &hub{}
+	engines := make([]*Engine, n)
+	for i := range engines {
+		engines[i] = NewEngine(newMemoryStore(), h.join(), passBBC{}, keyrings[i], n)
+		engines[i].ClusterClock = NewClusterClock(time.Hour)
+		engines[i].ClusterClock.Clock = clock
+		engines[i].SetRetention("metrics/", time.Hour)
+		engines[i].RetentionPeriod = 20 * time.Millisecond
+		engines[i].RetentionMaxKeys = 2
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, eng := range engines {
+		require.Nil(t, eng.Run(ctx))
+	}
+	h.waitSubscribers(t, 5*n) // queries, endorsements, checkpoints, beacons and node statuses
+
+	get := func(eng *Engine, key string) []byte {
+		eng.Store.Lock()
+		defer eng.Store.Unlock()
+		value, _, _ := eng.Store.Get(key)
+		return value
+	}
+
+	set := func(key string) {
+		q := NewQuery()
+		q.Deadline, _ = ptypes.TimestampProto(clock().Add(3 * time.Second))
+		q.Operations = []*Operation{{Key: key, Op: Operation_SET, Data: []byte(key)}}
+		require.Nil(t, engines[0].Submit(q))
+		for _, eng := range engines {
+			for !bytes.Equal(get(eng, key), []byte(key)) {
+				require.False(t, q.ExpiredAt(clock()), "query should be committed")
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}
+
+	for _, key := range []string{"metrics/a", "metrics/b", "metrics/c", "other/d"} {
+		set(key)
+	}
+
+	expired, err := engines[0].ExpiredKeys(0)
+	require.Nil(t, err)
+	require.Len(t, expired, 0)
+
+	// Modification times are kept in the store, without any dump
+	loaded := NewEngine(engines[0].Store, &recordingNetwork{}, passBBC{}, keyrings[0], n)
+
+	// Dry-run, two hours later
+	loaded.SetRetention("metrics/", time.Hour)
+	loaded.ClusterClock = NewClusterClock(time.Hour)
+	loaded.ClusterClock.Clock = func() time.Time { return clock().Add(2 * time.Hour) }
+	expired, err = loaded.ExpiredKeys(0)
+	require.Nil(t, err)
+	require.Len(t, expired, 3)
+	require.Exactly(t, "metrics/a", expired[0].Key, "oldest keys should come first")
+	require.Exactly(t, "metrics/", expired[0].Prefix)
+	expired, err = loaded.ExpiredKeys(2)
+	require.Nil(t, err)
+	require.Len(t, expired, 2)
+
+	// PRUNE operations are only endorsed on keys covered by a retention policy
+	q := NewQuery()
+	q.Deadline, _ = ptypes.TimestampProto(clock().Add(3 * time.Second))
+	q.Operations = []*Operation{{Key: "other/d", Op: Operation_PRUNE, Data: NewVersion([]byte("other/d")).Hash}}
+	require.False(t, engines[1].canEndorse(q))
+
+	// Nor on keys that have not outlived their policy at the deadline
+	engines[1].Store.Lock()
+	_, version, err := engines[1].Store.Get("metrics/a")
+	engines[1].Store.Unlock()
+	require.Nil(t, err)
+	q.Operations = []*Operation{{Key: "metrics/a", Op: Operation_PRUNE, Data: version.Hash}}
+	require.False(t, engines[1].canEndorse(q))
+	q.Deadline, _ = ptypes.TimestampProto(clock().Add(2 * time.Hour))
+	require.True(t, engines[1].canEndorse(q))
+
+	mutex.Lock()
+	now = now.Add(2 * time.Hour)
+	mutex.Unlock()
+	set("metrics/e")
+
+	// Expired keys are pruned by several queries, agreed by every node
+	timeout := time.Now().Add(5 * time.Second)
+	for _, key := range []string{"metrics/a", "metrics/b", "metrics/c"} {
+		for _, eng := range engines {
+			for !encoding.IsTombstone(get(eng, key)) {
+				require.True(t, time.Now().Before(timeout), "key %s should be pruned", key)
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond) // a few more rounds
+	for _, eng := range engines {
+		require.Exactly(t, []byte("other/d"), get(eng, "other/d"))
+		require.Exactly(t, []byte("metrics/e"), get(eng, "metrics/e"))
+		expired, err = eng.ExpiredKeys(0)
+		require.Nil(t, err)
+		require.Len(t, expired, 0)
+	}
+}
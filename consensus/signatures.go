@@ -8,40 +8,35 @@
 
 package consensus
 
-import "github.com/bluele/gcache"
+import (
+	"github.com/technicolor-research/pnyxdb/keyring"
+	"go.uber.org/zap"
+)
 
 func (eng *Engine) verifyQuery(q *Query) error {
-	hash, err := eng.hashes.GetIFPresent(q.Uuid)
-	if err == gcache.KeyNotFoundError {
-		hash, err = q.Hash()
-		if err != nil {
-			return err
-		}
-	} else if err != nil {
-		return err
-	}
-
-	err = eng.KeyRing.Verify(q.Emitter, hash.([]byte), q.Signature)
+	hash, err := eng.hashes.Hash(q)
 	if err != nil {
 		return err
 	}
 
-	_ = eng.hashes.Set(q.Uuid, hash)
-	return nil
+	return eng.KeyRing.Verify(q.Emitter, hash, q.Signature)
 }
 
+// signQuery signs q, whose hash is computed again since q may have been
+// modified since it was last hashed.
 func (eng *Engine) signQuery(q *Query) error {
-	hash, err := q.Hash()
+	eng.hashes.Invalidate(q)
+	hash, err := eng.hashes.Hash(q)
 	if err != nil {
 		return err
 	}
 
-	q.Signature, err = eng.KeyRing.Sign(hash)
+	q.Signature, err = eng.sign(hash)
 	return err
 }
 
 func (eng *Engine) verifyEndorsement(e *Endorsement) error {
-	hash, err := e.Hash()
+	hash, err := eng.hashes.Hash(e)
 	if err != nil {
 		return err
 	}
@@ -50,11 +45,109 @@ func (eng *Engine) verifyEndorsement(e *Endorsement) error {
 }
 
 func (eng *Engine) signEndorsement(e *Endorsement) error {
-	hash, err := e.Hash()
+	eng.hashes.Invalidate(e)
+	hash, err := eng.hashes.Hash(e)
 	if err != nil {
 		return err
 	}
 
-	e.Signature, err = eng.KeyRing.Sign(hash)
+	e.Signature, err = eng.sign(hash)
 	return err
 }
+
+func (eng *Engine) verifyBeacon(tb *TimeBeacon) error {
+	hash, err := tb.Hash()
+	if err != nil {
+		return err
+	}
+
+	return eng.KeyRing.Verify(tb.Emitter, hash, tb.Signature)
+}
+
+func (eng *Engine) signBeacon(tb *TimeBeacon) error {
+	hash, err := tb.Hash()
+	if err != nil {
+		return err
+	}
+
+	tb.Signature, err = eng.sign(hash)
+	return err
+}
+
+func (eng *Engine) verifyNodeStatus(ns *NodeStatus) error {
+	hash, err := ns.Hash()
+	if err != nil {
+		return err
+	}
+
+	return eng.KeyRing.Verify(ns.Emitter, hash, ns.Signature)
+}
+
+func (eng *Engine) signNodeStatus(ns *NodeStatus) error {
+	hash, err := ns.Hash()
+	if err != nil {
+		return err
+	}
+
+	ns.Signature, err = eng.sign(hash)
+	return err
+}
+
+func (eng *Engine) verifyCheckpointResult(r *CheckpointResult) error {
+	hash, err := r.Hash()
+	if err != nil {
+		return err
+	}
+
+	return eng.KeyRing.Verify(r.Emitter, hash, r.Signature)
+}
+
+func (eng *Engine) signCheckpointResult(r *CheckpointResult) error {
+	hash, err := r.Hash()
+	if err != nil {
+		return err
+	}
+
+	r.Signature, err = eng.sign(hash)
+	return err
+}
+
+// sign signs a hash with the private key of the engine. If the keyring has
+// been locked (see keyring.KeyRing.SetAutoLock), it is unlocked through
+// UnlockFunc before signing again.
+func (eng *Engine) sign(hash []byte) ([]byte, error) {
+	signature, err := eng.KeyRing.Sign(hash)
+	if err != keyring.ErrKeyRingLocked || eng.UnlockFunc == nil {
+		return signature, err
+	}
+
+	err = eng.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return eng.KeyRing.Sign(hash)
+}
+
+// unlock unlocks the keyring through UnlockFunc, if it is locked.
+// Concurrent callers wait for a single call to UnlockFunc.
+func (eng *Engine) unlock() error {
+	if eng.UnlockFunc == nil {
+		return keyring.ErrKeyRingLocked
+	}
+
+	eng.unlockMutex.Lock()
+	defer eng.unlockMutex.Unlock()
+
+	if !eng.KeyRing.Locked() {
+		return nil
+	}
+
+	err := eng.UnlockFunc()
+	if err != nil {
+		zap.L().Warn("KeyRingUnlock", zap.Error(err))
+		return err
+	}
+
+	zap.L().Info("KeyRingUnlock")
+	return nil
+}
@@ -0,0 +1,221 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+)
+
+// ErrSpeculativeDeletion is returned by GetSpeculative for a key removed by a
+// query which has not been written to the store yet.
+var ErrSpeculativeDeletion = errors.New("key deleted by a speculative query")
+
+// speculativeValue is a value of the speculative overlay.
+type speculativeValue struct {
+	data    []byte
+	version *Version
+}
+
+// speculativeOverlay holds the values written by the speculative queries,
+// executed in order on top of the store. It is guarded by the lock of the
+// store, and kept from one read to the next: only the queries which became
+// speculative since the previous read are executed.
+type speculativeOverlay struct {
+	entries []speculativeEntry
+	values  map[string]speculativeValue // merged values of the entries
+	expiry  time.Time                   // of the first value read or written, zero if none expires
+}
+
+// speculativeEntry holds the values written by a speculative query, nil if
+// it could not be executed.
+type speculativeEntry struct {
+	uuid   string
+	values map[string]speculativeValue
+}
+
+// GetSpeculative returns the value and the version of a key as if every
+// applicable query were already committed, like GetLive otherwise.
+//
+// The speculative queries are executed in the order they became applicable
+// on top of the store, each one reading the values written by the previous
+// ones, so that chains of dependent queries are taken into account. Queries
+// that cannot be executed are skipped, as well as those written to the store
+// in the meantime.
+// The overlay is updated rather than rebuilt: the queries which became
+// speculative since the previous read are executed on top of it, while a
+// query rolled back or moved ahead by its commit drops the part of the
+// overlay built on top of it. A query written to the store leaves the overlay
+// once apply writes it, and any other write to the store resets it.
+// This function is thread-safe.
+func (eng *Engine) GetSpeculative(key string) ([]byte, *Version, error) {
+	// The query store must not be accessed once the store is locked.
+	queries := eng.qs.Speculative()
+
+	eng.Store.Lock()
+	defer eng.Store.Unlock()
+
+	eng.updateOverlay(queries)
+	value, ok := eng.overlay.values[key]
+	if !ok {
+		return eng.GetLive(key)
+	}
+
+	if value.version == NoVersion {
+		return nil, NoVersion, ErrSpeculativeDeletion
+	}
+	if value.version.ExpiredAt(eng.now()) {
+		return nil, NoVersion, ErrExpired
+	}
+	return value.data, value.version, nil
+}
+
+// updateOverlay brings the speculative overlay up to date with queries.
+// unsafe
+func (eng *Engine) updateOverlay(queries []*Query) {
+	o := &eng.overlay
+	now := eng.now()
+	if !o.expiry.IsZero() && !now.Before(o.expiry) {
+		// Expired values are read differently
+		o.reset()
+	}
+
+	// Keep the entries of the queries still speculative in the same order
+	n := 0
+	for n < len(o.entries) && n < len(queries) && o.entries[n].uuid == queries[n].Uuid {
+		n++
+	}
+	if n < len(o.entries) {
+		o.truncate(n)
+	}
+
+	for _, q := range queries[n:] {
+		o.push(speculativeEntry{uuid: q.Uuid, values: eng.executeSpeculative(q, now)})
+	}
+}
+
+// executeSpeculative executes q on top of the overlay, and returns the
+// values it would write, nil if it cannot be executed.
+// unsafe
+func (eng *Engine) executeSpeculative(q *Query, now time.Time) map[string]speculativeValue {
+	o := &eng.overlay
+	_, done, err := eng.loadAppliedMarkers(q.Uuid)
+	if err != nil || done {
+		return nil
+	}
+
+	executed, _, err := eng.skipAppliedNonces(q)
+	if err != nil {
+		return nil
+	}
+
+	values, err := executed.execute(func(key string) ([]byte, *Version, error) {
+		if value, ok := o.values[key]; ok {
+			return value.data, value.version, nil
+		}
+
+		data, v, err := eng.get(key)
+		if err != nil && v != NoVersion {
+			return nil, nil, err
+		}
+		o.expires(v)
+		return data, v, nil
+	})
+	if err != nil {
+		zap.L().Debug("SpeculativeSkipped",
+			zap.String("uuid", q.Uuid),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	written := make(map[string]speculativeValue, len(values))
+	for k, value := range values {
+		if IsLocalKey(k) {
+			continue
+		}
+
+		if encoding.IsDeletion(value.Raw) {
+			written[k] = speculativeValue{version: NoVersion}
+			continue
+		}
+
+		version := NewVersion(value.Raw)
+		version.setExpiry(value.Expiry)
+		version.setProvenance(q, now)
+		o.expires(version)
+		written[k] = speculativeValue{data: value.Raw, version: version}
+	}
+	return written
+}
+
+// written removes a query written to the store from the overlay. The
+// overlay is reset unless the query comes first, since the values of the
+// next queries were read on top of it.
+// unsafe
+func (o *speculativeOverlay) written(uuid string) {
+	if len(o.entries) == 0 || o.entries[0].uuid != uuid {
+		o.reset()
+		return
+	}
+
+	o.entries = o.entries[1:]
+	o.merge()
+}
+
+// reset empties the overlay, after a write to the store.
+// unsafe
+func (o *speculativeOverlay) reset() {
+	o.entries = nil
+	o.values = nil
+	o.expiry = time.Time{}
+}
+
+// truncate keeps the first n entries of the overlay.
+// unsafe
+func (o *speculativeOverlay) truncate(n int) {
+	o.entries = o.entries[:n]
+	o.merge()
+}
+
+// push adds an entry on top of the overlay.
+// unsafe
+func (o *speculativeOverlay) push(e speculativeEntry) {
+	if o.values == nil {
+		o.values = make(map[string]speculativeValue)
+	}
+	o.entries = append(o.entries, e)
+	for k, value := range e.values {
+		o.values[k] = value
+	}
+}
+
+// merge computes the values of the overlay from its entries.
+// unsafe
+func (o *speculativeOverlay) merge() {
+	o.values = make(map[string]speculativeValue)
+	for _, e := range o.entries {
+		for k, value := range e.values {
+			o.values[k] = value
+		}
+	}
+}
+
+// expires records the expiry of a value read or written by the overlay.
+// unsafe
+func (o *speculativeOverlay) expires(v *Version) {
+	t := v.ExpiryTime()
+	if !t.IsZero() && (o.expiry.IsZero() || t.Before(o.expiry)) {
+		o.expiry = t
+	}
+}
@@ -0,0 +1,136 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_GetSpeculative(t *testing.T) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	eng := NewEngine(newMemoryStore(), nil, passBBC{}, kr, 3)
+	eng.Store.Lock()
+	require.Nil(t, eng.Store.Set("k", []byte("0"), NewVersion([]byte("0"))))
+	eng.Store.Unlock()
+
+	read := func(get func(string) ([]byte, *Version, error)) string {
+		value, _, err := get("k")
+		require.Nil(t, err)
+		return string(value)
+	}
+
+	endorse := func(q *Query, conditions []string, emitters ...string) {
+		eng.qs.AddQuery(q)
+		for _, emitter := range emitters {
+			eng.qs.AddEndorsement(&Endorsement{Emitter: emitter, Uuid: q.Uuid, Conditions: conditions})
+		}
+	}
+
+	// p never gets enough endorsements, q conflicts with r
+	p, q, r, s := NewQuery(), concat("k", "q", nil), concat("k", "r", nil), concat("k", "s", nil)
+	eng.qs.AddQuery(p)
+	eng.qs.AddQuery(q)
+	endorse(r, []string{q.Uuid}, "1", "2")
+	endorse(r, nil, "4")
+	endorse(s, []string{p.Uuid}, "1", "2", "3")
+
+	for _, query := range []*Query{r, s} {
+		commit, _ := eng.qs.CheckState(query.Uuid)
+		require.False(t, commit)
+	}
+	require.Equal(t, "0", read(eng.GetLive))
+	require.Equal(t, "0rs", read(eng.GetSpeculative), "s must be executed on top of r")
+
+	// The commit of q rolls r back
+	endorse(q, nil, "1", "2", "3")
+	commit, _ := eng.qs.CheckState(q.Uuid)
+	require.True(t, commit)
+	status, err := eng.QueryStatus(r.Uuid)
+	require.Nil(t, err)
+	require.Equal(t, StateDropped, status.State)
+	require.Equal(t, "0", read(eng.GetLive))
+	require.Equal(t, "0qs", read(eng.GetSpeculative), "committed queries must come first")
+
+	// Once written, q is read from the store
+	require.Nil(t, eng.apply(q.Uuid))
+	require.Equal(t, "0q", read(eng.GetLive))
+	require.Equal(t, "0qs", read(eng.GetSpeculative))
+	require.Len(t, eng.qs.Speculative(), 1)
+
+	// s is rolled back once p is committed
+	endorse(p, nil, "1", "2", "3")
+	commit, _ = eng.qs.CheckState(p.Uuid)
+	require.True(t, commit)
+	require.Equal(t, "0q", read(eng.GetSpeculative))
+
+	// Deletions are reported as such
+	x, d := NewQuery(), NewQuery()
+	d.Operations = []*Operation{{Key: "k", Op: Operation_DELETE}}
+	eng.qs.AddQuery(x)
+	endorse(d, []string{x.Uuid}, "1", "2", "3")
+	eng.qs.CheckState(d.Uuid)
+	_, version, err := eng.GetSpeculative("k")
+	require.Equal(t, ErrSpeculativeDeletion, err)
+	require.Equal(t, NoVersion, version)
+	require.Equal(t, "0q", read(eng.GetLive))
+}
+
+// readsStore counts the reads of each key.
+type readsStore struct {
+	*memoryStore
+	reads map[string]int
+}
+
+func (s *readsStore) Get(key string) ([]byte, *Version, error) {
+	s.reads[key]++
+	return s.memoryStore.Get(key)
+}
+
+func TestEngine_SpeculativeOverlay(t *testing.T) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	store := &readsStore{memoryStore: newMemoryStore(), reads: make(map[string]int)}
+	eng := NewEngine(store, nil, passBBC{}, kr, 3)
+	eng.Store.Lock()
+	require.Nil(t, eng.Store.Set("k", []byte("0"), NewVersion([]byte("0"))))
+	eng.Store.Unlock()
+
+	read := func() string {
+		value, _, err := eng.GetSpeculative("k")
+		require.Nil(t, err)
+		return string(value)
+	}
+	commit := func(q *Query) {
+		eng.qs.AddQuery(q)
+		for _, emitter := range []string{"1", "2", "3"} {
+			eng.qs.AddEndorsement(&Endorsement{Emitter: emitter, Uuid: q.Uuid})
+		}
+		commit, _ := eng.qs.CheckState(q.Uuid)
+		require.True(t, commit)
+	}
+
+	a, b := concat("k", "a", nil), concat("k", "b", nil)
+	commit(a)
+	require.Equal(t, "0a", read())
+	require.Equal(t, 1, store.reads["k"])
+	require.Equal(t, "0a", read())
+	require.Equal(t, 1, store.reads["k"], "the overlay must be kept between reads")
+
+	commit(b)
+	require.Equal(t, "0ab", read())
+	require.Equal(t, 1, store.reads["k"], "only b must be executed, on top of a")
+
+	require.Nil(t, eng.apply(a.Uuid))
+	reads := store.reads["k"]
+	require.Equal(t, "0ab", read())
+	require.Equal(t, reads, store.reads["k"], "b must be kept once a is written")
+
+}
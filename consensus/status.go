@@ -0,0 +1,184 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrUnknownQuery is returned for queries that have never been received,
+// or that have been forgotten after StatusRetention.
+var ErrUnknownQuery = errors.New("unknown query")
+
+// maxStatusInterval bounds the interval between two rounds forgetting
+// resolved queries.
+const maxStatusInterval = time.Minute
+
+// QueryState is the state of a query, as reported by QueryStatus.
+type QueryState string
+
+// Available query states
+const (
+	StatePending   QueryState = "pending"
+	StateCommitted QueryState = "committed"
+	StateDropped   QueryState = "dropped"
+	StateUnknown   QueryState = "unknown"
+)
+
+// QueryStatus describes the progress of a query known by the engine.
+type QueryStatus struct {
+	State        QueryState
+	Endorsements int       // received so far, including the local one
+	Deadline     time.Time // zero if the query has been committed through a checkpoint without being received
+	Resolved     time.Time // when the query has been committed or dropped, zero while pending
+	Applied      bool      // committed values have been written to the store
+	ApplyError   error     // set when a committed query could not be applied
+}
+
+// QueryStatus returns the status of a query. Unknown queries are reported
+// with the StateUnknown state, along with ErrUnknownQuery.
+// This function is thread-safe.
+func (eng *Engine) QueryStatus(uuid string) (QueryStatus, error) {
+	s, ok := eng.qs.Status(uuid)
+	if !ok {
+		return QueryStatus{State: StateUnknown}, ErrUnknownQuery
+	}
+	return s, nil
+}
+
+// SubmitAndWait submits a query like Submit, and blocks until it is
+// dropped, or committed and applied, until its deadline is reached while it
+// is still pending, or until ctx is done. It returns the status of the query
+// at that time.
+func (eng *Engine) SubmitAndWait(ctx context.Context, q *Query) (QueryStatus, error) {
+	err := eng.Submit(q)
+	if err != nil {
+		return QueryStatus{State: StateUnknown}, err
+	}
+
+	var deadline time.Time
+	if q.Deadline != nil {
+		deadline = q.DeadlineTime()
+	}
+	return eng.waitQuery(ctx, q.Uuid, deadline)
+}
+
+// waitQuery blocks until the query is settled (see queryInfo.settled), or
+// until the deadline is reached while the query is not committed yet.
+// Committed queries are waited for until their values are written, whatever
+// the deadline. A zero deadline is never reached.
+// The query is followed through its events, which its status may not reflect
+// yet when they are received: the result of an EventApplied is recorded by
+// the query store right after it is emitted.
+func (eng *Engine) waitQuery(ctx context.Context, uuid string, deadline time.Time) (QueryStatus, error) {
+	var stopped <-chan struct{} // nil until Run is called
+	if runCtx := eng.runContext(); runCtx != nil {
+		stopped = runCtx.Done()
+	}
+
+	committed := make(chan struct{}, 1)
+	settled := make(chan EngineEvent, 1)
+	release := eng.events.listen(func(ev EngineEvent) {
+		if ev.Uuid != uuid {
+			return
+		}
+
+		switch ev.Type {
+		case EventCommitted:
+			select {
+			case committed <- struct{}{}:
+			default:
+			}
+		case EventApplied, EventDropped:
+			select {
+			case settled <- ev:
+			default: // the first result is kept
+			}
+		}
+	})
+	defer release()
+
+	s, err := eng.QueryStatus(uuid)
+	if s.State == StateDropped || s.Applied || s.ApplyError != nil {
+		return s, nil
+	}
+
+	for {
+		var expiry <-chan time.Time
+		if s.State != StateCommitted && !deadline.IsZero() {
+			wait := deadline.Sub(eng.now())
+			if wait <= 0 {
+				return s, err
+			}
+			expiry = eng.clock().After(wait)
+		}
+
+		select {
+		case <-committed:
+			s.State = StateCommitted
+			continue
+		case ev := <-settled:
+			s, err = eng.QueryStatus(uuid)
+			if ev.Type == EventApplied {
+				s.State = StateCommitted
+				s.Applied = ev.Err == nil
+				s.ApplyError = ev.Err
+			}
+			return s, err
+		case <-expiry:
+			s, err = eng.QueryStatus(uuid)
+			if s.State == StateDropped || s.Applied || s.ApplyError != nil {
+				return s, nil
+			}
+			if s.State != StateCommitted {
+				return s, err
+			}
+		case <-ctx.Done():
+			s, _ = eng.QueryStatus(uuid)
+			return s, ctx.Err()
+		case <-stopped:
+			s, _ = eng.QueryStatus(uuid)
+			return s, ErrEngineStopped
+		}
+	}
+}
+
+// statusWorker periodically forgets the queries resolved for more than
+// StatusRetention, whose deadline has also been reached for as long.
+func (eng *Engine) statusWorker(ctx context.Context) {
+	interval := eng.StatusRetention
+	if interval > maxStatusInterval {
+		interval = maxStatusInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			n := eng.qs.Forget(eng.clock().Now().Add(-eng.StatusRetention), eng.now().Add(-eng.StatusRetention))
+			if n > 0 {
+				zap.L().Debug("ForgetQueries", zap.Int("count", n))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// outdated returns true if the deadline of the query has been reached for
+// more than StatusRetention, meaning it may have been forgotten already.
+func (eng *Engine) outdated(q *Query) bool {
+	return eng.StatusRetention > 0 && q.ExpiredAt(eng.now().Add(-eng.StatusRetention))
+}
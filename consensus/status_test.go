@@ -0,0 +1,158 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func newTimedQuery(deadline time.Time) *Query {
+	q := NewQuery()
+	q.Deadline, _ = ptypes.TimestampProto(deadline)
+	return q
+}
+
+func TestEngine_QueryStatus(t *testing.T) {
+	eng := NewEngine(newMemoryStore(), nil, nil, nil, 3)
+	deadline := time.Now().Add(time.Minute).Truncate(time.Second)
+	q := newTimedQuery(deadline)
+	eng.qs.AddQuery(q)
+	eng.qs.AddEndorsement(&Endorsement{Emitter: "a", Uuid: q.Uuid})
+	eng.qs.AddEndorsement(&Endorsement{Emitter: "b", Uuid: q.Uuid})
+
+	s, err := eng.QueryStatus(q.Uuid)
+	require.Nil(t, err)
+	require.Equal(t, StatePending, s.State)
+	require.Equal(t, 2, s.Endorsements)
+	require.True(t, deadline.Equal(s.Deadline))
+	require.True(t, s.Resolved.IsZero())
+
+	require.True(t, eng.qs.DropPending(q.Uuid))
+	s, err = eng.QueryStatus(q.Uuid)
+	require.Nil(t, err)
+	require.Equal(t, StateDropped, s.State)
+	require.False(t, s.Resolved.IsZero())
+
+	s, err = eng.QueryStatus("unknown")
+	require.Equal(t, ErrUnknownQuery, err)
+	require.Equal(t, StateUnknown, s.State)
+}
+
+func TestQueryStore_Forget(t *testing.T) {
+	qs := newQueryStore()
+	qs.threshold = 1
+	past := time.Now().Add(-time.Hour)
+
+	applied := newTimedQuery(past)
+	unapplied := newTimedQuery(past)
+	live := newTimedQuery(time.Now().Add(time.Hour))
+	dropped := newTimedQuery(past)
+	dependent := newTimedQuery(past)
+	for _, q := range []*Query{applied, unapplied, live, dropped, dependent} {
+		qs.AddQuery(q)
+	}
+	// dependent is endorsed on the condition that dropped is dropped
+	qs.AddEndorsement(&Endorsement{Emitter: "a", Uuid: dependent.Uuid, Conditions: []string{dropped.Uuid}})
+
+	qs.Lock()
+	qs.commit(applied.Uuid)
+	qs.commit(unapplied.Uuid)
+	qs.commit(live.Uuid)
+	qs.drop(dropped.Uuid)
+	qs.Unlock()
+	qs.SetApplyResult(applied.Uuid, nil)
+	qs.SetApplyResult(live.Uuid, nil)
+
+	now := time.Now()
+	require.Equal(t, 0, qs.Forget(past, now), "nothing has been resolved before the retention")
+	require.Equal(t, 1, qs.Forget(now.Add(time.Second), now))
+
+	_, ok := qs.Status(applied.Uuid)
+	require.False(t, ok, "applied and expired, must be forgotten")
+	for _, q := range []*Query{unapplied, live, dropped, dependent} {
+		_, ok := qs.Status(q.Uuid)
+		require.True(t, ok)
+	}
+
+	require.True(t, qs.DropPending(dependent.Uuid))
+	require.Equal(t, 2, qs.Forget(now.Add(time.Second), now))
+	_, ok = qs.Status(dropped.Uuid)
+	require.False(t, ok, "no pending query depends on it anymore")
+}
+
+func TestEngine_OutdatedQuery(t *testing.T) {
+	eng := NewEngine(newMemoryStore(), nil, nil, nil, 1)
+	q := newTimedQuery(time.Now().Add(-time.Hour))
+	require.False(t, eng.outdated(q), "never outdated without retention")
+
+	eng.StatusRetention = time.Minute
+	require.True(t, eng.outdated(q))
+	require.False(t, eng.outdated(newTimedQuery(time.Now().Add(-time.Second))))
+}
+
+func TestEngine_SubmitAndWait(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 2)
+	h := &hub{}
+	eng := NewEngine(newMemoryStore(), h.join(), passBBC{}, keyrings[0], 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.Nil(t, eng.Run(ctx))
+	h.waitSubscribers(t, 4) // queries, endorsements, checkpoints and node statuses
+
+	set := func(key, value string) *Query {
+		q := NewQuery()
+		q.SetTimeout(time.Second)
+		q.Operations = []*Operation{{Key: key, Op: Operation_SET, Data: []byte(value)}}
+		return q
+	}
+
+	t.Run("Committed", func(t *testing.T) {
+		s, err := eng.SubmitAndWait(context.Background(), set("a", "1"))
+		require.Nil(t, err)
+		require.Equal(t, StateCommitted, s.State)
+		require.True(t, s.Applied)
+
+		eng.Store.Lock()
+		value, _, err := eng.Store.Get("a")
+		eng.Store.Unlock()
+		require.Nil(t, err)
+		require.Equal(t, []byte("1"), value, "values must be written once SubmitAndWait returns")
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		waiting := NewEngine(newMemoryStore(), h.join(), passBBC{}, keyrings[1], 2)
+		require.Nil(t, waiting.Run(ctx))
+
+		wctx, wcancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer wcancel()
+		s, err := waiting.SubmitAndWait(wctx, set("b", "1"))
+		require.Equal(t, context.DeadlineExceeded, err)
+		require.Equal(t, StatePending, s.State)
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		waiting := NewEngine(newMemoryStore(), h.join(), passBBC{}, keyrings[1], 3)
+		require.Nil(t, waiting.Run(ctx))
+
+		q := set("c", "1")
+		q.SetTimeout(100 * time.Millisecond)
+		start := time.Now()
+		s, err := waiting.SubmitAndWait(context.Background(), q)
+		require.Nil(t, err)
+		require.Equal(t, StatePending, s.State, "the query cannot reach the threshold")
+		require.False(t, time.Now().Before(q.DeadlineTime()))
+		require.True(t, time.Since(start) < time.Second)
+	})
+}
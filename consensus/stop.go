@@ -0,0 +1,96 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// stopPollPeriod is the interval between two checks of the queries left to
+// apply by Stop.
+const stopPollPeriod = 50 * time.Millisecond
+
+// Stop stops the engine gracefully. New queries are rejected, whether they
+// are submitted locally or received from peers, while the queries already
+// applicable or committed are given until ctx is done to be applied: the
+// engine keeps handling endorsements and checkpoints meanwhile. The state of
+// the engine is then persisted with PersistFunc, and the engine stopped as
+// if the context given to Run was done.
+//
+// The engine is stopped in any case: the error returned is the one of ctx
+// when some queries were not applied in time, or the one of PersistFunc.
+// Stop fails with ErrEngineStopped if the engine is not running.
+func (eng *Engine) Stop(ctx context.Context) error {
+	eng.runMutex.Lock()
+	cancel := eng.cancel
+	running := eng.ctx != nil && eng.ctx.Err() == nil
+	eng.stopping = true
+	eng.runMutex.Unlock()
+
+	if !running {
+		return ErrEngineStopped
+	}
+	defer cancel()
+
+	from := time.Now()
+	err := eng.waitApplied(ctx)
+	if err != nil {
+		zap.L().Warn("EngineStop",
+			zap.Int("speculative", len(eng.qs.Speculative())),
+			zap.Error(err),
+		)
+	}
+
+	if eng.PersistFunc != nil {
+		if perr := eng.PersistFunc(); perr != nil {
+			zap.L().Error("EngineStop", zap.Error(perr))
+			if err == nil {
+				err = perr
+			}
+		}
+	}
+
+	zap.L().Info("EngineStop", zap.Duration("duration", time.Since(from)))
+	return err
+}
+
+// accepting returns false once Stop has been called, or the engine stopped.
+// This function is thread-safe.
+func (eng *Engine) accepting() bool {
+	eng.runMutex.RLock()
+	defer eng.runMutex.RUnlock()
+	return !eng.stopping && (eng.ctx == nil || eng.ctx.Err() == nil)
+}
+
+// waitApplied waits until no query is applicable or committed without being
+// applied, or until ctx is done.
+func (eng *Engine) waitApplied(ctx context.Context) error {
+	for {
+		if len(eng.qs.Speculative()) == 0 && !eng.applyingAny() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(stopPollPeriod):
+		}
+	}
+}
+
+// applyingAny returns true if some query is being applied.
+// This function is thread-safe.
+func (eng *Engine) applyingAny() bool {
+	eng.applyMutex.Lock()
+	defer eng.applyMutex.Unlock()
+	return len(eng.inflightApply) > 0
+}
@@ -0,0 +1,105 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_Stop(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 2)
+	signer := NewEngine(nil, nil, nil, keyrings[1], 3)
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, passBBC{}, keyrings[0], 3)
+
+	stored := func(key string) bool {
+		eng.Store.Lock()
+		defer eng.Store.Unlock()
+		_, v, _ := eng.Store.Get(key)
+		return v != nil && v != NoVersion
+	}
+
+	persisted := make(chan bool, 1)
+	eng.PersistFunc = func() error {
+		persisted <- stored("k")
+		return nil
+	}
+
+	endorse := func(q *Query, conditions []string) {
+		for _, emitter := range []string{"1", "2", "3"} {
+			eng.qs.AddEndorsement(&Endorsement{Emitter: emitter, Uuid: q.Uuid, Conditions: conditions})
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.Nil(t, eng.Run(ctx))
+
+	// q is applicable, but not committed before the conflicting p is dropped
+	p, q := NewQuery(), concat("k", "q", nil)
+	q.SetTimeout(time.Minute)
+	eng.qs.AddQuery(p)
+	eng.qs.AddQuery(q)
+	endorse(q, []string{p.Uuid})
+	eng.checkState(q.Uuid)
+	require.Len(t, eng.qs.Speculative(), 1)
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- eng.Stop(context.Background()) }()
+	time.Sleep(100 * time.Millisecond)
+
+	select {
+	case <-stopped:
+		require.FailNow(t, "Stop should wait for the applicable query to be applied")
+	default:
+	}
+
+	other := NewQuery()
+	other.SetTimeout(time.Minute)
+	require.Equal(t, ErrEngineStopped, eng.Submit(other), "new queries should be rejected")
+	other.Emitter = keyrings[1].Identity()
+	require.Nil(t, signer.signQuery(other))
+	eng.handleQuery(other)
+	_, err := eng.QueryStatus(other.Uuid)
+	require.Equal(t, ErrUnknownQuery, err, "queries of peers should be ignored")
+
+	// Pending queries are still decided
+	require.True(t, eng.qs.DropPending(p.Uuid))
+	eng.checkState(q.Uuid)
+	require.Nil(t, <-stopped)
+	require.True(t, <-persisted, "the state should be persisted once the query is applied")
+	require.True(t, eng.stopped(), "the engine should be stopped")
+	require.Equal(t, ErrEngineStopped, eng.Stop(context.Background()))
+}
+
+func TestEngine_StopTimeout(t *testing.T) {
+	kr := tests.GetTestKeyRings(t, 1)[0]
+	eng := NewEngine(newMemoryStore(), &recordingNetwork{}, passBBC{}, kr, 1)
+	require.Equal(t, ErrEngineStopped, eng.Stop(context.Background()), "the engine should be running")
+
+	var persisted int
+	eng.PersistFunc = func() error {
+		persisted++
+		return nil
+	}
+	require.Nil(t, eng.Run(context.Background()))
+
+	// A query is being applied
+	require.True(t, eng.applying("uuid"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, eng.Stop(ctx))
+	require.Equal(t, 1, persisted, "the state should be persisted anyway")
+	require.True(t, eng.stopped())
+}
@@ -0,0 +1,66 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"sync"
+)
+
+// memoryStore is a minimal in-memory Store used by tests.
+type memoryStore struct {
+	sync.Mutex
+	values   map[string][]byte
+	versions map[string]*Version
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		values:   make(map[string][]byte),
+		versions: make(map[string]*Version),
+	}
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
+
+func (s *memoryStore) Get(key string) ([]byte, *Version, error) {
+	v, ok := s.versions[key]
+	if !ok {
+		return nil, NoVersion, nil
+	}
+	return s.values[key], v, nil
+}
+
+func (s *memoryStore) Set(key string, value []byte, version *Version) error {
+	s.values[key] = value
+	s.versions[key] = version
+	return nil
+}
+
+func (s *memoryStore) SetBatch(keys []string, values [][]byte, versions []*Version) error {
+	for i, k := range keys {
+		_ = s.Set(k, values[i], versions[i])
+	}
+	return nil
+}
+
+func (s *memoryStore) Delete(key string) error {
+	delete(s.values, key)
+	delete(s.versions, key)
+	return nil
+}
+
+func (s *memoryStore) List() (map[string]*Version, error) {
+	list := make(map[string]*Version, len(s.versions))
+	for k, v := range s.versions {
+		list[k] = v
+	}
+	return list, nil
+}
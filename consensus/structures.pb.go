@@ -6,6 +6,7 @@ package consensus
 import proto "github.com/golang/protobuf/proto"
 import fmt "fmt"
 import math "math"
+import duration "github.com/golang/protobuf/ptypes/duration"
 import timestamp "github.com/golang/protobuf/ptypes/timestamp"
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -23,52 +24,115 @@ type Operation_Op int32
 
 const (
 	// Operations on every values
-	Operation_SET    Operation_Op = 0
-	Operation_CONCAT Operation_Op = 1
+	Operation_SET      Operation_Op = 0
+	Operation_CONCAT   Operation_Op = 1
+	Operation_REPLACE  Operation_Op = 2
+	Operation_TRUNCATE Operation_Op = 3
+	Operation_SETRANGE Operation_Op = 4
+	Operation_CAS      Operation_Op = 5
+	Operation_EXPIRE   Operation_Op = 6
 	// Operations on numeric values
 	Operation_ADD Operation_Op = 10
 	Operation_MUL Operation_Op = 11
+	// Operations on integer values
+	Operation_INCR Operation_Op = 12
+	Operation_DECR Operation_Op = 13
+	// Merge of numeric values
+	Operation_MIN Operation_Op = 14
+	Operation_MAX Operation_Op = 15
 	// Operations on set values
 	Operation_SADD Operation_Op = 20
 	Operation_SREM Operation_Op = 21
+	// Operations on list values
+	Operation_LPUSH Operation_Op = 22
+	Operation_RPUSH Operation_Op = 23
+	Operation_LPOP  Operation_Op = 24
+	// Operations on map values
+	Operation_HSET Operation_Op = 25
+	Operation_HDEL Operation_Op = 26
+	// Reversible deletion
+	Operation_SOFTDELETE Operation_Op = 30
+	Operation_RESTORE    Operation_Op = 31
+	// Deletion by retention policies
+	Operation_PRUNE Operation_Op = 32
+	// Permanent deletion
+	Operation_DELETE Operation_Op = 33
 )
 
 var Operation_Op_name = map[int32]string{
 	0:  "SET",
 	1:  "CONCAT",
+	2:  "REPLACE",
+	3:  "TRUNCATE",
+	4:  "SETRANGE",
+	5:  "CAS",
+	6:  "EXPIRE",
 	10: "ADD",
 	11: "MUL",
+	12: "INCR",
+	13: "DECR",
+	14: "MIN",
+	15: "MAX",
 	20: "SADD",
 	21: "SREM",
+	22: "LPUSH",
+	23: "RPUSH",
+	24: "LPOP",
+	25: "HSET",
+	26: "HDEL",
+	30: "SOFTDELETE",
+	31: "RESTORE",
+	32: "PRUNE",
+	33: "DELETE",
 }
 var Operation_Op_value = map[string]int32{
-	"SET":    0,
-	"CONCAT": 1,
-	"ADD":    10,
-	"MUL":    11,
-	"SADD":   20,
-	"SREM":   21,
+	"SET":        0,
+	"CONCAT":     1,
+	"REPLACE":    2,
+	"TRUNCATE":   3,
+	"SETRANGE":   4,
+	"CAS":        5,
+	"EXPIRE":     6,
+	"ADD":        10,
+	"MUL":        11,
+	"INCR":       12,
+	"DECR":       13,
+	"MIN":        14,
+	"MAX":        15,
+	"SADD":       20,
+	"SREM":       21,
+	"LPUSH":      22,
+	"RPUSH":      23,
+	"LPOP":       24,
+	"HSET":       25,
+	"HDEL":       26,
+	"SOFTDELETE": 30,
+	"RESTORE":    31,
+	"PRUNE":      32,
+	"DELETE":     33,
 }
 
 func (x Operation_Op) String() string {
 	return proto.EnumName(Operation_Op_name, int32(x))
 }
 func (Operation_Op) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_structures_08f3d7062cc30271, []int{2, 0}
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{3, 0}
 }
 
 type Version struct {
-	Hash                 []byte   `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Hash                 []byte               `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Expiry               *timestamp.Timestamp `protobuf:"bytes,2,opt,name=expiry,proto3" json:"expiry,omitempty"`
+	Provenance           *Provenance          `protobuf:"bytes,3,opt,name=provenance,proto3" json:"provenance,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
 func (m *Version) Reset()         { *m = Version{} }
 func (m *Version) String() string { return proto.CompactTextString(m) }
 func (*Version) ProtoMessage()    {}
 func (*Version) Descriptor() ([]byte, []int) {
-	return fileDescriptor_structures_08f3d7062cc30271, []int{0}
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{0}
 }
 func (m *Version) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Version.Unmarshal(m, b)
@@ -95,6 +159,83 @@ func (m *Version) GetHash() []byte {
 	return nil
 }
 
+func (m *Version) GetExpiry() *timestamp.Timestamp {
+	if m != nil {
+		return m.Expiry
+	}
+	return nil
+}
+
+func (m *Version) GetProvenance() *Provenance {
+	if m != nil {
+		return m.Provenance
+	}
+	return nil
+}
+
+// Provenance identifies the query which wrote a value.
+type Provenance struct {
+	Uuid                 string               `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Emitter              string               `protobuf:"bytes,2,opt,name=emitter,proto3" json:"emitter,omitempty"`
+	Committed            *timestamp.Timestamp `protobuf:"bytes,3,opt,name=committed,proto3" json:"committed,omitempty"`
+	Modified             *timestamp.Timestamp `protobuf:"bytes,4,opt,name=modified,proto3" json:"modified,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Provenance) Reset()         { *m = Provenance{} }
+func (m *Provenance) String() string { return proto.CompactTextString(m) }
+func (*Provenance) ProtoMessage()    {}
+func (*Provenance) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{1}
+}
+func (m *Provenance) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Provenance.Unmarshal(m, b)
+}
+func (m *Provenance) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Provenance.Marshal(b, m, deterministic)
+}
+func (dst *Provenance) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Provenance.Merge(dst, src)
+}
+func (m *Provenance) XXX_Size() int {
+	return xxx_messageInfo_Provenance.Size(m)
+}
+func (m *Provenance) XXX_DiscardUnknown() {
+	xxx_messageInfo_Provenance.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Provenance proto.InternalMessageInfo
+
+func (m *Provenance) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *Provenance) GetEmitter() string {
+	if m != nil {
+		return m.Emitter
+	}
+	return ""
+}
+
+func (m *Provenance) GetCommitted() *timestamp.Timestamp {
+	if m != nil {
+		return m.Committed
+	}
+	return nil
+}
+
+func (m *Provenance) GetModified() *timestamp.Timestamp {
+	if m != nil {
+		return m.Modified
+	}
+	return nil
+}
+
 type Query struct {
 	Uuid                 string               `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
 	Policy               string               `protobuf:"bytes,2,opt,name=policy,proto3" json:"policy,omitempty"`
@@ -112,7 +253,7 @@ func (m *Query) Reset()         { *m = Query{} }
 func (m *Query) String() string { return proto.CompactTextString(m) }
 func (*Query) ProtoMessage()    {}
 func (*Query) Descriptor() ([]byte, []int) {
-	return fileDescriptor_structures_08f3d7062cc30271, []int{1}
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{2}
 }
 func (m *Query) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Query.Unmarshal(m, b)
@@ -182,20 +323,22 @@ func (m *Query) GetSignature() []byte {
 }
 
 type Operation struct {
-	Key                  string       `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Op                   Operation_Op `protobuf:"varint,2,opt,name=op,proto3,enum=consensus.Operation_Op" json:"op,omitempty"`
-	Data                 []byte       `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
-	Metadata             []byte       `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
-	XXX_unrecognized     []byte       `json:"-"`
-	XXX_sizecache        int32        `json:"-"`
+	Key                  string             `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Op                   Operation_Op       `protobuf:"varint,2,opt,name=op,proto3,enum=consensus.Operation_Op" json:"op,omitempty"`
+	Data                 []byte             `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Metadata             []byte             `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Nonce                []byte             `protobuf:"bytes,5,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Ttl                  *duration.Duration `protobuf:"bytes,6,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
 }
 
 func (m *Operation) Reset()         { *m = Operation{} }
 func (m *Operation) String() string { return proto.CompactTextString(m) }
 func (*Operation) ProtoMessage()    {}
 func (*Operation) Descriptor() ([]byte, []int) {
-	return fileDescriptor_structures_08f3d7062cc30271, []int{2}
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{3}
 }
 func (m *Operation) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Operation.Unmarshal(m, b)
@@ -243,10 +386,25 @@ func (m *Operation) GetMetadata() []byte {
 	return nil
 }
 
+func (m *Operation) GetNonce() []byte {
+	if m != nil {
+		return m.Nonce
+	}
+	return nil
+}
+
+func (m *Operation) GetTtl() *duration.Duration {
+	if m != nil {
+		return m.Ttl
+	}
+	return nil
+}
+
 type Endorsement struct {
 	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
 	Emitter              string   `protobuf:"bytes,2,opt,name=emitter,proto3" json:"emitter,omitempty"`
 	Conditions           []string `protobuf:"bytes,3,rep,name=conditions,proto3" json:"conditions,omitempty"`
+	Follows              []string `protobuf:"bytes,4,rep,name=follows,proto3" json:"follows,omitempty"`
 	Signature            []byte   `protobuf:"bytes,16,opt,name=signature,proto3" json:"signature,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -257,7 +415,7 @@ func (m *Endorsement) Reset()         { *m = Endorsement{} }
 func (m *Endorsement) String() string { return proto.CompactTextString(m) }
 func (*Endorsement) ProtoMessage()    {}
 func (*Endorsement) Descriptor() ([]byte, []int) {
-	return fileDescriptor_structures_08f3d7062cc30271, []int{3}
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{4}
 }
 func (m *Endorsement) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Endorsement.Unmarshal(m, b)
@@ -298,6 +456,13 @@ func (m *Endorsement) GetConditions() []string {
 	return nil
 }
 
+func (m *Endorsement) GetFollows() []string {
+	if m != nil {
+		return m.Follows
+	}
+	return nil
+}
+
 func (m *Endorsement) GetSignature() []byte {
 	if m != nil {
 		return m.Signature
@@ -305,8 +470,58 @@ func (m *Endorsement) GetSignature() []byte {
 	return nil
 }
 
+// AggregatedEndorsement gathers endorsements of a single query whose
+// signatures have been aggregated, which requires a crypto engine able to do
+// so (see keyring.KeyRing.CanAggregate). The endorsements are not signed.
+type AggregatedEndorsement struct {
+	Endorsements         []*Endorsement `protobuf:"bytes,1,rep,name=endorsements,proto3" json:"endorsements,omitempty"`
+	Signature            []byte         `protobuf:"bytes,16,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *AggregatedEndorsement) Reset()         { *m = AggregatedEndorsement{} }
+func (m *AggregatedEndorsement) String() string { return proto.CompactTextString(m) }
+func (*AggregatedEndorsement) ProtoMessage()    {}
+func (*AggregatedEndorsement) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{5}
+}
+func (m *AggregatedEndorsement) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AggregatedEndorsement.Unmarshal(m, b)
+}
+func (m *AggregatedEndorsement) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AggregatedEndorsement.Marshal(b, m, deterministic)
+}
+func (dst *AggregatedEndorsement) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AggregatedEndorsement.Merge(dst, src)
+}
+func (m *AggregatedEndorsement) XXX_Size() int {
+	return xxx_messageInfo_AggregatedEndorsement.Size(m)
+}
+func (m *AggregatedEndorsement) XXX_DiscardUnknown() {
+	xxx_messageInfo_AggregatedEndorsement.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AggregatedEndorsement proto.InternalMessageInfo
+
+func (m *AggregatedEndorsement) GetEndorsements() []*Endorsement {
+	if m != nil {
+		return m.Endorsements
+	}
+	return nil
+}
+
+func (m *AggregatedEndorsement) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
 type StartCheckpoint struct {
 	Queries              []string `protobuf:"bytes,1,rep,name=queries,proto3" json:"queries,omitempty"`
+	Epoch                uint64   `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -316,7 +531,7 @@ func (m *StartCheckpoint) Reset()         { *m = StartCheckpoint{} }
 func (m *StartCheckpoint) String() string { return proto.CompactTextString(m) }
 func (*StartCheckpoint) ProtoMessage()    {}
 func (*StartCheckpoint) Descriptor() ([]byte, []int) {
-	return fileDescriptor_structures_08f3d7062cc30271, []int{4}
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{6}
 }
 func (m *StartCheckpoint) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_StartCheckpoint.Unmarshal(m, b)
@@ -343,10 +558,20 @@ func (m *StartCheckpoint) GetQueries() []string {
 	return nil
 }
 
+func (m *StartCheckpoint) GetEpoch() uint64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
 type Proof struct {
 	// Types that are valid to be assigned to Content:
 	//	*Proof_Query
 	//	*Proof_Endorsement
+	//	*Proof_Aggregate
+	//	*Proof_Summary
+	//	*Proof_Vote
 	Content              isProof_Content `protobuf_oneof:"content"`
 	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
 	XXX_unrecognized     []byte          `json:"-"`
@@ -357,7 +582,7 @@ func (m *Proof) Reset()         { *m = Proof{} }
 func (m *Proof) String() string { return proto.CompactTextString(m) }
 func (*Proof) ProtoMessage()    {}
 func (*Proof) Descriptor() ([]byte, []int) {
-	return fileDescriptor_structures_08f3d7062cc30271, []int{5}
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{7}
 }
 func (m *Proof) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Proof.Unmarshal(m, b)
@@ -389,10 +614,28 @@ type Proof_Endorsement struct {
 	Endorsement *Endorsement `protobuf:"bytes,2,opt,name=endorsement,proto3,oneof"`
 }
 
+type Proof_Aggregate struct {
+	Aggregate *AggregatedEndorsement `protobuf:"bytes,3,opt,name=aggregate,proto3,oneof"`
+}
+
+type Proof_Summary struct {
+	Summary *ProofSummary `protobuf:"bytes,4,opt,name=summary,proto3,oneof"`
+}
+
+type Proof_Vote struct {
+	Vote *Vote `protobuf:"bytes,5,opt,name=vote,proto3,oneof"`
+}
+
 func (*Proof_Query) isProof_Content() {}
 
 func (*Proof_Endorsement) isProof_Content() {}
 
+func (*Proof_Aggregate) isProof_Content() {}
+
+func (*Proof_Summary) isProof_Content() {}
+
+func (*Proof_Vote) isProof_Content() {}
+
 func (m *Proof) GetContent() isProof_Content {
 	if m != nil {
 		return m.Content
@@ -414,11 +657,35 @@ func (m *Proof) GetEndorsement() *Endorsement {
 	return nil
 }
 
+func (m *Proof) GetAggregate() *AggregatedEndorsement {
+	if x, ok := m.GetContent().(*Proof_Aggregate); ok {
+		return x.Aggregate
+	}
+	return nil
+}
+
+func (m *Proof) GetSummary() *ProofSummary {
+	if x, ok := m.GetContent().(*Proof_Summary); ok {
+		return x.Summary
+	}
+	return nil
+}
+
+func (m *Proof) GetVote() *Vote {
+	if x, ok := m.GetContent().(*Proof_Vote); ok {
+		return x.Vote
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*Proof) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _Proof_OneofMarshaler, _Proof_OneofUnmarshaler, _Proof_OneofSizer, []interface{}{
 		(*Proof_Query)(nil),
 		(*Proof_Endorsement)(nil),
+		(*Proof_Aggregate)(nil),
+		(*Proof_Summary)(nil),
+		(*Proof_Vote)(nil),
 	}
 }
 
@@ -436,6 +703,21 @@ func _Proof_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.Endorsement); err != nil {
 			return err
 		}
+	case *Proof_Aggregate:
+		b.EncodeVarint(3<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Aggregate); err != nil {
+			return err
+		}
+	case *Proof_Summary:
+		b.EncodeVarint(4<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Summary); err != nil {
+			return err
+		}
+	case *Proof_Vote:
+		b.EncodeVarint(5<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Vote); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("Proof.Content has unexpected type %T", x)
@@ -462,6 +744,30 @@ func _Proof_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer)
 		err := b.DecodeMessage(msg)
 		m.Content = &Proof_Endorsement{msg}
 		return true, err
+	case 3: // content.aggregate
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(AggregatedEndorsement)
+		err := b.DecodeMessage(msg)
+		m.Content = &Proof_Aggregate{msg}
+		return true, err
+	case 4: // content.summary
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(ProofSummary)
+		err := b.DecodeMessage(msg)
+		m.Content = &Proof_Summary{msg}
+		return true, err
+	case 5: // content.vote
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Vote)
+		err := b.DecodeMessage(msg)
+		m.Content = &Proof_Vote{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -481,6 +787,21 @@ func _Proof_OneofSizer(msg proto.Message) (n int) {
 		n += 1 // tag and wire
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *Proof_Aggregate:
+		s := proto.Size(x.Aggregate)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Proof_Summary:
+		s := proto.Size(x.Summary)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Proof_Vote:
+		s := proto.Size(x.Vote)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -488,151 +809,1355 @@ func _Proof_OneofSizer(msg proto.Message) (n int) {
 	return n
 }
 
-type RecoveryRequest struct {
-	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+// ProofSummary replaces the proofs of a veto when they are large: the
+// receivers fetch the query and the listed endorsements from their peers
+// (see PendingSyncRequest). Hashes are the SHA-256 of the signatures of the
+// endorsements, or of their aggregate, in the order of emitters.
+type ProofSummary struct {
+	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Emitters             []string `protobuf:"bytes,2,rep,name=emitters,proto3" json:"emitters,omitempty"`
+	Hashes               [][]byte `protobuf:"bytes,3,rep,name=hashes,proto3" json:"hashes,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *RecoveryRequest) Reset()         { *m = RecoveryRequest{} }
-func (m *RecoveryRequest) String() string { return proto.CompactTextString(m) }
-func (*RecoveryRequest) ProtoMessage()    {}
-func (*RecoveryRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_structures_08f3d7062cc30271, []int{6}
+func (m *ProofSummary) Reset()         { *m = ProofSummary{} }
+func (m *ProofSummary) String() string { return proto.CompactTextString(m) }
+func (*ProofSummary) ProtoMessage()    {}
+func (*ProofSummary) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{8}
 }
-func (m *RecoveryRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_RecoveryRequest.Unmarshal(m, b)
+func (m *ProofSummary) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProofSummary.Unmarshal(m, b)
 }
-func (m *RecoveryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_RecoveryRequest.Marshal(b, m, deterministic)
+func (m *ProofSummary) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProofSummary.Marshal(b, m, deterministic)
 }
-func (dst *RecoveryRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_RecoveryRequest.Merge(dst, src)
+func (dst *ProofSummary) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProofSummary.Merge(dst, src)
 }
-func (m *RecoveryRequest) XXX_Size() int {
-	return xxx_messageInfo_RecoveryRequest.Size(m)
+func (m *ProofSummary) XXX_Size() int {
+	return xxx_messageInfo_ProofSummary.Size(m)
 }
-func (m *RecoveryRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_RecoveryRequest.DiscardUnknown(m)
+func (m *ProofSummary) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProofSummary.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_RecoveryRequest proto.InternalMessageInfo
+var xxx_messageInfo_ProofSummary proto.InternalMessageInfo
 
-func (m *RecoveryRequest) GetKey() string {
+func (m *ProofSummary) GetUuid() string {
 	if m != nil {
-		return m.Key
+		return m.Uuid
 	}
 	return ""
 }
 
-type RecoveryResponse struct {
-	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Version              *Version `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
-	Data                 []byte   `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+func (m *ProofSummary) GetEmitters() []string {
+	if m != nil {
+		return m.Emitters
+	}
+	return nil
+}
+
+func (m *ProofSummary) GetHashes() [][]byte {
+	if m != nil {
+		return m.Hashes
+	}
+	return nil
+}
+
+// Vote is the signed choice of a node in a checkpoint decided by a threshold
+// of matching votes (see package bbc/threshold). Votes against a checkpoint
+// carry proofs, like vetoes, and the votes reaching the threshold are the
+// proof of the decision.
+type Vote struct {
+	Identifier           string   `protobuf:"bytes,1,opt,name=identifier,proto3" json:"identifier,omitempty"`
+	Emitter              string   `protobuf:"bytes,2,opt,name=emitter,proto3" json:"emitter,omitempty"`
+	Choice               bool     `protobuf:"varint,3,opt,name=choice,proto3" json:"choice,omitempty"`
+	Proofs               []*Proof `protobuf:"bytes,4,rep,name=proofs,proto3" json:"proofs,omitempty"`
+	Signature            []byte   `protobuf:"bytes,16,opt,name=signature,proto3" json:"signature,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *RecoveryResponse) Reset()         { *m = RecoveryResponse{} }
-func (m *RecoveryResponse) String() string { return proto.CompactTextString(m) }
-func (*RecoveryResponse) ProtoMessage()    {}
-func (*RecoveryResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_structures_08f3d7062cc30271, []int{7}
+func (m *Vote) Reset()         { *m = Vote{} }
+func (m *Vote) String() string { return proto.CompactTextString(m) }
+func (*Vote) ProtoMessage()    {}
+func (*Vote) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{9}
 }
-func (m *RecoveryResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_RecoveryResponse.Unmarshal(m, b)
+func (m *Vote) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Vote.Unmarshal(m, b)
 }
-func (m *RecoveryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_RecoveryResponse.Marshal(b, m, deterministic)
+func (m *Vote) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Vote.Marshal(b, m, deterministic)
 }
-func (dst *RecoveryResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_RecoveryResponse.Merge(dst, src)
+func (dst *Vote) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Vote.Merge(dst, src)
 }
-func (m *RecoveryResponse) XXX_Size() int {
-	return xxx_messageInfo_RecoveryResponse.Size(m)
+func (m *Vote) XXX_Size() int {
+	return xxx_messageInfo_Vote.Size(m)
 }
-func (m *RecoveryResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_RecoveryResponse.DiscardUnknown(m)
+func (m *Vote) XXX_DiscardUnknown() {
+	xxx_messageInfo_Vote.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_RecoveryResponse proto.InternalMessageInfo
+var xxx_messageInfo_Vote proto.InternalMessageInfo
 
-func (m *RecoveryResponse) GetKey() string {
+func (m *Vote) GetIdentifier() string {
 	if m != nil {
-		return m.Key
+		return m.Identifier
 	}
 	return ""
 }
 
-func (m *RecoveryResponse) GetVersion() *Version {
+func (m *Vote) GetEmitter() string {
 	if m != nil {
-		return m.Version
+		return m.Emitter
+	}
+	return ""
+}
+
+func (m *Vote) GetChoice() bool {
+	if m != nil {
+		return m.Choice
+	}
+	return false
+}
+
+func (m *Vote) GetProofs() []*Proof {
+	if m != nil {
+		return m.Proofs
 	}
 	return nil
 }
 
-func (m *RecoveryResponse) GetData() []byte {
+func (m *Vote) GetSignature() []byte {
 	if m != nil {
-		return m.Data
+		return m.Signature
+	}
+	return nil
+}
+
+// CheckpointResult is the signed decision of a checkpoint, sent back by the
+// nodes having decided it when the checkpoint is started again, so that the
+// nodes which missed it apply the decision without running it again (see
+// Engine.CheckpointQuorum).
+type CheckpointResult struct {
+	Epoch                uint64   `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Queries              []string `protobuf:"bytes,2,rep,name=queries,proto3" json:"queries,omitempty"`
+	Emitter              string   `protobuf:"bytes,3,opt,name=emitter,proto3" json:"emitter,omitempty"`
+	Decision             bool     `protobuf:"varint,4,opt,name=decision,proto3" json:"decision,omitempty"`
+	Proofs               []*Proof `protobuf:"bytes,5,rep,name=proofs,proto3" json:"proofs,omitempty"`
+	Signature            []byte   `protobuf:"bytes,16,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CheckpointResult) Reset()         { *m = CheckpointResult{} }
+func (m *CheckpointResult) String() string { return proto.CompactTextString(m) }
+func (*CheckpointResult) ProtoMessage()    {}
+func (*CheckpointResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{10}
+}
+func (m *CheckpointResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckpointResult.Unmarshal(m, b)
+}
+func (m *CheckpointResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckpointResult.Marshal(b, m, deterministic)
+}
+func (dst *CheckpointResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckpointResult.Merge(dst, src)
+}
+func (m *CheckpointResult) XXX_Size() int {
+	return xxx_messageInfo_CheckpointResult.Size(m)
+}
+func (m *CheckpointResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckpointResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckpointResult proto.InternalMessageInfo
+
+func (m *CheckpointResult) GetEpoch() uint64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *CheckpointResult) GetQueries() []string {
+	if m != nil {
+		return m.Queries
+	}
+	return nil
+}
+
+func (m *CheckpointResult) GetEmitter() string {
+	if m != nil {
+		return m.Emitter
+	}
+	return ""
+}
+
+func (m *CheckpointResult) GetDecision() bool {
+	if m != nil {
+		return m.Decision
+	}
+	return false
+}
+
+func (m *CheckpointResult) GetProofs() []*Proof {
+	if m != nil {
+		return m.Proofs
+	}
+	return nil
+}
+
+func (m *CheckpointResult) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// AdminDrop asks every node to drop a pending query, as if a checkpoint had
+// decided against it. It must be signed by a quorum of administrators (see
+// Engine.Admins), each signing the statement without signatures.
+type AdminDrop struct {
+	Uuid                 string            `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Reason               string            `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Signatures           []*AdminSignature `protobuf:"bytes,3,rep,name=signatures,proto3" json:"signatures,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *AdminDrop) Reset()         { *m = AdminDrop{} }
+func (m *AdminDrop) String() string { return proto.CompactTextString(m) }
+func (*AdminDrop) ProtoMessage()    {}
+func (*AdminDrop) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{11}
+}
+func (m *AdminDrop) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AdminDrop.Unmarshal(m, b)
+}
+func (m *AdminDrop) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AdminDrop.Marshal(b, m, deterministic)
+}
+func (dst *AdminDrop) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AdminDrop.Merge(dst, src)
+}
+func (m *AdminDrop) XXX_Size() int {
+	return xxx_messageInfo_AdminDrop.Size(m)
+}
+func (m *AdminDrop) XXX_DiscardUnknown() {
+	xxx_messageInfo_AdminDrop.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AdminDrop proto.InternalMessageInfo
+
+func (m *AdminDrop) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *AdminDrop) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *AdminDrop) GetSignatures() []*AdminSignature {
+	if m != nil {
+		return m.Signatures
+	}
+	return nil
+}
+
+type AdminSignature struct {
+	Admin                string   `protobuf:"bytes,1,opt,name=admin,proto3" json:"admin,omitempty"`
+	Signature            []byte   `protobuf:"bytes,16,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AdminSignature) Reset()         { *m = AdminSignature{} }
+func (m *AdminSignature) String() string { return proto.CompactTextString(m) }
+func (*AdminSignature) ProtoMessage()    {}
+func (*AdminSignature) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{12}
+}
+func (m *AdminSignature) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AdminSignature.Unmarshal(m, b)
+}
+func (m *AdminSignature) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AdminSignature.Marshal(b, m, deterministic)
+}
+func (dst *AdminSignature) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AdminSignature.Merge(dst, src)
+}
+func (m *AdminSignature) XXX_Size() int {
+	return xxx_messageInfo_AdminSignature.Size(m)
+}
+func (m *AdminSignature) XXX_DiscardUnknown() {
+	xxx_messageInfo_AdminSignature.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AdminSignature proto.InternalMessageInfo
+
+func (m *AdminSignature) GetAdmin() string {
+	if m != nil {
+		return m.Admin
+	}
+	return ""
+}
+
+func (m *AdminSignature) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type RecoveryRequest struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RecoveryRequest) Reset()         { *m = RecoveryRequest{} }
+func (m *RecoveryRequest) String() string { return proto.CompactTextString(m) }
+func (*RecoveryRequest) ProtoMessage()    {}
+func (*RecoveryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{13}
+}
+func (m *RecoveryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecoveryRequest.Unmarshal(m, b)
+}
+func (m *RecoveryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecoveryRequest.Marshal(b, m, deterministic)
+}
+func (dst *RecoveryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecoveryRequest.Merge(dst, src)
+}
+func (m *RecoveryRequest) XXX_Size() int {
+	return xxx_messageInfo_RecoveryRequest.Size(m)
+}
+func (m *RecoveryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecoveryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecoveryRequest proto.InternalMessageInfo
+
+func (m *RecoveryRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+type RecoveryResponse struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Version              *Version `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Data                 []byte   `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RecoveryResponse) Reset()         { *m = RecoveryResponse{} }
+func (m *RecoveryResponse) String() string { return proto.CompactTextString(m) }
+func (*RecoveryResponse) ProtoMessage()    {}
+func (*RecoveryResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{14}
+}
+func (m *RecoveryResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecoveryResponse.Unmarshal(m, b)
+}
+func (m *RecoveryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecoveryResponse.Marshal(b, m, deterministic)
+}
+func (dst *RecoveryResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecoveryResponse.Merge(dst, src)
+}
+func (m *RecoveryResponse) XXX_Size() int {
+	return xxx_messageInfo_RecoveryResponse.Size(m)
+}
+func (m *RecoveryResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecoveryResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecoveryResponse proto.InternalMessageInfo
+
+func (m *RecoveryResponse) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *RecoveryResponse) GetVersion() *Version {
+	if m != nil {
+		return m.Version
+	}
+	return nil
+}
+
+func (m *RecoveryResponse) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+// PendingSyncRequest lists the pending queries of a peer when uuids is empty,
+// or fetches the listed queries along with their endorsements. The queries
+// are only returned while pending, unless proofs is set (see ProofSummary).
+type PendingSyncRequest struct {
+	Uuids                []string `protobuf:"bytes,1,rep,name=uuids,proto3" json:"uuids,omitempty"`
+	Proofs               bool     `protobuf:"varint,2,opt,name=proofs,proto3" json:"proofs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PendingSyncRequest) Reset()         { *m = PendingSyncRequest{} }
+func (m *PendingSyncRequest) String() string { return proto.CompactTextString(m) }
+func (*PendingSyncRequest) ProtoMessage()    {}
+func (*PendingSyncRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{15}
+}
+func (m *PendingSyncRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PendingSyncRequest.Unmarshal(m, b)
+}
+func (m *PendingSyncRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PendingSyncRequest.Marshal(b, m, deterministic)
+}
+func (dst *PendingSyncRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PendingSyncRequest.Merge(dst, src)
+}
+func (m *PendingSyncRequest) XXX_Size() int {
+	return xxx_messageInfo_PendingSyncRequest.Size(m)
+}
+func (m *PendingSyncRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PendingSyncRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PendingSyncRequest proto.InternalMessageInfo
+
+func (m *PendingSyncRequest) GetUuids() []string {
+	if m != nil {
+		return m.Uuids
+	}
+	return nil
+}
+
+func (m *PendingSyncRequest) GetProofs() bool {
+	if m != nil {
+		return m.Proofs
+	}
+	return false
+}
+
+type PendingSyncResponse struct {
+	Pending              []*PendingQuery          `protobuf:"bytes,1,rep,name=pending,proto3" json:"pending,omitempty"`
+	Queries              []*Query                 `protobuf:"bytes,2,rep,name=queries,proto3" json:"queries,omitempty"`
+	Endorsements         []*Endorsement           `protobuf:"bytes,3,rep,name=endorsements,proto3" json:"endorsements,omitempty"`
+	Aggregates           []*AggregatedEndorsement `protobuf:"bytes,4,rep,name=aggregates,proto3" json:"aggregates,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *PendingSyncResponse) Reset()         { *m = PendingSyncResponse{} }
+func (m *PendingSyncResponse) String() string { return proto.CompactTextString(m) }
+func (*PendingSyncResponse) ProtoMessage()    {}
+func (*PendingSyncResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{16}
+}
+func (m *PendingSyncResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PendingSyncResponse.Unmarshal(m, b)
+}
+func (m *PendingSyncResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PendingSyncResponse.Marshal(b, m, deterministic)
+}
+func (dst *PendingSyncResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PendingSyncResponse.Merge(dst, src)
+}
+func (m *PendingSyncResponse) XXX_Size() int {
+	return xxx_messageInfo_PendingSyncResponse.Size(m)
+}
+func (m *PendingSyncResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PendingSyncResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PendingSyncResponse proto.InternalMessageInfo
+
+func (m *PendingSyncResponse) GetPending() []*PendingQuery {
+	if m != nil {
+		return m.Pending
+	}
+	return nil
+}
+
+func (m *PendingSyncResponse) GetQueries() []*Query {
+	if m != nil {
+		return m.Queries
+	}
+	return nil
+}
+
+func (m *PendingSyncResponse) GetEndorsements() []*Endorsement {
+	if m != nil {
+		return m.Endorsements
+	}
+	return nil
+}
+
+func (m *PendingSyncResponse) GetAggregates() []*AggregatedEndorsement {
+	if m != nil {
+		return m.Aggregates
+	}
+	return nil
+}
+
+type PendingQuery struct {
+	Uuid                 string               `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Deadline             *timestamp.Timestamp `protobuf:"bytes,2,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *PendingQuery) Reset()         { *m = PendingQuery{} }
+func (m *PendingQuery) String() string { return proto.CompactTextString(m) }
+func (*PendingQuery) ProtoMessage()    {}
+func (*PendingQuery) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{17}
+}
+func (m *PendingQuery) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PendingQuery.Unmarshal(m, b)
+}
+func (m *PendingQuery) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PendingQuery.Marshal(b, m, deterministic)
+}
+func (dst *PendingQuery) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PendingQuery.Merge(dst, src)
+}
+func (m *PendingQuery) XXX_Size() int {
+	return xxx_messageInfo_PendingQuery.Size(m)
+}
+func (m *PendingQuery) XXX_DiscardUnknown() {
+	xxx_messageInfo_PendingQuery.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PendingQuery proto.InternalMessageInfo
+
+func (m *PendingQuery) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *PendingQuery) GetDeadline() *timestamp.Timestamp {
+	if m != nil {
+		return m.Deadline
+	}
+	return nil
+}
+
+type CommitEvent struct {
+	Sequence             uint64               `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Uuid                 string               `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Emitter              string               `protobuf:"bytes,3,opt,name=emitter,proto3" json:"emitter,omitempty"`
+	Keys                 []string             `protobuf:"bytes,4,rep,name=keys,proto3" json:"keys,omitempty"`
+	Versions             []*Version           `protobuf:"bytes,5,rep,name=versions,proto3" json:"versions,omitempty"`
+	Time                 *timestamp.Timestamp `protobuf:"bytes,6,opt,name=time,proto3" json:"time,omitempty"`
+	Certificate          *CommitCertificate   `protobuf:"bytes,7,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *CommitEvent) Reset()         { *m = CommitEvent{} }
+func (m *CommitEvent) String() string { return proto.CompactTextString(m) }
+func (*CommitEvent) ProtoMessage()    {}
+func (*CommitEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{18}
+}
+func (m *CommitEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CommitEvent.Unmarshal(m, b)
+}
+func (m *CommitEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CommitEvent.Marshal(b, m, deterministic)
+}
+func (dst *CommitEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CommitEvent.Merge(dst, src)
+}
+func (m *CommitEvent) XXX_Size() int {
+	return xxx_messageInfo_CommitEvent.Size(m)
+}
+func (m *CommitEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_CommitEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CommitEvent proto.InternalMessageInfo
+
+func (m *CommitEvent) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *CommitEvent) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *CommitEvent) GetEmitter() string {
+	if m != nil {
+		return m.Emitter
+	}
+	return ""
+}
+
+func (m *CommitEvent) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+func (m *CommitEvent) GetVersions() []*Version {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+func (m *CommitEvent) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *CommitEvent) GetCertificate() *CommitCertificate {
+	if m != nil {
+		return m.Certificate
+	}
+	return nil
+}
+
+type CommitCertificate struct {
+	Query                *Query                   `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Endorsements         []*Endorsement           `protobuf:"bytes,2,rep,name=endorsements,proto3" json:"endorsements,omitempty"`
+	Threshold            uint32                   `protobuf:"varint,3,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	Attester             string                   `protobuf:"bytes,4,opt,name=attester,proto3" json:"attester,omitempty"`
+	Time                 *timestamp.Timestamp     `protobuf:"bytes,5,opt,name=time,proto3" json:"time,omitempty"`
+	Aggregates           []*AggregatedEndorsement `protobuf:"bytes,6,rep,name=aggregates,proto3" json:"aggregates,omitempty"`
+	Signature            []byte                   `protobuf:"bytes,16,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *CommitCertificate) Reset()         { *m = CommitCertificate{} }
+func (m *CommitCertificate) String() string { return proto.CompactTextString(m) }
+func (*CommitCertificate) ProtoMessage()    {}
+func (*CommitCertificate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{19}
+}
+func (m *CommitCertificate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CommitCertificate.Unmarshal(m, b)
+}
+func (m *CommitCertificate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CommitCertificate.Marshal(b, m, deterministic)
+}
+func (dst *CommitCertificate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CommitCertificate.Merge(dst, src)
+}
+func (m *CommitCertificate) XXX_Size() int {
+	return xxx_messageInfo_CommitCertificate.Size(m)
+}
+func (m *CommitCertificate) XXX_DiscardUnknown() {
+	xxx_messageInfo_CommitCertificate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CommitCertificate proto.InternalMessageInfo
+
+func (m *CommitCertificate) GetQuery() *Query {
+	if m != nil {
+		return m.Query
+	}
+	return nil
+}
+
+func (m *CommitCertificate) GetEndorsements() []*Endorsement {
+	if m != nil {
+		return m.Endorsements
+	}
+	return nil
+}
+
+func (m *CommitCertificate) GetThreshold() uint32 {
+	if m != nil {
+		return m.Threshold
+	}
+	return 0
+}
+
+func (m *CommitCertificate) GetAttester() string {
+	if m != nil {
+		return m.Attester
+	}
+	return ""
+}
+
+func (m *CommitCertificate) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *CommitCertificate) GetAggregates() []*AggregatedEndorsement {
+	if m != nil {
+		return m.Aggregates
+	}
+	return nil
+}
+
+func (m *CommitCertificate) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type TimeBeacon struct {
+	Emitter              string               `protobuf:"bytes,1,opt,name=emitter,proto3" json:"emitter,omitempty"`
+	Time                 *timestamp.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	Signature            []byte               `protobuf:"bytes,16,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *TimeBeacon) Reset()         { *m = TimeBeacon{} }
+func (m *TimeBeacon) String() string { return proto.CompactTextString(m) }
+func (*TimeBeacon) ProtoMessage()    {}
+func (*TimeBeacon) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{20}
+}
+func (m *TimeBeacon) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TimeBeacon.Unmarshal(m, b)
+}
+func (m *TimeBeacon) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TimeBeacon.Marshal(b, m, deterministic)
+}
+func (dst *TimeBeacon) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TimeBeacon.Merge(dst, src)
+}
+func (m *TimeBeacon) XXX_Size() int {
+	return xxx_messageInfo_TimeBeacon.Size(m)
+}
+func (m *TimeBeacon) XXX_DiscardUnknown() {
+	xxx_messageInfo_TimeBeacon.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TimeBeacon proto.InternalMessageInfo
+
+func (m *TimeBeacon) GetEmitter() string {
+	if m != nil {
+		return m.Emitter
+	}
+	return ""
+}
+
+func (m *TimeBeacon) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *TimeBeacon) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// NodeStatus is periodically broadcast by nodes to report their resource
+// usage, see Engine.ClusterStatus.
+type NodeStatus struct {
+	Emitter              string               `protobuf:"bytes,1,opt,name=emitter,proto3" json:"emitter,omitempty"`
+	Time                 *timestamp.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	Version              string               `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	Pending              uint32               `protobuf:"varint,4,opt,name=pending,proto3" json:"pending,omitempty"`
+	Keys                 uint64               `protobuf:"varint,5,opt,name=keys,proto3" json:"keys,omitempty"`
+	LastCommit           *timestamp.Timestamp `protobuf:"bytes,6,opt,name=last_commit,json=lastCommit,proto3" json:"last_commit,omitempty"`
+	Uptime               uint64               `protobuf:"varint,7,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	PendingCheckpoints   uint32               `protobuf:"varint,8,opt,name=pending_checkpoints,json=pendingCheckpoints,proto3" json:"pending_checkpoints,omitempty"`
+	Signature            []byte               `protobuf:"bytes,16,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *NodeStatus) Reset()         { *m = NodeStatus{} }
+func (m *NodeStatus) String() string { return proto.CompactTextString(m) }
+func (*NodeStatus) ProtoMessage()    {}
+func (*NodeStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{21}
+}
+func (m *NodeStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeStatus.Unmarshal(m, b)
+}
+func (m *NodeStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeStatus.Marshal(b, m, deterministic)
+}
+func (dst *NodeStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeStatus.Merge(dst, src)
+}
+func (m *NodeStatus) XXX_Size() int {
+	return xxx_messageInfo_NodeStatus.Size(m)
+}
+func (m *NodeStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeStatus proto.InternalMessageInfo
+
+func (m *NodeStatus) GetEmitter() string {
+	if m != nil {
+		return m.Emitter
+	}
+	return ""
+}
+
+func (m *NodeStatus) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *NodeStatus) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *NodeStatus) GetPending() uint32 {
+	if m != nil {
+		return m.Pending
+	}
+	return 0
+}
+
+func (m *NodeStatus) GetKeys() uint64 {
+	if m != nil {
+		return m.Keys
+	}
+	return 0
+}
+
+func (m *NodeStatus) GetLastCommit() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastCommit
+	}
+	return nil
+}
+
+func (m *NodeStatus) GetUptime() uint64 {
+	if m != nil {
+		return m.Uptime
+	}
+	return 0
+}
+
+func (m *NodeStatus) GetPendingCheckpoints() uint32 {
+	if m != nil {
+		return m.PendingCheckpoints
+	}
+	return 0
+}
+
+func (m *NodeStatus) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// EndorsementRecords holds the endorsements emitted by the node for some
+// queries, see recordEndorsement.
+type EndorsementRecords struct {
+	Records              []*EndorsementRecords_Record `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                     `json:"-"`
+	XXX_unrecognized     []byte                       `json:"-"`
+	XXX_sizecache        int32                        `json:"-"`
+}
+
+func (m *EndorsementRecords) Reset()         { *m = EndorsementRecords{} }
+func (m *EndorsementRecords) String() string { return proto.CompactTextString(m) }
+func (*EndorsementRecords) ProtoMessage()    {}
+func (*EndorsementRecords) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{22}
+}
+func (m *EndorsementRecords) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EndorsementRecords.Unmarshal(m, b)
+}
+func (m *EndorsementRecords) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EndorsementRecords.Marshal(b, m, deterministic)
+}
+func (dst *EndorsementRecords) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EndorsementRecords.Merge(dst, src)
+}
+func (m *EndorsementRecords) XXX_Size() int {
+	return xxx_messageInfo_EndorsementRecords.Size(m)
+}
+func (m *EndorsementRecords) XXX_DiscardUnknown() {
+	xxx_messageInfo_EndorsementRecords.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EndorsementRecords proto.InternalMessageInfo
+
+func (m *EndorsementRecords) GetRecords() []*EndorsementRecords_Record {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+type EndorsementRecords_Record struct {
+	Uuid                 string               `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Deadline             *timestamp.Timestamp `protobuf:"bytes,2,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	Hash                 []byte               `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *EndorsementRecords_Record) Reset()         { *m = EndorsementRecords_Record{} }
+func (m *EndorsementRecords_Record) String() string { return proto.CompactTextString(m) }
+func (*EndorsementRecords_Record) ProtoMessage()    {}
+func (*EndorsementRecords_Record) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{22, 0}
+}
+func (m *EndorsementRecords_Record) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EndorsementRecords_Record.Unmarshal(m, b)
+}
+func (m *EndorsementRecords_Record) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EndorsementRecords_Record.Marshal(b, m, deterministic)
+}
+func (dst *EndorsementRecords_Record) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EndorsementRecords_Record.Merge(dst, src)
+}
+func (m *EndorsementRecords_Record) XXX_Size() int {
+	return xxx_messageInfo_EndorsementRecords_Record.Size(m)
+}
+func (m *EndorsementRecords_Record) XXX_DiscardUnknown() {
+	xxx_messageInfo_EndorsementRecords_Record.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EndorsementRecords_Record proto.InternalMessageInfo
+
+func (m *EndorsementRecords_Record) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *EndorsementRecords_Record) GetDeadline() *timestamp.Timestamp {
+	if m != nil {
+		return m.Deadline
+	}
+	return nil
+}
+
+func (m *EndorsementRecords_Record) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+// AppliedMarkers holds the queries already applied by the node, see
+// Engine.apply.
+type AppliedMarkers struct {
+	Markers              []*AppliedMarkers_Marker `protobuf:"bytes,1,rep,name=markers,proto3" json:"markers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *AppliedMarkers) Reset()         { *m = AppliedMarkers{} }
+func (m *AppliedMarkers) String() string { return proto.CompactTextString(m) }
+func (*AppliedMarkers) ProtoMessage()    {}
+func (*AppliedMarkers) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{23}
+}
+func (m *AppliedMarkers) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AppliedMarkers.Unmarshal(m, b)
+}
+func (m *AppliedMarkers) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AppliedMarkers.Marshal(b, m, deterministic)
+}
+func (dst *AppliedMarkers) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AppliedMarkers.Merge(dst, src)
+}
+func (m *AppliedMarkers) XXX_Size() int {
+	return xxx_messageInfo_AppliedMarkers.Size(m)
+}
+func (m *AppliedMarkers) XXX_DiscardUnknown() {
+	xxx_messageInfo_AppliedMarkers.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AppliedMarkers proto.InternalMessageInfo
+
+func (m *AppliedMarkers) GetMarkers() []*AppliedMarkers_Marker {
+	if m != nil {
+		return m.Markers
+	}
+	return nil
+}
+
+type AppliedMarkers_Marker struct {
+	Uuid                 string               `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Deadline             *timestamp.Timestamp `protobuf:"bytes,2,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *AppliedMarkers_Marker) Reset()         { *m = AppliedMarkers_Marker{} }
+func (m *AppliedMarkers_Marker) String() string { return proto.CompactTextString(m) }
+func (*AppliedMarkers_Marker) ProtoMessage()    {}
+func (*AppliedMarkers_Marker) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{23, 0}
+}
+func (m *AppliedMarkers_Marker) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AppliedMarkers_Marker.Unmarshal(m, b)
+}
+func (m *AppliedMarkers_Marker) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AppliedMarkers_Marker.Marshal(b, m, deterministic)
+}
+func (dst *AppliedMarkers_Marker) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AppliedMarkers_Marker.Merge(dst, src)
+}
+func (m *AppliedMarkers_Marker) XXX_Size() int {
+	return xxx_messageInfo_AppliedMarkers_Marker.Size(m)
+}
+func (m *AppliedMarkers_Marker) XXX_DiscardUnknown() {
+	xxx_messageInfo_AppliedMarkers_Marker.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AppliedMarkers_Marker proto.InternalMessageInfo
+
+func (m *AppliedMarkers_Marker) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *AppliedMarkers_Marker) GetDeadline() *timestamp.Timestamp {
+	if m != nil {
+		return m.Deadline
+	}
+	return nil
+}
+
+// DecidedQueries holds the queries committed or dropped by the node, see
+// DecidedPrefix.
+type DecidedQueries struct {
+	Decisions            []*DecidedQueries_Decision `protobuf:"bytes,1,rep,name=decisions,proto3" json:"decisions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *DecidedQueries) Reset()         { *m = DecidedQueries{} }
+func (m *DecidedQueries) String() string { return proto.CompactTextString(m) }
+func (*DecidedQueries) ProtoMessage()    {}
+func (*DecidedQueries) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{24}
+}
+func (m *DecidedQueries) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DecidedQueries.Unmarshal(m, b)
+}
+func (m *DecidedQueries) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DecidedQueries.Marshal(b, m, deterministic)
+}
+func (dst *DecidedQueries) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DecidedQueries.Merge(dst, src)
+}
+func (m *DecidedQueries) XXX_Size() int {
+	return xxx_messageInfo_DecidedQueries.Size(m)
+}
+func (m *DecidedQueries) XXX_DiscardUnknown() {
+	xxx_messageInfo_DecidedQueries.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DecidedQueries proto.InternalMessageInfo
+
+func (m *DecidedQueries) GetDecisions() []*DecidedQueries_Decision {
+	if m != nil {
+		return m.Decisions
+	}
+	return nil
+}
+
+type DecidedQueries_Decision struct {
+	Uuid                 string               `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Deadline             *timestamp.Timestamp `protobuf:"bytes,2,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	Committed            bool                 `protobuf:"varint,3,opt,name=committed,proto3" json:"committed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *DecidedQueries_Decision) Reset()         { *m = DecidedQueries_Decision{} }
+func (m *DecidedQueries_Decision) String() string { return proto.CompactTextString(m) }
+func (*DecidedQueries_Decision) ProtoMessage()    {}
+func (*DecidedQueries_Decision) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{24, 0}
+}
+func (m *DecidedQueries_Decision) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DecidedQueries_Decision.Unmarshal(m, b)
+}
+func (m *DecidedQueries_Decision) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DecidedQueries_Decision.Marshal(b, m, deterministic)
+}
+func (dst *DecidedQueries_Decision) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DecidedQueries_Decision.Merge(dst, src)
+}
+func (m *DecidedQueries_Decision) XXX_Size() int {
+	return xxx_messageInfo_DecidedQueries_Decision.Size(m)
+}
+func (m *DecidedQueries_Decision) XXX_DiscardUnknown() {
+	xxx_messageInfo_DecidedQueries_Decision.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DecidedQueries_Decision proto.InternalMessageInfo
+
+func (m *DecidedQueries_Decision) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *DecidedQueries_Decision) GetDeadline() *timestamp.Timestamp {
+	if m != nil {
+		return m.Deadline
+	}
+	return nil
+}
+
+func (m *DecidedQueries_Decision) GetCommitted() bool {
+	if m != nil {
+		return m.Committed
+	}
+	return false
+}
+
+// AppliedNonces holds the latest nonces applied to a key, oldest first, see
+// NoncePrefix.
+type AppliedNonces struct {
+	Nonces               [][]byte `protobuf:"bytes,1,rep,name=nonces,proto3" json:"nonces,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AppliedNonces) Reset()         { *m = AppliedNonces{} }
+func (m *AppliedNonces) String() string { return proto.CompactTextString(m) }
+func (*AppliedNonces) ProtoMessage()    {}
+func (*AppliedNonces) Descriptor() ([]byte, []int) {
+	return fileDescriptor_structures_e6dae78a35d7771f, []int{25}
+}
+func (m *AppliedNonces) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AppliedNonces.Unmarshal(m, b)
+}
+func (m *AppliedNonces) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AppliedNonces.Marshal(b, m, deterministic)
+}
+func (dst *AppliedNonces) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AppliedNonces.Merge(dst, src)
+}
+func (m *AppliedNonces) XXX_Size() int {
+	return xxx_messageInfo_AppliedNonces.Size(m)
+}
+func (m *AppliedNonces) XXX_DiscardUnknown() {
+	xxx_messageInfo_AppliedNonces.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AppliedNonces proto.InternalMessageInfo
+
+func (m *AppliedNonces) GetNonces() [][]byte {
+	if m != nil {
+		return m.Nonces
 	}
 	return nil
 }
 
 func init() {
 	proto.RegisterType((*Version)(nil), "consensus.Version")
+	proto.RegisterType((*Provenance)(nil), "consensus.Provenance")
 	proto.RegisterType((*Query)(nil), "consensus.Query")
 	proto.RegisterMapType((map[string]*Version)(nil), "consensus.Query.RequirementsEntry")
 	proto.RegisterType((*Operation)(nil), "consensus.Operation")
 	proto.RegisterType((*Endorsement)(nil), "consensus.Endorsement")
+	proto.RegisterType((*AggregatedEndorsement)(nil), "consensus.AggregatedEndorsement")
 	proto.RegisterType((*StartCheckpoint)(nil), "consensus.StartCheckpoint")
 	proto.RegisterType((*Proof)(nil), "consensus.Proof")
+	proto.RegisterType((*ProofSummary)(nil), "consensus.ProofSummary")
+	proto.RegisterType((*Vote)(nil), "consensus.Vote")
+	proto.RegisterType((*CheckpointResult)(nil), "consensus.CheckpointResult")
+	proto.RegisterType((*AdminDrop)(nil), "consensus.AdminDrop")
+	proto.RegisterType((*AdminSignature)(nil), "consensus.AdminSignature")
 	proto.RegisterType((*RecoveryRequest)(nil), "consensus.RecoveryRequest")
 	proto.RegisterType((*RecoveryResponse)(nil), "consensus.RecoveryResponse")
+	proto.RegisterType((*PendingSyncRequest)(nil), "consensus.PendingSyncRequest")
+	proto.RegisterType((*PendingSyncResponse)(nil), "consensus.PendingSyncResponse")
+	proto.RegisterType((*PendingQuery)(nil), "consensus.PendingQuery")
+	proto.RegisterType((*CommitEvent)(nil), "consensus.CommitEvent")
+	proto.RegisterType((*CommitCertificate)(nil), "consensus.CommitCertificate")
+	proto.RegisterType((*TimeBeacon)(nil), "consensus.TimeBeacon")
+	proto.RegisterType((*NodeStatus)(nil), "consensus.NodeStatus")
+	proto.RegisterType((*EndorsementRecords)(nil), "consensus.EndorsementRecords")
+	proto.RegisterType((*EndorsementRecords_Record)(nil), "consensus.EndorsementRecords.Record")
+	proto.RegisterType((*AppliedMarkers)(nil), "consensus.AppliedMarkers")
+	proto.RegisterType((*AppliedMarkers_Marker)(nil), "consensus.AppliedMarkers.Marker")
+	proto.RegisterType((*DecidedQueries)(nil), "consensus.DecidedQueries")
+	proto.RegisterType((*DecidedQueries_Decision)(nil), "consensus.DecidedQueries.Decision")
+	proto.RegisterType((*AppliedNonces)(nil), "consensus.AppliedNonces")
 	proto.RegisterEnum("consensus.Operation_Op", Operation_Op_name, Operation_Op_value)
 }
 
 func init() {
-	proto.RegisterFile("consensus/structures.proto", fileDescriptor_structures_08f3d7062cc30271)
-}
-
-var fileDescriptor_structures_08f3d7062cc30271 = []byte{
-	// 561 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x53, 0x5d, 0x4f, 0xd4, 0x40,
-	0x14, 0xa5, 0xed, 0x7e, 0xd0, 0x5b, 0x22, 0x75, 0x82, 0xd8, 0x6c, 0xfc, 0xd8, 0xd4, 0x07, 0x37,
-	0xd1, 0x94, 0x64, 0x35, 0xc6, 0xf0, 0x62, 0x10, 0xd6, 0xf0, 0x20, 0xae, 0xce, 0xa2, 0xef, 0xa5,
-	0xbd, 0xc0, 0x84, 0xdd, 0x99, 0x32, 0x33, 0x25, 0xf6, 0x97, 0xf9, 0xcf, 0x7c, 0x36, 0x33, 0xdd,
-	0x2e, 0x45, 0x36, 0xbc, 0xdd, 0x39, 0xf7, 0xf4, 0x7e, 0x9d, 0x53, 0x18, 0x64, 0x82, 0x2b, 0xe4,
-	0xaa, 0x54, 0x7b, 0x4a, 0xcb, 0x32, 0xd3, 0xa5, 0x44, 0x95, 0x14, 0x52, 0x68, 0x41, 0xfc, 0x55,
-	0x6e, 0xf0, 0xf2, 0x42, 0x88, 0x8b, 0x39, 0xee, 0xd9, 0xc4, 0x59, 0x79, 0xbe, 0xa7, 0xd9, 0x02,
-	0x95, 0x4e, 0x17, 0x45, 0xcd, 0x8d, 0x9f, 0x43, 0xff, 0x17, 0x4a, 0xc5, 0x04, 0x27, 0x04, 0x3a,
-	0x97, 0xa9, 0xba, 0x8c, 0x9c, 0xa1, 0x33, 0xda, 0xa2, 0x36, 0x8e, 0xff, 0xba, 0xd0, 0xfd, 0x51,
-	0xa2, 0xac, 0x4c, 0xb6, 0x2c, 0x59, 0x6e, 0xb3, 0x3e, 0xb5, 0x31, 0xd9, 0x85, 0x5e, 0x21, 0xe6,
-	0x2c, 0xab, 0x22, 0xd7, 0xa2, 0xcb, 0x17, 0x89, 0xa0, 0x8f, 0x0b, 0xa6, 0x35, 0xca, 0xc8, 0xb3,
-	0x89, 0xe6, 0x49, 0x3e, 0xc0, 0x66, 0x8e, 0x69, 0x3e, 0x67, 0x1c, 0xa3, 0xce, 0xd0, 0x19, 0x05,
-	0xe3, 0x41, 0x52, 0x8f, 0x98, 0x34, 0x23, 0x26, 0xa7, 0xcd, 0x88, 0x74, 0xc5, 0x25, 0x5f, 0x60,
-	0x4b, 0xe2, 0x75, 0xc9, 0x24, 0x2e, 0x90, 0x6b, 0x15, 0x75, 0x87, 0xde, 0x28, 0x18, 0xc7, 0xc9,
-	0x6a, 0xd3, 0xc4, 0x4e, 0x99, 0xd0, 0x16, 0x69, 0xc2, 0xb5, 0xac, 0xe8, 0x9d, 0xef, 0xc8, 0x7b,
-	0x00, 0x51, 0xa0, 0x4c, 0x35, 0x13, 0x5c, 0x45, 0x3d, 0x5b, 0x65, 0xa7, 0x55, 0x65, 0xda, 0x24,
-	0x69, 0x8b, 0x47, 0x9e, 0x81, 0xaf, 0xd8, 0x05, 0x4f, 0xcd, 0x91, 0xa3, 0xd0, 0x9e, 0xe7, 0x16,
-	0x18, 0xcc, 0xe0, 0xf1, 0xbd, 0xb6, 0x24, 0x04, 0xef, 0x0a, 0xab, 0xe5, 0xb5, 0x4c, 0x48, 0x46,
-	0xd0, 0xbd, 0x49, 0xe7, 0x25, 0xda, 0x5b, 0x05, 0x63, 0xd2, 0xea, 0xba, 0x54, 0x80, 0xd6, 0x84,
-	0x7d, 0xf7, 0xa3, 0x13, 0xff, 0x71, 0xc0, 0x5f, 0x0d, 0xb3, 0xa6, 0xda, 0x6b, 0x70, 0x45, 0x61,
-	0x4b, 0x3d, 0x1a, 0x3f, 0x5d, 0xb7, 0x40, 0x32, 0x2d, 0xa8, 0x2b, 0x0a, 0xa3, 0x5b, 0x9e, 0xea,
-	0xd4, 0x0a, 0xb1, 0x45, 0x6d, 0x4c, 0x06, 0xb0, 0xb9, 0x40, 0x9d, 0x5a, 0xbc, 0x63, 0xf1, 0xd5,
-	0x3b, 0xfe, 0x04, 0xee, 0xb4, 0x20, 0x7d, 0xf0, 0x66, 0x93, 0xd3, 0x70, 0x83, 0x00, 0xf4, 0x0e,
-	0xa7, 0xdf, 0x0e, 0x0f, 0x4e, 0x43, 0xc7, 0x80, 0x07, 0x47, 0x47, 0x21, 0x98, 0xe0, 0xe4, 0xe7,
-	0xd7, 0x30, 0x20, 0x9b, 0xd0, 0x99, 0x19, 0x68, 0xc7, 0x46, 0x74, 0x72, 0x12, 0x3e, 0x89, 0x2b,
-	0x08, 0x26, 0x3c, 0x17, 0x52, 0xd9, 0x73, 0xac, 0xf5, 0x4d, 0xcb, 0x1f, 0xee, 0x5d, 0x7f, 0xbc,
-	0x00, 0xc8, 0x04, 0xcf, 0x59, 0xad, 0x8f, 0x37, 0xf4, 0x46, 0x3e, 0x6d, 0x21, 0x0f, 0x2b, 0x11,
-	0xbf, 0x81, 0xed, 0x99, 0x4e, 0xa5, 0x3e, 0xbc, 0xc4, 0xec, 0xaa, 0x10, 0x8c, 0x6b, 0xd3, 0xea,
-	0xba, 0x44, 0xc9, 0x50, 0x45, 0x8e, 0xad, 0xd6, 0x3c, 0xe3, 0xdf, 0xd0, 0xfd, 0x2e, 0x85, 0x38,
-	0x37, 0xc2, 0x18, 0xac, 0x3e, 0x6f, 0x30, 0x0e, 0xff, 0x37, 0xd5, 0xf1, 0x06, 0xad, 0x09, 0x64,
-	0x1f, 0x02, 0xbc, 0x5d, 0x6d, 0x29, 0xe4, 0x6e, 0x8b, 0xdf, 0x5a, 0xfc, 0x78, 0x83, 0xb6, 0xc9,
-	0x9f, 0x7d, 0xe8, 0x67, 0x82, 0x6b, 0xe4, 0x3a, 0x7e, 0x05, 0xdb, 0x14, 0x33, 0x71, 0x83, 0xb2,
-	0x32, 0xc6, 0x41, 0xa5, 0xef, 0x0b, 0x1c, 0x9f, 0x43, 0x78, 0x4b, 0x52, 0x85, 0x69, 0xb1, 0xc6,
-	0x06, 0x6f, 0xa1, 0x7f, 0x53, 0x9b, 0xe7, 0x01, 0x5b, 0x35, 0x94, 0x75, 0x5e, 0x38, 0xeb, 0xd9,
-	0xff, 0xee, 0xdd, 0xbf, 0x00, 0x00, 0x00, 0xff, 0xff, 0xbf, 0x84, 0xc0, 0x99, 0x51, 0x04, 0x00,
-	0x00,
+	proto.RegisterFile("consensus/structures.proto", fileDescriptor_structures_e6dae78a35d7771f)
+}
+
+var fileDescriptor_structures_e6dae78a35d7771f = []byte{
+	// 1613 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0x4f, 0x6f, 0xdb, 0xc6,
+	0x12, 0x37, 0xa9, 0xff, 0x23, 0xc5, 0x66, 0x36, 0x8e, 0xc3, 0x08, 0x41, 0xe2, 0xc7, 0xf7, 0x5e,
+	0x63, 0xb4, 0x85, 0x8c, 0x3a, 0x6d, 0x90, 0xba, 0x40, 0x10, 0x45, 0x62, 0xea, 0x00, 0x8e, 0xad,
+	0xac, 0xe4, 0x20, 0xe8, 0x25, 0x60, 0xc8, 0xb5, 0x45, 0x58, 0xe2, 0x32, 0xe4, 0xca, 0xad, 0xbe,
+	0x41, 0x81, 0x1e, 0x0b, 0xf4, 0x5c, 0xf4, 0xdc, 0xcf, 0x50, 0xb4, 0xbd, 0xb4, 0x9f, 0xa7, 0x87,
+	0x9e, 0x8b, 0xdd, 0xe5, 0x9f, 0x95, 0xad, 0x58, 0x36, 0xea, 0x93, 0x66, 0x76, 0x7e, 0xb3, 0x3b,
+	0x3b, 0xfb, 0xdb, 0x99, 0xa5, 0xa0, 0xe9, 0xd2, 0x20, 0x26, 0x41, 0x3c, 0x89, 0x37, 0x63, 0x16,
+	0x4d, 0x5c, 0x36, 0x89, 0x48, 0xdc, 0x0a, 0x23, 0xca, 0x28, 0xaa, 0x65, 0xb6, 0xe6, 0xdd, 0x23,
+	0x4a, 0x8f, 0x46, 0x64, 0x53, 0x18, 0xde, 0x4e, 0x0e, 0x37, 0xbd, 0x49, 0xe4, 0x30, 0x9f, 0x06,
+	0x12, 0xda, 0xbc, 0x77, 0xda, 0xce, 0xfc, 0x31, 0x89, 0x99, 0x33, 0x0e, 0x25, 0xc0, 0xfa, 0x56,
+	0x83, 0xca, 0x2b, 0x12, 0xc5, 0x3e, 0x0d, 0x10, 0x82, 0xe2, 0xd0, 0x89, 0x87, 0xa6, 0xb6, 0xae,
+	0x6d, 0x34, 0xb0, 0x90, 0xd1, 0x16, 0x94, 0xc9, 0x37, 0xa1, 0x1f, 0x4d, 0x4d, 0x7d, 0x5d, 0xdb,
+	0xa8, 0x6f, 0x35, 0x5b, 0x72, 0xc6, 0x56, 0x3a, 0x63, 0x6b, 0x90, 0xce, 0x88, 0x13, 0x24, 0xfa,
+	0x0c, 0x20, 0x8c, 0xe8, 0x09, 0x09, 0x9c, 0xc0, 0x25, 0x66, 0x41, 0xf8, 0xdd, 0x6c, 0x65, 0x41,
+	0xb7, 0x7a, 0x99, 0x11, 0x2b, 0x40, 0xeb, 0x67, 0x0d, 0x20, 0x37, 0xf1, 0x68, 0x26, 0x13, 0xdf,
+	0x13, 0xd1, 0xd4, 0xb0, 0x90, 0x91, 0x09, 0x15, 0x32, 0xf6, 0x19, 0x23, 0x91, 0x08, 0xa7, 0x86,
+	0x53, 0x15, 0x3d, 0x82, 0x9a, 0x4b, 0xc7, 0x42, 0xf1, 0xcc, 0xc2, 0xc2, 0x50, 0x73, 0x30, 0x7a,
+	0x08, 0xd5, 0x31, 0xf5, 0xfc, 0x43, 0x9f, 0x78, 0x66, 0x71, 0xa1, 0x63, 0x86, 0xb5, 0xfe, 0xd6,
+	0xa1, 0xf4, 0x72, 0x42, 0xa2, 0xe9, 0xdc, 0x48, 0xd7, 0xa0, 0x1c, 0xd2, 0x91, 0xef, 0x4e, 0x93,
+	0x40, 0x13, 0x4d, 0xdd, 0x41, 0x61, 0x76, 0x07, 0x0f, 0xa1, 0xea, 0x11, 0xc7, 0x1b, 0xf9, 0x01,
+	0xb9, 0x48, 0x1c, 0x29, 0x16, 0x3d, 0x83, 0x46, 0x44, 0xde, 0x4d, 0xfc, 0x88, 0x8c, 0x49, 0xc0,
+	0x62, 0xb3, 0xb4, 0x5e, 0xd8, 0xa8, 0x6f, 0x59, 0x4a, 0xbe, 0x45, 0x94, 0x2d, 0xac, 0x80, 0xec,
+	0x80, 0x45, 0x53, 0x3c, 0xe3, 0x87, 0x3e, 0x05, 0xa0, 0x21, 0x91, 0xec, 0x89, 0xcd, 0xb2, 0x98,
+	0x65, 0x55, 0x99, 0x65, 0x3f, 0x35, 0x62, 0x05, 0x87, 0xee, 0x40, 0x2d, 0xf6, 0x8f, 0x02, 0x87,
+	0xf3, 0xd3, 0x34, 0x04, 0x71, 0xf2, 0x81, 0x66, 0x1f, 0xae, 0x9f, 0x59, 0x16, 0x19, 0x50, 0x38,
+	0x26, 0xd3, 0x24, 0x5b, 0x5c, 0x44, 0x1b, 0x50, 0x3a, 0x71, 0x46, 0x13, 0x92, 0x70, 0x0c, 0x29,
+	0xab, 0x26, 0xdc, 0xc4, 0x12, 0xb0, 0xad, 0x3f, 0xd2, 0xac, 0x3f, 0x0a, 0x50, 0xcb, 0x82, 0x99,
+	0x33, 0xdb, 0x7d, 0xd0, 0x69, 0x28, 0xa6, 0x5a, 0xde, 0xba, 0x35, 0x6f, 0x03, 0xad, 0xfd, 0x10,
+	0xeb, 0x34, 0xe4, 0xe7, 0xe6, 0x39, 0xcc, 0x11, 0x07, 0xd1, 0xc0, 0x42, 0x46, 0x4d, 0xa8, 0x8e,
+	0x09, 0x73, 0xc4, 0x78, 0x51, 0x8c, 0x67, 0x3a, 0x5a, 0x85, 0x52, 0x40, 0x39, 0xa5, 0x4b, 0xc2,
+	0x20, 0x15, 0xf4, 0x11, 0x14, 0x18, 0x1b, 0x99, 0x65, 0x11, 0xfa, 0xed, 0x33, 0x47, 0xd6, 0x4d,
+	0x2e, 0x24, 0xe6, 0x28, 0xeb, 0x3b, 0x1d, 0xf4, 0xfd, 0x10, 0x55, 0xa0, 0xd0, 0xb7, 0x07, 0xc6,
+	0x12, 0x02, 0x28, 0x77, 0xf6, 0xf7, 0x3a, 0xed, 0x81, 0xa1, 0xa1, 0x3a, 0x54, 0xb0, 0xdd, 0xdb,
+	0x6d, 0x77, 0x6c, 0x43, 0x47, 0x0d, 0xa8, 0x0e, 0xf0, 0x01, 0xb7, 0xd8, 0x46, 0x81, 0x6b, 0x7d,
+	0x7b, 0x80, 0xdb, 0x7b, 0x5f, 0xda, 0x46, 0x91, 0x7b, 0x77, 0xda, 0x7d, 0xa3, 0xc4, 0xbd, 0xed,
+	0xd7, 0xbd, 0xe7, 0xd8, 0x36, 0xca, 0x7c, 0xb0, 0xdd, 0xed, 0x1a, 0xc0, 0x85, 0x17, 0x07, 0xbb,
+	0x46, 0x1d, 0x55, 0xa1, 0xf8, 0x7c, 0xaf, 0x83, 0x8d, 0x06, 0x97, 0xba, 0x76, 0x07, 0x1b, 0xd7,
+	0x84, 0xf1, 0xf9, 0x9e, 0xb1, 0x2c, 0x84, 0xf6, 0x6b, 0x63, 0x85, 0xdb, 0xfa, 0xdc, 0x71, 0x55,
+	0x48, 0xd8, 0x7e, 0x61, 0xdc, 0x44, 0x35, 0x28, 0xed, 0xf6, 0x0e, 0xfa, 0x3b, 0xc6, 0x1a, 0x17,
+	0xb1, 0x10, 0x6f, 0x71, 0xfb, 0x6e, 0x6f, 0xbf, 0x67, 0x98, 0x5c, 0xda, 0xe1, 0xf1, 0xdf, 0x16,
+	0x52, 0xd7, 0xde, 0x35, 0x9a, 0x68, 0x19, 0xa0, 0xbf, 0xff, 0x6c, 0xd0, 0xb5, 0x77, 0xed, 0x81,
+	0x6d, 0xdc, 0x95, 0xbb, 0xe9, 0x0f, 0xf6, 0xb1, 0x6d, 0xdc, 0xe3, 0xb3, 0xf4, 0xf0, 0xc1, 0x9e,
+	0x6d, 0xac, 0xf3, 0x98, 0x13, 0xcc, 0x7f, 0xac, 0xef, 0x35, 0xa8, 0xdb, 0x81, 0x47, 0xa3, 0x58,
+	0xf0, 0xe3, 0x92, 0x57, 0xfe, 0x2e, 0x80, 0x4b, 0x03, 0xcf, 0x97, 0x84, 0x2d, 0xac, 0x17, 0x36,
+	0x6a, 0x58, 0x19, 0xe1, 0x9e, 0x87, 0x74, 0x34, 0xa2, 0x5f, 0xc7, 0x66, 0x51, 0x18, 0x53, 0xf5,
+	0x7c, 0xd2, 0x5a, 0xef, 0xe0, 0x66, 0xfb, 0xe8, 0x28, 0x22, 0x47, 0x0e, 0x23, 0x9e, 0x1a, 0xde,
+	0x36, 0x34, 0x48, 0xae, 0xc6, 0xa6, 0x26, 0xee, 0xc8, 0x9a, 0x42, 0x31, 0x05, 0x8d, 0x67, 0xb0,
+	0x0b, 0x96, 0x6c, 0xc3, 0x4a, 0x9f, 0x39, 0x11, 0xeb, 0x0c, 0x89, 0x7b, 0x1c, 0x52, 0x3f, 0x60,
+	0x3c, 0xfa, 0x77, 0x13, 0x12, 0xf9, 0x44, 0xae, 0x53, 0xc3, 0xa9, 0xca, 0x69, 0x48, 0x42, 0xea,
+	0x0e, 0x45, 0x3e, 0x8a, 0x58, 0x2a, 0xd6, 0x0f, 0x3a, 0x94, 0x7a, 0x11, 0xa5, 0x87, 0xfc, 0x36,
+	0x71, 0xa8, 0xbc, 0x13, 0xf5, 0x2d, 0xe3, 0x74, 0x25, 0xd8, 0x59, 0xc2, 0x12, 0x80, 0xb6, 0xa1,
+	0xae, 0x04, 0x99, 0xdc, 0xbe, 0xf7, 0xec, 0x67, 0x67, 0x09, 0xab, 0x60, 0xf4, 0x04, 0x6a, 0x4e,
+	0x9a, 0xa5, 0xa4, 0xe0, 0xae, 0x2b, 0x9e, 0x73, 0x33, 0xb8, 0xb3, 0x84, 0x73, 0x27, 0xf4, 0x00,
+	0x2a, 0xf1, 0x64, 0x3c, 0x76, 0xa2, 0x69, 0x52, 0xef, 0x6e, 0xcd, 0xf6, 0x08, 0x7a, 0xd8, 0x97,
+	0xe6, 0x9d, 0x25, 0x9c, 0x22, 0xd1, 0xff, 0xa1, 0x78, 0x42, 0x99, 0xbc, 0x82, 0xf5, 0xad, 0x15,
+	0xb5, 0x52, 0x50, 0x46, 0x76, 0x96, 0xb0, 0x30, 0x3f, 0xad, 0x41, 0xc5, 0xa5, 0x01, 0x23, 0x01,
+	0xb3, 0x5e, 0x41, 0x43, 0x9d, 0x6c, 0x2e, 0xc9, 0x9a, 0x50, 0x4d, 0x58, 0x15, 0x9b, 0xba, 0xc8,
+	0x76, 0xa6, 0xf3, 0x4a, 0xce, 0x3b, 0x21, 0x91, 0x14, 0x6b, 0xe0, 0x44, 0xb3, 0x7e, 0xd4, 0xa0,
+	0xc8, 0xd7, 0xe4, 0x3c, 0xf4, 0x3d, 0x12, 0x30, 0xde, 0x16, 0xa2, 0x64, 0x5a, 0x65, 0xe4, 0x1c,
+	0x06, 0xaf, 0x41, 0xd9, 0x1d, 0x52, 0x3f, 0x69, 0x92, 0x55, 0x9c, 0x68, 0x68, 0x03, 0xca, 0x21,
+	0x0f, 0x59, 0x12, 0x77, 0xf6, 0x08, 0xc5, 0x5e, 0x70, 0x62, 0x5f, 0x40, 0xab, 0x5f, 0x35, 0x30,
+	0x72, 0x4a, 0x61, 0x12, 0x4f, 0x46, 0x2c, 0xa7, 0x8f, 0xa6, 0xd0, 0x47, 0xa5, 0x9b, 0x3e, 0x4b,
+	0xb7, 0xf7, 0x77, 0xac, 0x26, 0xef, 0x58, 0xae, 0xcf, 0xeb, 0xb3, 0x38, 0xc1, 0x2a, 0xce, 0x74,
+	0x65, 0x0b, 0xa5, 0x7f, 0xb5, 0x85, 0x08, 0x6a, 0x6d, 0x6f, 0xec, 0x07, 0xdd, 0x48, 0x16, 0xec,
+	0x79, 0x8d, 0x36, 0x22, 0x4e, 0x4c, 0x83, 0xb4, 0xd1, 0x4a, 0x0d, 0x7d, 0x0e, 0x90, 0xcd, 0x22,
+	0x8f, 0x8e, 0x57, 0x67, 0x85, 0xa0, 0x7c, 0xd6, 0x7e, 0x8a, 0xc0, 0x0a, 0xd8, 0xea, 0xc2, 0xf2,
+	0xac, 0x95, 0xe7, 0xcc, 0xe1, 0x23, 0xc9, 0xca, 0x52, 0x59, 0x10, 0xf9, 0x7f, 0x61, 0x05, 0x13,
+	0x97, 0x9e, 0x90, 0x68, 0xca, 0x7b, 0x20, 0x89, 0xd9, 0xd9, 0x5e, 0x65, 0x1d, 0x82, 0x91, 0x83,
+	0xe2, 0x90, 0x47, 0x77, 0x16, 0x85, 0x3e, 0x86, 0xca, 0x89, 0xec, 0x83, 0xe7, 0x74, 0xc8, 0x14,
+	0x32, 0xaf, 0xad, 0x59, 0x4f, 0x01, 0xf5, 0x48, 0xe0, 0xf9, 0xc1, 0x51, 0x7f, 0x1a, 0xb8, 0x69,
+	0x3c, 0xab, 0x50, 0xe2, 0x39, 0x4c, 0x2b, 0x8c, 0x54, 0xc4, 0xd3, 0x45, 0x1e, 0x9d, 0x2e, 0x59,
+	0x29, 0x35, 0xeb, 0x2f, 0x0d, 0x6e, 0xcc, 0x4c, 0x92, 0xc4, 0xfb, 0x09, 0x54, 0x42, 0x39, 0x9c,
+	0x54, 0xc4, 0x99, 0x7b, 0x2c, 0x2d, 0xa2, 0xf0, 0xe0, 0x14, 0x87, 0x3e, 0x9c, 0x65, 0xdb, 0x9c,
+	0x22, 0x95, 0xf3, 0xef, 0x74, 0xd5, 0x2d, 0x5c, 0xa2, 0xea, 0x3e, 0x01, 0xc8, 0xea, 0x4d, 0x7a,
+	0x99, 0x16, 0x56, 0x29, 0xac, 0xf8, 0x58, 0x5f, 0x41, 0x43, 0xdd, 0xc2, 0x5c, 0x0a, 0xaa, 0x2f,
+	0x37, 0xfd, 0xe2, 0x2f, 0x37, 0xfe, 0x18, 0xa8, 0x77, 0xc4, 0x3b, 0xd4, 0x3e, 0xe1, 0x25, 0xb5,
+	0x09, 0xd5, 0x98, 0x9f, 0x0c, 0x7f, 0x62, 0xc8, 0xcb, 0x99, 0xe9, 0xd9, 0xba, 0xfa, 0xfc, 0xd6,
+	0x78, 0xea, 0x66, 0x22, 0x28, 0x1e, 0x93, 0x69, 0xda, 0xf7, 0x84, 0x8c, 0x5a, 0x50, 0x4d, 0x18,
+	0x92, 0xde, 0xc9, 0x79, 0x2c, 0xca, 0x30, 0xa8, 0x05, 0x45, 0xfe, 0xb1, 0x60, 0x96, 0x17, 0xee,
+	0x48, 0xe0, 0xd0, 0x63, 0xa8, 0xbb, 0x24, 0xe2, 0x35, 0xcf, 0xe5, 0x2d, 0xa1, 0x22, 0xdc, 0xee,
+	0x28, 0x4b, 0xc8, 0xad, 0x76, 0x72, 0x0c, 0x56, 0x1d, 0xac, 0xdf, 0x74, 0xb8, 0x7e, 0x06, 0x82,
+	0x3e, 0x58, 0xd0, 0xcc, 0xf2, 0x56, 0x36, 0xcb, 0x12, 0xfd, 0x72, 0xbd, 0x99, 0x0d, 0x23, 0x12,
+	0x0f, 0xe9, 0x48, 0x7e, 0x3b, 0x5c, 0xc3, 0xf9, 0x00, 0x3f, 0x15, 0x87, 0x31, 0x12, 0xf3, 0x34,
+	0x17, 0x45, 0x9a, 0x33, 0x3d, 0xcb, 0x51, 0xe9, 0x82, 0x39, 0x9a, 0xe5, 0x63, 0xf9, 0xf2, 0x7c,
+	0x5c, 0x50, 0x73, 0x18, 0x00, 0x5f, 0xf2, 0x29, 0x71, 0x5c, 0x1a, 0xa8, 0xfc, 0xd0, 0x66, 0xf9,
+	0x91, 0xc6, 0xad, 0x5f, 0x30, 0xee, 0xf3, 0x57, 0xfd, 0x5d, 0x07, 0xd8, 0xa3, 0x1e, 0xe9, 0x33,
+	0x87, 0x4d, 0xe2, 0x2b, 0x5c, 0xd6, 0xcc, 0xeb, 0x5e, 0x42, 0xf0, 0x44, 0xe5, 0x96, 0xb4, 0xe6,
+	0x14, 0xc5, 0x81, 0xa5, 0x6a, 0x46, 0xfd, 0x92, 0xb8, 0x40, 0x42, 0x46, 0x5f, 0x40, 0x7d, 0xe4,
+	0xc4, 0xec, 0x8d, 0xfc, 0xe8, 0xbb, 0x00, 0xa3, 0x81, 0xc3, 0x25, 0x19, 0x79, 0x39, 0x9c, 0x84,
+	0x22, 0xec, 0x8a, 0x98, 0x32, 0xd1, 0xd0, 0x26, 0xdc, 0x48, 0xd6, 0x7c, 0xe3, 0x66, 0x3d, 0x36,
+	0x36, 0xab, 0x22, 0x1c, 0x94, 0x98, 0xf2, 0xee, 0xbb, 0xe8, 0xe8, 0x7e, 0xd1, 0x00, 0xa9, 0x87,
+	0x4e, 0x5c, 0x1a, 0x79, 0x31, 0x7a, 0x0c, 0x95, 0x48, 0x8a, 0x49, 0x71, 0xfd, 0xdf, 0x7b, 0x28,
+	0x2d, 0x41, 0x2d, 0xf9, 0x8b, 0x53, 0xa7, 0xe6, 0x10, 0xca, 0x72, 0xe8, 0x2a, 0x2b, 0x57, 0xf6,
+	0x4f, 0x41, 0x21, 0xff, 0xa7, 0xc0, 0xfa, 0x49, 0x83, 0xe5, 0x76, 0x18, 0x8e, 0x7c, 0xe2, 0xbd,
+	0x70, 0xa2, 0x63, 0xfe, 0x74, 0xda, 0x86, 0xca, 0x58, 0x8a, 0xa6, 0x76, 0x96, 0xeb, 0x33, 0xd8,
+	0x96, 0xfc, 0xc5, 0xa9, 0x43, 0x73, 0x00, 0x65, 0x39, 0x74, 0xa5, 0x25, 0xf7, 0x4f, 0x0d, 0x96,
+	0xbb, 0xc4, 0xf5, 0x3d, 0xe2, 0xbd, 0x4c, 0xfa, 0xcb, 0x13, 0xa8, 0xa5, 0xaf, 0x96, 0x34, 0x4c,
+	0xf5, 0xe3, 0x79, 0x16, 0xdd, 0xea, 0x26, 0x50, 0x9c, 0x3b, 0x35, 0x19, 0x54, 0xd3, 0xe1, 0x2b,
+	0xcd, 0xf2, 0x9d, 0xd3, 0xff, 0x69, 0x54, 0x95, 0xff, 0x2d, 0xac, 0xfb, 0x70, 0x2d, 0x49, 0xe1,
+	0x1e, 0xff, 0x0e, 0x15, 0x7d, 0x5b, 0x7c, 0x91, 0xca, 0x5d, 0x34, 0x70, 0xa2, 0xbd, 0x2d, 0x8b,
+	0x45, 0x1e, 0xfc, 0x33, 0x00, 0xbb, 0x41, 0x70, 0x43, 0x53, 0x12, 0x00, 0x00,
 }
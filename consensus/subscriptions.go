@@ -0,0 +1,177 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+)
+
+// DefaultAcceptBuffer is the number of messages buffered by each channel
+// returned by Network.Accept, unless configured otherwise.
+const DefaultAcceptBuffer = 1024
+
+// acceptDropLogSample is the number of messages dropped between two logs.
+const acceptDropLogSample = 100
+
+// Subscriptions dispatches incoming messages to channels, as required by
+// Network.Accept, for network implementations.
+// This type is thread-safe.
+type Subscriptions struct {
+	Buffer int  // capacity of each channel, DefaultAcceptBuffer if zero
+	Retain bool // keep up to Buffer messages accepted by no channel, and deliver them to the next channel accepting them
+
+	mu       sync.Mutex
+	subs     []*subscription
+	retained []proto.Message // oldest first
+	closing  chan struct{}   // closed by Close
+	closed   bool
+	drops    uint64
+}
+
+type subscription struct {
+	acceptor MessageAcceptor
+	output   chan proto.Message
+}
+
+func (s *Subscriptions) buffer() int {
+	if s.Buffer <= 0 {
+		return DefaultAcceptBuffer
+	}
+	return s.Buffer
+}
+
+// unsafe
+func (s *Subscriptions) init() {
+	if s.closing == nil {
+		s.closing = make(chan struct{})
+	}
+}
+
+// Subscribe returns a channel delivering the messages for which acceptor
+// returns true, closed once ctx is done or Close is called.
+func (s *Subscriptions) Subscribe(ctx context.Context, acceptor MessageAcceptor) <-chan proto.Message {
+	sub := &subscription{
+		acceptor: acceptor,
+		output:   make(chan proto.Message, s.buffer()),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		close(sub.output)
+		return sub.output
+	}
+	s.init()
+	s.subs = append(s.subs, sub)
+
+	retained := s.retained[:0]
+	for _, m := range s.retained {
+		if acceptor(m) {
+			s.send(sub, m)
+		} else {
+			retained = append(retained, m)
+		}
+	}
+	s.retained = retained
+
+	closing := s.closing
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-closing:
+		}
+		s.unsubscribe(sub)
+	}()
+
+	return sub.output
+}
+
+func (s *Subscriptions) unsubscribe(sub *subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, other := range s.subs {
+		if other == sub {
+			s.subs = append(s.subs[:i], s.subs[i+1:]...)
+			close(sub.output)
+			return
+		}
+	}
+}
+
+// Deliver sends a message to every channel accepting it, without blocking,
+// and returns true if at least one of them did.
+func (s *Subscriptions) Deliver(m proto.Message) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var accepted bool
+	for _, sub := range s.subs {
+		if sub.acceptor(m) {
+			s.send(sub, m)
+			accepted = true
+		}
+	}
+
+	if !accepted && s.Retain && !s.closed {
+		s.retained = append(s.retained, m)
+		if extra := len(s.retained) - s.buffer(); extra > 0 {
+			s.retained = s.retained[extra:]
+			s.dropped(extra)
+		}
+	}
+	return accepted
+}
+
+// unsafe
+func (s *Subscriptions) send(sub *subscription, m proto.Message) {
+	select {
+	case sub.output <- m:
+	default:
+		s.dropped(1)
+	}
+}
+
+// unsafe
+func (s *Subscriptions) dropped(n int) {
+	for i := 0; i < n; i++ {
+		s.drops++
+		if s.drops%acceptDropLogSample == 1 {
+			zap.L().Warn("AcceptOverflow", zap.Uint64("drops", s.drops))
+		}
+	}
+}
+
+// AcceptDrops returns the number of messages dropped since the creation of
+// the subscriptions, because their channel or the retained messages were
+// full.
+func (s *Subscriptions) AcceptDrops() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.drops
+}
+
+// Close closes every channel, and the channels subscribed afterwards.
+func (s *Subscriptions) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.init()
+	s.closed = true
+	s.retained = nil
+	close(s.closing)
+}
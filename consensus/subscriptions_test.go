@@ -0,0 +1,51 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptions_Retain(t *testing.T) {
+	s := &Subscriptions{Buffer: 2, Retain: true}
+	isQuery := func(m proto.Message) bool {
+		_, ok := m.(*Query)
+		return ok
+	}
+
+	queries := []*Query{NewQuery(), NewQuery(), NewQuery()}
+	for _, q := range queries {
+		require.False(t, s.Deliver(q))
+	}
+	require.False(t, s.Deliver(&Endorsement{}))
+	require.Equal(t, uint64(2), s.AcceptDrops(), "only Buffer messages should be retained")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := s.Subscribe(ctx, isQuery)
+	require.Len(t, c, 1, "retained messages should be delivered to the first channel accepting them")
+	require.Equal(t, queries[2], <-c)
+
+	// The retained endorsement is not accepted by the channel
+	require.True(t, s.Deliver(queries[0]))
+	require.True(t, s.Deliver(queries[1]))
+	require.True(t, s.Deliver(queries[2]))
+	require.Equal(t, uint64(3), s.AcceptDrops())
+
+	cancel()
+	for range c {
+	}
+
+	s.Close()
+	_, ok := <-s.Subscribe(context.Background(), isQuery)
+	require.False(t, ok, "channels should be closed once the subscriptions are")
+}
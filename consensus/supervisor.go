@@ -0,0 +1,229 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+)
+
+// Long-lived loops of the engine, restarted after a panic (see supervise).
+const (
+	loopQueries      = "queries"
+	loopEndorsements = "endorsements"
+	loopCheckpoints  = "checkpoints"
+	loopBatcher      = "batcher"
+	loopGC           = "gc"
+	loopRecovery     = "recovery"
+)
+
+// Handlers of single messages, run in goroutines of their own (see spawn).
+const (
+	taskQuery      = "query"
+	taskCheckpoint = "checkpoint"
+)
+
+// DefaultMaxRestarts is the number of restarts of a loop of the engine after
+// a panic, once the engine has failed (see Engine.Failed).
+const DefaultMaxRestarts = 5
+
+const (
+	restartBackoff    = 100 * time.Millisecond // doubled at each restart of the same loop
+	restartMaxBackoff = 10 * time.Second
+)
+
+// ErrLoopFailed is returned by Engine.Failed once a loop of the engine has
+// panicked more than MaxRestarts times.
+type ErrLoopFailed struct {
+	Loop  string
+	Panic string // value of the last panic
+}
+
+// Error returns error's string value.
+func (e ErrLoopFailed) Error() string {
+	return fmt.Sprintf("engine loop %s has failed: %s", e.Loop, e.Panic)
+}
+
+// supervisorTracker counts the restarts of the loops of the engine.
+type supervisorTracker struct {
+	sync.Mutex
+	restarts map[string]int
+	failure  error
+}
+
+// restart records a panic of loop, and returns the number of restarts of
+// loop so far, or -1 if loop shall not be restarted anymore.
+func (t *supervisorTracker) restart(loop string, value interface{}, max int) int {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.restarts == nil {
+		t.restarts = make(map[string]int)
+	}
+	if t.restarts[loop] >= max {
+		if t.failure == nil {
+			t.failure = ErrLoopFailed{Loop: loop, Panic: fmt.Sprint(value)}
+		}
+		return -1
+	}
+
+	t.restarts[loop]++
+	return t.restarts[loop]
+}
+
+// recovered records a panic of a task, which is not run again.
+func (t *supervisorTracker) recovered(task string) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.restarts == nil {
+		t.restarts = make(map[string]int)
+	}
+	t.restarts[task]++
+}
+
+// Restarts returns the number of restarts of each loop of the engine after
+// a panic, along with the number of panics of the handlers of single
+// messages, by task (see spawn).
+// This function is thread-safe.
+func (eng *Engine) Restarts() map[string]int {
+	t := &eng.supervisor
+	t.Lock()
+	defer t.Unlock()
+
+	restarts := make(map[string]int, len(t.restarts))
+	for loop, n := range t.restarts {
+		restarts[loop] = n
+	}
+	return restarts
+}
+
+// Failed returns an ErrLoopFailed once a loop of the engine has panicked
+// more than MaxRestarts times, or nil. A failed engine does not process
+// some messages anymore, and the node shall be restarted.
+// This function is thread-safe.
+func (eng *Engine) Failed() error {
+	t := &eng.supervisor
+	t.Lock()
+	defer t.Unlock()
+	return t.failure
+}
+
+func (eng *Engine) maxRestarts() int {
+	if eng.MaxRestarts > 0 {
+		return eng.MaxRestarts
+	}
+	if eng.MaxRestarts < 0 {
+		return 0
+	}
+	return DefaultMaxRestarts
+}
+
+// supervise runs loop in a new goroutine, until it returns. After a panic,
+// the stack is logged, and loop is restarted with an exponential backoff,
+// up to MaxRestarts times.
+func (eng *Engine) supervise(ctx context.Context, name string, loop func()) {
+	go func() {
+		backoff := restartBackoff
+		for {
+			value, panicked := eng.runLoop(name, loop)
+			if !panicked || ctx.Err() != nil {
+				return
+			}
+
+			n := eng.supervisor.restart(name, value, eng.maxRestarts())
+			if n < 0 {
+				zap.L().Error("EngineFailed",
+					zap.String("loop", name),
+					zap.Any("panic", value),
+				)
+				return
+			}
+
+			zap.L().Warn("EngineRestart",
+				zap.String("loop", name),
+				zap.Int("restarts", n),
+				zap.Duration("backoff", backoff),
+			)
+
+			select {
+			case <-eng.clock().After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			if backoff *= 2; backoff > restartMaxBackoff {
+				backoff = restartMaxBackoff
+			}
+		}
+	}()
+}
+
+// spawn runs task in a new goroutine. A panic is logged and counted like a
+// restart (see Restarts), but neither runs task again, since it handles a
+// single message, nor fails the engine.
+func (eng *Engine) spawn(name string, task func()) {
+	go func() {
+		_, panicked := eng.runLoop(name, func() {
+			eng.step(name)
+			task()
+		})
+		if panicked {
+			eng.supervisor.recovered(name)
+		}
+	}()
+}
+
+// runLoop runs loop, and returns the value of its panic, if any.
+func (eng *Engine) runLoop(name string, loop func()) (value interface{}, panicked bool) {
+	defer func() {
+		if value = recover(); panicked {
+			zap.L().Error("EnginePanic",
+				zap.String("loop", name),
+				zap.Any("panic", value),
+				zap.ByteString("stack", debug.Stack()),
+			)
+		}
+	}()
+
+	panicked = true // unless loop returns
+	loop()
+	panicked = false
+	return
+}
+
+// acceptLoop returns a loop handling the messages of the network accepted
+// by acceptor. It subscribes to the network on its first run only, so that
+// the messages received in the meantime are still handled once restarted.
+func (eng *Engine) acceptLoop(ctx context.Context, name string, acceptor MessageAcceptor, handle func(proto.Message)) func() {
+	var messages <-chan proto.Message
+	return func() {
+		if messages == nil {
+			messages = eng.Network.Accept(ctx, acceptor)
+		}
+
+		for m := range messages {
+			eng.step(name)
+			handle(m)
+		}
+	}
+}
+
+// step is called by the supervised loops for each message or round that
+// they process.
+func (eng *Engine) step(loop string) {
+	if eng.loopHook != nil {
+		eng.loopHook(loop)
+	}
+}
@@ -0,0 +1,102 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/tests"
+)
+
+func TestEngine_Supervise(t *testing.T) {
+	keyrings := tests.GetTestKeyRings(t, 2)
+	h := &hub{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The checkpoint loop panics on its first message, and is restarted
+	var calls int32
+	eng := NewEngine(newMemoryStore(), h.join(), passBBC{}, keyrings[0], 2)
+	eng.loopHook = func(loop string) {
+		if loop == loopCheckpoints && atomic.AddInt32(&calls, 1) == 1 {
+			panic("injected")
+		}
+	}
+	require.Nil(t, eng.Run(ctx))
+	h.waitSubscribers(t, 4) // queries, endorsements, checkpoints and node statuses
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: []byte("a")}}
+	require.Nil(t, eng.Submit(q))
+
+	require.Nil(t, eng.broadcast(&StartCheckpoint{Queries: []string{q.Uuid}}))
+	eventually(t, func() bool { return eng.Restarts()[loopCheckpoints] > 0 }, "checkpoint loop should be restarted")
+	s, _ := eng.QueryStatus(q.Uuid)
+	require.Exactly(t, StatePending, s.State)
+
+	require.Nil(t, eng.broadcast(&StartCheckpoint{Queries: []string{q.Uuid}}))
+	eventually(t, func() bool {
+		s, _ := eng.QueryStatus(q.Uuid)
+		return s.State == StateDropped
+	}, "query should be dropped once restarted")
+	require.Exactly(t, map[string]int{loopCheckpoints: 1}, eng.Restarts())
+	require.Nil(t, eng.Failed())
+
+	// The engine fails once a loop has panicked too many times
+	failing := NewEngine(newMemoryStore(), h.join(), passBBC{}, keyrings[1], 2)
+	failing.MaxRestarts = 1
+	failing.loopHook = func(loop string) {
+		if loop == loopCheckpoints {
+			panic("injected")
+		}
+	}
+	require.Nil(t, failing.Run(ctx))
+	h.waitSubscribers(t, 8)
+
+	for i := 0; failing.Failed() == nil; i++ {
+		require.True(t, i < 100, "engine should fail")
+		require.Nil(t, failing.broadcast(&StartCheckpoint{Queries: []string{q.Uuid}}))
+		time.Sleep(50 * time.Millisecond)
+	}
+	require.Exactly(t, ErrLoopFailed{Loop: loopCheckpoints, Panic: "injected"}, failing.Failed())
+	require.Exactly(t, 1, failing.Restarts()[loopCheckpoints])
+}
+
+func TestEngine_SpawnRecover(t *testing.T) {
+	// The handler of the first query panics, the next ones are handled
+	var calls int32
+	m := newFakeMetrics()
+	engines, stop := startEngines(t, 1, 1, func(_ int, eng *Engine) {
+		eng.loopHook = func(loop string) {
+			if loop == taskQuery && atomic.AddInt32(&calls, 1) == 1 {
+				panic("injected")
+			}
+		}
+		eng.Metrics = m
+	})
+	defer stop()
+	eng := engines[0]
+
+	q := NewQuery()
+	q.SetTimeout(time.Minute)
+	q.Operations = []*Operation{{Key: "a", Op: Operation_SET, Data: []byte("a")}}
+	s, err := eng.SubmitAndWait(context.Background(), q)
+	require.Nil(t, err, "the query should be handled once received from the network")
+	require.True(t, s.Applied)
+
+	require.Exactly(t, 1, eng.Restarts()[taskQuery])
+	require.Nil(t, eng.Failed())
+	eng.CollectMetrics()
+	require.Equal(t, 1.0, m.get(MetricEngineRestarts+`{loop="query"}`))
+}
@@ -0,0 +1,147 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"fmt"
+
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+	"github.com/technicolor-research/pnyxdb/consensus/operations"
+)
+
+// OperationError reports an operation of a query which cannot succeed,
+// whatever the stored values.
+type OperationError struct {
+	Index int // of the operation in the query
+	Key   string
+	Op    Operation_Op
+	Err   error
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("operation %d (%s on %q) cannot succeed: %v", e.Index, e.Op, e.Key, e.Err)
+}
+
+// Validate checks that the operations of q may succeed together, and returns
+// an *OperationError designating the first one that cannot.
+//
+// Validate never reads the store, so that every node reaches the same
+// decision: once an operation defines the whole value of a key (SET, DELETE
+// or a successful CAS), the following operations on this key are executed
+// against it; before that, only the type of the value is known from the
+// previous operations, such as a set after SADD. TTLs, and keys reserved to
+// the nodes (see IsLocalKey), are checked in any case.
+func (q *Query) Validate() error {
+	known := make(map[string]*operations.Value)
+	types := make(map[string]encoding.Type)
+
+	for i, op := range q.Operations {
+		fail := func(err error) error {
+			return &OperationError{Index: i, Key: op.Key, Op: op.Op, Err: err}
+		}
+
+		if _, implemented := runners[op.Op]; !implemented {
+			return fail(errNotImplemented)
+		}
+
+		if IsLocalKey(op.Key) {
+			return fail(ErrReservedKey)
+		}
+
+		if err := op.checkTTL(); err != nil {
+			return fail(err)
+		}
+
+		value, ok := known[op.Key]
+		switch {
+		case op.Op == Operation_SET || op.Op == Operation_DELETE:
+			value = q.newValue(nil)
+			known[op.Key] = value
+		case !ok && op.Op == Operation_CAS:
+			args, err := encoding.DecodeArgs(op.Data, 2)
+			if err != nil {
+				return fail(err)
+			}
+
+			value = q.newValue(args[1]) // if it succeeds
+			known[op.Key] = value
+			continue
+		case !ok:
+			t, err := nextType(op.Op, types[op.Key])
+			if err != nil {
+				return fail(err)
+			}
+			types[op.Key] = t
+			continue
+		}
+
+		if err := op.Exec(value); err != nil {
+			return fail(err)
+		}
+	}
+
+	return nil
+}
+
+// nextType returns the type of a value of unknown content but of type t
+// (unknown if empty) after op, or an error if op cannot succeed on it.
+// Numeric values, sets, lists and maps exclude each other: numeric values
+// are made of printable characters, whereas non-empty sets start with a
+// non-null length prefix, and non-empty lists and maps with distinct markers
+// starting with a null one.
+func nextType(op Operation_Op, t encoding.Type) (encoding.Type, error) {
+	switch op {
+	case Operation_ADD, Operation_MUL, Operation_INCR, Operation_DECR, Operation_MIN, Operation_MAX:
+		if t == encoding.TypeSet || t == encoding.TypeList || t == encoding.TypeMap {
+			return t, operations.ErrNotNumeric
+		}
+		return encoding.TypeFloat, nil
+	case Operation_SADD, Operation_SREM:
+		if t == encoding.TypeFloat || t == encoding.TypeList || t == encoding.TypeMap {
+			return t, operations.ErrNotValidSet
+		}
+		if op == Operation_SREM {
+			return "", nil // possibly emptied
+		}
+		return encoding.TypeSet, nil
+	case Operation_LPUSH, Operation_RPUSH, Operation_LPOP:
+		if t == encoding.TypeFloat || t == encoding.TypeSet || t == encoding.TypeMap {
+			return t, operations.ErrNotValidList
+		}
+		if op == Operation_LPOP {
+			return "", nil // possibly emptied
+		}
+		return encoding.TypeList, nil
+	case Operation_HSET, Operation_HDEL:
+		if t == encoding.TypeFloat || t == encoding.TypeSet || t == encoding.TypeList {
+			return t, operations.ErrNotValidMap
+		}
+		if op == Operation_HDEL {
+			return "", nil // possibly emptied
+		}
+		return encoding.TypeMap, nil
+	default:
+		return "", nil
+	}
+}
+
+// checkTTL returns an error if the TTL of o, or the duration given to EXPIRE,
+// is invalid.
+func (o *Operation) checkTTL() error {
+	if o.Op == Operation_EXPIRE {
+		_, err := operations.ParseTTL(o.Data)
+		return err
+	}
+
+	if o.Ttl == nil {
+		return nil
+	}
+	_, err := o.ttl()
+	return err
+}
@@ -12,6 +12,9 @@ import (
 	"bytes"
 	"crypto/sha512"
 	"errors"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
 )
 
 // ErrVersionMismatch is returned when two versions are not matching.
@@ -44,7 +47,48 @@ func (v *Version) Matches(v2 *Version) error {
 	return nil
 }
 
+// ExpiryTime returns the time from which the key of v is seen as missing, or
+// the zero time if it does not expire.
+func (v *Version) ExpiryTime() time.Time {
+	if v == nil || v.Expiry == nil {
+		return time.Time{}
+	}
+
+	t, err := ptypes.Timestamp(v.Expiry)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// ExpiredAt returns true if the key of v has expired at time t.
+func (v *Version) ExpiredAt(t time.Time) bool {
+	expiry := v.ExpiryTime()
+	return !expiry.IsZero() && !expiry.After(t)
+}
+
+// setExpiry sets the expiry of v, removing it if t is the zero time.
+func (v *Version) setExpiry(t time.Time) {
+	v.Expiry = nil
+	if !t.IsZero() {
+		v.Expiry, _ = ptypes.TimestampProto(t)
+	}
+}
+
+// setProvenance records that v was written by q, applied at time t.
+func (v *Version) setProvenance(q *Query, t time.Time) {
+	committed, _ := ptypes.TimestampProto(t)
+	modified, _ := ptypes.TimestampProto(q.DeadlineTime())
+	v.Provenance = &Provenance{
+		Uuid:      q.Uuid,
+		Emitter:   q.Emitter,
+		Committed: committed,
+		Modified:  modified,
+	}
+}
+
 // MarshalBinary converts the version to a VersionBytes-sized bytes slice.
+// The expiry and the provenance are not included.
 func (v *Version) MarshalBinary() (data []byte, err error) {
 	if v == nil {
 		return make([]byte, VersionBytes), nil
@@ -10,6 +10,7 @@ package consensus
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -38,3 +39,21 @@ func TestVersion_Marshal(t *testing.T) {
 	require.Nil(t, err)
 	require.Nil(t, a.Matches(b))
 }
+
+func TestVersion_Expiry(t *testing.T) {
+	now := time.Now()
+	v := NewVersion([]byte("hello"))
+	require.True(t, v.ExpiryTime().IsZero())
+	require.False(t, v.ExpiredAt(now))
+
+	v.setExpiry(now)
+	require.True(t, v.ExpiryTime().Equal(now))
+	require.True(t, v.ExpiredAt(now))
+	require.False(t, v.ExpiredAt(now.Add(-time.Nanosecond)))
+
+	v.setExpiry(time.Time{})
+	require.Nil(t, v.Expiry)
+
+	var missing *Version
+	require.False(t, missing.ExpiredAt(now))
+}
@@ -0,0 +1,26 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package consensus
+
+import (
+	"crypto/sha512"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Hash returns a fixed-size hash of the (unsigned) version of the vote.
+// Passed by value because of internal modifications.
+// Proofs are marshalled deterministically, since queries contain maps.
+func (v Vote) Hash() ([]byte, error) {
+	v.Signature = nil
+	b := proto.NewBuffer(nil)
+	b.SetDeterministic(true)
+	err := b.Marshal(&v)
+	hash := sha512.Sum512(b.Bytes())
+	return hash[:], err
+}
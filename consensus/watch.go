@@ -0,0 +1,125 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// DefaultWatchBuffer is the number of updates buffered for each watcher,
+// see Engine.Watch.
+const DefaultWatchBuffer = 256
+
+// KeyUpdate describes a key written by a committed query.
+type KeyUpdate struct {
+	Key     string
+	Version *Version
+	Uuid    string // of the committing query, empty for expired keys removed from the store
+	Dropped uint64 // updates of the watcher dropped since the previous one delivered
+}
+
+type keyWatcher struct {
+	prefix  string
+	c       chan KeyUpdate
+	dropped uint64
+}
+
+// watchTracker dispatches the keys written by committed queries to the
+// watchers of their prefixes.
+//
+// watchTracker is thread-safe.
+type watchTracker struct {
+	mutex    sync.Mutex
+	watchers map[*keyWatcher]struct{}
+	dropped  uint64 // by every watcher, since the start
+}
+
+func (t *watchTracker) add(prefix string, buffer int) *keyWatcher {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	w := &keyWatcher{prefix: prefix, c: make(chan KeyUpdate, buffer)}
+	if t.watchers == nil {
+		t.watchers = make(map[*keyWatcher]struct{})
+	}
+	t.watchers[w] = struct{}{}
+	return w
+}
+
+func (t *watchTracker) remove(w *keyWatcher) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.watchers, w)
+	close(w.c)
+}
+
+// publish sends the written keys to the watchers of their prefixes, without
+// blocking: the updates are dropped when the buffer of a watcher is full.
+func (t *watchTracker) publish(uuid string, keys []string, versions []*Version) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for w := range t.watchers {
+		for i, key := range keys {
+			if !strings.HasPrefix(key, w.prefix) {
+				continue
+			}
+
+			select {
+			case w.c <- KeyUpdate{Key: key, Version: versions[i], Uuid: uuid, Dropped: w.dropped}:
+				w.dropped = 0
+			default:
+				if w.dropped == 0 {
+					zap.L().Warn("WatchOverflow",
+						zap.String("prefix", w.prefix),
+						zap.Int("buffer", cap(w.c)),
+					)
+				}
+				w.dropped++
+				t.dropped++
+			}
+		}
+	}
+}
+
+// Watch returns a channel receiving the keys starting with prefix, every time
+// they are written by a committed query, until ctx is done. The channel is
+// then closed.
+//
+// Updates are buffered (see WatchBuffer), and dropped when the buffer is full
+// rather than slowing down the application of queries; the number of updates
+// dropped before an update is reported by its Dropped field.
+// This function is thread-safe.
+func (eng *Engine) Watch(ctx context.Context, prefix string) <-chan KeyUpdate {
+	buffer := eng.WatchBuffer
+	if buffer <= 0 {
+		buffer = DefaultWatchBuffer
+	}
+
+	w := eng.watches.add(prefix, buffer)
+	go func() {
+		<-ctx.Done()
+		eng.watches.remove(w)
+	}()
+	return w.c
+}
+
+// DroppedUpdates returns the number of key updates dropped because of slow
+// watchers, since the start of the engine.
+// This function is thread-safe.
+func (eng *Engine) DroppedUpdates() uint64 {
+	eng.watches.mutex.Lock()
+	defer eng.watches.mutex.Unlock()
+	return eng.watches.dropped
+}
@@ -0,0 +1,85 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package consensus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Watch(t *testing.T) {
+	qs := newQueryStore()
+	e := &Engine{Store: newMemoryStore(), qs: qs}
+	write := func(keys ...string) *Query {
+		q := NewQuery()
+		q.SetTimeout(time.Minute)
+		for _, key := range keys {
+			q.Operations = append(q.Operations, &Operation{Key: key, Op: Operation_SET, Data: []byte(key)})
+		}
+		qs.AddQuery(q)
+		require.Nil(t, e.apply(q.Uuid))
+		return q
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	prefixed := e.Watch(ctx, "a/")
+	all := e.Watch(ctx, "")
+
+	q1 := write("b/1", "a/1")
+	q2 := write("a/2")
+
+	for _, expected := range []struct {
+		key  string
+		uuid string
+	}{{"a/1", q1.Uuid}, {"a/2", q2.Uuid}} {
+		u := <-prefixed
+		require.Equal(t, expected.key, u.Key)
+		require.Equal(t, expected.uuid, u.Uuid)
+		require.Nil(t, u.Version.Matches(NewVersion([]byte(expected.key))))
+		require.Zero(t, u.Dropped)
+	}
+
+	var keys []string
+	for i := 0; i < 3; i++ {
+		keys = append(keys, (<-all).Key)
+	}
+	require.Equal(t, []string{"a/1", "b/1", "a/2"}, keys, "updates must follow the order of the writes")
+
+	cancel()
+	for range prefixed { // closed once the context is done
+	}
+}
+
+func TestEngine_WatchOverflow(t *testing.T) {
+	qs := newQueryStore()
+	e := &Engine{Store: newMemoryStore(), qs: qs, WatchBuffer: 1}
+	write := func() {
+		q := NewQuery()
+		q.SetTimeout(time.Minute)
+		q.Operations = []*Operation{{Key: "k", Op: Operation_ADD, Data: []byte("1")}}
+		qs.AddQuery(q)
+		require.Nil(t, e.apply(q.Uuid))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := e.Watch(ctx, "k")
+
+	for i := 0; i < 3; i++ {
+		write() // never blocked by the watcher
+	}
+	require.Zero(t, (<-c).Dropped)
+	require.EqualValues(t, 2, e.DroppedUpdates())
+
+	write()
+	require.EqualValues(t, 2, (<-c).Dropped, "the next update must report the dropped ones")
+}
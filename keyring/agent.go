@@ -0,0 +1,235 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// The agent protocol allows a KeyRing to delegate its signatures to another
+// process listening on a Unix socket, so that the private key never enters
+// the memory of the node. The agent may itself forward the requests to a
+// hardware token.
+//
+// Each request is followed by a response, and a connection may be used for
+// several requests. Both are made of a type byte, followed by a payload
+// prefixed by its length as an uvarint:
+//   - requests are agentPublic (without payload) and agentSign (cleartext);
+//   - responses are agentOK (public key or signature) and agentFailure
+//     (error message).
+const (
+	agentPublic  byte = 1
+	agentSign    byte = 2
+	agentOK      byte = 0x80
+	agentFailure byte = 0x81
+
+	maxAgentPayload = 16 << 20
+	agentTimeout    = time.Minute // hardware tokens may wait for a user presence
+)
+
+// ErrAgentProtocol is returned when an agent sends an invalid message.
+var ErrAgentProtocol = errors.New("invalid agent message")
+
+// ErrAgentFailure is returned when an agent fails to answer a request.
+type ErrAgentFailure struct {
+	Message string
+}
+
+// Error returns error's string value.
+func (e ErrAgentFailure) Error() string {
+	return "agent failure: " + e.Message
+}
+
+func writeAgentMessage(w io.Writer, t byte, payload []byte) error {
+	buf := make([]byte, 1+binary.MaxVarintLen64, 1+binary.MaxVarintLen64+len(payload))
+	buf[0] = t
+	n := binary.PutUvarint(buf[1:], uint64(len(payload)))
+	_, err := w.Write(append(buf[:1+n], payload...))
+	return err
+}
+
+func readAgentMessage(r *bufio.Reader) (t byte, payload []byte, err error) {
+	t, err = r.ReadByte()
+	if err != nil {
+		return
+	}
+
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return
+	}
+	if size > maxAgentPayload {
+		err = ErrAgentProtocol
+		return
+	}
+
+	payload = make([]byte, size)
+	_, err = io.ReadFull(r, payload)
+	return
+}
+
+// AgentSigner is a Signer delegating signatures to an agent listening on a
+// Unix socket, see ServeAgent. The connection is established again if it is
+// lost, so that the agent may be restarted.
+type AgentSigner struct {
+	path   string
+	public []byte
+
+	mutex  sync.Mutex // protects conn and reader, and serializes requests
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// DialAgent connects to the agent listening on a Unix socket, and fetches
+// its public key.
+func DialAgent(path string) (*AgentSigner, error) {
+	a := &AgentSigner{path: path}
+	public, err := a.request(agentPublic, nil)
+	if err != nil {
+		_ = a.Close()
+		return nil, err
+	}
+
+	a.public = public
+	return a, nil
+}
+
+// Public returns the public key of the agent, fetched by DialAgent.
+func (a *AgentSigner) Public() []byte {
+	return a.public
+}
+
+// Sign asks the agent to sign the message.
+// This function is thread-safe.
+func (a *AgentSigner) Sign(cleartext []byte) ([]byte, error) {
+	return a.request(agentSign, cleartext)
+}
+
+// Close closes the connection to the agent.
+// This function is thread-safe.
+func (a *AgentSigner) Close() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.closeUnsafe()
+}
+
+func (a *AgentSigner) closeUnsafe() error {
+	if a.conn == nil {
+		return nil
+	}
+
+	err := a.conn.Close()
+	a.conn, a.reader = nil, nil
+	return err
+}
+
+// request sends a request to the agent, connecting again once if the
+// connection has been lost.
+func (a *AgentSigner) request(t byte, payload []byte) ([]byte, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for retry := true; ; retry = false {
+		reconnected := a.conn == nil
+		if reconnected {
+			conn, err := net.DialTimeout("unix", a.path, agentTimeout)
+			if err != nil {
+				return nil, err
+			}
+			a.conn, a.reader = conn, bufio.NewReader(conn)
+		}
+
+		res, err := a.exchangeUnsafe(t, payload)
+		if _, ok := err.(ErrAgentFailure); ok || err == nil {
+			return res, err
+		}
+
+		_ = a.closeUnsafe()
+		if !retry || reconnected {
+			return nil, err
+		}
+	}
+}
+
+func (a *AgentSigner) exchangeUnsafe(t byte, payload []byte) ([]byte, error) {
+	err := a.conn.SetDeadline(time.Now().Add(agentTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeAgentMessage(a.conn, t, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	rt, res, err := readAgentMessage(a.reader)
+	if err != nil {
+		return nil, err
+	}
+
+	switch rt {
+	case agentOK:
+		return res, nil
+	case agentFailure:
+		return nil, ErrAgentFailure{Message: string(res)}
+	default:
+		return nil, ErrAgentProtocol
+	}
+}
+
+// ServeAgent answers the requests of AgentSigner instances on the
+// connections accepted by the listener, by forwarding them to a signer
+// (typically an unlocked KeyRing). It returns when the listener is closed.
+func ServeAgent(l net.Listener, s Signer) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go serveAgentConn(conn, s)
+	}
+}
+
+func serveAgentConn(conn net.Conn, s Signer) {
+	defer func() { _ = conn.Close() }()
+
+	r := bufio.NewReader(conn)
+	for {
+		t, payload, err := readAgentMessage(r)
+		if err != nil {
+			return
+		}
+
+		var res []byte
+		switch t {
+		case agentPublic:
+			res = s.Public()
+		case agentSign:
+			res, err = s.Sign(payload)
+		default:
+			err = ErrAgentProtocol
+		}
+
+		if err != nil {
+			err = writeAgentMessage(conn, agentFailure, []byte(err.Error()))
+		} else {
+			err = writeAgentMessage(conn, agentOK, res)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
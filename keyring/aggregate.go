@@ -0,0 +1,75 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package keyring
+
+// aggregateEngine is implemented by crypto engines able to combine several
+// signatures into a single one.
+type aggregateEngine interface {
+	// Aggregate returns the combination of signatures.
+	Aggregate(signatures [][]byte) ([]byte, error)
+	// VerifyAggregate checks an aggregate, publics[i] having signed cleartexts[i].
+	VerifyAggregate(publics, cleartexts [][]byte, signature []byte) bool
+}
+
+// CanAggregate returns true if the crypto engine of the KeyRing supports
+// signature aggregation (only bls12381 does).
+func (k *KeyRing) CanAggregate() bool {
+	_, ok := k.cryptoEngine.(aggregateEngine)
+	return ok
+}
+
+// Aggregate combines signatures of distinct messages into a single one,
+// to be checked with VerifyAggregate.
+//
+// It returns ErrNoAggregation if the crypto engine does not support
+// aggregation, and ErrInvalidSignature if a signature is malformed.
+func (k *KeyRing) Aggregate(signatures [][]byte) ([]byte, error) {
+	engine, ok := k.cryptoEngine.(aggregateEngine)
+	if !ok {
+		return nil, ErrNoAggregation
+	}
+	return engine.Aggregate(signatures)
+}
+
+// VerifyAggregate checks an aggregated signature, emitters[i] having signed
+// messages[i]. Like Verify, every emitter must be trusted. Messages must be
+// distinct.
+//
+// It may returns ErrNoAggregation, ErrUnknownIdentity, ErrKeyRevoked,
+// ErrKeyExpired, ErrInsufficientTrust or ErrInvalidSignature.
+//
+// This function is thread-safe.
+func (k *KeyRing) VerifyAggregate(emitters []string, messages [][]byte, signature []byte) error {
+	engine, ok := k.cryptoEngine.(aggregateEngine)
+	if !ok {
+		return ErrNoAggregation
+	}
+
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	k.waitForStaleCleared()
+
+	publics := make([][]byte, len(emitters))
+	for i, emitter := range emitters {
+		key, ok := k.keys[emitter]
+		if !ok {
+			return &ErrUnknownIdentity{I: emitter}
+		}
+
+		err := k.trustedUnsafe(key)
+		if err != nil {
+			return err
+		}
+		publics[i] = key.Public
+	}
+
+	if !engine.VerifyAggregate(publics, messages, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
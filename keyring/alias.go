@@ -0,0 +1,40 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import "bytes"
+
+// AliasPublic is like AddPublic, but the public key may already belong to
+// other identities. Since a signature then no longer designates a unique
+// identity, aliases shall only be used when a node really has several
+// identities, and never to count endorsements.
+//
+// The alias is kept by MarshalBinary, but is not taken into account by the
+// keyrings importing the key.
+//
+// This function is thread-safe.
+func (k *KeyRing) AliasPublic(identity string, trust TrustLevel, data []byte) error {
+	return k.addPublic(identity, trust, data, true)
+}
+
+// ownerUnsafe returns an identity other than the given one holding a public
+// key, if any. Aliases are ignored, since they accept to share their key.
+// unsafe
+func (k *KeyRing) ownerUnsafe(identity string, public []byte) (string, bool) {
+	if len(public) == 0 {
+		return "", false
+	}
+
+	for id, key := range k.keys {
+		if id != identity && !key.alias && bytes.Equal(key.Public, public) {
+			return id, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,141 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"strconv"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Default Argon2id parameters for new private keys.
+// They are stored in the PEM headers, so that they can be raised later
+// without breaking existing keyrings.
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024 // in KiB
+	argon2Threads = 4
+	argon2SaltLen = 16
+
+	argon2MaxMemory = 4 * 1024 * 1024 // in KiB, bounds the memory used to unlock a tampered block
+)
+
+const kdfArgon2id = "argon2id"
+
+var errInvalidArmor = errors.New("invalid private key armor")
+
+// armorPrivate encrypts a secret with AES-256-GCM, using a key derived from
+// password with Argon2id.
+func armorPrivate(secret, password []byte) (*pem.Block, error) {
+	salt := make([]byte, argon2SaltLen)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey(password, salt, argon2Time, argon2Memory, argon2Threads, 32)
+	defer wipe(key)
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pem.Block{
+		Type: pemPrivateType,
+		Headers: map[string]string{
+			"KDF":         kdfArgon2id,
+			"KDF-Salt":    hex.EncodeToString(salt),
+			"KDF-Time":    strconv.Itoa(argon2Time),
+			"KDF-Memory":  strconv.Itoa(argon2Memory),
+			"KDF-Threads": strconv.Itoa(argon2Threads),
+			"Cipher":      "AES-256-GCM",
+			"Nonce":       hex.EncodeToString(nonce),
+		},
+		Bytes: aead.Seal(nil, nonce, secret, []byte(pemPrivateType)),
+	}, nil
+}
+
+// unarmorPrivate decrypts a private key block, either produced by
+// armorPrivate or by the legacy x509.EncryptPEMBlock.
+func unarmorPrivate(block *pem.Block, password []byte) ([]byte, error) {
+	if legacyArmor(block) {
+		return x509.DecryptPEMBlock(block, password)
+	}
+
+	if block.Headers["KDF"] != kdfArgon2id || block.Headers["Cipher"] != "AES-256-GCM" {
+		return nil, errInvalidArmor
+	}
+
+	salt, err1 := hex.DecodeString(block.Headers["KDF-Salt"])
+	nonce, err2 := hex.DecodeString(block.Headers["Nonce"])
+	iterations, err3 := strconv.ParseUint(block.Headers["KDF-Time"], 10, 32)
+	memory, err4 := strconv.ParseUint(block.Headers["KDF-Memory"], 10, 32)
+	threads, err5 := strconv.ParseUint(block.Headers["KDF-Threads"], 10, 8)
+	for _, err := range []error{err1, err2, err3, err4, err5} {
+		if err != nil {
+			return nil, errInvalidArmor
+		}
+	}
+
+	if iterations == 0 || threads == 0 || memory > argon2MaxMemory {
+		return nil, errInvalidArmor
+	}
+
+	key := argon2.IDKey(password, salt, uint32(iterations), uint32(memory), uint8(threads), 32)
+	defer wipe(key)
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nonce) != aead.NonceSize() {
+		return nil, errInvalidArmor
+	}
+
+	secret, err := aead.Open(nil, nonce, block.Bytes, []byte(pemPrivateType))
+	if err != nil {
+		return nil, x509.IncorrectPasswordError
+	}
+	return secret, nil
+}
+
+// legacyArmor returns true if block has been encrypted by x509.EncryptPEMBlock.
+func legacyArmor(block *pem.Block) bool {
+	_, ok := block.Headers["KDF"]
+	return !ok && x509.IsEncryptedPEMBlock(block)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(c)
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
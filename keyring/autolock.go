@@ -0,0 +1,68 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SetAutoLock locks the private key once it has not been used by Sign for
+// d, so that it does not stay in clear in memory for the whole lifetime of a
+// process. Sign then returns ErrKeyRingLocked until UnlockPrivate is called
+// again. A zero duration disables the auto-lock.
+// This function is thread-safe.
+func (k *KeyRing) SetAutoLock(d time.Duration) {
+	k.secretMutex.Lock()
+	defer k.secretMutex.Unlock()
+
+	k.autoLock = d
+	k.startAutoLockUnsafe()
+}
+
+func (k *KeyRing) startAutoLockUnsafe() {
+	k.stopAutoLockUnsafe()
+	if k.autoLock <= 0 || k.secret == nil {
+		return
+	}
+
+	k.armAutoLockUnsafe(k.autoLock)
+}
+
+func (k *KeyRing) armAutoLockUnsafe(d time.Duration) {
+	gen := k.autoLockGen
+	k.autoLockTimer = time.AfterFunc(d, func() { k.autoLockExpired(gen) })
+}
+
+func (k *KeyRing) stopAutoLockUnsafe() {
+	if k.autoLockTimer != nil {
+		k.autoLockTimer.Stop()
+		k.autoLockTimer = nil
+	}
+	k.autoLockGen++ // a stopped timer may already be waiting for the lock
+}
+
+// autoLockExpired locks the private key if it has not been used since the
+// auto-lock duration, or re-arms the timer for the remaining time.
+func (k *KeyRing) autoLockExpired(gen int) {
+	k.secretMutex.Lock()
+	defer k.secretMutex.Unlock()
+
+	if gen != k.autoLockGen {
+		return // replaced in the meantime
+	}
+
+	idle := time.Since(time.Unix(0, atomic.LoadInt64(&k.lastUse)))
+	if idle < k.autoLock {
+		k.armAutoLockUnsafe(k.autoLock - idle)
+		return
+	}
+
+	k.lockUnsafe()
+}
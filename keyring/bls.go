@@ -0,0 +1,118 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import (
+	"crypto/rand"
+
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+func init() {
+	cryptoEngines["bls12381"] = blsEngine{}
+}
+
+// blsDomain separates the signatures of PnyxDB from the ones made by other
+// protocols with the same keys.
+var blsDomain = []byte("PNYXDB_BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_")
+
+// blsEngine uses BLS signatures over the BLS12-381 curve. Public keys are
+// compressed G1 points (48 bytes), signatures compressed G2 points (96 bytes),
+// and secret keys raw scalars (32 bytes).
+//
+// Signatures of distinct messages can be aggregated into a single one, see
+// KeyRing.Aggregate. Since aggregates of identical messages are refused,
+// keys do not need any proof of possession.
+type blsEngine struct{}
+
+// Generate returns the public key first, like ed25519.GenerateKey.
+func (blsEngine) Generate() (public, secret []byte, err error) {
+	sk, err := bls12381.NewFr().Rand(rand.Reader)
+	if err != nil {
+		return
+	}
+
+	g1 := bls12381.NewG1()
+	pk := g1.MulScalar(g1.New(), g1.One(), sk)
+	return g1.ToCompressed(pk), sk.ToBytes(), nil
+}
+
+func (blsEngine) Validate(public []byte) bool {
+	g1 := bls12381.NewG1()
+	pk, err := g1.FromCompressed(public)
+	return err == nil && !g1.IsZero(pk)
+}
+
+func (blsEngine) Sign(secret, cleartext []byte) []byte {
+	g2 := bls12381.NewG2()
+	h, err := g2.HashToCurve(cleartext, blsDomain)
+	if err != nil {
+		return nil
+	}
+
+	sk := bls12381.NewFr().FromBytes(secret)
+	return g2.ToCompressed(g2.MulScalar(g2.New(), h, sk))
+}
+
+func (e blsEngine) Verify(public, cleartext, signature []byte) bool {
+	return e.VerifyAggregate([][]byte{public}, [][]byte{cleartext}, signature)
+}
+
+// Aggregate adds up signatures.
+func (blsEngine) Aggregate(signatures [][]byte) ([]byte, error) {
+	g2 := bls12381.NewG2()
+	aggregate := g2.Zero()
+	for _, signature := range signatures {
+		s, err := g2.FromCompressed(signature)
+		if err != nil {
+			return nil, ErrInvalidSignature
+		}
+		g2.Add(aggregate, aggregate, s)
+	}
+	return g2.ToCompressed(aggregate), nil
+}
+
+// VerifyAggregate checks the aggregate of the signatures of distinct
+// messages, publics[i] having signed cleartexts[i].
+func (blsEngine) VerifyAggregate(publics, cleartexts [][]byte, signature []byte) bool {
+	if len(publics) == 0 || len(publics) != len(cleartexts) {
+		return false
+	}
+
+	seen := make(map[string]bool, len(cleartexts))
+	for _, cleartext := range cleartexts {
+		if seen[string(cleartext)] {
+			return false
+		}
+		seen[string(cleartext)] = true
+	}
+
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+	s, err := g2.FromCompressed(signature)
+	if err != nil {
+		return false
+	}
+
+	// e(g1, s) == e(pk_1, H(m_1)) * ... * e(pk_n, H(m_n))
+	engine := bls12381.NewEngine()
+	engine.AddPairInv(g1.One(), s)
+	for i, public := range publics {
+		pk, err := g1.FromCompressed(public)
+		if err != nil || g1.IsZero(pk) {
+			return false
+		}
+
+		h, err := g2.HashToCurve(cleartexts[i], blsDomain)
+		if err != nil {
+			return false
+		}
+		engine.AddPair(pk, h)
+	}
+	return engine.Check()
+}
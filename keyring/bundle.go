@@ -0,0 +1,203 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import (
+	"bytes"
+	"encoding/pem"
+	"sort"
+)
+
+// ExportAll exports every public key of the KeyRing, sorted by identity,
+// followed by the known revocations. Unlike MarshalBinary, the private key is
+// not exported, and the local key carries its identity and the TrustHIGH
+// level, so that the bundle can be imported by other nodes with ImportAll.
+// The local key is omitted until it has been created.
+//
+// This function is thread-safe.
+func (k *KeyRing) ExportAll() ([]byte, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	identities := make([]string, 0, len(k.keys))
+	for identity := range k.keys {
+		identities = append(identities, identity)
+	}
+	sort.Strings(identities)
+
+	var buf []byte
+	for _, identity := range identities {
+		if len(k.keys[identity].Public) == 0 { // local key not created yet
+			continue
+		}
+
+		b, err := k.publicBlockUnsafe(k.keys[identity])
+		if err != nil {
+			return nil, err
+		}
+
+		if identity == k.selfIdentity {
+			b.Headers["trust"] = TrustHIGH.String()
+		}
+		buf = append(buf, pem.EncodeToMemory(b)...)
+	}
+
+	for _, identity := range identities {
+		key := k.keys[identity]
+		r, ok := k.revocations[string(key.Public)]
+		if !ok || len(key.Public) == 0 {
+			continue
+		}
+
+		raw, err := encodeRevocation(r, identity, k.crypto)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, raw...)
+	}
+
+	return buf, nil
+}
+
+// ImportAll imports every public key of a bundle made by ExportAll, with the
+// trust level of its header, along with revocations. Private blocks are
+// ignored. It returns the number of imported keys, including the ones that
+// were already known, whose local trust is kept and whose signatures are
+// merged (their metadata are only completed, see SetMeta).
+//
+// The signatures made by imported keys are verified; the ones of keys that
+// are neither in the bundle nor in the KeyRing cannot be verified, and are
+// discarded. Nothing is imported if any block is invalid, if some
+// identities are already bound to other public keys (ErrIdentityConflict),
+// or if a new public key already belongs to another identity
+// (ErrDuplicatePublicKey). Aliases of the bundle are not kept.
+//
+// This function is thread-safe.
+func (k *KeyRing) ImportAll(data []byte) (int, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if len(data) > MaxKeyRingSize {
+		return 0, ErrKeyRingTooLarge
+	}
+
+	bundle := make(map[string]*Key)
+	var keys []*Key
+	var revocations []*Revocation
+	var conflicts []string
+	for len(bytes.TrimSpace(data)) > 0 {
+		block, rest, err := decodeBlock(data)
+		if err != nil {
+			return 0, err
+		}
+		data = rest
+
+		err = k.checkCrypto(block)
+		if err != nil {
+			return 0, err
+		}
+
+		switch block.Type {
+		case pemRevocationType:
+			r, err := k.decodeRevocation(block)
+			if err != nil {
+				return 0, err
+			}
+			revocations = append(revocations, r)
+
+		case pemPublicType:
+			key, err := k.decodePublic(block)
+			if err != nil {
+				return 0, err
+			}
+
+			if key.identity == "" || len(key.Public) == 0 {
+				return 0, ErrInvalidIdentity
+			}
+
+			known, ok := bundle[key.identity]
+			if !ok {
+				known, ok = k.keys[key.identity]
+			}
+			if ok && !bytes.Equal(known.Public, key.Public) {
+				conflicts = append(conflicts, key.identity)
+				continue
+			}
+
+			key.alias = false
+			if !ok {
+				if owner, dup := k.ownerUnsafe(key.identity, key.Public); dup {
+					return 0, &ErrDuplicatePublicKey{I: owner}
+				}
+				for _, other := range keys {
+					if bytes.Equal(other.Public, key.Public) {
+						return 0, &ErrDuplicatePublicKey{I: other.identity}
+					}
+				}
+			}
+
+			if key.Signatures == nil {
+				key.Signatures = make(map[string]*Signature)
+			}
+			bundle[key.identity] = key
+			keys = append(keys, key)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return 0, &ErrIdentityConflict{I: conflicts}
+	}
+
+	for _, key := range keys {
+		for identity, signature := range key.Signatures {
+			signee, ok := bundle[identity]
+			if !ok {
+				signee, ok = k.keys[identity]
+			}
+
+			if !ok {
+				delete(key.Signatures, identity)
+				continue
+			}
+
+			if !k.cryptoEngine.Verify(key.Public, signedMessage(signee, signature.Trust, signature.CreatedAt), signature.Data) {
+				return 0, ErrInvalidSignature
+			}
+		}
+	}
+
+	var count int
+	for _, key := range keys {
+		if key.identity == k.selfIdentity {
+			continue
+		}
+
+		count++
+		known, ok := k.keys[key.identity]
+		if !ok {
+			k.keys[key.identity] = key
+			continue
+		}
+
+		if known.Signatures == nil {
+			known.Signatures = make(map[string]*Signature)
+		}
+		for identity, signature := range key.Signatures {
+			known.Signatures[identity] = signature
+		}
+		known.mergeMeta(key)
+	}
+
+	for _, r := range revocations {
+		k.revocations[string(r.Public)] = r
+	}
+
+	k.changed()
+	return count, nil
+}
@@ -9,7 +9,9 @@
 package keyring
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha512"
 
 	"golang.org/x/crypto/ed25519"
 )
@@ -24,6 +26,17 @@ func (ed25519Engine) Generate() (secret, public []byte, err error) {
 	return ed25519.GenerateKey(rand.Reader)
 }
 
+// GenerateFromSeed derives the master key of SLIP-0010 from a BIP39 seed.
+func (ed25519Engine) GenerateFromSeed(seed []byte) (public, secret []byte, err error) {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	_, _ = mac.Write(seed)
+	sum := mac.Sum(nil)
+	defer wipe(sum)
+
+	key := ed25519.NewKeyFromSeed(sum[:ed25519.SeedSize])
+	return key.Public().(ed25519.PublicKey), key, nil
+}
+
 func (ed25519Engine) Validate(public []byte) bool {
 	return ed25519.PublicKeySize == len(public)
 }
@@ -0,0 +1,345 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// Encryption errors
+var (
+	ErrNoEncryptionKey = errors.New("no encryption key for this identity")
+	ErrNotEncrypted    = errors.New("value is not an encrypted envelope")
+	ErrInvalidEnvelope = errors.New("invalid encrypted envelope")
+	ErrNotRecipient    = errors.New("value is not encrypted for this identity")
+	ErrNoRecipients    = errors.New("no recipient")
+)
+
+// Domain separation of the hashes and signatures used for encryption
+var (
+	encryptionKeyDomain = []byte("pnyxdb-x25519")
+	encryptionSigDomain = []byte("pnyxdb-encryption-key:")
+	envelopeKeyDomain   = []byte("pnyxdb-envelope")
+)
+
+// envelopeMagic prefixes the values encrypted with EncryptFor.
+const envelopeMagic = "pnyxdb-enc1:"
+
+// An envelope is a value encrypted with a random data key, itself wrapped
+// for each recipient with an X25519 key agreement.
+type envelope struct {
+	Ephemeral  []byte // X25519 public key, generated for this value
+	Recipients []*envelopeRecipient
+	Ciphertext []byte // nonce followed by the AES-256-GCM sealed value
+}
+
+type envelopeRecipient struct {
+	Identity string // informative, recipients are matched by Key
+	Key      []byte // X25519 public key of the recipient
+	Wrapped  []byte // nonce followed by the sealed data key
+}
+
+// deriveEncryption returns the X25519 key pair derived from the secret of a
+// signature key, along with the signature of the public key by this secret.
+// Keys of every crypto engine are supported, since the X25519 secret is a
+// hash of the signature secret.
+func (k *KeyRing) deriveEncryption(secret []byte) (public [32]byte, signature []byte) {
+	private := encryptionPrivate(secret)
+	defer wipe(private[:])
+	curve25519.ScalarBaseMult(&public, &private)
+	signature = k.cryptoEngine.Sign(secret, encryptionMessage(public[:]))
+	return
+}
+
+func encryptionPrivate(secret []byte) (private [32]byte) {
+	h := sha256.New()
+	_, _ = h.Write(encryptionKeyDomain)
+	_, _ = h.Write(secret)
+	copy(private[:], h.Sum(nil))
+	return
+}
+
+func encryptionMessage(public []byte) []byte {
+	return append(append([]byte{}, encryptionSigDomain...), public...)
+}
+
+// verifyEncryption returns true if the encryption key of key, if any, has
+// been signed by its signature key.
+func (k *KeyRing) verifyEncryption(key *Key) bool {
+	if len(key.Encryption) == 0 && len(key.EncryptionSignature) == 0 {
+		return true
+	}
+	return len(key.Encryption) == 32 && k.Validate(key.Public) &&
+		k.cryptoEngine.Verify(key.Public, encryptionMessage(key.Encryption), key.EncryptionSignature)
+}
+
+// setSelfEncryption publishes the encryption key derived from the local
+// secret with the local public key.
+// This function is thread-safe.
+func (k *KeyRing) setSelfEncryption(public [32]byte, signature []byte) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	self := k.keys[k.selfIdentity]
+	if len(self.Public) == 0 || bytes.Equal(self.Encryption, public[:]) {
+		return // the public key of the secret is not known yet
+	}
+
+	self.Encryption = append([]byte{}, public[:]...)
+	self.EncryptionSignature = signature
+	k.notify()
+}
+
+// EncryptionKey returns the X25519 public key used to encrypt values for an
+// identity, the empty identity meaning the local one.
+//
+// It may returns ErrUnknownIdentity or ErrNoEncryptionKey.
+//
+// This function is thread-safe.
+func (k *KeyRing) EncryptionKey(identity string) ([]byte, error) {
+	if identity == "" {
+		identity = k.selfIdentity
+	}
+
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	key, ok := k.keys[identity]
+	if !ok {
+		return nil, &ErrUnknownIdentity{I: identity}
+	}
+	if len(key.Encryption) == 0 {
+		return nil, ErrNoEncryptionKey
+	}
+	return append([]byte{}, key.Encryption...), nil
+}
+
+// EncryptFor encrypts a value so that only the provided identities can read
+// it with Decrypt. Recipients must be trusted, and have published an
+// encryption key (see Key.Encryption). The local identity is not a recipient
+// unless it is listed.
+//
+// It may returns ErrNoRecipients, ErrNoEncryptionKey or any error of Trusted.
+//
+// This function is thread-safe.
+func (k *KeyRing) EncryptFor(plaintext []byte, recipients ...string) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+
+	keys := make([][]byte, len(recipients))
+	for i, identity := range recipients {
+		if identity != k.selfIdentity {
+			if err := k.Trusted(identity); err != nil {
+				return nil, err
+			}
+		}
+
+		var err error
+		keys[i], err = k.EncryptionKey(identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var ephemeralPrivate, ephemeral [32]byte
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, ephemeralPrivate[:]); err != nil {
+		return nil, err
+	}
+	defer wipe(ephemeralPrivate[:])
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	defer wipe(dataKey)
+	curve25519.ScalarBaseMult(&ephemeral, &ephemeralPrivate)
+
+	e := &envelope{Ephemeral: ephemeral[:]}
+	var err error
+	e.Ciphertext, err = seal(dataKey, plaintext, e.Ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, identity := range recipients {
+		kek, err := wrappingKey(&ephemeralPrivate, keys[i], e.Ephemeral, keys[i])
+		if err != nil {
+			return nil, err
+		}
+
+		wrapped, err := seal(kek, dataKey, nil)
+		wipe(kek)
+		if err != nil {
+			return nil, err
+		}
+
+		e.Recipients = append(e.Recipients, &envelopeRecipient{
+			Identity: identity,
+			Key:      keys[i],
+			Wrapped:  wrapped,
+		})
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(envelopeMagic), data...), nil
+}
+
+// Encrypted returns true if a value has been encrypted with EncryptFor.
+func Encrypted(value []byte) bool {
+	return bytes.HasPrefix(value, []byte(envelopeMagic))
+}
+
+// Recipients returns the identities a value has been encrypted for, as
+// declared by its sender.
+//
+// It may returns ErrNotEncrypted or ErrInvalidEnvelope.
+func Recipients(value []byte) ([]string, error) {
+	e, err := decodeEnvelope(value)
+	if err != nil {
+		return nil, err
+	}
+
+	identities := make([]string, len(e.Recipients))
+	for i, r := range e.Recipients {
+		identities[i] = r.Identity
+	}
+	return identities, nil
+}
+
+// Decrypt returns the plaintext of a value encrypted with EncryptFor for the
+// local identity. The keyring must be unlocked: keyrings using an external
+// signer cannot decrypt values.
+//
+// It may returns ErrNotEncrypted, ErrInvalidEnvelope, ErrKeyRingLocked,
+// ErrNoEncryptionKey or ErrNotRecipient.
+//
+// This function is thread-safe.
+func (k *KeyRing) Decrypt(value []byte) ([]byte, error) {
+	e, err := decodeEnvelope(value)
+	if err != nil {
+		return nil, err
+	}
+
+	k.secretMutex.RLock()
+	if k.secret == nil {
+		k.secretMutex.RUnlock()
+		if k.getSigner() != nil {
+			return nil, ErrNoEncryptionKey
+		}
+		return nil, ErrKeyRingLocked
+	}
+	private := encryptionPrivate(k.secret.Buffer())
+	k.secretMutex.RUnlock()
+	defer wipe(private[:])
+
+	var public [32]byte
+	curve25519.ScalarBaseMult(&public, &private)
+
+	for _, r := range e.Recipients {
+		if subtle.ConstantTimeCompare(r.Key, public[:]) != 1 {
+			continue
+		}
+
+		kek, err := wrappingKey(&private, e.Ephemeral, e.Ephemeral, public[:])
+		if err != nil {
+			return nil, err
+		}
+
+		dataKey, err := open(kek, r.Wrapped, nil)
+		wipe(kek)
+		if err != nil {
+			return nil, ErrInvalidEnvelope
+		}
+		defer wipe(dataKey)
+
+		plaintext, err := open(dataKey, e.Ciphertext, e.Ephemeral)
+		if err != nil {
+			return nil, ErrInvalidEnvelope
+		}
+		return plaintext, nil
+	}
+
+	return nil, ErrNotRecipient
+}
+
+func decodeEnvelope(value []byte) (*envelope, error) {
+	if !Encrypted(value) {
+		return nil, ErrNotEncrypted
+	}
+
+	e := &envelope{}
+	err := json.Unmarshal(value[len(envelopeMagic):], e)
+	if err != nil || len(e.Ephemeral) != 32 {
+		return nil, ErrInvalidEnvelope
+	}
+	return e, nil
+}
+
+// wrappingKey derives the key wrapping the data key of an envelope for a
+// recipient, from the X25519 agreement between private and peer.
+func wrappingKey(private *[32]byte, peer, ephemeral, recipient []byte) ([]byte, error) {
+	if len(peer) != 32 {
+		return nil, ErrInvalidEnvelope
+	}
+
+	var p, shared [32]byte
+	copy(p[:], peer)
+	curve25519.ScalarMult(&shared, private, &p)
+	defer wipe(shared[:])
+
+	var zero [32]byte
+	if subtle.ConstantTimeCompare(shared[:], zero[:]) == 1 {
+		return nil, ErrInvalidPublicKey
+	}
+
+	h := sha256.New()
+	_, _ = h.Write(envelopeKeyDomain)
+	_, _ = h.Write(shared[:])
+	_, _ = h.Write(ephemeral)
+	_, _ = h.Write(recipient)
+	return h.Sum(nil), nil
+}
+
+// seal encrypts data with AES-256-GCM, and prepends the random nonce.
+func seal(key, data, additional []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, data, additional), nil
+}
+
+func open(key, sealed, additional []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidEnvelope
+	}
+	n := aead.NonceSize()
+	return aead.Open(nil, sealed[:n], sealed[n:], additional)
+}
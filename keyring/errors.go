@@ -12,14 +12,28 @@ package keyring
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
 
 // Error messages.
 var (
-	ErrKeyRingLocked    = errors.New("keyring is locked")
-	ErrInvalidIdentity  = errors.New("invalid identity")
-	ErrInvalidPublicKey = errors.New("invalid public key")
-	ErrInvalidSignature = errors.New("invalid signature")
+	ErrKeyRingLocked     = errors.New("keyring is locked")
+	ErrInvalidIdentity   = errors.New("invalid identity")
+	ErrInvalidPublicKey  = errors.New("invalid public key")
+	ErrInvalidSignature  = errors.New("invalid signature")
+	ErrMissingPrivateKey = errors.New("missing private key")
+	ErrMissingSignature  = errors.New("missing signature")
+	ErrForeignSignature  = errors.New("signature made by another key")
+	ErrInvalidMnemonic   = errors.New("invalid mnemonic")
+	ErrNoMnemonic        = errors.New("crypto engine does not support mnemonics")
+	ErrNoAggregation     = errors.New("crypto engine does not support signature aggregation")
+	ErrSelfKeyChanged    = errors.New("local public key has changed")
+	ErrKeyRingTooLarge   = errors.New("keyring data too large")
+	ErrTooManySignatures = errors.New("too many signatures")
+	ErrMalformedBlock    = errors.New("malformed PEM block")
+	ErrUnknownBlockType  = errors.New("unknown block type")
+	ErrInvalidMeta       = errors.New("invalid metadata field or value")
 )
 
 // ErrUnknownIdentity is returned when an operation is asked for an unknown identity.
@@ -43,6 +57,47 @@ func (e ErrInsufficientTrust) Error() string {
 	return fmt.Sprintf("insufficient trust for identity %s (%d/%d)", e.I, e.L, TrustThreshold)
 }
 
+// ErrKeyExpired is returned when a verification cannot be performed because one's public key has expired.
+type ErrKeyExpired struct {
+	I string
+	T time.Time
+}
+
+// Error returns error's string value.
+func (e ErrKeyExpired) Error() string {
+	return fmt.Sprintf("key of identity %s expired on %s", e.I, e.T.Format(time.RFC3339))
+}
+
+// ErrKeyRevoked is returned when a verification cannot be performed because one's public key has been revoked.
+type ErrKeyRevoked struct {
+	I string
+}
+
+// Error returns error's string value.
+func (e ErrKeyRevoked) Error() string {
+	return "key of identity " + e.I + " has been revoked"
+}
+
+// ErrIdentityConflict is returned when importing keys whose identities are already bound to other public keys.
+type ErrIdentityConflict struct {
+	I []string
+}
+
+// Error returns error's string value.
+func (e ErrIdentityConflict) Error() string {
+	return "identities already bound to other public keys: " + strings.Join(e.I, ", ")
+}
+
+// ErrDuplicatePublicKey is returned when adding a public key which already belongs to another identity, see AliasPublic.
+type ErrDuplicatePublicKey struct {
+	I string // identity holding the public key
+}
+
+// Error returns error's string value.
+func (e ErrDuplicatePublicKey) Error() string {
+	return "public key already belongs to identity " + e.I
+}
+
 // ErrUnknownCryptoEngine is returned when an operation requires an unknown crypto engine.
 type ErrUnknownCryptoEngine struct {
 	CE string
@@ -51,3 +106,45 @@ type ErrUnknownCryptoEngine struct {
 func (e ErrUnknownCryptoEngine) Error() string {
 	return "unknown crypto engine: " + e.CE
 }
+
+// ErrCryptoMismatch is returned when loading a key generated with another crypto engine than the KeyRing's one.
+type ErrCryptoMismatch struct {
+	CE string
+}
+
+func (e ErrCryptoMismatch) Error() string {
+	return "key generated with another crypto engine: " + e.CE
+}
+
+// SkippedBlock describes a block of a marshaled KeyRing that could not be loaded.
+type SkippedBlock struct {
+	Index    int    // position of the block, starting at 0
+	Type     string // PEM type, empty if the block could not be decoded
+	Identity string // "identity" header, if any
+	Err      error
+}
+
+func (b SkippedBlock) String() string {
+	s := fmt.Sprintf("#%d", b.Index)
+	if b.Type != "" {
+		s += " " + b.Type
+	}
+	if b.Identity != "" {
+		s += " (" + b.Identity + ")"
+	}
+	return s + ": " + b.Err.Error()
+}
+
+// ErrSkippedBlocks is returned when some blocks of a marshaled KeyRing have been skipped while loading it.
+// The other blocks are loaded nonetheless.
+type ErrSkippedBlocks struct {
+	Blocks []SkippedBlock
+}
+
+func (e ErrSkippedBlocks) Error() string {
+	blocks := make([]string, len(e.Blocks))
+	for i, b := range e.Blocks {
+		blocks[i] = b.String()
+	}
+	return fmt.Sprintf("%d keyring blocks skipped: %s", len(e.Blocks), strings.Join(blocks, "; "))
+}
@@ -0,0 +1,62 @@
+//go:build go1.18
+// +build go1.18
+
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// addFuzzSeeds adds the test keyrings and the corrupted keyrings of testdata
+// to the seed corpus.
+func addFuzzSeeds(f *testing.F) {
+	for _, block := range armoredTestKeyRing {
+		f.Add([]byte(block))
+	}
+	f.Add([]byte(armoredTestKeyRingJoined))
+
+	files, err := filepath.Glob(filepath.Join("testdata", "corrupt", "*.pem"))
+	require.Nil(f, err)
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		require.Nil(f, err)
+		f.Add(data)
+	}
+}
+
+func FuzzKeyRing_UnmarshalBinary(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		k, _ := NewKeyRing("k1", "ed25519")
+		err := k.UnmarshalBinary(data)
+		if skipped, ok := err.(ErrSkippedBlocks); ok {
+			require.NotEmpty(t, skipped.Blocks)
+		}
+
+		_, err = k.MarshalBinary()
+		require.Nil(t, err)
+	})
+}
+
+func FuzzKeyRing_Import(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		k, _ := NewKeyRing("k1", "ed25519")
+		if k.Import(data, "k0", TrustHIGH) == nil {
+			_, _, err := k.GetPublic("k0")
+			require.Nil(t, err)
+		}
+	})
+}
@@ -10,21 +10,21 @@ package keyring
 
 import (
 	"bytes"
-	"crypto/rand"
-	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
-	"io"
 	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/awnumar/memguard"
 )
 
 // Signature represents a local or third-party public key's signature.
 type Signature struct {
-	Data  []byte
-	Trust TrustLevel
+	Data      []byte
+	Trust     TrustLevel
+	CreatedAt time.Time // zero for signatures made before creation dates were recorded
 }
 
 type cryptoEngine interface {
@@ -36,15 +36,31 @@ type cryptoEngine interface {
 
 var cryptoEngines = make(map[string]cryptoEngine)
 
+// legacyCrypto is the crypto engine of the keyrings stored before the
+// "crypto" PEM header was introduced.
+const legacyCrypto = "ed25519"
+
 // Key is the representation of a Key for the KeyRing.
 type Key struct {
 	Public     []byte
 	Signatures map[string]*Signature
+	Expiry     time.Time `json:"-"` // zero if the key never expires, exported as a PEM header
+
+	// X25519 public key used to encrypt values for the identity (see
+	// EncryptFor), derived from the secret key and signed by it.
+	Encryption          []byte `json:",omitempty"`
+	EncryptionSignature []byte `json:",omitempty"`
 
 	identity       string
+	alias          bool // may share its public key with other identities, see AliasPublic
 	signedBy       []*Key
-	trust          TrustLevel // set by user
-	effectiveTrust TrustLevel // computed from web of trust, >= trust
+	trustEdges     []TrustEdge       // provenance of effectiveTrust, computed with signedBy
+	trust          TrustLevel        // set by user
+	effectiveTrust TrustLevel        // computed from web of trust, >= trust
+	trusted        bool              // propagates its signatures in the web of trust
+	depth          int               // hops from a key trusted locally, if trusted (see trustDepth)
+	signers        map[string]bool   // identities having signed the key, trusted or not
+	meta           map[string]string // unauthenticated, see KeyRing.SetMeta
 }
 
 // Info shall be used to get basic informations about this key.
@@ -52,26 +68,62 @@ func (k *Key) Info() (identity string, data []byte, trust TrustLevel) {
 	return k.identity, k.Public, k.trust
 }
 
-// KeyRing is a KeyRing saving data as PEM, and using the Ed25519
-// high-speed high-security signatures algorithm.
+// EffectiveTrust returns the trust level computed from the web of trust,
+// which is never lower than the trust level set by the user.
+func (k *Key) EffectiveTrust() TrustLevel {
+	return k.effectiveTrust
+}
+
+// Signers returns the sorted identities of the trusted keys whose signatures
+// have been added to the effective trust of the key.
+func (k *Key) Signers() []string {
+	signers := make([]string, len(k.signedBy))
+	for i, signer := range k.signedBy {
+		signers[i] = signer.identity
+	}
+
+	sort.Strings(signers)
+	return signers
+}
+
+// Expired returns true if the key has an expiry which is not after t.
+func (k *Key) Expired(t time.Time) bool {
+	return !k.Expiry.IsZero() && !k.Expiry.After(t)
+}
+
+// KeyRing is a KeyRing saving data as PEM, and using a pluggable signature
+// algorithm (Ed25519 or secp256k1).
 //
 // This KeyRing also provides a lazy web of trust computation feature,
 // similar to PGP's web of trust.
 type KeyRing struct {
 	cryptoEngine
-
-	selfIdentity  string
-	mutex         sync.RWMutex
-	keys          map[string]*Key
-	secret        *memguard.LockedBuffer
-	armoredSecret *pem.Block
-	stale         bool
+	crypto string // name of cryptoEngine
+
+	selfIdentity    string
+	mutex           sync.RWMutex
+	keys            map[string]*Key
+	secretMutex     sync.RWMutex // protects secret and the auto-lock state, see SetAutoLock
+	secret          *memguard.LockedBuffer
+	signer          Signer // replaces secret if set, see SetSigner
+	autoLock        time.Duration
+	autoLockTimer   *time.Timer
+	autoLockGen     int   // incremented when the timer is replaced
+	lastUse         int64 // last use of the secret by Sign, in Unix nanoseconds (atomic)
+	armoredSecret   *pem.Block
+	stale           bool
+	nextExpiry      time.Time              // earliest expiry (of a key or a signature) that will change the web of trust
+	revocations     map[string]*Revocation // by public key, see Revoke
+	maxTrustDepth   int                    // 0 if unlimited, see SetMaxTrustDepth
+	maxSignatureAge time.Duration          // 0 if unlimited, see SetMaxSignatureAge
+	watchers        map[chan struct{}]bool
 }
 
 // NewKeyRing instanciates a new KeyRing.
 // It MUST be called to create a new KeyRing.
 //
-// cryptoType may only be "ed25519" at the moment.
+// crypto may be "ed25519" or "secp256k1".
+// A KeyRing refuses to load keys generated with another crypto engine.
 func NewKeyRing(selfIdentity string, crypto string) (*KeyRing, error) {
 	ce, ok := cryptoEngines[crypto]
 	if !ok {
@@ -80,6 +132,7 @@ func NewKeyRing(selfIdentity string, crypto string) (*KeyRing, error) {
 
 	return &KeyRing{
 		cryptoEngine: ce,
+		crypto:       crypto,
 		selfIdentity: selfIdentity,
 		keys: map[string]*Key{
 			selfIdentity: {
@@ -89,6 +142,9 @@ func NewKeyRing(selfIdentity string, crypto string) (*KeyRing, error) {
 				Signatures:     make(map[string]*Signature),
 			},
 		},
+		revocations: make(map[string]*Revocation),
+		watchers:    make(map[chan struct{}]bool),
+		stale:       true,
 	}, nil
 }
 
@@ -97,39 +153,83 @@ func (k *KeyRing) Identity() string {
 	return k.selfIdentity
 }
 
+// Crypto returns the name of the crypto engine of the KeyRing.
+func (k *KeyRing) Crypto() string {
+	return k.crypto
+}
+
 // Locked returns wether the KeyRing is currently locked or not (private key in cleartext in memory).
+// A KeyRing with an external signer is never locked.
+// This function is thread-safe.
 func (k *KeyRing) Locked() bool {
-	return k.secret == nil
+	k.secretMutex.RLock()
+	defer k.secretMutex.RUnlock()
+	return k.secret == nil && k.signer == nil
 }
 
 // LockPrivate locks the KeyRing by removing any remaining clear private key data in memory.
+// This function is thread-safe.
 func (k *KeyRing) LockPrivate() (err error) {
-	if k.Locked() {
+	k.secretMutex.Lock()
+	defer k.secretMutex.Unlock()
+	k.lockUnsafe()
+	return
+}
+
+func (k *KeyRing) lockUnsafe() {
+	k.stopAutoLockUnsafe()
+	if k.secret == nil {
 		return // already locked
 	}
 
 	k.secret.Destroy()
 	k.secret = nil
-	return
 }
 
 // UnlockPrivate tries to decypher the private key block in memory.
+// This function is thread-safe.
 func (k *KeyRing) UnlockPrivate(password *memguard.LockedBuffer) (err error) {
 	if !k.Locked() {
 		return // already unlocked
 	}
 
 	var secret []byte
-	secret, err = x509.DecryptPEMBlock(k.armoredSecret, password.Buffer())
+	secret, err = unarmorPrivate(k.armoredSecret, password.Buffer())
 	if err != nil {
 		return
 	}
 
+	return k.setSecret(secret)
+}
+
+// setSecret replaces the clear private key, starts the auto-lock timer, and
+// publishes the encryption key derived from the private key.
+func (k *KeyRing) setSecret(secret []byte) error {
+	encryption, signature := k.deriveEncryption(secret) // before secret is wiped
+	if err := k.storeSecret(secret); err != nil {
+		return err
+	}
+
+	k.setSelfEncryption(encryption, signature)
+	return nil
+}
+
+func (k *KeyRing) storeSecret(secret []byte) (err error) {
+	k.secretMutex.Lock()
+	defer k.secretMutex.Unlock()
+
+	k.lockUnsafe()
 	k.secret, err = memguard.NewImmutableFromBytes(secret)
+	if err != nil {
+		return
+	}
+
+	atomic.StoreInt64(&k.lastUse, time.Now().UnixNano())
+	k.startAutoLockUnsafe()
 	return
 }
 
-// CreatePrivate generates a new Ed25519 private key and its associated PEM-armored block.
+// CreatePrivate generates a new private key and its associated PEM-armored block.
 func (k *KeyRing) CreatePrivate(password *memguard.LockedBuffer) (err error) {
 	var secret []byte
 	k.keys[k.selfIdentity].Public, secret, err = k.Generate()
@@ -137,26 +237,77 @@ func (k *KeyRing) CreatePrivate(password *memguard.LockedBuffer) (err error) {
 		return
 	}
 
-	k.secret, err = memguard.NewImmutableFromBytes(secret)
+	err = k.setSecret(secret)
 	if err != nil {
 		return
 	}
 
 	// Generate private key PEM
-	k.armoredSecret, err = x509.EncryptPEMBlock(
-		rand.Reader,
-		pemPrivateType,
-		k.secret.Buffer(),
-		password.Buffer(),
-		pemCipher,
-	)
-	return
+	return k.ReEncryptPrivate(password)
+}
+
+// ReEncryptPrivate encrypts the private key with password, replacing its
+// PEM-armored block. The KeyRing must be unlocked.
+//
+// Private keys are encrypted with AES-256-GCM and a key derived with
+// Argon2id. Keys encrypted with the legacy format (AES-256-CBC without
+// KDF, see LegacyPrivate) can still be unlocked, and shall be migrated
+// with this function.
+func (k *KeyRing) ReEncryptPrivate(password *memguard.LockedBuffer) error {
+	k.secretMutex.RLock()
+	defer k.secretMutex.RUnlock()
+
+	if k.secret == nil {
+		return ErrKeyRingLocked
+	}
+
+	return k.setArmor(k.secret.Buffer(), password)
+}
+
+// ChangePassword re-encrypts the private key with a new password.
+// The private key is decrypted with the old password first: if it is wrong,
+// an error is returned and the KeyRing is left untouched. The public key,
+// the signatures and the lock state of the KeyRing are preserved.
+func (k *KeyRing) ChangePassword(oldPassword, newPassword *memguard.LockedBuffer) error {
+	if k.armoredSecret == nil {
+		return ErrMissingPrivateKey
+	}
+
+	secret, err := unarmorPrivate(k.armoredSecret, oldPassword.Buffer())
+	if err != nil {
+		return err
+	}
+	defer wipe(secret)
+
+	return k.setArmor(secret, newPassword)
+}
+
+func (k *KeyRing) setArmor(secret []byte, password *memguard.LockedBuffer) error {
+	block, err := armorPrivate(secret, password.Buffer())
+	if err != nil {
+		return err
+	}
+
+	block.Headers["crypto"] = k.crypto
+	k.armoredSecret = block
+	return nil
+}
+
+// LegacyPrivate returns true if the private key is encrypted with the legacy
+// format, and shall be migrated with ReEncryptPrivate.
+func (k *KeyRing) LegacyPrivate() bool {
+	return k.armoredSecret != nil && legacyArmor(k.armoredSecret)
 }
 
 // GetPrivate returns a memguarded slice containing the raw private key.
 // This can be useful when sharing a private key between several objects
-// (for instance between a KeyRing and a consensus.Network)
+// (for instance between a KeyRing and a consensus.Network).
+// The slice is wiped when the KeyRing is locked: it shall be copied by
+// objects keeping the private key (see SetAutoLock).
 func (k *KeyRing) GetPrivate() []byte {
+	k.secretMutex.RLock()
+	defer k.secretMutex.RUnlock()
+
 	if k.secret == nil {
 		return nil
 	}
@@ -167,8 +318,15 @@ func (k *KeyRing) GetPrivate() []byte {
 // AddPublic adds or overwrite a new public key in the keyring.
 // It resets the related signatures if the key is modified.
 //
+// It returns ErrDuplicatePublicKey if the public key belongs to another
+// identity, unless the identity is already an alias of this key.
+//
 // This function is thread-safe.
 func (k *KeyRing) AddPublic(identity string, trust TrustLevel, data []byte) (err error) {
+	return k.addPublic(identity, trust, data, false)
+}
+
+func (k *KeyRing) addPublic(identity string, trust TrustLevel, data []byte, alias bool) (err error) {
 	k.mutex.Lock()
 	defer k.mutex.Unlock()
 
@@ -181,30 +339,49 @@ func (k *KeyRing) AddPublic(identity string, trust TrustLevel, data []byte) (err
 	}
 
 	key, ok := k.keys[identity]
+	unchanged := ok && bytes.Equal(key.Public, data)
+	if !alias && !(unchanged && key.alias) {
+		if owner, ok := k.ownerUnsafe(identity, data); ok {
+			return &ErrDuplicatePublicKey{I: owner}
+		}
+	}
+
 	if !ok {
 		key = &Key{}
 		k.keys[identity] = key
 	}
 
-	if !bytes.Equal(key.Public, data) {
+	if !unchanged {
 		key.Public = make([]byte, len(data))
 		key.Signatures = make(map[string]*Signature)
 		copy(key.Public, data)
+		key.alias = false
+		key.Encryption = nil
+		key.EncryptionSignature = nil
 	}
 
 	key.identity = identity
 	key.trust = trust
-	k.stale = true
+	key.alias = key.alias || alias
+	k.changed()
 	return
 }
 
-// ListPublic returns every stored public key.
+// ListPublic returns a snapshot of every stored public key, along with its
+// effective trust computed from an up-to-date web of trust.
 // The self public key is also included.
+//
+// This function is thread-safe.
 func (k *KeyRing) ListPublic() []ListedKey {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	k.waitForStaleCleared()
+
 	keys := make([]ListedKey, len(k.keys))
 	var i int
 	for _, key := range k.keys {
-		keys[i] = key
+		snapshot := *key
+		keys[i] = &snapshot
 		i++
 	}
 
@@ -235,6 +412,52 @@ func (k *KeyRing) GetPublic(identity string) (data []byte, trust TrustLevel, err
 	return
 }
 
+// SetExpiry sets the expiration date of a key, the zero time meaning that
+// the key never expires. Since the expiry is part of the signed message,
+// every existing signature of the key is removed when it changes.
+//
+// It may returns ErrUnknownIdentity.
+//
+// This function is thread-safe.
+func (k *KeyRing) SetExpiry(identity string, expiry time.Time) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	key, ok := k.keys[identity]
+	if !ok {
+		return &ErrUnknownIdentity{I: identity}
+	}
+
+	if key.Expiry.Equal(expiry) {
+		return nil
+	}
+
+	key.Expiry = expiry
+	for _, signer := range k.keys {
+		delete(signer.Signatures, identity)
+	}
+
+	k.changed()
+	return nil
+}
+
+// Expiry returns the expiration date of a key, or the zero time if the key
+// never expires.
+//
+// It may returns ErrUnknownIdentity.
+//
+// This function is thread-safe.
+func (k *KeyRing) Expiry(identity string) (time.Time, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	key, ok := k.keys[identity]
+	if !ok {
+		return time.Time{}, &ErrUnknownIdentity{I: identity}
+	}
+	return key.Expiry, nil
+}
+
 // RemovePublic removes a key from the KeyRing.
 // This function is thread-safe.
 func (k *KeyRing) RemovePublic(identity string) {
@@ -244,26 +467,55 @@ func (k *KeyRing) RemovePublic(identity string) {
 
 	k.mutex.Lock()
 	defer k.mutex.Unlock()
-	delete(k.keys, identity)
-	k.stale = true
+
+	if key, ok := k.keys[identity]; ok {
+		k.removeKey(key)
+	}
 }
 
 // Export exports a public key to a PEM block.
+// If the key has been revoked, the revocation block is appended, so that
+// peers importing the key also learn about its revocation.
 func (k *KeyRing) Export(identity string) ([]byte, error) {
 	k.mutex.RLock()
 	defer k.mutex.RUnlock()
 
-	_, ok := k.keys[identity]
+	key, ok := k.keys[identity]
 	if !ok {
 		return nil, &ErrUnknownIdentity{I: identity}
 	}
 
-	return k.exportUnsafe(identity)
+	data, err := k.exportUnsafe(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	if r, ok := k.revocations[string(key.Public)]; ok {
+		block, err := encodeRevocation(r, identity, k.crypto)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, block...)
+	}
+
+	return data, nil
 }
 
 func (k *KeyRing) exportUnsafe(identity string) ([]byte, error) {
-	key := k.keys[identity]
+	b, err := k.publicBlockUnsafe(k.keys[identity])
+	if err != nil {
+		return nil, err
+	}
+
+	if identity == k.selfIdentity {
+		delete(b.Headers, "identity")
+		delete(b.Headers, "trust")
+	}
 
+	return pem.EncodeToMemory(b), nil
+}
+
+func (k *KeyRing) publicBlockUnsafe(key *Key) (*pem.Block, error) {
 	bytes, err := json.Marshal(key)
 	if err != nil {
 		return nil, err
@@ -274,15 +526,60 @@ func (k *KeyRing) exportUnsafe(identity string) ([]byte, error) {
 		Headers: map[string]string{
 			"identity": key.identity,
 			"trust":    key.trust.String(),
+			"crypto":   k.crypto,
 		},
 		Bytes: bytes,
 	}
 
-	if key.identity == k.selfIdentity {
-		b.Headers = map[string]string{}
+	if !key.Expiry.IsZero() {
+		b.Headers["expiry"] = key.Expiry.UTC().Format(time.RFC3339)
+	}
+	if key.alias {
+		b.Headers["alias"] = "true"
 	}
+	encodeMeta(b, key)
+	return b, nil
+}
 
-	return pem.EncodeToMemory(b), nil
+// decodePublic decodes a public key block, along with its identity, trust,
+// expiry, alias and metadata headers.
+func (k *KeyRing) decodePublic(block *pem.Block) (*Key, error) {
+	lvl, _ := ParseTrust(block.Headers["trust"]) // error is handled by the default lvl value
+	key := &Key{
+		identity: block.Headers["identity"],
+		trust:    lvl,
+		alias:    block.Headers["alias"] == "true",
+	}
+
+	err := json.Unmarshal(block.Bytes, key)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	if len(key.Signatures) > MaxSignaturesPerKey {
+		return nil, ErrTooManySignatures
+	}
+
+	if len(key.Public) > 0 && !k.Validate(key.Public) {
+		return nil, ErrInvalidPublicKey
+	}
+
+	if !k.verifyEncryption(key) {
+		return nil, ErrInvalidSignature
+	}
+
+	key.meta, err = decodeMeta(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiry, ok := block.Headers["expiry"]; ok {
+		key.Expiry, err = time.Parse(time.RFC3339, expiry)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
 }
 
 // MarshalBinary returns a PEM-armored version of this KeyRing.
@@ -290,7 +587,10 @@ func (k *KeyRing) MarshalBinary() ([]byte, error) {
 	k.mutex.RLock()
 	defer k.mutex.RUnlock()
 
-	buf := pem.EncodeToMemory(k.armoredSecret)
+	var buf []byte
+	if k.armoredSecret != nil {
+		buf = pem.EncodeToMemory(k.armoredSecret)
+	}
 
 	for identity := range k.keys {
 		raw, err := k.exportUnsafe(identity)
@@ -301,16 +601,42 @@ func (k *KeyRing) MarshalBinary() ([]byte, error) {
 		buf = append(buf, raw...)
 	}
 
+	for public, r := range k.revocations {
+		var identity string
+		for _, key := range k.keys {
+			if string(key.Public) == public {
+				identity = key.identity
+			}
+		}
+
+		raw, err := encodeRevocation(r, identity, k.crypto)
+		if err != nil {
+			return nil, err
+		}
+
+		buf = append(buf, raw...)
+	}
+
 	return buf, nil
 }
 
+// Bounds of the data accepted by Import, ImportAll and UnmarshalBinary.
+const (
+	MaxKeyRingSize      = 16 << 20 // in bytes
+	MaxSignaturesPerKey = 1024     // made by a key on other keys
+)
+
+var pemBegin = []byte("-----BEGIN ")
+
 // Import imports a public PEM block to the keyring.
 // Identity must be defined, and third-party signatures are verified afterwards.
 //
 // This function accepts following results of function Export:
 // - Local exports (without any headears)
 // - Third-party exports (with "identity" header set)
-//   * If the provided identity is different that the "identity" header, an error is returned
+//   - If the provided identity is different that the "identity" header, an error is returned
+//
+// - Any of the above followed by revocation blocks
 //
 // This function is thread-safe.
 func (k *KeyRing) Import(data []byte, identity string, trust TrustLevel) error {
@@ -321,69 +647,179 @@ func (k *KeyRing) Import(data []byte, identity string, trust TrustLevel) error {
 		return ErrInvalidIdentity
 	}
 
-	_, err := k.importUnsafe(data, identity, trust)
+	if len(data) > MaxKeyRingSize {
+		return ErrKeyRingTooLarge
+	}
+
+	block, remaining, err := decodeBlock(data)
+	if err == nil {
+		err = k.importBlock(block, identity, trust)
+	}
+	for err == nil && len(bytes.TrimSpace(remaining)) > 0 {
+		block, remaining, err = decodeBlock(remaining)
+		if err != nil || block.Type != pemRevocationType {
+			break
+		}
+
+		err = k.checkCrypto(block)
+		if err == nil {
+			err = k.importRevocation(block)
+		}
+	}
+
 	return err
 }
 
-func (k *KeyRing) importUnsafe(data []byte, identity string, trust TrustLevel) (remaining []byte, err error) {
-	block, remaining := pem.Decode(data)
+// decodeBlock decodes the first PEM block of data. Unlike pem.Decode, it
+// does not skip malformed blocks, nor the data preceding the block: they are
+// reported as ErrMalformedBlock, along with the data following them.
+func decodeBlock(data []byte) (block *pem.Block, rest []byte, err error) {
+	data = bytes.TrimLeft(data, " \t\r\n")
 
-	if block == nil {
-		err = io.EOF
-		return
+	next := len(data)
+	if i := bytes.Index(data, append([]byte("\n"), pemBegin...)); i >= 0 {
+		next = i + 1
 	}
 
-	if block.Type == pemPrivateType {
-		if identity != "" && identity != k.selfIdentity { // Avoid private key override when importing unsafely.
-			err = ErrInvalidIdentity
-			return
+	if !bytes.HasPrefix(data, pemBegin) {
+		return nil, data[next:], ErrMalformedBlock
+	}
+
+	block, rest = pem.Decode(data)
+	if block == nil || len(data)-len(rest) > next { // the first block has been skipped by pem.Decode
+		return nil, data[next:], ErrMalformedBlock
+	}
+	return block, rest, nil
+}
+
+// importBlock imports a private key, public key or revocation block.
+// Public keys of other identities are checked by the crypto engine.
+func (k *KeyRing) importBlock(block *pem.Block, identity string, trust TrustLevel) error {
+	err := k.checkCrypto(block)
+	if err != nil {
+		return err
+	}
+
+	switch block.Type {
+	case pemRevocationType:
+		err = k.importRevocation(block)
+		if err != nil {
+			return err
 		}
-		k.armoredSecret = block
-	} else if block.Type == pemPublicType {
-		lvl, _ := ParseTrust(block.Headers["trust"]) // error is handled by the default lvl value
-		id := block.Headers["identity"]
 
-		key := &Key{
-			identity: id,
-			trust:    lvl,
+	case pemPrivateType:
+		if identity != "" && identity != k.selfIdentity { // Avoid private key override when importing unsafely.
+			return ErrInvalidIdentity
 		}
+		k.armoredSecret = block
 
-		err = json.Unmarshal(block.Bytes, key)
+	case pemPublicType:
+		key, err := k.decodePublic(block)
 		if err != nil {
-			err = ErrInvalidSignature
-			return
+			return err
 		}
 
 		if identity != "" {
 			if key.identity != "" && key.identity != identity {
-				err = ErrInvalidIdentity
-				return
+				return ErrInvalidIdentity
 			}
 
 			key.identity = identity
 			key.trust = trust
+			key.alias = false // aliases are only restored from marshaled KeyRings
 		} else if key.identity == "" { // identity == "" and key.identity == ""
 			key.identity = k.selfIdentity
 			key.trust = TrustULTIMATE
 		}
 
+		// The local public key is empty until the private key is created
+		if key.identity != k.selfIdentity && !k.Validate(key.Public) {
+			return ErrInvalidPublicKey
+		}
+
+		if owner, ok := k.ownerUnsafe(key.identity, key.Public); ok && !key.alias {
+			return &ErrDuplicatePublicKey{I: owner}
+		}
+
+		if old, ok := k.keys[key.identity]; ok {
+			key.mergeMeta(old) // keep the fields set locally
+		}
 		k.keys[key.identity] = key
+
+	default:
+		return ErrUnknownBlockType
 	}
 
-	k.stale = true
-	return remaining, nil
+	k.changed()
+	return nil
+}
+
+// checkCrypto returns an error if a PEM block has been generated by another
+// crypto engine. Blocks without "crypto" header predate this header.
+func (k *KeyRing) checkCrypto(block *pem.Block) error {
+	crypto, ok := block.Headers["crypto"]
+	if !ok {
+		crypto = legacyCrypto
+	}
+
+	if crypto != k.crypto {
+		return &ErrCryptoMismatch{CE: crypto}
+	}
+	return nil
 }
 
 // UnmarshalBinary rebuilds a KeyRing from its PEM-armored version.
-// - It may not return an error if a parse error is encountered ;
+// - Blocks that cannot be loaded are skipped: the other blocks are loaded,
+//   then ErrSkippedBlocks is returned with the reason of each skipped block ;
+// - It returns ErrCryptoMismatch if the KeyRing uses another crypto engine ;
+// - It returns ErrKeyRingTooLarge beyond MaxKeyRingSize ;
 // - NewKeyRing must be called before to instantiate the KeyRing.
 func (k *KeyRing) UnmarshalBinary(data []byte) error {
-	var err error
-	buffer := data
+	if len(data) > MaxKeyRingSize {
+		return ErrKeyRingTooLarge
+	}
 
-	for len(buffer) > 0 && err != io.EOF {
-		buffer, err = k.importUnsafe(buffer, "", 0)
+	var skipped []SkippedBlock
+	for i := 0; len(bytes.TrimSpace(data)) > 0; i++ {
+		var block *pem.Block
+		var err error
+		block, data, err = decodeBlock(data)
+		if err == nil {
+			err = k.importBlock(block, "", 0)
+		}
+
+		if _, ok := err.(*ErrCryptoMismatch); ok {
+			return err
+		}
+		if err != nil {
+			s := SkippedBlock{Index: i, Err: err}
+			if block != nil {
+				s.Type = block.Type
+				s.Identity = block.Headers["identity"]
+			}
+			skipped = append(skipped, s)
+		}
 	}
 
+	if len(skipped) > 0 {
+		return ErrSkippedBlocks{Blocks: skipped}
+	}
 	return nil
 }
+
+// CryptoOf returns the name of the crypto engine used by a PEM-armored
+// KeyRing, as returned by MarshalBinary. It shall be used to choose the
+// crypto engine given to NewKeyRing before loading a stored KeyRing.
+func CryptoOf(data []byte) string {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return legacyCrypto
+		}
+
+		if crypto, ok := block.Headers["crypto"]; ok {
+			return crypto
+		}
+	}
+}
@@ -8,6 +8,13 @@
 
 package keyring
 
+import (
+	"encoding/binary"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
 // GetSignatures returns a map of (signer, signatures) where the provided identity is the signee.
 // This function is thread-safe.
 func (k *KeyRing) GetSignatures(identity string) map[string]*Signature {
@@ -32,7 +39,8 @@ func (k *KeyRing) GetSignatures(identity string) map[string]*Signature {
 // AddSignature adds a signature to the identity, from signer "from".
 // If "from" equals the k.selfIdentity, the KeyRing adds a new signature to the identity using its own private key.
 //
-// It may returns ErrKeyRingLocked or ErrUnknownIdentity.
+// It may returns ErrKeyRingLocked, ErrUnknownIdentity, or ErrTooManySignatures if
+// the signer has already signed MaxSignaturesPerKey other keys.
 //
 // This function is thread-safe.
 func (k *KeyRing) AddSignature(identity, from string, signature *Signature) error {
@@ -50,15 +58,20 @@ func (k *KeyRing) AddSignature(identity, from string, signature *Signature) erro
 	}
 
 	if from == k.selfIdentity { // emit local signature
-		message := append(key.Public, byte(key.trust))
+		createdAt := time.Now().UTC().Truncate(time.Second)
+		k.mutex.RLock()
+		message := signedMessage(key, key.trust, createdAt)
+		k.mutex.RUnlock()
+
 		signData, err := k.Sign(message)
 		if err != nil {
 			return err
 		}
 
 		signature = &Signature{
-			Data:  signData,
-			Trust: key.trust,
+			Data:      signData,
+			Trust:     key.trust,
+			CreatedAt: createdAt,
 		}
 	} else if err := k.verifySignature(from, key, signature); err != nil {
 		// verify third-party signature
@@ -68,19 +81,72 @@ func (k *KeyRing) AddSignature(identity, from string, signature *Signature) erro
 	k.mutex.Lock()
 	defer k.mutex.Unlock()
 
-	k.stale = true
-	signer.Signatures[identity] = signature
+	// Keys may have been removed meanwhile
+	if k.keys[identity] != key {
+		return &ErrUnknownIdentity{I: identity}
+	}
+	if k.keys[from] != signer {
+		return &ErrUnknownIdentity{I: from}
+	}
+	if _, ok := signer.Signatures[identity]; !ok && len(signer.Signatures) >= MaxSignaturesPerKey {
+		return ErrTooManySignatures
+	}
+
+	k.setSignature(signer, key, signature)
+	return nil
+}
+
+// RemoveSignature removes the signature of the identity made by signer "from".
+// Signatures made by other keys than the local one are only removed when force is set,
+// since they may be imported again with the key of their signer.
+//
+// It may returns ErrUnknownIdentity, ErrForeignSignature or ErrMissingSignature.
+//
+// This function is thread-safe.
+func (k *KeyRing) RemoveSignature(identity, from string, force bool) error {
+	if from != k.selfIdentity && !force {
+		return ErrForeignSignature
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	key, ok := k.keys[identity]
+	if !ok {
+		return &ErrUnknownIdentity{I: identity}
+	}
+
+	signer, ok := k.keys[from]
+	if !ok {
+		return &ErrUnknownIdentity{I: from}
+	}
+
+	if _, ok := signer.Signatures[identity]; !ok {
+		return ErrMissingSignature
+	}
+
+	k.removeSignature(signer, key)
 	return nil
 }
 
-// Sign signs the message with the unlocked private key.
+// Sign signs the message with the unlocked private key, or with the
+// external signer if any.
 // This function is thread-safe.
 func (k *KeyRing) Sign(cleartext []byte) (signature []byte, err error) {
-	if k.Locked() {
+	if s := k.getSigner(); s != nil {
+		return k.signWithSigner(s, cleartext) // without lock, signers may be slow
+	}
+
+	k.secretMutex.RLock()
+	defer k.secretMutex.RUnlock()
+
+	if k.secret == nil {
 		err = ErrKeyRingLocked
 		return
 	}
 
+	atomic.StoreInt64(&k.lastUse, time.Now().UnixNano())
+
 	signature = k.cryptoEngine.Sign(k.secret.Buffer(), cleartext)
 	return
 }
@@ -88,7 +154,7 @@ func (k *KeyRing) Sign(cleartext []byte) (signature []byte, err error) {
 // Verify checks the message signed by "from".
 // The addition of local trust and third-party trust levels must be greater or equals than TrustThreshold.
 //
-// It may returns ErrUnknownIdentity, ErrInsufficientTrust or ErrInvalidSignature.
+// It may returns ErrUnknownIdentity, ErrKeyRevoked, ErrKeyExpired, ErrInsufficientTrust or ErrInvalidSignature.
 //
 // This function is thread-safe.
 func (k *KeyRing) Verify(from string, cleartext, signature []byte) error {
@@ -111,16 +177,44 @@ func (k *KeyRing) Verify(from string, cleartext, signature []byte) error {
 // Verify signature does NOT check for trust chain.
 // It only checks that a signature fulfill cryptographic requirements.
 func (k *KeyRing) verifySignature(signer string, signee *Key, signature *Signature) error {
-	message := append(signee.Public, byte(signature.Trust))
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	message := signedMessage(signee, signature.Trust, signature.CreatedAt)
 	if !k.cryptoEngine.Verify(k.keys[signer].Public, message, signature.Data) {
 		return ErrInvalidSignature
 	}
 	return nil
 }
 
+// signedMessage returns the message that is signed to certify a key at a
+// given trust level. The expiry and the creation date of the signature are
+// only part of the message when they are set, which keeps signatures of keys
+// without expiry, and signatures made before creation dates were introduced,
+// unchanged. The creation date is tagged, so that it cannot be mistaken for
+// an expiry.
+func signedMessage(key *Key, trust TrustLevel, createdAt time.Time) []byte {
+	message := make([]byte, len(key.Public), len(key.Public)+18)
+	copy(message, key.Public)
+	message = append(message, byte(trust))
+
+	var date [8]byte
+	if !key.Expiry.IsZero() {
+		binary.BigEndian.PutUint64(date[:], uint64(key.Expiry.Unix()))
+		message = append(message, date[:]...)
+	}
+
+	if !createdAt.IsZero() {
+		binary.BigEndian.PutUint64(date[:], uint64(createdAt.Unix()))
+		message = append(message, 'c')
+		message = append(message, date[:]...)
+	}
+	return message
+}
+
 // Trusted shall return nil if an identity is currently trusted by the keyring.
 //
-// It may returns ErrUnknownIdentity or ErrInsufficientTrust.
+// It may returns ErrUnknownIdentity, ErrKeyRevoked, ErrKeyExpired or ErrInsufficientTrust.
 //
 // This function is thread-safe.
 func (k *KeyRing) Trusted(identity string) error {
@@ -136,13 +230,138 @@ func (k *KeyRing) Trusted(identity string) error {
 	return k.trustedUnsafe(key)
 }
 
+// EffectiveTrust returns the trust level of an identity, computed from the
+// web of trust.
+//
+// It may returns ErrUnknownIdentity.
+//
+// This function is thread-safe.
+func (k *KeyRing) EffectiveTrust(identity string) (TrustLevel, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	k.waitForStaleCleared()
+
+	key, ok := k.keys[identity]
+	if !ok {
+		return TrustNONE, &ErrUnknownIdentity{I: identity}
+	}
+
+	return key.effectiveTrust, nil
+}
+
+// TrustPath explains the effective trust of an identity. It returns the
+// signatures that contributed to it and, recursively, to the effective trust
+// of their signers, up to keys trusted locally. Edges are ordered from the
+// locally trusted keys to the identity.
+//
+// When the identity is not trusted, the returned edges are the best partial
+// path found by the web of trust. Keys trusted locally have an empty path.
+//
+// It may returns ErrUnknownIdentity.
+//
+// This function is thread-safe.
+func (k *KeyRing) TrustPath(identity string) ([]TrustEdge, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	k.waitForStaleCleared()
+
+	key, ok := k.keys[identity]
+	if !ok {
+		return nil, &ErrUnknownIdentity{I: identity}
+	}
+
+	var edges []TrustEdge
+	visited := map[string]bool{identity: true}
+	queue := []*Key{key}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, e := range current.trustEdges {
+			edges = append(edges, e)
+			if visited[e.Signer] {
+				continue
+			}
+
+			visited[e.Signer] = true
+			if signer := k.keys[e.Signer]; signer != nil && signer.trust < TrustThreshold {
+				queue = append(queue, signer)
+			}
+		}
+	}
+
+	for i, j := 0, len(edges)-1; i < j; i, j = i+1, j-1 {
+		edges[i], edges[j] = edges[j], edges[i]
+	}
+	return edges, nil
+}
+
+// SetMaxTrustDepth limits the propagation of trust in the web of trust:
+// signatures only add trust to keys at most depth hops away from a key
+// trusted locally (directly signed keys are one hop away). Keys beyond this
+// depth only keep the trust level set by the user. Zero, the default, means
+// that signature chains of any length may certify a key.
+//
+// This function is thread-safe.
+func (k *KeyRing) SetMaxTrustDepth(depth int) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if depth < 0 {
+		depth = 0
+	}
+
+	if depth != k.maxTrustDepth {
+		k.maxTrustDepth = depth
+		k.changed()
+	}
+}
+
+// SetMaxSignatureAge sets the maximum age of the signatures taken into account
+// in the web of trust, the zero value meaning that signatures never age.
+// Signatures without creation date, made before it was recorded, are always
+// taken into account.
+//
+// This function is thread-safe.
+func (k *KeyRing) SetMaxSignatureAge(age time.Duration) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if age < 0 {
+		age = 0
+	}
+
+	if age != k.maxSignatureAge {
+		k.maxSignatureAge = age
+		k.changed()
+	}
+}
+
+// MaxSignatureAge returns the maximum age of signatures, or zero if unlimited.
+// This function is thread-safe.
+func (k *KeyRing) MaxSignatureAge() time.Duration {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.maxSignatureAge
+}
+
+// MaxTrustDepth returns the maximum depth of the web of trust, zero meaning
+// unlimited.
+//
+// This function is thread-safe.
+func (k *KeyRing) MaxTrustDepth() int {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.maxTrustDepth
+}
+
 // This function MUST me called by other functions that hold a read-only
 // lock against the KeyRing, and wish to clear the staled state.
 func (k *KeyRing) waitForStaleCleared() {
-	for k.stale {
+	for k.staleUnsafe() {
 		k.mutex.RUnlock()
 		k.mutex.Lock()
-		if k.stale {
+		if k.staleUnsafe() {
 			k.buildTrustWeb()
 		}
 		k.mutex.Unlock()
@@ -150,7 +369,70 @@ func (k *KeyRing) waitForStaleCleared() {
 	}
 }
 
+// staleUnsafe returns true if the web of trust must be computed again,
+// either because the keyring has been modified or because a key involved
+// in the web of trust has expired since the last computation.
+func (k *KeyRing) staleUnsafe() bool {
+	return k.stale || (!k.nextExpiry.IsZero() && !k.nextExpiry.After(time.Now()))
+}
+
+// Watch returns a channel receiving a value after modifications of the
+// KeyRing that may change the trust of some identities (keys added, removed
+// or revoked, signatures, expiry dates...). Successive notifications are
+// coalesced: receivers shall check again every identity they care about.
+// Keys reaching their expiry date are not notified.
+//
+// The returned function must be called to release the channel once it is
+// not needed.
+//
+// This function is thread-safe.
+func (k *KeyRing) Watch() (<-chan struct{}, func()) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	c := make(chan struct{}, 1)
+	if k.watchers == nil {
+		k.watchers = make(map[chan struct{}]bool)
+	}
+	k.watchers[c] = true
+
+	return c, func() {
+		k.mutex.Lock()
+		defer k.mutex.Unlock()
+		delete(k.watchers, c)
+	}
+}
+
+// changed marks the web of trust as stale, and notifies the watchers.
+// unsafe
+func (k *KeyRing) changed() {
+	k.stale = true
+	k.notify()
+}
+
+// notify notifies the watchers of a modification of the KeyRing.
+// unsafe
+func (k *KeyRing) notify() {
+	for c := range k.watchers {
+		select {
+		case c <- struct{}{}:
+		default: // a notification is already pending
+		}
+	}
+}
+
 func (k *KeyRing) trustedUnsafe(key *Key) error {
+	if k.revokedUnsafe(key) {
+		return &ErrKeyRevoked{I: key.identity}
+	}
+
+	if key.Expired(time.Now()) {
+		return &ErrKeyExpired{
+			I: key.identity,
+			T: key.Expiry,
+		}
+	}
+
 	if key.effectiveTrust < TrustThreshold {
 		return &ErrInsufficientTrust{
 			I: key.identity,
@@ -167,22 +449,41 @@ func (k *KeyRing) trustedUnsafe(key *Key) error {
 // peer directed graph. This strategy is used because we
 // need to iteratively trust more and more peers.
 //
+// Expired and revoked keys never propagate any trust, nor keys beyond
+// the maximum depth (see SetMaxTrustDepth).
+//
+// Some modifications update the web of trust without building it again,
+// see trust_web.go.
+//
 // This function is not thread-safe and is called internally
 // when the KeyRing is considered stale.
 func (k *KeyRing) buildTrustWeb() {
 	var queue []*Key
-	visited := make(map[string]bool)
+	now := time.Now()
+	k.nextExpiry = time.Time{}
 
 	// Populate initial trusted peers.
 	// The queue only contains peers whose signatures can be trusted.
 	for _, key := range k.keys {
-		if key.trust >= TrustThreshold {
+		expired := key.Expired(now)
+		if !expired && !key.Expiry.IsZero() && (k.nextExpiry.IsZero() || key.Expiry.Before(k.nextExpiry)) {
+			k.nextExpiry = key.Expiry
+		}
+
+		key.resetTrust()
+		if key.trust >= TrustThreshold && !expired && !k.revokedUnsafe(key) {
 			queue = append(queue, key)
-			visited[key.identity] = true
+			key.trusted = true
 		}
+		key.signers = nil
+	}
 
-		key.effectiveTrust = key.trust
-		key.signedBy = nil
+	for _, key := range k.keys {
+		for signee := range key.Signatures {
+			if signeeKey := k.keys[signee]; signeeKey != nil {
+				signeeKey.addSigner(key.identity)
+			}
+		}
 	}
 
 	// While there are some vertexes to be processed
@@ -190,31 +491,78 @@ func (k *KeyRing) buildTrustWeb() {
 	for len(queue) > 0 {
 		current, queue = queue[0], queue[1:]
 
+		// Keys are dequeued by increasing depth
+		if k.maxTrustDepth > 0 && current.depth >= k.maxTrustDepth {
+			continue
+		}
+
 		// For each signatures
 		for signee, signature := range current.Signatures {
-
 			// The signature is valid, add its value (if exists)
 			signeeKey := k.keys[signee]
-			if signeeKey == nil {
-				continue
-			}
-
-			// EffectiveTrust calculation takes into account previously
-			// accumulated trust wrt signer's trust.
-			signeeKey.effectiveTrust = signeeKey.effectiveTrust.Add(
-				signature.Trust.Min(current.effectiveTrust),
-			)
-			signeeKey.signedBy = append(signeeKey.signedBy, current)
-
-			// Is it the first time we can trust the signee?
-			if signeeKey.effectiveTrust >= TrustThreshold {
-				if !visited[signee] {
-					queue = append(queue, signeeKey)
-					visited[signee] = true
-				}
+			if signeeKey != nil && k.contribute(current, signeeKey, signature, now) {
+				queue = append(queue, signeeKey)
 			}
 		}
 	}
 
 	k.stale = false
 }
+
+// contribute adds the trust of a signature to its signee, unless it is too
+// old (see SetMaxSignatureAge). It returns true if it is the first time the
+// signee can be trusted, in which case its own signatures shall be
+// propagated.
+// unsafe
+func (k *KeyRing) contribute(signer, signee *Key, signature *Signature, now time.Time) bool {
+	if k.maxSignatureAge > 0 && !signature.CreatedAt.IsZero() {
+		limit := signature.CreatedAt.Add(k.maxSignatureAge)
+		if !limit.After(now) {
+			return false // too old
+		}
+		if k.nextExpiry.IsZero() || limit.Before(k.nextExpiry) {
+			k.nextExpiry = limit
+		}
+	}
+
+	// EffectiveTrust calculation takes into account previously
+	// accumulated trust wrt signer's trust.
+	contributed := signature.Trust.Min(signer.effectiveTrust)
+	signee.effectiveTrust = signee.effectiveTrust.Add(contributed)
+	signee.signedBy = append(signee.signedBy, signer)
+	signee.trustEdges = append(signee.trustEdges, TrustEdge{
+		Signer: signer.identity,
+		Signee: signee.identity,
+		Trust:  contributed,
+	})
+
+	// Is it the first time we can trust the signee?
+	if signee.effectiveTrust >= TrustThreshold && !signee.trusted && !signee.Expired(now) && !k.revokedUnsafe(signee) {
+		signee.trusted = true
+		signee.depth = trustDepth(signee)
+		return true
+	}
+	return false
+}
+
+// trustDepth returns the depth of a key made trusted by its signers, that is
+// one hop further than the deepest signer needed to reach TrustThreshold
+// when signatures are taken by increasing depth, as buildTrustWeb does.
+func trustDepth(key *Key) int {
+	edges := make([]int, len(key.signedBy))
+	for i := range edges {
+		edges[i] = i
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		return key.signedBy[edges[i]].depth < key.signedBy[edges[j]].depth
+	})
+
+	trust := key.trust
+	for _, i := range edges {
+		trust = trust.Add(key.trustEdges[i].Trust)
+		if trust >= TrustThreshold {
+			return key.signedBy[i].depth + 1
+		}
+	}
+	return 0 // unreachable for trusted keys
+}
@@ -0,0 +1,226 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// MergeStrategy decides how Merge handles identities bound to different
+// public keys in both KeyRings.
+type MergeStrategy byte
+
+// MergeStrategy available values.
+const (
+	MergeError      MergeStrategy = iota // fail with ErrIdentityConflict, without merging anything
+	MergeKeepLocal                       // ignore the conflicting keys of the other KeyRing
+	MergeKeepRemote                      // replace the local keys by the ones of the other KeyRing
+)
+
+var mergeStrategyName = map[MergeStrategy]string{
+	MergeError:      "error",
+	MergeKeepLocal:  "keep-local",
+	MergeKeepRemote: "keep-remote",
+}
+
+// ParseMergeStrategy returns a MergeStrategy from its string representation.
+func ParseMergeStrategy(strategy string) (MergeStrategy, error) {
+	strategy = strings.ToLower(strategy)
+	for s, str := range mergeStrategyName {
+		if str == strategy {
+			return s, nil
+		}
+	}
+
+	return MergeError, errors.New("unrecognized merge strategy")
+}
+
+func (s MergeStrategy) String() string {
+	return mergeStrategyName[s]
+}
+
+// Merge imports the public keys, signatures and revocations of other.
+//
+// When both KeyRings know an identity with the same public key, the highest
+// trust level is kept, signatures are merged, and local metadata take
+// precedence over the ones of other. Conflicting identities are handled
+// according to strategy; the local key is never replaced. Keys of other get
+// at most the TrustHIGH level, since TrustULTIMATE is reserved to the local
+// key.
+//
+// Signatures made by keys of other are verified against the keys of other
+// (ErrInvalidSignature is returned if one of them is forged), and only kept
+// if they still certify the merged keys. New keys whose public key already
+// belongs to another identity are rejected with ErrDuplicatePublicKey, as
+// aliases are not merged. Nothing is merged if an error is returned.
+//
+// This function is thread-safe.
+func (k *KeyRing) Merge(other *KeyRing, strategy MergeStrategy) error {
+	if other == k {
+		return nil
+	}
+
+	remote, revocations, err := other.mergeSnapshot(k.crypto)
+	if err != nil {
+		return err
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	var conflicts []string
+	for identity, r := range remote {
+		local, ok := k.keys[identity]
+		if ok && len(local.Public) > 0 && !bytes.Equal(local.Public, r.Public) {
+			conflicts = append(conflicts, identity)
+		}
+	}
+
+	if len(conflicts) > 0 && strategy == MergeError {
+		return &ErrIdentityConflict{I: conflicts}
+	}
+
+	replaced := make(map[string]bool)
+	for _, identity := range conflicts {
+		if strategy == MergeKeepRemote && identity != k.selfIdentity {
+			replaced[identity] = true
+		} else {
+			delete(remote, identity)
+		}
+	}
+
+	// New keys shall not share their public key with another identity
+	for identity, r := range remote {
+		if _, ok := k.keys[identity]; ok && !replaced[identity] {
+			continue
+		}
+
+		if owner, ok := k.ownerUnsafe(identity, r.Public); ok && !replaced[owner] {
+			return &ErrDuplicatePublicKey{I: owner}
+		}
+		for owner, o := range remote {
+			if owner != identity && bytes.Equal(o.Public, r.Public) {
+				return &ErrDuplicatePublicKey{I: owner}
+			}
+		}
+	}
+
+	// Signatures of other are only kept if they certify the merged keys
+	signee := func(identity string) *Key {
+		if r, ok := remote[identity]; ok && (replaced[identity] || k.keys[identity] == nil) {
+			return r
+		}
+		return k.keys[identity]
+	}
+
+	for _, r := range remote {
+		for identity, s := range r.Signatures {
+			target := signee(identity)
+			if target == nil || !k.cryptoEngine.Verify(r.Public, signedMessage(target, s.Trust, s.CreatedAt), s.Data) {
+				delete(r.Signatures, identity)
+			}
+		}
+	}
+
+	for identity, r := range remote {
+		local, ok := k.keys[identity]
+		if !ok || replaced[identity] {
+			k.keys[identity] = r
+			continue
+		}
+
+		if r.trust > local.trust && identity != k.selfIdentity {
+			local.trust = r.trust
+		}
+
+		if len(local.Encryption) == 0 && len(r.Encryption) > 0 && k.verifyEncryption(r) {
+			local.Encryption = r.Encryption
+			local.EncryptionSignature = r.EncryptionSignature
+		}
+
+		local.mergeMeta(r)
+
+		if local.Signatures == nil {
+			local.Signatures = make(map[string]*Signature)
+		}
+		for signee, s := range r.Signatures {
+			if known, ok := local.Signatures[signee]; !ok || s.CreatedAt.After(known.CreatedAt) {
+				local.Signatures[signee] = s
+			}
+		}
+	}
+
+	// Signatures of the replaced keys do not certify the new ones
+	for _, key := range k.keys {
+		for identity, s := range key.Signatures {
+			if replaced[identity] && !k.cryptoEngine.Verify(key.Public, signedMessage(k.keys[identity], s.Trust, s.CreatedAt), s.Data) {
+				delete(key.Signatures, identity)
+			}
+		}
+	}
+
+	for public, r := range revocations {
+		k.revocations[public] = r
+	}
+
+	k.changed()
+	return nil
+}
+
+// mergeSnapshot returns copies of the public keys of the KeyRing (its local
+// key included, but not the keys without identity or public key), after
+// having verified the signatures they made, along with its revocations.
+func (k *KeyRing) mergeSnapshot(crypto string) (map[string]*Key, map[string]*Revocation, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	if k.crypto != crypto {
+		return nil, nil, &ErrCryptoMismatch{CE: k.crypto}
+	}
+
+	keys := make(map[string]*Key, len(k.keys))
+	for identity, key := range k.keys {
+		if identity == "" || len(key.Public) == 0 {
+			continue
+		}
+
+		c := &Key{
+			Public:              key.Public,
+			Signatures:          make(map[string]*Signature, len(key.Signatures)),
+			Expiry:              key.Expiry,
+			Encryption:          key.Encryption,
+			EncryptionSignature: key.EncryptionSignature,
+			identity:            identity,
+			trust:               key.trust.Min(TrustHIGH),
+			meta:                key.Meta(),
+		}
+
+		for signee, s := range key.Signatures {
+			target, ok := k.keys[signee]
+			if !ok {
+				continue
+			}
+
+			if !k.cryptoEngine.Verify(key.Public, signedMessage(target, s.Trust, s.CreatedAt), s.Data) {
+				return nil, nil, ErrInvalidSignature
+			}
+			copied := *s
+			c.Signatures[signee] = &copied
+		}
+		keys[identity] = c
+	}
+
+	revocations := make(map[string]*Revocation, len(k.revocations))
+	for public, r := range k.revocations {
+		revocations[public] = r
+	}
+	return keys, revocations, nil
+}
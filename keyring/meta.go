@@ -0,0 +1,174 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package keyring
+
+import (
+	"encoding/pem"
+	"strings"
+)
+
+// Well-known metadata fields of a key, see SetMeta.
+const (
+	MetaName         = "name"
+	MetaOrganization = "organization"
+	MetaEmail        = "email"
+)
+
+// Bounds of the metadata of a key.
+const (
+	MaxMetaFields     = 16
+	MaxMetaFieldSize  = 32  // in bytes
+	MaxMetaValueSize  = 256 // in bytes
+	metaHeaderPrefix  = "meta-"
+	metaNoticeHeader  = "meta"
+	metaNoticeContent = "unauthenticated, not covered by signatures"
+)
+
+// validMeta returns true if field is made of lowercase letters, digits and
+// dashes, and value is printable on a single line.
+func validMeta(field, value string) bool {
+	if field == "" || len(field) > MaxMetaFieldSize || len(value) > MaxMetaValueSize {
+		return false
+	}
+
+	for _, c := range field {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	for _, c := range value {
+		if c < ' ' || c == 0x7f {
+			return false
+		}
+	}
+	return strings.TrimSpace(value) == value
+}
+
+// Meta returns a copy of the metadata of the key.
+func (k *Key) Meta() map[string]string {
+	meta := make(map[string]string, len(k.meta))
+	for field, value := range k.meta {
+		meta[field] = value
+	}
+	return meta
+}
+
+// mergeMeta copies the metadata fields of other that the key lacks.
+func (k *Key) mergeMeta(other *Key) {
+	for field, value := range other.meta {
+		if _, ok := k.meta[field]; ok || len(k.meta) >= MaxMetaFields {
+			continue
+		}
+		if k.meta == nil {
+			k.meta = make(map[string]string)
+		}
+		k.meta[field] = value
+	}
+}
+
+// encodeMeta adds the metadata of key to the headers of its public block,
+// along with a notice that they are not authenticated.
+func encodeMeta(b *pem.Block, key *Key) {
+	for field, value := range key.meta {
+		b.Headers[metaHeaderPrefix+field] = value
+	}
+	if len(key.meta) > 0 {
+		b.Headers[metaNoticeHeader] = metaNoticeContent
+	}
+}
+
+// decodeMeta reads the metadata of a public block.
+func decodeMeta(b *pem.Block) (map[string]string, error) {
+	var meta map[string]string
+	for header, value := range b.Headers {
+		if !strings.HasPrefix(header, metaHeaderPrefix) {
+			continue
+		}
+
+		field := strings.TrimPrefix(header, metaHeaderPrefix)
+		if !validMeta(field, value) || len(meta) >= MaxMetaFields {
+			return nil, ErrInvalidMeta
+		}
+		if meta == nil {
+			meta = make(map[string]string)
+		}
+		meta[field] = value
+	}
+	return meta, nil
+}
+
+// SetMeta sets a metadata field of the key of an identity, such as its
+// display name (MetaName), or removes it if value is empty. Metadata are
+// only meant to be read by humans: they are exported along with the key,
+// but not signed, so that they can be changed without invalidating its
+// signatures, and shall not be trusted.
+//
+// It may returns ErrUnknownIdentity or ErrInvalidMeta.
+//
+// This function is thread-safe.
+func (k *KeyRing) SetMeta(identity, field, value string) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	key, ok := k.keys[identity]
+	if !ok {
+		return &ErrUnknownIdentity{I: identity}
+	}
+
+	if value == "" {
+		if _, ok := key.meta[field]; ok {
+			delete(key.meta, field)
+			k.notify()
+		}
+		return nil
+	}
+
+	if !validMeta(field, value) {
+		return ErrInvalidMeta
+	}
+	if _, ok := key.meta[field]; !ok && len(key.meta) >= MaxMetaFields {
+		return ErrInvalidMeta
+	}
+
+	if key.meta == nil {
+		key.meta = make(map[string]string)
+	}
+	key.meta[field] = value
+	k.notify()
+	return nil
+}
+
+// GetMeta returns a copy of the metadata of the key of an identity, see
+// SetMeta.
+//
+// It may returns ErrUnknownIdentity.
+//
+// This function is thread-safe.
+func (k *KeyRing) GetMeta(identity string) (map[string]string, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	key, ok := k.keys[identity]
+	if !ok {
+		return nil, &ErrUnknownIdentity{I: identity}
+	}
+	return key.Meta(), nil
+}
+
+// DisplayName returns the name of an identity (see MetaName), or the empty
+// string if it has none.
+// This function is thread-safe.
+func (k *KeyRing) DisplayName(identity string) string {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	if key, ok := k.keys[identity]; ok {
+		return key.meta[MetaName]
+	}
+	return ""
+}
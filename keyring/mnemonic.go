@@ -0,0 +1,73 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import (
+	"strings"
+
+	"github.com/awnumar/memguard"
+	bip39 "github.com/tyler-smith/go-bip39"
+)
+
+// mnemonicEntropy is the entropy of generated mnemonics, in bits (24 words).
+const mnemonicEntropy = 256
+
+// seededCryptoEngine is implemented by crypto engines able to derive a key
+// pair from a seed.
+type seededCryptoEngine interface {
+	// GenerateFromSeed returns the key pair derived from a 64-byte BIP39 seed.
+	GenerateFromSeed(seed []byte) (public, secret []byte, err error)
+}
+
+// NewMnemonic returns a random 24-word phrase of the BIP39 English wordlist,
+// to be used with CreatePrivateFromMnemonic.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(mnemonicEntropy)
+	if err != nil {
+		return "", err
+	}
+	defer wipe(entropy)
+
+	return bip39.NewMnemonic(entropy)
+}
+
+// CreatePrivateFromMnemonic is like CreatePrivate, but derives the key pair
+// from a BIP39 mnemonic instead of generating it randomly: a given mnemonic
+// always yields the same key pair, which allows to recover a lost keyring.
+// Case and spacing of the mnemonic are not significant.
+//
+// It returns ErrInvalidMnemonic if the words or the checksum of the
+// mnemonic are invalid, and ErrNoMnemonic if the crypto engine of the
+// KeyRing does not support seeded key generation (only ed25519 does).
+func (k *KeyRing) CreatePrivateFromMnemonic(mnemonic string, password *memguard.LockedBuffer) error {
+	engine, ok := k.cryptoEngine.(seededCryptoEngine)
+	if !ok {
+		return ErrNoMnemonic
+	}
+
+	mnemonic = strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, "")
+	if err != nil {
+		return ErrInvalidMnemonic
+	}
+	defer wipe(seed)
+
+	public, secret, err := engine.GenerateFromSeed(seed)
+	if err != nil {
+		return err
+	}
+
+	k.keys[k.selfIdentity].Public = public
+	err = k.setSecret(secret)
+	if err != nil {
+		return err
+	}
+
+	return k.ReEncryptPrivate(password)
+}
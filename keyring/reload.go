@@ -0,0 +1,52 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import "bytes"
+
+// Reload replaces the public keys, trust levels, signatures and revocations
+// of the KeyRing by the ones of a marshaled KeyRing, as returned by
+// MarshalBinary. It is meant to take into account the modifications of a
+// stored KeyRing made by another process, while the KeyRing is in use.
+//
+// The private key and the lock state are kept, hence the public key of the
+// local identity must not have changed: ErrSelfKeyChanged is returned
+// otherwise, and the KeyRing is left untouched. It is also left untouched
+// when some blocks are skipped (see ErrSkippedBlocks), so that a corrupted
+// file does not remove keys in use. Watchers are notified.
+func (k *KeyRing) Reload(data []byte) error {
+	if crypto := CryptoOf(data); crypto != k.crypto {
+		return &ErrCryptoMismatch{CE: crypto}
+	}
+
+	fresh, err := NewKeyRing(k.selfIdentity, k.crypto)
+	if err != nil {
+		return err
+	}
+
+	err = fresh.UnmarshalBinary(data)
+	if err != nil {
+		return err
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if !bytes.Equal(fresh.keys[k.selfIdentity].Public, k.keys[k.selfIdentity].Public) {
+		return ErrSelfKeyChanged
+	}
+
+	k.keys = fresh.keys
+	k.revocations = fresh.revocations
+	if fresh.armoredSecret != nil {
+		k.armoredSecret = fresh.armoredSecret // the password may have changed
+	}
+	k.changed()
+	return nil
+}
@@ -0,0 +1,129 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"time"
+)
+
+// Revocation is a self-signed certificate stating that a key must not be
+// trusted anymore, typically because its private key has been compromised.
+type Revocation struct {
+	Public    []byte
+	Date      time.Time
+	Reason    string
+	Signature []byte
+}
+
+func (r *Revocation) message() []byte {
+	message := make([]byte, 0, len(pemRevocationType)+len(r.Public)+8+len(r.Reason))
+	message = append(message, pemRevocationType...)
+	message = append(message, r.Public...)
+
+	var date [8]byte
+	binary.BigEndian.PutUint64(date[:], uint64(r.Date.Unix()))
+	message = append(message, date[:]...)
+	return append(message, r.Reason...)
+}
+
+// Revoke revokes the local key, and returns the PEM-armored revocation
+// certificate that shall be sent to other peers. Once revoked, a key is
+// never trusted again by the keyring.
+//
+// It may returns ErrKeyRingLocked.
+//
+// This function is thread-safe.
+func (k *KeyRing) Revoke(reason string) ([]byte, error) {
+	k.mutex.RLock()
+	public := k.keys[k.selfIdentity].Public
+	k.mutex.RUnlock()
+
+	r := &Revocation{
+		Public: public,
+		Date:   time.Now().UTC().Truncate(time.Second),
+		Reason: reason,
+	}
+
+	var err error
+	r.Signature, err = k.Sign(r.message())
+	if err != nil {
+		return nil, err
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.revocations[string(public)] = r
+	k.changed()
+	return encodeRevocation(r, k.selfIdentity, k.crypto)
+}
+
+// Revocation returns the revocation certificate of an identity, or nil if
+// its key has not been revoked.
+//
+// This function is thread-safe.
+func (k *KeyRing) Revocation(identity string) *Revocation {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	key, ok := k.keys[identity]
+	if !ok {
+		return nil
+	}
+	return k.revocations[string(key.Public)]
+}
+
+func (k *KeyRing) revokedUnsafe(key *Key) bool {
+	_, ok := k.revocations[string(key.Public)]
+	return ok && len(key.Public) > 0
+}
+
+func encodeRevocation(r *Revocation, identity, crypto string) ([]byte, error) {
+	bytes, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:    pemRevocationType,
+		Headers: map[string]string{"identity": identity, "crypto": crypto},
+		Bytes:   bytes,
+	}), nil
+}
+
+// importRevocation verifies a revocation block and records it.
+// Revocations are recorded by public key, so that they survive the
+// removal or the import of the revoked key.
+func (k *KeyRing) importRevocation(block *pem.Block) error {
+	r, err := k.decodeRevocation(block)
+	if err != nil {
+		return err
+	}
+
+	k.revocations[string(r.Public)] = r
+	k.changed()
+	return nil
+}
+
+// decodeRevocation decodes and verifies a revocation block.
+func (k *KeyRing) decodeRevocation(block *pem.Block) (*Revocation, error) {
+	r := &Revocation{}
+	err := json.Unmarshal(block.Bytes, r)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	if !k.cryptoEngine.Validate(r.Public) || !k.cryptoEngine.Verify(r.Public, r.message(), r.Signature) {
+		return nil, ErrInvalidSignature
+	}
+	return r, nil
+}
@@ -0,0 +1,79 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import (
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+func init() {
+	cryptoEngines["secp256k1"] = secp256k1Engine{}
+}
+
+// secp256k1Engine uses ECDSA signatures over the secp256k1 curve.
+// Public keys are stored in their compressed form (33 bytes), secret keys
+// as raw scalars (32 bytes), and signatures are DER-encoded.
+// Messages are hashed with SHA-256 before being signed, and signatures are
+// normalized to their low-S form: those with a high S value, which are valid
+// ECDSA signatures of the same message, are refused so that a signature
+// cannot be altered into another valid one.
+type secp256k1Engine struct{}
+
+var secp256k1HalfOrder = new(big.Int).Rsh(btcec.S256().N, 1)
+
+// Generate returns the public key first, like ed25519.GenerateKey.
+func (secp256k1Engine) Generate() (public, secret []byte, err error) {
+	sk, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		return
+	}
+
+	return sk.PubKey().SerializeCompressed(), sk.Serialize(), nil
+}
+
+func (secp256k1Engine) Validate(public []byte) bool {
+	if len(public) != btcec.PubKeyBytesLenCompressed {
+		return false
+	}
+
+	_, err := btcec.ParsePubKey(public, btcec.S256())
+	return err == nil
+}
+
+func (secp256k1Engine) Sign(secret, cleartext []byte) []byte {
+	sk, _ := btcec.PrivKeyFromBytes(btcec.S256(), secret)
+	hash := sha256.Sum256(cleartext)
+	signature, err := sk.Sign(hash[:])
+	if err != nil {
+		return nil
+	}
+
+	if signature.S.Cmp(secp256k1HalfOrder) > 0 {
+		signature.S = new(big.Int).Sub(btcec.S256().N, signature.S)
+	}
+	return signature.Serialize()
+}
+
+func (secp256k1Engine) Verify(public, cleartext, signature []byte) bool {
+	pk, err := btcec.ParsePubKey(public, btcec.S256())
+	if err != nil {
+		return false
+	}
+
+	s, err := btcec.ParseDERSignature(signature, btcec.S256())
+	if err != nil || s.S.Cmp(secp256k1HalfOrder) > 0 {
+		return false
+	}
+
+	hash := sha256.Sum256(cleartext)
+	return s.Verify(hash[:], pk)
+}
@@ -0,0 +1,99 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import "bytes"
+
+// Signer signs messages with a private key kept outside of the KeyRing,
+// for instance in a hardware token (see AgentSigner).
+type Signer interface {
+	Public() []byte
+	Sign(cleartext []byte) ([]byte, error)
+}
+
+// NewKeyRingWithSigner is like NewKeyRing, but the signatures of the
+// KeyRing are delegated to an external signer, see SetSigner.
+func NewKeyRingWithSigner(selfIdentity string, crypto string, s Signer) (*KeyRing, error) {
+	k, err := NewKeyRing(selfIdentity, crypto)
+	if err != nil {
+		return nil, err
+	}
+
+	err = k.SetSigner(s)
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// SetSigner delegates the signatures of the KeyRing to an external signer.
+// The private key held in memory, if any, is locked, and the KeyRing is
+// never seen as locked afterwards. Verifications, trust and imports are not
+// affected.
+//
+// The public key of the signer becomes the local public key. If the latter
+// is already known, it must be the same: ErrSelfKeyChanged is returned
+// otherwise, and the KeyRing is left untouched.
+//
+// This function is thread-safe.
+func (k *KeyRing) SetSigner(s Signer) error {
+	public := s.Public()
+	if !k.Validate(public) {
+		return ErrInvalidPublicKey
+	}
+
+	k.mutex.Lock()
+	self := k.keys[k.selfIdentity]
+	if len(self.Public) > 0 && !bytes.Equal(self.Public, public) {
+		k.mutex.Unlock()
+		return ErrSelfKeyChanged
+	}
+	if len(self.Public) == 0 {
+		self.Public = append([]byte(nil), public...)
+		k.changed()
+	}
+	k.mutex.Unlock()
+
+	k.secretMutex.Lock()
+	defer k.secretMutex.Unlock()
+	k.lockUnsafe()
+	k.signer = s
+	return nil
+}
+
+// Public returns the local public key.
+// Along with Sign, it allows to use an unlocked KeyRing as the Signer of
+// another one, for instance through ServeAgent.
+//
+// This function is thread-safe.
+func (k *KeyRing) Public() []byte {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return append([]byte(nil), k.keys[k.selfIdentity].Public...)
+}
+
+func (k *KeyRing) getSigner() Signer {
+	k.secretMutex.RLock()
+	defer k.secretMutex.RUnlock()
+	return k.signer
+}
+
+// signWithSigner delegates a signature to the external signer, and checks
+// it, since a misconfigured signer would otherwise only be noticed by peers.
+func (k *KeyRing) signWithSigner(s Signer, cleartext []byte) ([]byte, error) {
+	signature, err := s.Sign(cleartext)
+	if err != nil {
+		return nil, err
+	}
+
+	if !k.cryptoEngine.Verify(s.Public(), cleartext, signature) {
+		return nil, ErrInvalidSignature
+	}
+	return signature, nil
+}
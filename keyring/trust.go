@@ -9,6 +9,7 @@
 package keyring
 
 import (
+	"crypto/sha256"
 	"crypto/x509"
 	"errors"
 	"fmt"
@@ -80,14 +81,27 @@ func (t TrustLevel) Add(t2 TrustLevel) TrustLevel {
 var TrustThreshold = TrustHIGH
 
 const (
-	pemPublicType  = "PNYXDB PUBLIC KEY"
-	pemPrivateType = "PNYXDB PRIVATE KEY"
-	pemCipher      = x509.PEMCipherAES256
+	pemPublicType     = "PNYXDB PUBLIC KEY"
+	pemPrivateType    = "PNYXDB PRIVATE KEY"
+	pemRevocationType = "PNYXDB REVOCATION"
+	pemCipher         = x509.PEMCipherAES256 // legacy private key armor, see KeyRing.LegacyPrivate
 )
 
-// ListedKey shall contain one function returning basic informations about one's key.
+// ListedKey shall contain functions returning basic informations about one's key.
 type ListedKey interface {
 	Info() (identity string, data []byte, trust TrustLevel)
+	// EffectiveTrust returns the trust computed from the web of trust.
+	EffectiveTrust() TrustLevel
+	// Signers returns the identities whose signatures contributed to the effective trust.
+	Signers() []string
+}
+
+// TrustEdge is a signature that contributed to the effective trust of a key
+// in the web of trust.
+type TrustEdge struct {
+	Signer string
+	Signee string
+	Trust  TrustLevel // trust added to the signee, bounded by the signer's effective trust
 }
 
 // ByIdentity is a helper to sort ListeKey by their identity.
@@ -102,10 +116,33 @@ func (a ByIdentity) Less(i, j int) bool {
 }
 
 // Fingerprint is a helper function to get a human-friendly representation of one's key.
+// It is too short to authenticate a key, see FingerprintFull.
 func Fingerprint(data []byte) string {
-	if len(data) < 4 {
+	if len(data) < 5 {
 		return ""
 	}
 
 	return strings.Replace(fmt.Sprintf("% X", data[len(data)-5:]), " ", ":", -1)
 }
+
+// FingerprintFull returns the SHA-256 hash of a public key, as hexadecimal
+// digits grouped by four, to be compared out-of-band before trusting a key.
+func FingerprintFull(data []byte) string {
+	h := sha256.Sum256(data)
+	digits := fmt.Sprintf("%X", h[:])
+
+	groups := make([]string, 0, len(digits)/4)
+	for i := 0; i < len(digits); i += 4 {
+		groups = append(groups, digits[i:i+4])
+	}
+	return strings.Join(groups, " ")
+}
+
+// MatchFingerprint returns true if the expected fingerprint is the full
+// fingerprint of a public key. Case, spaces and colons are ignored, so that
+// it can be copied from any representation.
+func MatchFingerprint(data []byte, expected string) bool {
+	normalize := strings.NewReplacer(" ", "", ":", "", "\t", "")
+	want := strings.ToUpper(normalize.Replace(expected))
+	return want == normalize.Replace(FingerprintFull(data))
+}
@@ -0,0 +1,307 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package keyring
+
+import "time"
+
+// The web of trust is built again from scratch by buildTrustWeb when the
+// KeyRing is stale. Adding or removing signatures and keys is frequent, and
+// only affects the keys reachable from the modified one: these modifications
+// update the web of trust in place instead, unless it is already stale.
+//
+// Signatures are only propagated by a key once its effective trust reaches
+// TrustThreshold, and Add saturates at this threshold: the result does not
+// depend on the order of propagation, except when a signature brings more
+// than TrustThreshold, or when the depth is limited (see SetMaxTrustDepth).
+// In these cases, the incremental update gives up and marks the KeyRing as
+// stale, so that it is built again on the next read.
+
+// resetTrust clears the trust computed from the web of trust.
+func (key *Key) resetTrust() {
+	key.effectiveTrust = key.trust
+	key.signedBy = nil
+	key.trustEdges = nil
+	key.trusted = false
+	key.depth = 0
+}
+
+func (key *Key) addSigner(identity string) {
+	if key.signers == nil {
+		key.signers = make(map[string]bool)
+	}
+	key.signers[identity] = true
+}
+
+// setSignature adds or replaces the signature of signee made by signer.
+// unsafe
+func (k *KeyRing) setSignature(signer, signee *Key, signature *Signature) {
+	previous := signer.Signatures[signee.identity]
+	signer.Signatures[signee.identity] = signature
+	signee.addSigner(signer.identity)
+
+	switch {
+	case k.stale:
+	case previous != nil:
+		k.stale = !k.refreshTrust(signee.identity)
+	default:
+		k.stale = !k.linkTrust(signer, signee, signature)
+	}
+	k.notify()
+}
+
+// removeSignature removes the signature of signee made by signer.
+// unsafe
+func (k *KeyRing) removeSignature(signer, signee *Key) {
+	delete(signer.Signatures, signee.identity)
+	delete(signee.signers, signer.identity)
+
+	if !k.stale && signer.trusted {
+		k.stale = !k.refreshTrust(signee.identity)
+	}
+	k.notify()
+}
+
+// removeKey removes a key, along with the trust it brought to other keys.
+// unsafe
+func (k *KeyRing) removeKey(key *Key) {
+	delete(k.keys, key.identity)
+
+	var signees []string
+	for signee := range key.Signatures {
+		if signeeKey := k.keys[signee]; signeeKey != nil {
+			delete(signeeKey.signers, key.identity)
+			signees = append(signees, signee)
+		}
+	}
+
+	if !k.stale && key.trusted {
+		k.stale = !k.refreshTrust(signees...)
+	}
+	k.notify()
+}
+
+// linkTrust propagates a new signature. Since trust only increases, only
+// the signee and the keys it makes trusted are updated. It returns false if
+// the web of trust must be built again.
+// unsafe
+func (k *KeyRing) linkTrust(signer, signee *Key, signature *Signature) bool {
+	if !signer.trusted || k.maxTrustDepth > 0 && signer.depth >= k.maxTrustDepth {
+		return true // the signature does not bring any trust
+	}
+
+	if !k.incremental(signer, signee, signature) {
+		return false
+	}
+
+	now := time.Now()
+	if !k.contribute(signer, signee, signature, now) {
+		return true
+	}
+	return k.spreadTrust([]*Key{signee}, now)
+}
+
+// refreshTrust computes again the trust of some keys after the removal or
+// the replacement of some of their signatures. Keys whose trust is still
+// above or still below TrustThreshold propagate the same signatures, and
+// do not affect any other key. It returns false if the web of trust must be
+// built again.
+// unsafe
+func (k *KeyRing) refreshTrust(identities ...string) bool {
+	if k.maxTrustDepth > 0 {
+		return false // depths of the keys outside the refreshed ones may change
+	}
+
+	now := time.Now()
+	var changed []string
+	wasTrusted := make(map[string]bool)
+	for _, identity := range identities {
+		key := k.keys[identity]
+		if key == nil {
+			continue
+		}
+
+		trusted := key.trusted
+		if !k.recomputeTrust(key, now) {
+			return false
+		}
+
+		if key.trusted != trusted {
+			changed = append(changed, identity)
+			wasTrusted[identity] = trusted
+		}
+	}
+
+	if len(changed) == 0 {
+		return true
+	}
+	return k.refreshDescendants(changed, wasTrusted, now)
+}
+
+// recomputeTrust computes again the trust of a single key from its signers.
+// If the key was trusted, the signers of greater depth may have been trusted
+// thanks to the key itself, and would keep a cycle of keys trusted after its
+// root is removed: they are only taken into account once the key is trusted
+// by the others. Otherwise, the key appears as not trusted anymore, and
+// refreshDescendants settles it.
+// It returns false if the web of trust must be built again.
+// unsafe
+func (k *KeyRing) recomputeTrust(key *Key, now time.Time) bool {
+	wasTrusted, depth := key.trusted, key.depth
+	key.resetTrust()
+	key.trusted = key.trust >= TrustThreshold && !key.Expired(now) && !k.revokedUnsafe(key)
+
+	var deeper []*Key
+	for identity := range key.signers {
+		signer := k.keys[identity]
+		if signer == nil || signer == key || !signer.trusted {
+			continue
+		}
+
+		if wasTrusted && signer.depth >= depth {
+			deeper = append(deeper, signer)
+			continue
+		}
+
+		if !k.contributeIncremental(signer, key, now) {
+			return false
+		}
+	}
+
+	if !key.trusted {
+		return true
+	}
+
+	for _, signer := range deeper {
+		if !k.contributeIncremental(signer, key, now) {
+			return false
+		}
+	}
+
+	// A key signing itself only does so once trusted
+	if key.Signatures[key.identity] != nil {
+		return k.contributeIncremental(key, key, now)
+	}
+	return true
+}
+
+// contributeIncremental adds the trust of the signature of signee made by
+// signer, if any. It returns false if the web of trust must be built again.
+// unsafe
+func (k *KeyRing) contributeIncremental(signer, signee *Key, now time.Time) bool {
+	signature := signer.Signatures[signee.identity]
+	if signature == nil {
+		return true
+	}
+
+	if !k.incremental(signer, signee, signature) {
+		return false
+	}
+	k.contribute(signer, signee, signature, now)
+	return true
+}
+
+// refreshDescendants computes again the trust of some keys, and of every key
+// which could have been trusted thanks to them. wasTrusted holds the keys
+// which were trusted before their trust was computed again. It returns false
+// if the web of trust must be built again.
+// unsafe
+func (k *KeyRing) refreshDescendants(identities []string, wasTrusted map[string]bool, now time.Time) bool {
+	// Only the signatures of trusted keys contributed to the web of trust
+	dirty := make(map[string]*Key)
+	for len(identities) > 0 {
+		identity := identities[0]
+		identities = identities[1:]
+
+		key := k.keys[identity]
+		if key == nil || dirty[identity] != nil {
+			continue
+		}
+
+		dirty[identity] = key
+		if key.trusted || wasTrusted[identity] {
+			for signee := range key.Signatures {
+				identities = append(identities, signee)
+			}
+		}
+	}
+
+	var queue []*Key
+	for _, key := range dirty {
+		key.resetTrust()
+		if key.trust >= TrustThreshold && !key.Expired(now) && !k.revokedUnsafe(key) {
+			key.trusted = true
+			queue = append(queue, key)
+		}
+	}
+
+	// Trust brought by the other keys is left unchanged
+	for _, key := range dirty {
+		for identity := range key.signers {
+			signer := k.keys[identity]
+			if signer == nil || dirty[identity] != nil || !signer.trusted {
+				continue
+			}
+
+			signature := signer.Signatures[key.identity]
+			if signature == nil {
+				continue
+			}
+
+			if !k.incremental(signer, key, signature) {
+				return false
+			}
+			if k.contribute(signer, key, signature, now) {
+				queue = append(queue, key)
+			}
+		}
+	}
+
+	return k.spreadTrust(queue, now)
+}
+
+// spreadTrust propagates the signatures of keys which have just been
+// trusted, and recursively of the keys they make trusted. It returns false
+// if the web of trust must be built again.
+// unsafe
+func (k *KeyRing) spreadTrust(queue []*Key, now time.Time) bool {
+	var current *Key
+	for len(queue) > 0 {
+		current, queue = queue[0], queue[1:]
+		if k.maxTrustDepth > 0 && current.depth >= k.maxTrustDepth {
+			continue
+		}
+
+		for signee, signature := range current.Signatures {
+			signeeKey := k.keys[signee]
+			if signeeKey == nil {
+				continue
+			}
+
+			if !k.incremental(current, signeeKey, signature) {
+				return false
+			}
+			if k.contribute(current, signeeKey, signature, now) {
+				queue = append(queue, signeeKey)
+			}
+		}
+	}
+	return true
+}
+
+// incremental returns false if a signature cannot be propagated in place,
+// see the beginning of this file.
+// unsafe
+func (k *KeyRing) incremental(signer, signee *Key, signature *Signature) bool {
+	if signature.Trust.Min(signer.effectiveTrust) > TrustThreshold {
+		return false
+	}
+
+	// A shorter path could let the signee propagate further
+	return !(k.maxTrustDepth > 0 && signee.trusted && signer.depth+1 < signee.depth)
+}
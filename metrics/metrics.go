@@ -0,0 +1,255 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+// Package metrics collects the measures of a PnyxDB node and exports them in
+// the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds of the buckets of the histograms, in
+// seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// contentType is the content type of the Prometheus text format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metric types, as written in the TYPE lines.
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+type histogram struct {
+	counts []uint64 // by bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// Registry holds the metrics of a node. It implements consensus.Metrics, and
+// serves them over HTTP. The zero value is ready to use.
+// This structure is thread-safe.
+type Registry struct {
+	Help    map[string]string // optional, the description of the metrics by name
+	Buckets []float64         // of the histograms, DefaultBuckets if nil
+	Collect func()            // optional, called before the metrics are written, typically Engine.CollectMetrics
+
+	mutex      sync.Mutex
+	types      map[string]string
+	values     map[string]float64 // counters and gauges
+	histograms map[string]*histogram
+}
+
+// init registers a metric, and returns false if it has already been
+// registered with another type.
+// unsafe
+func (r *Registry) init(name, typ string) bool {
+	if r.types == nil {
+		r.types = make(map[string]string)
+		r.values = make(map[string]float64)
+		r.histograms = make(map[string]*histogram)
+	}
+	if t, ok := r.types[name]; ok {
+		return t == typ
+	}
+	r.types[name] = typ
+	return true
+}
+
+// Count adds delta to a counter. Negative deltas are ignored.
+func (r *Registry) Count(name string, delta float64) {
+	if delta < 0 {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.init(name, typeCounter) {
+		r.values[name] += delta
+	}
+}
+
+// Set sets the value of a gauge.
+func (r *Registry) Set(name string, value float64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.init(name, typeGauge) {
+		r.values[name] = value
+	}
+}
+
+// Observe adds an observation to a histogram.
+func (r *Registry) Observe(name string, value float64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.init(name, typeHistogram) {
+		return
+	}
+
+	buckets := r.buckets()
+	h := r.histograms[name]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(buckets))}
+		r.histograms[name] = h
+	}
+
+	i := sort.SearchFloat64s(buckets, value) // first upper bound >= value
+	if i < len(buckets) {
+		h.counts[i]++
+	}
+	h.sum += value
+	h.count++
+}
+
+func (r *Registry) buckets() []float64 {
+	if r.Buckets == nil {
+		return DefaultBuckets
+	}
+	return r.Buckets
+}
+
+// WriteTo writes the metrics in the Prometheus text format, sorted by name,
+// after calling Collect.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	if r.Collect != nil {
+		r.Collect()
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if fi, fj := family(names[i]), family(names[j]); fi != fj {
+			return fi < fj
+		}
+		return names[i] < names[j]
+	})
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	var previous string
+	for _, name := range names {
+		typ := r.types[name]
+		if f := family(name); f != previous {
+			if help, ok := r.Help[f]; ok {
+				fmt.Fprintf(cw, "# HELP %s %s\n", f, helpEscaper.Replace(help))
+			}
+			fmt.Fprintf(cw, "# TYPE %s %s\n", f, typ)
+			previous = f
+		}
+
+		if typ != typeHistogram {
+			fmt.Fprintf(cw, "%s %s\n", name, formatFloat(r.values[name]))
+			continue
+		}
+
+		h := r.histograms[name]
+		var cumulative uint64
+		for i, bound := range r.buckets() {
+			cumulative += h.counts[i]
+			fmt.Fprintf(cw, "%s_bucket{le=\"%s\"} %d\n", name, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(cw, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+		fmt.Fprintf(cw, "%s_sum %s\n", name, formatFloat(h.sum))
+		fmt.Fprintf(cw, "%s_count %d\n", name, h.count)
+	}
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP writes the metrics, see WriteTo.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "read-only endpoint", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = r.WriteTo(w)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// helpEscaper escapes the backslashes and line feeds of a description.
+var helpEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n")
+
+// labelEscaper escapes the backslashes, double quotes and line feeds of a
+// label value.
+var labelEscaper = strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n")
+
+// Labeled returns the name of a counter or a gauge with labels, given as
+// pairs of names and values: Labeled("requests_total", "code", "OK") is
+// written as requests_total{code="OK"}. The metrics sharing a name are
+// described once, and must have the same type. Histograms cannot be labeled.
+func Labeled(name string, labels ...string) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i == 0 {
+			b.WriteByte('{')
+		} else {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", labels[i], labelEscaper.Replace(labels[i+1]))
+	}
+	if len(labels) > 1 {
+		b.WriteByte('}')
+	}
+	return b.String()
+}
+
+// family returns the name of a metric without its labels.
+func family(name string) string {
+	if i := strings.IndexByte(name, '{'); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// countingWriter counts the bytes written, and keeps the first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
@@ -0,0 +1,118 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+var _ consensus.Metrics = &Registry{}
+
+func TestRegistry_WriteTo(t *testing.T) {
+	var collected int
+	r := &Registry{
+		Help:    map[string]string{"b_total": "Some\\counter\nof b."},
+		Buckets: []float64{0.25, 1},
+		Collect: func() { collected++ },
+	}
+
+	r.Count("b_total", 2)
+	r.Count("b_total", 0.5)
+	r.Count("b_total", -1) // ignored
+	r.Set("a", 3)
+	r.Set("a", -1.5)
+	r.Observe("c_seconds", 0.125)
+	r.Observe("c_seconds", 0.25)
+	r.Observe("c_seconds", 0.5)
+	r.Observe("c_seconds", 4)
+	r.Set("b_total", 10) // already a counter, ignored
+
+	var buf bytes.Buffer
+	n, err := r.WriteTo(&buf)
+	require.Nil(t, err)
+	require.Equal(t, int64(buf.Len()), n)
+	require.Equal(t, 1, collected)
+	require.Equal(t, `# TYPE a gauge
+a -1.5
+# HELP b_total Some\\counter\nof b.
+# TYPE b_total counter
+b_total 2.5
+# TYPE c_seconds histogram
+c_seconds_bucket{le="0.25"} 2
+c_seconds_bucket{le="1"} 3
+c_seconds_bucket{le="+Inf"} 4
+c_seconds_sum 4.875
+c_seconds_count 4
+`, buf.String())
+}
+
+func TestRegistry_Labeled(t *testing.T) {
+	r := &Registry{Help: map[string]string{"requests_total": "Requests."}}
+	r.Count(Labeled("requests_total", "method", "Get", "code", "OK"), 2)
+	r.Count(Labeled("requests_total", "method", "Get", "code", "NotFound"), 1)
+	r.Count("requests_total_other", 1)
+	r.Set(Labeled("quoted", "value", "a\"b\\c\nd"), 1)
+
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	require.Nil(t, err)
+	require.Equal(t, `# TYPE quoted gauge
+quoted{value="a\"b\\c\nd"} 1
+# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{method="Get",code="NotFound"} 1
+requests_total{method="Get",code="OK"} 2
+# TYPE requests_total_other counter
+requests_total_other 1
+`, buf.String())
+}
+
+func TestRegistry_Concurrent(t *testing.T) {
+	r := &Registry{}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.Count("n_total", 1)
+				r.Observe("d_seconds", 0.2)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	require.Nil(t, err)
+	require.Contains(t, buf.String(), "n_total 800\n")
+	require.Contains(t, buf.String(), "d_seconds_count 800\n")
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	r := &Registry{}
+	r.Count("n_total", 1)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, contentType, w.Header().Get("Content-Type"))
+	require.Equal(t, "# TYPE n_total counter\nn_total 1\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/protobuf/proto"
@@ -56,15 +57,21 @@ func Defaults(h host.Host) Parameters {
 }
 
 type network struct {
-	sync.RWMutex
+	// Messages counted since the creation of the network, atomic and first
+	// for their alignment, see CollectMetrics
+	received  uint64
+	published uint64
+	invalid   uint64
+
 	Parameters
 	*floodsub.PubSub
 
-	pending   []proto.Message
-	acceptors []consensus.MessageAcceptor
-	receivers []chan proto.Message
-	cancel    context.CancelFunc
-	rand      *rand.Rand
+	subs   consensus.Subscriptions // messages accepted by no subscription are retained for the next ones
+	cancel context.CancelFunc
+	rand   *rand.Rand
+
+	collectMutex sync.Mutex
+	collected    [3]uint64 // received, published and invalid messages at the previous collection
 }
 
 // New returns a new gossipsub-based network.
@@ -140,6 +147,7 @@ func New(p Parameters) (consensus.Network, error) {
 	n := &network{
 		Parameters: p,
 		PubSub:     gs,
+		subs:       consensus.Subscriptions{Buffer: int(p.ChannelsBuffer), Retain: true},
 		cancel:     cancel,
 		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
@@ -162,103 +170,75 @@ func (n *network) run(ctx context.Context, s *floodsub.Subscription) {
 		if err != nil {
 			// TODO add log
 			fmt.Println("TODO ERROR 2", err)
+			atomic.AddUint64(&n.invalid, 1)
 			continue
 		}
+		atomic.AddUint64(&n.received, 1)
 
-		n.RLock()
-		var delivered bool
-		for i, acceptor := range n.acceptors {
-			if acceptor(m) {
-				n.receivers[i] <- m
-				delivered = true
-			}
-		}
-		n.RUnlock()
-
-		if !delivered {
-			n.Lock()
-			n.pending = append(n.pending, m)
-			n.Unlock()
-		}
+		// Never blocks, for a slow subscription not to delay the others
+		n.subs.Deliver(m)
 	}
 }
 
 func (n *network) Accept(ctx context.Context, acceptor consensus.MessageAcceptor) <-chan proto.Message {
-	input := make(chan proto.Message, n.Parameters.ChannelsBuffer)
-	output := make(chan proto.Message)
-
-	n.Lock()
-	defer n.Unlock()
-
-	n.acceptors = append(n.acceptors, acceptor)
-	n.receivers = append(n.receivers, input)
-
-	// Consume pending messages if possible
-	var toSend []proto.Message
-	newPending := n.pending[:0]
-	for _, m := range n.pending {
-		if acceptor(m) {
-			toSend = append(toSend, m)
-		} else {
-			newPending = append(newPending, m)
-		}
-	}
-
-	n.pending = newPending
+	return n.subs.Subscribe(ctx, acceptor)
+}
 
-	// Run in a routine to avoid locking if many released pending messages
-	go func() {
-		for _, p := range toSend {
-			select {
-			case input <- p:
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
+// AcceptDrops returns the number of messages dropped because a subscription
+// was full.
+func (n *network) AcceptDrops() uint64 {
+	return n.subs.AcceptDrops()
+}
 
-	go func() {
-		defer close(output)
+func (n *network) Broadcast(ctx context.Context, m proto.Message) error {
+	raw, err := protocol.Pack(m)
+	if err != nil {
+		return err
+	}
 
-		var done bool
-		for !done {
-			select {
-			case m := <-input:
-				select {
-				case output <- m:
-				case <-ctx.Done():
-					done = true
-				}
-			case <-ctx.Done():
-				done = true
-			}
-		}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
 
-		n.Lock()
-		defer n.Unlock()
+	// Publish blocks while the pubsub loop is busy, during peer churn
+	done := make(chan error, 1)
+	go func() { done <- n.Publish(n.Parameters.Topic, raw) }()
 
-		for i, r := range n.receivers {
-			if r == input {
-				n.acceptors = append(n.acceptors[:i], n.acceptors[i+1:]...)
-				n.receivers = append(n.receivers[:i], n.receivers[i+1:]...)
-				return
-			}
+	select {
+	case err = <-done:
+		if err == nil {
+			atomic.AddUint64(&n.published, 1)
 		}
-	}()
-
-	return output
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (n *network) Broadcast(m proto.Message) error {
-	raw, err := protocol.Pack(m)
-	if err != nil {
-		return err
+// CollectMetrics reports the messages received and published since the
+// previous call, and the number of peers subscribed to the topic.
+func (n *network) CollectMetrics(m consensus.Metrics) {
+	n.collectMutex.Lock()
+	defer n.collectMutex.Unlock()
+
+	for i, c := range []struct {
+		name  string
+		value *uint64
+	}{
+		{consensus.MetricNetworkReceived, &n.received},
+		{consensus.MetricNetworkPublished, &n.published},
+		{consensus.MetricNetworkInvalid, &n.invalid},
+	} {
+		v := atomic.LoadUint64(c.value)
+		m.Count(c.name, float64(v-n.collected[i]))
+		n.collected[i] = v
 	}
 
-	return n.Publish(n.Parameters.Topic, raw)
+	m.Set(consensus.MetricNetworkPeers, float64(len(n.ListPeers(n.Parameters.Topic))))
 }
 
 func (n *network) Close() error {
 	n.cancel()
+	n.subs.Close()
 	return nil
 }
@@ -17,6 +17,7 @@ import (
 	libp2p "github.com/libp2p/go-libp2p"
 	"github.com/stretchr/testify/require"
 	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/consensus/networktest"
 )
 
 func TestGossipSub(t *testing.T) {
@@ -30,11 +31,11 @@ func TestGossipSub(t *testing.T) {
 	time.Sleep(20 * time.Millisecond)
 
 	q := consensus.NewQuery()
-	err = n.Broadcast(q)
+	err = n.Broadcast(p.Ctx, q)
 	require.Nil(t, err, "must broadcast without error")
 
 	q2 := consensus.NewQuery()
-	_ = n.Broadcast(q2)
+	_ = n.Broadcast(p.Ctx, q2)
 	fetched := make(chan proto.Message)
 
 	go func() {
@@ -47,3 +48,20 @@ func TestGossipSub(t *testing.T) {
 	require.Equal(t, q.Uuid, (<-fetched).(*consensus.Query).Uuid)
 	require.Equal(t, q2.Uuid, (<-fetched).(*consensus.Query).Uuid)
 }
+
+func TestConformance(t *testing.T) {
+	networktest.RunConformance(t, networktest.Config{
+		New: func(t *testing.T) consensus.Network {
+			h, err := libp2p.New(context.Background())
+			require.Nil(t, err)
+			p := Defaults(h)
+			p.ChannelsBuffer = 16
+
+			n, err := New(p)
+			require.Nil(t, err)
+			time.Sleep(20 * time.Millisecond)
+			return n
+		},
+		Buffer: 16,
+	})
+}
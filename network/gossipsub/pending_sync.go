@@ -0,0 +1,128 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package gossipsub
+
+import (
+	"bufio"
+	"context"
+	"errors"
+
+	net "github.com/libp2p/go-libp2p-net"
+	peer "github.com/libp2p/go-libp2p-peer"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/network/protocol"
+	"go.uber.org/zap"
+)
+
+const pendingSyncProtocolID = "/p2p/pnyxdb_pending_sync"
+
+func (n *network) PendingSyncPeers() []string {
+	if n == nil {
+		return nil
+	}
+
+	peers := n.ListPeers(n.Topic)
+	out := make([]string, len(peers))
+	for i, p := range peers {
+		out[i] = p.Pretty()
+	}
+	return out
+}
+
+func (n *network) RequestPendingSync(ctx context.Context, rawPeerID string, req *consensus.PendingSyncRequest) (*consensus.PendingSyncResponse, error) {
+	pid, err := peer.IDB58Decode(rawPeerID)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := protocol.Pack(req)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := n.Host.NewStream(ctx, pid, pendingSyncProtocolID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = s.Reset() }()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.SetDeadline(deadline)
+	}
+
+	_, err = s.Write(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := protocol.Unpack(bufio.NewReader(s))
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := m.(*consensus.PendingSyncResponse)
+	if !ok {
+		return nil, errors.New("invalid type")
+	}
+	return res, nil
+}
+
+func (n *network) AcceptPendingSync(ctx context.Context, handler consensus.PendingSyncHandler) {
+	if n == nil {
+		return
+	}
+
+	if handler == nil {
+		n.Host.SetStreamHandler(pendingSyncProtocolID, nil)
+		return
+	}
+
+	n.Host.SetStreamHandler(pendingSyncProtocolID, func(s net.Stream) {
+		defer func() { _ = s.Reset() }()
+
+		remotePeer := s.Conn().RemotePeer().Pretty()
+		m, err := protocol.Unpack(bufio.NewReader(s))
+		if err != nil {
+			zap.L().Warn("PendingSyncHandlerRead", zap.String("peer", remotePeer), zap.Error(err))
+			return
+		}
+
+		req, ok := m.(*consensus.PendingSyncRequest)
+		if !ok {
+			zap.L().Warn("PendingSyncHandlerUnpack",
+				zap.String("peer", remotePeer),
+				zap.Error(errors.New("invalid type")),
+			)
+			return
+		}
+
+		res, err := handler(req)
+		if err != nil {
+			zap.L().Error("PendingSyncHandlerPass", zap.String("peer", remotePeer), zap.Error(err))
+			return
+		}
+
+		raw, err := protocol.Pack(res)
+		if err != nil {
+			zap.L().Error("PendingSyncHandlerPack", zap.String("peer", remotePeer), zap.Error(err))
+			return
+		}
+
+		_, err = s.Write(raw)
+		if err != nil {
+			zap.L().Error("PendingSyncHandlerWrite", zap.String("peer", remotePeer), zap.Error(err))
+			return
+		}
+
+		zap.L().Debug("PendingSyncHandler",
+			zap.Int("uuids", len(req.Uuids)),
+			zap.String("peer", remotePeer),
+		)
+	})
+}
@@ -35,6 +35,14 @@ var typeIdentifiers = []string{
 	"consensus.RecoveryResponse",
 	"reserved",
 	"bbc.Choice",
+	"consensus.TimeBeacon",
+	"consensus.PendingSyncRequest",
+	"consensus.PendingSyncResponse",
+	"consensus.AggregatedEndorsement",
+	"consensus.AdminDrop",
+	"consensus.NodeStatus",
+	"consensus.Vote",
+	"consensus.CheckpointResult",
 }
 
 func getTypeFromName(name string) byte {
@@ -79,7 +87,7 @@ func Unpack(in InputStream) (m proto.Message, err error) {
 		return
 	}
 
-	if b == 0 || b > byte(len(typeIdentifiers)) {
+	if b == 0 || b >= byte(len(typeIdentifiers)) {
 		err = proto.ErrInternalBadWireType
 		return
 	}
@@ -45,6 +45,7 @@ func Test_Call_Unpack_Invalid(t *testing.T) {
 
 	check([]byte{}, "must handle empty data")
 	check([]byte{0xf2}, "must handle invalid function")
+	check([]byte{byte(len(typeIdentifiers))}, "must handle first unknown function")
 	check([]byte{0x01, 0xff}, "must handle invalid uvarint")
 	check([]byte{0x01, 0xff}, "must handle invalid uvarint")
 	check([]byte{0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, "must handle too large uvarint")
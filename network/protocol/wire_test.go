@@ -0,0 +1,438 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/consensus/bbc"
+)
+
+// The wire fixtures are the binary encoding of every protobuf message, at the
+// time they were generated. Decoding them with the current schema detects
+// renumbered, retyped or removed fields.
+//
+// Messages exchanged between peers are stored packed (.pack), the other ones
+// are stored raw (.bin). After an intended change of the schema, run
+// `make fixtures` to regenerate them along with their manifest.
+//
+// The manifest is never written by the tests, so that fixtures regenerated
+// by accident are detected.
+const (
+	fixturesDir       = "testdata/wire"
+	fixturesManifest  = "SHA256SUMS"
+	fixturesUpdateEnv = "PNYXDB_UPDATE_FIXTURES"
+)
+
+// wireFiles lists the protobuf files whose messages must have a fixture.
+var wireFiles = []string{
+	"consensus/structures.proto",
+	"consensus/bbc/veto.proto",
+	"api/api.proto",
+}
+
+func wireFixtures() []proto.Message {
+	ts := &timestamp.Timestamp{Seconds: 1546300800, Nanos: 42}
+	v1 := &consensus.Version{Hash: []byte("version-1")}
+	v2 := &consensus.Version{Hash: []byte("version-2")}
+	op := &consensus.Operation{
+		Key:      "key",
+		Op:       consensus.Operation_SADD,
+		Data:     []byte("data"),
+		Metadata: []byte("metadata"),
+	}
+	query := &consensus.Query{
+		Uuid:         "query-uuid",
+		Policy:       "policy",
+		Emitter:      "emitter",
+		Deadline:     ts,
+		Requirements: map[string]*consensus.Version{"a": v1, "b": v2},
+		Operations:   []*consensus.Operation{op, {Key: "other", Op: consensus.Operation_PRUNE}},
+		Signature:    []byte("query-signature"),
+	}
+	endorsement := &consensus.Endorsement{
+		Uuid:       "query-uuid",
+		Emitter:    "endorser",
+		Conditions: []string{"condition-1", "condition-2"},
+		Signature:  []byte("endorsement-signature"),
+	}
+	aggregate := &consensus.AggregatedEndorsement{
+		Endorsements: []*consensus.Endorsement{
+			{Uuid: "query-uuid", Emitter: "endorser-1", Conditions: []string{"condition"}},
+			{Uuid: "query-uuid", Emitter: "endorser-2"},
+		},
+		Signature: []byte("aggregate-signature"),
+	}
+	certificate := &consensus.CommitCertificate{
+		Query:        query,
+		Endorsements: []*consensus.Endorsement{endorsement},
+		Threshold:    3,
+		Attester:     "attester",
+		Time:         ts,
+		Aggregates:   []*consensus.AggregatedEndorsement{aggregate},
+		Signature:    []byte("certificate-signature"),
+	}
+
+	return []proto.Message{
+		v1,
+		&consensus.Provenance{Uuid: "query-uuid", Emitter: "emitter", Committed: ts},
+		query,
+		op,
+		endorsement,
+		aggregate,
+		&consensus.StartCheckpoint{Queries: []string{"query-1", "query-2"}},
+		&consensus.Proof{Content: &consensus.Proof_Aggregate{Aggregate: aggregate}},
+		&consensus.ProofSummary{
+			Uuid:     "query-uuid",
+			Emitters: []string{"endorser-1", "endorser-2"},
+			Hashes:   [][]byte{[]byte("hash-1"), []byte("hash-2")},
+		},
+		&consensus.AdminDrop{
+			Uuid:   "query-uuid",
+			Reason: "reason",
+			Signatures: []*consensus.AdminSignature{
+				{Admin: "admin-1", Signature: []byte("admin-signature-1")},
+				{Admin: "admin-2", Signature: []byte("admin-signature-2")},
+			},
+		},
+		&consensus.AdminSignature{Admin: "admin", Signature: []byte("admin-signature")},
+		&consensus.RecoveryRequest{Key: "key"},
+		&consensus.RecoveryResponse{Key: "key", Version: v1, Data: []byte("data")},
+		&consensus.PendingSyncRequest{Uuids: []string{"query-1", "query-2"}, Proofs: true},
+		&consensus.PendingSyncResponse{
+			Pending:      []*consensus.PendingQuery{{Uuid: "query-uuid", Deadline: ts}},
+			Queries:      []*consensus.Query{query},
+			Endorsements: []*consensus.Endorsement{endorsement},
+			Aggregates:   []*consensus.AggregatedEndorsement{aggregate},
+		},
+		&consensus.PendingQuery{Uuid: "query-uuid", Deadline: ts},
+		&consensus.CommitEvent{
+			Sequence:    7,
+			Uuid:        "query-uuid",
+			Emitter:     "emitter",
+			Keys:        []string{"a", "b"},
+			Versions:    []*consensus.Version{v1, v2},
+			Time:        ts,
+			Certificate: certificate,
+		},
+		certificate,
+		&consensus.TimeBeacon{Emitter: "emitter", Time: ts, Signature: []byte("beacon-signature")},
+		&consensus.EndorsementRecords{Records: []*consensus.EndorsementRecords_Record{
+			{Uuid: "query-uuid", Deadline: ts, Hash: []byte("hash")},
+		}},
+		&consensus.AppliedMarkers{Markers: []*consensus.AppliedMarkers_Marker{
+			{Uuid: "query-uuid", Deadline: ts},
+		}},
+		&consensus.AppliedNonces{Nonces: [][]byte{[]byte("nonce-1"), []byte("nonce-2")}},
+		&consensus.DecidedQueries{Decisions: []*consensus.DecidedQueries_Decision{
+			{Uuid: "query-uuid", Deadline: ts, Committed: true},
+		}},
+		&bbc.Choice{
+			Identifier: "identifier",
+			Emitter:    "emitter",
+			Choice:     true,
+			Proofs: []*consensus.Proof{
+				{Content: &consensus.Proof_Query{Query: query}},
+				{Content: &consensus.Proof_Endorsement{Endorsement: endorsement}},
+				{Content: &consensus.Proof_Summary{Summary: &consensus.ProofSummary{Uuid: "query-uuid"}}},
+			},
+			Signature: []byte("choice-signature"),
+		},
+		&consensus.Vote{
+			Identifier: "identifier",
+			Emitter:    "emitter",
+			Proofs:     []*consensus.Proof{{Content: &consensus.Proof_Query{Query: query}}},
+			Signature:  []byte("vote-signature"),
+		},
+		&consensus.CheckpointResult{
+			Epoch:     3,
+			Queries:   []string{"query-uuid-1", "query-uuid-2"},
+			Emitter:   "emitter",
+			Decision:  true,
+			Proofs:    []*consensus.Proof{{Content: &consensus.Proof_Query{Query: query}}},
+			Signature: []byte("result-signature"),
+		},
+		&api.Key{Key: "key"},
+		&api.Value{Version: v1, Data: []byte("data")},
+		&api.Provenance{Version: v1, Data: []byte("data"), Uuid: "query-uuid", Emitter: "emitter", Committed: ts},
+		&api.KeyValue{Key: "key", Value: []byte("value")},
+		&api.Values{Version: v1, Data: [][]byte{[]byte("data-1"), []byte("data-2")}},
+		&api.Integer{Value: -42, Version: v1},
+		&api.RangeRequest{Key: "key-1", Start: 1, Stop: -1},
+		&api.Length{Length: 3, Version: v1},
+		&api.FieldRequest{Key: "key", Field: "field"},
+		&api.Fields{Version: v1, Fields: []*api.KeyValue{{Key: "field", Value: []byte("value")}}},
+		&api.Boolean{Boolean: true},
+		&api.Transaction{
+			Policy:       "policy",
+			Deadline:     ts,
+			Requirements: map[string]*consensus.Version{"a": v1, "b": v2},
+			Operations:   []*consensus.Operation{op},
+			Sequence:     7,
+		},
+		&api.Receipt{Uuid: "query-uuid", Sequence: 7, Error: "error"},
+		&api.ReplayRequest{Since: 7, Follow: true},
+		&api.WatchRequest{Prefix: "prefix"},
+		&api.KeyUpdate{Key: "key", Version: v1, Uuid: "query-uuid", Dropped: 3},
+		&api.Empty{},
+		&api.Quota{Prefix: "prefix", Used: 1, Limit: 2},
+		&api.Quotas{Quotas: []*api.Quota{{Prefix: "prefix", Used: 1, Limit: 2}}},
+		&api.KeysRequest{Prefix: "prefix", Details: true, PreviewLimit: 16},
+		&api.KeyInfo{Key: "key", Version: v1, Type: "string", Size: 4, Preview: "data"},
+		&api.KeyInfos{Keys: []*api.KeyInfo{{Key: "key", Version: v1}}},
+		&api.KeyList{Keys: []string{"a", "b"}},
+		&api.Requirements{Requirements: map[string]*consensus.Version{"a": v1, "b": v2}},
+		&api.CertificateRequest{Uuid: "query-uuid"},
+		&api.EndorsersRequest{Uuid: "query-uuid"},
+		&api.EndorserInfo{Emitter: "emitter", Conditions: []string{"conflict"}, Fingerprint: "01:23:45:67:89", Aggregated: true},
+		&api.EndorserInfos{Endorsers: []*api.EndorserInfo{{Emitter: "emitter", Fingerprint: "01:23:45:67:89"}}, Certified: true},
+		&api.RetentionPolicy{Prefix: "prefix", Age: 3600},
+		&api.ExpiredKey{Key: "key", Prefix: "prefix", Modified: ts},
+		&api.NodeInfo{
+			Identity:      "identity",
+			Version:       "version",
+			Threshold:     3,
+			Policies:      []*api.PolicyInfo{{Name: "none", Prefixes: []string{"a/", "b/"}}},
+			MaxTimeout:    60000,
+			MaxOperations: 100,
+			MaxValueSize:  1024,
+			Operations:    []string{"SET", "ADD"},
+			Features:      []string{"events"},
+		},
+		&api.PolicyInfo{Name: "none", Prefixes: []string{"a/", "b/"}},
+		&api.RetentionReport{
+			Policies: []*api.RetentionPolicy{{Prefix: "prefix", Age: 3600}},
+			Expired:  []*api.ExpiredKey{{Key: "key", Prefix: "prefix", Modified: ts}},
+		},
+		&api.RecoveryReport{
+			Breaker:         "open",
+			Pending:         2,
+			Retries:         3,
+			Successes:       4,
+			Failures:        5,
+			BreakerOpenings: 1,
+			DeadLetters:     []*api.DeadLetter{{Key: "key", Attempts: 10, Error: "unreachable", Time: ts}},
+		},
+		&api.DeadLetter{Key: "key", Attempts: 10, Error: "unreachable", Time: ts},
+		&consensus.NodeStatus{
+			Emitter:    "emitter",
+			Time:       ts,
+			Version:    "1.0.0",
+			Pending:    2,
+			Keys:       1024,
+			LastCommit: ts,
+			Uptime:     3600,
+			Signature:  []byte("status-signature"),
+		},
+		&api.NodeStatuses{Nodes: []*consensus.NodeStatus{{Emitter: "emitter", Time: ts, Version: "1.0.0"}}},
+		&api.UnknownEmitterList{
+			Emitters: []*api.UnknownEmitter{{Identity: "dave", Messages: 42, Quarantined: 2, FirstSeen: ts, LastSeen: ts}},
+		},
+		&api.UnknownEmitter{Identity: "dave", Messages: 42, Quarantined: 2, FirstSeen: ts, LastSeen: ts},
+		&api.QueryStatus{
+			State:        "committed",
+			Endorsements: 3,
+			Deadline:     ts,
+			Resolved:     ts,
+			Applied:      true,
+			ApplyError:   "error",
+		},
+		&api.FaultProfile{
+			MinLatency:    10,
+			MedianLatency: 50,
+			MaxLatency:    1000,
+			Loss:          0.01,
+			Duplication:   0.02,
+			Seed:          42,
+		},
+	}
+}
+
+func fixtureName(m proto.Message) string {
+	name := proto.MessageName(m)
+	if getTypeFromName(name) != 0 {
+		return name + ".pack"
+	}
+	return name + ".bin"
+}
+
+// encodeFixture returns the fixture of a message. Maps are encoded in a
+// deterministic order, so that regenerating fixtures is reproducible.
+func encodeFixture(m proto.Message) ([]byte, error) {
+	b := proto.NewBuffer(nil)
+	b.SetDeterministic(true)
+	if err := b.Marshal(m); err != nil {
+		return nil, err
+	}
+	raw := b.Bytes()
+
+	t := getTypeFromName(proto.MessageName(m))
+	if t == 0 {
+		return raw, nil
+	}
+
+	data := make([]byte, 1+binary.MaxVarintLen64)
+	data[0] = t
+	n := binary.PutUvarint(data[1:], uint64(len(raw)))
+	return append(data[:n+1], raw...), nil
+}
+
+func decodeFixture(name string, data []byte) (proto.Message, error) {
+	if strings.HasSuffix(name, ".pack") {
+		return Unpack(bytes.NewBuffer(data))
+	}
+
+	mType := proto.MessageType(strings.TrimSuffix(name, ".bin"))
+	if mType == nil {
+		return nil, fmt.Errorf("unknown message type for %s", name)
+	}
+	m := reflect.New(mType.Elem()).Interface().(proto.Message)
+	return m, proto.Unmarshal(data, m)
+}
+
+func TestWire_Fixtures(t *testing.T) {
+	if os.Getenv(fixturesUpdateEnv) != "" {
+		require.Nil(t, os.MkdirAll(fixturesDir, 0755))
+		for _, m := range wireFixtures() {
+			data, err := encodeFixture(m)
+			require.Nil(t, err)
+			require.Nil(t, ioutil.WriteFile(filepath.Join(fixturesDir, fixtureName(m)), data, 0644))
+		}
+		t.Logf("fixtures written to %s, update %s accordingly", fixturesDir, fixturesManifest)
+	}
+
+	for _, expected := range wireFixtures() {
+		name := fixtureName(expected)
+		data, err := ioutil.ReadFile(filepath.Join(fixturesDir, name))
+		require.Nil(t, err, "missing fixture, see %s", fixturesUpdateEnv)
+
+		m, err := decodeFixture(name, data)
+		require.Nil(t, err, name)
+		require.True(t, proto.Equal(expected, m), "%s: decoded %v, expected %v", name, m, expected)
+	}
+}
+
+func TestWire_Manifest(t *testing.T) {
+	f, err := os.Open(filepath.Join(fixturesDir, fixturesManifest))
+	require.Nil(t, err)
+	defer func() { _ = f.Close() }()
+
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		require.Len(t, fields, 2, "invalid manifest line %q", scanner.Text())
+		sums[fields[1]] = fields[0]
+	}
+	require.Nil(t, scanner.Err())
+
+	files, err := ioutil.ReadDir(fixturesDir)
+	require.Nil(t, err)
+	for _, file := range files {
+		if file.Name() == fixturesManifest {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(fixturesDir, file.Name()))
+		require.Nil(t, err)
+		sum := sha256.Sum256(data)
+
+		expected, ok := sums[file.Name()]
+		require.True(t, ok, "%s is not listed in the manifest", file.Name())
+		require.Equal(t, expected, hex.EncodeToString(sum[:]), "%s does not match the manifest", file.Name())
+		delete(sums, file.Name())
+	}
+	require.Empty(t, sums, "missing fixtures")
+}
+
+func TestWire_Coverage(t *testing.T) {
+	fixtures := make(map[string]bool)
+	for _, m := range wireFixtures() {
+		fixtures[fixtureName(m)] = true
+	}
+
+	for _, file := range wireFiles {
+		for _, name := range fileMessages(t, file) {
+			ok := fixtures[name+".bin"] || fixtures[name+".pack"]
+			require.True(t, ok, "%s (%s) has no wire fixture", name, file)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, name := range typeIdentifiers {
+		if i == 0 {
+			require.Empty(t, name, "the type 0 is invalid")
+			continue
+		}
+		if name == "reserved" {
+			continue
+		}
+
+		require.False(t, seen[name], "%s is registered twice", name)
+		seen[name] = true
+		require.NotNil(t, proto.MessageType(name), "%s is not a registered message", name)
+		require.True(t, fixtures[name+".pack"], "%s has no wire fixture", name)
+	}
+}
+
+// fileMessages returns the top-level messages of a registered protobuf file.
+func fileMessages(t *testing.T, file string) []string {
+	gz := proto.FileDescriptor(file)
+	require.NotNil(t, gz, "%s is not registered", file)
+
+	r, err := gzip.NewReader(bytes.NewReader(gz))
+	require.Nil(t, err)
+	raw, err := ioutil.ReadAll(r)
+	require.Nil(t, err)
+
+	fd := new(descriptor.FileDescriptorProto)
+	require.Nil(t, proto.Unmarshal(raw, fd))
+
+	names := make([]string, 0, len(fd.MessageType))
+	for _, m := range fd.MessageType {
+		names = append(names, fd.GetPackage()+"."+m.GetName())
+	}
+	return names
+}
+
+func TestWire_PackRoundTrip(t *testing.T) {
+	for _, m := range wireFixtures() {
+		name := proto.MessageName(m)
+		data, err := Pack(m)
+		require.Nil(t, err, name)
+
+		if getTypeFromName(name) == 0 {
+			require.Exactly(t, byte(0), data[0], name)
+			_, err = Unpack(bytes.NewBuffer(data))
+			require.NotNil(t, err, "%s is not a protocol message", name)
+			continue
+		}
+
+		m2, err := Unpack(bytes.NewBuffer(data))
+		require.Nil(t, err, name)
+		require.True(t, proto.Equal(m, m2), name)
+	}
+}
@@ -28,11 +28,16 @@ type network struct {
 	sync.Mutex
 
 	streamName string
+	subs       consensus.Subscriptions // messages accepted by no subscription are retained for the next ones
+
+	pullMutex sync.Mutex // protects pull, written by Close and after a failure
+	pull      redis.Conn // not pooled, so that closing it interrupts a blocking read
+	cancel    context.CancelFunc
 }
 
 // New returns a new redis-based centralized network.
 // It should only be used for demonstration purposes.
-func New(address, streamName string, database int) (n consensus.Network, err error) {
+func New(address, streamName string, database int) (consensus.Network, error) {
 	pool := &redis.Pool{
 		MaxIdle:     64,
 		IdleTimeout: 2 * time.Minute,
@@ -41,18 +46,29 @@ func New(address, streamName string, database int) (n consensus.Network, err err
 
 	push, err := pool.Dial()
 	if err != nil {
-		return
+		return nil, err
+	}
+
+	pull, err := pool.Dial()
+	if err != nil {
+		_ = push.Close()
+		return nil, err
 	}
 
-	n = &network{
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &network{
 		push:       push,
 		pool:       pool,
 		streamName: streamName,
+		subs:       consensus.Subscriptions{Retain: true},
+		pull:       pull,
+		cancel:     cancel,
 	}
-	return
+	go n.run(ctx)
+	return n, nil
 }
 
-func (n *network) Broadcast(m proto.Message) error {
+func (n *network) Broadcast(ctx context.Context, m proto.Message) error {
 	data, err := protocol.Pack(m)
 	if err != nil {
 		return err
@@ -61,53 +77,107 @@ func (n *network) Broadcast(m proto.Message) error {
 	n.Lock()
 	defer n.Unlock()
 
-	_, err = n.push.Do("XADD", n.streamName, "MAXLEN", "~", "1024", "*", "raw", data)
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	// A timed out connection cannot be used anymore
+	if n.push.Err() != nil {
+		_ = n.push.Close()
+		n.push, err = n.pool.Dial()
+		if err != nil {
+			return err
+		}
+	}
+
+	var timeout time.Duration // none without deadline
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	_, err = redis.DoWithTimeout(n.push, timeout, "XADD", n.streamName, "MAXLEN", "~", "1024", "*", "raw", data)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
 	return err
 }
 
-func (n *network) Accept(ctx context.Context, acceptor consensus.MessageAcceptor) <-chan proto.Message {
-	output := make(chan proto.Message)
-
-	go func() {
-		lastSeen := "0"
-		pull := n.pool.Get()
-		defer func() { _ = pull.Close() }()
-		defer close(output)
-
-		for {
-			streams, err := redis.Values(pull.Do("XREAD", "COUNT", "20", "BLOCK", "10000", "STREAMS", n.streamName, lastSeen))
-			if err != nil || len(streams) == 0 {
-				time.Sleep(time.Second) // Add some cooldown
-				select {
-				case <-ctx.Done():
-					return
-				default:
-					continue
-				}
+// run reads the stream from its beginning, and delivers its messages to the
+// subscriptions until the network is closed.
+func (n *network) run(ctx context.Context) {
+	lastSeen := "0"
+	for {
+		n.pullMutex.Lock()
+		pull := n.pull
+		n.pullMutex.Unlock()
+
+		streams, err := redis.Values(pull.Do("XREAD", "COUNT", "20", "BLOCK", "10000", "STREAMS", n.streamName, lastSeen))
+		if ctx.Err() != nil {
+			return
+		}
+		if err == redis.ErrNil { // no message before the timeout
+			continue
+		}
+		if err != nil || len(streams) == 0 {
+			select {
+			case <-time.After(time.Second): // Add some cooldown
+			case <-ctx.Done():
+				return
 			}
+			if pull.Err() != nil {
+				n.redial(ctx)
+			}
+			continue
+		}
 
-			events := streams[0].([]interface{})[1].([]interface{})
-			for _, event := range events {
-				eventData := event.([]interface{})
-				lastSeen = eventData[0].(string)
-				data := eventData[1].([]interface{})[1].([]byte)
-				m, err := protocol.Unpack(bytes.NewBuffer(data))
-				if err != nil || !acceptor(m) {
-					continue
-				}
-
-				select {
-				case output <- m:
-				case <-ctx.Done():
-					return
-				}
+		events := streams[0].([]interface{})[1].([]interface{})
+		for _, event := range events {
+			eventData := event.([]interface{})
+			lastSeen = eventData[0].(string)
+			data := eventData[1].([]interface{})[1].([]byte)
+			m, err := protocol.Unpack(bytes.NewBuffer(data))
+			if err != nil {
+				continue
 			}
+			n.subs.Deliver(m)
 		}
-	}()
+	}
+}
+
+// redial replaces a failed pull connection, unless the network is closed.
+func (n *network) redial(ctx context.Context) {
+	n.pullMutex.Lock()
+	defer n.pullMutex.Unlock()
 
-	return output
+	if ctx.Err() != nil {
+		return
+	}
+
+	pull, err := n.pool.Dial()
+	if err != nil {
+		return // retried after the next failure
+	}
+	_ = n.pull.Close()
+	n.pull = pull
+}
+
+func (n *network) Accept(ctx context.Context, acceptor consensus.MessageAcceptor) <-chan proto.Message {
+	return n.subs.Subscribe(ctx, acceptor)
+}
+
+// AcceptDrops returns the number of messages dropped because a subscription
+// was full.
+func (n *network) AcceptDrops() uint64 {
+	return n.subs.AcceptDrops()
 }
 
 func (n *network) Close() error {
+	n.cancel()
+
+	n.pullMutex.Lock()
+	_ = n.pull.Close() // interrupts the blocking read of run
+	n.pullMutex.Unlock()
+
+	n.subs.Close()
 	return n.push.Close()
 }
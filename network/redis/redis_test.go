@@ -14,20 +14,32 @@ import (
 	"time"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/gomodule/redigo/redis"
 	"github.com/stretchr/testify/require"
 	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/consensus/networktest"
 )
 
 const testKey = "teststream_network"
 
-func TestBroadcast(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+// newTestNetwork returns a network reading an empty stream.
+func newTestNetwork(t *testing.T) consensus.Network {
+	c, err := redis.Dial("tcp", ":6379")
+	require.Nil(t, err, "must connect to redis")
+	_, _ = c.Do("DEL", testKey)
+	_ = c.Close()
 
 	n, err := New(":6379", testKey, 0)
 	require.Nil(t, err, "must connect to redis")
+	return n
+}
 
-	_, _ = n.(*network).push.Do("DEL", testKey)
+func TestBroadcast(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	n := newTestNetwork(t)
+	defer func() { _ = n.Close() }()
 
 	fetched := make(chan proto.Message)
 	go func() {
@@ -39,12 +51,19 @@ func TestBroadcast(t *testing.T) {
 	time.Sleep(20 * time.Millisecond)
 
 	q := consensus.NewQuery()
-	err = n.Broadcast(q)
+	err := n.Broadcast(ctx, q)
 	require.Nil(t, err, "must broadcast without error")
 
 	q2 := consensus.NewQuery()
-	_ = n.Broadcast(q2)
+	_ = n.Broadcast(ctx, q2)
 
 	require.Equal(t, q.Uuid, (<-fetched).(*consensus.Query).Uuid)
 	require.Equal(t, q2.Uuid, (<-fetched).(*consensus.Query).Uuid)
 }
+
+func TestConformance(t *testing.T) {
+	networktest.RunConformance(t, networktest.Config{
+		New:    newTestNetwork,
+		Buffer: consensus.DefaultAcceptBuffer,
+	})
+}
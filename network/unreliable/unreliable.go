@@ -10,10 +10,13 @@
 package unreliable
 
 import (
+	"container/heap"
 	"context"
+	"errors"
 	"math"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/protobuf/proto"
@@ -22,6 +25,9 @@ import (
 
 var log2 = math.Log(2)
 
+// ErrInvalidParameters is returned when setting inconsistent parameters.
+var ErrInvalidParameters = errors.New("invalid unreliable network parameters")
+
 // Parameters hold unreliable network characterics
 type Parameters struct {
 	Seed int64
@@ -29,77 +35,259 @@ type Parameters struct {
 	MinLatency    time.Duration
 	MedianLatency time.Duration
 	MaxLatency    time.Duration
+
+	Loss        float64 // probability of dropping a broadcast message
+	Duplication float64 // probability of broadcasting a message twice
+}
+
+// Validate checks that latencies are ordered and probabilities within [0, 1].
+func (p Parameters) Validate() error {
+	if p.MinLatency < 0 || p.MinLatency > p.MaxLatency {
+		return ErrInvalidParameters
+	}
+
+	if p.Loss < 0 || p.Loss > 1 || p.Duplication < 0 || p.Duplication > 1 {
+		return ErrInvalidParameters
+	}
+
+	return nil
 }
 
 // New returns a new simulated unreliable network from a parent network
-func New(parent consensus.Network, p Parameters) consensus.Network {
+func New(parent consensus.Network, p Parameters) *Network {
 	if p.Seed <= 0 {
 		p.Seed = time.Now().UnixNano()
 	}
 
-	return &network{
-		Network:    parent,
-		Parameters: p,
-		rng:        rand.New(rand.NewSource(p.Seed)),
+	return &Network{
+		Network: parent,
+		params:  p,
+		rng:     rand.New(rand.NewSource(p.Seed)),
 	}
 }
 
-type network struct {
+// Network is a simulated unreliable network, whose parameters can be
+// updated at runtime. This type is thread-safe.
+type Network struct {
 	consensus.Network
-	Parameters
-	sync.Mutex // rng is not thread-safe
+	sync.Mutex // protects params and rng, which is not thread-safe
+
+	// AcceptBuffer is the capacity of the channels returned by Accept, and
+	// the number of messages each of them can delay, consensus.DefaultAcceptBuffer
+	// if zero. It shall not be modified once Accept has been called.
+	AcceptBuffer int
 
-	rng *rand.Rand
+	params Parameters
+	rng    *rand.Rand
+	drops  uint64 // atomic, messages dropped by Accept
 }
 
-func (n *network) Broadcast(m proto.Message) error {
-	go func() {
-		time.Sleep(n.randLatency())
-		_ = n.Network.Broadcast(m)
-	}()
+// Parameters returns the current parameters of the network.
+func (n *Network) Parameters() Parameters {
+	n.Lock()
+	defer n.Unlock()
+	return n.params
+}
 
+// SetParameters updates the parameters of the network, taken into account by
+// subsequent messages. The seed is kept unless a positive one is provided.
+func (n *Network) SetParameters(p Parameters) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	n.Lock()
+	defer n.Unlock()
+
+	if p.Seed > 0 {
+		n.rng.Seed(p.Seed)
+	} else {
+		p.Seed = n.params.Seed
+	}
+	n.params = p
 	return nil
 }
 
-func (n *network) Accept(ctx context.Context, acceptor consensus.MessageAcceptor) <-chan proto.Message {
-	output := make(chan proto.Message)
+// WithManagers returns the network, along with the recovery and pending sync
+// managers of its parent if any. Recovery and pending sync requests are not
+// affected by the unreliable parameters.
+func (n *Network) WithManagers() consensus.Network {
+	rec, isRec := n.Network.(consensus.RecoveryManager)
+	psm, isPsm := n.Network.(consensus.PendingSyncManager)
+
+	switch {
+	case isRec && isPsm:
+		return struct {
+			*Network
+			consensus.RecoveryManager
+			consensus.PendingSyncManager
+		}{n, rec, psm}
+	case isRec:
+		return struct {
+			*Network
+			consensus.RecoveryManager
+		}{n, rec}
+	case isPsm:
+		return struct {
+			*Network
+			consensus.PendingSyncManager
+		}{n, psm}
+	default:
+		return n
+	}
+}
+
+// Broadcast sends a message after a random latency, unless it is lost.
+// The message may be sent twice. It returns immediately: the parent network
+// is given as much time after the latency as ctx had before its deadline.
+func (n *Network) Broadcast(ctx context.Context, m proto.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var timeout time.Duration // none without deadline
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	n.Lock()
+	lost := n.rng.Float64() < n.params.Loss
+	copies := 1
+	if n.rng.Float64() < n.params.Duplication {
+		copies++
+	}
+	n.Unlock()
+
+	if lost {
+		return nil
+	}
+
+	for i := 0; i < copies; i++ {
+		d := n.randLatency()
+		go func() {
+			time.Sleep(d)
+
+			ctx := context.Background()
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			_ = n.Network.Broadcast(ctx, m)
+		}()
+	}
+
+	return nil
+}
+
+// Accept delivers the messages of the parent network after a random latency.
+// Messages are dropped when more than AcceptBuffer of them are delayed, or
+// ready but not read yet.
+func (n *Network) Accept(ctx context.Context, acceptor consensus.MessageAcceptor) <-chan proto.Message {
+	buffer := n.AcceptBuffer
+	if buffer <= 0 {
+		buffer = consensus.DefaultAcceptBuffer
+	}
+
+	output := make(chan proto.Message, buffer)
 	parentOutput := n.Network.Accept(ctx, acceptor)
 
 	go func() {
 		defer close(output)
-		for m := range parentOutput {
-			d := n.randLatency()
-
-			go func(m proto.Message) {
-				select {
-				case <-ctx.Done():
-				case <-time.After(d):
-					output <- m
+
+		var delayed delayedMessages
+		for {
+			var timer *time.Timer
+			var next <-chan time.Time
+			if len(delayed) > 0 {
+				timer = time.NewTimer(time.Until(delayed[0].at))
+				next = timer.C
+			}
+
+			select {
+			case m, ok := <-parentOutput:
+				if !ok {
+					return
+				}
+				if len(delayed) < buffer {
+					heap.Push(&delayed, delayedMessage{m: m, at: time.Now().Add(n.randLatency())})
+				} else {
+					atomic.AddUint64(&n.drops, 1)
+				}
+			case <-next:
+				now := time.Now()
+				for len(delayed) > 0 && !delayed[0].at.After(now) {
+					select {
+					case output <- heap.Pop(&delayed).(delayedMessage).m:
+					default:
+						atomic.AddUint64(&n.drops, 1)
+					}
 				}
-			}(m)
+			case <-ctx.Done():
+				return
+			}
+
+			if timer != nil {
+				timer.Stop()
+			}
 		}
 	}()
 
 	return output
 }
 
-func (n *network) randExp(median float64) float64 {
-	n.Lock()
-	defer n.Unlock()
+// AcceptDrops returns the number of messages dropped by Accept, including the
+// messages dropped by the parent network if it reports them.
+func (n *Network) AcceptDrops() uint64 {
+	drops := atomic.LoadUint64(&n.drops)
+	if d, ok := n.Network.(consensus.AcceptDropper); ok {
+		drops += d.AcceptDrops()
+	}
+	return drops
+}
+
+// CollectMetrics collects the measures of the parent network if it reports
+// them. Messages lost or delayed by the unreliable parameters are not counted.
+func (n *Network) CollectMetrics(m consensus.Metrics) {
+	if c, ok := n.Network.(consensus.MetricsCollector); ok {
+		c.CollectMetrics(m)
+	}
+}
 
-	factor := median / log2
-	return rand.ExpFloat64() * factor
+// delayedMessage is a message to deliver at some time.
+type delayedMessage struct {
+	m  proto.Message
+	at time.Time
 }
 
-func (n *network) randLatency() time.Duration {
-	d := time.Duration(n.randExp(float64(n.MedianLatency)))
+// delayedMessages is a heap of messages, the next one to deliver first.
+type delayedMessages []delayedMessage
+
+func (d delayedMessages) Len() int            { return len(d) }
+func (d delayedMessages) Less(i, j int) bool  { return d[i].at.Before(d[j].at) }
+func (d delayedMessages) Swap(i, j int)       { d[i], d[j] = d[j], d[i] }
+func (d *delayedMessages) Push(x interface{}) { *d = append(*d, x.(delayedMessage)) }
+
+func (d *delayedMessages) Pop() interface{} {
+	old := *d
+	m := old[len(old)-1]
+	*d = old[:len(old)-1]
+	return m
+}
+
+func (n *Network) randLatency() time.Duration {
+	n.Lock()
+	defer n.Unlock()
+
+	factor := float64(n.params.MedianLatency) / log2
+	d := time.Duration(n.rng.ExpFloat64() * factor)
 
-	if d < n.MinLatency {
-		return n.MinLatency
+	if d < n.params.MinLatency {
+		return n.params.MinLatency
 	}
 
-	if d > n.MaxLatency {
-		return n.MaxLatency
+	if d > n.params.MaxLatency {
+		return n.params.MaxLatency
 	}
 
 	return d
@@ -9,10 +9,17 @@
 package unreliable
 
 import (
+	"context"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+
 	"github.com/stretchr/testify/require"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/consensus/networktest"
 )
 
 func TestUnreliableLatency(t *testing.T) {
@@ -21,20 +28,20 @@ func TestUnreliableLatency(t *testing.T) {
 		MinLatency:    10 * time.Millisecond,
 		MedianLatency: 100 * time.Millisecond,
 		MaxLatency:    1 * time.Second,
-	}).(*network)
+	})
 
 	var down, up int
 	var max time.Duration
 	for i := 0; i < 10000; i++ {
 		d := n.randLatency()
-		require.True(t, d >= n.MinLatency, "minimum latency should be respected")
-		require.True(t, d <= n.MaxLatency, "maximum latency should be respected")
+		require.True(t, d >= n.params.MinLatency, "minimum latency should be respected")
+		require.True(t, d <= n.params.MaxLatency, "maximum latency should be respected")
 
 		if d > max {
 			max = d
 		}
 
-		if d < n.MedianLatency {
+		if d < n.params.MedianLatency {
 			down++
 		} else {
 			up++
@@ -43,3 +50,119 @@ func TestUnreliableLatency(t *testing.T) {
 
 	require.InEpsilon(t, down, up, 0.01, "median should be respected with lower than 1% error")
 }
+
+// recorder is a parent network recording the time of each broadcast.
+type recorder struct {
+	sync.Mutex
+	times []time.Time
+}
+
+func (r *recorder) Close() error { return nil }
+
+func (r *recorder) Broadcast(context.Context, proto.Message) error {
+	r.Lock()
+	defer r.Unlock()
+	r.times = append(r.times, time.Now())
+	return nil
+}
+
+func (r *recorder) Accept(context.Context, consensus.MessageAcceptor) <-chan proto.Message {
+	return nil
+}
+
+func (r *recorder) count() int {
+	r.Lock()
+	defer r.Unlock()
+	return len(r.times)
+}
+
+func (r *recorder) last() time.Time {
+	r.Lock()
+	defer r.Unlock()
+	return r.times[len(r.times)-1]
+}
+
+func (r *recorder) wait(t *testing.T, count int) {
+	for i := 0; i < 100 && r.count() < count; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, count, r.count())
+}
+
+func TestUnreliableSetParameters(t *testing.T) {
+	parent := &recorder{}
+	n := New(parent, Parameters{Seed: 1234})
+
+	from := time.Now()
+	require.Nil(t, n.Broadcast(context.Background(), &consensus.Query{}))
+	parent.wait(t, 1)
+	require.True(t, parent.last().Sub(from) < 100*time.Millisecond)
+
+	latency := 200 * time.Millisecond
+	require.Nil(t, n.SetParameters(Parameters{
+		MinLatency:    latency,
+		MedianLatency: latency,
+		MaxLatency:    latency,
+	}))
+	require.Equal(t, int64(1234), n.Parameters().Seed, "seed should be kept")
+
+	from = time.Now()
+	require.Nil(t, n.Broadcast(context.Background(), &consensus.Query{}))
+	time.Sleep(latency / 2)
+	require.Equal(t, 1, parent.count(), "latency should be applied to subsequent broadcasts")
+	parent.wait(t, 2)
+	require.True(t, parent.last().Sub(from) >= latency)
+
+	require.Equal(t, ErrInvalidParameters, n.SetParameters(Parameters{MinLatency: time.Second}))
+	require.Equal(t, ErrInvalidParameters, n.SetParameters(Parameters{Loss: 2}))
+	require.Equal(t, latency, n.Parameters().MinLatency, "invalid parameters should be ignored")
+}
+
+func TestUnreliableLossDuplication(t *testing.T) {
+	parent := &recorder{}
+	n := New(parent, Parameters{Seed: 1234, Loss: 1})
+
+	for i := 0; i < 10; i++ {
+		require.Nil(t, n.Broadcast(context.Background(), &consensus.Query{}))
+	}
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, 0, parent.count(), "every message should be lost")
+
+	require.Nil(t, n.SetParameters(Parameters{Duplication: 1}))
+	for i := 0; i < 10; i++ {
+		require.Nil(t, n.Broadcast(context.Background(), &consensus.Query{}))
+	}
+	parent.wait(t, 20)
+}
+
+type recoveryRecorder struct {
+	recorder
+	consensus.RecoveryManager
+}
+
+func TestUnreliableWithManagers(t *testing.T) {
+	n := New(&recorder{}, Parameters{})
+	_, ok := n.WithManagers().(consensus.RecoveryManager)
+	require.False(t, ok)
+
+	n = New(&recoveryRecorder{}, Parameters{})
+	_, ok = n.WithManagers().(consensus.RecoveryManager)
+	require.True(t, ok)
+	_, ok = n.WithManagers().(consensus.PendingSyncManager)
+	require.False(t, ok)
+}
+
+func TestUnreliableConformance(t *testing.T) {
+	networktest.RunConformance(t, networktest.Config{
+		New: func(*testing.T) consensus.Network {
+			n := New(networktest.NewLoopback(16), Parameters{
+				MinLatency:    time.Millisecond,
+				MedianLatency: 2 * time.Millisecond,
+				MaxLatency:    10 * time.Millisecond,
+			})
+			n.AcceptBuffer = 16
+			return n
+		},
+		Buffer: 16,
+	})
+}
@@ -0,0 +1,191 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+)
+
+// Content types of the debug endpoint.
+const (
+	contentBase64 = "text/plain; charset=utf-8"
+	contentRaw    = "application/octet-stream"
+	contentJSON   = "application/json"
+)
+
+// DebugHandler returns a read-only HTTP handler, serving:
+//
+//	GET /kv/{key}          the value of a key, base64-encoded, or raw when
+//	                       application/octet-stream is accepted
+//	GET /kv/{key}/version  the version of a key, in JSON
+//	GET /keys?prefix=      the keys starting with a prefix, in JSON
+//	GET /status            the information reported by Info, in JSON, with
+//	                       a 503 status code once the engine has failed
+//	GET /requests          the number of API requests by method and status
+//	                       code, in JSON, if they are logged (see RequestLog)
+//
+// Keys must be escaped as a single path segment (a slash is written %2F).
+// Values are read as through Get: soft-deleted, expired and local keys are
+// reported as missing.
+func (s *Server) DebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/kv/", s.debugKV)
+	mux.HandleFunc("/keys", s.debugKeys)
+	mux.HandleFunc("/status", s.debugStatus)
+	mux.HandleFunc("/requests", s.debugRequests)
+	return readOnly(mux)
+}
+
+// ServeDebug starts the read-only HTTP endpoint, see DebugHandler.
+func (s *Server) ServeDebug() error {
+	lis, err := net.Listen("tcp", s.DebugListen)
+	if err != nil {
+		return err
+	}
+
+	return http.Serve(lis, s.DebugHandler())
+}
+
+// readOnly rejects the requests which are neither GET nor HEAD.
+func readOnly(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "read-only endpoint", http.StatusMethodNotAllowed)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) debugKV(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/kv/"), "/")
+	if len(segments) > 2 || (len(segments) == 2 && segments[1] != "version") {
+		http.NotFound(w, r)
+		return
+	}
+
+	key, err := url.PathUnescape(segments[0])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	value, version, err := s.get(key, false)
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+
+	if len(segments) == 2 {
+		writeJSON(w, version)
+		return
+	}
+
+	switch negotiate(r.Header.Get("Accept")) {
+	case contentRaw:
+		w.Header().Set("Content-Type", contentRaw)
+		_, _ = w.Write(value)
+	case contentBase64:
+		w.Header().Set("Content-Type", contentBase64)
+		_, _ = w.Write([]byte(base64.StdEncoding.EncodeToString(value)))
+	default:
+		http.Error(w, "values are served as "+contentBase64+" or "+contentRaw, http.StatusNotAcceptable)
+	}
+}
+
+func (s *Server) debugKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.Keys(r.Context(), &api.KeysRequest{Prefix: r.URL.Query().Get("prefix")})
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+	writeJSON(w, keys)
+}
+
+func (s *Server) debugStatus(w http.ResponseWriter, r *http.Request) {
+	info, err := s.Info(r.Context(), &api.Empty{})
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+	if info.Failure != "" {
+		w.Header().Set("Content-Type", contentJSON)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSON(w, info)
+}
+
+func (s *Server) debugRequests(w http.ResponseWriter, r *http.Request) {
+	if s.RequestLog == nil {
+		http.Error(w, "requests are not logged", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentJSON)
+	if err := json.NewEncoder(w).Encode(s.RequestLog.Counts()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// negotiate returns the content type of a value matching an Accept header:
+// base64 text is preferred unless only raw bytes are accepted. It returns
+// the empty string if neither is acceptable.
+func negotiate(accept string) string {
+	if accept == "" {
+		return contentBase64
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		t, params, err := mime.ParseMediaType(part)
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		switch t {
+		case "text/plain", "text/*", "*/*":
+			return contentBase64
+		case contentRaw, "application/*":
+			return contentRaw
+		}
+	}
+	return ""
+}
+
+func writeJSON(w http.ResponseWriter, m proto.Message) {
+	w.Header().Set("Content-Type", contentJSON)
+	if err := (&jsonpb.Marshaler{}).Marshal(w, m); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// writeStatusError writes an error returned by the GRPC methods.
+func writeStatusError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch status.Code(err) {
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.InvalidArgument:
+		code = http.StatusBadRequest
+	case codes.FailedPrecondition:
+		code = http.StatusConflict
+	}
+	http.Error(w, status.Convert(err).Message(), code)
+}
@@ -0,0 +1,177 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package server
+
+import (
+	"context"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/metrics"
+)
+
+// readMethods are the methods which do not modify anything, and may be called
+// at a high rate: their logs are sampled.
+var readMethods = map[string]bool{
+	"Get":                  true,
+	"GetInt":               true,
+	"Members":              true,
+	"Contains":             true,
+	"Range":                true,
+	"Len":                  true,
+	"HGet":                 true,
+	"HGetAll":              true,
+	"GetProvenance":        true,
+	"GetStatus":            true,
+	"Keys":                 true,
+	"SnapshotRequirements": true,
+	"QuotaStatus":          true,
+	"Info":                 true,
+	"ClusterStatus":        true,
+	"Endorsers":            true,
+	"Certificate":          true,
+}
+
+// RequestLogger logs the requests of the API, and counts them by method and
+// status code. Requests modifying the database are always logged, read
+// requests are sampled, and requests slower than SlowThreshold are always
+// logged as warnings. Values are never logged, only the size of requests.
+type RequestLogger struct {
+	SampleRate    int               // one read request out of SampleRate is logged, none if zero
+	SlowThreshold time.Duration     // disabled if zero
+	Metrics       consensus.Metrics // optional, receives the counts as consensus.MetricAPIRequests
+
+	mutex  sync.Mutex
+	reads  map[string]uint64 // by method, for sampling
+	counts map[RequestCount]uint64
+}
+
+// RequestCount is the number of requests of a method which returned a status
+// code.
+type RequestCount struct {
+	Method string `json:"method"`
+	Code   string `json:"code"`
+	Count  uint64 `json:"count"`
+}
+
+// UnaryInterceptor logs and counts unary requests, see grpc.UnaryInterceptor.
+func (l *RequestLogger) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	res, err := handler(ctx, req)
+
+	fields := requestFields(ctx, req, res)
+	l.log(info.FullMethod, time.Since(start), err, fields)
+	return res, err
+}
+
+// StreamInterceptor logs and counts streams once they end, see
+// grpc.StreamInterceptor.
+func (l *RequestLogger) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+
+	fields := requestFields(ss.Context(), nil, nil)
+	l.log(info.FullMethod, time.Since(start), err, fields)
+	return err
+}
+
+// Counts returns the number of requests by method and status code, sorted.
+// This function is thread-safe.
+func (l *RequestLogger) Counts() []RequestCount {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	counts := make([]RequestCount, 0, len(l.counts))
+	for c, n := range l.counts {
+		c.Count = n
+		counts = append(counts, c)
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Method != counts[j].Method {
+			return counts[i].Method < counts[j].Method
+		}
+		return counts[i].Code < counts[j].Code
+	})
+	return counts
+}
+
+func (l *RequestLogger) log(fullMethod string, duration time.Duration, err error, fields []zap.Field) {
+	method := path.Base(fullMethod)
+	code := status.Code(err)
+
+	l.mutex.Lock()
+	if l.counts == nil {
+		l.counts = make(map[RequestCount]uint64)
+		l.reads = make(map[string]uint64)
+	}
+	l.counts[RequestCount{Method: method, Code: code.String()}]++
+
+	sampled := true
+	if readMethods[method] {
+		n := l.reads[method]
+		l.reads[method]++
+		sampled = l.SampleRate > 0 && n%uint64(l.SampleRate) == 0
+	}
+	l.mutex.Unlock()
+
+	if l.Metrics != nil {
+		l.Metrics.Count(metrics.Labeled(consensus.MetricAPIRequests, "method", method, "code", code.String()), 1)
+	}
+
+	slow := l.SlowThreshold > 0 && duration >= l.SlowThreshold
+	if !sampled && !slow {
+		return
+	}
+
+	fields = append(fields,
+		zap.String("method", method),
+		zap.Duration("duration", duration),
+		zap.String("code", code.String()),
+	)
+	if slow {
+		zap.L().Warn("SlowRequest", fields...)
+	} else {
+		zap.L().Info("Request", fields...)
+	}
+}
+
+// requestFields returns the fields describing the peer of a request, the
+// request and its response. The response is nil for failed requests.
+func requestFields(ctx context.Context, req, res interface{}) []zap.Field {
+	var fields []zap.Field
+	if p, ok := peer.FromContext(ctx); ok {
+		fields = append(fields, zap.Stringer("peer", p.Addr))
+	}
+
+	if m, ok := req.(proto.Message); ok {
+		fields = append(fields, zap.Int("size", proto.Size(m)))
+	}
+	if tx, ok := req.(*api.Transaction); ok {
+		fields = append(fields, zap.Int("operations", len(tx.Operations)))
+	}
+
+	switch r := res.(type) {
+	case *api.Receipt:
+		fields = append(fields, zap.String("uuid", r.Uuid))
+	case *api.QueryStatus:
+		fields = append(fields, zap.String("uuid", r.Uuid))
+	}
+	return fields
+}
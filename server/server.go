@@ -10,34 +10,107 @@
 package server
 
 import (
+	"io"
 	"net"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/golang/protobuf/ptypes"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/technicolor-research/pnyxdb/api"
 	"github.com/technicolor-research/pnyxdb/consensus"
 	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+	"github.com/technicolor-research/pnyxdb/network/unreliable"
+)
+
+const (
+	defaultPreviewLimit = 32
+	maxPreviewLimit     = 1024
+	maxStreamBatch      = 256 // transactions submitted at once by SubmitStream
 )
 
 // Server is the GRPC PnyxDB endpoint.
 type Server struct {
 	*consensus.Engine
-	Listen string
+	Listen        string
+	DebugListen   string        // read-only HTTP endpoint, see DebugHandler
+	Version       string        // reported by Info
+	MaxTimeout    time.Duration // later deadlines of transactions are clamped, unlimited if zero
+	MaxOperations int           // per transaction, unlimited if zero
+	MaxValueSize  int           // in bytes, for the data of an operation, unlimited if zero
+
+	Faults     *unreliable.Network // adjustable through the API, on staging nodes only
+	RequestLog *RequestLogger      // optional, logs and counts the requests of the API
+}
+
+// get reads a key from the store, or through the speculative overlay of the
+// engine if requested. Soft-deleted, expired and local keys are reported as
+// missing.
+func (s *Server) get(key string, speculative bool) ([]byte, *consensus.Version, error) {
+	if consensus.IsLocalKey(key) {
+		return nil, consensus.NoVersion, status.Error(codes.NotFound, "key is local to the node")
+	}
+
+	get := s.GetLive
+	if speculative {
+		get = s.GetSpeculative
+	}
+
+	value, version, err := get(key)
+	if err == consensus.ErrExpired {
+		return nil, consensus.NoVersion, status.Error(codes.NotFound, "key expired")
+	}
+	if err == consensus.ErrSpeculativeDeletion {
+		return nil, consensus.NoVersion, status.Error(codes.NotFound, "key deleted")
+	}
+	if version == consensus.NoVersion {
+		return nil, consensus.NoVersion, status.Error(codes.NotFound, "key not found")
+	}
+	if err == nil && encoding.IsTombstone(value) {
+		return nil, consensus.NoVersion, status.Error(codes.NotFound, "key deleted")
+	}
+	return value, version, err
 }
 
 // Get gets a value from the database.
 func (s *Server) Get(ctx context.Context, key *api.Key) (*api.Value, error) {
-	value, version, err := s.Store.Get(key.Key)
+	value, version, err := s.get(key.Key, key.Speculative)
 	return &api.Value{
 		Version: version,
 		Data:    value,
 	}, err
 }
 
+// GetInt gets an integer value from the database, such as a counter written
+// by INCR and DECR operations.
+func (s *Server) GetInt(ctx context.Context, key *api.Key) (*api.Integer, error) {
+	value, version, err := s.get(key.Key, key.Speculative)
+	if err != nil {
+		return nil, err
+	}
+
+	i := encoding.NewInt()
+	if err = i.UnmarshalBinary(value); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if !i.IsInt64() {
+		return nil, status.Error(codes.OutOfRange, "integer does not fit in 64 bits")
+	}
+
+	return &api.Integer{
+		Value:   i.Int64(),
+		Version: version,
+	}, nil
+}
+
 // Members returns the members of a specific set.
 func (s *Server) Members(ctx context.Context, key *api.Key) (*api.Values, error) {
-	value, version, err := s.Store.Get(key.Key)
+	value, version, err := s.get(key.Key, key.Speculative)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +133,7 @@ func (s *Server) Members(ctx context.Context, key *api.Key) (*api.Values, error)
 
 // Contains returns whether a particular set contains a specific value or not.
 func (s *Server) Contains(ctx context.Context, kv *api.KeyValue) (*api.Boolean, error) {
-	value, _, err := s.Store.Get(kv.Key)
+	value, _, err := s.get(kv.Key, kv.Speculative)
 	if err != nil {
 		return nil, err
 	}
@@ -74,15 +147,719 @@ func (s *Server) Contains(ctx context.Context, kv *api.KeyValue) (*api.Boolean,
 	return &api.Boolean{Boolean: set.Contains(kv.Value)}, nil
 }
 
+// getList returns the list stored at key.
+func (s *Server) getList(key string, speculative bool) (*encoding.List, *consensus.Version, error) {
+	value, version, err := s.get(key, speculative)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list := encoding.NewList()
+	if err = list.UnmarshalBinary(value); err != nil {
+		return nil, nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return list, version, nil
+}
+
+// Range returns the elements of a specific list from start to stop, both
+// included. Negative indexes count from the end of the list.
+func (s *Server) Range(ctx context.Context, r *api.RangeRequest) (*api.Values, error) {
+	list, version, err := s.getList(r.Key, r.Speculative)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.Values{
+		Version: version,
+		Data:    list.Range(int(r.Start), int(r.Stop)),
+	}, nil
+}
+
+// Len returns the number of elements of a specific list.
+func (s *Server) Len(ctx context.Context, key *api.Key) (*api.Length, error) {
+	list, version, err := s.getList(key.Key, key.Speculative)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.Length{
+		Length:  uint64(list.Len()),
+		Version: version,
+	}, nil
+}
+
+// getMap returns the map stored at key.
+func (s *Server) getMap(key string, speculative bool) (*encoding.Map, *consensus.Version, error) {
+	value, version, err := s.get(key, speculative)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m := encoding.NewMap()
+	if err = m.UnmarshalBinary(value); err != nil {
+		return nil, nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return m, version, nil
+}
+
+// HGet returns the value of a field of a specific map.
+func (s *Server) HGet(ctx context.Context, r *api.FieldRequest) (*api.Value, error) {
+	m, version, err := s.getMap(r.Key, r.Speculative)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := m.Fields[r.Field]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "field not found")
+	}
+
+	return &api.Value{
+		Version: version,
+		Data:    value,
+	}, nil
+}
+
+// HGetAll returns the fields of a specific map, sorted.
+func (s *Server) HGetAll(ctx context.Context, key *api.Key) (*api.Fields, error) {
+	m, version, err := s.getMap(key.Key, key.Speculative)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := &api.Fields{Version: version}
+	for _, field := range m.Names() {
+		fields.Fields = append(fields.Fields, &api.KeyValue{Key: field, Value: m.Fields[field]})
+	}
+	return fields, nil
+}
+
+// GetProvenance gets a value from the database, along with the query which
+// wrote it: its UUID, its emitter and when the node applied it. They are
+// empty for values written before provenance was recorded.
+func (s *Server) GetProvenance(ctx context.Context, key *api.Key) (*api.Provenance, error) {
+	value, version, err := s.get(key.Key, key.Speculative)
+	if err != nil {
+		return nil, err
+	}
+
+	p := version.GetProvenance()
+	return &api.Provenance{
+		Version:   version,
+		Data:      value,
+		Uuid:      p.GetUuid(),
+		Emitter:   p.GetEmitter(),
+		Committed: p.GetCommitted(),
+	}, nil
+}
+
 // Submit submits a set of operations to the database.
 func (s *Server) Submit(ctx context.Context, tx *api.Transaction) (*api.Receipt, error) {
+	query, err := s.newQuery(tx)
+	if err != nil {
+		return nil, err
+	}
+	err = s.Engine.Submit(query)
+	if err == consensus.ErrBackpressure {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return &api.Receipt{Uuid: query.Uuid}, err
+}
+
+// SubmitAndWait submits a set of operations to the database, and waits until
+// the transaction is dropped, committed and written to the local store, or
+// still pending at its deadline, before returning its status.
+func (s *Server) SubmitAndWait(ctx context.Context, tx *api.Transaction) (*api.QueryStatus, error) {
+	query, err := s.newQuery(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := s.Engine.SubmitAndWait(ctx, query)
+	switch err {
+	case nil, consensus.ErrUnknownQuery:
+	case context.Canceled:
+		return nil, status.Error(codes.Canceled, err.Error())
+	case context.DeadlineExceeded:
+		return nil, status.Error(codes.DeadlineExceeded, err.Error())
+	case consensus.ErrBackpressure:
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return nil, err
+	}
+	return queryStatus(query.Uuid, st)
+}
+
+// SubmitStream submits the transactions received on the stream, and sends
+// back a receipt for each of them, in order, holding its sequence. The
+// transactions received while a batch is submitted are gathered in the next
+// one. Failed submissions are reported by the receipts and do not end the
+// stream, which ends once the client has closed its side.
+func (s *Server) SubmitStream(stream api.Endorser_SubmitStreamServer) error {
+	ctx := stream.Context()
+
+	// The reception stops when the batch channel is full, leaving the flow
+	// control of the stream to slow down the client.
+	txs := make(chan *api.Transaction, maxStreamBatch)
+	failure := make(chan error, 1)
+	go func() {
+		defer close(txs)
+		for {
+			tx, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					failure <- err
+				}
+				return
+			}
+
+			select {
+			case txs <- tx:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for tx := range txs {
+		batch := []*api.Transaction{tx}
+	gather:
+		for len(batch) < maxStreamBatch {
+			select {
+			case tx, ok := <-txs:
+				if !ok {
+					break gather
+				}
+				batch = append(batch, tx)
+			default:
+				break gather
+			}
+		}
+
+		// Transactions exceeding the limits are reported without being submitted
+		queries := make([]*consensus.Query, len(batch))
+		errs := make([]error, len(batch))
+		var valid []*consensus.Query
+		for i, tx := range batch {
+			queries[i], errs[i] = s.newQuery(tx)
+			if errs[i] == nil {
+				valid = append(valid, queries[i])
+			}
+		}
+
+		submitted := s.Engine.SubmitBatch(valid)
+		for i, tx := range batch {
+			if errs[i] == nil {
+				errs[i], submitted = submitted[0], submitted[1:]
+			}
+
+			receipt := &api.Receipt{Sequence: tx.Sequence}
+			if errs[i] != nil {
+				receipt.Error = status.Convert(errs[i]).Message()
+			} else {
+				receipt.Uuid = queries[i].Uuid
+			}
+
+			err := stream.Send(receipt)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	select {
+	case err := <-failure:
+		return err
+	default:
+		return nil
+	}
+}
+
+// newQuery builds the query of a transaction, checking the limits of the
+// server.
+func (s *Server) newQuery(tx *api.Transaction) (*consensus.Query, error) {
+	if s.MaxOperations > 0 && len(tx.Operations) > s.MaxOperations {
+		return nil, status.Errorf(codes.InvalidArgument, "too many operations: %d > %d", len(tx.Operations), s.MaxOperations)
+	}
+	for _, op := range tx.Operations {
+		if consensus.IsLocalKey(op.Key) {
+			return nil, status.Errorf(codes.PermissionDenied, "key %s is reserved to the nodes", op.Key)
+		}
+		if s.MaxValueSize > 0 && len(op.Data) > s.MaxValueSize {
+			return nil, status.Errorf(codes.InvalidArgument, "value of %s too large: %d > %d bytes", op.Key, len(op.Data), s.MaxValueSize)
+		}
+	}
+
 	query := consensus.NewQuery()
 	query.Policy = tx.Policy
 	query.Requirements = tx.Requirements
 	query.Operations = tx.Operations
 	query.Deadline = tx.Deadline
 
-	return &api.Receipt{Uuid: query.Uuid}, s.Engine.Submit(query)
+	if s.MaxTimeout > 0 && tx.Deadline != nil {
+		max := time.Now().Add(s.MaxTimeout)
+		if deadline, err := ptypes.Timestamp(tx.Deadline); err == nil && deadline.After(max) {
+			query.Deadline, _ = ptypes.TimestampProto(max)
+		}
+	}
+
+	if err := query.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.CheckDeadline(query); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return query, nil
+}
+
+// Watch streams the keys starting with the requested prefix, every time they
+// are written by a committed transaction. Updates are dropped rather than
+// slowing down the node when the client does not keep up, as reported by
+// their dropped field.
+func (s *Server) Watch(req *api.WatchRequest, stream api.Endorser_WatchServer) error {
+	for u := range s.Engine.Watch(stream.Context(), req.Prefix) {
+		err := stream.Send(&api.KeyUpdate{
+			Key:     u.Key,
+			Version: u.Version,
+			Uuid:    u.Uuid,
+			Dropped: u.Dropped,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplayEvents streams the commit events recorded by the local journal,
+// starting after the requested sequence number. When follow is set, the
+// stream is kept open and new events are sent as they are committed.
+func (s *Server) ReplayEvents(req *api.ReplayRequest, stream api.Endorser_ReplayEventsServer) error {
+	if s.Journal == nil {
+		return status.Error(codes.Unavailable, "commit journal is disabled")
+	}
+
+	since := req.Since
+	for {
+		events, err := s.Journal.Replay(since)
+		if err == consensus.ErrJournalGap {
+			return status.Error(codes.OutOfRange, err.Error())
+		}
+
+		for _, e := range events {
+			err = stream.Send(e)
+			if err != nil {
+				return err
+			}
+			since = e.Sequence
+		}
+
+		if !req.Follow {
+			return nil
+		}
+
+		err = s.Journal.Wait(stream.Context(), since)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Certificate returns the commit certificate of a query committed locally,
+// as long as its commit event is retained by the journal.
+func (s *Server) Certificate(ctx context.Context, req *api.CertificateRequest) (*consensus.CommitCertificate, error) {
+	if s.Journal == nil {
+		return nil, status.Error(codes.Unavailable, "commit journal is disabled")
+	}
+
+	c, err := s.Journal.Certificate(req.Uuid)
+	if err == consensus.ErrNoCertificate {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return c, err
+}
+
+// Endorsers lists the endorsements of a query, while it is known by the
+// node, or from its commit certificate afterwards (see Engine.Endorsers).
+func (s *Server) Endorsers(ctx context.Context, req *api.EndorsersRequest) (*api.EndorserInfos, error) {
+	endorsers, certified, err := s.Engine.Endorsers(req.Uuid)
+	if err == consensus.ErrUnknownQuery {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := &api.EndorserInfos{
+		Endorsers: make([]*api.EndorserInfo, len(endorsers)),
+		Certified: certified,
+	}
+	for i, e := range endorsers {
+		out.Endorsers[i] = &api.EndorserInfo{
+			Emitter:     e.Emitter,
+			Conditions:  e.Conditions,
+			Fingerprint: e.Fingerprint,
+			Aggregated:  e.Aggregated,
+		}
+	}
+	return out, nil
+}
+
+// SnapshotRequirements returns the versions of several keys, read
+// atomically. Missing keys are mapped to the empty version.
+func (s *Server) SnapshotRequirements(ctx context.Context, req *api.KeyList) (*api.Requirements, error) {
+	versions, err := s.Engine.Snapshot(req.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.Requirements{Requirements: versions}, nil
+}
+
+// RetentionDryRun reports the retention policies, along with the expired
+// keys that retention queries would delete, without deleting anything.
+func (s *Server) RetentionDryRun(ctx context.Context, _ *api.Empty) (*api.RetentionReport, error) {
+	limit := s.RetentionMaxKeys
+	if limit <= 0 {
+		limit = consensus.DefaultRetentionMaxKeys
+	}
+
+	expired, err := s.Engine.ExpiredKeys(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &api.RetentionReport{}
+	for _, p := range s.Engine.RetentionPolicies() {
+		res.Policies = append(res.Policies, &api.RetentionPolicy{
+			Prefix: p.Prefix,
+			Age:    int64(p.Age / time.Second),
+		})
+	}
+
+	for _, e := range expired {
+		modified, err := ptypes.TimestampProto(e.Modified)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Expired = append(res.Expired, &api.ExpiredKey{
+			Key:      e.Key,
+			Prefix:   e.Prefix,
+			Modified: modified,
+		})
+	}
+	return res, nil
+}
+
+// AdminDrop drops a pending query on every node, on behalf of a quorum of
+// administrators.
+func (s *Server) AdminDrop(ctx context.Context, d *consensus.AdminDrop) (*api.Empty, error) {
+	err := consensus.VerifyAdminDrop(d, s.KeyRing, s.Admins, s.AdminQuorum)
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	return &api.Empty{}, s.Engine.SubmitAdminDrop(d)
+}
+
+// maxTimeout returns the maximum timeout of the transactions accepted by the
+// node, zero if unlimited: deadlines beyond MaxTimeout are clamped, and those
+// beyond the limit of the engine rejected.
+func (s *Server) maxTimeout() time.Duration {
+	limit := s.QueryTimeoutLimit()
+	if limit < 0 || s.MaxTimeout > 0 && s.MaxTimeout < limit {
+		return s.MaxTimeout
+	}
+	return limit
+}
+
+// Info returns the configuration of the node relevant to its clients: the
+// limits of the transactions it accepts, the policies allowing it to write
+// keys, and its optional features.
+func (s *Server) Info(ctx context.Context, _ *api.Empty) (*api.NodeInfo, error) {
+	info := &api.NodeInfo{
+		Identity:      s.Identity(),
+		Version:       s.Version,
+		Threshold:     uint32(s.Quorum()),
+		MaxTimeout:    int64(s.maxTimeout() / time.Millisecond),
+		MaxOperations: uint32(s.MaxOperations),
+		MaxValueSize:  uint64(s.MaxValueSize),
+	}
+	if err := s.Failed(); err != nil {
+		info.Failure = err.Error()
+	}
+
+	memory := s.MemoryUsage()
+	info.MemoryUsed = uint64(memory.Used)
+	info.MemoryBudget = uint64(memory.Budget)
+	info.MemoryRejected = memory.Rejected
+
+	policies := make(map[string]*api.PolicyInfo)
+	for _, r := range s.WriteRules() {
+		p, ok := policies[r.Policy]
+		if !ok {
+			p = &api.PolicyInfo{Name: r.Policy}
+			policies[r.Policy] = p
+			info.Policies = append(info.Policies, p)
+		}
+		if r.Identity == info.Identity || r.Identity == consensus.AnyIdentity {
+			p.Prefixes = append(p.Prefixes, r.Prefixes...)
+		}
+	}
+
+	ops := make([]int, 0, len(consensus.Operation_Op_name))
+	for op := range consensus.Operation_Op_name {
+		ops = append(ops, int(op))
+	}
+	sort.Ints(ops)
+	for _, op := range ops {
+		info.Operations = append(info.Operations, consensus.Operation_Op_name[int32(op)])
+	}
+
+	_, recovery := s.Network.(consensus.RecoveryManager)
+	features := []struct {
+		name    string
+		enabled bool
+	}{
+		{"events", s.Journal != nil},
+		{"quotas", len(s.Engine.QuotaStatus()) > 0},
+		{"retention", s.RetentionPeriod > 0},
+		{"writerules", len(info.Policies) > 0},
+		{"admindrop", len(s.Admins) > 0},
+		{"aggregation", s.CanAggregate()},
+		{"recovery", recovery},
+		{"statusretention", s.StatusRetention > 0},
+		{"nodestatus", s.NodeStatusPeriod > 0},
+		{"faultinjection", s.Faults != nil},
+	}
+	for _, f := range features {
+		if f.enabled {
+			info.Features = append(info.Features, f.name)
+		}
+	}
+	return info, nil
+}
+
+// QuotaStatus returns the storage usage of every prefix having a quota.
+func (s *Server) QuotaStatus(ctx context.Context, _ *api.Empty) (*api.Quotas, error) {
+	res := &api.Quotas{}
+	for _, q := range s.Engine.QuotaStatus() {
+		res.Quotas = append(res.Quotas, &api.Quota{
+			Prefix: q.Prefix,
+			Used:   q.Used,
+			Limit:  q.Limit,
+		})
+	}
+	return res, nil
+}
+
+// GetStatus returns the status of a submitted transaction. Transactions
+// unknown to the node, or forgotten after the status retention, are reported
+// with the "unknown" state.
+func (s *Server) GetStatus(ctx context.Context, r *api.Receipt) (*api.QueryStatus, error) {
+	st, err := s.Engine.QueryStatus(r.GetUuid())
+	if err != nil && err != consensus.ErrUnknownQuery {
+		return nil, err
+	}
+	return queryStatus(r.GetUuid(), st)
+}
+
+func queryStatus(uuid string, st consensus.QueryStatus) (*api.QueryStatus, error) {
+	res := &api.QueryStatus{
+		Uuid:         uuid,
+		State:        string(st.State),
+		Endorsements: uint32(st.Endorsements),
+		Applied:      st.Applied,
+	}
+	if st.ApplyError != nil {
+		res.ApplyError = st.ApplyError.Error()
+	}
+
+	var err error
+	if !st.Deadline.IsZero() {
+		res.Deadline, err = ptypes.TimestampProto(st.Deadline)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !st.Resolved.IsZero() {
+		res.Resolved, err = ptypes.TimestampProto(st.Resolved)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// ClusterStatus returns the status of the node, followed by the recent
+// statuses broadcast by the other nodes.
+func (s *Server) ClusterStatus(ctx context.Context, _ *api.Empty) (*api.NodeStatuses, error) {
+	nodes, err := s.Engine.ClusterStatus()
+	if err != nil {
+		return nil, err
+	}
+	return &api.NodeStatuses{Nodes: nodes}, nil
+}
+
+// UnknownEmitters returns the identities missing from the keyring of the
+// node, whose queries or endorsements have been received recently.
+func (s *Server) UnknownEmitters(ctx context.Context, _ *api.Empty) (*api.UnknownEmitterList, error) {
+	res := &api.UnknownEmitterList{}
+	for _, u := range s.Engine.UnknownEmitters() {
+		firstSeen, err := ptypes.TimestampProto(u.FirstSeen)
+		if err != nil {
+			return nil, err
+		}
+		lastSeen, err := ptypes.TimestampProto(u.LastSeen)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Emitters = append(res.Emitters, &api.UnknownEmitter{
+			Identity:    u.Identity,
+			Messages:    u.Messages,
+			Quarantined: uint32(u.Quarantined),
+			FirstSeen:   firstSeen,
+			LastSeen:    lastSeen,
+		})
+	}
+	return res, nil
+}
+
+// RecoveryStatus returns the state of the recoveries asked to the node,
+// including the keys whose recovery failed too many times.
+func (s *Server) RecoveryStatus(ctx context.Context, _ *api.Empty) (*api.RecoveryReport, error) {
+	return recoveryReport(s.Engine.RecoveryStatus())
+}
+
+// RetryRecovery submits again the recovery of dead letters, or of all of
+// them if no key is provided.
+func (s *Server) RetryRecovery(ctx context.Context, req *api.KeyList) (*api.RecoveryReport, error) {
+	_, err := s.Engine.RetryDeadLetters(req.GetKeys()...)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return recoveryReport(s.Engine.RecoveryStatus())
+}
+
+func recoveryReport(r consensus.RecoveryReport) (*api.RecoveryReport, error) {
+	res := &api.RecoveryReport{
+		Breaker:         string(r.Breaker),
+		Pending:         uint32(r.Pending),
+		Retries:         r.Retries,
+		Successes:       r.Successes,
+		Failures:        r.Failures,
+		BreakerOpenings: r.BreakerOpenings,
+	}
+
+	for _, d := range r.DeadLetters {
+		t, err := ptypes.TimestampProto(d.Time)
+		if err != nil {
+			return nil, err
+		}
+
+		res.DeadLetters = append(res.DeadLetters, &api.DeadLetter{
+			Key:      d.Key,
+			Attempts: uint32(d.Attempts),
+			Error:    d.Err,
+			Time:     t,
+		})
+	}
+	return res, nil
+}
+
+// GetFaultProfile returns the faults injected in the broadcasts of a staging
+// node.
+func (s *Server) GetFaultProfile(ctx context.Context, _ *api.Empty) (*api.FaultProfile, error) {
+	if s.Faults == nil {
+		return nil, status.Error(codes.FailedPrecondition, "fault injection is disabled")
+	}
+	return faultProfile(s.Faults.Parameters()), nil
+}
+
+// SetFaultProfile updates the faults injected in the broadcasts of a staging
+// node, starting with the next messages.
+func (s *Server) SetFaultProfile(ctx context.Context, p *api.FaultProfile) (*api.FaultProfile, error) {
+	if s.Faults == nil {
+		return nil, status.Error(codes.FailedPrecondition, "fault injection is disabled")
+	}
+
+	err := s.Faults.SetParameters(unreliable.Parameters{
+		Seed:          p.Seed,
+		MinLatency:    time.Duration(p.MinLatency) * time.Millisecond,
+		MedianLatency: time.Duration(p.MedianLatency) * time.Millisecond,
+		MaxLatency:    time.Duration(p.MaxLatency) * time.Millisecond,
+		Loss:          p.Loss,
+		Duplication:   p.Duplication,
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return faultProfile(s.Faults.Parameters()), nil
+}
+
+func faultProfile(p unreliable.Parameters) *api.FaultProfile {
+	return &api.FaultProfile{
+		MinLatency:    int64(p.MinLatency / time.Millisecond),
+		MedianLatency: int64(p.MedianLatency / time.Millisecond),
+		MaxLatency:    int64(p.MaxLatency / time.Millisecond),
+		Loss:          p.Loss,
+		Duplication:   p.Duplication,
+		Seed:          p.Seed,
+	}
+}
+
+// Keys lists the keys starting with a prefix, sorted. Soft-deleted, expired
+// and local keys are omitted. Details about values (type, size and a preview of at most
+// PreviewLimit bytes) can be requested.
+func (s *Server) Keys(ctx context.Context, req *api.KeysRequest) (*api.KeyInfos, error) {
+	limit := int(req.PreviewLimit)
+	if limit == 0 {
+		limit = defaultPreviewLimit
+	}
+	if limit > maxPreviewLimit {
+		limit = maxPreviewLimit
+	}
+
+	s.Store.Lock()
+	defer s.Store.Unlock()
+
+	list, err := s.Store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &api.KeyInfos{}
+	for key, version := range list {
+		if !strings.HasPrefix(key, req.Prefix) || consensus.IsLocalKey(key) {
+			continue
+		}
+
+		data, _, err := s.GetLive(key)
+		if err == consensus.ErrExpired {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if encoding.IsTombstone(data) {
+			continue
+		}
+
+		info := &api.KeyInfo{Key: key, Version: version}
+		if req.Details {
+			t := encoding.TypeOf(data)
+			info.Type = string(t)
+			info.Size = uint64(len(data))
+			info.Preview = encoding.Preview(data, t, limit)
+		}
+		res.Keys = append(res.Keys, info)
+	}
+
+	sort.Slice(res.Keys, func(i, j int) bool {
+		return res.Keys[i].Key < res.Keys[j].Key
+	})
+	return res, nil
 }
 
 // Serve starts the PnyxDB GRPC server for clients.
@@ -92,7 +869,15 @@ func (s *Server) Serve() error {
 		return err
 	}
 
-	srv := grpc.NewServer()
+	var opts []grpc.ServerOption
+	if s.RequestLog != nil {
+		opts = append(opts,
+			grpc.UnaryInterceptor(s.RequestLog.UnaryInterceptor),
+			grpc.StreamInterceptor(s.RequestLog.StreamInterceptor),
+		)
+	}
+
+	srv := grpc.NewServer(opts...)
 	api.RegisterEndorserServer(srv, s)
 	return srv.Serve(lis)
 }
@@ -10,15 +10,41 @@
 package boltdb
 
 import (
+	"encoding/binary"
 	"errors"
 	"sync"
+	"time"
 
 	bolt "github.com/coreos/bbolt"
+	"github.com/golang/protobuf/proto"
 	"github.com/technicolor-research/pnyxdb/consensus"
 )
 
 var bucketName = []byte("pnyxdb")
+var systemBucketName = []byte("pnyxdb-system")
+var metaBucketName = []byte("pnyxdb-meta")
+var layoutKey = []byte("layout")
+var upgradeKey = []byte("upgrade") // layout an upgrade in progress started from
+
 var errNotFound = errors.New("key corrupted or unknown")
+var errLayout = errors.New("unsupported record layout, the database has been written by a newer version")
+
+// Records are made of the hash of the version, followed by the value in the
+// legacy layout. The current layout inserts the other fields of the version,
+// such as the expiry and the provenance, as a protobuf message prefixed by its
+// length (varint) between the hash and the value; records written before a
+// field was added simply lack it.
+//
+// Keys local to the node (see consensus.IsLocalKey) are kept in a bucket of
+// their own since the system bucket layout, apart from the keys written by
+// clients. Databases are upgraded when opened, see migrations.
+const (
+	layoutLegacy byte = iota
+	layoutVersionFields
+	layoutSystemBucket
+
+	currentLayout = layoutSystemBucket
+)
 
 // store is the driver for the BoltDB store engine.
 type store struct {
@@ -27,41 +53,195 @@ type store struct {
 	db *bolt.DB
 }
 
-// New generates a new BoltDB store from the storage path.
+// Options control how a database is opened, see Open.
+type Options struct {
+	// NoMigrate refuses to open databases written with an older layout,
+	// with ErrMigrationRefused, instead of upgrading them.
+	NoMigrate bool
+}
+
+// New generates a new BoltDB store from the storage path, upgrading the
+// database if needed.
 func New(path string) (consensus.Store, error) {
-	db, err := bolt.Open(path, 0600, nil)
+	return Open(path, Options{})
+}
+
+// Open generates a new BoltDB store from the storage path. Databases
+// written with an older layout are copied next to the database before being
+// upgraded (see backupPath).
+func Open(path string, opts Options) (consensus.Store, error) {
+	// BoltDB databases can be opened by a single process: fail instead of
+	// waiting forever when the database is used by a running node.
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
 	if err != nil {
 		return nil, err
 	}
 
 	s := &store{db: db}
+	err = s.open(path, opts)
+	if err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *store) open(path string, opts Options) error {
+	layout, err := s.layout()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case layout > currentLayout:
+		return errLayout
+	case layout < currentLayout && opts.NoMigrate:
+		return ErrMigrationRefused
+	case layout < currentLayout:
+		if err = s.backup(path, layout); err != nil {
+			return err
+		}
+		if err = s.migrate(layout); err != nil {
+			return err
+		}
+	}
 
-	err = s.db.Update(func(tx *bolt.Tx) error {
-		_, e := tx.CreateBucketIfNotExists(bucketName)
-		return e
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := createBuckets(tx); err != nil {
+			return err
+		}
+		meta := tx.Bucket(metaBucketName)
+		if err := meta.Delete(upgradeKey); err != nil {
+			return err
+		}
+		return meta.Put(layoutKey, []byte{currentLayout})
 	})
+}
+
+// upgradeLegacy converts the records of b from the legacy layout, inserting
+// an empty set of version fields after each hash.
+func upgradeLegacy(b *bolt.Bucket) error {
+	var keys, records [][]byte
+	err := b.ForEach(func(k, d []byte) error {
+		if len(d) < consensus.VersionBytes {
+			return nil // left as is, reported as corrupted
+		}
 
+		record := make([]byte, 0, len(d)+1)
+		record = append(record, d[:consensus.VersionBytes]...)
+		record = append(record, 0) // length of the fields
+		record = append(record, d[consensus.VersionBytes:]...)
+
+		keys = append(keys, append([]byte(nil), k...))
+		records = append(records, record)
+		return nil
+	})
 	if err != nil {
-		_ = s.Close()
+		return err
 	}
 
-	return s, nil
+	for i, k := range keys {
+		if err = b.Put(k, records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// separateSystemKeys moves the records of the keys local to the node from b
+// to system.
+func separateSystemKeys(b, system *bolt.Bucket) error {
+	var keys [][]byte
+	err := b.ForEach(func(k, _ []byte) error {
+		if consensus.IsLocalKey(string(k)) {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err = system.Put(k, b.Get(k)); err != nil {
+			return err
+		}
+		if err = b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bucketOf returns the bucket holding a key.
+func bucketOf(tx *bolt.Tx, key string) *bolt.Bucket {
+	if consensus.IsLocalKey(key) {
+		return tx.Bucket(systemBucketName)
+	}
+	return tx.Bucket(bucketName)
+}
+
+// encodeRecord returns the record of a value and its version.
+func encodeRecord(value []byte, v *consensus.Version) ([]byte, error) {
+	hash, err := v.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if len(hash) != consensus.VersionBytes {
+		return nil, errors.New("invalid version")
+	}
+
+	fields, err := proto.Marshal(&consensus.Version{Expiry: v.GetExpiry(), Provenance: v.GetProvenance()})
+	if err != nil {
+		return nil, err
+	}
+
+	record := make([]byte, consensus.VersionBytes+binary.MaxVarintLen64, consensus.VersionBytes+binary.MaxVarintLen64+len(fields)+len(value))
+	copy(record, hash)
+	n := binary.PutUvarint(record[consensus.VersionBytes:], uint64(len(fields)))
+	record = record[:consensus.VersionBytes+n]
+	record = append(record, fields...)
+	return append(record, value...), nil
+}
+
+// decodeRecord returns the version stored in a record, and the offset of the
+// value.
+func decodeRecord(record []byte) (*consensus.Version, int, error) {
+	if len(record) < consensus.VersionBytes {
+		return consensus.NoVersion, 0, errNotFound
+	}
+
+	size, n := binary.Uvarint(record[consensus.VersionBytes:])
+	start := consensus.VersionBytes + n
+	if n <= 0 || size > uint64(len(record)-start) {
+		return consensus.NoVersion, 0, errNotFound
+	}
+	end := start + int(size)
+
+	v := &consensus.Version{}
+	if err := proto.Unmarshal(record[start:end], v); err != nil {
+		return consensus.NoVersion, 0, errNotFound
+	}
+	if err := v.UnmarshalBinary(record[:consensus.VersionBytes]); err != nil {
+		return consensus.NoVersion, 0, err
+	}
+	return v, end, nil
 }
 
 func (s *store) Get(key string) (value []byte, v *consensus.Version, err error) {
 	err = s.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bucketName)
-
-		data := b.Get([]byte(key))
-		if len(data) < consensus.VersionBytes {
-			v = consensus.NoVersion
-			return errNotFound
+		data := bucketOf(tx, key).Get([]byte(key))
+		var offset int
+		var e error
+		v, offset, e = decodeRecord(data)
+		if e != nil {
+			return e
 		}
 
-		value = make([]byte, len(data[consensus.VersionBytes:]))
-		copy(value, data[consensus.VersionBytes:])
-		v = &consensus.Version{}
-		return v.UnmarshalBinary(data[:consensus.VersionBytes])
+		value = make([]byte, len(data[offset:]))
+		copy(value, data[offset:])
+		return nil
 	})
 
 	return
@@ -72,16 +252,26 @@ func (s *store) Set(key string, value []byte, v *consensus.Version) error {
 }
 
 func (s *store) SetBatch(keys []string, values [][]byte, versions []*consensus.Version) error {
+	return s.UpdateBatch(keys, values, versions, nil)
+}
+
+// UpdateBatch sets and removes keys in a single transaction, see
+// consensus.BatchDeleter.
+func (s *store) UpdateBatch(keys []string, values [][]byte, versions []*consensus.Version, deleted []string) error {
 	return s.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bucketName)
+		for _, k := range deleted {
+			if err := bucketOf(tx, k).Delete([]byte(k)); err != nil {
+				return err
+			}
+		}
 
 		for i, k := range keys {
-			rv, err := versions[i].MarshalBinary()
+			record, err := encodeRecord(values[i], versions[i])
 			if err != nil {
 				return err
 			}
 
-			err = b.Put([]byte(k), append(rv[:consensus.VersionBytes], values[i]...))
+			err = bucketOf(tx, k).Put([]byte(k), record)
 			if err != nil {
 				return err
 			}
@@ -91,16 +281,19 @@ func (s *store) SetBatch(keys []string, values [][]byte, versions []*consensus.V
 	})
 }
 
+func (s *store) Delete(key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return bucketOf(tx, key).Delete([]byte(key))
+	})
+}
+
 func (s *store) List() (map[string]*consensus.Version, error) {
 	catalog := make(map[string]*consensus.Version)
 	err := s.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bucketName)
-		c := b.Cursor()
-
-		for k, d := c.First(); k != nil; k, d = c.Next() {
-			if len(d) >= consensus.VersionBytes {
-				v := &consensus.Version{}
-				if v.UnmarshalBinary(d[:consensus.VersionBytes]) == nil {
+		for _, name := range [][]byte{bucketName, systemBucketName} {
+			c := tx.Bucket(name).Cursor()
+			for k, d := c.First(); k != nil; k, d = c.Next() {
+				if v, _, err := decodeRecord(d); err == nil {
 					catalog[string(k)] = v
 				}
 			}
@@ -14,7 +14,11 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	bolt "github.com/coreos/bbolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/stretchr/testify/require"
 	"github.com/technicolor-research/pnyxdb/consensus"
 )
@@ -95,6 +99,21 @@ func TestS_Get_Unknown(t *testing.T) {
 	require.Exactly(t, v, consensus.NoVersion)
 }
 
+func TestS_Delete(t *testing.T) {
+	d := []byte("Content")
+	require.Nil(t, ts.Set("testDelete", d, consensus.NewVersion(d)))
+	require.Nil(t, ts.Delete("testDelete"))
+
+	_, v, err := ts.Get("testDelete")
+	require.NotNil(t, err)
+	require.Exactly(t, v, consensus.NoVersion)
+
+	list, err := ts.List()
+	require.Nil(t, err)
+	require.NotContains(t, list, "testDelete")
+	require.Nil(t, ts.Delete("testDelete"), "deleting an unknown key should be a no-op")
+}
+
 func TestS_List(t *testing.T) {
 	d := []byte("Content")
 	v := consensus.NewVersion(d)
@@ -109,3 +128,167 @@ func TestS_List(t *testing.T) {
 	require.Contains(t, catalog, "testBatch_c")
 	require.Exactly(t, catalog["testList"], v)
 }
+
+func TestS_UpdateBatch(t *testing.T) {
+	d := []byte("Content")
+	require.Nil(t, ts.Set("testUpdate_a", d, consensus.NewVersion(d)))
+
+	// An invalid version aborts the whole batch, including the deletions
+	err := ts.UpdateBatch(
+		[]string{"testUpdate_b"},
+		[][]byte{d},
+		[]*consensus.Version{{Hash: []byte("short")}},
+		[]string{"testUpdate_a"},
+	)
+	require.NotNil(t, err)
+	_, _, err = ts.Get("testUpdate_a")
+	require.Nil(t, err, "the deletion should be rolled back")
+
+	require.Nil(t, ts.UpdateBatch(
+		[]string{"testUpdate_b"},
+		[][]byte{d},
+		[]*consensus.Version{consensus.NewVersion(d)},
+		[]string{"testUpdate_a"},
+	))
+	_, _, err = ts.Get("testUpdate_a")
+	require.NotNil(t, err)
+	value, _, err := ts.Get("testUpdate_b")
+	require.Nil(t, err)
+	require.Exactly(t, d, value)
+
+	require.Nil(t, ts.Delete("testUpdate_b"))
+}
+
+func TestS_Expiry(t *testing.T) {
+	d := []byte("Ephemeral")
+	v := consensus.NewVersion(d)
+	v.Expiry, _ = ptypes.TimestampProto(time.Unix(1500000000, 42))
+	require.Nil(t, ts.Set("testExpiry", d, v))
+	defer func() { _ = ts.Delete("testExpiry") }()
+
+	d2, v2, err := ts.Get("testExpiry")
+	require.Nil(t, err)
+	require.Exactly(t, d, d2)
+	require.Nil(t, v2.Matches(v))
+	require.True(t, v2.ExpiryTime().Equal(time.Unix(1500000000, 42)))
+
+	catalog, err := ts.List()
+	require.Nil(t, err)
+	require.True(t, catalog["testExpiry"].ExpiryTime().Equal(time.Unix(1500000000, 42)))
+}
+
+func TestS_Provenance(t *testing.T) {
+	d := []byte("Audited")
+	v := consensus.NewVersion(d)
+	committed, _ := ptypes.TimestampProto(time.Unix(1500000000, 42))
+	v.Provenance = &consensus.Provenance{Uuid: "query-uuid", Emitter: "emitter", Committed: committed}
+	require.Nil(t, ts.Set("testProvenance", d, v))
+	defer func() { _ = ts.Delete("testProvenance") }()
+
+	d2, v2, err := ts.Get("testProvenance")
+	require.Nil(t, err)
+	require.Exactly(t, d, d2)
+	require.Nil(t, v2.Matches(v))
+	require.True(t, proto.Equal(v.Provenance, v2.Provenance))
+
+	// Written before provenance was recorded
+	require.Nil(t, ts.Set("testProvenance", d, consensus.NewVersion(d)))
+	d2, v2, err = ts.Get("testProvenance")
+	require.Nil(t, err)
+	require.Exactly(t, d, d2)
+	require.Nil(t, v2.Provenance)
+}
+
+// bucketHolding returns the name of the bucket holding key in s, if any.
+func bucketHolding(t *testing.T, s *store, key string) string {
+	var name string
+	require.Nil(t, s.db.View(func(tx *bolt.Tx) error {
+		for _, n := range [][]byte{bucketName, systemBucketName} {
+			if tx.Bucket(n).Get([]byte(key)) != nil {
+				name = string(n)
+			}
+		}
+		return nil
+	}))
+	return name
+}
+
+func TestS_SystemKeys(t *testing.T) {
+	key := consensus.AppliedPrefix + "testSystem"
+	d := []byte("System")
+	v := consensus.NewVersion(d)
+	require.Nil(t, ts.SetBatch([]string{key, "testSystem"}, [][]byte{d, d}, []*consensus.Version{v, v}))
+	defer func() {
+		_ = ts.Delete(key)
+		_ = ts.Delete("testSystem")
+	}()
+
+	require.Exactly(t, string(systemBucketName), bucketHolding(t, ts, key))
+	require.Exactly(t, string(bucketName), bucketHolding(t, ts, "testSystem"))
+
+	d2, v2, err := ts.Get(key)
+	require.Nil(t, err)
+	require.Exactly(t, d, d2)
+	require.Nil(t, v2.Matches(v))
+
+	catalog, err := ts.List()
+	require.Nil(t, err)
+	require.Contains(t, catalog, key)
+
+	require.Nil(t, ts.Delete(key))
+	require.Empty(t, bucketHolding(t, ts, key))
+}
+
+func TestS_LegacyLayout(t *testing.T) {
+	path, err := ioutil.TempDir("", "pnyxdb_boltdb_")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(path) }()
+	path = filepath.Join(path, "db")
+
+	// Written by a version without version fields
+	d := []byte("Legacy")
+	v := consensus.NewVersion(d)
+	db, err := bolt.Open(path, 0600, nil)
+	require.Nil(t, err)
+	require.Nil(t, db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket(bucketName)
+		if err != nil {
+			return err
+		}
+		if err = b.Put([]byte(consensus.NoncePrefix+"legacy"), append(append([]byte(nil), v.Hash...), d...)); err != nil {
+			return err
+		}
+		return b.Put([]byte("legacy"), append(append([]byte(nil), v.Hash...), d...))
+	}))
+	require.Nil(t, db.Close())
+
+	for i := 0; i < 2; i++ { // upgraded once
+		s, err := New(path)
+		require.Nil(t, err)
+
+		d2, v2, err := s.Get("legacy")
+		require.Nil(t, err)
+		require.Exactly(t, d, d2)
+		require.Nil(t, v2.Matches(v))
+		require.Nil(t, v2.Expiry)
+		require.Nil(t, v2.Provenance)
+
+		d2, v2, err = s.Get(consensus.NoncePrefix + "legacy")
+		require.Nil(t, err)
+		require.Exactly(t, d, d2)
+		require.Nil(t, v2.Matches(v))
+		require.Exactly(t, string(systemBucketName), bucketHolding(t, s.(*store), consensus.NoncePrefix+"legacy"))
+		require.Nil(t, s.Close())
+	}
+
+	// Written by a newer version
+	db, err = bolt.Open(path, 0600, nil)
+	require.Nil(t, err)
+	require.Nil(t, db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucketName).Put(layoutKey, []byte{currentLayout + 1})
+	}))
+	require.Nil(t, db.Close())
+
+	_, err = New(path)
+	require.Equal(t, errLayout, err)
+}
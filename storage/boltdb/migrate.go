@@ -0,0 +1,144 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package boltdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	bolt "github.com/coreos/bbolt"
+	"go.uber.org/zap"
+)
+
+// ErrMigrationRefused is returned by Open when the database must be upgraded
+// to be opened, and migrations are disabled.
+var ErrMigrationRefused = errors.New("database has been written by an older version, and must be upgraded")
+
+// migration upgrades the database from a layout to the next one.
+type migration struct {
+	name string
+	run  func(tx *bolt.Tx) error
+}
+
+// migrations are indexed by the layout they upgrade from, and applied in
+// order. Each of them runs in a transaction of its own, which also records
+// the new layout: an interrupted upgrade resumes after the last migration
+// completed.
+var migrations = [currentLayout]migration{
+	layoutLegacy: {"length-prefixed version fields", func(tx *bolt.Tx) error {
+		return upgradeLegacy(tx.Bucket(bucketName))
+	}},
+	layoutVersionFields: {"system bucket", func(tx *bolt.Tx) error {
+		return separateSystemKeys(tx.Bucket(bucketName), tx.Bucket(systemBucketName))
+	}},
+}
+
+// createBuckets creates the buckets of the current layout.
+func createBuckets(tx *bolt.Tx) error {
+	for _, name := range [][]byte{bucketName, systemBucketName, metaBucketName} {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// layout returns the layout of the database. Databases written before the
+// layout was recorded have the legacy one, unless they are empty.
+func (s *store) layout() (layout byte, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		if meta := tx.Bucket(metaBucketName); meta != nil {
+			if l := meta.Get(layoutKey); len(l) == 1 {
+				layout = l[0]
+				return nil
+			}
+		}
+
+		layout = currentLayout // nothing to upgrade
+		if b := tx.Bucket(bucketName); b != nil {
+			if k, _ := b.Cursor().First(); k != nil {
+				layout = layoutLegacy
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// migrate upgrades the database from layout to the current one.
+func (s *store) migrate(layout byte) error {
+	for ; layout < currentLayout; layout++ {
+		m := migrations[layout]
+		zap.L().Info("Migration",
+			zap.Uint8("from", layout),
+			zap.String("name", m.name),
+		)
+
+		err := s.db.Update(func(tx *bolt.Tx) error {
+			if err := createBuckets(tx); err != nil {
+				return err
+			}
+			if err := m.run(tx); err != nil {
+				return err
+			}
+			return tx.Bucket(metaBucketName).Put(layoutKey, []byte{layout + 1})
+		})
+		if err != nil {
+			return fmt.Errorf("migration %q: %v", m.name, err)
+		}
+	}
+	return nil
+}
+
+// backupPath returns the path of the copy of a database made before
+// upgrading it from layout.
+func backupPath(path string, layout byte) string {
+	return fmt.Sprintf("%s.layout%d.bak", path, layout)
+}
+
+// backup copies the database to backupPath before an upgrade from layout,
+// and records the upgrade in progress in the meta bucket (see upgradeKey).
+// When that upgrade is resumed, the copy made before it started, which holds
+// the original content, is kept; copies left by previous upgrades are not.
+func (s *store) backup(path string, layout byte) error {
+	var from []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if meta := tx.Bucket(metaBucketName); meta != nil {
+			from = append(from, meta.Get(upgradeKey)...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(from) == 1 && from[0] <= layout {
+		if _, err = os.Stat(backupPath(path, from[0])); err == nil {
+			return nil
+		}
+	}
+
+	dst := backupPath(path, layout)
+	zap.L().Info("MigrationBackup", zap.String("path", dst))
+	err = s.db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(dst, 0600)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucketName)
+		if err != nil {
+			return err
+		}
+		return meta.Put(upgradeKey, []byte{layout})
+	})
+}
@@ -0,0 +1,183 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package boltdb
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+// legacyFixture is a database written by a version without version fields,
+// nor layout. It is written again when PNYXDB_UPDATE_FIXTURES is set.
+const legacyFixture = "testdata/legacy.db"
+
+var legacyValues = map[string]string{
+	"a":                         "Legacy",
+	"b":                         "",
+	consensus.NoncePrefix + "n": "nonce",
+}
+
+func writeLegacyFixture(t *testing.T) {
+	_ = os.Remove(legacyFixture)
+	db, err := bolt.Open(legacyFixture, 0600, nil)
+	require.Nil(t, err)
+	require.Nil(t, db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket(bucketName)
+		if err != nil {
+			return err
+		}
+		for k, d := range legacyValues {
+			v := consensus.NewVersion([]byte(d))
+			if err = b.Put([]byte(k), append(append([]byte(nil), v.Hash...), d...)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	require.Nil(t, db.Close())
+}
+
+// copyLegacyFixture returns the path of a copy of the legacy database, and
+// its content.
+func copyLegacyFixture(t *testing.T, dir string) (string, []byte) {
+	if os.Getenv("PNYXDB_UPDATE_FIXTURES") != "" {
+		writeLegacyFixture(t)
+	}
+
+	original, err := ioutil.ReadFile(legacyFixture)
+	require.Nil(t, err)
+	path := filepath.Join(dir, "db")
+	require.Nil(t, ioutil.WriteFile(path, original, 0600))
+	return path, original
+}
+
+func metaValue(t *testing.T, path string, key []byte) []byte {
+	db, err := bolt.Open(path, 0600, nil)
+	require.Nil(t, err)
+	defer func() { _ = db.Close() }()
+
+	var value []byte
+	require.Nil(t, db.View(func(tx *bolt.Tx) error {
+		if meta := tx.Bucket(metaBucketName); meta != nil {
+			value = append(value, meta.Get(key)...)
+		}
+		return nil
+	}))
+	return value
+}
+
+func storedLayout(t *testing.T, path string) byte {
+	layout := metaValue(t, path, layoutKey)
+	require.Len(t, layout, 1)
+	return layout[0]
+}
+
+func TestMigrate_Fixture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pnyxdb_boltdb_")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	path, original := copyLegacyFixture(t, dir)
+
+	_, err = Open(path, Options{NoMigrate: true})
+	require.Exactly(t, ErrMigrationRefused, err)
+	untouched, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	require.Exactly(t, original, untouched, "should not modify a database when migrations are refused")
+
+	s, err := Open(path, Options{})
+	require.Nil(t, err)
+	for k, d := range legacyValues {
+		d2, v2, err := s.Get(k)
+		require.Nil(t, err)
+		require.Exactly(t, d, string(d2))
+		require.Nil(t, v2.Matches(consensus.NewVersion([]byte(d))))
+	}
+	require.Exactly(t, string(systemBucketName), bucketHolding(t, s.(*store), consensus.NoncePrefix+"n"))
+	require.Nil(t, s.Close())
+	require.Equal(t, currentLayout, storedLayout(t, path))
+	require.Empty(t, metaValue(t, path, upgradeKey), "the upgrade should be completed")
+
+	backup, err := ioutil.ReadFile(backupPath(path, layoutLegacy))
+	require.Nil(t, err)
+	require.NotEmpty(t, backup)
+
+	// The backup is a database of the legacy layout
+	s, err = Open(backupPath(path, layoutLegacy), Options{NoMigrate: true})
+	require.Exactly(t, ErrMigrationRefused, err, "should back up the database before upgrading it")
+
+	// Upgraded databases open without migration
+	s, err = Open(path, Options{NoMigrate: true})
+	require.Nil(t, err)
+	require.Nil(t, s.Close())
+}
+
+func TestMigrate_Resume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pnyxdb_boltdb_")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	path, _ := copyLegacyFixture(t, dir)
+
+	// Interrupted after the first migration
+	last := migrations[currentLayout-1]
+	migrations[currentLayout-1].run = func(*bolt.Tx) error { return errors.New("interrupted") }
+	_, err = New(path)
+	migrations[currentLayout-1] = last
+	require.NotNil(t, err)
+	require.Equal(t, currentLayout-1, storedLayout(t, path))
+
+	s, err := New(path)
+	require.Nil(t, err)
+	for k, d := range legacyValues {
+		d2, _, err := s.Get(k)
+		require.Nil(t, err)
+		require.Exactly(t, d, string(d2))
+	}
+	require.Nil(t, s.Close())
+	require.Equal(t, currentLayout, storedLayout(t, path))
+
+	_, err = os.Stat(backupPath(path, currentLayout-1))
+	require.True(t, os.IsNotExist(err), "should keep the backup of the original database only")
+}
+
+func TestMigrate_StaleBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pnyxdb_boltdb_")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	path, _ := copyLegacyFixture(t, dir)
+
+	// A database left at an intermediate layout, next to the backup of a
+	// previous upgrade which has completed
+	last := migrations[currentLayout-1]
+	migrations[currentLayout-1].run = func(*bolt.Tx) error { return errors.New("interrupted") }
+	_, err = New(path)
+	migrations[currentLayout-1] = last
+	require.NotNil(t, err)
+	require.Equal(t, []byte{layoutLegacy}, metaValue(t, path, upgradeKey))
+
+	db, err := bolt.Open(path, 0600, nil)
+	require.Nil(t, err)
+	require.Nil(t, db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucketName).Delete(upgradeKey)
+	}))
+	require.Nil(t, db.Close())
+
+	s, err := New(path)
+	require.Nil(t, err)
+	require.Nil(t, s.Close())
+
+	_, err = os.Stat(backupPath(path, currentLayout-1))
+	require.Nil(t, err, "should back up the database before a new upgrade")
+}
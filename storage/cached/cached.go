@@ -0,0 +1,226 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+// Package cached provides a read-through cache in front of another store.
+package cached
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+// entryOverhead approximates the memory used by an entry, besides its key,
+// value and version.
+const entryOverhead = 128
+
+type entry struct {
+	key     string
+	value   []byte
+	version *consensus.Version
+	size    int
+}
+
+// Store keeps the most recently read values of another store in memory, up
+// to a total size. Reads are served from memory when possible, and writes
+// invalidate the cached values of the keys they modify: the underlying
+// store must only be modified through the Store.
+//
+// The lock of the Store is the lock of the underlying store. The cache uses
+// its own lock, so that it is safe whether the store is locked or not.
+type Store struct {
+	consensus.Store
+
+	maxBytes      int
+	maxEntryBytes int
+
+	mutex      sync.Mutex // protects the fields below
+	entries    map[string]*list.Element
+	lru        *list.List // most recently used first
+	size       int
+	generation uint64 // incremented by every write, see add
+
+	hits, misses                   uint64 // atomic
+	collectedHits, collectedMisses uint64 // atomic, reads reported by CollectMetrics
+}
+
+// New returns a cache of at most maxBytes in front of s. Values larger than
+// a sixteenth of the cache are never cached, so that a single read cannot
+// evict every other value.
+func New(s consensus.Store, maxBytes int) *Store {
+	return &Store{
+		Store:         s,
+		maxBytes:      maxBytes,
+		maxEntryBytes: maxBytes / 16,
+		entries:       make(map[string]*list.Element),
+		lru:           list.New(),
+	}
+}
+
+// Get returns the value and the version stored for the specified key, from
+// memory if possible. The returned value can be modified by the caller.
+func (s *Store) Get(key string) ([]byte, *consensus.Version, error) {
+	s.mutex.Lock()
+	if elem, ok := s.entries[key]; ok {
+		s.lru.MoveToFront(elem)
+		e := elem.Value.(*entry)
+		value, version := copyValue(e.value, e.version)
+		s.mutex.Unlock()
+
+		atomic.AddUint64(&s.hits, 1)
+		return value, version, nil
+	}
+	generation := s.generation
+	s.mutex.Unlock()
+
+	atomic.AddUint64(&s.misses, 1)
+	value, version, err := s.Store.Get(key)
+	if err == nil {
+		s.add(key, value, version, generation)
+	}
+	return value, version, err
+}
+
+// add caches a value read from the underlying store, unless a write has
+// happened since the read started, in which case the value may be outdated.
+func (s *Store) add(key string, value []byte, version *consensus.Version, generation uint64) {
+	size := entryOverhead + len(key) + len(value)
+	if version != nil {
+		size += len(version.Hash)
+	}
+	if size > s.maxEntryBytes {
+		return
+	}
+
+	value, version = copyValue(value, version)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.generation != generation {
+		return
+	}
+	if _, ok := s.entries[key]; ok {
+		return // added by a concurrent read
+	}
+
+	s.entries[key] = s.lru.PushFront(&entry{
+		key:     key,
+		value:   value,
+		version: version,
+		size:    size,
+	})
+	s.size += size
+
+	for s.size > s.maxBytes {
+		s.removeUnsafe(s.lru.Back())
+	}
+}
+
+// unsafe
+func (s *Store) removeUnsafe(elem *list.Element) {
+	e := s.lru.Remove(elem).(*entry)
+	delete(s.entries, e.key)
+	s.size -= e.size
+}
+
+// Set sets the value and the version of a key, and invalidates its cached
+// value.
+func (s *Store) Set(key string, value []byte, version *consensus.Version) error {
+	return s.SetBatch([]string{key}, [][]byte{value}, []*consensus.Version{version})
+}
+
+// SetBatch executes the given "Set" operations atomically, and invalidates
+// the cached values of their keys. They are invalidated even if the batch
+// fails, since its outcome is unknown.
+func (s *Store) SetBatch(keys []string, values [][]byte, versions []*consensus.Version) error {
+	err := s.Store.SetBatch(keys, values, versions)
+	s.invalidate(keys)
+	return err
+}
+
+// UpdateBatch executes the given "Set" operations and removes the deleted
+// keys, atomically if the underlying store is a consensus.BatchDeleter, and
+// invalidates the cached values of every key, as SetBatch does.
+func (s *Store) UpdateBatch(keys []string, values [][]byte, versions []*consensus.Version, deleted []string) error {
+	err := consensus.UpdateBatch(s.Store, keys, values, versions, deleted)
+	s.invalidate(keys)
+	s.invalidate(deleted)
+	return err
+}
+
+// Delete removes a key, and invalidates its cached value.
+func (s *Store) Delete(key string) error {
+	err := s.Store.Delete(key)
+	s.invalidate([]string{key})
+	return err
+}
+
+// invalidate removes the cached values of some keys, and discards the values
+// being read concurrently.
+func (s *Store) invalidate(keys []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.generation++
+	for _, key := range keys {
+		if elem, ok := s.entries[key]; ok {
+			s.removeUnsafe(elem)
+		}
+	}
+}
+
+// Stats returns the number of reads served from memory (hits), and of reads
+// forwarded to the underlying store (misses).
+// This function is thread-safe.
+func (s *Store) Stats() (hits, misses uint64) {
+	return atomic.LoadUint64(&s.hits), atomic.LoadUint64(&s.misses)
+}
+
+// CollectMetrics reports the hits and misses since the previous call and the
+// size of the cache, along with the measures of the underlying store if it
+// reports them, see consensus.MetricsCollector.
+// This function is thread-safe.
+func (s *Store) CollectMetrics(m consensus.Metrics) {
+	hits, misses := s.Stats()
+	// Concurrent calls may swap the totals out of order: the negative
+	// difference is ignored rather than wrapped around
+	m.Count(consensus.MetricStoreCacheHits, float64(hits)-float64(atomic.SwapUint64(&s.collectedHits, hits)))
+	m.Count(consensus.MetricStoreCacheMisses, float64(misses)-float64(atomic.SwapUint64(&s.collectedMisses, misses)))
+
+	entries, bytes := s.Len()
+	m.Set(consensus.MetricStoreCacheEntries, float64(entries))
+	m.Set(consensus.MetricStoreCacheBytes, float64(bytes))
+
+	if c, ok := s.Store.(consensus.MetricsCollector); ok {
+		c.CollectMetrics(m)
+	}
+}
+
+// Len returns the number of cached values, and their approximate size.
+// This function is thread-safe.
+func (s *Store) Len() (entries, bytes int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.entries), s.size
+}
+
+func copyValue(value []byte, version *consensus.Version) ([]byte, *consensus.Version) {
+	if value != nil {
+		value = append([]byte(nil), value...)
+	}
+	if version != nil {
+		version = &consensus.Version{
+			Hash:       append([]byte(nil), version.Hash...),
+			Expiry:     version.Expiry, // never modified in place
+			Provenance: version.Provenance,
+		}
+	}
+	return value, version
+}
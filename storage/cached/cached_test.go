@@ -0,0 +1,240 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package cached
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/storage/boltdb"
+)
+
+func newTestStore(t testing.TB) (consensus.Store, func()) {
+	path, err := ioutil.TempDir("", "pnyxdb_cached_")
+	require.Nil(t, err)
+
+	s, err := boltdb.New(filepath.Join(path, "db"))
+	require.Nil(t, err)
+
+	return s, func() {
+		_ = s.Close()
+		_ = os.RemoveAll(path)
+	}
+}
+
+func TestStore_Get(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+	c := New(s, 1<<20)
+
+	_, _, err := c.Get("missing")
+	require.NotNil(t, err)
+	require.Nil(t, s.Set("missing", []byte("now here"), consensus.NewVersion([]byte("now here"))))
+	value, _, err := c.Get("missing")
+	require.Nil(t, err, "errors must not be cached")
+	require.Equal(t, []byte("now here"), value)
+
+	v := consensus.NewVersion([]byte("a"))
+	require.Nil(t, c.Set("a", []byte("a"), v))
+
+	for i := 0; i < 3; i++ {
+		value, version, err := c.Get("a")
+		require.Nil(t, err)
+		require.Equal(t, []byte("a"), value)
+		require.Nil(t, version.Matches(v))
+
+		// the caller is allowed to modify the returned value
+		value[0] = 'x'
+		version.Hash[0]++
+	}
+
+	hits, misses := c.Stats()
+	require.Equal(t, uint64(2), hits)
+	require.Equal(t, uint64(3), misses)
+
+	// Reads are reported once through the metrics of the engine
+	m := counters{}
+	eng := consensus.NewEngine(c, nil, nil, nil, 1)
+	eng.Metrics = m
+	eng.CollectMetrics()
+	require.Equal(t, 2.0, m[consensus.MetricStoreCacheHits])
+	require.Equal(t, 3.0, m[consensus.MetricStoreCacheMisses])
+	_, size := c.Len()
+	require.Equal(t, 2.0, m[consensus.MetricStoreCacheEntries])
+	require.Equal(t, float64(size), m[consensus.MetricStoreCacheBytes])
+
+	_, _, _ = c.Get("a")
+	eng.CollectMetrics()
+	eng.CollectMetrics()
+	require.Equal(t, 3.0, m[consensus.MetricStoreCacheHits])
+	require.Equal(t, 3.0, m[consensus.MetricStoreCacheMisses])
+}
+
+// counters records the counters and gauges reported by a store.
+type counters map[string]float64
+
+func (c counters) Count(name string, delta float64)   { c[name] += delta }
+func (c counters) Observe(name string, value float64) {}
+func (c counters) Set(name string, value float64)     { c[name] = value }
+
+func TestStore_Invalidation(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+	c := New(s, 1<<20)
+
+	require.Nil(t, c.SetBatch(
+		[]string{"a", "b", "c"},
+		[][]byte{[]byte("a1"), []byte("b1"), []byte("c1")},
+		[]*consensus.Version{consensus.NewVersion([]byte("a1")), consensus.NewVersion([]byte("b1")), consensus.NewVersion([]byte("c1"))},
+	))
+	for _, key := range []string{"a", "b", "c"} {
+		_, _, err := c.Get(key)
+		require.Nil(t, err)
+	}
+	entries, _ := c.Len()
+	require.Equal(t, 3, entries)
+
+	v := consensus.NewVersion([]byte("a2"))
+	require.Nil(t, c.Set("a", []byte("a2"), v))
+	value, version, err := c.Get("a")
+	require.Nil(t, err)
+	require.Equal(t, []byte("a2"), value)
+	require.Nil(t, version.Matches(v))
+
+	require.Nil(t, c.SetBatch(
+		[]string{"b", "c"},
+		[][]byte{[]byte("b2"), []byte("c2")},
+		[]*consensus.Version{consensus.NewVersion([]byte("b2")), consensus.NewVersion([]byte("c2"))},
+	))
+	entries, _ = c.Len()
+	require.Equal(t, 1, entries)
+	for _, key := range []string{"b", "c"} {
+		value, _, err := c.Get(key)
+		require.Nil(t, err)
+		require.Equal(t, []byte(key+"2"), value)
+	}
+
+	require.Nil(t, c.Delete("a"))
+	_, version, err = c.Get("a")
+	require.NotNil(t, err)
+	require.Equal(t, consensus.NoVersion, version)
+
+	// Keys removed by a batch are invalidated too
+	require.Nil(t, c.UpdateBatch(
+		[]string{"c"},
+		[][]byte{[]byte("c3")},
+		[]*consensus.Version{consensus.NewVersion([]byte("c3"))},
+		[]string{"b"},
+	))
+	_, _, err = c.Get("b")
+	require.NotNil(t, err)
+	value, _, err = c.Get("c")
+	require.Nil(t, err)
+	require.Equal(t, []byte("c3"), value)
+}
+
+func TestStore_Generation(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+	c := New(s, 1<<20)
+
+	require.Nil(t, c.Set("a", []byte("a1"), consensus.NewVersion([]byte("a1"))))
+
+	// simulate a read that started before a concurrent write
+	generation := c.generation
+	value, version, err := s.Get("a")
+	require.Nil(t, err)
+	require.Nil(t, c.Set("a", []byte("a2"), consensus.NewVersion([]byte("a2"))))
+	c.add("a", value, version, generation)
+
+	entries, _ := c.Len()
+	require.Equal(t, 0, entries)
+	value, _, err = c.Get("a")
+	require.Nil(t, err)
+	require.Equal(t, []byte("a2"), value)
+}
+
+func TestStore_Eviction(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	entrySize := entryOverhead + 1 + 64 + len(consensus.NewVersion(nil).Hash)
+	c := New(s, 3*entrySize)
+	c.maxEntryBytes = entrySize
+
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		d := make([]byte, 64)
+		require.Nil(t, c.Set(key, d, consensus.NewVersion(d)))
+	}
+	require.Nil(t, c.Set("big", make([]byte, 65), consensus.NewVersion(nil)))
+
+	for _, key := range []string{"a", "b", "c", "a", "d", "big"} {
+		_, _, err := c.Get(key)
+		require.Nil(t, err)
+	}
+
+	entries, size := c.Len()
+	require.Equal(t, 3, entries)
+	require.Equal(t, 3*entrySize, size)
+
+	// "b" is the least recently used one
+	require.NotContains(t, c.entries, "b")
+	require.NotContains(t, c.entries, "big")
+	for _, key := range []string{"a", "c", "d"} {
+		require.Contains(t, c.entries, key)
+	}
+}
+
+// BenchmarkRequirements simulates the requirement checks of queries, where
+// 90% of the reads concern 10% of the keys.
+func BenchmarkRequirements(b *testing.B) {
+	const keys = 10000
+	const hot = keys / 10
+
+	s, cleanup := newTestStore(b)
+	defer cleanup()
+
+	names := make([]string, keys)
+	values := make([][]byte, keys)
+	versions := make([]*consensus.Version, keys)
+	for i := range names {
+		names[i] = fmt.Sprintf("key%d", i)
+		values[i] = make([]byte, 256)
+		rand.Read(values[i])
+		versions[i] = consensus.NewVersion(values[i])
+	}
+	require.Nil(b, s.SetBatch(names, values, versions))
+
+	run := func(b *testing.B, s consensus.Store) {
+		r := rand.New(rand.NewSource(1))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			k := r.Intn(keys)
+			if r.Intn(10) > 0 {
+				k = r.Intn(hot)
+			}
+			_, version, err := s.Get(names[k])
+			if err != nil || version.Matches(versions[k]) != nil {
+				b.Fatal("unexpected version", err)
+			}
+		}
+	}
+
+	b.Run("direct", func(b *testing.B) {
+		run(b, s)
+	})
+	b.Run("cached", func(b *testing.B) {
+		run(b, New(s, 4<<20))
+	})
+}
@@ -0,0 +1,82 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+// Package verified checks the values read from another store against their
+// version, to detect silent corruption of the database.
+package verified
+
+import (
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+// Store verifies a sample of the values read from another store: values
+// which do not match the hash of their version are reported with
+// consensus.ErrCorruptValue, so that the engine recovers them from its peers.
+//
+// The lock of the Store is the lock of the underlying store.
+type Store struct {
+	consensus.Store
+
+	rate        float64
+	corruptions uint64 // atomic
+	collected   uint64 // atomic, corruptions reported by CollectMetrics
+}
+
+// New returns a store verifying a proportion rate of the reads from s,
+// between 0 (none) and 1 (every read). Sampling bounds the overhead of
+// hashing large values.
+func New(s consensus.Store, rate float64) *Store {
+	return &Store{
+		Store: s,
+		rate:  rate,
+	}
+}
+
+// Get returns the value and the version stored for the specified key, or
+// consensus.ErrCorruptValue if the read is verified and they do not match.
+func (s *Store) Get(key string) ([]byte, *consensus.Version, error) {
+	value, version, err := s.Store.Get(key)
+	if err != nil || !s.sample() {
+		return value, version, err
+	}
+
+	err = consensus.VerifyValue(key, value, version)
+	if err != nil {
+		atomic.AddUint64(&s.corruptions, 1)
+	}
+	return value, version, err
+}
+
+// UpdateBatch executes the given "Set" operations and removes the deleted
+// keys, atomically if the underlying store supports it, see
+// consensus.UpdateBatch.
+func (s *Store) UpdateBatch(keys []string, values [][]byte, versions []*consensus.Version, deleted []string) error {
+	return consensus.UpdateBatch(s.Store, keys, values, versions, deleted)
+}
+
+func (s *Store) sample() bool {
+	return s.rate >= 1 || (s.rate > 0 && rand.Float64() < s.rate)
+}
+
+// Corruptions returns the number of corrupt values detected.
+// This function is thread-safe.
+func (s *Store) Corruptions() uint64 {
+	return atomic.LoadUint64(&s.corruptions)
+}
+
+// CollectMetrics reports the corrupt values detected since the previous call,
+// as consensus.MetricStoreCorruptions.
+// This function is thread-safe.
+func (s *Store) CollectMetrics(m consensus.Metrics) {
+	n := atomic.LoadUint64(&s.corruptions)
+	// Concurrent calls may swap the totals out of order: the negative
+	// difference is ignored rather than wrapped around
+	m.Count(consensus.MetricStoreCorruptions, float64(n)-float64(atomic.SwapUint64(&s.collected, n)))
+}
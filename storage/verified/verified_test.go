@@ -0,0 +1,109 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package verified
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/stretchr/testify/require"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/storage/boltdb"
+	"github.com/technicolor-research/pnyxdb/storage/cached"
+)
+
+// newCorruptStore returns a database where the last byte of the value of
+// "corrupt" has been flipped behind the back of the driver.
+func newCorruptStore(t *testing.T) (consensus.Store, func()) {
+	dir, err := ioutil.TempDir("", "pnyxdb_verified_")
+	require.Nil(t, err)
+	path := filepath.Join(dir, "db")
+
+	s, err := boltdb.New(path)
+	require.Nil(t, err)
+	for _, key := range []string{"corrupt", "sound"} {
+		require.Nil(t, s.Set(key, []byte("value"), consensus.NewVersion([]byte("value"))))
+	}
+	require.Nil(t, s.Close())
+
+	db, err := bolt.Open(path, 0600, nil)
+	require.Nil(t, err)
+	require.Nil(t, db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("pnyxdb"))
+		record := append([]byte(nil), b.Get([]byte("corrupt"))...)
+		record[len(record)-1] ^= 1
+		return b.Put([]byte("corrupt"), record)
+	}))
+	require.Nil(t, db.Close())
+
+	s, err = boltdb.New(path)
+	require.Nil(t, err)
+	return s, func() {
+		_ = s.Close()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+// counters records the counters reported by a store.
+type counters map[string]float64
+
+func (c counters) Count(name string, delta float64)   { c[name] += delta }
+func (c counters) Observe(name string, value float64) {}
+func (c counters) Set(name string, value float64)     { c[name] = value }
+
+func TestStore_Get(t *testing.T) {
+	s, cleanup := newCorruptStore(t)
+	defer cleanup()
+	v := New(s, 1)
+
+	value, _, err := v.Get("sound")
+	require.Nil(t, err)
+	require.Equal(t, []byte("value"), value)
+
+	_, _, err = v.Get("corrupt")
+	require.Exactly(t, consensus.ErrCorruptValue{Key: "corrupt"}, err)
+	require.Equal(t, uint64(1), v.Corruptions())
+
+	_, version, err := v.Get("missing")
+	require.NotNil(t, err)
+	require.Equal(t, consensus.NoVersion, version)
+	require.Equal(t, uint64(1), v.Corruptions(), "missing keys are not corrupt")
+
+	// Corruptions are reported once through the metrics of the engine,
+	// including when the store is wrapped by a cache
+	m := counters{}
+	eng := consensus.NewEngine(cached.New(v, 1<<20), nil, nil, nil, 1)
+	eng.Metrics = m
+	eng.CollectMetrics()
+	require.Equal(t, 1.0, m[consensus.MetricStoreCorruptions])
+	_, _, _ = v.Get("corrupt")
+	eng.CollectMetrics()
+	eng.CollectMetrics()
+	require.Equal(t, 2.0, m[consensus.MetricStoreCorruptions])
+
+	unverified := New(s, 0)
+	_, _, err = unverified.Get("corrupt")
+	require.Nil(t, err)
+	require.Equal(t, uint64(0), unverified.Corruptions())
+}
+
+func TestStore_Sampling(t *testing.T) {
+	s, cleanup := newCorruptStore(t)
+	defer cleanup()
+	v := New(s, 0.5)
+
+	const reads = 1000
+	for i := 0; i < reads; i++ {
+		_, _, _ = v.Get("corrupt")
+	}
+	n := v.Corruptions()
+	require.True(t, n > reads/4 && n < 3*reads/4, "%d reads out of %d verified", n, reads)
+}
@@ -0,0 +1,115 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package tests
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ManualClock is a clock whose time only changes when it is advanced, so that
+// tests depending on deadlines and timers neither sleep nor depend on the
+// load of the machine. It implements consensus.Clock.
+type ManualClock struct {
+	mutex   sync.Mutex
+	changed *sync.Cond // broadcast when timers are added or fired
+	now     time.Time
+	timers  []*manualTimer
+}
+
+type manualTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewManualClock returns a clock set to t.
+func NewManualClock(t time.Time) *ManualClock {
+	c := &ManualClock{now: t}
+	c.changed = sync.NewCond(&c.mutex)
+	return c
+}
+
+// Now returns the current time of the clock.
+func (c *ManualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the time once the clock has been
+// advanced by d.
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	ch, _ := c.NewTimer(d)
+	return ch
+}
+
+// NewTimer returns a channel receiving the time once the clock has been
+// advanced by d, and a function stopping the timer.
+func (c *ManualClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	t := &manualTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t.c, func() bool { return false }
+	}
+
+	c.timers = append(c.timers, t)
+	c.changed.Broadcast()
+	return t.c, func() bool { return c.stop(t) }
+}
+
+func (c *ManualClock) stop(t *manualTimer) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i, t2 := range c.timers {
+		if t2 == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Advance moves the clock forward by d, firing the timers which are due, the
+// earliest first.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].at.Before(c.timers[j].at)
+	})
+
+	var fired int
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			break
+		}
+		t.c <- c.now
+		fired++
+	}
+	c.timers = c.timers[fired:]
+	c.changed.Broadcast()
+}
+
+// WaitTimers blocks until at least n timers are pending, that is until the
+// goroutines expected to wait on the clock do so.
+func (c *ManualClock) WaitTimers(n int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for len(c.timers) < n {
+		c.changed.Wait()
+	}
+}
@@ -102,3 +102,88 @@ func TestEngine(t *testing.T) {
 	fmt.Println(ref)
 	wg.Wait()
 }
+
+// TestEngine_SilentNode checks that the nodes agree when the binary consensus
+// instances of their checkpoints time out, since one of the nodes never runs.
+func TestEngine_SilentNode(t *testing.T) {
+	n := 20
+	w := 19
+	silent := n - 1 // never runs an engine, so binary consensus instances time out
+	s := strconv.Itoa(int(time.Now().UnixNano()))
+
+	p := unreliable.Parameters{
+		MinLatency:    1 * time.Millisecond,
+		MedianLatency: 30 * time.Millisecond,
+		MaxLatency:    200 * time.Millisecond,
+	}
+
+	keyrings := GetTestKeyRings(t, n)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	out := make(chan []byte, n)
+	var wg sync.WaitGroup
+	wg.Add(n - 1)
+
+	for i := 0; i < n; i++ {
+		if i == silent {
+			continue
+		}
+		go func(i int) {
+			defer wg.Done()
+			testdir, err := ioutil.TempDir("", "consensus_engine_")
+			require.Nil(t, err)
+			defer func() { _ = os.RemoveAll(testdir) }()
+
+			store, err := boltdb.New(filepath.Join(testdir, "db"))
+			require.Nil(t, err)
+			defer store.Close()
+
+			network, err := redis.New(":6379", "stream_"+s, 0)
+			require.Nil(t, err)
+			defer network.Close()
+
+			unreliableNetwork := unreliable.New(network, p)
+
+			ve, err := bbc.NewVetoEngineWithTimeouts(unreliableNetwork, keyrings[i], n, bbc.Timeouts{
+				Round:    100 * time.Millisecond,
+				Deadline: time.Second,
+			})
+			require.Nil(t, err)
+
+			engine := consensus.NewEngine(store, unreliableNetwork, ve, keyrings[i], w)
+			err = engine.Run(ctx)
+			require.Nil(t, err, "should run without error")
+
+			if i < 3 {
+				q := consensus.NewQuery()
+				q.SetTimeout(time.Duration(i) * time.Second)
+				fmt.Println("Query", i, "is", q.Uuid)
+				q.Operations = []*consensus.Operation{
+					{Key: "a", Op: consensus.Operation_CONCAT, Data: []byte{byte(i)}},
+				}
+				err = engine.Submit(q)
+				require.Nil(t, err, "should submit new query without error")
+			}
+
+			<-ctx.Done()
+
+			value, _, _ := store.Get("a")
+			out <- value
+		}(i)
+	}
+
+	var ref []byte
+	for i := 0; i < n-1; i++ {
+		state := <-out
+		if i == 0 {
+			ref = state
+		}
+
+		require.Equal(t, ref, state, "states must be consistent")
+	}
+
+	fmt.Println(ref)
+	wg.Wait()
+}
@@ -0,0 +1,142 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/server"
+)
+
+func TestHTTPDebug(t *testing.T) {
+	var srv *server.Server
+	node := startConfiguredTestNode(t, func(s *server.Server) {
+		s.Version = "test"
+		srv = s
+	})
+	defer node.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	set := func(key, value string) *consensus.Operation {
+		return &consensus.Operation{Key: key, Op: consensus.Operation_SET, Data: []byte(value)}
+	}
+	node.commit(t, ctx, transaction(t, set("hello", "world"), set("dir/file", "\x00\xff"), set("deleted", "value")))
+	node.commit(t, ctx, transaction(t, &consensus.Operation{Key: "deleted", Op: consensus.Operation_SOFTDELETE, Data: []byte("1h")}))
+	require.Nil(t, node.engine.Store.Set(consensus.KeyRingPrefix+"secret", []byte("local"), consensus.NewVersion([]byte("local"))))
+
+	h := httptest.NewServer(srv.DebugHandler())
+	defer h.Close()
+
+	get := func(path, accept string) (int, string, string) {
+		req, err := http.NewRequest(http.MethodGet, h.URL+path, nil)
+		require.Nil(t, err)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.Nil(t, err)
+		return res.StatusCode, res.Header.Get("Content-Type"), string(body)
+	}
+
+	t.Run("value", func(t *testing.T) {
+		code, ct, body := get("/kv/hello", "")
+		require.Exactly(t, http.StatusOK, code)
+		require.Exactly(t, "text/plain; charset=utf-8", ct)
+		require.Exactly(t, base64.StdEncoding.EncodeToString([]byte("world")), body)
+
+		code, ct, body = get("/kv/dir%2Ffile", "application/octet-stream")
+		require.Exactly(t, http.StatusOK, code)
+		require.Exactly(t, "application/octet-stream", ct)
+		require.Exactly(t, "\x00\xff", body)
+
+		code, ct, _ = get("/kv/hello", "application/json, text/*;q=0.5")
+		require.Exactly(t, http.StatusOK, code)
+		require.Exactly(t, "text/plain; charset=utf-8", ct)
+
+		code, _, _ = get("/kv/hello", "application/json")
+		require.Exactly(t, http.StatusNotAcceptable, code)
+	})
+
+	t.Run("version", func(t *testing.T) {
+		code, ct, body := get("/kv/hello/version", "")
+		require.Exactly(t, http.StatusOK, code)
+		require.Exactly(t, "application/json", ct)
+
+		_, expected, err := node.engine.Store.Get("hello")
+		require.Nil(t, err)
+		version := &consensus.Version{}
+		require.Nil(t, jsonpb.UnmarshalString(body, version))
+		require.Nil(t, expected.Matches(version))
+
+		code, _, _ = get("/kv/hello/other", "")
+		require.Exactly(t, http.StatusNotFound, code)
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		code, ct, body := get("/keys", "")
+		require.Exactly(t, http.StatusOK, code)
+		require.Exactly(t, "application/json", ct)
+
+		keys := &api.KeyInfos{}
+		require.Nil(t, jsonpb.UnmarshalString(body, keys))
+		var names []string
+		for _, k := range keys.Keys {
+			names = append(names, k.Key)
+		}
+		require.Equal(t, []string{"dir/file", "hello"}, names)
+
+		_, _, body = get("/keys?prefix=he", "")
+		require.Nil(t, jsonpb.UnmarshalString(body, keys))
+		require.Len(t, keys.Keys, 1)
+		require.Exactly(t, "hello", keys.Keys[0].Key)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		code, ct, body := get("/status", "")
+		require.Exactly(t, http.StatusOK, code)
+		require.Exactly(t, "application/json", ct)
+
+		info := &api.NodeInfo{}
+		require.Nil(t, jsonpb.UnmarshalString(body, info))
+		require.Exactly(t, node.engine.Identity(), info.Identity)
+		require.Exactly(t, "test", info.Version)
+	})
+
+	t.Run("authorization", func(t *testing.T) {
+		for _, key := range []string{"missing", "deleted", consensus.KeyRingPrefix + "secret"} {
+			code, _, _ := get("/kv/"+url.PathEscape(key), "")
+			require.Exactly(t, http.StatusNotFound, code, key)
+			code, _, _ = get("/kv/"+url.PathEscape(key)+"/version", "")
+			require.Exactly(t, http.StatusNotFound, code, key)
+		}
+
+		res, err := http.Post(h.URL+"/kv/hello", "application/octet-stream", strings.NewReader("overwritten"))
+		require.Nil(t, err)
+		_ = res.Body.Close()
+		require.Exactly(t, http.StatusMethodNotAllowed, res.StatusCode)
+	})
+}
@@ -0,0 +1,89 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/server"
+)
+
+func TestInfo(t *testing.T) {
+	node := startConfiguredTestNode(t, func(s *server.Server) {
+		s.Version = "test"
+		s.MaxTimeout = 2 * time.Second
+		s.MaxOperations = 2
+		s.MaxValueSize = 4
+		s.AddWriteRule(consensus.WriteRule{Policy: "none", Identity: s.Identity(), Prefixes: []string{"a", "b"}})
+		s.AddWriteRule(consensus.WriteRule{Policy: "none", Identity: "other", Prefixes: []string{"c"}})
+	})
+	defer node.close()
+	engine, c := node.engine, node.client
+
+	// The client adapts its defaults at Connect
+	require.Exactly(t, 2*time.Second, c.TxTimeout(), "default timeout should be clamped")
+	require.Nil(t, c.SetTxTimeout("1s"))
+	require.Exactly(t, time.Second, c.TxTimeout())
+	require.Nil(t, c.SetTxTimeout("1m"))
+	require.Exactly(t, 2*time.Second, c.TxTimeout())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	info, err := c.Info(ctx)
+	require.Nil(t, err)
+	require.Exactly(t, engine.Identity(), info.Identity)
+	require.Exactly(t, "test", info.Version)
+	require.Exactly(t, uint32(1), info.Threshold)
+	require.Exactly(t, int64(2000), info.MaxTimeout)
+	require.Exactly(t, uint32(2), info.MaxOperations)
+	require.Exactly(t, uint64(4), info.MaxValueSize)
+	require.Equal(t, []*api.PolicyInfo{{Name: "none", Prefixes: []string{"a", "b"}}}, info.Policies)
+	require.Exactly(t, "SET", info.Operations[0])
+	require.Contains(t, info.Operations, "SADD")
+	require.Equal(t, []string{"events", "writerules"}, info.Features)
+
+	// Transactions exceeding the limits are rejected, later deadlines are clamped
+	deadline, _ := ptypes.TimestampProto(time.Now().Add(time.Hour))
+	set := func(key, value string) *consensus.Operation {
+		return &consensus.Operation{Key: key, Op: consensus.Operation_SET, Data: []byte(value)}
+	}
+
+	_, err = c.Submit(ctx, &api.Transaction{Policy: "none", Deadline: deadline, Operations: []*consensus.Operation{set("a", "v"), set("b", "v"), set("a", "w")}})
+	require.Exactly(t, codes.InvalidArgument, status.Code(err), "too many operations")
+	_, err = c.Submit(ctx, &api.Transaction{Policy: "none", Deadline: deadline, Operations: []*consensus.Operation{set("a", "value")}})
+	require.Exactly(t, codes.InvalidArgument, status.Code(err), "value too large")
+
+	uuid, err := c.Submit(ctx, &api.Transaction{Policy: "none", Deadline: deadline, Operations: []*consensus.Operation{set("a", "v")}})
+	require.Nil(t, err)
+
+	var since uint64
+	for {
+		events, err := engine.Journal.Replay(since)
+		require.Nil(t, err)
+		for _, e := range events {
+			if e.Uuid == uuid {
+				clamped, err := ptypes.Timestamp(e.Certificate.Query.Deadline)
+				require.Nil(t, err)
+				require.True(t, clamped.Before(time.Now().Add(2*time.Second)), "deadline should be clamped")
+				return
+			}
+			since = e.Sequence
+		}
+		require.Nil(t, engine.Journal.Wait(ctx, since), "query should be committed")
+	}
+}
@@ -0,0 +1,136 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/client"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/consensus/encoding"
+)
+
+// commit submits a transaction made of the given operations, and waits for
+// its commit event.
+func (n *testNode) commit(t *testing.T, ctx context.Context, tx *api.Transaction) *consensus.CommitEvent {
+	since := n.engine.Journal.Sequence()
+	uuid, err := n.client.Submit(ctx, tx)
+	require.Nil(t, err)
+
+	for {
+		events, err := n.engine.Journal.Replay(since)
+		require.Nil(t, err)
+		for _, e := range events {
+			if e.Uuid == uuid {
+				return e
+			}
+			since = e.Sequence
+		}
+		require.Nil(t, n.engine.Journal.Wait(ctx, since), "query %s is not committed", uuid)
+	}
+}
+
+func transaction(t *testing.T, operations ...*consensus.Operation) *api.Transaction {
+	deadline, err := ptypes.TimestampProto(time.Now().Add(time.Minute))
+	require.Nil(t, err)
+	return &api.Transaction{
+		Policy:     "none",
+		Deadline:   deadline,
+		Operations: operations,
+	}
+}
+
+func TestPreview(t *testing.T) {
+	node := startTestNode(t)
+	defer node.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cases := map[consensus.Operation_Op]struct {
+		setup []*consensus.Operation
+		data  []byte
+	}{
+		consensus.Operation_SET:      {nil, []byte("value")},
+		consensus.Operation_CONCAT:   {[]*consensus.Operation{{Op: consensus.Operation_SET, Data: []byte("foo")}}, []byte("bar")},
+		consensus.Operation_REPLACE:  {[]*consensus.Operation{{Op: consensus.Operation_SET, Data: []byte("a-b-c")}}, encoding.EncodeArgs([]byte("-"), []byte("+"), []byte("-1"))},
+		consensus.Operation_TRUNCATE: {[]*consensus.Operation{{Op: consensus.Operation_SET, Data: []byte("abcdef")}}, []byte("3")},
+		consensus.Operation_SETRANGE: {[]*consensus.Operation{{Op: consensus.Operation_SET, Data: []byte("abc")}}, encoding.EncodeArgs([]byte("5"), []byte("xy"))},
+		consensus.Operation_ADD:      {[]*consensus.Operation{{Op: consensus.Operation_SET, Data: []byte("1.5")}}, []byte("2")},
+		consensus.Operation_MUL:      {[]*consensus.Operation{{Op: consensus.Operation_ADD, Data: []byte("3")}}, []byte("-2")},
+		consensus.Operation_SADD:     {[]*consensus.Operation{{Op: consensus.Operation_SADD, Data: []byte("a")}}, []byte("b")},
+		consensus.Operation_SREM:     {[]*consensus.Operation{{Op: consensus.Operation_SADD, Data: []byte("a")}, {Op: consensus.Operation_SADD, Data: []byte("b")}}, []byte("a")},
+		consensus.Operation_SOFTDELETE: {
+			[]*consensus.Operation{{Op: consensus.Operation_SET, Data: []byte("deleted")}},
+			[]byte("1h"),
+		},
+		consensus.Operation_RESTORE: {
+			[]*consensus.Operation{{Op: consensus.Operation_SET, Data: []byte("restored")}, {Op: consensus.Operation_SOFTDELETE, Data: []byte("1h")}},
+			nil,
+		},
+	}
+	// PRUNE is only endorsed for keys covered by retention policies
+	require.Len(t, cases, len(consensus.Operation_Op_name)-1, "every operation should be tested")
+
+	for op, c := range cases {
+		key := "preview_" + op.String()
+		for _, setup := range c.setup {
+			setup.Key = key
+			node.commit(t, ctx, transaction(t, setup))
+		}
+
+		// Deleted values are hidden by the API, use the store directly
+		current, _, err := node.engine.Store.Get(key)
+		if err != nil {
+			current = nil
+		}
+
+		tx := transaction(t, &consensus.Operation{Key: key, Op: op, Data: c.data})
+		preview, err := client.PreviewTransaction(tx, map[string][]byte{key: current})
+		require.Nil(t, err, op.String())
+
+		event := node.commit(t, ctx, tx)
+		value, _, err := node.engine.Store.Get(key)
+		require.Nil(t, err, op.String())
+		require.Equal(t, value, preview.Values[key], op.String())
+		require.Empty(t, preview.Diverging(event), op.String())
+	}
+}
+
+func TestPreview_Diverging(t *testing.T) {
+	node := startTestNode(t)
+	defer node.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx := transaction(t,
+		&consensus.Operation{Key: "x", Op: consensus.Operation_CONCAT, Data: []byte("bar")},
+		&consensus.Operation{Key: "y", Op: consensus.Operation_SET, Data: []byte("baz")},
+	)
+	preview, err := node.client.Preview(ctx, tx)
+	require.Nil(t, err)
+	require.Equal(t, []byte("bar"), preview.Values["x"])
+
+	// A concurrent transaction is committed before ours
+	node.commit(t, ctx, transaction(t, &consensus.Operation{Key: "x", Op: consensus.Operation_SET, Data: []byte("foo")}))
+
+	event := node.commit(t, ctx, tx)
+	require.Equal(t, []string{"x"}, preview.Diverging(event))
+
+	value, _, err := node.engine.Store.Get("x")
+	require.Nil(t, err)
+	require.Equal(t, []byte("foobar"), value)
+}
@@ -0,0 +1,100 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/metrics"
+	"github.com/technicolor-research/pnyxdb/server"
+)
+
+func TestRequestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	defer zap.ReplaceGlobals(zap.New(core))()
+
+	const slow = 50 * time.Millisecond
+	registry := &metrics.Registry{}
+	l := &server.RequestLogger{SampleRate: 10, SlowThreshold: slow, Metrics: registry}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 4242}})
+	call := func(method string, req interface{}, handler grpc.UnaryHandler) {
+		_, _ = l.UnaryInterceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/api.Endorser/" + method}, handler)
+	}
+	found := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &api.Value{Data: []byte("secret")}, nil
+	}
+
+	// Fast reads are sampled
+	for i := 0; i < 25; i++ {
+		call("Get", &api.Key{Key: "a"}, found)
+	}
+	require.Equal(t, 3, logs.FilterMessage("Request").Len())
+	entry := logs.FilterMessage("Request").All()[0].ContextMap()
+	require.Equal(t, "Get", entry["method"])
+	require.Equal(t, "192.0.2.1:4242", entry["peer"])
+	require.Equal(t, "OK", entry["code"])
+
+	// Submissions are always logged, without their values
+	tx := &api.Transaction{Operations: []*consensus.Operation{
+		{Key: "a", Op: consensus.Operation_SET, Data: []byte("secret")},
+		{Key: "b", Op: consensus.Operation_SET, Data: []byte("secret")},
+	}}
+	logs.TakeAll()
+	call("Submit", tx, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &api.Receipt{Uuid: "query-uuid"}, nil
+	})
+	require.Equal(t, 1, logs.Len())
+	entry = logs.All()[0].ContextMap()
+	require.Equal(t, "query-uuid", entry["uuid"])
+	require.EqualValues(t, 2, entry["operations"])
+	require.NotZero(t, entry["size"])
+	for _, v := range entry {
+		require.NotContains(t, fmt.Sprint(v), "secret")
+	}
+
+	// Slow requests are always logged, as warnings
+	logs.TakeAll()
+	call("Get", &api.Key{Key: "a"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		time.Sleep(slow)
+		return nil, status.Error(codes.NotFound, "key not found")
+	})
+	require.Equal(t, 1, logs.FilterMessage("SlowRequest").Len())
+	entry = logs.All()[0].ContextMap()
+	require.Equal(t, zapcore.WarnLevel, logs.All()[0].Level)
+	require.Equal(t, "NotFound", entry["code"])
+
+	require.Exactly(t, []server.RequestCount{
+		{Method: "Get", Code: "NotFound", Count: 1},
+		{Method: "Get", Code: "OK", Count: 25},
+		{Method: "Submit", Code: "OK", Count: 1},
+	}, l.Counts())
+
+	// Counts are exported along with the metrics of the engine
+	var buf bytes.Buffer
+	_, err := registry.WriteTo(&buf)
+	require.Nil(t, err)
+	require.Contains(t, buf.String(), `pnyxdb_api_requests_total{method="Get",code="OK"} 25`)
+	require.Contains(t, buf.String(), `pnyxdb_api_requests_total{method="Submit",code="OK"} 1`)
+}
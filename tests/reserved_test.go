@@ -0,0 +1,48 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/technicolor-research/pnyxdb/consensus"
+)
+
+func TestReservedKeys(t *testing.T) {
+	node := startTestNode(t)
+	defer node.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, prefix := range []string{consensus.KeyRingPrefix, consensus.EndorsedPrefix, consensus.AppliedPrefix, consensus.NoncePrefix} {
+		_, err := node.client.Submit(ctx, transaction(t, &consensus.Operation{Key: prefix + "forged", Op: consensus.Operation_SET, Data: []byte("forged")}))
+		require.Exactly(t, codes.PermissionDenied, status.Code(err), prefix)
+	}
+
+	// Internal writers are not affected
+	node.commit(t, ctx, transaction(t, &consensus.Operation{Key: "a", Op: consensus.Operation_SET, Data: []byte("a")}))
+	list, err := node.engine.Store.List()
+	require.Nil(t, err)
+	var markers int
+	for key := range list {
+		require.NotContains(t, key, "forged")
+		if strings.HasPrefix(key, consensus.AppliedPrefix) {
+			markers++
+		}
+	}
+	require.NotZero(t, markers, "the applied marker should be written")
+}
@@ -0,0 +1,194 @@
+/**
+ * Copyright (c) 2019 - Present – Thomson Licensing, SAS
+ * All rights reserved.
+ *
+ * This source code is licensed under the Clear BSD license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/technicolor-research/pnyxdb/api"
+	"github.com/technicolor-research/pnyxdb/client"
+	"github.com/technicolor-research/pnyxdb/consensus"
+	"github.com/technicolor-research/pnyxdb/server"
+	"github.com/technicolor-research/pnyxdb/storage/boltdb"
+)
+
+// loopback is a network delivering the messages of a single node to itself.
+type loopback struct {
+	sync.Mutex
+	acceptors []consensus.MessageAcceptor
+	channels  []chan proto.Message
+}
+
+func (l *loopback) Close() error {
+	return nil
+}
+
+func (l *loopback) Broadcast(_ context.Context, m proto.Message) error {
+	l.Lock()
+	defer l.Unlock()
+
+	for i, accept := range l.acceptors {
+		if accept(m) {
+			go func(c chan proto.Message, m proto.Message) { c <- m }(l.channels[i], proto.Clone(m))
+		}
+	}
+	return nil
+}
+
+func (l *loopback) Accept(ctx context.Context, acceptor consensus.MessageAcceptor) <-chan proto.Message {
+	l.Lock()
+	defer l.Unlock()
+
+	c := make(chan proto.Message)
+	l.acceptors = append(l.acceptors, acceptor)
+	l.channels = append(l.channels, c)
+	return c
+}
+
+// agreeingBBC decides the local choice, as in a cluster of a single node.
+type agreeingBBC struct{}
+
+func (agreeingBBC) Execute(ctx context.Context, id string, choice bool, proofs []*consensus.Proof) (bool, []*consensus.Proof, error) {
+	return choice, proofs, nil
+}
+
+// testNode is a single node, serving the API on a random local port.
+type testNode struct {
+	engine *consensus.Engine
+	client *client.Client
+	close  func()
+}
+
+// startTestNode starts a node with a commit journal, and connects a client
+// to its API.
+func startTestNode(t *testing.T) *testNode {
+	return startConfiguredTestNode(t, func(*server.Server) {})
+}
+
+// startConfiguredTestNode is like startTestNode, configure being called
+// before the API is served.
+func startConfiguredTestNode(t *testing.T, configure func(*server.Server)) *testNode {
+	dir, err := ioutil.TempDir("", "pnyxdb")
+	require.Nil(t, err)
+
+	store, err := boltdb.New(filepath.Join(dir, "db"))
+	require.Nil(t, err)
+
+	engine := consensus.NewEngine(store, &loopback{}, agreeingBBC{}, GetTestKeyRings(t, 1)[0], 1)
+	engine.Journal, err = consensus.OpenJournal(filepath.Join(dir, "events"), 0, 0)
+	require.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.Nil(t, engine.Run(ctx))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	srv := grpc.NewServer()
+	s := &server.Server{Engine: engine}
+	configure(s)
+	api.RegisterEndorserServer(srv, s)
+	go func() { _ = srv.Serve(lis) }()
+
+	c := &client.Client{Addr: lis.Addr().String(), Timeout: 5 * time.Second}
+	require.Nil(t, c.Connect())
+
+	return &testNode{
+		engine: engine,
+		client: c,
+		close: func() {
+			c.Close()
+			srv.Stop()
+			cancel()
+			_ = engine.Journal.Close()
+			_ = store.Close()
+			_ = os.RemoveAll(dir)
+		},
+	}
+}
+
+func TestSubmitStream(t *testing.T) {
+	const count = 5000
+
+	node := startTestNode(t)
+	defer node.close()
+	engine, c := node.engine, node.client
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	session, err := c.SubmitStream(ctx)
+	require.Nil(t, err)
+
+	sent := make(chan error, 1)
+	go func() {
+		deadline, _ := ptypes.TimestampProto(time.Now().Add(time.Minute))
+		for i := 1; i <= count; i++ {
+			sequence, err := session.Send(&api.Transaction{
+				Policy:     "none",
+				Deadline:   deadline,
+				Operations: []*consensus.Operation{{Key: fmt.Sprintf("k%d", i), Op: consensus.Operation_SET, Data: []byte("v")}},
+			})
+			if err != nil {
+				sent <- err
+				return
+			}
+			if sequence != uint64(i) {
+				sent <- fmt.Errorf("unexpected sequence %d for transaction %d", sequence, i)
+				return
+			}
+		}
+		sent <- session.CloseSend()
+	}()
+
+	uuids := make(map[string]bool, count)
+	for sequence := uint64(1); ; sequence++ {
+		receipt, err := session.Recv()
+		if err == io.EOF {
+			require.Equal(t, uint64(count+1), sequence, "should receive a receipt per transaction")
+			break
+		}
+		require.Nil(t, err)
+		require.Equal(t, sequence, receipt.Sequence, "receipts should be in order")
+		require.Empty(t, receipt.Error)
+		require.NotEmpty(t, receipt.Uuid)
+		uuids[receipt.Uuid] = true
+	}
+	require.Nil(t, <-sent)
+	require.Len(t, uuids, count)
+
+	// Every receipt should correspond to a committed query
+	var since uint64
+	timeout, done := context.WithTimeout(ctx, 30*time.Second)
+	defer done()
+	for len(uuids) > 0 {
+		events, err := engine.Journal.Replay(since)
+		require.Nil(t, err)
+		for _, e := range events {
+			delete(uuids, e.Uuid)
+			since = e.Sequence
+		}
+		if len(uuids) > 0 {
+			require.Nil(t, engine.Journal.Wait(timeout, since), "%d queries are not committed", len(uuids))
+		}
+	}
+}
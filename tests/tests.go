@@ -22,7 +22,13 @@ import (
 )
 
 // GetTestKeyRings returns a number of keyrings that trust each other.
-func GetTestKeyRings(t *testing.T, n int) []*keyring.KeyRing {
+func GetTestKeyRings(t testing.TB, n int) []*keyring.KeyRing {
+	return GetTestKeyRingsCrypto(t, n, "ed25519")
+}
+
+// GetTestKeyRingsCrypto is like GetTestKeyRings, with keys of the provided
+// crypto engine.
+func GetTestKeyRingsCrypto(t testing.TB, n int, crypto string) []*keyring.KeyRing {
 	t.Log("Starting keyring generation...")
 	start := time.Now()
 
@@ -30,7 +36,7 @@ func GetTestKeyRings(t *testing.T, n int) []*keyring.KeyRing {
 	keyrings := make([]*keyring.KeyRing, n)
 	password, _ := memguard.NewImmutableRandom(16)
 	for i := 0; i < n; i++ {
-		keyrings[i], _ = keyring.NewKeyRing(strconv.Itoa(i), "ed25519")
+		keyrings[i], _ = keyring.NewKeyRing(strconv.Itoa(i), crypto)
 		_ = keyrings[i].CreatePrivate(password)
 	}
 